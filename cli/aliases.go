@@ -0,0 +1,359 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/internal/ospath"
+)
+
+const (
+	defaultAliasesFileName = "aliases.conf"
+	aliasesFileEnvName     = "KOPIA_ALIASES_FILE"
+)
+
+// aliasDefinition is a user-defined alias for a kopia command line.
+type aliasDefinition struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// aliasesFile returns the path of the file where command aliases are defined.
+func (c *App) aliasesFile() string {
+	if p := os.Getenv(c.EnvName(aliasesFileEnvName)); p != "" {
+		return p
+	}
+
+	return filepath.Join(ospath.ConfigDir(), defaultAliasesFileName)
+}
+
+// parseAliasLine parses a single line of aliases file in the form 'name = command line',
+// returns ok==false for empty lines and comments.
+func parseAliasLine(line string) (a aliasDefinition, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return aliasDefinition{}, false, nil
+	}
+
+	name, definition, found := strings.Cut(line, "=")
+	if !found {
+		return aliasDefinition{}, false, errors.Errorf("invalid alias definition %q, must be 'name = command'", line)
+	}
+
+	a = aliasDefinition{strings.TrimSpace(name), strings.TrimSpace(definition)}
+	if err := validateAliasName(a.Name); err != nil {
+		return aliasDefinition{}, false, err
+	}
+
+	if _, err := splitCommandLine(a.Definition); err != nil {
+		return aliasDefinition{}, false, errors.Wrapf(err, "invalid definition of alias %q", a.Name)
+	}
+
+	return a, true, nil
+}
+
+func validateAliasName(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t=#\"'") {
+		return errors.Errorf("invalid alias name %q", name)
+	}
+
+	return nil
+}
+
+// readAliases reads aliases defined in the provided file, missing file has no aliases.
+func readAliases(fname string) ([]aliasDefinition, error) {
+	f, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, errors.Wrap(err, "unable to read aliases")
+	}
+
+	var result []aliasDefinition
+
+	s := bufio.NewScanner(bytes.NewReader(f))
+	for lineNumber := 1; s.Scan(); lineNumber++ {
+		a, ok, err := parseAliasLine(s.Text())
+		if err != nil {
+			return nil, errors.Wrapf(err, "%v:%v", fname, lineNumber)
+		}
+
+		if ok {
+			result = append(result, a)
+		}
+	}
+
+	return result, errors.Wrap(s.Err(), "error reading aliases")
+}
+
+// updateAliasesFile sets or removes (when definition is empty) the alias in the provided file,
+// preserving all other lines including comments.
+func updateAliasesFile(fname, name, definition string) (found bool, err error) {
+	data, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil && !os.IsNotExist(err) {
+		return false, errors.Wrap(err, "unable to read aliases")
+	}
+
+	var lines []string
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := s.Text()
+
+		if a, ok, _ := parseAliasLine(line); ok && a.Name == name {
+			found = true
+
+			if definition == "" {
+				continue
+			}
+
+			line = name + " = " + definition
+			definition = ""
+		}
+
+		lines = append(lines, line)
+	}
+
+	if definition != "" {
+		lines = append(lines, name+" = "+definition)
+	} else if !found {
+		// nothing to delete.
+		return false, nil
+	}
+
+	var buf bytes.Buffer
+
+	for _, l := range lines {
+		buf.WriteString(l)
+		buf.WriteString("\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fname), 0o700); err != nil { //nolint:gomnd
+		return false, errors.Wrap(err, "unable to create aliases directory")
+	}
+
+	return found, errors.Wrap(atomicfile.Write(fname, &buf), "unable to write aliases")
+}
+
+// splitCommandLine splits the command line into arguments, honoring single and double quotes
+// and backslash escapes.
+func splitCommandLine(s string) ([]string, error) {
+	var (
+		result  []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+		escaped bool
+	)
+
+	for _, ch := range s {
+		switch {
+		case escaped:
+			current.WriteRune(ch)
+			escaped = false
+
+		case ch == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else {
+				current.WriteRune(ch)
+			}
+
+		case ch == '"' || ch == '\'':
+			quote = ch
+			inArg = true
+
+		case ch == ' ' || ch == '\t':
+			if inArg {
+				result = append(result, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(ch)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.Errorf("unterminated quote or escape in %q", s)
+	}
+
+	if inArg {
+		result = append(result, current.String())
+	}
+
+	return result, nil
+}
+
+// substituteAliasParameters replaces positional parameters ($1..$9 and $@ for all arguments) in the alias
+// definition with the provided arguments. When the definition does not reference any parameters,
+// the arguments are appended at the end.
+func substituteAliasParameters(name string, definition, args []string) ([]string, error) {
+	var (
+		result        []string
+		usesParameter bool
+	)
+
+	for _, d := range definition {
+		if d == "$@" {
+			usesParameter = true
+
+			result = append(result, args...)
+
+			continue
+		}
+
+		var (
+			sb      strings.Builder
+			badArgs error
+		)
+
+		for i := 0; i < len(d); i++ {
+			if d[i] != '$' || i+1 >= len(d) {
+				sb.WriteByte(d[i])
+				continue
+			}
+
+			next := d[i+1]
+
+			switch {
+			case next == '$':
+				sb.WriteByte('$')
+				i++
+
+			case next >= '1' && next <= '9':
+				n, _ := strconv.Atoi(string(next))
+				if n > len(args) {
+					badArgs = errors.Errorf("alias %q requires at least %v argument(s)", name, n)
+				} else {
+					sb.WriteString(args[n-1])
+				}
+
+				usesParameter = true
+				i++
+
+			default:
+				sb.WriteByte(d[i])
+			}
+		}
+
+		if badArgs != nil {
+			return nil, badArgs
+		}
+
+		result = append(result, sb.String())
+	}
+
+	if !usesParameter {
+		result = append(result, args...)
+	}
+
+	return result, nil
+}
+
+// findCommandPosition returns the index of the first argument that is not a global flag or its value,
+// or -1 if there is none.
+func findCommandPosition(kpapp *kingpin.Application, args []string) int {
+	flagTakesValue := map[string]bool{}
+
+	for _, f := range kpapp.Model().Flags {
+		flagTakesValue["--"+f.Name] = !f.IsBoolFlag()
+
+		if f.IsBoolFlag() {
+			flagTakesValue["--no-"+f.Name] = false
+		}
+
+		if f.Short != 0 {
+			flagTakesValue["-"+string(f.Short)] = !f.IsBoolFlag()
+		}
+	}
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+
+		if a == "--" {
+			return -1
+		}
+
+		if !strings.HasPrefix(a, "-") {
+			return i
+		}
+
+		if !strings.Contains(a, "=") && flagTakesValue[a] {
+			// skip flag value
+			i++
+		}
+	}
+
+	return -1
+}
+
+// ExpandAliases replaces the user-defined alias used as a command with its definition.
+// Built-in commands always take precedence over aliases and aliases are not expanded recursively.
+func (c *App) ExpandAliases(kpapp *kingpin.Application, args []string) ([]string, error) {
+	expanded, err := c.expandAliases(kpapp, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cliArgs = expanded
+
+	return expanded, nil
+}
+
+func (c *App) expandAliases(kpapp *kingpin.Application, args []string) ([]string, error) {
+	pos := findCommandPosition(kpapp, args)
+	if pos < 0 {
+		return args, nil
+	}
+
+	name := args[pos]
+
+	for _, cmd := range kpapp.Model().Commands {
+		if cmd.Name == name || slices.Contains(cmd.Aliases, name) {
+			return args, nil
+		}
+	}
+
+	aliases, err := readAliases(c.aliasesFile())
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range aliases {
+		if a.Name != name {
+			continue
+		}
+
+		definition, err := splitCommandLine(a.Definition)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid definition of alias %q", a.Name)
+		}
+
+		expanded, err := substituteAliasParameters(a.Name, definition, args[pos+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		return append(append([]string(nil), args[:pos]...), expanded...), nil
+	}
+
+	return args, nil
+}
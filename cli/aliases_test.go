@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	cases := map[string][]string{
+		"":                            nil,
+		"  snapshot   create  ":       {"snapshot", "create"},
+		`snapshot create "my dir" x`:  {"snapshot", "create", "my dir", "x"},
+		`a 'b "c" \d' e\ f`:           {"a", `b "c" \d`, "e f"},
+		`--description="some text"`:   {"--description=some text"},
+		`empty "" arg`:                {"empty", "", "arg"},
+		`escaped \"quote\" and \\ ok`: {"escaped", `"quote"`, "and", `\`, "ok"},
+	}
+
+	for input, want := range cases {
+		got, err := splitCommandLine(input)
+		require.NoError(t, err, input)
+		require.Equal(t, want, got, input)
+	}
+
+	for _, bad := range []string{`"unterminated`, `'x`, `trailing\`} {
+		_, err := splitCommandLine(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestSubstituteAliasParameters(t *testing.T) {
+	got, err := substituteAliasParameters("a", []string{"snapshot", "create"}, []string{"/x", "/y"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"snapshot", "create", "/x", "/y"}, got)
+
+	got, err = substituteAliasParameters("a", []string{"restore", "$2", "--snapshot-time=$1", "cost:$$1"}, []string{"latest", "/x"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"restore", "/x", "--snapshot-time=latest", "cost:$1"}, got)
+
+	got, err = substituteAliasParameters("a", []string{"snapshot", "create", "$@", "--tags=x"}, []string{"/x", "/y"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"snapshot", "create", "/x", "/y", "--tags=x"}, got)
+
+	_, err = substituteAliasParameters("a", []string{"show", "$3"}, []string{"x"})
+	require.ErrorContains(t, err, `alias "a" requires at least 3 argument(s)`)
+}
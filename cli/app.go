@@ -5,6 +5,7 @@
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"time"
 
@@ -16,6 +17,7 @@
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/faketime"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/internal/passwordpersist"
 	"github.com/kopia/kopia/internal/releasable"
@@ -30,6 +32,13 @@
 
 var tracer = otel.Tracer("cli")
 
+// ExitCodeInterrupted is the process exit code used when a command has been interrupted by a signal
+// after saving its state, so that it can be resumed by running it again.
+const ExitCodeInterrupted = 3
+
+// ErrInterrupted is returned by commands which were interrupted by a signal after saving their state.
+var ErrInterrupted = errors.New("interrupted")
+
 //nolint:gochecknoglobals
 var (
 	defaultColor = color.New()
@@ -62,6 +71,26 @@ func (o *textOutput) stderr() io.Writer {
 	return o.svc.Stderr()
 }
 
+func (o *textOutput) format() *outputFormatFlags {
+	if o.svc == nil {
+		return nil
+	}
+
+	return o.svc.outputFormatFlags()
+}
+
+func (o *textOutput) formatTimestamp(ts time.Time) string {
+	return o.format().formatTimestamp(ts)
+}
+
+func (o *textOutput) formatTimestampPrecise(ts time.Time) string {
+	return o.format().formatTimestampPrecise(ts)
+}
+
+func (o *textOutput) formatDuration(d time.Duration) string {
+	return o.format().formatDuration(d)
+}
+
 func (o *textOutput) printStdout(msg string, args ...interface{}) {
 	fmt.Fprintf(o.stdout(), msg, args...)
 }
@@ -82,6 +111,7 @@ type appServices interface {
 	repositoryWriterAction(act func(ctx context.Context, rep repo.RepositoryWriter) error) func(ctx *kingpin.ParseContext) error
 	maybeRepositoryAction(act func(ctx context.Context, rep repo.Repository) error, mode repositoryAccessMode) func(ctx *kingpin.ParseContext) error
 	baseActionWithContext(act func(ctx context.Context) error) func(ctx *kingpin.ParseContext) error
+	runLocally(cmd *kingpin.CmdClause, when func() bool)
 	openRepository(ctx context.Context, mustBeConnected bool) (repo.Repository, error)
 	advancedCommand(ctx context.Context)
 	repositoryConfigFileName() string
@@ -93,6 +123,10 @@ type appServices interface {
 	onRepositoryFatalError(callback func(err error))
 	enableTestOnlyFlags() bool
 	EnvName(s string) string
+	aliasesFile() string
+	IsForensic() bool
+	IsLowMemory() bool
+	outputFormatFlags() *outputFormatFlags
 }
 
 //nolint:interfacebloat
@@ -106,9 +140,14 @@ type advancedAppServices interface {
 	maybeInitializeUpdateCheck(ctx context.Context, co *connectOptions)
 	removeUpdateState()
 	passwordPersistenceStrategy() passwordpersist.Strategy
+	disablePasswordPersistence()
 	getPasswordFromFlags(ctx context.Context, isCreate, allowPersistent bool) (string, error)
 	optionsFromFlags(ctx context.Context) *repo.Options
 	runAppWithContext(command *kingpin.CmdClause, callback func(ctx context.Context) error) error
+	daemonSocketPath() string
+	dialDaemon() (net.Conn, error)
+	daemonRequest(command string, callback func(resp *daemonResponse) error) error
+	serveDaemon(ctx context.Context, rep repo.Repository, l net.Listener) error
 }
 
 // App contains per-invocation flags and state of Kopia CLI.
@@ -121,38 +160,63 @@ type App struct {
 	updateCheckInterval           time.Duration
 	updateAvailableNotifyInterval time.Duration
 	password                      string
+	dataPassword                  string
+	shamirShares                  []string
 	configPath                    string
 	traceStorage                  bool
 	keyRingEnabled                bool
 	persistCredentials            bool
 	disableInternalLog            bool
 	dumpAllocatorStats            bool
+	forensic                      bool
+	lowMemory                     bool
+	deterministicTime             string
+	deterministicTimeStep         time.Duration
+	timeNow                       func() time.Time
 	AdvancedCommands              string
 	cliStorageProviders           []StorageProvider
 	trackReleasable               []string
 
 	observability       observabilityFlags
+	outputFormat        outputFormatFlags
 	upgradeOwnerID      string
 	doNotWaitForUpgrade bool
+	useDaemon           bool
+	daemonSocket        string
+
+	// mustRunLocally is set for commands which can't be executed by the daemon.
+	mustRunLocally bool
+
+	// sharedRepository is the repository held by the daemon when executing commands on behalf of its clients.
+	sharedRepository repo.Repository
+
+	// cliArgs are the command-line arguments after alias expansion, which are forwarded to the daemon.
+	cliArgs []string
 
 	currentAction         string
 	onExitCallbacks       []func()
 	onFatalErrorCallbacks []func(err error)
 
 	// subcommands
+	alias       commandAlias
+	analyze     commandAnalyze
 	blob        commandBlob
 	benchmark   commandBenchmark
 	cache       commandCache
+	daemon      commandDaemon
 	content     commandContent
 	diff        commandDiff
+	export      commandExport
 	index       commandIndex
 	list        commandList
 	server      commandServer
 	session     commandSession
 	policy      commandPolicy
 	restore     commandRestore
+	restic      commandRestic
 	show        commandShow
 	snapshot    commandSnapshot
+	stats       commandStats
 	manifest    commandManifest
 	mount       commandMount
 	maintenance commandMaintenance
@@ -163,7 +227,7 @@ type App struct {
 	testonlyIgnoreMissingRequiredFeatures bool
 
 	isInProcessTest bool
-	exitWithError   func(err error) // os.Exit() with 1 or 0 based on err
+	exitWithError   func(err error) // os.Exit() with ExitCodeInterrupted, 1 or 0 based on err
 	stdinReader     io.Reader
 	stdoutWriter    io.Writer
 	stderrWriter    io.Writer
@@ -211,8 +275,39 @@ func (c *App) runOnExit() {
 	}
 }
 
+// setupDeterministicTime replaces the repository clock with a simulated one when requested.
+func (c *App) setupDeterministicTime() error {
+	if c.deterministicTime == "" {
+		return nil
+	}
+
+	t, err := time.Parse(time.RFC3339, c.deterministicTime)
+	if err != nil {
+		return errors.Wrap(err, "invalid deterministic time")
+	}
+
+	c.timeNow = faketime.AutoAdvance(t, c.deterministicTimeStep)
+
+	return nil
+}
+
+func (c *App) outputFormatFlags() *outputFormatFlags {
+	return &c.outputFormat
+}
+
+// IsForensic returns true when running in read-only forensic mode, which must not perform any writes.
+func (c *App) IsForensic() bool {
+	return c.forensic
+}
+
+// disablePasswordPersistence turns off password persistence for the rest of the invocation.
+func (c *App) disablePasswordPersistence() {
+	c.persistCredentials = false
+}
+
 func (c *App) passwordPersistenceStrategy() passwordpersist.Strategy {
-	if !c.persistCredentials {
+	// passwords reconstructed from Shamir shares are never persisted, since that would defeat the split.
+	if !c.persistCredentials || c.forensic || len(c.shamirShares) > 0 {
 		return passwordpersist.None()
 	}
 
@@ -234,7 +329,15 @@ func (c *App) setup(app *kingpin.Application) {
 			c.currentAction = "unknown-action"
 		}
 
-		return nil
+		c.outputFormat.apply()
+
+		if err := c.setupDeterministicTime(); err != nil {
+			return err
+		}
+
+		c.applyLowMemoryMode()
+
+		return c.checkForensicMode()
 	})
 
 	_ = app.Flag("help-full", "Show help for all commands, including hidden").Action(func(pc *kingpin.ParseContext) error {
@@ -251,14 +354,21 @@ func (c *App) setup(app *kingpin.Application) {
 	app.Flag("update-available-notify-interval", "Interval between update notifications").Default("1h").Hidden().Envar(c.EnvName("KOPIA_UPDATE_NOTIFY_INTERVAL")).DurationVar(&c.updateAvailableNotifyInterval)
 	app.Flag("config-file", "Specify the config file to use").Default("repository.config").Envar(c.EnvName("KOPIA_CONFIG_PATH")).StringVar(&c.configPath)
 	app.Flag("trace-storage", "Enables tracing of storage operations.").Default("true").Hidden().BoolVar(&c.traceStorage)
-	app.Flag("timezone", "Format time according to specified time zone (local, utc, original or time zone name)").Hidden().StringVar(&timeZone)
 	app.Flag("password", "Repository password.").Envar(c.EnvName("KOPIA_PASSWORD")).Short('p').StringVar(&c.password)
+	app.Flag("data-password", "Password protecting the data key of repositories which encrypt data and metadata separately.").Envar(c.EnvName("KOPIA_DATA_PASSWORD")).StringVar(&c.dataPassword)
+	app.Flag("shamir-share", "Share of the repository password created with --shamir, specify multiple times to reach the threshold.").Envar(c.EnvName("KOPIA_SHAMIR_SHARES")).StringsVar(&c.shamirShares)
 	app.Flag("persist-credentials", "Persist credentials").Default("true").Envar(c.EnvName("KOPIA_PERSIST_CREDENTIALS_ON_CONNECT")).BoolVar(&c.persistCredentials)
 	app.Flag("disable-internal-log", "Disable internal log").Hidden().Envar(c.EnvName("KOPIA_DISABLE_INTERNAL_LOG")).BoolVar(&c.disableInternalLog)
 	app.Flag("advanced-commands", "Enable advanced (and potentially dangerous) commands.").Hidden().Envar(c.EnvName("KOPIA_ADVANCED_COMMANDS")).StringVar(&c.AdvancedCommands)
 	app.Flag("track-releasable", "Enable tracking of releasable resources.").Hidden().Envar(c.EnvName("KOPIA_TRACK_RELEASABLE")).StringsVar(&c.trackReleasable)
 	app.Flag("dump-allocator-stats", "Dump allocator stats at the end of execution.").Hidden().Envar(c.EnvName("KOPIA_DUMP_ALLOCATOR_STATS")).BoolVar(&c.dumpAllocatorStats)
 	app.Flag("upgrade-owner-id", "Repository format upgrade owner-id.").Hidden().Envar(c.EnvName("KOPIA_REPO_UPGRADE_OWNER_ID")).StringVar(&c.upgradeOwnerID)
+	app.Flag("deterministic-time", "Use simulated repository time starting at the provided timestamp and advancing by a fixed step each time it is read, which makes results reproducible.").Hidden().PlaceHolder(time.RFC3339).Envar(c.EnvName("KOPIA_DETERMINISTIC_TIME")).StringVar(&c.deterministicTime)
+	app.Flag("deterministic-time-step", "Amount of simulated time elapsed each time the time is read in deterministic mode.").Hidden().Default("1s").Envar(c.EnvName("KOPIA_DETERMINISTIC_TIME_STEP")).DurationVar(&c.deterministicTimeStep)
+	app.Flag("forensic", "Read-only forensic mode, which never writes to the repository, caches, configuration or logs and avoids updating access times of files being read.").Envar(c.EnvName("KOPIA_FORENSIC")).BoolVar(&c.forensic)
+	app.Flag("low-memory", "Reduce memory usage for devices with little RAM, at the expense of performance. Also uses smaller buffer pools and default cache sizes.").Envar(c.EnvName("KOPIA_LOW_MEMORY")).BoolVar(&c.lowMemory)
+	app.Flag("use-daemon", "Execute repository commands using the daemon holding the repository open when it's running.").Envar(c.EnvName("KOPIA_USE_DAEMON")).BoolVar(&c.useDaemon)
+	app.Flag("daemon-socket", "Path to the socket of the daemon, by default next to the config file.").Hidden().Envar(c.EnvName("KOPIA_DAEMON_SOCKET")).StringVar(&c.daemonSocket)
 	app.Flag("upgrade-no-block", "Do not block when repository format upgrade is in progress, instead exit with a message.").Hidden().Default("false").Envar(c.EnvName("KOPIA_REPO_UPGRADE_NO_BLOCK")).BoolVar(&c.doNotWaitForUpgrade)
 
 	if c.enableTestOnlyFlags() {
@@ -266,6 +376,7 @@ func (c *App) setup(app *kingpin.Application) {
 	}
 
 	c.observability.setup(c, app)
+	c.outputFormat.setup(c, app)
 
 	c.setupOSSpecificKeychainFlags(c, app)
 
@@ -280,19 +391,25 @@ func (c *App) setup(app *kingpin.Application) {
 	c.pf.setup(app)
 	c.progress.setup(c, app)
 
+	c.alias.setup(c, app)
+	c.analyze.setup(c, app)
 	c.blob.setup(c, app)
 	c.benchmark.setup(c, app)
 	c.cache.setup(c, app)
 	c.content.setup(c, app)
+	c.daemon.setup(c, app)
 	c.diff.setup(c, app)
+	c.export.setup(c, app)
 	c.index.setup(c, app)
 	c.list.setup(c, app)
 	c.logs.setup(c, app)
 	c.server.setup(c, app)
 	c.session.setup(c, app)
 	c.restore.setup(c, app)
+	c.restic.setup(c, app)
 	c.show.setup(c, app)
 	c.snapshot.setup(c, app)
+	c.stats.setup(c, app)
 	c.manifest.setup(c, app)
 	c.policy.setup(c, app)
 	c.mount.setup(c, app)
@@ -326,6 +443,10 @@ func NewApp() *App {
 
 		// testability hooks
 		exitWithError: func(err error) {
+			if errors.Is(err, ErrInterrupted) {
+				os.Exit(ExitCodeInterrupted)
+			}
+
 			if err != nil {
 				os.Exit(1)
 			}
@@ -454,6 +575,17 @@ func (c *App) directRepositoryReadAction(act func(ctx context.Context, rep repo.
 
 func (c *App) repositoryReaderAction(act func(ctx context.Context, rep repo.Repository) error) func(ctx *kingpin.ParseContext) error {
 	return c.maybeRepositoryAction(func(ctx context.Context, rep repo.Repository) error {
+		sr, err := openShardedRepository(ctx, c, rep)
+		if err != nil {
+			return err
+		}
+
+		if sr != nil {
+			defer sr.Close(ctx) //nolint:errcheck
+
+			return act(ctx, sr)
+		}
+
 		return act(ctx, rep)
 	}, repositoryAccessMode{
 		mustBeConnected:    true,
@@ -537,6 +669,15 @@ func (c *App) baseActionWithContext(act func(ctx context.Context) error) func(ct
 
 func (c *App) maybeRepositoryAction(act func(ctx context.Context, rep repo.Repository) error, mode repositoryAccessMode) func(ctx *kingpin.ParseContext) error {
 	return c.baseActionWithContext(func(ctx context.Context) error {
+		if handled, err := c.maybeRunUsingDaemon(ctx); handled {
+			if err != nil {
+				// the error has already been printed by the daemon.
+				c.exitWithError(err)
+			}
+
+			return nil
+		}
+
 		rep, err := c.openRepository(ctx, mode.mustBeConnected)
 		if err != nil && mode.mustBeConnected {
 			return errors.Wrap(err, "open repository")
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs/localfs"
+)
+
+// forensicCompatibleCommands lists commands (and their subcommands) which only read from the repository
+// and don't write to the local machine except for explicitly requested output, all other commands are refused
+// in forensic mode.
+//
+//nolint:gochecknoglobals
+var forensicCompatibleCommands = []string{
+	"help",
+	"alias list",
+	"analyze",
+	"blob list",
+	"blob show",
+	"blob stats",
+	"cache info",
+	"content list",
+	"content show",
+	"content stats",
+	"content verify",
+	"daemon status",
+	"index epoch list",
+	"index inspect",
+	"index list",
+	"list",
+	"logs list",
+	"logs show",
+	"maintenance info",
+	"manifest list",
+	"manifest show",
+	"policy list",
+	"policy show",
+	"policy simulate-retention",
+	"repository shard list",
+	"repository shard locate",
+	"repository status",
+	"repository throttle get",
+	"session list",
+	"show",
+	"snapshot describe",
+	"snapshot errors",
+	"snapshot estimate",
+	"snapshot explain-retention",
+	"snapshot list",
+	"snapshot reference list",
+	"snapshot verification-reports",
+	"snapshot verify",
+	"stats",
+}
+
+// checkForensicMode verifies that the current command can run in forensic mode and makes
+// filesystem reads preserve access times.
+func (c *App) checkForensicMode() error {
+	if !c.forensic {
+		return nil
+	}
+
+	if !isForensicCompatible(c.currentAction) {
+		return errors.Errorf("'%v' is not allowed in forensic mode", c.currentAction)
+	}
+
+	localfs.PreserveAccessTimes(true)
+
+	return nil
+}
+
+func isForensicCompatible(action string) bool {
+	for _, cmd := range forensicCompatibleCommands {
+		if action == cmd || strings.HasPrefix(action, cmd+" ") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,67 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestForensicMode(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	cacheDir := filepath.Join(testutil.TempDirectory(t), "cache")
+	srcDir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("aaa"), 0o600))
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--cache-directory", cacheDir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+	require.NoError(t, os.RemoveAll(cacheDir))
+
+	configBefore := listFilesWithModTimes(t, e.ConfigDir)
+	repoBefore := listFilesWithModTimes(t, e.RepoDir)
+
+	e.RunAndExpectSuccess(t, "--forensic", "snapshot", "list")
+	e.RunAndExpectSuccess(t, "--forensic", "snapshot", "verify", "--verify-files-percent=100")
+	e.RunAndExpectSuccess(t, "--forensic", "content", "verify")
+	e.RunAndExpectSuccess(t, "--forensic", "maintenance", "info")
+
+	e.RunAndExpectFailure(t, "--forensic", "snapshot", "create", srcDir)
+	e.RunAndExpectFailure(t, "--forensic", "repo", "disconnect")
+	e.RunAndExpectFailure(t, "--forensic", "cache", "clear")
+	e.RunAndExpectFailure(t, "--forensic", "restore", "k1234", testutil.TempDirectory(t))
+	e.RunAndExpectFailure(t, "--forensic", "export", "k1234", filepath.Join(testutil.TempDirectory(t), "out.sqfs"))
+	e.RunAndExpectFailure(t, "--forensic", "cache", "import", testutil.TempDirectory(t))
+	e.RunAndExpectFailure(t, "--forensic", "alias", "set", "foo", "snapshot list")
+	e.RunAndExpectFailure(t, "--forensic", "repository", "repair", "filesystem", "--path", e.RepoDir)
+	e.RunAndExpectFailure(t, "--forensic", "snapshot", "verify", "--save-report")
+
+	require.NoDirExists(t, cacheDir)
+	require.Equal(t, configBefore, listFilesWithModTimes(t, e.ConfigDir))
+	require.Equal(t, repoBefore, listFilesWithModTimes(t, e.RepoDir))
+}
+
+func listFilesWithModTimes(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var result []string
+
+	require.NoError(t, filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		result = append(result, path+" "+fi.ModTime().String())
+
+		return nil
+	}))
+
+	sort.Strings(result)
+
+	return result
+}
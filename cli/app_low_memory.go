@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"runtime/debug"
+
+	"github.com/kopia/kopia/internal/gather"
+)
+
+const (
+	// lowMemoryLimitBytes is the soft memory limit applied in low-memory mode, which makes the garbage
+	// collector run more aggressively as the heap approaches it.
+	lowMemoryLimitBytes = 192 << 20
+
+	// lowMemoryMaxFreeChunks and lowMemoryMaxFreeContiguousChunks limit the number of released buffers
+	// kept around for reuse, which by default is up to 128 MB of small chunks and two large ones per CPU.
+	lowMemoryMaxFreeChunks           = 64
+	lowMemoryMaxFreeContiguousChunks = 1
+
+	// lowMemoryCacheSizeMB is the default size of content and metadata caches of repositories
+	// connected in low-memory mode.
+	lowMemoryCacheSizeMB = 500
+)
+
+// applyLowMemoryMode configures the runtime for devices with little memory, unless the memory limit
+// was already provided using GOMEMLIMIT.
+func (c *App) applyLowMemoryMode() {
+	if !c.lowMemory {
+		return
+	}
+
+	if os.Getenv("GOMEMLIMIT") == "" {
+		debug.SetMemoryLimit(lowMemoryLimitBytes)
+	}
+
+	gather.SetMaxFreeChunks(lowMemoryMaxFreeChunks, lowMemoryMaxFreeContiguousChunks)
+}
+
+// IsLowMemory returns true when running in low-memory mode, which trades performance for lower memory usage.
+func (c *App) IsLowMemory() bool {
+	return c.lowMemory
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectCacheSizesLowMemory(t *testing.T) {
+	lowMemory := false
+
+	co := &connectOptions{isLowMemory: func() bool { return lowMemory }}
+	co.contentCacheSizeMB = 5000
+	co.metadataCacheSizeMB = 5000
+
+	contentMB, metadataMB := co.cacheSizesMB()
+	require.EqualValues(t, 5000, contentMB)
+	require.EqualValues(t, 5000, metadataMB)
+
+	lowMemory = true
+
+	contentMB, metadataMB = co.cacheSizesMB()
+	require.EqualValues(t, lowMemoryCacheSizeMB, contentMB)
+	require.EqualValues(t, lowMemoryCacheSizeMB, metadataMB)
+
+	// explicitly provided sizes are preserved.
+	co.metadataCacheSizeMB = 1234
+	co.metadataCacheSizeMBSet = true
+
+	contentMB, metadataMB = co.cacheSizesMB()
+	require.EqualValues(t, lowMemoryCacheSizeMB, contentMB)
+	require.EqualValues(t, 1234, metadataMB)
+}
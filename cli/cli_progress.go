@@ -2,6 +2,7 @@
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -10,6 +11,7 @@
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/fatih/color"
 
+	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/timetrack"
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
@@ -17,20 +19,36 @@
 
 const (
 	spinner = `|/-\`
+
+	// maximum number of files in progress to display.
+	maxDisplayedFilesInProgress = 20
 )
 
 type progressFlags struct {
 	enableProgress         bool
 	progressUpdateInterval time.Duration
+	showFilesInProgress    bool
+	stalledFileThreshold   time.Duration
 	out                    textOutput
 }
 
 func (p *progressFlags) setup(svc appServices, app *kingpin.Application) {
-	app.Flag("progress", "Enable progress bar").Hidden().Default("true").BoolVar(&p.enableProgress)
+	app.Flag("progress", "Enable progress bar, use --no-progress when writing output to logs").Default("true").BoolVar(&p.enableProgress)
 	app.Flag("progress-update-interval", "How often to update progress information").Hidden().Default("300ms").DurationVar(&p.progressUpdateInterval)
+	app.Flag("progress-files", "Show files currently being processed with their throughput and report stalled files").BoolVar(&p.showFilesInProgress)
+	app.Flag("progress-stalled-threshold", "Report files that made no progress for this long as stalled").Default("1m").DurationVar(&p.stalledFileThreshold)
 	p.out.setup(svc)
 }
 
+// fileInProgress describes a file currently being hashed.
+type fileInProgress struct {
+	path          string
+	startTime     time.Time
+	lastProgress  time.Time
+	hashedBytes   int64
+	stallReported bool
+}
+
 type cliProgress struct {
 	snapshotfs.NullUploadProgress
 
@@ -54,6 +72,15 @@ type cliProgress struct {
 	lastLineLength int
 	// +checklocks:outputMutex
 	spinPhase int
+	// +checklocks:outputMutex
+	lastFileLines int
+
+	filesMutex sync.Mutex
+	// +checklocks:filesMutex
+	filesInProgress map[string]*fileInProgress
+
+	stopRefreshing chan struct{} // +checklocksignore
+	refreshingDone chan struct{} // +checklocksignore
 
 	uploadStartTime timetrack.Estimator // +checklocksignore
 
@@ -63,23 +90,161 @@ type cliProgress struct {
 	// indicates shared instance that does not reset counters at the beginning of upload.
 	shared bool
 
+	// when set, receives the number of bytes uploaded to the repository.
+	uploadedBytesObserver func(numBytes int64)
+
 	progressFlags
 }
 
-func (p *cliProgress) HashingFile(_ string) {
+func (p *cliProgress) HashingFile(fname string) {
 	p.inProgressHashing.Add(1)
+
+	if !p.showFilesInProgress {
+		return
+	}
+
+	now := clock.Now()
+
+	p.filesMutex.Lock()
+	defer p.filesMutex.Unlock()
+
+	if p.filesInProgress == nil {
+		p.filesInProgress = map[string]*fileInProgress{}
+	}
+
+	p.filesInProgress[fname] = &fileInProgress{
+		path:         fname,
+		startTime:    now,
+		lastProgress: now,
+	}
 }
 
-func (p *cliProgress) FinishedHashingFile(_ string, _ int64) {
+func (p *cliProgress) FinishedHashingFile(fname string, _ int64) {
 	p.hashedFiles.Add(1)
 	p.inProgressHashing.Add(-1)
+
+	if p.showFilesInProgress {
+		p.filesMutex.Lock()
+		delete(p.filesInProgress, fname)
+		p.filesMutex.Unlock()
+	}
+
 	p.maybeOutput()
 }
 
+func (p *cliProgress) HashedFileBytes(fname string, numBytes int64) {
+	if !p.showFilesInProgress {
+		return
+	}
+
+	p.filesMutex.Lock()
+	defer p.filesMutex.Unlock()
+
+	if f := p.filesInProgress[fname]; f != nil {
+		f.hashedBytes += numBytes
+		f.lastProgress = clock.Now()
+	}
+}
+
+// fileProgressLines returns the description of files currently being hashed, oldest first,
+// and reports files which have become stalled since the last call.
+func (p *cliProgress) fileProgressLines(now time.Time) (lines, newlyStalled []string) {
+	p.filesMutex.Lock()
+	defer p.filesMutex.Unlock()
+
+	var files []*fileInProgress
+
+	for _, f := range p.filesInProgress {
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].startTime.Before(files[j].startTime)
+	})
+
+	for i, f := range files {
+		stalledFor := now.Sub(f.lastProgress)
+		stalled := p.stalledFileThreshold > 0 && stalledFor >= p.stalledFileThreshold
+
+		if stalled && !f.stallReported {
+			f.stallReported = true
+			newlyStalled = append(newlyStalled, fmt.Sprintf("File \"%v\" made no progress for %v and may be stalled\n", f.path, p.out.formatDuration(stalledFor)))
+		}
+
+		if i >= maxDisplayedFilesInProgress {
+			continue
+		}
+
+		line := fmt.Sprintf("   %v %v", f.path, units.BytesString(f.hashedBytes))
+
+		if elapsed := now.Sub(f.startTime).Seconds(); elapsed > 0 {
+			line += fmt.Sprintf(" (%v)", units.BytesPerSecondsString(float64(f.hashedBytes)/elapsed))
+		}
+
+		if stalled {
+			line += fmt.Sprintf(" STALLED for %v", p.out.formatDuration(stalledFor))
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(files) > maxDisplayedFilesInProgress {
+		lines = append(lines, fmt.Sprintf("   ... and %v more", len(files)-maxDisplayedFilesInProgress))
+	}
+
+	return lines, newlyStalled
+}
+
+// refreshFilesInProgress periodically refreshes the list of files in progress and reports stalled files,
+// which is necessary because stalled files don't produce any progress events.
+func (p *cliProgress) refreshFilesInProgress(stop, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(p.progressUpdateInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+
+		case <-t.C:
+			if p.uploading.Load() {
+				p.output(defaultColor, "")
+			}
+		}
+	}
+}
+
+func (p *cliProgress) startRefreshingFilesInProgress() {
+	if !p.showFilesInProgress || p.progressUpdateInterval <= 0 {
+		return
+	}
+
+	p.stopRefreshing = make(chan struct{})
+	p.refreshingDone = make(chan struct{})
+
+	go p.refreshFilesInProgress(p.stopRefreshing, p.refreshingDone)
+}
+
+func (p *cliProgress) stopRefreshingFilesInProgress() {
+	if p.stopRefreshing != nil {
+		close(p.stopRefreshing)
+		<-p.refreshingDone
+
+		p.stopRefreshing = nil
+		p.refreshingDone = nil
+	}
+}
+
 func (p *cliProgress) UploadedBytes(numBytes int64) {
 	p.uploadedBytes.Add(numBytes)
 	p.uploadedFiles.Add(1)
 
+	if p.uploadedBytesObserver != nil {
+		p.uploadedBytesObserver(numBytes)
+	}
+
 	p.maybeOutput()
 }
 
@@ -150,13 +315,30 @@ func (p *cliProgress) output(col *color.Color, msg string) {
 		line += fmt.Sprintf(" (%v errors ignored)", ignoredErrorCount)
 	}
 
-	if msg != "" {
-		prefix := "\n ! "
-		if !p.enableProgress {
-			prefix = ""
+	var fileLines, stalledFiles []string
+
+	if p.showFilesInProgress {
+		fileLines, stalledFiles = p.fileProgressLines(clock.Now())
+	}
+
+	if p.enableProgress && p.showFilesInProgress {
+		// move the cursor back to the beginning of the status line and clear everything below it.
+		if p.lastFileLines > 0 {
+			p.out.printStderr("\r\x1b[%dA\x1b[J", p.lastFileLines)
+		} else {
+			p.out.printStderr("\r\x1b[J")
 		}
 
-		col.Fprintf(p.out.stderr(), "%v%v", prefix, msg) //nolint:errcheck
+		p.lastLineLength = 0
+		p.lastFileLines = 0
+	}
+
+	if msg != "" {
+		p.printMessage(col, msg)
+	}
+
+	for _, m := range stalledFiles {
+		p.printMessage(warningColor, m)
 	}
 
 	if !p.enableProgress {
@@ -180,6 +362,25 @@ func (p *cliProgress) output(col *color.Color, msg string) {
 
 	p.lastLineLength = len(line)
 	p.out.printStderr("\r%v%v", line, extraSpaces)
+
+	for _, fl := range fileLines {
+		p.out.printStderr("\n%v", fl)
+	}
+
+	p.lastFileLines = len(fileLines)
+}
+
+// +checklocks:p.outputMutex
+func (p *cliProgress) printMessage(col *color.Color, msg string) {
+	switch {
+	case !p.enableProgress:
+		col.Fprintf(p.out.stderr(), "%v", msg) //nolint:errcheck
+	case p.showFilesInProgress:
+		// the status block has been cleared, print the message in its place.
+		col.Fprintf(p.out.stderr(), " ! %v", msg) //nolint:errcheck
+	default:
+		col.Fprintf(p.out.stderr(), "\n ! %v", msg) //nolint:errcheck
+	}
 }
 
 // +checklocks:p.outputMutex
@@ -197,16 +398,21 @@ func (p *cliProgress) spinnerCharacter() string {
 
 // +checklocksignore.
 func (p *cliProgress) StartShared() {
+	p.stopRefreshingFilesInProgress()
+
 	*p = cliProgress{
-		uploadStartTime: timetrack.Start(),
-		shared:          true,
-		progressFlags:   p.progressFlags,
+		uploadStartTime:       timetrack.Start(),
+		shared:                true,
+		progressFlags:         p.progressFlags,
+		uploadedBytesObserver: p.uploadedBytesObserver,
 	}
 
 	p.uploading.Store(true)
+	p.startRefreshingFilesInProgress()
 }
 
 func (p *cliProgress) FinishShared() {
+	p.stopRefreshingFilesInProgress()
 	p.uploadFinished.Store(true)
 	p.output(defaultColor, "")
 }
@@ -218,12 +424,16 @@ func (p *cliProgress) UploadStarted() {
 		return
 	}
 
+	p.stopRefreshingFilesInProgress()
+
 	*p = cliProgress{
-		uploadStartTime: timetrack.Start(),
-		progressFlags:   p.progressFlags,
+		uploadStartTime:       timetrack.Start(),
+		progressFlags:         p.progressFlags,
+		uploadedBytesObserver: p.uploadedBytesObserver,
 	}
 
 	p.uploading.Store(true)
+	p.startRefreshingFilesInProgress()
 }
 
 func (p *cliProgress) EstimatedDataSize(fileCount int, totalBytes int64) {
@@ -249,6 +459,7 @@ func (p *cliProgress) Finish() {
 		return
 	}
 
+	p.stopRefreshingFilesInProgress()
 	p.uploadFinished.Store(true)
 	p.uploading.Store(false)
 
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+)
+
+func TestProgressFilesInProgress(t *testing.T) {
+	p := &cliProgress{
+		progressFlags: progressFlags{
+			showFilesInProgress:  true,
+			stalledFileThreshold: time.Minute,
+		},
+	}
+
+	p.HashingFile("dir/file1")
+	p.HashedFileBytes("dir/file1", 1000)
+	p.HashingFile("dir/file2")
+
+	now := clock.Now()
+
+	lines, stalled := p.fileProgressLines(now)
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "dir/file1 1 KB")
+	require.Contains(t, lines[1], "dir/file2 0 B")
+	require.Empty(t, stalled)
+
+	// file1 keeps making progress, file2 does not.
+	p.HashedFileBytes("dir/file1", 1000)
+	p.filesInProgress["dir/file1"].lastProgress = now.Add(2 * time.Minute)
+
+	lines, stalled = p.fileProgressLines(now.Add(2 * time.Minute))
+	require.NotContains(t, lines[0], "STALLED")
+	require.Contains(t, lines[1], "STALLED for 2m0s")
+	require.Len(t, stalled, 1)
+	require.Contains(t, stalled[0], `File "dir/file2" made no progress for 2m0s`)
+
+	// stalled files are reported only once.
+	_, stalled = p.fileProgressLines(now.Add(150 * time.Second))
+	require.Empty(t, stalled)
+
+	p.FinishedHashingFile("dir/file2", 0)
+
+	lines, _ = p.fileProgressLines(now)
+	require.Len(t, lines, 1)
+
+	for i := 0; i < 2*maxDisplayedFilesInProgress; i++ {
+		p.HashingFile(fmt.Sprintf("many/file%v", i))
+	}
+
+	lines, _ = p.fileProgressLines(now)
+	require.Len(t, lines, maxDisplayedFilesInProgress+1)
+	require.True(t, strings.HasSuffix(lines[maxDisplayedFilesInProgress], fmt.Sprintf("and %v more", maxDisplayedFilesInProgress+1)))
+}
+
+func TestProgressFilesInProgressDisabled(t *testing.T) {
+	p := &cliProgress{}
+
+	p.HashingFile("file1")
+	p.HashedFileBytes("file1", 1000)
+
+	lines, stalled := p.fileProgressLines(clock.Now())
+	require.Empty(t, lines)
+	require.Empty(t, stalled)
+}
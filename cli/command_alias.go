@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+)
+
+type commandAlias struct {
+	list   commandAliasList
+	set    commandAliasSet
+	delete commandAliasDelete
+}
+
+func (c *commandAlias) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("alias", "Manage user-defined command aliases, which can be used in place of kopia commands.")
+
+	c.list.setup(svc, cmd)
+	c.set.setup(svc, cmd, parent)
+	c.delete.setup(svc, cmd)
+}
+
+type commandAliasList struct {
+	aliasesFile func() string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandAliasList) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("list", "List command aliases.").Alias("ls")
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	c.aliasesFile = svc.aliasesFile
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandAliasList) run(ctx context.Context) error {
+	aliases, err := readAliases(c.aliasesFile())
+	if err != nil {
+		return err
+	}
+
+	if c.jo.jsonOutput {
+		var jl jsonList
+
+		jl.begin(&c.jo)
+		defer jl.end()
+
+		for _, a := range aliases {
+			jl.emit(a)
+		}
+
+		return nil
+	}
+
+	if len(aliases) == 0 {
+		log(ctx).Infof("No aliases defined in %v", c.aliasesFile())
+		return nil
+	}
+
+	for _, a := range aliases {
+		c.out.printStdout("%v = %v\n", a.Name, a.Definition)
+	}
+
+	return nil
+}
+
+// commandLookup is implemented by kingpin.Application and allows checking for built-in commands.
+type commandLookup interface {
+	GetCommand(name string) *kingpin.CmdClause
+}
+
+type commandAliasSet struct {
+	name       string
+	definition string
+
+	aliasesFile func() string
+	commands    commandParent
+}
+
+func (c *commandAliasSet) setup(svc appServices, parent, commands commandParent) {
+	cmd := parent.Command("set", "Define a command alias. Definition may use positional parameters $1..$9 and $@ (all arguments), otherwise arguments are appended to it.")
+	cmd.Arg("name", "Alias name").Required().StringVar(&c.name)
+	cmd.Arg("definition", "Command line (without 'kopia') the alias expands to, e.g. \"snapshot create ~/Documents --tags=type:quick\"").Required().StringVar(&c.definition)
+	c.aliasesFile = svc.aliasesFile
+	c.commands = commands
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandAliasSet) run(ctx context.Context) error {
+	if err := validateAliasName(c.name); err != nil {
+		return err
+	}
+
+	if cl, ok := c.commands.(commandLookup); ok && cl.GetCommand(c.name) != nil {
+		return errors.Errorf("alias %q conflicts with a built-in command", c.name)
+	}
+
+	args, err := splitCommandLine(c.definition)
+	if err != nil {
+		return errors.Wrap(err, "invalid alias definition")
+	}
+
+	if len(args) == 0 {
+		return errors.Errorf("alias definition must not be empty")
+	}
+
+	if _, err := updateAliasesFile(c.aliasesFile(), c.name, c.definition); err != nil {
+		return err
+	}
+
+	log(ctx).Infof("Alias %q defined in %v", c.name, c.aliasesFile())
+
+	return nil
+}
+
+type commandAliasDelete struct {
+	name string
+
+	aliasesFile func() string
+}
+
+func (c *commandAliasDelete) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("delete", "Delete a command alias.").Alias("rm")
+	cmd.Arg("name", "Alias name").Required().StringVar(&c.name)
+	c.aliasesFile = svc.aliasesFile
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandAliasDelete) run(ctx context.Context) error {
+	found, err := updateAliasesFile(c.aliasesFile(), c.name, "")
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return errors.Errorf("alias %q not found", c.name)
+	}
+
+	log(ctx).Infof("Alias %q deleted", c.name)
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestAliases(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	e.Environment["KOPIA_ALIASES_FILE"] = filepath.Join(testutil.TempDirectory(t), "aliases.conf")
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+
+	require.Empty(t, e.RunAndExpectSuccess(t, "alias", "list"))
+
+	e.RunAndExpectSuccess(t, "alias", "set", "quick", "snapshot create --tags=type:quick")
+	e.RunAndExpectSuccess(t, "alias", "set", "tagged", "snapshot list $1 --tags=type:$2")
+	e.RunAndExpectFailure(t, "alias", "set", "snapshot", "snapshot list")
+	e.RunAndExpectFailure(t, "alias", "set", "broken", "snapshot list 'unterminated")
+
+	require.Equal(t, []string{
+		"quick = snapshot create --tags=type:quick",
+		"tagged = snapshot list $1 --tags=type:$2",
+	}, e.RunAndExpectSuccess(t, "alias", "list"))
+
+	// arguments are appended to the alias without positional parameters.
+	e.RunAndExpectSuccess(t, "quick", dir)
+
+	require.Len(t, e.RunAndExpectSuccess(t, "tagged", dir, "quick"), 2)
+	require.Empty(t, e.RunAndExpectSuccess(t, "tagged", dir, "other"))
+	e.RunAndExpectFailure(t, "tagged", dir)
+
+	// redefine existing alias.
+	e.RunAndExpectSuccess(t, "alias", "set", "quick", "snapshot list")
+	require.Equal(t, []string{"quick = snapshot list"}, e.RunAndExpectSuccess(t, "alias", "list")[:1])
+
+	e.RunAndExpectSuccess(t, "alias", "delete", "quick")
+	e.RunAndExpectFailure(t, "alias", "delete", "quick")
+	e.RunAndExpectFailure(t, "quick", dir)
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandAnalyze struct {
+	source string
+	quiet  bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandAnalyze) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("analyze", "Split and hash local directory without uploading it and report how much of its data already exists in the repository.")
+	cmd.Arg("source", "Directory to analyze.").Required().ExistingDirVar(&c.source)
+	cmd.Flag("quiet", "Do not display scanning progress").Short('q').BoolVar(&c.quiet)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+type analyzeProgress struct {
+	quiet bool
+}
+
+func (p *analyzeProgress) Processing(ctx context.Context, dirname string) {
+	if !p.quiet {
+		log(ctx).Infof("Analyzing %v...", dirname)
+	}
+}
+
+func (p *analyzeProgress) Error(ctx context.Context, filename string, err error) {
+	log(ctx).Errorf("Error in %v: %v", filename, err)
+}
+
+func (c *commandAnalyze) run(ctx context.Context, rep repo.DirectRepository) error {
+	path, err := filepath.Abs(c.source)
+	if err != nil {
+		return errors.Errorf("invalid path: '%s': %s", path, err)
+	}
+
+	sourceInfo := snapshot.SourceInfo{
+		Path:     filepath.Clean(path),
+		Host:     rep.ClientOptions().Hostname,
+		UserName: rep.ClientOptions().Username,
+	}
+
+	entry, err := getLocalFSEntry(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	dir, ok := entry.(fs.Directory)
+	if !ok {
+		return errors.Errorf("invalid path: '%s': must be a directory", path)
+	}
+
+	policyTree, err := policy.TreeForSource(ctx, rep, sourceInfo)
+	if err != nil {
+		return errors.Wrapf(err, "error creating policy tree for %v", sourceInfo)
+	}
+
+	a, err := snapshotfs.AnalyzeDeduplication(ctx, rep, dir, policyTree, &analyzeProgress{c.quiet})
+	if err != nil {
+		return errors.Wrap(err, "error analyzing")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(a))
+		return nil
+	}
+
+	c.out.printStdout("Analyzed %v file(s), %v in %v chunk(s).\n", a.Files, units.BytesString(a.TotalBytes), a.Chunks)
+	c.out.printStdout("  Already in repository:   %v in %v chunk(s) (%v)\n", units.BytesString(a.ExistingBytes), a.ExistingChunks, formatPercentage(a.ExistingBytes, a.TotalBytes))
+	c.out.printStdout("  Duplicated locally:      %v in %v chunk(s) (%v)\n", units.BytesString(a.DuplicateBytes), a.DuplicateChunks, formatPercentage(a.DuplicateBytes, a.TotalBytes))
+	c.out.printStdout("  New data to be uploaded: %v in %v chunk(s) (%v)\n", units.BytesString(a.NewBytes), a.NewChunks, formatPercentage(a.NewBytes, a.TotalBytes))
+
+	if a.Errors > 0 {
+		c.out.printStdout("Encountered %v error(s).\n", a.Errors)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestAnalyze(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file1"), []byte("hello world"), 0o600))
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "analyze", dir))
+	require.Contains(t, lines, "Analyzed 1 file(s), 11 B in 1 chunk(s).")
+	require.Contains(t, lines, " Already in repository: 0 B in 0 chunk(s) (0.0%)")
+	require.Contains(t, lines, " New data to be uploaded: 11 B in 1 chunk(s) (100.0%)")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	// identical file is deduplicated against the snapshot.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file2"), []byte("hello world"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file3"), []byte("new data"), 0o600))
+
+	lines = compressSpaces(e.RunAndExpectSuccess(t, "analyze", dir))
+	require.Contains(t, lines, "Analyzed 3 file(s), 30 B in 3 chunk(s).")
+	require.Contains(t, lines, " Already in repository: 11 B in 1 chunk(s) (36.7%)")
+	require.Contains(t, lines, " Duplicated locally: 11 B in 1 chunk(s) (36.7%)")
+	require.Contains(t, lines, " New data to be uploaded: 8 B in 1 chunk(s) (26.7%)")
+}
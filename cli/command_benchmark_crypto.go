@@ -84,7 +84,7 @@ func (c *commandBenchmarkCrypto) runBenchmark(ctx context.Context) []cryptoBench
 				continue
 			}
 
-			log(ctx).Infof("Benchmarking hash '%v' and encryption '%v'... (%v x %v bytes, parallelism %v)", ha, ea, c.repeat, len(data), c.parallel)
+			log(ctx).Infof("Benchmarking hash '%v' and encryption '%v'... (%v x %v, parallelism %v)", ha, ea, c.repeat, units.BytesString(int64(len(data))), c.parallel)
 
 			input := gather.FromSlice(data)
 			tt := timetrack.Start()
@@ -88,7 +88,7 @@ func (c *commandBenchmarkEcc) runBenchmark(ctx context.Context) []eccBenchResult
 				continue
 			}
 
-			log(ctx).Infof("Benchmarking ECC encoding '%v' with %v space overhead... (%v x %v bytes, parallelism %v)", name, spaceOverhead, c.repeat, len(data), c.parallel)
+			log(ctx).Infof("Benchmarking ECC encoding '%v' with %v space overhead... (%v x %v, parallelism %v)", name, spaceOverhead, c.repeat, units.BytesString(int64(len(data))), c.parallel)
 
 			input := gather.FromSlice(data)
 			tt := timetrack.Start()
@@ -109,7 +109,7 @@ func (c *commandBenchmarkEcc) runBenchmark(ctx context.Context) []eccBenchResult
 
 			_, bytesPerSecondEncoding := tt.Completed(float64(c.parallel) * float64(len(data)) * float64(repeat))
 
-			log(ctx).Infof("Benchmarking ECC decoding '%v' with %v space overhead... (%v x %v bytes, parallelism %v)", name, spaceOverhead, c.repeat, len(data), c.parallel)
+			log(ctx).Infof("Benchmarking ECC decoding '%v' with %v space overhead... (%v x %v, parallelism %v)", name, spaceOverhead, c.repeat, units.BytesString(int64(len(data))), c.parallel)
 
 			encodedBuffer.Reset()
 
@@ -76,7 +76,7 @@ func (c *commandBenchmarkEncryption) runBenchmark(ctx context.Context) []cryptoB
 			continue
 		}
 
-		log(ctx).Infof("Benchmarking encryption '%v'... (%v x %v bytes, parallelism %v)", ea, c.repeat, len(data), c.parallel)
+		log(ctx).Infof("Benchmarking encryption '%v'... (%v x %v, parallelism %v)", ea, c.repeat, units.BytesString(int64(len(data))), c.parallel)
 
 		input := gather.FromSlice(data)
 		tt := timetrack.Start()
@@ -71,7 +71,7 @@ func (c *commandBenchmarkHashing) runBenchmark(ctx context.Context) []cryptoBenc
 			continue
 		}
 
-		log(ctx).Infof("Benchmarking hash '%v' (%v x %v bytes, parallelism %v)", ha, c.repeat, len(data), c.parallel)
+		log(ctx).Infof("Benchmarking hash '%v' (%v x %v, parallelism %v)", ha, c.repeat, units.BytesString(int64(len(data))), c.parallel)
 
 		input := gather.FromSlice(data)
 		tt := timetrack.Start()
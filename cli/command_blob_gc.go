@@ -2,6 +2,8 @@
 
 import (
 	"context"
+	"sort"
+	"sync"
 
 	"github.com/pkg/errors"
 
@@ -16,6 +18,11 @@ type commandBlobGC struct {
 	prefix   string
 	safety   maintenance.SafetyParameters
 
+	inventory blobInventoryFlags
+	dryRun    dryRunFlags
+
+	jo  jsonOutput
+	out textOutput
 	svc appServices
 }
 
@@ -25,6 +32,10 @@ func (c *commandBlobGC) setup(svc appServices, parent commandParent) {
 	cmd.Flag("parallel", "Number of parallel blob scans").Default("16").IntVar(&c.parallel)
 	cmd.Flag("prefix", "Only GC blobs with given prefix").StringVar(&c.prefix)
 	safetyFlagVar(cmd, &c.safety)
+	c.inventory.setup(cmd)
+	c.dryRun.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
 
 	c.svc = svc
@@ -33,10 +44,24 @@ func (c *commandBlobGC) setup(svc appServices, parent commandParent) {
 func (c *commandBlobGC) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
 	c.svc.advancedCommand(ctx)
 
+	inv, err := c.inventory.load(ctx)
+	if err != nil {
+		return err
+	}
+
 	opts := maintenance.DeleteUnreferencedBlobsOptions{
-		DryRun:   c.delete != "yes",
-		Parallel: c.parallel,
-		Prefix:   blob.ID(c.prefix),
+		DryRun:    c.delete != "yes",
+		Parallel:  c.parallel,
+		Prefix:    blob.ID(c.prefix),
+		Inventory: inv,
+	}
+
+	if c.dryRun.dryRun {
+		if c.delete == "yes" {
+			return errors.New("--dry-run and --delete=yes can't be used together")
+		}
+
+		return c.reportDryRun(ctx, rep, opts)
 	}
 
 	n, err := maintenance.DeleteUnreferencedBlobs(ctx, rep, opts, c.safety)
@@ -50,3 +75,29 @@ func (c *commandBlobGC) run(ctx context.Context, rep repo.DirectRepositoryWriter
 
 	return nil
 }
+
+func (c *commandBlobGC) reportDryRun(ctx context.Context, rep repo.DirectRepositoryWriter, opts maintenance.DeleteUnreferencedBlobsOptions) error {
+	var mu sync.Mutex
+
+	r := &DryRunReport{Operation: "blob garbage collection"}
+
+	if err := maintenance.FindUnreferencedBlobs(ctx, rep, opts, c.safety, func(bm blob.Metadata) error {
+		// callback is invoked concurrently.
+		mu.Lock()
+		defer mu.Unlock()
+
+		r.Blobs++
+		r.BlobBytes += bm.Length
+		r.add(&DryRunItem{Type: "blob", ID: string(bm.BlobID), Bytes: bm.Length, Description: c.out.formatTimestamp(bm.Timestamp)})
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error finding unreferenced blobs")
+	}
+
+	sort.Slice(r.Items, func(i, j int) bool { return r.Items[i].ID < r.Items[j].ID })
+
+	c.dryRun.output(&c.jo, &c.out, r)
+
+	return nil
+}
@@ -2,6 +2,8 @@
 
 type commandCache struct {
 	clear    commandCacheClear
+	export   commandCacheExport
+	imp      commandCacheImport
 	info     commandCacheInfo
 	prefetch commandCachePrefetch
 	set      commandCacheSetParams
@@ -12,6 +14,8 @@ func (c *commandCache) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("cache", "Commands to manipulate local cache").Hidden()
 
 	c.clear.setup(svc, cmd)
+	c.export.setup(svc, cmd)
+	c.imp.setup(svc, cmd)
 	c.info.setup(svc, cmd)
 	c.prefetch.setup(svc, cmd)
 	c.set.setup(svc, cmd)
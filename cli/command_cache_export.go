@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/cache"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+)
+
+type commandCacheExport struct {
+	outputFile      string
+	includeContents bool
+
+	svc appServices
+}
+
+func (c *commandCacheExport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("export", "Exports metadata and index caches to a portable archive, which can be imported on another machine to avoid fetching them from the repository.")
+	cmd.Arg("file", "Archive file to write").Required().StringVar(&c.outputFile)
+	cmd.Flag("include-contents", "Include data contents cache").BoolVar(&c.includeContents)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandCacheExport) run(ctx context.Context, rep repo.DirectRepository) error {
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+	if err != nil {
+		return errors.Wrap(err, "error getting caching options")
+	}
+
+	if opts.CacheDirectory == "" {
+		return errors.New("caching not enabled")
+	}
+
+	caches := []string{"metadata", "indexes"}
+	if c.includeContents {
+		caches = append(caches, "contents")
+	}
+
+	f, err := os.Create(c.outputFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to create archive file")
+	}
+
+	stats, err := cache.ExportArchive(ctx, f, opts.CacheDirectory, caches, rep.UniqueID(), rep.Time())
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return errors.Wrap(err, "error exporting cache")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "error closing archive file")
+	}
+
+	log(ctx).Infof("Exported %v cache files (%v) to %v.", stats.Files, units.BytesString(stats.TotalBytes), c.outputFile)
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestCacheExportImport(t *testing.T) {
+	t.Parallel()
+
+	env1 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	env2 := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	other := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env1.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env1.RepoDir)
+	env1.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	env1.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	archive := filepath.Join(t.TempDir(), "cache.tar.gz")
+
+	_, stderr := env1.RunAndExpectSuccessWithErrOut(t, "cache", "export", archive, "--include-contents")
+	mustGetLineContaining(t, stderr, "Exported ")
+
+	env2.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env1.RepoDir)
+
+	_, stderr = env2.RunAndExpectSuccessWithErrOut(t, "cache", "import", archive)
+	line := mustGetLineContaining(t, stderr, "Imported ")
+	require.NotContains(t, line, "Imported 0 cache files")
+
+	// importing again does not overwrite existing entries.
+	_, stderr = env2.RunAndExpectSuccessWithErrOut(t, "cache", "import", archive)
+	mustGetLineContaining(t, stderr, "Imported 0 cache files")
+
+	env2.RunAndExpectSuccess(t, "snapshot", "list")
+	env2.RunAndExpectSuccess(t, "snapshot", "verify")
+
+	// archive from another repository is rejected unless forced.
+	other.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", other.RepoDir)
+	other.RunAndExpectFailure(t, "cache", "import", archive)
+	other.RunAndExpectSuccess(t, "cache", "import", archive, "--force")
+
+	other.RunAndExpectFailure(t, "cache", "import", filepath.Join(t.TempDir(), "no-such-file"))
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/cache"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+)
+
+type commandCacheImport struct {
+	inputFile string
+	force     bool
+
+	svc appServices
+}
+
+func (c *commandCacheImport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("import", "Imports cache archive produced by 'cache export' on another machine connected to the same repository.")
+	cmd.Arg("file", "Archive file to read").Required().ExistingFileVar(&c.inputFile)
+	cmd.Flag("force", "Import even if the archive was exported from a different repository").BoolVar(&c.force)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandCacheImport) run(ctx context.Context, rep repo.DirectRepository) error {
+	opts, err := repo.GetCachingOptions(ctx, c.svc.repositoryConfigFileName())
+	if err != nil {
+		return errors.Wrap(err, "error getting caching options")
+	}
+
+	if opts.CacheDirectory == "" {
+		return errors.New("caching not enabled")
+	}
+
+	uniqueID := rep.UniqueID()
+
+	// close repository before populating cache
+	if err := rep.Close(ctx); err != nil {
+		return errors.Wrap(err, "unable to close repository")
+	}
+
+	f, err := os.Open(c.inputFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to open archive file")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	stats, err := cache.ImportArchive(ctx, f, opts.CacheDirectory, uniqueID, c.force)
+	if err != nil {
+		return errors.Wrap(err, "error importing cache")
+	}
+
+	log(ctx).Infof("Imported %v cache files (%v), %v already present.", stats.Files, units.BytesString(stats.TotalBytes), stats.Skipped)
+
+	return nil
+}
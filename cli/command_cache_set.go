@@ -14,10 +14,12 @@
 
 type cacheSizeFlags struct {
 	contentCacheSizeMB      int64
+	contentCacheSizeMBSet   bool
 	contentCacheSizeLimitMB int64
 	contentMinSweepAge      time.Duration
 
 	metadataCacheSizeMB      int64
+	metadataCacheSizeMBSet   bool
 	metadataCacheSizeLimitMB int64
 	metadataMinSweepAge      time.Duration
 
@@ -28,10 +30,10 @@ type cacheSizeFlags struct {
 func (c *cacheSizeFlags) setup(cmd *kingpin.CmdClause) {
 	// do not use Defaults here, since this structure is shared between connect/create/set commands
 	// each command will set their default values in code.
-	cmd.Flag("content-cache-size-mb", "Desired size of local content cache (soft limit)").PlaceHolder("MB").Int64Var(&c.contentCacheSizeMB)
+	cmd.Flag("content-cache-size-mb", "Desired size of local content cache (soft limit)").PlaceHolder("MB").IsSetByUser(&c.contentCacheSizeMBSet).Int64Var(&c.contentCacheSizeMB)
 	cmd.Flag("content-cache-size-limit-mb", "Maximum size of local content cache (hard limit)").PlaceHolder("MB").Int64Var(&c.contentCacheSizeLimitMB)
 	cmd.Flag("content-min-sweep-age", "Minimal age of content cache item to be subject to sweeping").DurationVar(&c.contentMinSweepAge)
-	cmd.Flag("metadata-cache-size-mb", "Desired size of local metadata cache (soft limit)").PlaceHolder("MB").Int64Var(&c.metadataCacheSizeMB)
+	cmd.Flag("metadata-cache-size-mb", "Desired size of local metadata cache (soft limit)").PlaceHolder("MB").IsSetByUser(&c.metadataCacheSizeMBSet).Int64Var(&c.metadataCacheSizeMB)
 	cmd.Flag("metadata-cache-size-limit-mb", "Maximum size of local metadata cache (hard limit)").PlaceHolder("MB").Int64Var(&c.metadataCacheSizeLimitMB)
 	cmd.Flag("metadata-min-sweep-age", "Minimal age of metadata cache item to be subject to sweeping").DurationVar(&c.metadataMinSweepAge)
 	cmd.Flag("index-min-sweep-age", "Minimal age of index cache item to be subject to sweeping").DurationVar(&c.indexMinSweepAge)
@@ -23,6 +23,7 @@ type commandContentList struct {
 	contentRange contentRangeFlags
 	jo           jsonOutput
 	out          textOutput
+	table        tableOutput[content.Info]
 }
 
 func (c *commandContentList) setup(svc appServices, parent commandParent) {
@@ -35,17 +36,62 @@ func (c *commandContentList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("human", "Human-readable output").Short('h').BoolVar(&c.human)
 	c.contentRange.setup(cmd)
 	c.jo.setup(svc, cmd)
+	c.table.setup(cmd, []tableColumn[content.Info]{
+		{name: "id", value: func(b content.Info) string { return b.GetContentID().String() }},
+		{
+			name:  "size",
+			value: func(b content.Info) string { return maybeHumanReadableBytes(c.human, int64(b.GetOriginalLength())) },
+			less:  func(a, b content.Info) bool { return a.GetOriginalLength() < b.GetOriginalLength() },
+		},
+		{
+			name:  "packed",
+			value: func(b content.Info) string { return maybeHumanReadableBytes(c.human, int64(b.GetPackedLength())) },
+			less:  func(a, b content.Info) bool { return a.GetPackedLength() < b.GetPackedLength() },
+		},
+		{
+			name:  "time",
+			value: func(b content.Info) string { return c.out.formatTimestamp(b.Timestamp()) },
+			less:  func(a, b content.Info) bool { return a.Timestamp().Before(b.Timestamp()) },
+		},
+		{name: "pack", value: func(b content.Info) string { return string(b.GetPackBlobID()) }},
+		{
+			name:  "offset",
+			value: func(b content.Info) string { return fmt.Sprintf("%v", b.GetPackOffset()) },
+			less:  func(a, b content.Info) bool { return a.GetPackOffset() < b.GetPackOffset() },
+		},
+		{name: "compression", value: c.compressionInfoStringString},
+		{name: "deleted", value: func(b content.Info) string { return fmt.Sprintf("%v", b.GetDeleted()) }},
+	}, "id", "size", "packed", "time", "pack")
 	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
 }
 
 func (c *commandContentList) run(ctx context.Context, rep repo.DirectRepository) error {
+	if err := c.table.validate(); err != nil {
+		return err
+	}
+
 	var jl jsonList
 
 	jl.begin(&c.jo)
 	defer jl.end()
 
-	var totalSize stats.CountSum
+	var (
+		totalSize stats.CountSum
+		tableRows []content.Info
+		stream    *tableStream[content.Info]
+	)
+
+	useTable := c.table.enabled() && !c.jo.jsonOutput
+
+	// unsorted tables are written as contents are iterated, so that they don't have to be kept in memory.
+	if useTable && !c.table.sorted() {
+		var err error
+
+		if stream, err = c.table.stream(c.out.stdout()); err != nil {
+			return err
+		}
+	}
 
 	err := rep.ContentReader().IterateContents(
 		ctx,
@@ -61,6 +107,10 @@ func(b content.Info) error {
 			totalSize.Add(int64(b.GetPackedLength()))
 
 			switch {
+			case stream != nil:
+				return stream.add(b)
+			case useTable:
+				tableRows = append(tableRows, b)
 			case c.jo.jsonOutput:
 				jl.emit(b)
 			case c.compression:
@@ -77,6 +127,18 @@ func(b content.Info) error {
 		return errors.Wrap(err, "error iterating")
 	}
 
+	switch {
+	case stream != nil:
+		if err := stream.flush(); err != nil {
+			return err
+		}
+
+	case useTable:
+		if err := c.table.render(c.out.stdout(), tableRows); err != nil {
+			return err
+		}
+	}
+
 	if c.summary {
 		count, sz := totalSize.Approximate()
 		c.out.printStdout("Total: %v contents, %v total size\n",
@@ -91,7 +153,7 @@ func (c *commandContentList) outputLong(b content.Info) {
 	c.out.printStdout("%v %v %v %v %v+%v%v %v\n",
 		b.GetContentID(),
 		b.GetOriginalLength(),
-		formatTimestamp(b.Timestamp()),
+		c.out.formatTimestamp(b.Timestamp()),
 		b.GetPackBlobID(),
 		b.GetPackOffset(),
 		maybeHumanReadableBytes(c.human, int64(b.GetPackedLength())),
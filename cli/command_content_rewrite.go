@@ -17,10 +17,12 @@ type commandContentRewrite struct {
 	contentRewriteShortPacks    bool
 	contentRewriteFormatVersion int
 	contentRewritePackPrefix    string
-	contentRewriteDryRun        bool
 	contentRewriteSafety        maintenance.SafetyParameters
 
 	contentRange contentRangeFlags
+	dryRun       dryRunFlags
+	jo           jsonOutput
+	out          textOutput
 	svc          appServices
 }
 
@@ -32,8 +34,10 @@ func (c *commandContentRewrite) setup(svc appServices, parent commandParent) {
 	cmd.Flag("short", "Rewrite contents from short packs").BoolVar(&c.contentRewriteShortPacks)
 	cmd.Flag("format-version", "Rewrite contents using the provided format version").Default("-1").IntVar(&c.contentRewriteFormatVersion)
 	cmd.Flag("pack-prefix", "Only rewrite contents from pack blobs with a given prefix").StringVar(&c.contentRewritePackPrefix)
-	cmd.Flag("dry-run", "Do not actually rewrite, only print what would happen").Short('n').BoolVar(&c.contentRewriteDryRun)
 	c.contentRange.setup(cmd)
+	c.dryRun.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 	safetyFlagVar(cmd, &c.contentRewriteSafety)
 	cmd.Action(svc.directRepositoryWriteAction(c.runContentRewriteCommand))
 
@@ -48,16 +52,53 @@ func (c *commandContentRewrite) runContentRewriteCommand(ctx context.Context, re
 		return err
 	}
 
-	//nolint:wrapcheck
-	return maintenance.RewriteContents(ctx, rep, &maintenance.RewriteContentsOptions{
+	opt := &maintenance.RewriteContentsOptions{
 		ContentIDRange: c.contentRange.contentIDRange(),
 		ContentIDs:     contentIDs,
 		FormatVersion:  c.contentRewriteFormatVersion,
 		PackPrefix:     blob.ID(c.contentRewritePackPrefix),
 		Parallel:       c.contentRewriteParallelism,
 		ShortPacks:     c.contentRewriteShortPacks,
-		DryRun:         c.contentRewriteDryRun,
-	}, c.contentRewriteSafety)
+	}
+
+	if c.dryRun.dryRun {
+		return c.reportDryRun(ctx, rep, opt)
+	}
+
+	//nolint:wrapcheck
+	return maintenance.RewriteContents(ctx, rep, opt, c.contentRewriteSafety)
+}
+
+func (c *commandContentRewrite) reportDryRun(ctx context.Context, rep repo.DirectRepository, opt *maintenance.RewriteContentsOptions) error {
+	r := &DryRunReport{Operation: "content rewrite"}
+
+	var rewritten []content.Info
+
+	if err := maintenance.FindContentsToRewrite(ctx, rep, opt, c.contentRewriteSafety, func(ci content.Info) error {
+		r.Contents++
+		r.ContentBytes += int64(ci.GetPackedLength())
+		r.add(&DryRunItem{Type: "content", ID: ci.GetContentID().String(), Bytes: int64(ci.GetPackedLength()), Description: "pack:" + string(ci.GetPackBlobID())})
+
+		rewritten = append(rewritten, ci)
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error finding contents to rewrite")
+	}
+
+	// packs which become unreferenced because all their contents are rewritten.
+	if err := maintenance.FindPacksVacatedByRewrite(ctx, rep, rewritten, func(bm blob.Metadata) error {
+		r.Blobs++
+		r.BlobBytes += bm.Length
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error finding packs vacated by rewrite")
+	}
+
+	c.dryRun.output(&c.jo, &c.out, r)
+
+	return nil
 }
 
 func toContentIDs(s []string) ([]content.ID, error) {
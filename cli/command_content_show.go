@@ -8,12 +8,14 @@
 
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
 type commandContentShow struct {
 	ids        []string
 	indentJSON bool
 	decompress bool
+	describe   bool
 
 	out textOutput
 }
@@ -24,6 +26,7 @@ func (c *commandContentShow) setup(svc appServices, parent commandParent) {
 	cmd.Arg("id", "IDs of contents to show").Required().StringsVar(&c.ids)
 	cmd.Flag("json", "Pretty-print JSON content").Short('j').BoolVar(&c.indentJSON)
 	cmd.Flag("unzip", "Transparently decompress the content").Short('z').BoolVar(&c.decompress)
+	cmd.Flag("describe", "Show descriptions of objects stored in the contents instead of their data").BoolVar(&c.describe)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
 
 	c.out.setup(svc)
@@ -35,6 +38,10 @@ func (c *commandContentShow) run(ctx context.Context, rep repo.DirectRepository)
 		return err
 	}
 
+	if c.describe {
+		return c.describeContents(ctx, rep, contentIDs)
+	}
+
 	for _, contentID := range contentIDs {
 		if err := c.contentShow(ctx, rep, contentID); err != nil {
 			return err
@@ -52,3 +59,23 @@ func (c *commandContentShow) contentShow(ctx context.Context, r repo.DirectRepos
 
 	return showContentWithFlags(c.out.stdout(), bytes.NewReader(data), c.decompress, c.indentJSON)
 }
+
+func (c *commandContentShow) describeContents(ctx context.Context, rep repo.Repository, contentIDs []content.ID) error {
+	origins, err := snapshotfs.FindContentOrigins(ctx, rep, contentIDs)
+	if err != nil {
+		return errors.Wrap(err, "error looking up object descriptions")
+	}
+
+	for _, cid := range contentIDs {
+		if len(origins[cid]) == 0 {
+			c.out.printStdout("%v: no description available\n", cid)
+			continue
+		}
+
+		for _, o := range origins[cid] {
+			c.out.printStdout("%v: %v\n", cid, o)
+		}
+	}
+
+	return nil
+}
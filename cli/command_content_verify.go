@@ -13,6 +13,7 @@
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
 type commandContentVerify struct {
@@ -23,6 +24,9 @@ type commandContentVerify struct {
 	progressInterval            time.Duration
 
 	contentRange contentRangeFlags
+	inventory    blobInventoryFlags
+
+	out textOutput
 }
 
 func (c *commandContentVerify) setup(svc appServices, parent commandParent) {
@@ -34,7 +38,10 @@ func (c *commandContentVerify) setup(svc appServices, parent commandParent) {
 	cmd.Flag("download-percent", "Download a percentage of files [0.0 .. 100.0]").Float64Var(&c.contentVerifyPercent)
 	cmd.Flag("progress-interval", "Progress output interval").Default("3s").DurationVar(&c.progressInterval)
 	c.contentRange.setup(cmd)
+	c.inventory.setup(cmd)
 	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.out.setup(svc)
 }
 
 func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepository) error {
@@ -45,7 +52,7 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		downloadPercent = 100.0
 	}
 
-	blobMap, err := blob.ReadBlobMap(ctx, rep.BlobReader())
+	blobMap, err := c.inventory.readBlobMap(ctx, rep)
 	if err != nil {
 		return errors.Wrap(err, "unable to read blob map")
 	}
@@ -55,6 +62,9 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		successCount  atomic.Int32
 		errorCount    atomic.Int32
 		totalCount    atomic.Int32
+
+		failedMutex sync.Mutex
+		failed      []content.ID
 	)
 
 	subctx, cancel := context.WithCancel(ctx)
@@ -90,6 +100,10 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		if err := c.contentVerify(ctx, rep.ContentReader(), ci, blobMap, downloadPercent); err != nil {
 			log(ctx).Errorf("error %v", err)
 			errorCount.Add(1)
+
+			failedMutex.Lock()
+			failed = append(failed, ci.GetContentID())
+			failedMutex.Unlock()
 		} else {
 			successCount.Add(1)
 		}
@@ -105,7 +119,7 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 					timings.PercentComplete,
 					errorCount.Load(),
 					timings.Remaining,
-					formatTimestamp(timings.EstimatedEndTime),
+					c.out.formatTimestamp(timings.EstimatedEndTime),
 				)
 			} else {
 				log(ctx).Infof("  Verified %v contents, %v errors, estimating...", verifiedCount.Load(), errorCount.Load())
@@ -124,9 +138,26 @@ func (c *commandContentVerify) run(ctx context.Context, rep repo.DirectRepositor
 		return nil
 	}
 
+	reportContentOrigins(ctx, rep, failed)
+
 	return errors.Errorf("encountered %v errors", ec)
 }
 
+// reportContentOrigins logs descriptions of objects stored in the provided contents, as recorded by snapshots.
+func reportContentOrigins(ctx context.Context, rep repo.Repository, contentIDs []content.ID) {
+	origins, err := snapshotfs.FindContentOrigins(ctx, rep, contentIDs)
+	if err != nil {
+		log(ctx).Errorf("unable to determine origins of invalid contents: %v", err)
+		return
+	}
+
+	for _, cid := range contentIDs {
+		for _, o := range origins[cid] {
+			log(ctx).Errorf("content %v holds %v", cid, o)
+		}
+	}
+}
+
 func (c *commandContentVerify) getTotalContentCount(ctx context.Context, rep repo.DirectRepository, totalCount *atomic.Int32) {
 	var tc int32
 
@@ -169,3 +200,25 @@ func (c *commandContentVerify) contentVerify(ctx context.Context, r content.Read
 
 	return nil
 }
+
+// readBlobMap returns the metadata of all blobs in the repository, including blobs of the linked base repository.
+func readBlobMap(ctx context.Context, rep repo.DirectRepository) (map[blob.ID]blob.Metadata, error) {
+	if base := rep.BaseRepository(); base != nil {
+		baseMap, err := readBlobMap(ctx, base)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rep.BlobReader().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+			baseMap[bm.BlobID] = bm
+			return nil
+		}); err != nil {
+			return nil, errors.Wrap(err, "error listing blobs")
+		}
+
+		return baseMap, nil
+	}
+
+	//nolint:wrapcheck
+	return blob.ReadBlobMap(ctx, rep.BlobReader())
+}
@@ -0,0 +1,179 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo"
+)
+
+type commandDaemon struct {
+	start  commandDaemonStart
+	stop   commandDaemonStop
+	status commandDaemonStatus
+}
+
+func (c *commandDaemon) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("daemon", "Commands to control the local daemon which keeps the repository open for subsequent commands invoked with --use-daemon.")
+
+	c.start.setup(svc, cmd)
+	c.stop.setup(svc, cmd)
+	c.status.setup(svc, cmd)
+}
+
+type commandDaemonStart struct {
+	svc advancedAppServices
+}
+
+func (c *commandDaemonStart) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("start", "Open the repository and serve commands over a local socket until stopped.")
+	cmd.Action(svc.baseActionWithContext(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandDaemonStart) run(ctx context.Context) error {
+	socketPath := c.svc.daemonSocketPath()
+
+	if conn, err := c.svc.dialDaemon(); err == nil {
+		conn.Close() //nolint:errcheck
+		return errors.Errorf("daemon is already running on %v", socketPath)
+	}
+
+	rep, err := c.svc.openRepository(ctx, true)
+	if err != nil {
+		return errors.Wrap(err, "open repository")
+	}
+
+	// remove socket left behind by a daemon that did not shut down cleanly.
+	os.Remove(socketPath) //nolint:errcheck
+
+	l, err := listenDaemonSocket(socketPath)
+	if err != nil {
+		rep.Close(ctx) //nolint:errcheck
+
+		return errors.Wrap(err, "unable to listen on daemon socket")
+	}
+
+	defer os.Remove(socketPath) //nolint:errcheck
+
+	c.svc.onTerminate(func() {
+		l.Close() //nolint:errcheck,gosec
+	})
+
+	log(ctx).Infof("Daemon listening on %v", socketPath)
+
+	if err := c.svc.serveDaemon(ctx, rep, l); err != nil {
+		return err
+	}
+
+	log(ctx).Infof("Daemon stopped.")
+
+	return nil
+}
+
+type commandDaemonStop struct {
+	svc advancedAppServices
+}
+
+func (c *commandDaemonStop) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("stop", "Stop the daemon.")
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.svc = svc
+}
+
+func (c *commandDaemonStop) run(ctx context.Context) error {
+	if err := c.svc.daemonRequest(daemonRequestStop, func(*daemonResponse) error { return nil }); err != nil {
+		return err
+	}
+
+	log(ctx).Infof("Daemon stopped.")
+
+	return nil
+}
+
+type commandDaemonStatus struct {
+	svc advancedAppServices
+	out textOutput
+	jo  jsonOutput
+}
+
+func (c *commandDaemonStatus) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("status", "Show status of the daemon.")
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
+	c.jo.setup(svc, cmd)
+}
+
+func (c *commandDaemonStatus) run(ctx context.Context) error {
+	var st *daemonStatus
+
+	if err := c.svc.daemonRequest(daemonRequestStatus, func(resp *daemonResponse) error {
+		st = resp.Status
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if st == nil {
+		return errors.Errorf("daemon did not report its status")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonIndentedBytes(st, "  "))
+		return nil
+	}
+
+	c.out.printStdout("Config file:    %v\n", st.ConfigFile)
+	c.out.printStdout("Process ID:     %v\n", st.PID)
+	c.out.printStdout("Running for:    %v\n", c.out.formatDuration(clock.Now().Sub(st.StartTime).Truncate(time.Second)))
+	c.out.printStdout("Commands:       %v\n", st.RequestCount)
+
+	return nil
+}
+
+// daemonRequest sends a control request to the daemon serving the current configuration file.
+func (c *App) daemonRequest(command string, callback func(resp *daemonResponse) error) error {
+	conn, err := c.dialDaemon()
+	if err != nil {
+		return errors.Wrap(err, "daemon is not running")
+	}
+
+	defer conn.Close() //nolint:errcheck
+
+	return c.sendDaemonRequest(conn, &daemonRequest{
+		Command:    command,
+		ConfigFile: c.absoluteConfigFileName(),
+	}, nil, callback)
+}
+
+// serveDaemon executes commands received on the provided listener using the provided repository until the listener
+// is closed. The repository is closed when done, it may have been reopened in the meantime.
+func (c *App) serveDaemon(ctx context.Context, rep repo.Repository, l net.Listener) error {
+	d := &daemon{
+		app:        c,
+		configFile: c.absoluteConfigFileName(),
+		rep:        rep,
+		listener:   l,
+		startTime:  clock.Now(),
+	}
+
+	if st, err := os.Stat(d.configFile); err == nil {
+		d.configModTime = st.ModTime()
+	}
+
+	d.serve(ctx)
+
+	d.runMutex.Lock()
+	defer d.runMutex.Unlock()
+
+	return errors.Wrap(d.rep.Close(ctx), "unable to close repository")
+}
@@ -0,0 +1,97 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestDaemon(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	// daemon is not running, commands are executed locally.
+	e.RunAndExpectFailure(t, "daemon", "status")
+	e.RunAndVerifyOutputLineCount(t, 2, "snapshot", "list", "--use-daemon")
+
+	daemonStopped := make(chan struct{})
+	daemonStarted := make(chan struct{})
+
+	go func() {
+		defer close(daemonStopped)
+
+		wait, _ := e.RunAndProcessStderr(t, func(line string) bool {
+			if strings.Contains(line, "Daemon listening on") {
+				close(daemonStarted)
+				return false
+			}
+
+			return true
+		}, "daemon", "start")
+
+		wait() //nolint:errcheck
+	}()
+
+	<-daemonStarted
+
+	e.RunAndExpectFailure(t, "daemon", "start")
+
+	// commands are executed by the daemon and see changes made by other clients.
+	e.RunAndVerifyOutputLineCount(t, 2, "snapshot", "list", "--use-daemon")
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	e.RunAndVerifyOutputLineCount(t, 5, "snapshot", "list", "--use-daemon")
+
+	// errors are reported by the client.
+	e.RunAndExpectFailure(t, "snapshot", "list", "--use-daemon", "--tags=invalid-tag")
+
+	// stdin is forwarded to the daemon, keep the existing file when prompted.
+	src := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(src, "file.txt"), []byte("snapshot"), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", src)
+
+	var snaps []*cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", src, "--json"), &snaps)
+	require.Len(t, snaps, 1)
+
+	target := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(target, "file.txt"), []byte("existing"), 0o600))
+
+	runner.SetNextStdin(strings.NewReader("k\n"))
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "restore", string(snaps[0].ID), target, "--conflict-strategy=prompt", "--use-daemon")
+	require.Contains(t, strings.Join(stderr, "\n"), "already exists")
+
+	b, err := os.ReadFile(filepath.Join(target, "file.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "existing", string(b))
+
+	// commands changing the configuration are executed locally and the daemon reopens the repository.
+	e.RunAndExpectSuccess(t, "repo", "set-client", "--read-only", "--use-daemon")
+	_, stderr = e.RunAndExpectFailure(t, "snapshot", "create", src, "--use-daemon")
+	require.Contains(t, strings.Join(stderr, "\n"), "read-only")
+	e.RunAndExpectSuccess(t, "repo", "set-client", "--read-write", "--use-daemon")
+	e.RunAndExpectSuccess(t, "snapshot", "create", src, "--use-daemon")
+
+	status := e.RunAndExpectSuccess(t, "daemon", "status")
+	require.Contains(t, status, "Commands:       6")
+
+	e.RunAndExpectSuccess(t, "daemon", "stop")
+	<-daemonStopped
+
+	e.RunAndExpectFailure(t, "daemon", "status")
+	e.RunAndVerifyOutputLineCount(t, 9, "snapshot", "list", "--use-daemon")
+}
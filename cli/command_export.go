@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+const exportFormatSquashFS = "squashfs"
+
+type commandExport struct {
+	source    string
+	output    string
+	format    string
+	overwrite bool
+}
+
+func (c *commandExport) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("export", "Export a snapshot or its subtree as a read-only image file.")
+	cmd.Arg("source", "Snapshot ID, friendly snapshot name or object ID, optionally followed by a path within it (ID/path)").Required().StringVar(&c.source)
+	cmd.Arg("output", "Output file").Required().StringVar(&c.output)
+	cmd.Flag("format", "Image format").Default(exportFormatSquashFS).EnumVar(&c.format, exportFormatSquashFS)
+	cmd.Flag("overwrite", "Overwrite existing output file").BoolVar(&c.overwrite)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandExport) run(ctx context.Context, rep repo.Repository) error {
+	rootEntry, err := snapshotfs.FilesystemEntryFromIDWithPath(ctx, rep, c.source, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to get filesystem entry")
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if !c.overwrite {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(c.output, flags, 0o644) //nolint:gosec,gomnd
+	if err != nil {
+		return errors.Wrap(err, "unable to create output file")
+	}
+
+	output, err := restore.NewSquashFSOutput(f, f)
+	if err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+
+	log(ctx).Infof("Exporting %v to a SquashFS image (%v)...", c.source, c.output)
+
+	st, err := restore.Entry(ctx, rep, output, rootEntry, restore.Options{
+		RestoreDirEntryAtDepth: unlimitedDepth,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error exporting")
+	}
+
+	printRestoreStats(ctx, &st)
+
+	return nil
+}
@@ -40,7 +40,7 @@ func (c *commandIndexEpochList) run(ctx context.Context, rep repo.DirectReposito
 	c.out.printStdout("Current Epoch: %v\n", snap.WriteEpoch)
 
 	if est := snap.EpochStartTime[snap.WriteEpoch]; !est.IsZero() {
-		c.out.printStdout("Epoch Started  %v\n", formatTimestamp(est))
+		c.out.printStdout("Epoch Started  %v\n", c.out.formatTimestamp(est))
 	}
 
 	firstNonRangeCompacted := 0
@@ -55,18 +55,18 @@ func (c *commandIndexEpochList) run(ctx context.Context, rep repo.DirectReposito
 
 			c.out.printStdout("%v %v ... %v, %v blobs, %v, span %v\n",
 				e,
-				formatTimestamp(min),
-				formatTimestamp(max),
+				c.out.formatTimestamp(min),
+				c.out.formatTimestamp(max),
 				len(uces),
 				units.BytesString(blob.TotalLength(uces)),
-				max.Sub(min).Round(time.Second),
+				c.out.formatDuration(max.Sub(min).Round(time.Second)),
 			)
 		}
 
 		if secs := snap.SingleEpochCompactionSets[e]; secs != nil {
 			c.out.printStdout("%v: %v single-epoch %v blobs, %v\n",
 				e,
-				formatTimestamp(secs[0].Timestamp),
+				c.out.formatTimestamp(secs[0].Timestamp),
 				len(secs),
 				units.BytesString(blob.TotalLength(secs)),
 			)
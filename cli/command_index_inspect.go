@@ -122,8 +122,8 @@ func (c *commandIndexInspect) dumpIndexBlobEntries(entries chan indexBlobPlusCon
 		}
 
 		c.out.printStdout("%v %v %v %v %v %v %v %v\n",
-			formatTimestampPrecise(bm.Timestamp), bm.BlobID,
-			ci.GetContentID(), state, formatTimestampPrecise(ci.Timestamp()), ci.GetPackBlobID(), ci.GetPackOffset(), ci.GetPackedLength())
+			c.out.formatTimestampPrecise(bm.Timestamp), bm.BlobID,
+			ci.GetContentID(), state, c.out.formatTimestampPrecise(ci.Timestamp()), ci.GetPackBlobID(), ci.GetPackOffset(), ci.GetPackedLength())
 	}
 }
 
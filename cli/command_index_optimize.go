@@ -4,7 +4,10 @@
 	"context"
 	"time"
 
+	"github.com/pkg/errors"
+
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/content/indexblob"
 )
 
@@ -14,7 +17,10 @@ type commandIndexOptimize struct {
 	optimizeDropContents         []string
 	optimizeAllIndexes           bool
 
-	svc appServices
+	dryRun dryRunFlags
+	jo     jsonOutput
+	out    textOutput
+	svc    appServices
 }
 
 func (c *commandIndexOptimize) setup(svc appServices, parent commandParent) {
@@ -23,6 +29,9 @@ func (c *commandIndexOptimize) setup(svc appServices, parent commandParent) {
 	cmd.Flag("drop-deleted-older-than", "Drop deleted contents above given age").DurationVar(&c.optimizeDropDeletedOlderThan)
 	cmd.Flag("drop-contents", "Drop contents with given IDs").StringsVar(&c.optimizeDropContents)
 	cmd.Flag("all", "Optimize all indexes, even those above maximum size.").BoolVar(&c.optimizeAllIndexes)
+	c.dryRun.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 	cmd.Action(svc.directRepositoryWriteAction(c.runOptimizeCommand))
 
 	c.svc = svc
@@ -46,6 +55,49 @@ func (c *commandIndexOptimize) runOptimizeCommand(ctx context.Context, rep repo.
 		opt.DropDeletedBefore = rep.Time().Add(-age)
 	}
 
+	if c.dryRun.dryRun {
+		return c.reportDryRun(ctx, rep, opt)
+	}
+
 	//nolint:wrapcheck
 	return rep.ContentManager().CompactIndexes(ctx, opt)
 }
+
+// reportDryRun reports index blobs which would be compacted and contents which would be dropped from the index.
+func (c *commandIndexOptimize) reportDryRun(ctx context.Context, rep repo.DirectRepositoryWriter, opt indexblob.CompactOptions) error {
+	r := &DryRunReport{Operation: "index compaction"}
+
+	indexBlobs, err := rep.ContentManager().IndexBlobsToCompact(ctx, opt)
+	if err != nil {
+		return errors.Wrap(err, "error finding index blobs to compact")
+	}
+
+	for _, ib := range indexBlobs {
+		r.Blobs++
+		r.BlobBytes += ib.Length
+		r.add(&DryRunItem{Type: "index", ID: string(ib.BlobID), Bytes: ib.Length, Description: c.out.formatTimestamp(ib.Timestamp)})
+	}
+
+	dropped := map[content.ID]bool{}
+	for _, cid := range opt.DropContents {
+		dropped[cid] = true
+	}
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		if !dropped[ci.GetContentID()] && !(ci.GetDeleted() && ci.Timestamp().Before(opt.DropDeletedBefore)) {
+			return nil
+		}
+
+		r.Contents++
+		r.ContentBytes += int64(ci.GetPackedLength())
+		r.add(&DryRunItem{Type: "content", ID: ci.GetContentID().String(), Bytes: int64(ci.GetPackedLength()), Description: "dropped from index"})
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error iterating contents")
+	}
+
+	c.dryRun.output(&c.jo, &c.out, r)
+
+	return nil
+}
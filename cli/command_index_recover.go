@@ -24,6 +24,8 @@ type commandIndexRecover struct {
 	deleteIndexes bool
 
 	svc appServices
+
+	out textOutput
 }
 
 func (c *commandIndexRecover) setup(svc appServices, parent commandParent) {
@@ -37,6 +39,8 @@ func (c *commandIndexRecover) setup(svc appServices, parent commandParent) {
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
 
 	c.svc = svc
+
+	c.out.setup(svc)
 }
 
 func (c *commandIndexRecover) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
@@ -163,7 +167,7 @@ func (c *commandIndexRecover) recoverIndexesFromAllPacks(ctx context.Context, re
 								disc,
 								e.PercentComplete,
 								e.Remaining,
-								formatTimestamp(e.EstimatedEndTime))
+								c.out.formatTimestamp(e.EstimatedEndTime))
 						}
 					} else {
 						log(ctx).Infof("Recovered %v index entries from %v blobs, estimating time remaining... (found %v blobs)",
@@ -1,6 +1,7 @@
 package cli
 
 type commandLogs struct {
+	audit   commandLogsAudit
 	list    commandLogsList
 	cleanup commandLogsCleanup
 	show    commandLogsShow
@@ -9,6 +10,7 @@ type commandLogs struct {
 func (c *commandLogs) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("logs", "Commands to manipulate logs stored in the repository.").Hidden().Alias("log")
 
+	c.audit.setup(svc, cmd)
 	c.cleanup.setup(svc, cmd)
 	c.list.setup(svc, cmd)
 	c.show.setup(svc, cmd)
@@ -0,0 +1,219 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/blobcrypto"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/repodiag"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+type commandLogsAudit struct {
+	sessionIDs []string
+	blobPrefix string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+type auditLogSegmentInfo struct {
+	started  string
+	session  string
+	sequence int
+	metadata blob.Metadata
+}
+
+type auditLogEntryJSON struct {
+	Session string `json:"session"`
+	User    string `json:"user"`
+	repodiag.AuditLogEntry
+}
+
+func (c *commandLogsAudit) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("audit", "Show storage operations recorded in the audit log and verify its completeness.")
+
+	cmd.Arg("session-id", "Audit session ID to show").StringsVar(&c.sessionIDs)
+	cmd.Flag("blob-prefix", "Only show operations on blobs with the provided prefix").StringVar(&c.blobPrefix)
+
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+func (c *commandLogsAudit) run(ctx context.Context, rep repo.DirectRepository) error {
+	segments, err := getAuditLogSegments(ctx, rep.BlobReader())
+	if err != nil {
+		return errors.Wrap(err, "error listing audit log")
+	}
+
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	var data, decrypted gather.WriteBuffer
+	defer data.Close()
+	defer decrypted.Close()
+
+	var (
+		problems    int
+		lastSession string
+		nextSeq     int
+		lastFinal   = true
+	)
+
+	checkSessionEnd := func() {
+		if lastSession != "" && !lastFinal {
+			log(ctx).Warnf("audit session %v has not been closed cleanly or is still in progress", lastSession)
+		}
+	}
+
+	for _, s := range segments {
+		if !c.includeSession(s.session) {
+			continue
+		}
+
+		if s.session != lastSession {
+			checkSessionEnd()
+
+			lastSession = s.session
+			nextSeq = 0
+		}
+
+		if s.sequence != nextSeq {
+			log(ctx).Errorf("audit session %v is missing segments %v..%v", s.session, nextSeq, s.sequence-1)
+
+			problems++
+		}
+
+		nextSeq = s.sequence + 1
+
+		seg, err := readAuditLogSegment(ctx, rep, s.metadata.BlobID, &data, &decrypted)
+		if err != nil {
+			log(ctx).Errorf("invalid audit log segment %v: %v", s.metadata.BlobID, err)
+
+			problems++
+			lastFinal = false
+
+			continue
+		}
+
+		lastFinal = seg.Final
+
+		for _, e := range seg.Entries {
+			if !strings.HasPrefix(string(e.BlobID), c.blobPrefix) {
+				continue
+			}
+
+			if c.jo.jsonOutput {
+				jl.emit(auditLogEntryJSON{seg.Session, seg.User, e})
+				continue
+			}
+
+			c.out.printStdout("%v %v %v %-6v %v %v\n",
+				c.out.formatTimestampPrecise(e.Time),
+				seg.Session,
+				seg.User,
+				e.Op,
+				e.BlobID,
+				units.BytesString(e.Length),
+			)
+		}
+	}
+
+	checkSessionEnd()
+
+	if problems > 0 {
+		return errors.Errorf("found %v problems with the audit log", problems)
+	}
+
+	return nil
+}
+
+func (c *commandLogsAudit) includeSession(id string) bool {
+	if len(c.sessionIDs) == 0 {
+		return true
+	}
+
+	for _, sid := range c.sessionIDs {
+		if sid == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func readAuditLogSegment(ctx context.Context, rep repo.DirectRepository, blobID blob.ID, data, decrypted *gather.WriteBuffer) (*repodiag.AuditLogSegment, error) {
+	if err := rep.BlobReader().GetBlob(ctx, blobID, 0, -1, data); err != nil {
+		return nil, errors.Wrap(err, "error reading blob")
+	}
+
+	if err := blobcrypto.Decrypt(rep.ContentReader().ContentFormat(), data.Bytes(), blobID, decrypted); err != nil {
+		return nil, errors.Wrap(err, "error decrypting blob")
+	}
+
+	seg := &repodiag.AuditLogSegment{}
+	if err := json.NewDecoder(decrypted.Bytes().Reader()).Decode(seg); err != nil {
+		return nil, errors.Wrap(err, "error parsing blob")
+	}
+
+	return seg, nil
+}
+
+// getAuditLogSegments returns audit log segments ordered by session start time, session and sequence number.
+func getAuditLogSegments(ctx context.Context, st blob.Reader) ([]auditLogSegmentInfo, error) {
+	var result []auditLogSegmentInfo
+
+	if err := st.ListBlobs(ctx, repodiag.AuditLogBlobPrefix, func(bm blob.Metadata) error {
+		// _audit_<start-time>_<session>_<sequence>_<hash>
+		parts := strings.Split(string(bm.BlobID), "_")
+
+		//nolint:gomnd
+		if len(parts) != 6 {
+			log(ctx).Errorf("skipping unrecognized audit log: %v", bm.BlobID)
+			return nil
+		}
+
+		seq, err := strconv.Atoi(parts[4])
+		if err != nil {
+			log(ctx).Errorf("skipping unrecognized audit log: %v", bm.BlobID)
+			return nil
+		}
+
+		result = append(result, auditLogSegmentInfo{
+			started:  parts[2],
+			session:  parts[3],
+			sequence: seq,
+			metadata: bm,
+		})
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error listing audit logs")
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].started != result[j].started {
+			return result[i].started < result[j].started
+		}
+
+		if result[i].session != result[j].session {
+			return result[i].session < result[j].session
+		}
+
+		return result[i].sequence < result[j].sequence
+	})
+
+	return result, nil
+}
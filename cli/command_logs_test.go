@@ -110,3 +110,46 @@ func TestLogsMaintenance(t *testing.T) {
 	require.Contains(t, infoLines, "  max total size:  34.6 MB")
 	require.Contains(t, infoLines, "  max count:       44")
 }
+
+func TestLogsAudit(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// audit log is disabled by default.
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	e.RunAndVerifyOutputLineCount(t, 0, "logs", "audit")
+
+	e.RunAndExpectSuccess(t, "repo", "set-client", "--enable-audit-log")
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	lines := e.RunAndExpectSuccess(t, "logs", "audit")
+	require.NotEmpty(t, lines)
+
+	for _, l := range lines {
+		require.Regexp(t, " (put|delete) ", l)
+	}
+
+	require.NotEmpty(t, e.RunAndExpectSuccess(t, "logs", "audit", "--blob-prefix=q"))
+	e.RunAndVerifyOutputLineCount(t, 0, "logs", "audit", "--blob-prefix=_log_")
+
+	// writes of format blobs are audited too.
+	e.RunAndVerifyOutputLineCount(t, 0, "logs", "audit", "--blob-prefix=kopia.repository")
+	e.RunAndExpectSuccess(t, "repo", "set-parameters", "--max-pack-size-mb=30")
+	require.NotEmpty(t, e.RunAndExpectSuccess(t, "logs", "audit", "--blob-prefix=kopia.repository"))
+
+	lines = e.RunAndExpectSuccess(t, "logs", "audit")
+
+	var entries []map[string]any
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "logs", "audit", "--json"), &entries)
+	require.Len(t, entries, len(lines))
+
+	e.RunAndExpectSuccess(t, "repo", "set-client", "--disable-audit-log")
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	e.RunAndVerifyOutputLineCount(t, len(lines), "logs", "audit")
+}
@@ -18,6 +18,7 @@ type commandList struct {
 	recursive    bool
 	showOID      bool
 	errorSummary bool
+	showDeleted  bool
 	path         string
 
 	out textOutput
@@ -30,6 +31,7 @@ func (c *commandList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("recursive", "Recursive output").Short('r').BoolVar(&c.recursive)
 	cmd.Flag("show-object-id", "Show object IDs").Short('o').BoolVar(&c.showOID)
 	cmd.Flag("error-summary", "Emit error summary").Default("true").BoolVar(&c.errorSummary)
+	cmd.Flag("show-deleted", "Show entries deleted since the previous snapshot, if recorded").BoolVar(&c.showDeleted)
 	cmd.Arg("object-path", "Path").Required().StringVar(&c.path)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 
@@ -73,6 +75,17 @@ func (c *commandList) listDirectory(ctx context.Context, d fs.Directory, prefix,
 		return err //nolint:wrapcheck
 	}
 
+	if dwt, ok := d.(snapshotfs.DirectoryWithTombstones); ok && c.showDeleted {
+		tombstones, err := dwt.Tombstones(ctx)
+		if err != nil {
+			return errors.Wrap(err, "unable to read deleted entries")
+		}
+
+		for _, de := range tombstones {
+			c.printDeletedEntry(snapshotfs.EntryFromDirEntry(nil, de), prefix)
+		}
+	}
+
 	if dws, ok := d.(fs.DirectoryWithSummary); ok && c.errorSummary {
 		if ds, _ := dws.Summary(ctx); ds != nil && ds.FatalErrorCount > 0 {
 			errorColor.Fprintf(c.out.stderr(), "\nNOTE: Encountered %v errors while snapshotting this directory:\n\n", ds.FatalErrorCount) //nolint:errcheck
@@ -114,7 +127,7 @@ func (c *commandList) printDirectoryEntry(ctx context.Context, e fs.Entry, prefi
 			"%v %12d %v %-34v %v%v",
 			e.Mode(),
 			e.Size(),
-			formatTimestamp(e.ModTime().Local()),
+			c.out.formatTimestamp(e.ModTime().Local()),
 			oid,
 			c.nameToDisplay(prefix, e),
 			errorSummary,
@@ -139,6 +152,29 @@ func (c *commandList) printDirectoryEntry(ctx context.Context, e fs.Entry, prefi
 	return nil
 }
 
+func (c *commandList) printDeletedEntry(e fs.Entry, prefix string) {
+	var info string
+
+	switch {
+	case c.long:
+		info = fmt.Sprintf(
+			"%v %12d %v %-34v %v (deleted)",
+			e.Mode(),
+			e.Size(),
+			c.out.formatTimestamp(e.ModTime().Local()),
+			"-",
+			c.nameToDisplay(prefix, e),
+		)
+	case c.showOID:
+		info = fmt.Sprintf("%-34v %v (deleted)", "-", c.nameToDisplay(prefix, e))
+
+	default:
+		info = fmt.Sprintf("%v (deleted)", c.nameToDisplay(prefix, e))
+	}
+
+	warningColor.Fprintln(c.out.stdout(), info) //nolint:errcheck
+}
+
 func (c *commandList) nameToDisplay(prefix string, e fs.Entry) string {
 	suffix := ""
 	if e.IsDir() {
@@ -0,0 +1,66 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestListShowDeleted(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcDir, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("aaa"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "b.txt"), []byte("bbb"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "sub", "c.txt"), []byte("ccc"), 0o600))
+
+	e.RunAndExpectSuccess(t, "policy", "set", srcDir, "--record-deleted-entries=true")
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	require.NoError(t, os.Remove(filepath.Join(srcDir, "b.txt")))
+	require.NoError(t, os.Remove(filepath.Join(srcDir, "sub", "c.txt")))
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcDir, "--json"), &man)
+
+	rootOID := man.RootObjectID().String()
+
+	require.Equal(t, []string{
+		"sub",
+		"a.txt",
+	}, e.RunAndExpectSuccess(t, "ls", rootOID))
+
+	require.Equal(t, []string{
+		"sub",
+		"a.txt",
+		"b.txt (deleted)",
+	}, e.RunAndExpectSuccess(t, "ls", rootOID, "--show-deleted"))
+
+	require.Equal(t, []string{
+		rootOID + "/sub/",
+		rootOID + "/sub/c.txt (deleted)",
+		rootOID + "/a.txt",
+		rootOID + "/b.txt (deleted)",
+	}, e.RunAndExpectSuccess(t, "ls", rootOID, "--show-deleted", "-r"))
+
+	// without the policy, deleted entries are not recorded.
+	e.RunAndExpectSuccess(t, "policy", "set", srcDir, "--record-deleted-entries=inherit")
+	require.NoError(t, os.Remove(filepath.Join(srcDir, "a.txt")))
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcDir, "--json"), &man)
+
+	require.Equal(t, []string{
+		"sub",
+	}, e.RunAndExpectSuccess(t, "ls", man.RootObjectID().String(), "--show-deleted"))
+}
@@ -6,7 +6,7 @@ type commandMaintenance struct {
 	set  commandMaintenanceSet
 }
 
-func (c *commandMaintenance) setup(svc appServices, parent commandParent) {
+func (c *commandMaintenance) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("maintenance", "Maintenance commands.").Hidden().Alias("gc")
 
 	c.info.setup(svc, cmd)
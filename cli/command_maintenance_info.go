@@ -6,7 +6,6 @@
 
 	"github.com/pkg/errors"
 
-	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/maintenance"
@@ -59,6 +58,13 @@ func (c *commandMaintenanceInfo) run(ctx context.Context, rep repo.DirectReposit
 	c.out.printStdout("Full Cycle:\n")
 	c.displayCycleInfo(&p.FullCycle, s.NextFullMaintenanceTime, rep)
 
+	c.out.printStdout("Verification:\n")
+	c.displayCycleInfo(&p.VerifyCycle.CycleParams, s.NextVerifyTime, rep)
+
+	if p.VerifyCycle.Enabled {
+		c.out.printStdout("  files percent: %v%%\n", p.VerifyCycle.FilesPercent)
+	}
+
 	cl := p.LogRetention.OrDefault()
 
 	c.out.printStdout("Log Retention:\n")
@@ -87,9 +93,13 @@ func (c *commandMaintenanceInfo) run(ctx context.Context, rep repo.DirectReposit
 
 			c.out.printStdout(
 				"    %v (%v) %v\n",
-				formatTimestamp(t.Start),
-				t.End.Sub(t.Start).Truncate(time.Second),
+				c.out.formatTimestamp(t.Start),
+				c.out.formatDuration(t.End.Sub(t.Start)),
 				errInfo)
+
+			if len(t.Stats) > 0 {
+				c.out.printStdout("      %s\n", t.Stats)
+			}
 		}
 	}
 
@@ -103,7 +113,7 @@ func (c *commandMaintenanceInfo) displayCycleInfo(cp *maintenance.CycleParams, t
 		c.out.printStdout("  interval: %v\n", cp.Interval)
 
 		if rep.Time().Before(t) {
-			c.out.printStdout("  next run: %v (in %v)\n", formatTimestamp(t), t.Sub(clock.Now()).Truncate(time.Second))
+			c.out.printStdout("  next run: %v (in %v)\n", c.out.formatTimestamp(t), c.out.formatDuration(t.Sub(rep.Time())))
 		} else {
 			c.out.printStdout("  next run: now\n")
 		}
@@ -14,29 +14,133 @@ type commandMaintenanceRun struct {
 	maintenanceRunFull  bool
 	maintenanceRunForce bool
 	safety              maintenance.SafetyParameters
+
+	dryRun dryRunFlags
+	jo     jsonOutput
+	out    textOutput
+	svc    advancedAppServices
 }
 
-func (c *commandMaintenanceRun) setup(svc appServices, parent commandParent) {
+func (c *commandMaintenanceRun) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("run", "Run repository maintenance")
 	cmd.Flag("full", "Full maintenance").BoolVar(&c.maintenanceRunFull)
 	cmd.Flag("force", "Run maintenance even if not owned (unsafe)").Hidden().BoolVar(&c.maintenanceRunForce)
 	safetyFlagVar(cmd, &c.safety)
+	c.dryRun.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
+
+	c.svc = svc
 }
 
 func (c *commandMaintenanceRun) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
-	mode := maintenance.ModeQuick
+	if c.dryRun.dryRun {
+		return c.reportDryRun(ctx, rep)
+	}
 
+	if err := c.runOn(ctx, rep); err != nil {
+		return err
+	}
+
+	return c.runOnShards(ctx, rep, func(ctx context.Context, _ string, rep repo.DirectRepositoryWriter) error {
+		return c.runOn(ctx, rep)
+	})
+}
+
+func (c *commandMaintenanceRun) runOn(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	mode, err := c.mode(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return snapshotmaintenance.Run(ctx, rep, mode, c.maintenanceRunForce, c.safety)
+}
+
+func (c *commandMaintenanceRun) mode(ctx context.Context, rep repo.DirectRepositoryWriter) (maintenance.Mode, error) {
 	_, supportsEpochManager, err := rep.ContentManager().EpochManager(ctx)
 	if err != nil {
-		return errors.Wrap(err, "EpochManager")
+		return maintenance.ModeNone, errors.Wrap(err, "EpochManager")
 	}
 
 	if c.maintenanceRunFull || supportsEpochManager {
-		mode = maintenance.ModeFull
+		return maintenance.ModeFull, nil
 	}
 
-	//nolint:wrapcheck
-	return snapshotmaintenance.Run(ctx, rep, mode, c.maintenanceRunForce, c.safety)
+	return maintenance.ModeQuick, nil
+}
+
+// reportDryRun reports the impact of each maintenance task on the repository and its shards,
+// task names of shards are prefixed with the shard name.
+func (c *commandMaintenanceRun) reportDryRun(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	r := &DryRunReport{Operation: "maintenance"}
+
+	dryRunOn := func(ctx context.Context, shard string, rep repo.DirectRepositoryWriter) error {
+		mode, err := c.mode(ctx, rep)
+		if err != nil {
+			return err
+		}
+
+		tasks, err := snapshotmaintenance.DryRun(ctx, rep, mode, c.safety)
+		if err != nil {
+			return errors.Wrap(err, "error performing maintenance dry run")
+		}
+
+		for _, t := range tasks {
+			r.addMaintenanceTask(shard, t)
+		}
+
+		return nil
+	}
+
+	if err := dryRunOn(ctx, "", rep); err != nil {
+		return err
+	}
+
+	if err := c.runOnShards(ctx, rep, dryRunOn); err != nil {
+		return err
+	}
+
+	c.dryRun.output(&c.jo, &c.out, r)
+
+	return nil
+}
+
+// runOnShards invokes the provided function for all shards of the repository connected by this client.
+func (c *commandMaintenanceRun) runOnShards(ctx context.Context, rep repo.DirectRepositoryWriter, run func(ctx context.Context, shard string, rep repo.DirectRepositoryWriter) error) error {
+	shards, err := newShardRepositories(ctx, c.svc, rep)
+	if err != nil {
+		return err
+	}
+
+	defer shards.close(ctx)
+
+	for _, sh := range shards.cfg.Shards {
+		if _, connected := shards.conns[sh.Name]; !connected {
+			log(ctx).Infof("Skipping maintenance of shard %v, which is not connected.", sh.Name)
+			continue
+		}
+
+		r, err := shards.open(ctx, sh.Name)
+		if err != nil {
+			return err
+		}
+
+		dr, ok := r.(repo.DirectRepository)
+		if !ok {
+			return errors.Errorf("maintenance of shard %v requires direct repository access", sh.Name)
+		}
+
+		log(ctx).Infof("Running maintenance of shard %v...", sh.Name)
+
+		if err := repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{Purpose: "cli:maintenance-run-shard"}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+			return run(ctx, sh.Name, w)
+		}); err != nil {
+			return errors.Wrapf(err, "error running maintenance of shard %v", sh.Name)
+		}
+	}
+
+	return nil
 }
@@ -15,16 +15,22 @@ type commandMaintenanceSet struct {
 	maintenanceSetOwner          string
 	maintenanceSetEnableQuick    []bool // optional boolean
 	maintenanceSetEnableFull     []bool // optional boolean
+	maintenanceSetEnableVerify   []bool // optional boolean
 	maintenanceSetQuickFrequency time.Duration
 	maintenanceSetFullFrequency  time.Duration
 	maintenanceSetPauseQuick     time.Duration
 	maintenanceSetPauseFull      time.Duration
+	maintenanceSetVerifyInterval time.Duration
+	maintenanceSetPauseVerify    time.Duration
+	maintenanceSetVerifyPercent  float64
 
 	maxRetainedLogCount       int
 	maxRetainedLogAge         time.Duration
 	maxTotalRetainedLogSizeMB int64
 
 	extendObjectLocks []bool // optional boolean
+
+	out textOutput
 }
 
 func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
@@ -34,6 +40,9 @@ func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
 	c.maintenanceSetFullFrequency = -1
 	c.maintenanceSetPauseQuick = -1
 	c.maintenanceSetPauseFull = -1
+	c.maintenanceSetVerifyInterval = -1
+	c.maintenanceSetPauseVerify = -1
+	c.maintenanceSetVerifyPercent = -1
 
 	c.maxRetainedLogCount = -1
 	c.maxRetainedLogAge = -1
@@ -50,12 +59,19 @@ func (c *commandMaintenanceSet) setup(svc appServices, parent commandParent) {
 	cmd.Flag("pause-quick", "Pause quick maintenance for a specified duration").DurationVar(&c.maintenanceSetPauseQuick)
 	cmd.Flag("pause-full", "Pause full maintenance for a specified duration").DurationVar(&c.maintenanceSetPauseFull)
 
+	cmd.Flag("enable-verify", "Enable or disable scheduled verification of snapshots").BoolListVar(&c.maintenanceSetEnableVerify)
+	cmd.Flag("verify-interval", "Set interval of scheduled verification").DurationVar(&c.maintenanceSetVerifyInterval)
+	cmd.Flag("verify-files-percent", "Set percentage of files downloaded by scheduled verification [0.0 .. 100.0]").Float64Var(&c.maintenanceSetVerifyPercent)
+	cmd.Flag("pause-verify", "Pause scheduled verification for a specified duration").DurationVar(&c.maintenanceSetPauseVerify)
+
 	cmd.Flag("max-retained-log-count", "Set maximum number of log sessions to retain").IntVar(&c.maxRetainedLogCount)
 	cmd.Flag("max-retained-log-age", "Set maximum age of log sessions to retain").DurationVar(&c.maxRetainedLogAge)
 	cmd.Flag("max-retained-log-size-mb", "Set maximum total size of log sessions").Int64Var(&c.maxTotalRetainedLogSizeMB)
 	cmd.Flag("extend-object-locks", "Extend retention period of locked objects as part of full maintenance.").BoolListVar(&c.extendObjectLocks)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
+
+	c.out.setup(svc)
 }
 
 func (c *commandMaintenanceSet) setLogCleanupParametersFromFlags(ctx context.Context, p *maintenance.Params, changed *bool) {
@@ -124,6 +140,27 @@ func (c *commandMaintenanceSet) setMaintenanceEnabledAndIntervalFromFlags(ctx co
 	}
 }
 
+func (c *commandMaintenanceSet) setVerifyParametersFromFlags(ctx context.Context, p *maintenance.Params, changed *bool) error {
+	c.setMaintenanceEnabledAndIntervalFromFlags(ctx, &p.VerifyCycle.CycleParams, "verification", c.maintenanceSetEnableVerify, c.maintenanceSetVerifyInterval, changed)
+
+	if p.VerifyCycle.Enabled && p.VerifyCycle.Interval <= 0 {
+		p.VerifyCycle.Interval = maintenance.DefaultVerifyInterval
+	}
+
+	if v := c.maintenanceSetVerifyPercent; v != -1 {
+		if v < 0 || v > 100 { //nolint:gomnd
+			return errors.Errorf("invalid verification percentage: %v", v)
+		}
+
+		p.VerifyCycle.FilesPercent = v
+		*changed = true
+
+		log(ctx).Infof("Scheduled verification will download %v%% of files.", v)
+	}
+
+	return nil
+}
+
 func (c *commandMaintenanceSet) setMaintenanceObjectLockExtendFromFlags(ctx context.Context, p *maintenance.Params, changed *bool) {
 	// we use lists to distinguish between flag not set
 	// Zero elements == not set, more than zero - flag set, in which case we pick the last value
@@ -157,20 +194,32 @@ func (c *commandMaintenanceSet) run(ctx context.Context, rep repo.DirectReposito
 	c.setMaintenanceEnabledAndIntervalFromFlags(ctx, &p.QuickCycle, "quick", c.maintenanceSetEnableQuick, c.maintenanceSetQuickFrequency, &changedParams)
 	c.setMaintenanceEnabledAndIntervalFromFlags(ctx, &p.FullCycle, "full", c.maintenanceSetEnableFull, c.maintenanceSetFullFrequency, &changedParams)
 	c.setLogCleanupParametersFromFlags(ctx, p, &changedParams)
+
+	if err := c.setVerifyParametersFromFlags(ctx, p, &changedParams); err != nil {
+		return err
+	}
+
 	c.setMaintenanceObjectLockExtendFromFlags(ctx, p, &changedParams)
 
 	if pauseDuration := c.maintenanceSetPauseQuick; pauseDuration != -1 {
 		s.NextQuickMaintenanceTime = rep.Time().Add(pauseDuration)
 		changedSchedule = true
 
-		log(ctx).Infof("Quick maintenance paused until %v", formatTimestamp(s.NextQuickMaintenanceTime))
+		log(ctx).Infof("Quick maintenance paused until %v", c.out.formatTimestamp(s.NextQuickMaintenanceTime))
 	}
 
 	if pauseDuration := c.maintenanceSetPauseFull; pauseDuration != -1 {
 		s.NextFullMaintenanceTime = rep.Time().Add(pauseDuration)
 		changedSchedule = true
 
-		log(ctx).Infof("Full maintenance paused until %v", formatTimestamp(s.NextFullMaintenanceTime))
+		log(ctx).Infof("Full maintenance paused until %v", c.out.formatTimestamp(s.NextFullMaintenanceTime))
+	}
+
+	if pauseDuration := c.maintenanceSetPauseVerify; pauseDuration != -1 {
+		s.NextVerifyTime = rep.Time().Add(pauseDuration)
+		changedSchedule = true
+
+		log(ctx).Infof("Scheduled verification paused until %v", c.out.formatTimestamp(s.NextVerifyTime))
 	}
 
 	if !changedParams && !changedSchedule {
@@ -24,6 +24,8 @@ type commandMount struct {
 	mountPreferWebDAV           bool
 	maxCachedEntries            int
 	maxCachedDirectories        int
+	prefetchFiles               bool
+	prefetchMaxObjectsPerSecond int
 
 	svc appServices
 }
@@ -42,6 +44,8 @@ func (c *commandMount) setup(svc appServices, parent commandParent) {
 
 	cmd.Flag("max-cached-entries", "Limit the number of cached directory entries").Default("100000").IntVar(&c.maxCachedEntries)
 	cmd.Flag("max-cached-dirs", "Limit the number of cached directories").Default("100").IntVar(&c.maxCachedDirectories)
+	cmd.Flag("prefetch-files", "Prefetch files in the background when their directory is listed (FUSE only)").BoolVar(&c.prefetchFiles)
+	cmd.Flag("prefetch-max-objects-per-second", "Limit the rate of prefetching files").Default("100").IntVar(&c.prefetchMaxObjectsPerSecond)
 
 	c.svc = svc
 	cmd.Action(svc.repositoryReaderAction(c.run))
@@ -75,26 +79,41 @@ func (c *commandMount) run(ctx context.Context, rep repo.Repository) error {
 	//nolint:forcetypeassert
 	entry = cachefs.Wrap(entry, c.newFSCache()).(fs.Directory)
 
-	ctrl, mountErr := mount.Directory(ctx, entry, c.mountPoint,
-		mount.Options{
-			FuseAllowOther:         c.mountFuseAllowOther,
-			FuseAllowNonEmptyMount: c.mountFuseAllowNonEmptyMount,
-			PreferWebDAV:           c.mountPreferWebDAV,
+	mountOptions := mount.Options{
+		FuseAllowOther:         c.mountFuseAllowOther,
+		FuseAllowNonEmptyMount: c.mountFuseAllowNonEmptyMount,
+		PreferWebDAV:           c.mountPreferWebDAV,
+	}
+
+	if c.prefetchFiles {
+		p := repo.NewBackgroundPrefetcher(ctx, rep, repo.BackgroundPrefetchOptions{
+			MaxObjectsPerSecond: c.prefetchMaxObjectsPerSecond,
 		})
+		defer p.Close()
+
+		mountOptions.Prefetcher = p
+	}
+
+	return mountDirectoryAndWait(ctx, c.svc, entry, c.mountObjectID, c.mountPoint, mountOptions, c.mountPointBrowse)
+}
+
+// mountDirectoryAndWait mounts the provided directory and waits until it's unmounted or Ctrl-C is pressed.
+func mountDirectoryAndWait(ctx context.Context, svc appServices, entry fs.Directory, description, mountPoint string, mountOptions mount.Options, browse bool) error {
+	ctrl, mountErr := mount.Directory(ctx, entry, mountPoint, mountOptions)
 
 	if mountErr != nil {
 		return errors.Wrap(mountErr, "mount error")
 	}
 
-	log(ctx).Infof("Mounted '%v' on %v", c.mountObjectID, ctrl.MountPath())
+	log(ctx).Infof("Mounted '%v' on %v", description, ctrl.MountPath())
 
-	if c.mountPoint == "*" && !c.mountPointBrowse {
+	if mountPoint == "*" && !browse {
 		log(ctx).Infof("HINT: Pass --browse to automatically open file browser.")
 	}
 
 	log(ctx).Infof("Press Ctrl-C to unmount.")
 
-	if c.mountPointBrowse {
+	if browse {
 		if err := open.Start(ctrl.MountPath()); err != nil {
 			log(ctx).Errorf("unable to browse %v", err)
 		}
@@ -103,7 +122,7 @@ func (c *commandMount) run(ctx context.Context, rep repo.Repository) error {
 	// Wait until ctrl-c pressed or until the directory is unmounted.
 	ctrlCPressed := make(chan bool)
 
-	c.svc.onTerminate(func() {
+	svc.onTerminate(func() {
 		close(ctrlCPressed)
 	})
 
@@ -18,6 +18,8 @@ type commandPolicy struct {
 	delete commandPolicyDelete
 	set    commandPolicySet
 	show   commandPolicyShow
+
+	simulateRetention commandPolicySimulateRetention
 }
 
 func (c *commandPolicy) setup(svc appServices, parent commandParent) {
@@ -28,6 +30,7 @@ func (c *commandPolicy) setup(svc appServices, parent commandParent) {
 	c.delete.setup(svc, cmd)
 	c.set.setup(svc, cmd)
 	c.show.setup(svc, cmd)
+	c.simulateRetention.setup(svc, cmd)
 }
 
 type policyTargetFlags struct {
@@ -11,18 +11,30 @@
 )
 
 type commandPolicyList struct {
-	jo  jsonOutput
-	out textOutput
+	jo    jsonOutput
+	out   textOutput
+	table tableOutput[*policy.Policy]
 }
 
 func (c *commandPolicyList) setup(svc appServices, parent commandParent) {
 	cmd := parent.Command("list", "List policies.").Alias("ls")
 	c.jo.setup(svc, cmd)
+	c.table.setup(cmd, []tableColumn[*policy.Policy]{
+		{name: "id", value: func(p *policy.Policy) string { return p.ID() }},
+		{name: "target", value: func(p *policy.Policy) string { return p.Target().String() }},
+		{name: "host", value: func(p *policy.Policy) string { return p.Target().Host }},
+		{name: "user", value: func(p *policy.Policy) string { return p.Target().UserName }},
+		{name: "path", value: func(p *policy.Policy) string { return p.Target().Path }},
+	}, "id", "target")
 	c.out.setup(svc)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 }
 
 func (c *commandPolicyList) run(ctx context.Context, rep repo.Repository) error {
+	if err := c.table.validate(); err != nil {
+		return err
+	}
+
 	var jl jsonList
 
 	jl.begin(&c.jo)
@@ -37,6 +49,10 @@ func (c *commandPolicyList) run(ctx context.Context, rep repo.Repository) error
 		return policies[i].Target().String() < policies[j].Target().String()
 	})
 
+	if c.table.enabled() && !c.jo.jsonOutput {
+		return c.table.render(c.out.stdout(), policies)
+	}
+
 	for _, pol := range policies {
 		if c.jo.jsonOutput {
 			jl.emit(policy.TargetWithPolicy{ID: pol.ID(), Target: pol.Target(), Policy: pol})
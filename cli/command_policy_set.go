@@ -18,6 +18,7 @@ type commandPolicySet struct {
 	inherit []bool // not really a list, just an optional boolean
 
 	policyActionFlags
+	policyAnomalyDetectionFlags
 	policyCompressionFlags
 	policyErrorFlags
 	policyFilesFlags
@@ -34,6 +35,7 @@ func (c *commandPolicySet) setup(svc appServices, parent commandParent) {
 	cmd.Flag(inheritPolicyString, "Enable or disable inheriting policies from the parent").BoolListVar(&c.inherit)
 
 	c.policyActionFlags.setup(cmd)
+	c.policyAnomalyDetectionFlags.setup(cmd)
 	c.policyCompressionFlags.setup(cmd)
 	c.policyErrorFlags.setup(cmd)
 	c.policyFilesFlags.setup(cmd)
@@ -126,6 +128,10 @@ func (c *commandPolicySet) setPolicyFromFlags(ctx context.Context, p *policy.Pol
 		return errors.Wrap(err, "upload policy")
 	}
 
+	if err := c.setAnomalyDetectionPolicyFromFlags(ctx, &p.AnomalyDetection, changeCount); err != nil {
+		return errors.Wrap(err, "anomaly detection policy")
+	}
+
 	// It's not really a list, just optional boolean, last one wins.
 	for _, inherit := range c.inherit {
 		*changeCount++
@@ -21,6 +21,7 @@ type policyActionFlags struct {
 	policySetAfterFolderActionCommand        string
 	policySetBeforeSnapshotRootActionCommand string
 	policySetAfterSnapshotRootActionCommand  string
+	policySetSnapshotAnomalyActionCommand    string
 	policySetActionCommandTimeout            time.Duration
 	policySetActionCommandMode               string
 	policySetPersistActionScript             bool
@@ -31,6 +32,7 @@ func (c *policyActionFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("after-folder-action", "Path to after-folder action command ('none' to remove)").Default("-").PlaceHolder("COMMAND").StringVar(&c.policySetAfterFolderActionCommand)
 	cmd.Flag("before-snapshot-root-action", "Path to before-snapshot-root action command ('none' to remove or 'inherit')").Default("-").PlaceHolder("COMMAND").StringVar(&c.policySetBeforeSnapshotRootActionCommand)
 	cmd.Flag("after-snapshot-root-action", "Path to after-snapshot-root action command ('none' to remove or 'inherit')").Default("-").PlaceHolder("COMMAND").StringVar(&c.policySetAfterSnapshotRootActionCommand)
+	cmd.Flag("snapshot-anomaly-action", "Path to action command to run when anomalies are detected in a snapshot ('none' to remove or 'inherit')").Default("-").PlaceHolder("COMMAND").StringVar(&c.policySetSnapshotAnomalyActionCommand)
 	cmd.Flag("action-command-timeout", "Max time allowed for an action to run in seconds").Default("5m").DurationVar(&c.policySetActionCommandTimeout)
 	cmd.Flag("action-command-mode", "Action command mode").Default("essential").EnumVar(&c.policySetActionCommandMode, "essential", "optional", "async")
 	cmd.Flag("persist-action-script", "Persist action script").BoolVar(&c.policySetPersistActionScript)
@@ -53,6 +55,10 @@ func (c *policyActionFlags) setActionsFromFlags(ctx context.Context, p *policy.A
 		return errors.Wrap(err, "invalid after-snapshot-root-action")
 	}
 
+	if err := c.setActionCommandFromFlags(ctx, "snapshot-anomaly", &p.SnapshotAnomaly, c.policySetSnapshotAnomalyActionCommand, changeCount); err != nil {
+		return errors.Wrap(err, "invalid snapshot-anomaly-action")
+	}
+
 	return nil
 }
 
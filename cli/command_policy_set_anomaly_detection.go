@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+type policyAnomalyDetectionFlags struct {
+	ransomwareAction        string
+	minFiles                string
+	sampleFiles             string
+	changedFilesPercent     string
+	entropyIncreasePercent  string
+	extensionChangesPercent string
+	changeRateAction        string
+	maxNewMiB               string
+	maxChangedFiles         string
+}
+
+func (c *policyAnomalyDetectionFlags) setup(cmd *kingpin.CmdClause) {
+	var actions []string
+	for _, a := range policy.SupportedAnomalyActions {
+		actions = append(actions, string(a))
+	}
+
+	actions = append(actions, inheritPolicyString)
+
+	cmd.Flag("ransomware-action", "Action to take when a snapshot looks like the result of ransomware activity ('none', 'warn', 'confirm', 'notify', 'inherit')").PlaceHolder("ACTION").EnumVar(&c.ransomwareAction, actions...)
+	cmd.Flag("anomaly-min-files", "Minimum number of files in the previous snapshot required to detect anomalies").PlaceHolder("N").StringVar(&c.minFiles)
+	cmd.Flag("anomaly-sample-files", "Number of modified files whose contents are sampled to detect entropy changes").PlaceHolder("N").StringVar(&c.sampleFiles)
+	cmd.Flag("anomaly-changed-files-percent", "Percentage of modified or deleted files indicating ransomware activity").PlaceHolder("PERCENT").StringVar(&c.changedFilesPercent)
+	cmd.Flag("anomaly-entropy-increase-percent", "Percentage of sampled files becoming high-entropy indicating ransomware activity").PlaceHolder("PERCENT").StringVar(&c.entropyIncreasePercent)
+	cmd.Flag("anomaly-extension-changes-percent", "Percentage of files with changed extensions indicating ransomware activity").PlaceHolder("PERCENT").StringVar(&c.extensionChangesPercent)
+	cmd.Flag("change-rate-action", "Action to take when a snapshot exceeds the expected change rate ('none', 'warn', 'confirm', 'notify', 'inherit')").PlaceHolder("ACTION").EnumVar(&c.changeRateAction, actions...)
+	cmd.Flag("max-new-mib", "Expected maximum size of new or modified files in a single snapshot").PlaceHolder("MIB").StringVar(&c.maxNewMiB)
+	cmd.Flag("max-changed-files", "Expected maximum number of added, modified or deleted files in a single snapshot").PlaceHolder("N").StringVar(&c.maxChangedFiles)
+}
+
+func (c *policyAnomalyDetectionFlags) setAnomalyDetectionPolicyFromFlags(ctx context.Context, ap *policy.AnomalyDetectionPolicy, changeCount *int) error {
+	if err := applyAnomalyAction(ctx, "ransomware action", &ap.RansomwareAction, c.ransomwareAction, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt(ctx, "anomaly detection min files", &ap.MinFiles, c.minFiles, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt(ctx, "anomaly detection sample files", &ap.SampleFiles, c.sampleFiles, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt(ctx, "anomaly detection changed files percent", &ap.ChangedFilesPercent, c.changedFilesPercent, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt(ctx, "anomaly detection entropy increase percent", &ap.EntropyIncreasePercent, c.entropyIncreasePercent, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt(ctx, "anomaly detection extension changes percent", &ap.ExtensionChangesPercent, c.extensionChangesPercent, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyAnomalyAction(ctx, "change rate action", &ap.ChangeRateAction, c.changeRateAction, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt64MiB(ctx, "max new bytes", &ap.MaxNewBytes, c.maxNewMiB, changeCount); err != nil {
+		return err
+	}
+
+	return applyOptionalInt(ctx, "max changed files", &ap.MaxChangedFiles, c.maxChangedFiles, changeCount)
+}
+
+func applyAnomalyAction(ctx context.Context, desc string, val **policy.AnomalyAction, str string, changeCount *int) error {
+	if str == "" {
+		// not changed
+		return nil
+	}
+
+	*changeCount++
+
+	if str == inheritPolicyString {
+		log(ctx).Infof(" - resetting %q to a default value inherited from parent.", desc)
+
+		*val = nil
+
+		return nil
+	}
+
+	for _, a := range policy.SupportedAnomalyActions {
+		if string(a) == str {
+			log(ctx).Infof(" - setting %q to %v.", desc, a)
+
+			*val = policy.NewAnomalyAction(a)
+
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid %q action %q", desc, str)
+}
@@ -0,0 +1,134 @@
+package cli_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRansomwareDetection(t *testing.T) {
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", "--global"))
+	require.Contains(t, lines, " Ransomware action: none (defined for this target)")
+	require.Contains(t, lines, " Min files: 100 (defined for this target)")
+
+	td := testutil.TempDirectory(t)
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(td, fmt.Sprintf("doc%v.txt", i)), []byte(strings.Repeat("some text\n", 1000)), 0o600))
+	}
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--ransomware-action=confirm", "--anomaly-min-files=5")
+
+	lines = compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Ransomware action: confirm (defined for this target)")
+	require.Contains(t, lines, " Min files: 5 (defined for this target)")
+	require.Contains(t, lines, " Changed files percent: 50 inherited from (global)")
+
+	// encrypt and rename all files.
+	for i := 0; i < 10; i++ {
+		fname := filepath.Join(td, fmt.Sprintf("doc%v.txt", i))
+		b := make([]byte, 10000)
+		rand.Read(b)
+
+		require.NoError(t, os.WriteFile(fname+".locked", b, 0o600))
+		require.NoError(t, os.Remove(fname))
+	}
+
+	runner.SetNextStdin(strings.NewReader("n\n"))
+	e.RunAndExpectFailure(t, "snapshot", "create", td)
+
+	var manifests []*cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", td, "--json"), &manifests)
+	require.Len(t, manifests, 1)
+
+	runner.SetNextStdin(strings.NewReader("y\n"))
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", td, "--json"), &manifests)
+	require.Len(t, manifests, 2)
+	require.Empty(t, manifests[0].Anomalies)
+	require.Equal(t, []string{"possible ransomware activity: 100.0% of files modified or deleted, 100.0% of files had their extension changed"}, manifests[1].Anomalies)
+
+	lines = e.RunAndExpectSuccess(t, "snapshot", "list", td)
+	require.Contains(t, lines[len(lines)-1], "anomalous")
+
+	// with the warn action, the snapshot is saved without asking.
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--ransomware-action=warn")
+
+	for i := 0; i < 10; i++ {
+		fname := filepath.Join(td, fmt.Sprintf("doc%v.txt.locked", i))
+		require.NoError(t, os.Rename(fname, fname+"2"))
+	}
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", td, "--json"), &manifests)
+	require.Len(t, manifests, 3)
+	require.NotEmpty(t, manifests[2].Anomalies)
+}
+
+func TestChangeRateAlerts(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell command")
+	}
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+	notifyFile := filepath.Join(testutil.TempDirectory(t), "notified")
+
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file1"), []byte{1, 2, 3}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--max-changed-files=2", "--max-new-mib=1",
+		"--snapshot-anomaly-action", fmt.Sprintf(`sh -c "printenv KOPIA_SNAPSHOT_ANOMALIES > %v"`, notifyFile))
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Change rate action: notify inherited from (global)")
+	require.Contains(t, lines, " Max new bytes: 1 MB (defined for this target)")
+	require.Contains(t, lines, " Max changed files: 2 (defined for this target)")
+
+	// two changed files are expected.
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file2"), []byte{1, 2, 3}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file3"), []byte{1, 2, 3}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", td, "--force-enable-actions")
+	require.NoFileExists(t, notifyFile)
+
+	// three are not.
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file4"), []byte{1, 2, 3}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file5"), []byte{1, 2, 3}, 0o600))
+	require.NoError(t, os.Remove(filepath.Join(td, "file1")))
+	e.RunAndExpectSuccess(t, "snapshot", "create", td, "--force-enable-actions")
+
+	notified, err := os.ReadFile(notifyFile)
+	require.NoError(t, err)
+	require.Equal(t, "unexpected change rate: 3 changed files exceed the expected 2\n", string(notified))
+
+	var manifests []*cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", td, "--json"), &manifests)
+	require.Len(t, manifests, 3)
+	require.Empty(t, manifests[1].Anomalies)
+	require.Equal(t, []string{"unexpected change rate: 3 changed files exceed the expected 2"}, manifests[2].Anomalies)
+}
@@ -22,6 +22,10 @@ type policyCompressionFlags struct {
 	policySetAddNeverCompress    []string
 	policySetRemoveNeverCompress []string
 	policySetClearNeverCompress  bool
+
+	policySetAddCompressionRule    []string
+	policySetRemoveCompressionRule []string
+	policySetClearCompressionRules bool
 }
 
 func (c *policyCompressionFlags) setup(cmd *kingpin.CmdClause) {
@@ -39,6 +43,11 @@ func (c *policyCompressionFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("add-never-compress", "List of extensions to add to the never compress list").PlaceHolder("PATTERN").StringsVar(&c.policySetAddNeverCompress)
 	cmd.Flag("remove-never-compress", "List of extensions to remove from the never compress list").PlaceHolder("PATTERN").StringsVar(&c.policySetRemoveNeverCompress)
 	cmd.Flag("clear-never-compress", "Clear list of extensions in the never compress list").BoolVar(&c.policySetClearNeverCompress)
+
+	// Per-pattern compression rules.
+	cmd.Flag("add-compression-rule", "Add or replace rule selecting compression algorithm ('none' to disable) for files matching the pattern, e.g. '*.sql:zstd-better-compression'").PlaceHolder("PATTERN:ALGORITHM").StringsVar(&c.policySetAddCompressionRule)
+	cmd.Flag("remove-compression-rule", "Remove compression rule for the pattern").PlaceHolder("PATTERN").StringsVar(&c.policySetRemoveCompressionRule)
+	cmd.Flag("clear-compression-rules", "Clear all compression rules").BoolVar(&c.policySetClearCompressionRules)
 }
 
 func (c *policyCompressionFlags) setCompressionPolicyFromFlags(ctx context.Context, p *policy.CompressionPolicy, changeCount *int) error {
@@ -70,5 +79,60 @@ func (c *policyCompressionFlags) setCompressionPolicyFromFlags(ctx context.Conte
 	applyPolicyStringList(ctx, "never-compress extensions",
 		&p.NeverCompress, c.policySetAddNeverCompress, c.policySetRemoveNeverCompress, c.policySetClearNeverCompress, changeCount)
 
+	return c.applyCompressionRules(ctx, p, changeCount)
+}
+
+func (c *policyCompressionFlags) applyCompressionRules(ctx context.Context, p *policy.CompressionPolicy, changeCount *int) error {
+	if c.policySetClearCompressionRules {
+		log(ctx).Infof(" - removing all compression rules")
+
+		p.Rules = nil
+		*changeCount++
+	}
+
+	for _, pattern := range c.policySetRemoveCompressionRule {
+		var remaining []policy.CompressionRule
+
+		for _, r := range p.Rules {
+			if r.Pattern != pattern {
+				remaining = append(remaining, r)
+			}
+		}
+
+		if len(remaining) == len(p.Rules) {
+			log(ctx).Infof(" - compression rule for %q not found", pattern)
+			continue
+		}
+
+		log(ctx).Infof(" - removing compression rule for %q", pattern)
+
+		p.Rules = remaining
+		*changeCount++
+	}
+
+	for _, v := range c.policySetAddCompressionRule {
+		rule, err := policy.ParseCompressionRule(v)
+		if err != nil {
+			return errors.Wrap(err, "invalid compression rule")
+		}
+
+		log(ctx).Infof(" - setting compression algorithm for %q to %v", rule.Pattern, rule.CompressorName)
+
+		replaced := false
+
+		for i := range p.Rules {
+			if p.Rules[i].Pattern == rule.Pattern {
+				p.Rules[i] = rule
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			p.Rules = append(p.Rules, rule)
+		}
+
+		*changeCount++
+	}
+
 	return nil
 }
@@ -25,6 +25,26 @@ type policyFilesFlags struct {
 	policyOneFileSystem string
 
 	policyIgnoreCacheDirs string
+
+	// Skip junctions and mount points instead of storing them as links.
+	policyIgnoreReparsePoints string
+
+	// Users and groups whose files are included or excluded.
+	policySetAddIncludeOwner    []string
+	policySetRemoveIncludeOwner []string
+	policySetClearIncludeOwner  bool
+	policySetAddExcludeOwner    []string
+	policySetRemoveExcludeOwner []string
+	policySetClearExcludeOwner  bool
+	policySetAddIncludeGroup    []string
+	policySetRemoveIncludeGroup []string
+	policySetClearIncludeGroup  bool
+	policySetAddExcludeGroup    []string
+	policySetRemoveExcludeGroup []string
+	policySetClearExcludeGroup  bool
+
+	// Restore owners by names instead of numeric IDs.
+	policyRestoreOwnersByName string
 }
 
 func (c *policyFilesFlags) setup(cmd *kingpin.CmdClause) {
@@ -43,6 +63,24 @@ func (c *policyFilesFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("one-file-system", "Stay in parent filesystem when finding files ('true', 'false', 'inherit')").EnumVar(&c.policyOneFileSystem, booleanEnumValues...)
 
 	cmd.Flag("ignore-cache-dirs", "Ignore cache directories ('true', 'false', 'inherit')").EnumVar(&c.policyIgnoreCacheDirs, booleanEnumValues...)
+
+	cmd.Flag("ignore-reparse-points", "Skip Windows junctions and mount points instead of storing them as links ('true', 'false', 'inherit')").EnumVar(&c.policyIgnoreReparsePoints, booleanEnumValues...)
+
+	// Users and groups whose files are included or excluded.
+	cmd.Flag("add-include-owner", "Only include files owned by given users").PlaceHolder("USER|UID").StringsVar(&c.policySetAddIncludeOwner)
+	cmd.Flag("remove-include-owner", "Remove users from the list of included owners").PlaceHolder("USER|UID").StringsVar(&c.policySetRemoveIncludeOwner)
+	cmd.Flag("clear-include-owner", "Clear the list of included owners").BoolVar(&c.policySetClearIncludeOwner)
+	cmd.Flag("add-exclude-owner", "Exclude files owned by given users").PlaceHolder("USER|UID").StringsVar(&c.policySetAddExcludeOwner)
+	cmd.Flag("remove-exclude-owner", "Remove users from the list of excluded owners").PlaceHolder("USER|UID").StringsVar(&c.policySetRemoveExcludeOwner)
+	cmd.Flag("clear-exclude-owner", "Clear the list of excluded owners").BoolVar(&c.policySetClearExcludeOwner)
+	cmd.Flag("add-include-group", "Only include files owned by given groups").PlaceHolder("GROUP|GID").StringsVar(&c.policySetAddIncludeGroup)
+	cmd.Flag("remove-include-group", "Remove groups from the list of included groups").PlaceHolder("GROUP|GID").StringsVar(&c.policySetRemoveIncludeGroup)
+	cmd.Flag("clear-include-group", "Clear the list of included groups").BoolVar(&c.policySetClearIncludeGroup)
+	cmd.Flag("add-exclude-group", "Exclude files owned by given groups").PlaceHolder("GROUP|GID").StringsVar(&c.policySetAddExcludeGroup)
+	cmd.Flag("remove-exclude-group", "Remove groups from the list of excluded groups").PlaceHolder("GROUP|GID").StringsVar(&c.policySetRemoveExcludeGroup)
+	cmd.Flag("clear-exclude-group", "Clear the list of excluded groups").BoolVar(&c.policySetClearExcludeGroup)
+
+	cmd.Flag("restore-owners-by-name", "Restore owners by user and group names captured at snapshot time instead of numeric IDs ('true', 'false', 'inherit')").EnumVar(&c.policyRestoreOwnersByName, booleanEnumValues...)
 }
 
 func (c *policyFilesFlags) setFilesPolicyFromFlags(ctx context.Context, fp *policy.FilesPolicy, changeCount *int) error {
@@ -52,10 +90,22 @@ func (c *policyFilesFlags) setFilesPolicyFromFlags(ctx context.Context, fp *poli
 
 	applyPolicyStringList(ctx, "dot-ignore filenames", &fp.DotIgnoreFiles, c.policySetAddDotIgnore, c.policySetRemoveDotIgnore, c.policySetClearDotIgnore, changeCount)
 	applyPolicyStringList(ctx, "ignore rules", &fp.IgnoreRules, c.policySetAddIgnore, c.policySetRemoveIgnore, c.policySetClearIgnore, changeCount)
+	applyPolicyStringList(ctx, "included owners", &fp.IncludeOwners, c.policySetAddIncludeOwner, c.policySetRemoveIncludeOwner, c.policySetClearIncludeOwner, changeCount)
+	applyPolicyStringList(ctx, "excluded owners", &fp.ExcludeOwners, c.policySetAddExcludeOwner, c.policySetRemoveExcludeOwner, c.policySetClearExcludeOwner, changeCount)
+	applyPolicyStringList(ctx, "included groups", &fp.IncludeGroups, c.policySetAddIncludeGroup, c.policySetRemoveIncludeGroup, c.policySetClearIncludeGroup, changeCount)
+	applyPolicyStringList(ctx, "excluded groups", &fp.ExcludeGroups, c.policySetAddExcludeGroup, c.policySetRemoveExcludeGroup, c.policySetClearExcludeGroup, changeCount)
 
 	if err := applyPolicyBoolPtr(ctx, "ignore cache dirs", &fp.IgnoreCacheDirectories, c.policyIgnoreCacheDirs, changeCount); err != nil {
 		return err
 	}
 
+	if err := applyPolicyBoolPtr(ctx, "ignore reparse points", &fp.IgnoreReparsePoints, c.policyIgnoreReparsePoints, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyPolicyBoolPtr(ctx, "restore owners by name", &fp.RestoreOwnersByName, c.policyRestoreOwnersByName, changeCount); err != nil {
+		return err
+	}
+
 	return applyPolicyBoolPtr(ctx, "one filesystem", &fp.OneFileSystem, c.policyOneFileSystem, changeCount)
 }
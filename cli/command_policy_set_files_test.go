@@ -0,0 +1,118 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/ownername"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSetOwnerFilesPolicy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file owners are not supported on Windows")
+	}
+
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "a.txt"), []byte("aaa"), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(td, "sub"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "sub", "b.txt"), []byte("bbb"), 0o600))
+
+	uid := strconv.Itoa(os.Getuid())
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--add-exclude-owner", uid)
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Exclude files owned by users: "+uid+" (defined for this target)")
+
+	var manifests []*cli.SnapshotManifest
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", td, "--json"), &manifests)
+	require.Len(t, manifests, 1)
+	require.EqualValues(t, 2, manifests[0].Stats.ExcludedByOwnerCount)
+	require.EqualValues(t, 0, manifests[0].Stats.TotalFileCount)
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--clear-exclude-owner", "--add-include-owner", uid)
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", td, "--json"), &manifests)
+	require.Len(t, manifests, 2)
+	require.EqualValues(t, 0, manifests[1].Stats.ExcludedByOwnerCount)
+	require.EqualValues(t, 2, manifests[1].Stats.TotalFileCount)
+}
+
+func TestSetRestoreOwnersByNamePolicy(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	td := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "a.txt"), []byte("aaa"), 0o600))
+
+	lines := compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Restore owners by name: false inherited from (global)")
+
+	e.RunAndExpectSuccess(t, "policy", "set", "--global", "--restore-owners-by-name=true")
+
+	lines = compressSpaces(e.RunAndExpectSuccess(t, "policy", "show", td))
+	require.Contains(t, lines, " Restore owners by name: true inherited from (global)")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", td)
+
+	src, err := localfs.NewEntry(filepath.Join(td, "a.txt"))
+	require.NoError(t, err)
+
+	// simulate restoring on a system where the captured owner names resolve to different IDs.
+	const mappedUID, mappedGID = 4242, 4343
+
+	if runtime.GOOS != "windows" {
+		require.NotEmpty(t, src.Owner().UserName)
+		require.NotEmpty(t, src.Owner().GroupName)
+
+		ownername.SetIDsForTesting(src.Owner().UserName, mappedUID, src.Owner().GroupName, mappedGID)
+		t.Cleanup(ownername.ResetForTesting)
+	}
+
+	// restore target governed by the policy, --map-owners-by-name overrides it in both directions.
+	out1 := filepath.Join(testutil.TempDirectory(t), "out1")
+	out2 := filepath.Join(testutil.TempDirectory(t), "out2")
+	out3 := filepath.Join(testutil.TempDirectory(t), "out3")
+
+	e.RunAndExpectSuccess(t, "restore", td, out1, "--snapshot-time=latest")
+	e.RunAndExpectSuccess(t, "restore", td, out2, "--snapshot-time=latest", "--no-map-owners-by-name")
+	e.RunAndExpectSuccess(t, "restore", td, out3, "--snapshot-time=latest", "--map-owners-by-name")
+
+	if runtime.GOOS == "windows" || os.Getuid() != 0 {
+		// changing owners to other users requires root.
+		return
+	}
+
+	cases := map[string]fs.OwnerInfo{
+		out1: {UserID: mappedUID, GroupID: mappedGID},
+		out2: {UserID: src.Owner().UserID, GroupID: src.Owner().GroupID},
+		out3: {UserID: mappedUID, GroupID: mappedGID},
+	}
+
+	for out, want := range cases {
+		restored, err := localfs.NewEntry(filepath.Join(out, "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, want.UserID, restored.Owner().UserID, out)
+		require.Equal(t, want.GroupID, restored.Owner().GroupID, out)
+	}
+}
@@ -476,3 +476,41 @@ func TestSetSchedulingPolicyFromFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestSetCompressionRulesFromFlags(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	var p policy.CompressionPolicy
+
+	changeCount := 0
+
+	c := policyCompressionFlags{
+		policySetAddCompressionRule: []string{"*.sql:zstd-fastest", "*.mp4:none", "*.sql:zstd-better-compression"},
+	}
+
+	require.NoError(t, c.setCompressionPolicyFromFlags(ctx, &p, &changeCount))
+	require.Equal(t, 3, changeCount)
+	require.Equal(t, []policy.CompressionRule{
+		{Pattern: "*.sql", CompressorName: "zstd-better-compression"},
+		{Pattern: "*.mp4", CompressorName: "none"},
+	}, p.Rules)
+
+	c = policyCompressionFlags{
+		policySetRemoveCompressionRule: []string{"*.sql", "*.no-such-rule"},
+	}
+
+	require.NoError(t, c.setCompressionPolicyFromFlags(ctx, &p, &changeCount))
+	require.Equal(t, 4, changeCount)
+	require.Equal(t, []policy.CompressionRule{{Pattern: "*.mp4", CompressorName: "none"}}, p.Rules)
+
+	c = policyCompressionFlags{
+		policySetAddCompressionRule: []string{"*.sql:no-such-compressor"},
+	}
+
+	require.Error(t, c.setCompressionPolicyFromFlags(ctx, &p, &changeCount))
+
+	c = policyCompressionFlags{policySetClearCompressionRules: true}
+
+	require.NoError(t, c.setCompressionPolicyFromFlags(ctx, &p, &changeCount))
+	require.Empty(t, p.Rules)
+}
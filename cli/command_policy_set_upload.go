@@ -4,6 +4,7 @@
 	"context"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/snapshot/policy"
 )
@@ -12,12 +13,20 @@ type policyUploadFlags struct {
 	maxParallelUploads            string
 	maxParallelFileReads          string
 	parallelizeUploadAboveSizeMiB string
+	recordDeletedEntries          string
+	maxSnapshotSizeMiB            string
+	maxSnapshotSizeWarnOnly       string
+	changeDetection               string
 }
 
 func (c *policyUploadFlags) setup(cmd *kingpin.CmdClause) {
 	cmd.Flag("max-parallel-file-reads", "Maximum number of parallel file reads").StringVar(&c.maxParallelFileReads)
 	cmd.Flag("max-parallel-snapshots", "Maximum number of parallel snapshots (server, KopiaUI only)").StringVar(&c.maxParallelUploads)
 	cmd.Flag("parallel-upload-above-size-mib", "Use parallel uploads above size").StringVar(&c.parallelizeUploadAboveSizeMiB)
+	cmd.Flag("record-deleted-entries", "Record entries deleted since the previous snapshot in directory objects ('true', 'false', 'inherit')").EnumVar(&c.recordDeletedEntries, booleanEnumValues...)
+	cmd.Flag("max-snapshot-size-mib", "Maximum expected snapshot size, checked before uploading").StringVar(&c.maxSnapshotSizeMiB)
+	cmd.Flag("max-snapshot-size-warn-only", "Only warn instead of failing when snapshot is larger than maximum size ('true', 'false', 'inherit')").EnumVar(&c.maxSnapshotSizeWarnOnly, booleanEnumValues...)
+	cmd.Flag("change-detection", "Attributes used to detect changed files ('mtime-size', 'mtime', 'ctime', 'hash', 'inherit')").PlaceHolder("MODE").EnumVar(&c.changeDetection, append(append([]string(nil), policy.ChangeDetectionModeStrings...), inheritPolicyString)...)
 }
 
 func (c *policyUploadFlags) setUploadPolicyFromFlags(ctx context.Context, up *policy.UploadPolicy, changeCount *int) error {
@@ -29,5 +38,51 @@ func (c *policyUploadFlags) setUploadPolicyFromFlags(ctx context.Context, up *po
 		return err
 	}
 
-	return applyOptionalInt64MiB(ctx, "parallel upload above size", &up.ParallelUploadAboveSize, c.parallelizeUploadAboveSizeMiB, changeCount)
+	if err := applyOptionalInt64MiB(ctx, "parallel upload above size", &up.ParallelUploadAboveSize, c.parallelizeUploadAboveSizeMiB, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyPolicyBoolPtr(ctx, "record deleted entries", &up.RecordDeletedEntries, c.recordDeletedEntries, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyOptionalInt64MiB(ctx, "max snapshot size", &up.MaxSnapshotSize, c.maxSnapshotSizeMiB, changeCount); err != nil {
+		return err
+	}
+
+	if err := applyPolicyBoolPtr(ctx, "max snapshot size warn only", &up.MaxSnapshotSizeWarnOnly, c.maxSnapshotSizeWarnOnly, changeCount); err != nil {
+		return err
+	}
+
+	return applyPolicyChangeDetectionMode(ctx, "change detection", &up.ChangeDetection, c.changeDetection, changeCount)
+}
+
+func applyPolicyChangeDetectionMode(ctx context.Context, desc string, val **policy.ChangeDetectionMode, str string, changeCount *int) error {
+	if str == "" {
+		// not changed
+		return nil
+	}
+
+	if str == inheritPolicyString || str == defaultPolicyString {
+		*changeCount++
+
+		log(ctx).Infof(" - resetting %q to a default value inherited from parent.", desc)
+
+		*val = nil
+
+		return nil
+	}
+
+	mode, ok := policy.ParseChangeDetectionMode(str)
+	if !ok {
+		return errors.Errorf("invalid %q mode %q", desc, str)
+	}
+
+	*changeCount++
+
+	log(ctx).Infof(" - setting %q to %v.", desc, mode)
+
+	*val = &mode
+
+	return nil
 }
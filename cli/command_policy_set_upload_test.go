@@ -20,6 +20,9 @@ func TestSetUploadPolicy(t *testing.T) {
 	require.Contains(t, lines, " Max parallel snapshots (server/UI): 1 (defined for this target)")
 	require.Contains(t, lines, " Max parallel file reads: - (defined for this target)")
 	require.Contains(t, lines, " Parallel upload above size: 2.1 GB (defined for this target)")
+	require.Contains(t, lines, " Record deleted entries: false (defined for this target)")
+	require.Contains(t, lines, " Max snapshot size: - (defined for this target)")
+	require.Contains(t, lines, " Only warn when snapshot exceeds max size: false (defined for this target)")
 
 	// make some directory we'll be setting policy on
 	td := testutil.TempDirectory(t)
@@ -39,6 +42,21 @@ func TestSetUploadPolicy(t *testing.T) {
 	require.Contains(t, lines, " Max parallel file reads: 33 inherited from (global)")
 	require.Contains(t, lines, " Parallel upload above size: 4.3 GB inherited from (global)")
 
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--record-deleted-entries=true")
+
+	lines = e.RunAndExpectSuccess(t, "policy", "show", td)
+	lines = compressSpaces(lines)
+
+	require.Contains(t, lines, " Record deleted entries: true (defined for this target)")
+
+	e.RunAndExpectSuccess(t, "policy", "set", td, "--max-snapshot-size-mib=100", "--max-snapshot-size-warn-only=true")
+
+	lines = e.RunAndExpectSuccess(t, "policy", "show", td)
+	lines = compressSpaces(lines)
+
+	require.Contains(t, lines, " Max snapshot size: 104.9 MB (defined for this target)")
+	require.Contains(t, lines, " Only warn when snapshot exceeds max size: true (defined for this target)")
+
 	e.RunAndExpectSuccess(t, "policy", "set", "--global", "--max-parallel-snapshots=default", "--max-parallel-file-reads=default", "--parallel-upload-above-size-mib=default")
 
 	lines = e.RunAndExpectSuccess(t, "policy", "show", td)
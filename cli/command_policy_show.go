@@ -131,6 +131,8 @@ func printPolicy(out *textOutput, p *policy.Policy, def *policy.Definition) {
 	rows = appendOSSnapshotPolicyRows(rows, p, def)
 	rows = append(rows, policyTableRow{})
 	rows = appendLoggingPolicyRows(rows, p, def)
+	rows = append(rows, policyTableRow{})
+	rows = appendAnomalyDetectionPolicyRows(rows, p, def)
 
 	out.printStdout("Policy for %v:\n\n%v\n", p.Target(), alignedPolicyTableRows(rows))
 }
@@ -205,9 +207,34 @@ func appendFilesPolicyValue(items []policyTableRow, p *policy.Policy, def *polic
 		definitionPointToString(p.Target(), def.FilesPolicy.OneFileSystem),
 	})
 
+	items = append(items, policyTableRow{
+		"  Ignore junctions and mount points:",
+		boolToString(p.FilesPolicy.IgnoreReparsePoints.OrDefault(false)),
+		definitionPointToString(p.Target(), def.FilesPolicy.IgnoreReparsePoints),
+	})
+
+	items = appendOwnerListRows(items, "  Only include files owned by users:", p.FilesPolicy.IncludeOwners, p.Target(), def.FilesPolicy.IncludeOwners)
+	items = appendOwnerListRows(items, "  Exclude files owned by users:", p.FilesPolicy.ExcludeOwners, p.Target(), def.FilesPolicy.ExcludeOwners)
+	items = appendOwnerListRows(items, "  Only include files owned by groups:", p.FilesPolicy.IncludeGroups, p.Target(), def.FilesPolicy.IncludeGroups)
+	items = appendOwnerListRows(items, "  Exclude files owned by groups:", p.FilesPolicy.ExcludeGroups, p.Target(), def.FilesPolicy.ExcludeGroups)
+
+	items = append(items, policyTableRow{
+		"  Restore owners by name:",
+		boolToString(p.FilesPolicy.RestoreOwnersByName.OrDefault(false)),
+		definitionPointToString(p.Target(), def.FilesPolicy.RestoreOwnersByName),
+	})
+
 	return items
 }
 
+func appendOwnerListRows(items []policyTableRow, label string, owners []string, target snapshot.SourceInfo, def snapshot.SourceInfo) []policyTableRow {
+	if len(owners) == 0 {
+		return items
+	}
+
+	return append(items, policyTableRow{label, strings.Join(owners, ", "), definitionPointToString(target, def)})
+}
+
 func appendErrorHandlingPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
 	return append(rows,
 		policyTableRow{"Error handling policy:", "", ""},
@@ -273,6 +300,10 @@ func appendUploadPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy
 		policyTableRow{"  Max parallel snapshots (server/UI):", valueOrNotSet(p.UploadPolicy.MaxParallelSnapshots), definitionPointToString(p.Target(), def.UploadPolicy.MaxParallelSnapshots)},
 		policyTableRow{"  Max parallel file reads:", valueOrNotSet(p.UploadPolicy.MaxParallelFileReads), definitionPointToString(p.Target(), def.UploadPolicy.MaxParallelFileReads)},
 		policyTableRow{"  Parallel upload above size:", valueOrNotSetOptionalInt64Bytes(p.UploadPolicy.ParallelUploadAboveSize), definitionPointToString(p.Target(), def.UploadPolicy.ParallelUploadAboveSize)},
+		policyTableRow{"  Record deleted entries:", boolToString(p.UploadPolicy.RecordDeletedEntries.OrDefault(false)), definitionPointToString(p.Target(), def.UploadPolicy.RecordDeletedEntries)},
+		policyTableRow{"  Max snapshot size:", valueOrNotSetOptionalInt64Bytes(p.UploadPolicy.MaxSnapshotSize), definitionPointToString(p.Target(), def.UploadPolicy.MaxSnapshotSize)},
+		policyTableRow{"  Only warn when snapshot exceeds max size:", boolToString(p.UploadPolicy.MaxSnapshotSizeWarnOnly.OrDefault(false)), definitionPointToString(p.Target(), def.UploadPolicy.MaxSnapshotSizeWarnOnly)},
+		policyTableRow{"  Change detection:", p.UploadPolicy.ChangeDetection.OrDefault(policy.ChangeDetectionModTimeAndSize).String(), definitionPointToString(p.Target(), def.UploadPolicy.ChangeDetection)},
 	)
 }
 
@@ -335,7 +366,7 @@ func appendSchedulingPolicyRows(rows []policyTableRow, p *policy.Policy, def *po
 func appendCompressionPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
 	if p.CompressionPolicy.CompressorName == "" || p.CompressionPolicy.CompressorName == "none" {
 		rows = append(rows, policyTableRow{"Compression disabled.", "", ""})
-		return rows
+		return appendCompressionRuleRows(rows, p, def)
 	}
 
 	rows = append(rows,
@@ -383,6 +414,23 @@ func appendCompressionPolicyRows(rows []policyTableRow, p *policy.Policy, def *p
 		rows = append(rows, policyTableRow{"  Compress files of all sizes.", "", ""})
 	}
 
+	return appendCompressionRuleRows(rows, p, def)
+}
+
+func appendCompressionRuleRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
+	if len(p.CompressionPolicy.Rules) == 0 {
+		return rows
+	}
+
+	rows = append(rows, policyTableRow{
+		"  Compression rules (first match wins):", "",
+		definitionPointToString(p.Target(), def.CompressionPolicy.Rules),
+	})
+
+	for _, r := range p.CompressionPolicy.Rules {
+		rows = append(rows, policyTableRow{"    " + r.Pattern, string(r.CompressorName), ""})
+	}
+
 	return rows
 }
 
@@ -404,6 +452,13 @@ func appendActionsPolicyRows(rows []policyTableRow, p *policy.Policy, def *polic
 		anyActions = true
 	}
 
+	if h := p.Actions.SnapshotAnomaly; h != nil {
+		rows = append(rows, policyTableRow{"Run command on snapshot anomaly:", "", definitionPointToString(p.Target(), def.Actions.SnapshotAnomaly)})
+		rows = appendActionCommandRows(rows, h)
+
+		anyActions = true
+	}
+
 	if h := p.Actions.BeforeFolder; h != nil {
 		rows = append(rows, policyTableRow{"Run command before this folder:", "", "(non-inheritable)"})
 		rows = appendActionCommandRows(rows, h)
@@ -425,6 +480,23 @@ func appendActionsPolicyRows(rows []policyTableRow, p *policy.Policy, def *polic
 	return rows
 }
 
+func appendAnomalyDetectionPolicyRows(rows []policyTableRow, p *policy.Policy, def *policy.Definition) []policyTableRow {
+	ap := p.AnomalyDetection
+
+	return append(rows,
+		policyTableRow{"Anomaly detection:", "", ""},
+		policyTableRow{"  Ransomware action:", string(ap.RansomwareAction.OrDefault(policy.AnomalyActionNone)), definitionPointToString(p.Target(), def.AnomalyDetection.RansomwareAction)},
+		policyTableRow{"  Min files:", valueOrNotSet(ap.MinFiles), definitionPointToString(p.Target(), def.AnomalyDetection.MinFiles)},
+		policyTableRow{"  Sampled files:", valueOrNotSet(ap.SampleFiles), definitionPointToString(p.Target(), def.AnomalyDetection.SampleFiles)},
+		policyTableRow{"  Changed files percent:", valueOrNotSet(ap.ChangedFilesPercent), definitionPointToString(p.Target(), def.AnomalyDetection.ChangedFilesPercent)},
+		policyTableRow{"  Entropy increase percent:", valueOrNotSet(ap.EntropyIncreasePercent), definitionPointToString(p.Target(), def.AnomalyDetection.EntropyIncreasePercent)},
+		policyTableRow{"  Extension changes percent:", valueOrNotSet(ap.ExtensionChangesPercent), definitionPointToString(p.Target(), def.AnomalyDetection.ExtensionChangesPercent)},
+		policyTableRow{"  Change rate action:", string(ap.ChangeRateAction.OrDefault(policy.AnomalyActionNone)), definitionPointToString(p.Target(), def.AnomalyDetection.ChangeRateAction)},
+		policyTableRow{"  Max new bytes:", valueOrNotSetOptionalInt64Bytes(ap.MaxNewBytes), definitionPointToString(p.Target(), def.AnomalyDetection.MaxNewBytes)},
+		policyTableRow{"  Max changed files:", valueOrNotSet(ap.MaxChangedFiles), definitionPointToString(p.Target(), def.AnomalyDetection.MaxChangedFiles)},
+	)
+}
+
 func appendActionCommandRows(rows []policyTableRow, h *policy.ActionCommand) []policyTableRow {
 	if h.Script != "" {
 		rows = append(rows,
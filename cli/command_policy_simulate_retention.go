@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+type commandPolicySimulateRetention struct {
+	policyTargetFlags
+
+	startTime string
+	duration  time.Duration
+	interval  time.Duration
+
+	out textOutput
+}
+
+func (c *commandPolicySimulateRetention) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("simulate-retention", "Simulate snapshots taken periodically over a long period of time and show which of them are retained by the effective retention policy.")
+	c.policyTargetFlags.setup(cmd)
+	cmd.Flag("start-time", "Start time of the simulation (defaults to current time)").PlaceHolder(time.RFC3339).StringVar(&c.startTime)
+	cmd.Flag("duration", "Simulated time period").Default("8760h").DurationVar(&c.duration)
+	cmd.Flag("interval", "Interval between simulated snapshots").Default("1h").DurationVar(&c.interval)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandPolicySimulateRetention) run(ctx context.Context, rep repo.Repository) error {
+	targets, err := c.policyTargets(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	startTime := rep.Time()
+
+	if c.startTime != "" {
+		startTime, err = time.Parse(time.RFC3339, c.startTime)
+		if err != nil {
+			return errors.Wrap(err, "invalid start time")
+		}
+	}
+
+	for _, target := range targets {
+		effective, _, _, err := policy.GetEffectivePolicy(ctx, rep, target)
+		if err != nil {
+			return errors.Wrapf(err, "can't get effective policy for %q", target)
+		}
+
+		retained, err := effective.RetentionPolicy.SimulateRetention(policy.RetentionSimulationOptions{
+			StartTime:        startTime,
+			Duration:         c.duration,
+			SnapshotInterval: c.interval,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "unable to simulate retention for %q", target)
+		}
+
+		c.out.printStdout("Retention of snapshots of %v taken every %v for %v:\n", target, c.interval, c.duration)
+
+		for _, m := range retained {
+			c.out.printStdout("  %v %v\n", c.out.formatTimestamp(m.StartTime.ToTime()), strings.Join(policy.CompactRetentionReasons(m.RetentionReasons), ","))
+		}
+
+		c.out.printStdout("Retained %v snapshots.\n", len(retained))
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestPolicySimulateRetention(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	e.RunAndExpectSuccess(t, "policy", "set", "--global", "--keep-latest=2", "--keep-hourly=0", "--keep-daily=3", "--keep-weekly=0", "--keep-monthly=0", "--keep-annual=0")
+
+	lines := e.RunAndExpectSuccess(t, "policy", "simulate-retention", "--global", "--start-time=2020-01-01T00:00:00Z", "--duration=720h", "--interval=6h", "--timezone=utc")
+	require.Equal(t, []string{
+		"Retention of snapshots of (global) taken every 6h0m0s for 720h0m0s:",
+		"  2020-01-31 00:00:00 UTC latest-1,daily-1",
+		"  2020-01-30 18:00:00 UTC latest-2,daily-2",
+		"  2020-01-29 18:00:00 UTC daily-3",
+		"Retained 3 snapshots.",
+	}, lines)
+
+	e.RunAndExpectFailure(t, "policy", "simulate-retention", "--global", "--interval=0s")
+}
+
+func TestDeterministicTime(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir, "--deterministic-time=2020-01-01T00:00:00Z", "--deterministic-time-step=1m")
+	e.RunAndExpectFailure(t, "snapshot", "list", "--deterministic-time=invalid")
+
+	// all times are derived from the simulated clock.
+	out := strings.Join(e.RunAndExpectSuccess(t, "snapshot", "list", "--json", dir), "\n")
+	require.Regexp(t, `"startTime":"2020-01-01T00:\d\d:00Z"`, out)
+	require.Regexp(t, `"endTime":"2020-01-01T00:\d\d:00Z"`, out)
+}
@@ -4,10 +4,15 @@ type commandRepository struct {
 	connect          commandRepositoryConnect
 	create           commandRepositoryCreate
 	disconnect       commandRepositoryDisconnect
+	linked           commandRepositoryLinked
+	open             commandRepositoryOpen
 	repair           commandRepositoryRepair
 	setClient        commandRepositorySetClient
 	setParameters    commandRepositorySetParameters
+	shard            commandRepositoryShard
 	changePassword   commandRepositoryChangePassword
+	changeKey        commandRepositoryChangeEncryptionKey
+	rotateShares     commandRepositoryRotateShares
 	status           commandRepositoryStatus
 	syncTo           commandRepositorySyncTo
 	throttle         commandRepositoryThrottle
@@ -21,13 +26,18 @@ func (c *commandRepository) setup(svc advancedAppServices, parent commandParent)
 	c.connect.setup(svc, cmd)
 	c.create.setup(svc, cmd)
 	c.disconnect.setup(svc, cmd)
+	c.linked.setup(svc, cmd)
+	c.open.setup(svc, cmd)
 	c.repair.setup(svc, cmd)
 	c.setClient.setup(svc, cmd)
 	c.setParameters.setup(svc, cmd)
+	c.shard.setup(svc, cmd)
 	c.status.setup(svc, cmd)
 	c.syncTo.setup(svc, cmd)
 	c.throttle.setup(svc, cmd)
 	c.changePassword.setup(svc, cmd)
+	c.changeKey.setup(svc, cmd)
+	c.rotateShares.setup(svc, cmd)
 	c.validateProvider.setup(svc, cmd)
 	c.upgrade.setup(svc, cmd)
 }
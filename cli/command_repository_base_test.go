@@ -0,0 +1,85 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryLinkedToBase(t *testing.T) {
+	runner := testenv.NewInProcRunner(t)
+	base := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	linked := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	srcDir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("aaa"), 0o600))
+
+	base.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", base.RepoDir)
+	base.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	baseConfigFile := filepath.Join(base.ConfigDir, ".kopia.config")
+
+	linked.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", linked.RepoDir, "--base-repository-config", baseConfigFile)
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, linked.RunAndExpectSuccess(t, "snapshot", "create", srcDir, "--json"), &man)
+
+	// all contents have been referenced from the base repository.
+	require.Empty(t, linked.RunAndExpectSuccess(t, "blob", "list", "--prefix=p"))
+	require.Equal(t, []string{"aaa"}, linked.RunAndExpectSuccess(t, "show", man.RootObjectID().String()+"/a.txt"))
+	linked.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+
+	// new contents are uploaded to the linked repository.
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "b.txt"), []byte("bbb"), 0o600))
+	linked.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+	require.NotEmpty(t, linked.RunAndExpectSuccess(t, "blob", "list", "--prefix=p"))
+	linked.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+	linked.RunAndExpectSuccess(t, "content", "verify", "--full")
+
+	// maintenance of the linked repository does not touch the base repository.
+	linked.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+	linked.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+	base.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+
+	// base repository keeps pack blobs referenced by the linked repository even after its snapshots are gone.
+	base.RunAndExpectSuccess(t, "snapshot", "delete", "--all-snapshots-for-source", srcDir, "--delete")
+	base.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+	base.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+	require.NotEmpty(t, base.RunAndExpectSuccess(t, "blob", "list", "--prefix=p"))
+	linked.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+
+	var linkedRepos []*repo.LinkedRepository
+
+	testutil.MustParseJSONLines(t, base.RunAndExpectSuccess(t, "repo", "linked", "list", "--json"), &linkedRepos)
+	require.Len(t, linkedRepos, 1)
+
+	linkedID := linkedRepos[0].UniqueID
+
+	// keys of the base repository are not stored in the linked repository, so referenced contents
+	// can't be read without it.
+	linked.RunAndExpectSuccess(t, "repo", "disconnect")
+	linked.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", linked.RepoDir)
+	linked.RunAndExpectFailure(t, "show", man.RootObjectID().String()+"/a.txt")
+
+	// repositories which have not been linked when created can't be used with the base.
+	other := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	other.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", other.RepoDir)
+	other.RunAndExpectSuccess(t, "repo", "disconnect")
+	other.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", other.RepoDir, "--base-repository-config", baseConfigFile)
+
+	// after unlinking, maintenance of the base repository deletes its unreferenced pack blobs again.
+	base.RunAndExpectFailure(t, "repo", "linked", "unlink", linkedID)
+	base.RunAndExpectFailure(t, "repo", "linked", "unlink", "0123456789abcdef", "--unlink")
+	base.RunAndExpectSuccess(t, "repo", "linked", "unlink", linkedID, "--unlink")
+	require.Empty(t, base.RunAndExpectSuccess(t, "repo", "linked", "list"))
+	base.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
+	require.Empty(t, base.RunAndExpectSuccess(t, "blob", "list", "--prefix=p"))
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+type commandRepositoryChangeEncryptionKey struct {
+	status              bool
+	destroyPreviousKeys bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandRepositoryChangeEncryptionKey) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("change-encryption-key", "Change repository master key. Metadata is re-encrypted immediately, while data contents are re-encrypted in batches during full maintenance.")
+	cmd.Flag("status", "Show the progress of re-encryption using the current key").BoolVar(&c.status)
+	cmd.Flag("destroy-previous-keys", "Destroy previous master keys after all contents have been re-encrypted").BoolVar(&c.destroyPreviousKeys)
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryChangeEncryptionKey) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	switch {
+	case c.status:
+		return c.showStatus(ctx, rep)
+
+	case c.destroyPreviousKeys:
+		if err := maintenance.DestroyPreviousEncryptionKeys(ctx, rep); err != nil {
+			return errors.Wrap(err, "unable to destroy previous encryption keys")
+		}
+
+		log(ctx).Infof("Previous encryption keys have been destroyed.")
+
+		return nil
+
+	default:
+		return c.changeKey(ctx, rep)
+	}
+}
+
+func (c *commandRepositoryChangeEncryptionKey) changeKey(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	if err := repo.ChangeEncryptionKey(ctx, rep); err != nil {
+		return errors.Wrap(err, "unable to change encryption key")
+	}
+
+	log(ctx).Infof("Encryption key has been changed, re-encrypting metadata...")
+
+	// re-encrypt all metadata right away, including contents written recently.
+	safety := maintenance.SafetyFull
+	safety.RewriteMinAge = 0
+
+	if err := maintenance.ReencryptContents(ctx, rep, &maintenance.ReencryptContentsOptions{
+		MetadataOnly: true,
+	}, safety); err != nil {
+		return errors.Wrap(err, "unable to re-encrypt metadata")
+	}
+
+	log(ctx).Infof("NOTE: Remaining contents will be re-encrypted during full maintenance. Once it's complete, destroy previous keys using 'kopia repository change-encryption-key --destroy-previous-keys'.")
+
+	return nil
+}
+
+func (c *commandRepositoryChangeEncryptionKey) showStatus(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	usage, err := maintenance.GetEncryptionKeyUsage(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to get encryption key usage")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(usage))
+		return nil
+	}
+
+	for _, u := range usage {
+		var suffix string
+
+		switch {
+		case u.Current:
+			suffix = " (current)"
+		case u.Retired:
+			suffix = " (retired, can be destroyed)"
+		}
+
+		if u.RotatedTime != nil {
+			suffix += fmt.Sprintf(" rotated %v", c.out.formatTimestamp(*u.RotatedTime))
+		}
+
+		c.out.printStdout("Key %v%v: %v contents (%v) in %v packs, %v index blobs, %v log blobs\n", u.KeyID, suffix, u.ContentCount, units.BytesString(u.ContentBytes), u.PackBlobCount, u.IndexBlobCount, u.LogBlobCount)
+	}
+
+	if len(usage) > 1 {
+		c.out.printStdout("Re-encryption progress: %.1f%%\n", maintenance.ReencryptionProgress(usage))
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryChangeEncryptionKey(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	env.RunAndExpectSuccess(t, "repo", "change-encryption-key")
+	require.Contains(t, env.RunAndExpectSuccess(t, "repo", "status"), "Encryption key ID:   1 (1 previous keys pending re-encryption)")
+
+	var usage []maintenance.EncryptionKeyUsage
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "repo", "change-encryption-key", "--status", "--json"), &usage)
+	require.Len(t, usage, 2)
+	require.False(t, usage[0].Current)
+	require.True(t, usage[1].Current)
+	require.NotZero(t, usage[1].ContentCount, "metadata should be re-encrypted immediately")
+	require.NotNil(t, usage[0].RotatedTime)
+	require.False(t, usage[0].Retired)
+
+	out := env.RunAndExpectSuccess(t, "repo", "change-encryption-key", "--status")
+	mustGetLineContaining(t, out, "Key 1 (current)")
+	mustGetLineContaining(t, out, "Re-encryption progress:")
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	env.RunAndExpectSuccess(t, "snapshot", "verify", "--verify-files-percent=100")
+
+	// other clients may still be using the previous key.
+	env.RunAndExpectFailure(t, "repo", "change-encryption-key", "--destroy-previous-keys")
+}
@@ -20,6 +20,7 @@ func (c *commandRepositoryChangePassword) setup(svc advancedAppServices, parent
 
 	c.svc = svc
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
+	svc.runLocally(cmd, nil)
 }
 
 func (c *commandRepositoryChangePassword) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
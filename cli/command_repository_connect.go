@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"path/filepath"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
@@ -57,9 +58,14 @@ type connectOptions struct {
 	connectPermissiveCacheLoading bool
 	connectDescription            string
 	connectEnableActions          bool
+	connectAuditLog               bool
 
 	formatBlobCacheDuration time.Duration
 	disableFormatBlobCache  bool
+
+	baseRepositoryConfigFile string
+
+	isLowMemory func() bool
 }
 
 func (c *connectOptions) setup(svc appServices, cmd *kingpin.CmdClause) {
@@ -71,6 +77,7 @@ func (c *connectOptions) setup(svc appServices, cmd *kingpin.CmdClause) {
 	c.contentCacheSizeMB = 5000
 	c.metadataCacheSizeMB = 5000
 	c.cacheSizeFlags.setup(cmd)
+	c.isLowMemory = svc.IsLowMemory
 
 	cmd.Flag("override-hostname", "Override hostname used by this repository connection").Hidden().StringVar(&c.connectHostname)
 	cmd.Flag("override-username", "Override username used by this repository connection").Hidden().StringVar(&c.connectUsername)
@@ -79,8 +86,10 @@ func (c *connectOptions) setup(svc appServices, cmd *kingpin.CmdClause) {
 	cmd.Flag("permissive-cache-loading", "Do not fail when loading bad cache index entries.  Repository must be opened in read-only mode").Hidden().BoolVar(&c.connectPermissiveCacheLoading)
 	cmd.Flag("description", "Human-readable description of the repository").StringVar(&c.connectDescription)
 	cmd.Flag("enable-actions", "Allow snapshot actions").BoolVar(&c.connectEnableActions)
+	cmd.Flag("audit-log", "Record storage writes and deletions made by this client in the repository").BoolVar(&c.connectAuditLog)
 	cmd.Flag("repository-format-cache-duration", "Duration of kopia.repository format blob cache").Hidden().DurationVar(&c.formatBlobCacheDuration)
 	cmd.Flag("disable-repository-format-cache", "Disable caching of kopia.repository format blob").Hidden().BoolVar(&c.disableFormatBlobCache)
+	cmd.Flag("base-repository-config", "Configuration file of a linked base repository whose contents are referenced instead of being uploaded").PlaceHolder("PATH").StringVar(&c.baseRepositoryConfigFile)
 }
 
 func (c *connectOptions) getFormatBlobCacheDuration() time.Duration {
@@ -91,13 +100,46 @@ func (c *connectOptions) getFormatBlobCacheDuration() time.Duration {
 	return c.formatBlobCacheDuration
 }
 
+func (c *connectOptions) getBaseRepositoryConfigFile() string {
+	if c.baseRepositoryConfigFile == "" {
+		return ""
+	}
+
+	if p, err := filepath.Abs(c.baseRepositoryConfigFile); err == nil {
+		return p
+	}
+
+	return c.baseRepositoryConfigFile
+}
+
+// cacheSizesMB returns the content and metadata cache sizes, using smaller defaults in low-memory mode.
+func (c *connectOptions) cacheSizesMB() (contentMB, metadataMB int64) {
+	contentMB, metadataMB = c.contentCacheSizeMB, c.metadataCacheSizeMB
+
+	if c.isLowMemory == nil || !c.isLowMemory() {
+		return contentMB, metadataMB
+	}
+
+	if !c.contentCacheSizeMBSet {
+		contentMB = lowMemoryCacheSizeMB
+	}
+
+	if !c.metadataCacheSizeMBSet {
+		metadataMB = lowMemoryCacheSizeMB
+	}
+
+	return contentMB, metadataMB
+}
+
 func (c *connectOptions) toRepoConnectOptions() *repo.ConnectOptions {
+	contentCacheSizeMB, metadataCacheSizeMB := c.cacheSizesMB()
+
 	return &repo.ConnectOptions{
 		CachingOptions: content.CachingOptions{
 			CacheDirectory:              c.connectCacheDirectory,
-			ContentCacheSizeBytes:       c.contentCacheSizeMB << 20,       //nolint:gomnd
+			ContentCacheSizeBytes:       contentCacheSizeMB << 20,         //nolint:gomnd
 			ContentCacheSizeLimitBytes:  c.contentCacheSizeLimitMB << 20,  //nolint:gomnd
-			MetadataCacheSizeBytes:      c.metadataCacheSizeMB << 20,      //nolint:gomnd
+			MetadataCacheSizeBytes:      metadataCacheSizeMB << 20,        //nolint:gomnd
 			MetadataCacheSizeLimitBytes: c.metadataCacheSizeLimitMB << 20, //nolint:gomnd
 			MaxListCacheDuration:        content.DurationSeconds(c.maxListCacheDuration.Seconds()),
 			MinContentSweepAge:          content.DurationSeconds(c.contentMinSweepAge.Seconds()),
@@ -105,13 +147,15 @@ func (c *connectOptions) toRepoConnectOptions() *repo.ConnectOptions {
 			MinIndexSweepAge:            content.DurationSeconds(c.indexMinSweepAge.Seconds()),
 		},
 		ClientOptions: repo.ClientOptions{
-			Hostname:                c.connectHostname,
-			Username:                c.connectUsername,
-			ReadOnly:                c.connectReadonly,
-			PermissiveCacheLoading:  c.connectPermissiveCacheLoading,
-			Description:             c.connectDescription,
-			EnableActions:           c.connectEnableActions,
-			FormatBlobCacheDuration: c.getFormatBlobCacheDuration(),
+			Hostname:                 c.connectHostname,
+			Username:                 c.connectUsername,
+			ReadOnly:                 c.connectReadonly,
+			PermissiveCacheLoading:   c.connectPermissiveCacheLoading,
+			Description:              c.connectDescription,
+			EnableActions:            c.connectEnableActions,
+			AuditLog:                 c.connectAuditLog,
+			FormatBlobCacheDuration:  c.getFormatBlobCacheDuration(),
+			BaseRepositoryConfigFile: c.getBaseRepositoryConfigFile(),
 		},
 	}
 }
@@ -2,13 +2,16 @@
 
 import (
 	"context"
+	"crypto/rand"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/shamir"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/ecc"
 	"github.com/kopia/kopia/repo/encryption"
 	"github.com/kopia/kopia/repo/format"
@@ -33,6 +36,13 @@ type commandRepositoryCreate struct {
 	createFormatVersion           int
 	retentionMode                 string
 	retentionPeriod               time.Duration
+	autoTune                      bool
+	separateDataKey               bool
+	shamirScheme                  string
+
+	hashSetByUser       bool
+	encryptionSetByUser bool
+	splitterSetByUser   bool
 
 	co  connectOptions
 	svc advancedAppServices
@@ -42,15 +52,18 @@ type commandRepositoryCreate struct {
 func (c *commandRepositoryCreate) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("create", "Create new repository in a specified location.")
 
-	cmd.Flag("block-hash", "Content hash algorithm.").PlaceHolder("ALGO").Default(hashing.DefaultAlgorithm).EnumVar(&c.createBlockHashFormat, hashing.SupportedAlgorithms()...)
-	cmd.Flag("encryption", "Content encryption algorithm.").PlaceHolder("ALGO").Default(encryption.DefaultAlgorithm).EnumVar(&c.createBlockEncryptionFormat, encryption.SupportedAlgorithms(false)...)
+	cmd.Flag("block-hash", "Content hash algorithm.").PlaceHolder("ALGO").Default(hashing.DefaultAlgorithm).IsSetByUser(&c.hashSetByUser).EnumVar(&c.createBlockHashFormat, hashing.SupportedAlgorithms()...)
+	cmd.Flag("encryption", "Content encryption algorithm.").PlaceHolder("ALGO").Default(encryption.DefaultAlgorithm).IsSetByUser(&c.encryptionSetByUser).EnumVar(&c.createBlockEncryptionFormat, encryption.SupportedAlgorithms(false)...)
 	cmd.Flag("ecc", "[EXPERIMENTAL] Error correction algorithm.").PlaceHolder("ALGO").Default(ecc.DefaultAlgorithm).EnumVar(&c.createBlockECCFormat, ecc.SupportedAlgorithms()...)
 	cmd.Flag("ecc-overhead-percent", "[EXPERIMENTAL] How much space overhead can be used for error correction, in percentage. Use 0 to disable ECC.").Default("0").IntVar(&c.createBlockECCOverheadPercent)
-	cmd.Flag("object-splitter", "The splitter to use for new objects in the repository").Default(splitter.DefaultAlgorithm).EnumVar(&c.createSplitter, splitter.SupportedAlgorithms()...)
+	cmd.Flag("object-splitter", "The splitter to use for new objects in the repository").Default(splitter.DefaultAlgorithm).IsSetByUser(&c.splitterSetByUser).EnumVar(&c.createSplitter, splitter.SupportedAlgorithms()...)
 	cmd.Flag("create-only", "Create repository, but don't connect to it.").Short('c').BoolVar(&c.createOnly)
 	cmd.Flag("format-version", "Force a particular repository format version (1, 2 or 3, 0==default)").IntVar(&c.createFormatVersion)
 	cmd.Flag("retention-mode", "Set the blob retention-mode for supported storage backends.").EnumVar(&c.retentionMode, blob.Governance.String(), blob.Compliance.String())
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
+	cmd.Flag("separate-data-key", "Encrypt data using a separate key protected by --data-password, so that the repository password only grants access to metadata.").BoolVar(&c.separateDataKey)
+	cmd.Flag("shamir", "Generate a random repository password and split it into N shares, any K of which are required to open the repository.").PlaceHolder("K/N").StringVar(&c.shamirScheme)
+	cmd.Flag("auto-tune", "Benchmark local CPU and storage to select hash, encryption, splitter, compression and parallelism not specified explicitly.").BoolVar(&c.autoTune)
 
 	c.co.setup(svc, cmd)
 	c.svc = svc
@@ -118,9 +131,32 @@ func (c *commandRepositoryCreate) runCreateCommandWithStorage(ctx context.Contex
 
 	options := c.newRepositoryOptionsFromFlags()
 
-	pass, err := c.svc.getPasswordFromFlags(ctx, true, false)
+	if c.separateDataKey {
+		options.DataPassword = c.svc.optionsFromFlags(ctx).DataPassword
+		if options.DataPassword == "" {
+			return errors.New("--data-password (or KOPIA_DATA_PASSWORD) is required when using --separate-data-key")
+		}
+	}
+
+	if c.autoTune {
+		if err := c.runAutoTune(ctx, st, options); err != nil {
+			return errors.Wrap(err, "auto-tune failed")
+		}
+	}
+
+	pass, shares, err := c.getPassword(ctx)
 	if err != nil {
-		return errors.Wrap(err, "getting password")
+		return err
+	}
+
+	baseConfigFile := c.co.getBaseRepositoryConfigFile()
+	if baseConfigFile != "" {
+		// the unique ID is needed to record the link in the base repository.
+		options.UniqueID = make([]byte, format.UniqueIDLengthBytes)
+
+		if _, err := rand.Read(options.UniqueID); err != nil {
+			return errors.Wrap(err, "unable to generate unique ID")
+		}
 	}
 
 	log(ctx).Infof("Initializing repository with:")
@@ -138,19 +174,50 @@ func (c *commandRepositoryCreate) runCreateCommandWithStorage(ctx context.Contex
 
 	log(ctx).Infof("  splitter:            %v", options.ObjectFormat.Splitter)
 
+	if options.DataPassword != "" {
+		log(ctx).Infof("  data key:            separate from metadata")
+	}
+
+	if len(shares) > 0 {
+		log(ctx).Infof("  password:            split into %v shares, %v required", len(shares), shares[0].Threshold)
+	}
+
+	if at := options.AutoTune; at != nil {
+		log(ctx).Infof("Auto-tuning results:")
+
+		for _, r := range at.Rationale {
+			log(ctx).Infof("  %v", r)
+		}
+	}
+
 	if err := repo.Initialize(ctx, st, options, pass); err != nil {
 		return errors.Wrap(err, "cannot initialize repository")
 	}
 
+	if baseConfigFile != "" {
+		if err := c.linkToBaseRepository(ctx, baseConfigFile, pass, options.UniqueID); err != nil {
+			return err
+		}
+	}
+
+	if len(shares) > 0 {
+		printShamirShares(&c.out, shares)
+	}
+
 	if c.createOnly {
 		return nil
 	}
 
+	if len(shares) > 0 {
+		// the password must only be recoverable from the shares.
+		c.svc.disablePasswordPersistence()
+	}
+
 	if err := c.svc.runConnectCommandWithStorageAndPassword(ctx, &c.co, st, pass); err != nil {
 		return errors.Wrap(err, "unable to connect to repository")
 	}
 
-	if err := c.populateRepository(ctx, pass); err != nil {
+	if err := c.populateRepository(ctx, pass, options.AutoTune); err != nil {
 		return errors.Wrap(err, "error populating repository")
 	}
 
@@ -159,7 +226,45 @@ func (c *commandRepositoryCreate) runCreateCommandWithStorage(ctx context.Contex
 	return nil
 }
 
-func (c *commandRepositoryCreate) populateRepository(ctx context.Context, password string) error {
+// linkToBaseRepository records the new repository in the base repository, which must be opened using the same password.
+func (c *commandRepositoryCreate) linkToBaseRepository(ctx context.Context, baseConfigFile, password string, uniqueID []byte) error {
+	base, err := repo.Open(ctx, baseConfigFile, password, c.svc.optionsFromFlags(ctx))
+	if err != nil {
+		return errors.Wrap(err, "unable to open base repository")
+	}
+	defer base.Close(ctx) //nolint:errcheck
+
+	dr, ok := base.(repo.DirectRepository)
+	if !ok {
+		return errors.New("base repository must be connected directly to the storage")
+	}
+
+	if err := repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{Purpose: "LinkRepository"}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		return repo.LinkRepository(ctx, w, uniqueID, c.co.connectDescription)
+	}); err != nil {
+		return errors.Wrap(err, "unable to link to base repository")
+	}
+
+	log(ctx).Infof("Linked to base repository %v", baseConfigFile)
+
+	return nil
+}
+
+// getPassword returns the password of the new repository and its shares, if it is split using --shamir.
+func (c *commandRepositoryCreate) getPassword(ctx context.Context) (string, []shamir.Share, error) {
+	if c.shamirScheme != "" {
+		return newShamirPassword(c.shamirScheme)
+	}
+
+	pass, err := c.svc.getPasswordFromFlags(ctx, true, false)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "getting password")
+	}
+
+	return pass, nil, nil
+}
+
+func (c *commandRepositoryCreate) populateRepository(ctx context.Context, password string, at *format.AutoTuneResult) error {
 	rep, err := repo.Open(ctx, c.svc.repositoryConfigFileName(), password, c.svc.optionsFromFlags(ctx))
 	if err != nil {
 		return errors.Wrap(err, "unable to open repository")
@@ -170,14 +275,16 @@ func (c *commandRepositoryCreate) populateRepository(ctx context.Context, passwo
 	return repo.WriteSession(ctx, rep, repo.WriteSessionOptions{
 		Purpose: "populate repository",
 	}, func(ctx context.Context, w repo.RepositoryWriter) error {
-		if err := policy.SetPolicy(ctx, w, policy.GlobalPolicySourceInfo, policy.DefaultPolicy); err != nil {
+		globalPolicy := autoTunedGlobalPolicy(at)
+
+		if err := policy.SetPolicy(ctx, w, policy.GlobalPolicySourceInfo, globalPolicy); err != nil {
 			return errors.Wrap(err, "unable to set global policy")
 		}
 
 		var rows []policyTableRow
 
-		rows = appendRetentionPolicyRows(rows, policy.DefaultPolicy, &policy.Definition{})
-		rows = appendCompressionPolicyRows(rows, policy.DefaultPolicy, &policy.Definition{})
+		rows = appendRetentionPolicyRows(rows, globalPolicy, &policy.Definition{})
+		rows = appendCompressionPolicyRows(rows, globalPolicy, &policy.Definition{})
 
 		c.out.printStdout("%v\n", alignedPolicyTableRows(rows))
 
@@ -190,3 +297,23 @@ func (c *commandRepositoryCreate) populateRepository(ctx context.Context, passwo
 		return nil
 	})
 }
+
+// autoTunedGlobalPolicy returns the default global policy with auto-tuned parameters applied.
+func autoTunedGlobalPolicy(at *format.AutoTuneResult) *policy.Policy {
+	if at == nil {
+		return policy.DefaultPolicy
+	}
+
+	p := *policy.DefaultPolicy
+
+	if at.Compression != "" {
+		p.CompressionPolicy.CompressorName = compression.Name(at.Compression)
+	}
+
+	if at.MaxParallelFileReads > 0 {
+		v := policy.OptionalInt(at.MaxParallelFileReads)
+		p.UploadPolicy.MaxParallelFileReads = &v
+	}
+
+	return &p
+}
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/timetrack"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/encryption"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/hashing"
+	"github.com/kopia/kopia/repo/splitter"
+)
+
+const (
+	autoTuneCryptoBlockSize      = 1 << 20
+	autoTuneCryptoRepeat         = 16
+	autoTuneSplitterDataSize     = 16 << 20
+	autoTuneCompressionDataSize  = 4 << 20
+	autoTuneStorageBlobSize      = 64 << 10
+	autoTuneStorageRounds        = 3
+	autoTuneHighLatencyThreshold = 20 * time.Millisecond
+	autoTuneMaxParallelFileReads = 32
+
+	// minimum throughput of a compression method relative to the fastest one to be considered.
+	autoTuneMinCompressionThroughputPercent = 25
+)
+
+// compression methods considered by auto-tuning, general-purpose and reasonably fast.
+//
+//nolint:gochecknoglobals
+var autoTuneCompressionCandidates = []compression.Name{
+	"s2-default",
+	"s2-better",
+	"zstd-fastest",
+	"zstd",
+	"pgzip-best-speed",
+}
+
+// runAutoTune benchmarks local CPU and the provided storage and updates repository options
+// that were not explicitly provided by the user, recording the rationale in the options.
+func (c *commandRepositoryCreate) runAutoTune(ctx context.Context, st blob.Storage, options *repo.NewRepositoryOptions) error {
+	res := &format.AutoTuneResult{
+		Time: clock.Now(),
+	}
+
+	log(ctx).Infof("Auto-tuning repository parameters, this may take a few seconds...")
+
+	if c.hashSetByUser || c.encryptionSetByUser {
+		res.Rationale = append(res.Rationale, "hash and encryption: explicitly specified, not tuned")
+	} else {
+		h, e, throughput := autoTuneCrypto()
+		options.BlockFormat.Hash = h
+		options.BlockFormat.Encryption = e
+		res.Rationale = append(res.Rationale, fmt.Sprintf("hash %v and encryption %v: fastest combination of full-size hash and encryption at %v/s", h, e, units.BytesString(int64(throughput))))
+	}
+
+	if c.splitterSetByUser {
+		res.Rationale = append(res.Rationale, "splitter: explicitly specified, not tuned")
+	} else {
+		sp, throughput := autoTuneSplitter()
+		options.ObjectFormat.Splitter = sp
+		res.Rationale = append(res.Rationale, fmt.Sprintf("splitter %v: fastest splitter with default block size at %v/s", sp, units.BytesString(int64(throughput))))
+	}
+
+	comp, ratio, throughput := autoTuneCompression()
+	res.Compression = string(comp)
+	res.Rationale = append(res.Rationale, fmt.Sprintf("compression %v: best ratio (%.1f%%) among fast methods at %v/s", comp, ratio*100, units.BytesString(int64(throughput)))) //nolint:gomnd
+
+	latency, err := autoTuneStorageLatency(ctx, st)
+	if err != nil {
+		return errors.Wrap(err, "error benchmarking storage")
+	}
+
+	res.MaxParallelFileReads = runtime.NumCPU()
+
+	if latency >= autoTuneHighLatencyThreshold {
+		res.MaxParallelFileReads *= 2
+
+		if res.MaxParallelFileReads > autoTuneMaxParallelFileReads {
+			res.MaxParallelFileReads = autoTuneMaxParallelFileReads
+		}
+
+		res.Rationale = append(res.Rationale, fmt.Sprintf("parallelism %v: storage round-trip latency %v is high, using twice the number of CPUs", res.MaxParallelFileReads, latency))
+	} else {
+		res.Rationale = append(res.Rationale, fmt.Sprintf("parallelism %v: storage round-trip latency %v is low, using the number of CPUs", res.MaxParallelFileReads, latency))
+	}
+
+	options.AutoTune = res
+
+	return nil
+}
+
+func autoTuneCrypto() (hashAlgo, encryptionAlgo string, throughput float64) {
+	data := gather.FromSlice(make([]byte, autoTuneCryptoBlockSize))
+
+	for _, ha := range autoTuneHashCandidates() {
+		for _, ea := range encryption.SupportedAlgorithms(false) {
+			fo := &format.ContentFormat{
+				Encryption: ea,
+				Hash:       ha,
+				MasterKey:  make([]byte, 32), //nolint:gomnd
+				HMACSecret: make([]byte, 32), //nolint:gomnd
+			}
+
+			hf, err := hashing.CreateHashFunc(fo)
+			if err != nil {
+				continue
+			}
+
+			enc, err := encryption.CreateEncryptor(fo)
+			if err != nil {
+				continue
+			}
+
+			var (
+				hashOutput    [hashing.MaxHashSize]byte
+				encryptOutput gather.WriteBuffer
+			)
+
+			tt := timetrack.Start()
+
+			for i := 0; i < autoTuneCryptoRepeat; i++ {
+				contentID := hf(hashOutput[:0], data)
+
+				if err := enc.Encrypt(data, contentID, &encryptOutput); err != nil {
+					break
+				}
+			}
+
+			_, bytesPerSecond := tt.Completed(float64(autoTuneCryptoRepeat) * float64(data.Length()))
+
+			encryptOutput.Close()
+
+			if bytesPerSecond > throughput {
+				hashAlgo, encryptionAlgo, throughput = ha, ea, bytesPerSecond
+			}
+		}
+	}
+
+	return hashAlgo, encryptionAlgo, throughput
+}
+
+// autoTuneHashCandidates returns hash algorithms considered by auto-tuning, which excludes hashes truncated
+// to less than the full hash size, since they are more prone to collisions.
+func autoTuneHashCandidates() []string {
+	var result []string
+
+	for _, ha := range hashing.SupportedAlgorithms() {
+		hf, err := hashing.CreateHashFunc(&format.ContentFormat{
+			Hash:       ha,
+			HMACSecret: make([]byte, 32), //nolint:gomnd
+		})
+		if err != nil {
+			continue
+		}
+
+		if len(hf(nil, gather.FromSlice(nil))) == hashing.MaxHashSize {
+			result = append(result, ha)
+		}
+	}
+
+	return result
+}
+
+func autoTuneSplitter() (name string, throughput float64) {
+	data := make([]byte, autoTuneSplitterDataSize)
+	rand.New(rand.NewSource(0)).Read(data) //nolint:gosec
+
+	// only consider splitters with the same block size as the default one, which only differ in the hashing method.
+	prefix := splitter.DefaultAlgorithm[0 : strings.LastIndex(splitter.DefaultAlgorithm, "-")+1]
+
+	name = splitter.DefaultAlgorithm
+
+	for _, sp := range splitter.SupportedAlgorithms() {
+		if !strings.HasPrefix(sp, prefix) {
+			continue
+		}
+
+		s := splitter.GetFactory(sp)()
+		tt := timetrack.Start()
+
+		for d := data; len(d) > 0; {
+			n := s.NextSplitPoint(d)
+			if n < 0 {
+				break
+			}
+
+			d = d[n:]
+		}
+
+		s.Close()
+
+		if _, bytesPerSecond := tt.Completed(float64(len(data))); bytesPerSecond > throughput {
+			name, throughput = sp, bytesPerSecond
+		}
+	}
+
+	return name, throughput
+}
+
+// autoTuneCompressionData generates semi-compressible text-like data.
+func autoTuneCompressionData() []byte {
+	rnd := rand.New(rand.NewSource(0)) //nolint:gosec
+
+	var words []string
+
+	for i := 0; i < 1000; i++ {
+		w := make([]byte, 3+rnd.Intn(8)) //nolint:gomnd
+		for j := range w {
+			w[j] = byte('a' + rnd.Intn(26)) //nolint:gomnd
+		}
+
+		words = append(words, string(w))
+	}
+
+	var sb strings.Builder
+
+	for sb.Len() < autoTuneCompressionDataSize {
+		sb.WriteString(words[rnd.Intn(len(words))])
+		sb.WriteByte(' ')
+	}
+
+	return []byte(sb.String())
+}
+
+func autoTuneCompression() (name compression.Name, ratio, throughput float64) {
+	data := autoTuneCompressionData()
+
+	type result struct {
+		name       compression.Name
+		ratio      float64
+		throughput float64
+	}
+
+	var (
+		results []result
+		fastest float64
+	)
+
+	for _, cn := range autoTuneCompressionCandidates {
+		comp := compression.ByName[cn]
+		if comp == nil {
+			continue
+		}
+
+		var out gather.WriteBuffer
+
+		tt := timetrack.Start()
+		err := comp.Compress(&out, gather.FromSlice(data).Reader())
+		_, bytesPerSecond := tt.Completed(float64(len(data)))
+
+		r := result{cn, float64(out.Length()) / float64(len(data)), bytesPerSecond}
+
+		out.Close()
+
+		if err != nil {
+			continue
+		}
+
+		results = append(results, r)
+
+		if r.throughput > fastest {
+			fastest = r.throughput
+		}
+	}
+
+	ratio = 1
+
+	for _, r := range results {
+		if r.throughput*100 < fastest*autoTuneMinCompressionThroughputPercent {
+			continue
+		}
+
+		if name == "" || r.ratio < ratio {
+			name, ratio, throughput = r.name, r.ratio, r.throughput
+		}
+	}
+
+	return name, ratio, throughput
+}
+
+// autoTuneStorageLatency measures average time it takes to write, read and delete a small blob.
+func autoTuneStorageLatency(ctx context.Context, st blob.Storage) (time.Duration, error) {
+	data := make([]byte, autoTuneStorageBlobSize)
+	rand.Read(data) //nolint:gosec
+
+	var total time.Duration
+
+	for i := 0; i < autoTuneStorageRounds; i++ {
+		blobID := blob.ID(fmt.Sprintf("_autotune-%x-%v", data[0:8], i))
+
+		var tmp gather.WriteBuffer
+
+		tt := timetrack.Start()
+
+		if err := st.PutBlob(ctx, blobID, gather.FromSlice(data), blob.PutOptions{}); err != nil {
+			return 0, errors.Wrap(err, "error writing test blob")
+		}
+
+		err := st.GetBlob(ctx, blobID, 0, -1, &tmp)
+		tmp.Close()
+
+		if err != nil {
+			return 0, errors.Wrap(err, "error reading test blob")
+		}
+
+		if err := st.DeleteBlob(ctx, blobID); err != nil {
+			return 0, errors.Wrap(err, "error deleting test blob")
+		}
+
+		dur, _ := tt.Completed(0)
+		total += dur
+	}
+
+	return total / autoTuneStorageRounds, nil
+}
@@ -0,0 +1,19 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoTuneHashCandidates(t *testing.T) {
+	candidates := autoTuneHashCandidates()
+
+	require.Contains(t, candidates, "BLAKE2B-256")
+	require.Contains(t, candidates, "BLAKE3-256")
+	require.Contains(t, candidates, "HMAC-SHA256")
+	require.NotContains(t, candidates, "BLAKE2B-256-128")
+	require.NotContains(t, candidates, "BLAKE3-256-128")
+	require.NotContains(t, candidates, "HMAC-SHA256-128")
+	require.NotContains(t, candidates, "BLAKE2S-128")
+}
@@ -6,9 +6,13 @@
 	"strings"
 	"testing"
 
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
 	"github.com/kopia/kopia/tests/testenv"
 
 	"github.com/stretchr/testify/require"
@@ -61,3 +65,61 @@ func TestRepositoryCreateWithConfigFromStdin(t *testing.T) {
 
 	env.RunAndExpectSuccess(t, "repo", "create", "from-config", "--token-stdin")
 }
+
+func TestRepositoryCreateAutoTune(t *testing.T) {
+	env := testenv.NewCLITest(t, nil, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--auto-tune", "--encryption=CHACHA20-POLY1305-HMAC-SHA256")
+
+	var rs cli.RepositoryStatus
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "repo", "status", "--json"), &rs)
+
+	require.NotNil(t, rs.AutoTune)
+	require.NotEmpty(t, rs.AutoTune.Rationale)
+	require.NotEmpty(t, rs.AutoTune.Compression)
+	require.Positive(t, rs.AutoTune.MaxParallelFileReads)
+	require.Equal(t, "CHACHA20-POLY1305-HMAC-SHA256", rs.ContentFormat.Encryption)
+
+	var pol policy.Policy
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "policy", "show", "--global", "--json"), &pol)
+	require.Equal(t, rs.AutoTune.Compression, string(pol.CompressionPolicy.CompressorName))
+	require.Equal(t, rs.AutoTune.MaxParallelFileReads, pol.UploadPolicy.MaxParallelFileReads.OrDefault(0))
+
+	require.Contains(t, strings.Join(env.RunAndExpectSuccess(t, "repo", "status"), "\n"), "Auto-tuned:")
+}
+
+func TestRepositoryCreateSeparateDataKey(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectFailure(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--separate-data-key")
+
+	env.Environment["KOPIA_DATA_PASSWORD"] = "data-password"
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--separate-data-key")
+	require.Contains(t, env.RunAndExpectSuccess(t, "repo", "status"), "Data key:            separate from metadata")
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(path.Join(srcdir, "a.txt"), []byte("some secret data"), 0o600))
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+
+	fileOID := man.RootObjectID().String() + "/a.txt"
+	require.Equal(t, []string{"some secret data"}, env.RunAndExpectSuccess(t, "show", fileOID))
+
+	// without the data password snapshots can be listed and garbage-collected, but data can't be read.
+	delete(env.Environment, "KOPIA_DATA_PASSWORD")
+
+	require.Contains(t, strings.Join(env.RunAndExpectSuccess(t, "snapshot", "list", srcdir), "\n"), man.RootObjectID().String())
+	env.RunAndExpectSuccess(t, "ls", man.RootObjectID().String())
+	env.RunAndExpectFailure(t, "show", fileOID)
+	env.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--delete")
+
+	require.NoError(t, os.WriteFile(path.Join(srcdir, "b.txt"), []byte("more secret data"), 0o600))
+	env.RunAndExpectFailure(t, "snapshot", "create", srcdir)
+
+	env.Environment["KOPIA_DATA_PASSWORD"] = "wrong-password"
+	env.RunAndExpectFailure(t, "snapshot", "list", srcdir)
+}
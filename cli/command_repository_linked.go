@@ -0,0 +1,13 @@
+package cli
+
+type commandRepositoryLinked struct {
+	list   commandRepositoryLinkedList
+	unlink commandRepositoryLinkedUnlink
+}
+
+func (c *commandRepositoryLinked) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("linked", "Commands to manage repositories linked to this base repository.")
+
+	c.list.setup(svc, cmd)
+	c.unlink.setup(svc, cmd)
+}
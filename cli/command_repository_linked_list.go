@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryLinkedList struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandRepositoryLinkedList) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("list", "List repositories linked to this base repository.").Alias("ls")
+	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryLinkedList) run(ctx context.Context, rep repo.Repository) error {
+	entries, err := repo.ListLinkedRepositories(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to list linked repositories")
+	}
+
+	var linked []*repo.LinkedRepository
+
+	for _, e := range entries {
+		l := &repo.LinkedRepository{}
+
+		if _, err := rep.GetManifest(ctx, e.ID, l); err != nil {
+			return errors.Wrap(err, "unable to read linked repository")
+		}
+
+		linked = append(linked, l)
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(linked))
+		return nil
+	}
+
+	for _, l := range linked {
+		c.out.printStdout("%v %v %v %v\n", l.UniqueID, c.out.formatTimestamp(l.CreateTime), l.CreatedBy, l.Description)
+	}
+
+	return nil
+}
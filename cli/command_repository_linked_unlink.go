@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryLinkedUnlink struct {
+	uniqueID string
+	confirm  bool
+
+	out textOutput
+}
+
+func (c *commandRepositoryLinkedUnlink) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("unlink", "Unlink a repository, maintenance deletes pack blobs no longer referenced by this repository afterwards.")
+	cmd.Arg("id", "Unique ID of the linked repository").Required().StringVar(&c.uniqueID)
+	cmd.Flag("unlink", "Really unlink").BoolVar(&c.confirm)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryLinkedUnlink) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	if !c.confirm {
+		return errors.New("contents of the linked repository referenced from this repository become unreadable after the next full maintenance, pass --unlink to confirm")
+	}
+
+	if err := repo.UnlinkRepository(ctx, rep, c.uniqueID); err != nil {
+		return errors.Wrap(err, "unable to unlink repository")
+	}
+
+	c.out.printStdout("Unlinked repository %v, pack blobs no longer referenced by this repository will be deleted during full maintenance.\n", c.uniqueID)
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+)
+
+type commandRepositoryOpen struct {
+	asOf               string
+	viewConfigFile     string
+	useStorageVersions bool
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryOpen) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("open", "Open a read-only view of the repository as it existed at a point in time.")
+	cmd.Flag("as-of", "Point in time (RFC3339) at which to view the repository").Required().StringVar(&c.asOf)
+	cmd.Flag("view-config-file", "Configuration file to write the point-in-time view to").Required().StringVar(&c.viewConfigFile)
+	cmd.Flag("storage-versions", "Use previous blob versions kept by versioned storage, if supported").Default("true").BoolVar(&c.useStorageVersions)
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryOpen) run(ctx context.Context) error {
+	asOf, err := time.Parse(time.RFC3339, c.asOf)
+	if err != nil {
+		return errors.Wrap(err, "invalid --as-of, expected RFC3339 time")
+	}
+
+	if asOf.After(clock.Now()) {
+		return errors.New("--as-of must not be in the future")
+	}
+
+	configFile := c.svc.repositoryConfigFileName()
+
+	pass, err := c.svc.passwordPersistenceStrategy().GetPassword(ctx, configFile)
+	if err != nil {
+		pass, err = c.svc.getPasswordFromFlags(ctx, false, false)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "repository password")
+	}
+
+	usesVersions, err := repo.CreatePointInTimeView(configFile, c.viewConfigFile, asOf, repo.PointInTimeViewOptions{
+		UseStorageVersions: c.useStorageVersions,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create point-in-time view")
+	}
+
+	if err := c.svc.passwordPersistenceStrategy().PersistPassword(ctx, c.viewConfigFile, pass); err != nil {
+		return errors.Wrap(err, "unable to persist password")
+	}
+
+	r, err := openRepositoryWithConfig(ctx, c.svc, c.viewConfigFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to open point-in-time view")
+	}
+
+	defer r.Close(ctx) //nolint:errcheck
+
+	manifests, err := snapshot.ListSnapshotManifests(ctx, r, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshots")
+	}
+
+	c.out.printStdout("Opened read-only view of %v with %v snapshots.\n", r.ClientOptions().Description, len(manifests))
+
+	if !usesVersions {
+		c.out.printStdout("Storage does not provide previous versions of blobs, only blobs written after the point in time are hidden.\n")
+	}
+
+	c.out.printStdout("To use it, pass --config-file=%v to other commands.\n", c.viewConfigFile)
+
+	return nil
+}
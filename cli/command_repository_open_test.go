@@ -0,0 +1,69 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryOpenAsOf(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	viewEnv := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("first"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	// filesystem modification times have limited precision, make sure the point in time
+	// falls strictly between the two snapshots.
+	time.Sleep(1100 * time.Millisecond)
+
+	asOf := time.Now().UTC().Format(time.RFC3339)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("second"), 0o600))
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	require.Len(t, listSnapshotsJSON(t, env), 2)
+
+	viewConfig := filepath.Join(viewEnv.ConfigDir, ".kopia.config")
+
+	env.RunAndExpectFailure(t, "repo", "open", "--as-of", "not-a-time", "--view-config-file", viewConfig)
+	env.RunAndExpectFailure(t, "repo", "open", "--as-of", asOf, "--view-config-file", filepath.Join(env.ConfigDir, ".kopia.config"))
+
+	out := env.RunAndExpectSuccess(t, "repo", "open", "--as-of", asOf, "--view-config-file", viewConfig)
+	mustGetLineContaining(t, out, "with 1 snapshots")
+	mustGetLineContaining(t, out, "--config-file="+viewConfig)
+
+	require.Len(t, listSnapshotsJSON(t, viewEnv), 1)
+
+	// the view is read-only.
+	viewEnv.RunAndExpectFailure(t, "snapshot", "create", dir)
+
+	// the original connection is unaffected.
+	require.Len(t, listSnapshotsJSON(t, env), 2)
+}
+
+func listSnapshotsJSON(t *testing.T, e *testenv.CLITest) []cli.SnapshotManifest {
+	t.Helper()
+
+	var snapshots []cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", "--json"), &snapshots)
+
+	return snapshots
+}
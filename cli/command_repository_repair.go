@@ -16,6 +16,8 @@ type commandRepositoryRepair struct {
 	repairCommandRecoverFormatBlob         string
 	repairCommandRecoverFormatBlobPrefixes []string
 	repairDryRun                           bool
+
+	undeleteBlocks commandRepositoryRepairUndeleteBlocks
 }
 
 func (c *commandRepositoryRepair) setup(svc advancedAppServices, parent commandParent) {
@@ -25,6 +27,8 @@ func (c *commandRepositoryRepair) setup(svc advancedAppServices, parent commandP
 	cmd.Flag("recover-format-block-prefixes", "Prefixes of file names").StringsVar(&c.repairCommandRecoverFormatBlobPrefixes)
 	cmd.Flag("dry-run", "Do not modify repository").Short('n').BoolVar(&c.repairDryRun)
 
+	c.undeleteBlocks.setup(svc, cmd, &c.repairDryRun)
+
 	for _, prov := range svc.storageProviders() {
 		f := prov.NewFlags()
 		cc := cmd.Command(prov.Name, "Repair repository in "+prov.Description)
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+type commandRepositoryRepairUndeleteBlocks struct {
+	until    string
+	prefixes []string
+	dryRun   *bool
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryRepairUndeleteBlocks) setup(svc advancedAppServices, parent commandParent, dryRun *bool) {
+	cmd := parent.Command("undelete-blocks", "Restore repository blobs deleted after a point in time using previous versions kept by versioned storage.")
+	cmd.Flag("until", "Point in time (RFC3339), blobs which existed at that time and were deleted since are restored (defaults to the time of a point-in-time view)").StringVar(&c.until)
+	cmd.Flag("prefix", "Only restore blobs with the provided prefixes").StringsVar(&c.prefixes)
+	cmd.Action(svc.noRepositoryAction(c.run))
+
+	c.dryRun = dryRun
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryRepairUndeleteBlocks) run(ctx context.Context) error {
+	lc, err := repo.LoadConfigFromFile(c.svc.repositoryConfigFileName())
+	if err != nil {
+		return errors.Wrap(err, "unable to load repository configuration")
+	}
+
+	if lc.Storage == nil {
+		return errors.New("undelete is only supported for direct repository connections")
+	}
+
+	until, err := c.undeleteTime(lc)
+	if err != nil {
+		return err
+	}
+
+	// undelete operates on the current state of the storage, not a point-in-time view of it.
+	if pit, ok := lc.Storage.Config.(blob.PointInTimeSupport); ok {
+		pit.SetPointInTime(time.Time{})
+	}
+
+	st, err := blob.NewStorage(ctx, *lc.Storage, false)
+	if err != nil {
+		return errors.Wrap(err, "can't connect to storage")
+	}
+
+	defer st.Close(ctx) //nolint:errcheck
+
+	us, ok := st.(blob.UndeleteSupport)
+	if !ok {
+		return errors.Wrapf(blob.ErrUndeleteUnsupported, "%v", st.DisplayName())
+	}
+
+	prefixes := c.prefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{""}
+	}
+
+	var count int
+
+	for _, prefix := range prefixes {
+		if err := us.UndeleteBlobs(ctx, blob.ID(prefix), until, *c.dryRun, func(bm blob.Metadata) error {
+			count++
+
+			log(ctx).Infof("undeleting %v (%v bytes, %v)", bm.BlobID, bm.Length, c.out.formatTimestamp(bm.Timestamp))
+
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "unable to undelete blobs with prefix %q", prefix)
+		}
+	}
+
+	if *c.dryRun {
+		c.out.printStdout("Would undelete %v blobs deleted after %v.\n", count, c.out.formatTimestamp(until))
+		return nil
+	}
+
+	c.out.printStdout("Undeleted %v blobs deleted after %v.\n", count, c.out.formatTimestamp(until))
+
+	if lc.AsOf == nil && count > 0 {
+		c.out.printStdout("To examine the repository as it was at that time, use 'kopia repository open --as-of=%v'.\n", until.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func (c *commandRepositoryRepairUndeleteBlocks) undeleteTime(lc *repo.LocalConfig) (time.Time, error) {
+	if c.until == "" {
+		if lc.AsOf == nil {
+			return time.Time{}, errors.New("--until is required unless connected to a point-in-time view")
+		}
+
+		return *lc.AsOf, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, c.until)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "invalid --until, expected RFC3339 time")
+	}
+
+	return t, nil
+}
@@ -0,0 +1,38 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryRepairUndeleteBlocks_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	viewEnv := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	asOf := time.Now().UTC().Format(time.RFC3339)
+
+	// --until is required when not connected to a point-in-time view.
+	_, stderr := env.RunAndExpectFailure(t, "repo", "repair", "undelete-blocks")
+	require.Contains(t, strings.Join(stderr, "\n"), "--until is required")
+
+	// filesystem storage does not keep previous versions of blobs.
+	_, stderr = env.RunAndExpectFailure(t, "repo", "repair", "undelete-blocks", "--until", asOf)
+	require.Contains(t, strings.Join(stderr, "\n"), "undelete is not supported")
+
+	// point-in-time view provides the default time.
+	env.RunAndExpectSuccess(t, "repo", "open", "--as-of", asOf, "--view-config-file", filepath.Join(viewEnv.ConfigDir, ".kopia.config"))
+
+	_, stderr = viewEnv.RunAndExpectFailure(t, "repo", "repair", "undelete-blocks", "--dry-run")
+	require.Contains(t, strings.Join(stderr, "\n"), "undelete is not supported")
+}
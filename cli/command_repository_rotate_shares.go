@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+type commandRepositoryRotateShares struct {
+	shamirScheme string
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryRotateShares) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("rotate-shares", "Replace repository password with a new random one split into Shamir shares, invalidating previous shares and password.")
+	cmd.Flag("shamir", "Split the new password into N shares, any K of which are required to open the repository.").PlaceHolder("K/N").Required().StringVar(&c.shamirScheme)
+
+	c.svc = svc
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandRepositoryRotateShares) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	newPass, shares, err := newShamirPassword(c.shamirScheme)
+	if err != nil {
+		return err
+	}
+
+	if err := rep.FormatManager().ChangePassword(ctx, newPass); err != nil {
+		return errors.Wrap(err, "unable to change password")
+	}
+
+	log(ctx).Infof(`NOTE: Repository password has been changed, previous shares can no longer be used to open the repository.`)
+
+	printShamirShares(&c.out, shares)
+
+	// the new password must only be recoverable from the shares, so remove the previously persisted one
+	// instead of replacing it.
+	if err := c.svc.passwordPersistenceStrategy().DeletePassword(ctx, c.svc.repositoryConfigFileName()); err != nil {
+		return errors.Wrap(err, "unable to delete persisted password")
+	}
+
+	return nil
+}
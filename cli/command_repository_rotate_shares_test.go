@@ -0,0 +1,85 @@
+package cli_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryShamirShares(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	delete(env.Environment, "KOPIA_PASSWORD")
+
+	passwordFile := filepath.Join(env.ConfigDir, ".kopia.config.kopia-password")
+
+	shares := sharesFromOutput(env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--shamir", "2/3", "--disable-repository-format-cache"))
+	require.Len(t, shares, 3)
+
+	// the password is never persisted, so shares are required for every command.
+	require.NoFileExists(t, passwordFile)
+	env.RunAndExpectSuccess(t, "snapshot", "ls", "--shamir-share", shares[0], "--shamir-share", shares[1])
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// a single share is not sufficient.
+	env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--disable-repository-format-cache",
+		"--shamir-share", shares[1])
+
+	env.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--disable-repository-format-cache",
+		"--shamir-share", shares[0], "--shamir-share", shares[2])
+	require.NoFileExists(t, passwordFile)
+	env.RunAndExpectSuccess(t, "snapshot", "ls", "--shamir-share", shares[1], "--shamir-share", shares[2])
+
+	newShares := sharesFromOutput(env.RunAndExpectSuccess(t, "repo", "rotate-shares", "--shamir", "3/4",
+		"--shamir-share", shares[0], "--shamir-share", shares[1]))
+	require.Len(t, newShares, 4)
+	require.NoFileExists(t, passwordFile)
+
+	// the connection keeps working with the new shares.
+	env.RunAndExpectFailure(t, "snapshot", "ls", "--shamir-share", shares[0], "--shamir-share", shares[1])
+	env.RunAndExpectSuccess(t, "snapshot", "ls", "--shamir-share", newShares[0], "--shamir-share", newShares[1], "--shamir-share", newShares[2])
+	env.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	// old shares no longer work.
+	env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--disable-repository-format-cache",
+		"--shamir-share", shares[0], "--shamir-share", shares[1])
+
+	env.RunAndExpectFailure(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--disable-repository-format-cache",
+		"--shamir-share", newShares[0], "--shamir-share", newShares[1])
+
+	env.RunAndExpectSuccess(t, "repo", "connect", "filesystem", "--path", env.RepoDir, "--disable-repository-format-cache",
+		"--shamir-share", newShares[3], "--shamir-share", newShares[1], "--shamir-share", newShares[2])
+	env.RunAndExpectSuccess(t, "snapshot", "ls", "--shamir-share", newShares[3], "--shamir-share", newShares[1], "--shamir-share", newShares[2])
+}
+
+func TestRepositoryRotateSharesDeletesPersistedPassword(t *testing.T) {
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	passwordFile := filepath.Join(env.ConfigDir, ".kopia.config.kopia-password")
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir, "--disable-repository-format-cache")
+	require.FileExists(t, passwordFile)
+
+	newShares := sharesFromOutput(env.RunAndExpectSuccess(t, "repo", "rotate-shares", "--shamir", "2/3"))
+	require.Len(t, newShares, 3)
+	require.NoFileExists(t, passwordFile)
+
+	delete(env.Environment, "KOPIA_PASSWORD")
+	env.RunAndExpectSuccess(t, "snapshot", "ls", "--shamir-share", newShares[0], "--shamir-share", newShares[2])
+}
+
+func sharesFromOutput(lines []string) []string {
+	var shares []string
+
+	for _, l := range lines {
+		if s, ok := strings.CutPrefix(l, "share "); ok {
+			_, share, _ := strings.Cut(s, ": ")
+			shares = append(shares, share)
+		}
+	}
+
+	return shares
+}
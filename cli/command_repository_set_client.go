@@ -16,6 +16,8 @@ type commandRepositorySetClient struct {
 	repoClientOptionsDescription            []string
 	repoClientOptionsUsername               []string
 	repoClientOptionsHostname               []string
+	repoClientOptionsEnableAuditLog         bool
+	repoClientOptionsDisableAuditLog        bool
 
 	formatBlobCacheDuration time.Duration
 	disableFormatBlobCache  bool
@@ -32,9 +34,12 @@ func (c *commandRepositorySetClient) setup(svc appServices, parent commandParent
 	cmd.Flag("description", "Change description").StringsVar(&c.repoClientOptionsDescription)
 	cmd.Flag("username", "Change username").StringsVar(&c.repoClientOptionsUsername)
 	cmd.Flag("hostname", "Change hostname").StringsVar(&c.repoClientOptionsHostname)
+	cmd.Flag("enable-audit-log", "Record storage writes and deletions made by this client in the repository").BoolVar(&c.repoClientOptionsEnableAuditLog)
+	cmd.Flag("disable-audit-log", "Stop recording storage writes and deletions in the repository").BoolVar(&c.repoClientOptionsDisableAuditLog)
 	cmd.Flag("repository-format-cache-duration", "Duration of kopia.repository format blob cache").DurationVar(&c.formatBlobCacheDuration)
 	cmd.Flag("disable-repository-format-cache", "Disable caching of kopia.repository format blob").BoolVar(&c.disableFormatBlobCache)
 	cmd.Action(svc.repositoryReaderAction(c.run))
+	svc.runLocally(cmd, nil)
 
 	c.svc = svc
 }
@@ -98,6 +103,28 @@ func (c *commandRepositorySetClient) run(ctx context.Context, rep repo.Repositor
 		log(ctx).Infof("Setting local hostname to %v", opt.Hostname)
 	}
 
+	if c.repoClientOptionsEnableAuditLog {
+		if opt.AuditLog {
+			log(ctx).Infof("Audit log is already enabled.")
+		} else {
+			opt.AuditLog = true
+			anyChange = true
+
+			log(ctx).Infof("Enabling audit log.")
+		}
+	}
+
+	if c.repoClientOptionsDisableAuditLog {
+		if !opt.AuditLog {
+			log(ctx).Infof("Audit log is already disabled.")
+		} else {
+			opt.AuditLog = false
+			anyChange = true
+
+			log(ctx).Infof("Disabling audit log.")
+		}
+	}
+
 	if v := c.formatBlobCacheDuration; v != 0 {
 		opt.FormatBlobCacheDuration = v
 		anyChange = true
@@ -31,6 +31,8 @@ type commandRepositorySetParameters struct {
 
 	upgradeRepositoryFormat bool
 
+	minClientVersion string
+
 	addRequiredFeature           string
 	removeRequiredFeature        string
 	warnOnMissingRequiredFeature bool
@@ -47,6 +49,7 @@ func (c *commandRepositorySetParameters) setup(svc appServices, parent commandPa
 	cmd.Flag("retention-period", "Set the blob retention-period for supported storage backends.").DurationVar(&c.retentionPeriod)
 
 	cmd.Flag("upgrade", "Upgrade repository to the latest stable format").BoolVar(&c.upgradeRepositoryFormat)
+	cmd.Flag("min-client-version", "Refuse writes from kopia clients older than the provided version (X.Y.Z), 'none' to remove the restriction").StringVar(&c.minClientVersion)
 
 	cmd.Flag("epoch-refresh-frequency", "Epoch refresh frequency").DurationVar(&c.epochRefreshFrequency)
 	cmd.Flag("epoch-min-duration", "Minimal duration of a single epoch").DurationVar(&c.epochMinDuration)
@@ -63,6 +66,7 @@ func (c *commandRepositorySetParameters) setup(svc appServices, parent commandPa
 	}
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
+	svc.runLocally(cmd, nil)
 
 	c.svc = svc
 }
@@ -225,6 +229,10 @@ func (c *commandRepositorySetParameters) run(ctx context.Context, rep repo.Direc
 	c.setIntParameter(ctx, c.epochDeleteParallelism, "epoch delete parallelism", &mp.EpochParameters.DeleteParallelism, &anyChange)
 	c.setIntParameter(ctx, c.epochCheckpointFrequency, "epoch checkpoint frequency", &mp.EpochParameters.FullCheckpointFrequency, &anyChange)
 
+	if err := c.setMinClientVersion(ctx, &mp, &requiredFeatures, &anyChange); err != nil {
+		return err
+	}
+
 	requiredFeatures = c.addRemoveUpdateRequiredFeatures(requiredFeatures, &anyChange)
 
 	if !anyChange {
@@ -252,6 +260,38 @@ func (c *commandRepositorySetParameters) run(ctx context.Context, rep repo.Direc
 	return nil
 }
 
+func (c *commandRepositorySetParameters) setMinClientVersion(ctx context.Context, mp *format.MutableParameters, requiredFeatures *[]feature.Required, anyChange *bool) error {
+	if c.minClientVersion == "" {
+		return nil
+	}
+
+	// clients which are too old themselves can't change the restriction.
+	if err := format.CheckClientVersion(mp.MinClientVersion, repo.BuildVersion); err != nil {
+		return errors.Wrap(err, "unable to change minimum client version")
+	}
+
+	v := c.minClientVersion
+	if v == "none" {
+		v = ""
+	}
+
+	if err := format.CheckClientVersion(v, repo.BuildVersion); err != nil {
+		return errors.Wrap(err, "minimum client version must not be newer than the version of this client")
+	}
+
+	mp.MinClientVersion = v
+	*requiredFeatures = format.WithMinClientVersionFeature(*requiredFeatures, v)
+	*anyChange = true
+
+	if v == "" {
+		log(ctx).Infof(" - removing minimum client version.\n")
+	} else {
+		log(ctx).Infof(" - setting minimum client version to %v.\n", v)
+	}
+
+	return nil
+}
+
 func (c *commandRepositorySetParameters) addRemoveUpdateRequiredFeatures(orig []feature.Required, anyChange *bool) []feature.Required {
 	var result []feature.Required
 
@@ -281,3 +281,22 @@ func (s *formatSpecificTestSuite) TestRepositorySetParametersRequiredFeatures_Se
 	// the server will soon notice the new required feature and shut down.
 	require.ErrorContains(t, wait(), "no-such-feature")
 }
+
+func (s *formatSpecificTestSuite) TestRepositorySetParametersMinClientVersion(t *testing.T) {
+	env := s.setupInMemoryRepo(t)
+
+	env.RunAndExpectFailure(t, "repository", "set-parameters", "--min-client-version=latest")
+
+	env.RunAndExpectSuccess(t, "repository", "set-parameters", "--min-client-version=0.5.0")
+	out := env.RunAndExpectSuccess(t, "repository", "status")
+	require.Contains(t, out, "Min client version:  0.5.0")
+	require.Contains(t, out, "Required Features:   "+string(format.MinClientVersionFeature))
+
+	// development builds are allowed to write.
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	env.RunAndExpectSuccess(t, "repository", "set-parameters", "--min-client-version=none")
+	out = env.RunAndExpectSuccess(t, "repository", "status")
+	require.NotContains(t, out, "Min client version:")
+	require.NotContains(t, out, "Required Features:")
+}
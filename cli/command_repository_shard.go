@@ -0,0 +1,196 @@
+package cli
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/sharding"
+)
+
+type commandRepositoryShard struct {
+	add       commandRepositoryShardAdd
+	list      commandRepositoryShardList
+	locate    commandRepositoryShardLocate
+	rebalance commandRepositoryShardRebalance
+	remove    commandRepositoryShardRemove
+}
+
+func (c *commandRepositoryShard) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("shard", "Commands to spread snapshot sources across multiple repositories.")
+
+	c.add.setup(svc, cmd)
+	c.list.setup(svc, cmd)
+	c.locate.setup(svc, cmd)
+	c.rebalance.setup(svc, cmd)
+	c.remove.setup(svc, cmd)
+}
+
+func shardConnectionsFileName(svc appServices) string {
+	return sharding.ConnectionsFileName(svc.repositoryConfigFileName())
+}
+
+func loadShardConnections(svc appServices) (sharding.Connections, error) {
+	//nolint:wrapcheck
+	return sharding.LoadConnections(shardConnectionsFileName(svc))
+}
+
+// shardRepositories opens repositories of shards on demand, the primary shard is the connected repository.
+type shardRepositories struct {
+	svc     advancedAppServices
+	cfg     *sharding.Config
+	conns   sharding.Connections
+	primary repo.RepositoryWriter
+	opened  map[string]repo.Repository
+}
+
+func newShardRepositories(ctx context.Context, svc advancedAppServices, primary repo.Repository) (*shardRepositories, error) {
+	cfg, err := sharding.LoadConfig(ctx, primary)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load sharding configuration")
+	}
+
+	conns, err := loadShardConnections(svc)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &shardRepositories{svc: svc, cfg: cfg, conns: conns}
+
+	if w, ok := primary.(repo.RepositoryWriter); ok {
+		s.primary = w
+	}
+
+	return s, nil
+}
+
+func (s *shardRepositories) open(ctx context.Context, name string) (repo.Repository, error) {
+	if name == sharding.PrimaryShard {
+		return s.primary, nil
+	}
+
+	if r := s.opened[name]; r != nil {
+		return r, nil
+	}
+
+	sh, ok := s.cfg.Shard(name)
+	if !ok {
+		return nil, errors.Errorf("shard %q not found", name)
+	}
+
+	configFile, ok := s.conns[name]
+	if !ok {
+		return nil, errors.Errorf("shard %q is not connected, use 'kopia repository shard add %v --shard-config-file=...' to connect to it", name, name)
+	}
+
+	r, err := openRepositoryWithConfig(ctx, s.svc, configFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open shard %q", name)
+	}
+
+	if id := repositoryUniqueID(r); sh.UniqueID != "" && id != "" && id != sh.UniqueID {
+		r.Close(ctx) //nolint:errcheck
+
+		return nil, errors.Errorf("repository %v is not shard %q", configFile, name)
+	}
+
+	if s.opened == nil {
+		s.opened = map[string]repo.Repository{}
+	}
+
+	s.opened[name] = r
+
+	return r, nil
+}
+
+// openAll opens all shards besides the primary one.
+func (s *shardRepositories) openAll(ctx context.Context) (map[string]repo.Repository, error) {
+	for _, sh := range s.cfg.Shards {
+		if _, err := s.open(ctx, sh.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.opened, nil
+}
+
+// writeSession invokes the callback with a writer for the provided shard.
+func (s *shardRepositories) writeSession(ctx context.Context, name, purpose string, cb func(ctx context.Context, w repo.RepositoryWriter) error) error {
+	if name == sharding.PrimaryShard {
+		return cb(ctx, s.primary)
+	}
+
+	r, err := s.open(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	//nolint:wrapcheck
+	return repo.WriteSession(ctx, r, repo.WriteSessionOptions{Purpose: purpose}, cb)
+}
+
+func (s *shardRepositories) close(ctx context.Context) {
+	for name, r := range s.opened {
+		if err := r.Close(ctx); err != nil {
+			log(ctx).Errorf("error closing shard %v: %v", name, err)
+		}
+	}
+}
+
+// openShardedRepository returns a repository routing reads to all shards which can be opened or nil if the
+// repository has no shards or this client is not connected to any of them.
+func openShardedRepository(ctx context.Context, svc advancedAppServices, primary repo.Repository) (*sharding.Repository, error) {
+	// avoid reading sharding configuration from the repository when this client is not connected to shards.
+	if conns, err := loadShardConnections(svc); err != nil || len(conns) == 0 {
+		return nil, err
+	}
+
+	shards, err := newShardRepositories(ctx, svc, primary)
+	if err != nil {
+		return nil, err
+	}
+
+	if !shards.cfg.IsSharded() {
+		return nil, nil
+	}
+
+	// shards which can't be opened are skipped, so that sources in other shards remain accessible.
+	for _, sh := range shards.cfg.Shards {
+		if _, err := shards.open(ctx, sh.Name); err != nil {
+			log(ctx).Warnf("snapshots in shard %v are not accessible: %v", sh.Name, err)
+		}
+	}
+
+	return sharding.NewRepository(primary, shards.cfg, shards.opened), nil
+}
+
+// repositoryUniqueID returns hex-encoded unique ID of a direct repository or an empty string.
+func repositoryUniqueID(r repo.Repository) string {
+	if dr, ok := r.(repo.DirectRepository); ok {
+		return hex.EncodeToString(dr.UniqueID())
+	}
+
+	return ""
+}
+
+// openRepositoryWithConfig opens the repository using the provided configuration file, using persisted
+// password for that configuration or the password provided using flags.
+func openRepositoryWithConfig(ctx context.Context, svc advancedAppServices, configFile string) (repo.Repository, error) {
+	pass, err := svc.passwordPersistenceStrategy().GetPassword(ctx, configFile)
+	if err != nil {
+		pass, err = svc.getPasswordFromFlags(ctx, false, false)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "repository password")
+	}
+
+	r, err := repo.Open(ctx, configFile, pass, svc.optionsFromFlags(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "can't open repository using %v", configFile)
+	}
+
+	return r, nil
+}
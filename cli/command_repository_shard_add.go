@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/sharding"
+)
+
+type commandRepositoryShardAdd struct {
+	name       string
+	configFile string
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryShardAdd) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("add", "Add a repository as a shard receiving new sources or connect to a shard added by another client.")
+	cmd.Arg("name", "Name of the shard").Required().StringVar(&c.name)
+	cmd.Flag("shard-config-file", "Configuration file of the connected repository to use as a shard").Required().ExistingFileVar(&c.configFile)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+	svc.runLocally(cmd, nil)
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryShardAdd) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	cfg, err := sharding.LoadConfig(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to load sharding configuration")
+	}
+
+	conns, err := loadShardConnections(c.svc)
+	if err != nil {
+		return err
+	}
+
+	configFile, err := filepath.Abs(c.configFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to determine shard configuration file path")
+	}
+
+	// make sure the shard can be opened before adding it.
+	r, err := openRepositoryWithConfig(ctx, c.svc, configFile)
+	if err != nil {
+		return err
+	}
+
+	uniqueID := repositoryUniqueID(r)
+
+	if err := r.Close(ctx); err != nil {
+		return errors.Wrap(err, "error closing shard repository")
+	}
+
+	if sh, ok := cfg.Shard(c.name); ok {
+		// the shard was added by another client, only connect to it.
+		if _, connected := conns[c.name]; connected {
+			return errors.Errorf("shard %q already exists", c.name)
+		}
+
+		if sh.UniqueID != "" && uniqueID != "" && sh.UniqueID != uniqueID {
+			return errors.Errorf("repository %v is not shard %q", configFile, c.name)
+		}
+
+		return c.saveConnection(conns, configFile, "Connected to shard %v.\n")
+	}
+
+	if !cfg.IsSharded() {
+		// sources which already have snapshots remain in the primary repository until rebalanced.
+		sources, err := snapshot.ListSources(ctx, rep)
+		if err != nil {
+			return errors.Wrap(err, "unable to list sources")
+		}
+
+		for _, si := range sources {
+			if err := sharding.SavePlacement(ctx, rep, cfg, si, sharding.PrimaryShard); err != nil {
+				return errors.Wrap(err, "unable to place existing source")
+			}
+		}
+	}
+
+	if err := cfg.AddShard(c.name, uniqueID); err != nil {
+		return errors.Wrap(err, "unable to add shard")
+	}
+
+	if err := sharding.SaveShards(ctx, rep, cfg); err != nil {
+		return errors.Wrap(err, "unable to save sharding configuration")
+	}
+
+	return c.saveConnection(conns, configFile, "Added shard %v. Run 'kopia repository shard rebalance' to move existing sources.\n")
+}
+
+func (c *commandRepositoryShardAdd) saveConnection(conns sharding.Connections, configFile, msg string) error {
+	conns[c.name] = configFile
+
+	if err := conns.Save(shardConnectionsFileName(c.svc)); err != nil {
+		return errors.Wrap(err, "unable to save shard connections")
+	}
+
+	c.out.printStdout(msg, c.name)
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/sharding"
+)
+
+type commandRepositoryShardList struct {
+	svc advancedAppServices
+	jo  jsonOutput
+	out textOutput
+}
+
+// shardListEntry describes a shard and the configuration file used to connect to it by this client.
+type shardListEntry struct {
+	sharding.Shard
+
+	ConfigFile  string `json:"configFile,omitempty"`
+	SourceCount int    `json:"sourceCount"`
+}
+
+func (c *commandRepositoryShardList) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("list", "List shards.").Alias("ls")
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.svc = svc
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryShardList) run(ctx context.Context, rep repo.DirectRepository) error {
+	cfg, err := sharding.LoadConfig(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to load sharding configuration")
+	}
+
+	conns, err := loadShardConnections(c.svc)
+	if err != nil {
+		return err
+	}
+
+	shards := []shardListEntry{{
+		Shard:       sharding.Shard{Name: sharding.PrimaryShard, UniqueID: repositoryUniqueID(rep)},
+		ConfigFile:  c.svc.repositoryConfigFileName(),
+		SourceCount: cfg.SourceCount(sharding.PrimaryShard),
+	}}
+
+	for _, s := range cfg.Shards {
+		shards = append(shards, shardListEntry{s, conns[s.Name], cfg.SourceCount(s.Name)})
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(shards))
+		return nil
+	}
+
+	for _, s := range shards {
+		configFile := s.ConfigFile
+		if configFile == "" {
+			configFile = "(not connected)"
+		}
+
+		status := ""
+		if s.Draining {
+			status = " (draining)"
+		}
+
+		c.out.printStdout("%-20v %6v sources  %v%v\n", s.Name, s.SourceCount, configFile, status)
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/sharding"
+)
+
+type commandRepositoryShardLocate struct {
+	sources []string
+
+	out textOutput
+}
+
+func (c *commandRepositoryShardLocate) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("locate", "Show shards holding the provided sources.")
+	cmd.Arg("source", "Sources to locate").Required().StringsVar(&c.sources)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryShardLocate) run(ctx context.Context, rep repo.DirectRepository) error {
+	cfg, err := sharding.LoadConfig(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to load sharding configuration")
+	}
+
+	for _, s := range c.sources {
+		si, err := snapshot.ParseSourceInfo(s, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse %q", s)
+		}
+
+		c.out.printStdout("%v %v\n", si, cfg.Locate(si))
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package cli
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/sharding"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandRepositoryShardRebalance struct {
+	dryRun bool
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryShardRebalance) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("rebalance", "Move sources between shards to match consistent-hashing placement.")
+	cmd.Flag("dry-run", "Only print sources that would be moved").BoolVar(&c.dryRun)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+	svc.runLocally(cmd, nil)
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryShardRebalance) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	shards, err := newShardRepositories(ctx, c.svc, rep)
+	if err != nil {
+		return err
+	}
+
+	defer shards.close(ctx)
+
+	moves, err := shards.cfg.PlanRebalance()
+	if err != nil {
+		return errors.Wrap(err, "unable to plan rebalancing")
+	}
+
+	if c.dryRun {
+		for _, m := range moves {
+			c.out.printStdout("would move %v from %v to %v\n", m.Source, m.From, m.To)
+		}
+
+		c.out.printStdout("%v sources would be moved.\n", len(moves))
+
+		return nil
+	}
+
+	for _, m := range moves {
+		if err := c.moveSource(ctx, shards, m); err != nil {
+			return errors.Wrapf(err, "unable to move %v from %v to %v", m.Source, m.From, m.To)
+		}
+
+		c.out.printStdout("moved %v from %v to %v\n", m.Source, m.From, m.To)
+	}
+
+	removed := shards.cfg.RemoveDrainedShards()
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := sharding.SaveShards(ctx, rep, shards.cfg); err != nil {
+		return errors.Wrap(err, "unable to save sharding configuration")
+	}
+
+	for _, name := range removed {
+		c.out.printStdout("removed drained shard %v\n", name)
+	}
+
+	return removeShardConnections(c.svc, removed...)
+}
+
+// moveSource copies all snapshots of the source including incomplete ones to the destination shard, verifies
+// that they have been copied, records the new placement and removes them from the source shard.
+// Policies are stored in the primary repository and are not moved.
+func (c *commandRepositoryShardRebalance) moveSource(ctx context.Context, shards *shardRepositories, m sharding.Move) error {
+	from, err := shards.open(ctx, m.From)
+	if err != nil {
+		return err
+	}
+
+	existing, err := snapshot.ListSnapshots(ctx, from, m.Source)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshots")
+	}
+
+	sort.Slice(existing, func(i, j int) bool {
+		return existing[i].StartTime.Before(existing[j].StartTime)
+	})
+
+	policyTree, err := policy.TreeForSource(ctx, shards.primary, m.Source)
+	if err != nil {
+		return errors.Wrap(err, "unable to get policy tree")
+	}
+
+	if err := shards.writeSession(ctx, m.To, "ShardRebalance", func(ctx context.Context, w repo.RepositoryWriter) error {
+		uploader := snapshotfs.NewUploader(w)
+		uploader.Progress = c.svc.getProgress()
+		uploader.DisableIgnoreRules = true
+
+		for _, man := range existing {
+			if err := c.copySnapshot(ctx, uploader, from, w, policyTree, man); err != nil {
+				return err
+			}
+		}
+
+		return c.verifySnapshotsCopied(ctx, w, m.Source, existing)
+	}); err != nil {
+		return err
+	}
+
+	// record the new placement before deleting snapshots, so that interrupted rebalancing leaves
+	// unreachable copies in the old shard instead of losing track of the source.
+	if err := sharding.SavePlacement(ctx, shards.primary, shards.cfg, m.Source, m.To); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if err := shards.primary.Flush(ctx); err != nil {
+		return errors.Wrap(err, "unable to flush primary repository")
+	}
+
+	//nolint:wrapcheck
+	return shards.writeSession(ctx, m.From, "ShardRebalanceCleanup", func(ctx context.Context, w repo.RepositoryWriter) error {
+		for _, man := range existing {
+			if err := w.DeleteManifest(ctx, man.ID); err != nil {
+				return errors.Wrapf(err, "unable to delete snapshot %v", man.ID)
+			}
+		}
+
+		return nil
+	})
+}
+
+// copySnapshot copies the snapshot with all its attributes unless it has already been copied.
+func (c *commandRepositoryShardRebalance) copySnapshot(ctx context.Context, uploader *snapshotfs.Uploader, from repo.Repository, to repo.RepositoryWriter, policyTree *policy.Tree, m *snapshot.Manifest) error {
+	if copied, err := findCopiedSnapshot(ctx, to, m); err != nil || copied != nil {
+		return err
+	}
+
+	// objects referenced by the manifest are only valid in the source shard.
+	newm := m.Clone()
+	newm.ObjectDescriptions = nil
+	newm.ErrorManifest = nil
+
+	if m.RootEntry != nil {
+		root, err := snapshotfs.SnapshotRoot(from, m)
+		if err != nil {
+			return errors.Wrap(err, "error getting snapshot root entry")
+		}
+
+		previous, err := findPreviousSnapshotManifest(ctx, to, m.Source, &m.StartTime)
+		if err != nil {
+			return err
+		}
+
+		newm, err = uploader.Upload(ctx, root, policyTree, m.Source, previous...)
+		if err != nil {
+			return errors.Wrapf(err, "error copying snapshot of %v at %v", m.Source, c.out.formatTimestamp(m.StartTime.ToTime()))
+		}
+
+		newm.StartTime = m.StartTime
+		newm.EndTime = m.EndTime
+		newm.Description = m.Description
+		newm.Tags = m.Tags
+		newm.Pins = m.Pins
+		newm.IncompleteReason = m.IncompleteReason
+	}
+
+	_, err := snapshot.SaveSnapshot(ctx, to, newm)
+
+	return errors.Wrap(err, "cannot save manifest")
+}
+
+// findCopiedSnapshot returns a copy of the provided snapshot in the destination repository or nil if it does not exist.
+func findCopiedSnapshot(ctx context.Context, dest repo.Repository, m *snapshot.Manifest) (*snapshot.Manifest, error) {
+	copied, err := snapshot.ListSnapshots(ctx, dest, m.Source)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list copied snapshots")
+	}
+
+	for _, cm := range copied {
+		if cm.StartTime.Equal(m.StartTime) && cm.IncompleteReason == m.IncompleteReason {
+			return cm, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (c *commandRepositoryShardRebalance) verifySnapshotsCopied(ctx context.Context, dest repo.Repository, si snapshot.SourceInfo, snapshots []*snapshot.Manifest) error {
+	for _, man := range snapshots {
+		copied, err := findCopiedSnapshot(ctx, dest, man)
+		if err != nil {
+			return err
+		}
+
+		if copied == nil {
+			return errors.Errorf("snapshot of %v at %v was not copied", si, c.out.formatTimestamp(man.StartTime.ToTime()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/sharding"
+)
+
+type commandRepositoryShardRemove struct {
+	name string
+
+	svc advancedAppServices
+	out textOutput
+}
+
+func (c *commandRepositoryShardRemove) setup(svc advancedAppServices, parent commandParent) {
+	cmd := parent.Command("remove", "Remove a shard, shards holding sources are drained during the next rebalance.")
+	cmd.Arg("name", "Name of the shard").Required().StringVar(&c.name)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+	svc.runLocally(cmd, nil)
+
+	c.svc = svc
+	c.out.setup(svc)
+}
+
+func (c *commandRepositoryShardRemove) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	cfg, err := sharding.LoadConfig(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to load sharding configuration")
+	}
+
+	removed, err := cfg.RemoveShard(c.name)
+	if err != nil {
+		return errors.Wrap(err, "unable to remove shard")
+	}
+
+	if err := sharding.SaveShards(ctx, rep, cfg); err != nil {
+		return errors.Wrap(err, "unable to save sharding configuration")
+	}
+
+	if !removed {
+		c.out.printStdout("Shard %v holds %v sources and is now draining. Run 'kopia repository shard rebalance' to move them.\n", c.name, cfg.SourceCount(c.name))
+		return nil
+	}
+
+	if err := removeShardConnections(c.svc, c.name); err != nil {
+		return err
+	}
+
+	c.out.printStdout("Removed shard %v.\n", c.name)
+
+	return nil
+}
+
+// removeShardConnections forgets configuration files of the provided removed shards.
+func removeShardConnections(svc appServices, names ...string) error {
+	conns, err := loadShardConnections(svc)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range names {
+		delete(conns, n)
+	}
+
+	return errors.Wrap(conns.Save(shardConnectionsFileName(svc)), "unable to save shard connections")
+}
@@ -0,0 +1,91 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRepositoryShard(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+	shardEnv := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	shardEnv.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", shardEnv.RepoDir)
+
+	existingDir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(existingDir, "file.txt"), []byte("existing"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", existingDir)
+
+	env.RunAndExpectSuccess(t, "repo", "shard", "add", "shard1", "--shard-config-file", filepath.Join(shardEnv.ConfigDir, ".kopia.config"))
+	env.RunAndExpectFailure(t, "repo", "shard", "add", "shard1", "--shard-config-file", filepath.Join(shardEnv.ConfigDir, ".kopia.config"))
+
+	// source which had snapshots before sharding stays in the primary repository.
+	mustGetLineContaining(t, env.RunAndExpectSuccess(t, "repo", "shard", "locate", existingDir), "primary")
+
+	var dirs []string
+
+	for i := 0; i < 10; i++ {
+		d := testutil.TempDirectory(t)
+		require.NoError(t, os.WriteFile(filepath.Join(d, "file.txt"), []byte(d), 0o600))
+
+		env.RunAndExpectSuccess(t, "snapshot", "create", d)
+
+		dirs = append(dirs, d)
+	}
+
+	inShard := 0
+
+	for _, d := range dirs {
+		line := env.RunAndExpectSuccess(t, "repo", "shard", "locate", d)[0]
+		snaps := shardEnv.RunAndExpectSuccess(t, "snapshot", "list", d)
+
+		if strings.HasSuffix(line, " shard1") {
+			inShard++
+
+			require.NotEmpty(t, snaps, "snapshot of %v not found in shard", d)
+		} else {
+			require.Empty(t, snaps, "snapshot of %v unexpectedly found in shard", d)
+		}
+
+		// snapshots in shards are visible through the primary repository.
+		require.NotEmpty(t, env.RunAndExpectSuccess(t, "snapshot", "list", d))
+
+		// policies are always stored in the primary repository.
+		env.RunAndExpectSuccess(t, "policy", "set", d, "--keep-latest", "7")
+		mustGetLineContaining(t, env.RunAndExpectSuccess(t, "policy", "show", d), "(defined for this target)")
+		require.NotContains(t, strings.Join(shardEnv.RunAndExpectSuccess(t, "policy", "list", "--json"), "\n"), d)
+
+		env.RunAndExpectSuccess(t, "snapshot", "create", d)
+	}
+
+	require.Len(t, mustListSnapshots(t, env), 2*len(dirs)+1)
+
+	mustGetLineContaining(t, env.RunAndExpectSuccess(t, "repo", "shard", "list"), "shard1")
+
+	// removing the shard drains it during rebalance.
+	env.RunAndExpectSuccess(t, "repo", "shard", "remove", "shard1")
+	env.RunAndExpectSuccess(t, "repo", "shard", "rebalance", "--dry-run")
+	env.RunAndExpectSuccess(t, "repo", "shard", "rebalance")
+
+	for _, d := range dirs {
+		mustGetLineContaining(t, env.RunAndExpectSuccess(t, "repo", "shard", "locate", d), "primary")
+		require.NotEmpty(t, env.RunAndExpectSuccess(t, "snapshot", "list", d))
+		require.Empty(t, shardEnv.RunAndExpectSuccess(t, "snapshot", "list", d))
+	}
+
+	require.Len(t, mustListSnapshots(t, env), 2*len(dirs)+1)
+
+	lines := env.RunAndExpectSuccess(t, "repo", "shard", "list")
+	require.Len(t, lines, 1, "drained shard was not removed: %v (%v sources were in shard)", lines, inShard)
+}
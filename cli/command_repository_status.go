@@ -39,6 +39,7 @@ type RepositoryStatus struct {
 	ContentFormat format.ContentFormat            `json:"contentFormat"`
 	ObjectFormat  format.ObjectFormat             `json:"objectFormat"`
 	BlobRetention format.BlobStorageConfiguration `json:"blobRetention"`
+	AutoTune      *format.AutoTuneResult          `json:"autoTune,omitempty"`
 }
 
 func (c *commandRepositoryStatus) setup(svc advancedAppServices, parent commandParent) {
@@ -66,6 +67,7 @@ func (c *commandRepositoryStatus) outputJSON(ctx context.Context, r repo.Reposit
 		s.BlobRetention, _ = dr.FormatManager().BlobCfgBlob(ctx)
 		s.Storage = scrubber.ScrubSensitiveData(reflect.ValueOf(ci)).Interface().(blob.ConnectionInfo) //nolint:forcetypeassert
 		s.ContentFormat = dr.FormatManager().ScrubbedContentFormat()
+		s.AutoTune, _ = dr.FormatManager().AutoTune(ctx)
 
 		switch cp, err := dr.BlobVolume().GetCapacity(ctx); {
 		case err == nil:
@@ -183,12 +185,28 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	c.out.printStdout("Unique ID:           %x\n", dr.UniqueID())
 	c.out.printStdout("Hash:                %v\n", contentFormat.GetHashFunction())
 	c.out.printStdout("Encryption:          %v\n", contentFormat.GetEncryptionAlgorithm())
+
+	if contentFormat.GetDataKey() != nil {
+		c.out.printStdout("Data key:            separate from metadata\n")
+	}
+
+	if pk := contentFormat.GetPreviousEncryptionKeys(); len(pk) > 0 {
+		c.out.printStdout("Encryption key ID:   %v (%v previous keys pending re-encryption)\n", contentFormat.GetEncryptionKeyID(), len(pk))
+	} else if id := contentFormat.GetEncryptionKeyID(); id != 0 {
+		c.out.printStdout("Encryption key ID:   %v\n", id)
+	}
+
 	c.out.printStdout("Splitter:            %v\n", dr.ObjectFormat().Splitter)
 	c.out.printStdout("Format version:      %v\n", mp.Version)
 	c.out.printStdout("Content compression: %v\n", mp.IndexVersion >= index.Version2)
 	c.out.printStdout("Password changes:    %v\n", contentFormat.SupportsPasswordChange())
 
+	if mp.MinClientVersion != "" {
+		c.out.printStdout("Min client version:  %v\n", mp.MinClientVersion)
+	}
+
 	c.outputRequiredFeatures(ctx, dr)
+	c.outputAutoTune(ctx, dr)
 
 	c.out.printStdout("Max pack length:     %v\n", units.BytesString(int64(mp.MaxPackSize)))
 	c.out.printStdout("Index Format:        v%v\n", mp.IndexVersion)
@@ -251,6 +269,19 @@ func (c *commandRepositoryStatus) run(ctx context.Context, rep repo.Repository)
 	return nil
 }
 
+func (c *commandRepositoryStatus) outputAutoTune(ctx context.Context, dr repo.DirectRepository) {
+	at, _ := dr.FormatManager().AutoTune(ctx)
+	if at == nil {
+		return
+	}
+
+	c.out.printStdout("Auto-tuned:          %v\n", c.out.formatTimestamp(at.Time))
+
+	for _, r := range at.Rationale {
+		c.out.printStdout("                     %v\n", r)
+	}
+}
+
 func (c *commandRepositoryStatus) outputRequiredFeatures(ctx context.Context, dr repo.DirectRepository) {
 	if req, _ := dr.FormatManager().RequiredFeatures(ctx); len(req) > 0 {
 		var featureIDs []string
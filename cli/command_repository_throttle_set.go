@@ -17,6 +17,7 @@ func (c *commandRepositoryThrottleSet) setup(svc appServices, parent commandPare
 	c.cts.setup(cmd)
 
 	cmd.Action(svc.directRepositoryWriteAction(c.run))
+	svc.runLocally(cmd, nil)
 }
 
 func (c *commandRepositoryThrottleSet) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
@@ -30,6 +30,8 @@ type commandRepositoryUpgrade struct {
 	maxPermittedClockDrift time.Duration
 
 	svc advancedAppServices
+
+	out textOutput
 }
 
 const (
@@ -48,13 +50,16 @@ type commandRepositoryUpgrade struct {
 
 func (c *commandRepositoryUpgrade) setup(svc advancedAppServices, parent commandParent) {
 	// override the parent, the upgrade sub-command becomes the new parent here-onwards
-	parent = parent.Command("upgrade", fmt.Sprintf("Upgrade repository format.\n\n%s", warningColor.Sprint(experimentalWarning))).Hidden().
+	upgradeCmd := parent.Command("upgrade", fmt.Sprintf("Upgrade repository format.\n\n%s", warningColor.Sprint(experimentalWarning))).Hidden().
 		Validate(func(tmpCmd *kingpin.CmdClause) error {
 			if v := os.Getenv(c.svc.EnvName(upgradeLockFeatureEnv)); v == "" {
 				return errors.Errorf("please set %q env variable to use this feature", upgradeLockFeatureEnv)
 			}
 			return nil
 		})
+	svc.runLocally(upgradeCmd, nil)
+
+	parent = upgradeCmd
 
 	beginCmd := parent.Command("begin", "Begin upgrade.")
 	beginCmd.Flag("io-drain-timeout", "Max time it should take all other Kopia clients to drop repository connections").Default(format.DefaultRepositoryBlobCacheDuration.String()).DurationVar(&c.ioDrainTimeout)
@@ -88,6 +93,8 @@ func (c *commandRepositoryUpgrade) setup(svc advancedAppServices, parent command
 	validateCmd.Action(svc.directRepositoryWriteAction(c.validateAction))
 
 	c.svc = svc
+
+	c.out.setup(svc)
 }
 
 // assign store the info struct in a map that can be used to compare indexes.
@@ -411,7 +418,7 @@ func (c *commandRepositoryUpgrade) drainAllClients(ctx context.Context, rep repo
 		upgradeTime := l.UpgradeTime()
 		now := rep.Time()
 
-		log(ctx).Infof("Waiting for %s to allow all other kopia clients to drain ...", upgradeTime.Sub(rep.Time()).Round(time.Second))
+		log(ctx).Infof("Waiting for %s to allow all other kopia clients to drain ...", c.out.formatDuration(upgradeTime.Sub(rep.Time()).Round(time.Second)))
 
 		locked, writersDrained := l.IsLocked(now)
 		if locked {
@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/resticfs"
+	"github.com/kopia/kopia/internal/mount"
+	"github.com/kopia/kopia/snapshot/restore"
+)
+
+type commandRestic struct {
+	snapshots commandResticSnapshots
+	restore   commandResticRestore
+	mount     commandResticMount
+}
+
+func (c *commandRestic) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("restic", "[EXPERIMENTAL] Read-only access to restic repositories.")
+
+	c.snapshots.setup(svc, cmd)
+	c.restore.setup(svc, cmd)
+	c.mount.setup(svc, cmd)
+}
+
+// resticRepositoryFlags are flags used to open restic repository.
+type resticRepositoryFlags struct {
+	path     string
+	password string
+
+	svc appServices
+}
+
+func (c *resticRepositoryFlags) setup(svc appServices, cmd *kingpin.CmdClause) {
+	cmd.Flag("restic-repo", "Path to restic repository").Envar("RESTIC_REPOSITORY").Required().StringVar(&c.path)
+	cmd.Flag("restic-password", "Password of restic repository").Envar("RESTIC_PASSWORD").StringVar(&c.password)
+
+	c.svc = svc
+}
+
+func (c *resticRepositoryFlags) open(ctx context.Context) (*resticfs.Repository, error) {
+	pass := c.password
+	if pass == "" {
+		p, err := askPass(c.svc.stdout(), "Enter password to open restic repository: ")
+		if err != nil {
+			return nil, err
+		}
+
+		pass = p
+	}
+
+	rep, err := resticfs.Open(ctx, c.path, pass)
+
+	return rep, errors.Wrap(err, "unable to open restic repository")
+}
+
+// resticSnapshotEntry returns the root of the snapshot with the provided ID, optionally followed by a path within the snapshot.
+func resticSnapshotEntry(ctx context.Context, rep *resticfs.Repository, idWithPath string) (fs.Entry, error) {
+	id, subPath, _ := strings.Cut(idWithPath, "/")
+
+	s, err := rep.FindSnapshot(ctx, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to find snapshot %v", id)
+	}
+
+	var e fs.Entry = rep.SnapshotRoot(s)
+
+	for _, p := range strings.Split(subPath, "/") {
+		if p == "" {
+			continue
+		}
+
+		dir, ok := e.(fs.Directory)
+		if !ok {
+			return nil, errors.Errorf("%v is not a directory", e.Name())
+		}
+
+		e, err = dir.Child(ctx, p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get %v", p)
+		}
+	}
+
+	return e, nil
+}
+
+type commandResticSnapshots struct {
+	repo resticRepositoryFlags
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandResticSnapshots) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("snapshots", "List snapshots in restic repository.")
+	c.repo.setup(svc, cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+type resticSnapshotJSON struct {
+	ID string `json:"id"`
+	*resticfs.Snapshot
+}
+
+func (c *commandResticSnapshots) run(ctx context.Context) error {
+	rep, err := c.repo.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer rep.Close()
+
+	snaps, err := rep.ListSnapshots(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to list snapshots")
+	}
+
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	for _, s := range snaps {
+		if c.jo.jsonOutput {
+			jl.emit(resticSnapshotJSON{s.ID, s})
+			continue
+		}
+
+		c.out.printStdout("%v %v %v %v\n", s.ShortID(), c.out.formatTimestamp(s.Time), s.Hostname, strings.Join(s.Paths, ","))
+	}
+
+	return nil
+}
+
+type commandResticRestore struct {
+	repo resticRepositoryFlags
+
+	snapshotID string
+	targetPath string
+
+	parallel     int
+	ignoreErrors bool
+	skipOwners   bool
+	overwrite    bool
+}
+
+func (c *commandResticRestore) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("restore", "Restore restic snapshot to local filesystem.")
+	cmd.Arg("snapshot", "Snapshot ID, optionally followed by path within the snapshot, or 'latest'").Required().StringVar(&c.snapshotID)
+	cmd.Arg("target-path", "Path of the directory to restore to").Required().StringVar(&c.targetPath)
+	cmd.Flag("parallel", "Restore parallelism (1=disable)").Default("8").IntVar(&c.parallel)
+	cmd.Flag("ignore-errors", "Ignore all errors").BoolVar(&c.ignoreErrors)
+	cmd.Flag("skip-owners", "Skip owners during restore").BoolVar(&c.skipOwners)
+	cmd.Flag("overwrite", "Overwrite existing files and directories").BoolVar(&c.overwrite)
+	c.repo.setup(svc, cmd)
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandResticRestore) run(ctx context.Context) error {
+	rep, err := c.repo.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer rep.Close()
+
+	e, err := resticSnapshotEntry(ctx, rep, c.snapshotID)
+	if err != nil {
+		return err
+	}
+
+	output := &restore.FilesystemOutput{
+		TargetPath:           c.targetPath,
+		OverwriteDirectories: c.overwrite,
+		OverwriteFiles:       c.overwrite,
+		OverwriteSymlinks:    c.overwrite,
+		SkipOwners:           c.skipOwners,
+	}
+
+	if err := output.Init(ctx); err != nil {
+		return errors.Wrap(err, "unable to create output")
+	}
+
+	st, err := restore.Entry(ctx, nil, output, e, restore.Options{
+		Parallel:               c.parallel,
+		IgnoreErrors:           c.ignoreErrors,
+		RestoreDirEntryAtDepth: math.MaxInt32,
+	})
+	if err != nil {
+		return errors.Wrap(err, "error restoring")
+	}
+
+	printRestoreStats(ctx, &st)
+
+	return nil
+}
+
+type commandResticMount struct {
+	repo resticRepositoryFlags
+
+	snapshotID string
+	mountPoint string
+	browse     bool
+	webdav     bool
+
+	svc appServices
+}
+
+func (c *commandResticMount) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("mount", "Mount restic snapshots as a local filesystem.")
+	cmd.Arg("snapshot", "Snapshot ID, optionally followed by path within the snapshot, 'latest' or 'all'").Default("all").StringVar(&c.snapshotID)
+	cmd.Arg("mountPoint", "Mount point").Default("*").StringVar(&c.mountPoint)
+	cmd.Flag("browse", "Open file browser").BoolVar(&c.browse)
+	cmd.Flag("webdav", "Use WebDAV to mount the snapshots regardless of fuse availability.").BoolVar(&c.webdav)
+	c.repo.setup(svc, cmd)
+	c.svc = svc
+	cmd.Action(svc.noRepositoryAction(c.run))
+}
+
+func (c *commandResticMount) run(ctx context.Context) error {
+	rep, err := c.repo.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer rep.Close()
+
+	var dir fs.Directory
+
+	if c.snapshotID == "all" {
+		dir, err = rep.AllSnapshots(ctx)
+		if err != nil {
+			return errors.Wrap(err, "unable to list snapshots")
+		}
+	} else {
+		e, err := resticSnapshotEntry(ctx, rep, c.snapshotID)
+		if err != nil {
+			return err
+		}
+
+		d, ok := e.(fs.Directory)
+		if !ok {
+			return errors.Errorf("%v is not a directory", c.snapshotID)
+		}
+
+		dir = d
+	}
+
+	return mountDirectoryAndWait(ctx, c.svc, dir, c.snapshotID, c.mountPoint, mount.Options{PreferWebDAV: c.webdav}, c.browse)
+}
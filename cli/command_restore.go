@@ -9,8 +9,10 @@
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -24,6 +26,7 @@
 	"github.com/kopia/kopia/repo/content/index"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
 	"github.com/kopia/kopia/snapshot/restore"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
@@ -88,7 +91,10 @@
 	restoreCommandSourcePathHelp = `Two forms: 1. Source directory ID/path in the form of a
 directory ID and optionally a sub-directory path. For example,
 'kffbb7c28ea6c34d6cbe555d1cf80faa9' or
-'kffbb7c28ea6c34d6cbe555d1cf80faa9/subdir1/subdir2'
+'kffbb7c28ea6c34d6cbe555d1cf80faa9/subdir1/subdir2',
+or a snapshot name in the form of source followed by ':latest', ':oldest',
+':yesterday', ':YYYY-MM-DD' or '~N' (Nth snapshot before the latest), for example
+'/home/user:yesterday/subdir1' or '/home/user~2'
 followed by the path of the directory for the contents to be restored.
 
 2. one or more placeholder files of the form path.kopia-entry
@@ -108,22 +114,43 @@ type commandRestore struct {
 	restoreOverwriteDirectories   bool
 	restoreOverwriteFiles         bool
 	restoreOverwriteSymlinks      bool
+	restoreJunctionsAsSymlinks    bool
 	restoreWriteSparseFiles       bool
+	restoreCheckDiskSpace         bool
+	restoreMaxWriteSpeed          float64
 	restoreConsistentAttributes   bool
 	restoreMode                   string
 	restoreParallel               int
 	restoreIgnorePermissionErrors bool
 	restoreWriteFilesAtomically   bool
+	restoreDeferMetadata          bool
 	restoreSkipTimes              bool
 	restoreSkipOwners             bool
+	restoreMapOwnersByName        bool
+	restoreMapOwnersByNameSet     bool
 	restoreSkipPermissions        bool
 	restoreIncremental            bool
 	restoreIgnoreErrors           bool
 	restoreShallowAtDepth         int32
 	minSizeForPlaceholder         int32
 	snapshotTime                  string
+	conflictStrategy              string
+	keepBothSuffix                string
+	conflictReportFile            string
 
 	restores []restoreSourceTarget
+
+	// conflict resolution chosen interactively for all remaining conflicts.
+	conflictAnswerForAll restore.ConflictStrategy
+
+	events jsonEvents
+
+	// +checklocks:lastStatsMutex
+	lastStats      restore.Stats
+	lastStatsMutex sync.Mutex
+
+	svc appServices
+	out textOutput
 }
 
 func (c *commandRestore) setup(svc appServices, parent commandParent) {
@@ -134,21 +161,33 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	cmd.Flag("overwrite-directories", "Overwrite existing directories").Default("true").BoolVar(&c.restoreOverwriteDirectories)
 	cmd.Flag("overwrite-files", "Specifies whether or not to overwrite already existing files").Default("true").BoolVar(&c.restoreOverwriteFiles)
 	cmd.Flag("overwrite-symlinks", "Specifies whether or not to overwrite already existing symlinks").Default("true").BoolVar(&c.restoreOverwriteSymlinks)
+	cmd.Flag("junctions-as-symlinks", "Restore Windows junctions and mount points as symbolic links").BoolVar(&c.restoreJunctionsAsSymlinks)
 	cmd.Flag("write-sparse-files", "When doing a restore, attempt to write files sparsely-allocating the minimum amount of disk space needed.").Default("false").BoolVar(&c.restoreWriteSparseFiles)
+	cmd.Flag("check-disk-space", "Fail before restoring to local filesystem if the target does not have enough free space for files that will be written (use --no-check-disk-space to skip)").Default("true").BoolVar(&c.restoreCheckDiskSpace)
+	cmd.Flag("max-write-speed", "Limit the speed of writing restored files to local filesystem.").PlaceHolder("BYTES_PER_SEC").FloatVar(&c.restoreMaxWriteSpeed)
 	cmd.Flag("consistent-attributes", "When multiple snapshots match, fail if they have inconsistent attributes").Envar(svc.EnvName("KOPIA_RESTORE_CONSISTENT_ATTRIBUTES")).BoolVar(&c.restoreConsistentAttributes)
-	cmd.Flag("mode", "Override restore mode").Default(restoreModeAuto).EnumVar(&c.restoreMode, restoreModeAuto, restoreModeLocal, restoreModeZip, restoreModeZipNoCompress, restoreModeTar, restoreModeTgz)
+	cmd.Flag("mode", "Override restore mode").Default(restoreModeAuto).EnumVar(&c.restoreMode, restoreModeAuto, restoreModeLocal, restoreModeZip, restoreModeZipNoCompress, restoreModeTar, restoreModeTgz, restoreModeSquashFS)
 	cmd.Flag("parallel", "Restore parallelism (1=disable)").Default("8").IntVar(&c.restoreParallel)
 	cmd.Flag("skip-owners", "Skip owners during restore").BoolVar(&c.restoreSkipOwners)
+	cmd.Flag("map-owners-by-name", "Map owners by user and group names captured at snapshot time instead of numeric IDs (overrides files policy of the restore target)").IsSetByUser(&c.restoreMapOwnersByNameSet).BoolVar(&c.restoreMapOwnersByName)
 	cmd.Flag("skip-permissions", "Skip permissions during restore").BoolVar(&c.restoreSkipPermissions)
 	cmd.Flag("skip-times", "Skip times during restore").BoolVar(&c.restoreSkipTimes)
 	cmd.Flag("ignore-permission-errors", "Ignore permission errors").Default("true").BoolVar(&c.restoreIgnorePermissionErrors)
 	cmd.Flag("write-files-atomically", "Write files atomically to disk, ensuring they are either fully committed, or not written at all, preventing partially written files").Default("false").BoolVar(&c.restoreWriteFilesAtomically)
+	cmd.Flag("defer-metadata", "Apply owners, permissions and times in a separate parallel pass after all contents have been restored").BoolVar(&c.restoreDeferMetadata)
 	cmd.Flag("ignore-errors", "Ignore all errors").BoolVar(&c.restoreIgnoreErrors)
 	cmd.Flag("skip-existing", "Skip files and symlinks that exist in the output").BoolVar(&c.restoreIncremental)
 	cmd.Flag("shallow", "Shallow restore the directory hierarchy starting at this level (default is to deep restore the entire hierarchy.)").Int32Var(&c.restoreShallowAtDepth)
 	cmd.Flag("shallow-minsize", "When doing a shallow restore, write actual files instead of placeholders smaller than this size.").Int32Var(&c.minSizeForPlaceholder)
 	cmd.Flag("snapshot-time", "When using a path as the source, use the latest snapshot available before this date. Default is latest").StringVar(&c.snapshotTime)
+	cmd.Flag("conflict-strategy", "How to handle files that already exist in the target, overrides --overwrite-files").EnumVar(&c.conflictStrategy, restore.ConflictStrategies...)
+	cmd.Flag("keep-both-suffix", "Suffix added to names of files restored next to existing ones when using keep-both strategy").Default(restore.DefaultKeepBothSuffix).StringVar(&c.keepBothSuffix)
+	cmd.Flag("conflict-report", "Write JSON report of conflicts with existing files and their resolution to the provided file").StringVar(&c.conflictReportFile)
+	c.events.setup(svc, cmd)
 	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.svc = svc
+	c.out.setup(svc)
 }
 
 const (
@@ -158,6 +197,7 @@ func (c *commandRestore) setup(svc appServices, parent commandParent) {
 	restoreModeZipNoCompress = "zip-nocompress"
 	restoreModeTar           = "tar"
 	restoreModeTgz           = "tgz"
+	restoreModeSquashFS      = "squashfs"
 )
 
 // constructTargetPairs builds the sourceIdPathPairs array for this
@@ -248,17 +288,29 @@ func (c *commandRestore) restoreOutput(ctx context.Context, rep repo.Repository)
 	m := c.detectRestoreMode(ctx, c.restoreMode, targetpath)
 	switch m {
 	case restoreModeLocal:
+		mapOwnersByName, err := c.mapOwnersByName(ctx, rep, targetpath)
+		if err != nil {
+			return nil, err
+		}
+
 		o := &restore.FilesystemOutput{
 			TargetPath:             targetpath,
 			OverwriteDirectories:   c.restoreOverwriteDirectories,
 			OverwriteFiles:         c.restoreOverwriteFiles,
 			OverwriteSymlinks:      c.restoreOverwriteSymlinks,
+			JunctionsAsSymlinks:    c.restoreJunctionsAsSymlinks,
 			IgnorePermissionErrors: c.restoreIgnorePermissionErrors,
 			WriteFilesAtomically:   c.restoreWriteFilesAtomically,
+			DeferMetadata:          c.restoreDeferMetadata,
 			SkipOwners:             c.restoreSkipOwners,
+			MapOwnersByName:        mapOwnersByName,
 			SkipPermissions:        c.restoreSkipPermissions,
 			SkipTimes:              c.restoreSkipTimes,
 			WriteSparseFiles:       c.restoreWriteSparseFiles,
+			MaxWriteBytesPerSecond: c.restoreMaxWriteSpeed,
+			ConflictStrategy:       restore.ConflictStrategy(c.conflictStrategy),
+			KeepBothSuffix:         c.keepBothSuffix,
+			ConflictResolver:       c.promptForConflictResolution,
 		}
 
 		if err := o.Init(ctx); err != nil {
@@ -296,11 +348,50 @@ func (c *commandRestore) restoreOutput(ctx context.Context, rep repo.Repository)
 
 		return restore.NewTarOutput(gzip.NewWriter(f)), nil
 
+	case restoreModeSquashFS:
+		f, err := os.Create(targetpath) //nolint:gosec
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create output file")
+		}
+
+		o, err := restore.NewSquashFSOutput(f, f)
+		if err != nil {
+			f.Close() //nolint:errcheck
+			return nil, err
+		}
+
+		return o, nil
+
 	default:
 		return nil, errors.Errorf("unknown mode %v", m)
 	}
 }
 
+// mapOwnersByName determines whether owners should be restored by name, --map-owners-by-name takes
+// precedence over the files policy of the restore target, since numbering of users and groups is
+// a property of the system being restored to.
+func (c *commandRestore) mapOwnersByName(ctx context.Context, rep repo.Repository, targetpath string) (bool, error) {
+	if c.restoreMapOwnersByNameSet {
+		return c.restoreMapOwnersByName, nil
+	}
+
+	abspath, err := filepath.Abs(targetpath)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to determine absolute path")
+	}
+
+	pol, _, _, err := policy.GetEffectivePolicy(ctx, rep, snapshot.SourceInfo{
+		Host:     rep.ClientOptions().Hostname,
+		UserName: rep.ClientOptions().Username,
+		Path:     abspath,
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "unable to get policy of restore target")
+	}
+
+	return pol.FilesPolicy.RestoreOwnersByName.OrDefault(false), nil
+}
+
 func (c *commandRestore) detectRestoreMode(ctx context.Context, m, targetpath string) string {
 	if m != "auto" {
 		return m
@@ -319,6 +410,10 @@ func (c *commandRestore) detectRestoreMode(ctx context.Context, m, targetpath st
 		log(ctx).Infof("Restoring to a tar+gzip file (%v)...", targetpath)
 		return restoreModeTgz
 
+	case strings.HasSuffix(targetpath, ".squashfs") || strings.HasSuffix(targetpath, ".sqfs"):
+		log(ctx).Infof("Restoring to a SquashFS image (%v)...", targetpath)
+		return restoreModeSquashFS
+
 	default:
 		log(ctx).Infof("Restoring to local filesystem (%v) with parallelism=%v...", targetpath, c.restoreParallel)
 		return restoreModeLocal
@@ -365,12 +460,35 @@ func (c *commandRestore) setupPlaceholderExpansion(ctx context.Context, rep repo
 	return rootEntry, nil
 }
 
-func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
+func (c *commandRestore) run(ctx context.Context, rep repo.Repository) (err error) {
+	if err := c.events.begin(); err != nil {
+		return err
+	}
+
+	defer func() { c.events.finish(err) }()
+
+	c.events.startProgressEvents(c.progressStats)
+
+	return c.restoreAll(ctx, rep)
+}
+
+func (c *commandRestore) progressStats() any {
+	c.lastStatsMutex.Lock()
+	defer c.lastStatsMutex.Unlock()
+
+	return c.lastStats
+}
+
+func (c *commandRestore) restoreAll(ctx context.Context, rep repo.Repository) error {
 	output, oerr := c.restoreOutput(ctx, rep)
 	if oerr != nil {
 		return errors.Wrap(oerr, "unable to initialize output")
 	}
 
+	if _, ok := output.(*restore.FilesystemOutput); !ok && (c.conflictStrategy != "" || c.conflictReportFile != "") {
+		return errors.New("conflict strategies are only supported when restoring to local filesystem")
+	}
+
 	for _, rstp := range c.restores {
 		var rootEntry fs.Entry
 
@@ -395,6 +513,10 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 			rootEntry = re
 		}
 
+		if err := c.checkDiskSpace(ctx, output, rootEntry); err != nil {
+			return err
+		}
+
 		eta := timetrack.Start()
 
 		st, err := restore.Entry(ctx, rep, output, rootEntry, restore.Options{
@@ -404,6 +526,10 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 			RestoreDirEntryAtDepth: c.restoreShallowAtDepth,
 			MinSizeForPlaceholder:  c.minSizeForPlaceholder,
 			ProgressCallback: func(ctx context.Context, stats restore.Stats) {
+				c.lastStatsMutex.Lock()
+				c.lastStats = stats
+				c.lastStatsMutex.Unlock()
+
 				restoredCount := stats.RestoredFileCount + stats.RestoredDirCount + stats.RestoredSymlinkCount + stats.SkippedCount
 				enqueuedCount := stats.EnqueuedFileCount + stats.EnqueuedDirCount + stats.EnqueuedSymlinkCount
 
@@ -441,14 +567,47 @@ func (c *commandRestore) run(ctx context.Context, rep repo.Repository) error {
 		}
 
 		printRestoreStats(ctx, &st)
+
+		c.events.emit(jsonEventResult, restoreResult{Source: rstp.source, Stats: st})
 	}
 
-	return nil
+	return c.reportConflicts(ctx, output)
+}
+
+// restoreResult is emitted as a JSON event for each completed restore.
+type restoreResult struct {
+	Source string        `json:"source"`
+	Stats  restore.Stats `json:"stats"`
 }
 
-// tryToConvertPathToID checks if the source is a path and in this case returns the ID of the snapshot
-// containing the latest version available.
+// checkDiskSpace verifies that local filesystem target has enough space for files of the entry that will be written.
+func (c *commandRestore) checkDiskSpace(ctx context.Context, output restore.Output, rootEntry fs.Entry) error {
+	fso, ok := output.(*restore.FilesystemOutput)
+	if !ok || !c.restoreCheckDiskSpace || c.restoreShallowAtDepth != unlimitedDepth {
+		return nil
+	}
+
+	required, err := fso.EstimateRequiredSpace(ctx, rootEntry, c.restoreIncremental)
+	if err != nil {
+		return errors.Wrap(err, "unable to estimate required disk space")
+	}
+
+	return errors.Wrap(fso.CheckDiskSpace(ctx, required), "disk space check failed (use --no-check-disk-space to skip)")
+}
+
+// tryToConvertPathToID checks if the source is a path (and not a friendly snapshot name) and in this case
+// returns the ID of the snapshot containing the latest version available.
 func (c *commandRestore) tryToConvertPathToID(ctx context.Context, rep repo.Repository, source string) (string, error) {
+	man, _, err := snapshotfs.ResolveSnapshotName(ctx, rep, source)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to resolve snapshot name")
+	}
+
+	if man != nil {
+		// friendly snapshot names are resolved when looking up the entry.
+		return source, nil
+	}
+
 	pathElements := strings.Split(filepath.ToSlash(source), "/")
 
 	if pathElements[0] != "" {
@@ -502,7 +661,7 @@ func (c *commandRestore) tryToConvertPathToID(ctx context.Context, rep repo.Repo
 		"   Snapshot source: %v\n"+
 		"   Snapshot time: %v\n"+
 		"   Relative path: %v\n"+
-		"   Object ID: %v", m.Source, formatTimestamp(m.StartTime.ToTime()), relPath, ohid)
+		"   Object ID: %v", m.Source, c.out.formatTimestamp(m.StartTime.ToTime()), relPath, ohid)
 
 	return ohid.String(), nil
 }
@@ -621,6 +780,22 @@ func computeMaxTime(timespec string) (time.Time, error) {
 	return now, errors.Errorf("Invalid time spec: %v", timespec)
 }
 
+// findPathPartsWithinSnapshot returns path elements of the given path relative to the root of the snapshot,
+// which for partial snapshots is the subpath of the source.
+func findPathPartsWithinSnapshot(m *snapshot.Manifest, path string) ([]string, error) {
+	parts, err := findRelativePathParts(m, path)
+	if err != nil || m.Subpath == "" {
+		return parts, err
+	}
+
+	subpathParts := strings.Split(m.Subpath, "/")
+	if len(parts) < len(subpathParts) || !slices.Equal(parts[0:len(subpathParts)], subpathParts) {
+		return nil, errors.Errorf("%v is not within subpath %v of the snapshot", path, m.Subpath)
+	}
+
+	return parts[len(subpathParts):], nil
+}
+
 func findLastManifestWithPath(ctx context.Context, rep repo.Repository, ms []*snapshot.Manifest, path string, filter func(*snapshot.Manifest, int, int) bool) (*snapshot.Manifest, string, object.ID) {
 	ms = snapshot.SortByTime(ms, true)
 
@@ -644,7 +819,7 @@ type candidateInfo struct {
 			continue
 		}
 
-		pathElements, err := findRelativePathParts(m, path)
+		pathElements, err := findPathPartsWithinSnapshot(m, path)
 		if err != nil {
 			// Ignore this snapshot
 			continue
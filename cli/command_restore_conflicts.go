@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/snapshot/restore"
+)
+
+// conflictAnswers maps answers to the interactive conflict prompt to resolutions,
+// upper-case answers apply the resolution to all remaining conflicts.
+//
+//nolint:gochecknoglobals
+var conflictAnswers = map[string]restore.ConflictStrategy{
+	"o": restore.ConflictOverwrite,
+	"k": restore.ConflictKeepExisting,
+	"n": restore.ConflictKeepNewer,
+	"b": restore.ConflictKeepBoth,
+}
+
+// promptForConflictResolution asks the user how to resolve a conflict with an existing file.
+// Calls are serialized by the restore output.
+func (c *commandRestore) promptForConflictResolution(_ context.Context, cf restore.Conflict) (restore.ConflictStrategy, error) {
+	if c.conflictAnswerForAll != "" {
+		return c.conflictAnswerForAll, nil
+	}
+
+	for {
+		c.out.printStderr("\n%v already exists.\n", cf.Path)
+		c.out.printStderr("  existing: %v, modified %v\n", units.BytesString(cf.ExistingSize), c.out.formatTimestamp(cf.ExistingModTime))
+		c.out.printStderr("  restored: %v, modified %v\n", units.BytesString(cf.RestoredSize), c.out.formatTimestamp(cf.RestoredModTime))
+		c.out.printStderr("[o]verwrite, [k]eep existing, keep [n]ewer, keep [b]oth (upper-case applies to all remaining)? ")
+
+		var answer string
+
+		if _, err := fmt.Fscanln(c.svc.stdin(), &answer); err != nil && answer == "" {
+			return "", errors.Wrap(err, "unable to read answer")
+		}
+
+		if res, ok := conflictAnswers[answer]; ok {
+			return res, nil
+		}
+
+		if res, ok := conflictAnswers[strings.ToLower(answer)]; ok {
+			c.conflictAnswerForAll = res
+			return res, nil
+		}
+	}
+}
+
+// reportConflicts prints the summary of conflicts and writes the conflict report if requested.
+func (c *commandRestore) reportConflicts(ctx context.Context, output restore.Output) error {
+	fso, ok := output.(*restore.FilesystemOutput)
+	if !ok {
+		return nil
+	}
+
+	conflicts := fso.Conflicts()
+
+	if len(conflicts) > 0 {
+		counts := map[restore.ConflictStrategy]int{}
+
+		for _, cf := range conflicts {
+			counts[cf.Resolution]++
+		}
+
+		log(ctx).Infof("Resolved %v conflicts with existing files: %v overwritten, %v kept, %v restored next to existing files.",
+			len(conflicts), counts[restore.ConflictOverwrite], counts[restore.ConflictKeepExisting], counts[restore.ConflictKeepBoth])
+	}
+
+	if c.conflictReportFile == "" {
+		return nil
+	}
+
+	if conflicts == nil {
+		conflicts = []restore.Conflict{}
+	}
+
+	b, err := json.MarshalIndent(conflicts, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal conflict report")
+	}
+
+	//nolint:gomnd
+	return errors.Wrap(os.WriteFile(c.conflictReportFile, append(b, '\n'), 0o600), "unable to write conflict report")
+}
@@ -0,0 +1,104 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/restore"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRestoreConflictStrategies(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	names := []string{"a.txt", "b.txt", "c.txt"}
+
+	for _, n := range names {
+		require.NoError(t, os.WriteFile(filepath.Join(srcDir, n), []byte("snapshot-"+n), 0o600))
+	}
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	snaps := mustListSnapshots(t, env)
+	require.Len(t, snaps, 1)
+
+	prepareTarget := func() string {
+		td := testutil.TempDirectory(t)
+
+		for _, n := range names {
+			require.NoError(t, os.WriteFile(filepath.Join(td, n), []byte("existing-"+n), 0o600))
+		}
+
+		return td
+	}
+
+	readReport := func(fname string) []restore.Conflict {
+		b, err := os.ReadFile(fname)
+		require.NoError(t, err)
+
+		var conflicts []restore.Conflict
+
+		require.NoError(t, json.Unmarshal(b, &conflicts))
+
+		return conflicts
+	}
+
+	// without conflict strategy, existing files are not overwritten when asked not to.
+	env.RunAndExpectFailure(t, "snapshot", "restore", string(snaps[0].ID), prepareTarget(), "--overwrite-files=false")
+
+	td := prepareTarget()
+	reportFile := filepath.Join(testutil.TempDirectory(t), "conflicts.json")
+
+	env.RunAndExpectSuccess(t, "snapshot", "restore", string(snaps[0].ID), td, "--conflict-strategy=keep-both", "--conflict-report", reportFile)
+
+	for _, n := range names {
+		requireFileContents(t, filepath.Join(td, n), "existing-"+n)
+		requireFileContents(t, filepath.Join(td, strings.TrimSuffix(n, ".txt")+".restored.txt"), "snapshot-"+n)
+	}
+
+	conflicts := readReport(reportFile)
+	require.Len(t, conflicts, len(names))
+
+	for _, c := range conflicts {
+		require.Equal(t, restore.ConflictKeepBoth, c.Resolution)
+	}
+
+	// overwrite the first conflict, keep existing files for all remaining ones.
+	td = prepareTarget()
+
+	runner.SetNextStdin(strings.NewReader("o\nK\n"))
+	env.RunAndExpectSuccess(t, "snapshot", "restore", string(snaps[0].ID), td, "--conflict-strategy=prompt", "--parallel=1", "--conflict-report", reportFile)
+
+	counts := map[restore.ConflictStrategy]int{}
+	for _, c := range readReport(reportFile) {
+		counts[c.Resolution]++
+	}
+
+	require.Equal(t, map[restore.ConflictStrategy]int{
+		restore.ConflictOverwrite:    1,
+		restore.ConflictKeepExisting: 2,
+	}, counts)
+
+	// conflict strategies only apply to local filesystem.
+	env.RunAndExpectFailure(t, "snapshot", "restore", string(snaps[0].ID), filepath.Join(testutil.TempDirectory(t), "out.zip"), "--conflict-strategy=keep-both")
+}
+
+func requireFileContents(t *testing.T, fname, want string) {
+	t.Helper()
+
+	got, err := os.ReadFile(fname)
+	require.NoError(t, err)
+	require.Equal(t, want, string(got))
+}
@@ -0,0 +1,33 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestRestoreCheckDiskSpace(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "a.txt"), []byte("some data"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	snaps := mustListSnapshots(t, env)
+	require.Len(t, snaps, 1)
+
+	// disk space is checked by default, and the check can be skipped.
+	env.RunAndExpectSuccess(t, "snapshot", "restore", string(snaps[0].ID), filepath.Join(testutil.TempDirectory(t), "out1"))
+	env.RunAndExpectSuccess(t, "snapshot", "restore", string(snaps[0].ID), filepath.Join(testutil.TempDirectory(t), "out2"), "--no-check-disk-space")
+}
@@ -22,6 +22,7 @@
 	"github.com/kopia/kopia/internal/ctxutil"
 	"github.com/kopia/kopia/internal/server"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/sharding"
 )
 
 const serverRandomPasswordLength = 32
@@ -36,9 +37,10 @@ type commandServerStart struct {
 	serverStartGRPC                bool
 	serverStartControlAPI          bool
 
-	serverStartRefreshInterval time.Duration
-	serverStartInsecure        bool
-	serverStartMaxConcurrency  int
+	serverStartRefreshInterval     time.Duration
+	serverStartPolicyWatchInterval time.Duration
+	serverStartInsecure            bool
+	serverStartMaxConcurrency      int
 
 	serverStartWithoutPassword bool
 	serverStartRandomPassword  bool
@@ -87,6 +89,7 @@ func (c *commandServerStart) setup(svc advancedAppServices, parent commandParent
 	cmd.Flag("control-api", "Start the control API").Default("true").BoolVar(&c.serverStartControlAPI)
 
 	cmd.Flag("refresh-interval", "Frequency for refreshing repository status").Default("4h").DurationVar(&c.serverStartRefreshInterval)
+	cmd.Flag("policy-watch-interval", "Frequency for checking for policy changes made by other clients (0 to disable)").Default("1m").DurationVar(&c.serverStartPolicyWatchInterval)
 	cmd.Flag("insecure", "Allow insecure configurations (do not use in production)").Hidden().BoolVar(&c.serverStartInsecure)
 	cmd.Flag("max-concurrency", "Maximum number of server goroutines").Default("0").IntVar(&c.serverStartMaxConcurrency)
 
@@ -145,6 +148,7 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 		ConfigFile:           c.svc.repositoryConfigFileName(),
 		ConnectOptions:       c.co.toRepoConnectOptions(),
 		RefreshInterval:      c.serverStartRefreshInterval,
+		PolicyWatchInterval:  c.serverStartPolicyWatchInterval,
 		MaxConcurrency:       c.serverStartMaxConcurrency,
 		Authenticator:        authn,
 		Authorizer:           auth.DefaultAuthorizer(),
@@ -160,13 +164,23 @@ func (c *commandServerStart) serverStartOptions(ctx context.Context) (*server.Op
 		DebugScheduler:         c.debugScheduler,
 		MinMaintenanceInterval: c.minMaintenanceInterval,
 		DisableCSRFTokenChecks: c.disableCSRFTokenChecks,
+		LowMemory:              c.svc.IsLowMemory(),
 	}, nil
 }
 
 func (c *commandServerStart) initRepositoryPossiblyAsync(ctx context.Context, srv *server.Server) error {
 	initialize := func(ctx context.Context) (repo.Repository, error) {
-		//nolint:wrapcheck
-		return c.svc.openRepository(ctx, false)
+		rep, err := c.svc.openRepository(ctx, false)
+		if err != nil || rep == nil {
+			return rep, err //nolint:wrapcheck
+		}
+
+		if err := checkNotSharded(ctx, rep); err != nil {
+			rep.Close(ctx) //nolint:errcheck
+			return nil, err
+		}
+
+		return rep, nil
 	}
 
 	if c.asyncRepoConnect {
@@ -181,6 +195,21 @@ func (c *commandServerStart) initRepositoryPossiblyAsync(ctx context.Context, sr
 	return nil
 }
 
+// checkNotSharded returns an error if the repository has shards, snapshots in shards would not be visible
+// to clients of the server and new snapshots would not be placed in shards.
+func checkNotSharded(ctx context.Context, rep repo.Repository) error {
+	cfg, err := sharding.LoadConfig(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to load sharding configuration")
+	}
+
+	if cfg.IsSharded() {
+		return errors.New("sharded repositories can't be served, start a separate server for each shard instead")
+	}
+
+	return nil
+}
+
 func (c *commandServerStart) run(ctx context.Context) error {
 	opts, err := c.serverStartOptions(ctx)
 	if err != nil {
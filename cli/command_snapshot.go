@@ -4,15 +4,22 @@ type commandSnapshot struct {
 	copyHistory commandSnapshotCopyMoveHistory
 	moveHistory commandSnapshotCopyMoveHistory
 	create      commandSnapshotCreate
+	createOID   commandSnapshotCreateFromOID
 	delete      commandSnapshotDelete
+	describe    commandSnapshotDescribe
+	errors      commandSnapshotErrors
 	estimate    commandSnapshotEstimate
 	expire      commandSnapshotExpire
+	explain     commandSnapshotExplainRetention
 	fix         commandSnapshotFix
+	gc          commandSnapshotGC
 	list        commandSnapshotList
 	migrate     commandSnapshotMigrate
 	pin         commandSnapshotPin
 	restore     commandSnapshotRestore
 	verify      commandSnapshotVerify
+	reports     commandSnapshotVerificationReports
+	reference   commandSnapshotReference
 }
 
 func (c *commandSnapshot) setup(svc advancedAppServices, parent commandParent) {
@@ -20,13 +27,20 @@ func (c *commandSnapshot) setup(svc advancedAppServices, parent commandParent) {
 	c.copyHistory.setup(svc, cmd, false)
 	c.moveHistory.setup(svc, cmd, true)
 	c.create.setup(svc, cmd)
+	c.createOID.setup(svc, cmd)
 	c.delete.setup(svc, cmd)
+	c.describe.setup(svc, cmd)
+	c.errors.setup(svc, cmd)
 	c.estimate.setup(svc, cmd)
 	c.expire.setup(svc, cmd)
+	c.explain.setup(svc, cmd)
 	c.fix.setup(svc, cmd)
+	c.gc.setup(svc, cmd)
 	c.list.setup(svc, cmd)
 	c.migrate.setup(svc, cmd)
 	c.pin.setup(svc, cmd)
 	c.restore.setup(svc, cmd)
 	c.verify.setup(svc, cmd)
+	c.reports.setup(svc, cmd)
+	c.reference.setup(svc, cmd)
 }
@@ -2,19 +2,29 @@
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/virtualfs"
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/sharding"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
@@ -40,18 +50,34 @@ type commandSnapshotCreate struct {
 	snapshotCreateTags                    []string
 	flushPerSource                        bool
 	sourceOverride                        string
+	subpath                               string
 
 	pins []string
 
+	classify          bool
+	classifyExtension []string
+	classifyContent   []string
+
+	uploaderClassifiers   []snapshotfs.Classifier
+	uploaderClassifiersID string
+
+	// set when the upload was canceled by a signal.
+	interrupted atomic.Bool
+
+	verbose bool
+
+	events         jsonEvents
+	eventsProgress *jsonEventsUploadProgress
+
 	logDirDetail   int
 	logEntryDetail int
 
 	jo  jsonOutput
-	svc appServices
+	svc advancedAppServices
 	out textOutput
 }
 
-func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
+func (c *commandSnapshotCreate) setup(svc advancedAppServices, parent commandParent) {
 	cmd := parent.Command("create", "Creates a snapshot of local directory or file.")
 
 	cmd.Arg("source", "Files or directories to create snapshot(s) of.").StringsVar(&c.snapshotCreateSources)
@@ -71,6 +97,11 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	cmd.Flag("pin", "Create a pinned snapshot that will not expire automatically").StringsVar(&c.pins)
 	cmd.Flag("flush-per-source", "Flush writes at the end of each source").Hidden().BoolVar(&c.flushPerSource)
 	cmd.Flag("override-source", "Override the source of the snapshot.").StringVar(&c.sourceOverride)
+	cmd.Flag("classify", "Tag files using built-in classifiers (source code, media, documents, archives).").BoolVar(&c.classify)
+	cmd.Flag("classify-extension", "Tag files with the given extension, in the <extension>:<tag> format.").StringsVar(&c.classifyExtension)
+	cmd.Flag("classify-content", "Tag files whose contents match a regular expression, in the <tag>:<regexp> format.").StringsVar(&c.classifyContent)
+	cmd.Flag("verbose", "Show breakdown of new and deduplicated bytes per top-level directory.").BoolVar(&c.verbose)
+	cmd.Flag("subpath", "Snapshot only the given subdirectory of the source, using the previous full snapshot as a baseline.").StringVar(&c.subpath)
 
 	c.logDirDetail = -1
 	c.logEntryDetail = -1
@@ -79,14 +110,26 @@ func (c *commandSnapshotCreate) setup(svc appServices, parent commandParent) {
 	cmd.Flag("log-entry-detail", "Override log level for entries").IntVar(&c.logEntryDetail)
 
 	c.jo.setup(svc, cmd)
+	c.events.setup(svc, cmd)
 	c.out.setup(svc)
 
 	c.svc = svc
 	cmd.Action(svc.repositoryWriterAction(c.run))
+	svc.runLocally(cmd, func() bool { return c.snapshotCreateStdinFileName != "" })
+}
+
+func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWriter) (err error) {
+	if err := c.events.begin(); err != nil {
+		return err
+	}
+
+	defer func() { c.events.finish(err) }()
+
+	return c.createSnapshots(ctx, rep)
 }
 
 //nolint:gocyclo
-func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWriter) error {
+func (c *commandSnapshotCreate) createSnapshots(ctx context.Context, rep repo.RepositoryWriter) error {
 	sources := c.snapshotCreateSources
 
 	if c.snapshotCreateAll && len(sources) > 0 {
@@ -97,8 +140,26 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 		return errors.Wrap(err, "error upgrading repository")
 	}
 
+	shards, err := newShardRepositories(ctx, c.svc, rep)
+	if err != nil {
+		return err
+	}
+
+	defer shards.close(ctx)
+
 	if c.snapshotCreateAll {
-		local, err := getLocalBackupPaths(ctx, rep)
+		var sourcesRep repo.Repository = rep
+
+		if shards.cfg.IsSharded() {
+			opened, err := shards.openAll(ctx)
+			if err != nil {
+				return err
+			}
+
+			sourcesRep = sharding.NewRepository(rep, shards.cfg, opened)
+		}
+
+		local, err := getLocalBackupPaths(ctx, sourcesRep)
 		if err != nil {
 			return err
 		}
@@ -118,6 +179,27 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 		return errors.New("description too long")
 	}
 
+	if c.subpath != "" {
+		if c.snapshotCreateStdinFileName != "" {
+			return errors.New("cannot use --subpath when snapshotting stdin")
+		}
+
+		sp, err := normalizeSubpath(c.subpath)
+		if err != nil {
+			return err
+		}
+
+		c.subpath = sp
+	}
+
+	classifiers, err := c.classifiers()
+	if err != nil {
+		return err
+	}
+
+	c.uploaderClassifiers = classifiers
+	c.uploaderClassifiersID = c.classifiersID()
+
 	u := c.setupUploader(rep)
 
 	var finalErrors []string
@@ -135,10 +217,12 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 
 		fsEntry, sourceInfo, setManual, err := c.getContentToSnapshot(ctx, snapshotDir, rep)
 		if err != nil {
+			c.events.emitError(snapshotDir, err)
 			finalErrors = append(finalErrors, fmt.Sprintf("failed to prepare source: %s", err))
 		}
 
-		if err := c.snapshotSingleSource(ctx, fsEntry, setManual, rep, u, sourceInfo, tags); err != nil {
+		if err := c.snapshotSingleSourceInShard(ctx, fsEntry, setManual, shards, u, sourceInfo, tags); err != nil {
+			c.events.emitError(snapshotDir, err)
 			finalErrors = append(finalErrors, err.Error())
 		}
 	}
@@ -152,6 +236,15 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 		}
 	}
 
+	if c.interrupted.Load() {
+		// incomplete snapshot and its contents have been flushed, so the next run will resume from it.
+		for _, e := range finalErrors {
+			log(ctx).Errorf("%v", e)
+		}
+
+		return errors.Wrap(ErrInterrupted, "snapshot creation")
+	}
+
 	if len(finalErrors) == 0 {
 		return nil
 	}
@@ -163,6 +256,60 @@ func (c *commandSnapshotCreate) run(ctx context.Context, rep repo.RepositoryWrit
 	return errors.Errorf("encountered %v errors:\n%v", len(finalErrors), strings.Join(finalErrors, "\n"))
 }
 
+// classifiers returns classifiers requested using flags.
+func (c *commandSnapshotCreate) classifiers() ([]snapshotfs.Classifier, error) {
+	var result []snapshotfs.Classifier
+
+	if c.classify {
+		result = append(result, snapshotfs.DefaultExtensionClassifier())
+	}
+
+	if len(c.classifyExtension) > 0 {
+		ec := snapshotfs.ExtensionClassifier{}
+
+		for _, v := range c.classifyExtension {
+			ext, tag, ok := strings.Cut(v, ":")
+			if !ok || ext == "" || tag == "" {
+				return nil, errors.Errorf("invalid extension classification (%s). Requires <extension>:<tag>", v)
+			}
+
+			if !strings.HasPrefix(ext, ".") {
+				ext = "." + ext
+			}
+
+			ec[strings.ToLower(ext)] = tag
+		}
+
+		result = append(result, ec)
+	}
+
+	for _, v := range c.classifyContent {
+		tag, pattern, ok := strings.Cut(v, ":")
+		if !ok || tag == "" || pattern == "" {
+			return nil, errors.Errorf("invalid content classification (%s). Requires <tag>:<regexp>", v)
+		}
+
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid content classification pattern for %v", tag)
+		}
+
+		result = append(result, &snapshotfs.ContentPatternClassifier{Tag: tag, Patterns: []*regexp.Regexp{re}})
+	}
+
+	return result, nil
+}
+
+// classifiersID returns an identifier of the classifiers requested using flags, which changes whenever
+// any of the classification flags changes.
+func (c *commandSnapshotCreate) classifiersID() string {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "%v\n%q\n%q", c.classify, c.classifyExtension, c.classifyContent)
+
+	return hex.EncodeToString(h.Sum(nil))[0:16]
+}
+
 func getTags(tagStrings []string) (map[string]string, error) {
 	numberOfPartsInTagString := 2
 	// tagKeyPrefix is the prefix for user defined tag keys.
@@ -208,6 +355,7 @@ func validateStartEndTime(st, et string) error {
 func (c *commandSnapshotCreate) setupUploader(rep repo.RepositoryWriter) *snapshotfs.Uploader {
 	u := snapshotfs.NewUploader(rep)
 	u.MaxUploadBytes = c.snapshotCreateCheckpointUploadLimitMB << 20 //nolint:gomnd
+	u.Subpath = c.subpath
 
 	if c.snapshotCreateForceEnableActions {
 		u.EnableActions = true
@@ -233,17 +381,64 @@ func (c *commandSnapshotCreate) setupUploader(rep repo.RepositoryWriter) *snapsh
 		u.CheckpointInterval = interval
 	}
 
-	c.svc.onTerminate(u.Cancel)
+	c.svc.onTerminate(func() {
+		c.out.printStderr("\nInterrupted, saving incomplete snapshot...\n")
+
+		c.interrupted.Store(true)
+		u.Cancel()
+	})
 
 	u.ForceHashPercentage = c.snapshotCreateForceHash
 	u.ParallelUploads = c.snapshotCreateParallelUploads
+	u.LowMemory = c.svc.IsLowMemory()
+	u.Classifiers = c.uploaderClassifiers
+	u.ClassifiersID = c.uploaderClassifiersID
 
 	u.FailFast = c.snapshotCreateFailFast
 	u.Progress = c.svc.getProgress()
 
+	if c.events.isEnabled() {
+		// the same progress is used by uploaders of all sources, which reset counters when starting.
+		if c.eventsProgress == nil {
+			c.eventsProgress = &jsonEventsUploadProgress{UploadProgress: u.Progress, events: &c.events}
+			c.svc.getProgress().uploadedBytesObserver = c.eventsProgress.uploaded
+			c.events.startProgressEvents(c.eventsProgress.counters)
+		}
+
+		u.Progress = c.eventsProgress
+	}
+
 	return u
 }
 
+// snapshotSingleSourceInShard snapshots the source in the shard holding it, sources which are not
+// placed yet are placed where consistent hashing assigns them.
+func (c *commandSnapshotCreate) snapshotSingleSourceInShard(ctx context.Context, fsEntry fs.Entry, setManual bool, shards *shardRepositories, u *snapshotfs.Uploader, sourceInfo snapshot.SourceInfo, tags map[string]string) error {
+	if !shards.cfg.IsSharded() {
+		return c.snapshotSingleSource(ctx, fsEntry, setManual, shards.primary, u, sourceInfo, tags)
+	}
+
+	shard := shards.cfg.Locate(sourceInfo)
+
+	log(ctx).Debugf("source %v is in shard %v", sourceInfo, shard)
+
+	if err := shards.writeSession(ctx, shard, "SnapshotCreate", func(ctx context.Context, w repo.RepositoryWriter) error {
+		if shard == sharding.PrimaryShard {
+			return c.snapshotSingleSource(ctx, fsEntry, setManual, w, u, sourceInfo, tags)
+		}
+
+		return c.snapshotSingleSource(ctx, fsEntry, setManual, sharding.NewSourceWriter(w, shards.primary), c.setupUploader(w), sourceInfo, tags)
+	}); err != nil {
+		return errors.Wrapf(err, "error snapshotting %v in shard %v", sourceInfo, shard)
+	}
+
+	if _, ok := shards.cfg.Placement(sourceInfo); ok {
+		return nil
+	}
+
+	return errors.Wrap(sharding.SavePlacement(ctx, shards.primary, shards.cfg, sourceInfo, shard), "unable to save source placement")
+}
+
 func parseTimestamp(timestamp string) (time.Time, error) {
 	if timestamp == "" {
 		return time.Time{}, nil
@@ -275,6 +470,16 @@ func (c *commandSnapshotCreate) snapshotSingleSource(ctx context.Context, fsEntr
 		return errors.Wrap(err, "unable to get policy tree")
 	}
 
+	if c.subpath != "" {
+		log(ctx).Infof("Snapshotting only %v", c.subpath)
+
+		previous = subtreeManifests(ctx, rep, previous, c.subpath)
+
+		for _, part := range strings.Split(c.subpath, "/") {
+			policyTree = policyTree.Child(part)
+		}
+	}
+
 	manifest, err := u.Upload(ctx, fsEntry, policyTree, sourceInfo, previous...)
 	if err != nil {
 		// fail-fast uploads will fail here without recording a manifest, other uploads will
@@ -316,10 +521,31 @@ func (c *commandSnapshotCreate) snapshotSingleSource(ctx context.Context, fsEntr
 		}
 	}
 
+	pol := policyTree.EffectivePolicy()
+
+	anomalyActions, err := snapshotfs.DetectAnomalies(ctx, rep, pol, previous, manifest)
+	if err != nil {
+		return errors.Wrap(err, "unable to detect anomalies")
+	}
+
+	for _, a := range manifest.Anomalies {
+		log(ctx).Warnf("Snapshot of %v: %v", sourceInfo, a)
+	}
+
+	if slices.Contains(anomalyActions, policy.AnomalyActionConfirm) && !c.confirmAnomalousSnapshot() {
+		return errors.Errorf("snapshot of %v has not been saved because of anomalies", sourceInfo)
+	}
+
 	if _, err = snapshot.SaveSnapshot(ctx, rep, manifest); err != nil {
 		return errors.Wrap(err, "cannot save manifest")
 	}
 
+	if slices.Contains(anomalyActions, policy.AnomalyActionNotify) {
+		if err := u.ExecuteSnapshotAnomalyAction(ctx, pol.Actions.SnapshotAnomaly, manifest); err != nil {
+			log(ctx).Errorf("unable to notify about snapshot anomalies: %v", err)
+		}
+	}
+
 	if _, err = policy.ApplyRetentionPolicy(ctx, rep, sourceInfo, true); err != nil {
 		return errors.Wrap(err, "unable to apply retention policy")
 	}
@@ -341,6 +567,17 @@ func (c *commandSnapshotCreate) snapshotSingleSource(ctx context.Context, fsEntr
 	return c.reportSnapshotStatus(ctx, manifest)
 }
 
+// confirmAnomalousSnapshot asks the user whether to save a snapshot with suspicious changes.
+func (c *commandSnapshotCreate) confirmAnomalousSnapshot() bool {
+	c.out.printStderr("\nSave the snapshot anyway? (y/N) ")
+
+	var answer string
+
+	fmt.Fscanln(c.svc.stdin(), &answer) //nolint:errcheck
+
+	return strings.HasPrefix(strings.ToLower(answer), "y")
+}
+
 func (c *commandSnapshotCreate) reportSnapshotStatus(ctx context.Context, manifest *snapshot.Manifest) error {
 	var maybePartial string
 	if manifest.IncompleteReason != "" {
@@ -351,10 +588,31 @@ func (c *commandSnapshotCreate) reportSnapshotStatus(ctx context.Context, manife
 
 	snapID := manifest.ID
 
-	if c.jo.jsonOutput {
+	c.events.emit(jsonEventResult, snapshotCreateResult{
+		Source:       manifest.Source,
+		ID:           manifest.ID,
+		RootObjectID: manifest.RootObjectID(),
+		StartTime:    manifest.StartTime,
+		EndTime:      manifest.EndTime,
+		Incomplete:   manifest.IncompleteReason,
+		Stats:        &manifest.Stats,
+	})
+
+	switch {
+	case c.events.usesStdout():
+		// the result event already describes the snapshot.
+	case c.jo.jsonOutput:
 		c.out.printStdout("%s\n", c.jo.jsonIndentedBytes(manifest, "  "))
-	} else {
-		log(ctx).Infof("Created%v snapshot with root %v and ID %v in %v", maybePartial, manifest.RootObjectID(), snapID, manifest.EndTime.Sub(manifest.StartTime).Truncate(time.Second))
+	default:
+		log(ctx).Infof("Created%v snapshot with root %v and ID %v in %v", maybePartial, manifest.RootObjectID(), snapID, c.out.formatDuration(manifest.EndTime.Sub(manifest.StartTime)))
+	}
+
+	if c.verbose && !c.jo.jsonOutput {
+		c.reportDirectoryUploadStats(ctx, manifest)
+	}
+
+	if n := manifest.Stats.ExcludedByOwnerCount; n > 0 {
+		log(ctx).Infof("Skipped %v file(s) and directories owned by excluded users or groups.", n)
 	}
 
 	if ds := manifest.RootEntry.DirSummary; ds != nil {
@@ -370,6 +628,29 @@ func (c *commandSnapshotCreate) reportSnapshotStatus(ctx context.Context, manife
 	return nil
 }
 
+// snapshotCreateResult is emitted as a JSON event for each snapshot created.
+type snapshotCreateResult struct {
+	Source       snapshot.SourceInfo `json:"source"`
+	ID           manifest.ID         `json:"id"`
+	RootObjectID object.ID           `json:"rootID"`
+	StartTime    fs.UTCTimestamp     `json:"startTime"`
+	EndTime      fs.UTCTimestamp     `json:"endTime"`
+	Incomplete   string              `json:"incomplete,omitempty"`
+	Stats        *snapshot.Stats     `json:"stats"`
+}
+
+func (c *commandSnapshotCreate) reportDirectoryUploadStats(ctx context.Context, manifest *snapshot.Manifest) {
+	if len(manifest.DirectoryUploadStats) == 0 {
+		return
+	}
+
+	log(ctx).Infof("New and deduplicated bytes by directory:")
+
+	for _, d := range manifest.DirectoryUploadStats {
+		log(ctx).Infof("  %-30v %10v new %10v deduplicated", d.Path, units.BytesString(d.NewBytes), units.BytesString(d.DeduplicatedBytes))
+	}
+}
+
 // findPreviousSnapshotManifest returns the list of previous snapshots for a given source, including
 // last complete snapshot and possibly some number of incomplete snapshots following it.
 func findPreviousSnapshotManifest(ctx context.Context, rep repo.Repository, sourceInfo snapshot.SourceInfo, noLaterThan *fs.UTCTimestamp) ([]*snapshot.Manifest, error) {
@@ -390,6 +671,11 @@ func findPreviousSnapshotManifest(ctx context.Context, rep repo.Repository, sour
 			continue
 		}
 
+		// snapshots of a subtree of the source can't be used as a baseline.
+		if p.Subpath != "" {
+			continue
+		}
+
 		if p.IncompleteReason == "" && (previousComplete == nil || p.StartTime.After(previousComplete.StartTime)) {
 			previousComplete = p
 			previousCompleteStartTime = p.StartTime
@@ -406,7 +692,7 @@ func findPreviousSnapshotManifest(ctx context.Context, rep repo.Repository, sour
 			continue
 		}
 
-		if p.IncompleteReason != "" && p.StartTime.After(previousCompleteStartTime) {
+		if p.IncompleteReason != "" && p.Subpath == "" && p.StartTime.After(previousCompleteStartTime) {
 			result = append(result, p)
 		}
 	}
@@ -414,6 +700,50 @@ func findPreviousSnapshotManifest(ctx context.Context, rep repo.Repository, sour
 	return result, nil
 }
 
+// subtreeManifests returns copies of the provided snapshot manifests with the root entry replaced
+// with the entry at the given subpath, skipping snapshots where it does not exist.
+func subtreeManifests(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest, subpath string) []*snapshot.Manifest {
+	var result []*snapshot.Manifest
+
+	for _, m := range manifests {
+		root, err := snapshotfs.SnapshotRoot(rep, m)
+		if err != nil {
+			log(ctx).Debugf("unable to open root of previous snapshot %v: %v", m.ID, err)
+			continue
+		}
+
+		e, err := snapshotfs.GetNestedEntry(ctx, root, strings.Split(subpath, "/"))
+		if err != nil {
+			log(ctx).Debugf("%v not found in previous snapshot %v: %v", subpath, m.ID, err)
+			continue
+		}
+
+		hde, ok := e.(snapshot.HasDirEntry)
+		if !ok {
+			continue
+		}
+
+		sub := *m
+		sub.RootEntry = hde.DirEntry()
+		sub.Subpath = subpath
+
+		result = append(result, &sub)
+	}
+
+	return result
+}
+
+// normalizeSubpath validates the provided subpath and converts it to a clean, slash-separated relative path.
+func normalizeSubpath(sp string) (string, error) {
+	clean := path.Clean(filepath.ToSlash(sp))
+
+	if path.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.Errorf("invalid subpath %q, must be a relative path within the source", sp)
+	}
+
+	return clean, nil
+}
+
 func getLocalBackupPaths(ctx context.Context, rep repo.Repository) ([]string, error) {
 	log(ctx).Debugf("Looking for previous backups of '%v@%v'...", rep.ClientOptions().Hostname, rep.ClientOptions().Username)
 
@@ -485,7 +815,7 @@ func (c *commandSnapshotCreate) getContentToSnapshot(ctx context.Context, dir st
 		})
 		setManual = true
 	} else {
-		fsEntry, err = getLocalFSEntry(ctx, absDir)
+		fsEntry, err = getLocalFSEntry(ctx, filepath.Join(absDir, filepath.FromSlash(c.subpath)))
 		if err != nil {
 			return nil, info, false, errors.Wrap(err, "unable to get local filesystem entry")
 		}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandSnapshotCreateFromOID struct {
+	rootOID     string
+	source      string
+	description string
+	tags        []string
+	pins        []string
+	startTime   string
+	endTime     string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotCreateFromOID) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("create-from-oid", "Create a snapshot whose root is an existing directory object in the repository.")
+	cmd.Arg("root-oid", "Object ID of the root directory").Required().StringVar(&c.rootOID)
+	cmd.Flag("source", "Source of the snapshot ([user@host:]path).").Required().StringVar(&c.source)
+	cmd.Flag("description", "Free-form snapshot description.").StringVar(&c.description)
+	cmd.Flag("tags", "Tags applied on the snapshot. Must be provided in the <key>:<value> format.").StringsVar(&c.tags)
+	cmd.Flag("pin", "Create a pinned snapshot that will not expire automatically").StringsVar(&c.pins)
+	cmd.Flag("start-time", "Snapshot start timestamp.").StringVar(&c.startTime)
+	cmd.Flag("end-time", "Snapshot end timestamp.").StringVar(&c.endTime)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandSnapshotCreateFromOID) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	rootOID, err := object.ParseID(c.rootOID)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse object ID")
+	}
+
+	si, err := snapshot.ParseSourceInfo(c.source, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
+	if err != nil {
+		return errors.Wrap(err, "invalid source")
+	}
+
+	tags, err := getTags(c.tags)
+	if err != nil {
+		return err
+	}
+
+	startTime, err := parseTimestamp(c.startTime)
+	if err != nil {
+		return errors.Wrap(err, "invalid start time")
+	}
+
+	endTime, err := parseTimestamp(c.endTime)
+	if err != nil {
+		return errors.Wrap(err, "invalid end time")
+	}
+
+	man, err := snapshotfs.CreateSnapshotFromObject(ctx, rep, si, rootOID, snapshotfs.SyntheticSnapshotOptions{
+		Description: c.description,
+		Tags:        tags,
+		Pins:        c.pins,
+		StartTime:   startTime,
+		EndTime:     endTime,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to create snapshot")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(man))
+		return nil
+	}
+
+	log(ctx).Infof("Created snapshot %v of %v with root %v", man.ID, man.Source, man.RootObjectID())
+
+	return nil
+}
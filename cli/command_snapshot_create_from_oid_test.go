@@ -0,0 +1,69 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotCreateFromOID(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "file1.txt"), []byte("hello"), 0o600))
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	snapshots := mustListSnapshots(t, env)
+	require.Len(t, snapshots, 1)
+
+	rootOID := snapshots[0].RootObjectID().String()
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "create-from-oid", rootOID,
+		"--source=someuser@somehost:/synthetic",
+		"--description=assembled",
+		"--tags=origin:tool",
+		"--pin=keep",
+		"--json", "--json-verbose"), &man)
+
+	require.NotEmpty(t, man.ID)
+	require.Equal(t, rootOID, man.RootObjectID().String())
+	require.Equal(t, snapshots[0].Stats.TotalFileCount, man.Stats.TotalFileCount)
+	require.Equal(t, snapshots[0].Stats.TotalFileSize, man.Stats.TotalFileSize)
+	require.Equal(t, "tool", man.Tags["tag:origin"])
+	require.Equal(t, []string{"keep"}, man.Pins)
+
+	var synthetic *snapshot.Manifest
+
+	for _, m := range mustListSnapshots(t, env) {
+		if m.Source.Path == "/synthetic" {
+			synthetic = m
+		}
+	}
+
+	require.NotNil(t, synthetic)
+	require.Equal(t, "assembled", synthetic.Description)
+	require.Equal(t, "synthetic", synthetic.RootEntry.Name)
+
+	restoreDir := testutil.TempDirectory(t)
+	env.RunAndExpectSuccess(t, "snapshot", "restore", string(man.ID), restoreDir)
+	restored, err := os.ReadFile(filepath.Join(restoreDir, "file1.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(restored))
+
+	// not a directory
+	env.RunAndExpectFailure(t, "snapshot", "create-from-oid", "k0123456789abcdef0123456789abcdef", "--source=someuser@somehost:/synthetic")
+	env.RunAndExpectFailure(t, "snapshot", "create-from-oid", "not-an-oid", "--source=someuser@somehost:/synthetic")
+}
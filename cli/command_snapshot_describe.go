@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+)
+
+type commandSnapshotDescribe struct {
+	snapshotIDs []string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotDescribe) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("describe", "Show detailed information about snapshots, including file size, type and directory histograms.")
+	cmd.Arg("id", "Snapshot ID").Required().StringsVar(&c.snapshotIDs)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotDescribe) run(ctx context.Context, rep repo.Repository) error {
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	for _, id := range c.snapshotIDs {
+		m, err := snapshot.LoadSnapshot(ctx, rep, manifest.ID(id))
+		if err != nil {
+			return errors.Wrapf(err, "error loading snapshot %v", id)
+		}
+
+		if c.jo.jsonOutput {
+			jl.emit(m)
+			continue
+		}
+
+		c.describe(m)
+	}
+
+	return nil
+}
+
+func (c *commandSnapshotDescribe) describe(m *snapshot.Manifest) {
+	c.out.printStdout("Snapshot:    %v\n", m.ID)
+	c.out.printStdout("Source:      %v\n", m.Source)
+	c.out.printStdout("Start time:  %v\n", c.out.formatTimestamp(m.StartTime.ToTime()))
+	c.out.printStdout("End time:    %v\n", c.out.formatTimestamp(m.EndTime.ToTime()))
+	c.out.printStdout("Root:        %v\n", m.RootObjectID())
+	c.out.printStdout("Files:       %v (%v)\n", units.Count(int64(m.Stats.TotalFileCount)), units.BytesString(m.Stats.TotalFileSize))
+	c.out.printStdout("Directories: %v\n", units.Count(int64(m.Stats.TotalDirectoryCount)))
+
+	if m.IncompleteReason != "" {
+		c.out.printStdout("Incomplete:  %v\n", m.IncompleteReason)
+	}
+
+	if len(m.Classifications) > 0 {
+		c.out.printStdout("\nClassifications:\n")
+
+		for _, t := range m.Classifications {
+			c.out.printStdout("  %-22v %10v files %10v\n", t.Name, units.Count(t.Count), units.BytesString(t.TotalSize))
+		}
+	}
+
+	if len(m.DirectoryUploadStats) > 0 {
+		c.out.printStdout("\nNew and deduplicated bytes by directory:\n")
+
+		for _, d := range m.DirectoryUploadStats {
+			c.out.printStdout("  %-22v %10v new %10v deduplicated\n", d.Path, units.BytesString(d.NewBytes), units.BytesString(d.DeduplicatedBytes))
+		}
+	}
+
+	h := m.Histograms
+	if h == nil {
+		c.out.printStdout("\nNo histograms recorded for this snapshot.\n")
+		return
+	}
+
+	c.out.printStdout("\nFile sizes:\n")
+
+	for _, b := range h.FileSizes {
+		c.out.printStdout("  %-22v %10v files %10v\n", sizeBucketRange(b), units.Count(b.Count), units.BytesString(b.TotalSize))
+	}
+
+	c.out.printStdout("\nTop extensions by size:\n")
+
+	for _, e := range h.Extensions {
+		name := e.Name
+		if name == "" {
+			name = "(none)"
+		}
+
+		c.out.printStdout("  %-22v %10v files %10v\n", name, units.Count(e.Count), units.BytesString(e.TotalSize))
+	}
+
+	c.out.printStdout("\nTop directories by size:\n")
+
+	for _, d := range h.TopDirectories {
+		c.out.printStdout("  %-22v %10v files %10v\n", d.Name, units.Count(d.Count), units.BytesString(d.TotalSize))
+	}
+}
+
+func sizeBucketRange(b *snapshot.SizeBucket) string {
+	switch {
+	case b.MaxSize == 0:
+		return "empty"
+	case b.MaxSize < 0:
+		return "> " + units.BytesString(b.MinSize)
+	default:
+		return "<= " + units.BytesString(b.MaxSize)
+	}
+}
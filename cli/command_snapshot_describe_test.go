@@ -0,0 +1,94 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotDescribe(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(srcdir, "docs"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "docs", "a.txt"), []byte{1, 2, 3}, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "docs", "b.txt"), []byte{1, 2, 3, 4}, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "c.jpg"), []byte{1}, 0o644))
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+
+	var described []*snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "describe", string(man.ID), "--json"), &described)
+	require.Len(t, described, 1)
+	require.NotNil(t, described[0].Histograms)
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: ".txt", Count: 2, TotalSize: 7},
+		{Name: ".jpg", Count: 1, TotalSize: 1},
+	}, described[0].Histograms.Extensions)
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: "docs", Count: 2, TotalSize: 7},
+		{Name: ".", Count: 1, TotalSize: 1},
+	}, described[0].Histograms.TopDirectories)
+
+	e.RunAndExpectSuccess(t, "snapshot", "describe", string(man.ID))
+	e.RunAndExpectFailure(t, "snapshot", "describe", "no-such-snapshot")
+}
+
+func TestSnapshotDescribe_Classifications(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "main.go"), []byte("package main"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "photo.JPG"), []byte{1, 2}, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "contacts.csv"), []byte("name,ssn\njohn,123-45-6789\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "notes.csv"), []byte("nothing here"), 0o644))
+
+	e.RunAndExpectFailure(t, "snapshot", "create", srcdir, "--classify-extension=nocolon")
+	e.RunAndExpectFailure(t, "snapshot", "create", srcdir, "--classify-content=pii:[")
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json",
+		"--classify", "--classify-extension=csv:spreadsheet", `--classify-content=contains-PII:\d{3}-\d{2}-\d{4}`), &man)
+
+	var described []*snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "describe", string(man.ID), "--json"), &described)
+	require.Len(t, described, 1)
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: "spreadsheet", Count: 2, TotalSize: 38},
+		{Name: "contains-PII", Count: 1, TotalSize: 26},
+		{Name: "source-code", Count: 1, TotalSize: 12},
+		{Name: "media", Count: 1, TotalSize: 2},
+	}, described[0].Classifications)
+
+	// cached files are classified too.
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json", "--classify"), &man)
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: "source-code", Count: 1, TotalSize: 12},
+		{Name: "media", Count: 1, TotalSize: 2},
+	}, man.Classifications)
+
+	mustGetLineContaining(t, e.RunAndExpectSuccess(t, "snapshot", "describe", string(man.ID)), "source-code")
+}
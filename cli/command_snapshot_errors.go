@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandSnapshotErrors struct {
+	snapshotID    string
+	includeIgnore bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotErrors) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("errors", "List errors of individual files and directories encountered when the snapshot was created.")
+	cmd.Arg("id", "Snapshot ID").Required().StringVar(&c.snapshotID)
+	cmd.Flag("include-ignored", "Include ignored errors").Default("true").BoolVar(&c.includeIgnore)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotErrors) run(ctx context.Context, rep repo.Repository) error {
+	m, err := snapshot.LoadSnapshot(ctx, rep, manifest.ID(c.snapshotID))
+	if err != nil {
+		return errors.Wrapf(err, "error loading snapshot %v", c.snapshotID)
+	}
+
+	em, err := snapshotfs.ReadErrorManifest(ctx, rep, m)
+	if err != nil {
+		return errors.Wrap(err, "unable to read error manifest")
+	}
+
+	var entries []snapshotfs.EntryError
+
+	for _, e := range em.Entries {
+		if e.Ignored && !c.includeIgnore {
+			continue
+		}
+
+		entries = append(entries, e)
+	}
+
+	if c.jo.jsonOutput {
+		var jl jsonList
+
+		jl.begin(&c.jo)
+		defer jl.end()
+
+		for _, e := range entries {
+			jl.emit(e)
+		}
+
+		return nil
+	}
+
+	for _, e := range entries {
+		var suffix string
+
+		if e.Errno != 0 {
+			suffix = fmt.Sprintf(" (errno %v)", e.Errno)
+		}
+
+		if e.Ignored {
+			suffix += " (ignored)"
+		}
+
+		c.out.printStdout("%v: %v: %v%v\n", e.Path, e.Phase, e.Error, suffix)
+	}
+
+	if em.Omitted > 0 {
+		c.out.printStdout("... and %v more errors which were not recorded.\n", em.Omitted)
+	}
+
+	if len(em.Entries) == 0 && em.Omitted == 0 {
+		log(ctx).Infof("No errors recorded for snapshot %v.", m.ID)
+	}
+
+	return nil
+}
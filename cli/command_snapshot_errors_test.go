@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are not supported on Windows")
+	}
+
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	srcDir := testutil.TempDirectory(t)
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	snaps := mustListSnapshots(t, env)
+	require.Len(t, snaps, 1)
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "snapshot", "errors", string(snaps[0].ID))
+	mustGetLineContaining(t, stderr, "No errors recorded")
+
+	// named pipes are not supported and are reported as ignored errors.
+	if err := exec.Command("mkfifo", filepath.Join(srcDir, "some-pipe")).Run(); err != nil {
+		t.Skipf("unable to create named pipe: %v", err)
+	}
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", srcDir)
+
+	snaps = mustListSnapshots(t, env)
+	require.Len(t, snaps, 2)
+
+	out := env.RunAndExpectSuccess(t, "snapshot", "errors", string(snaps[1].ID))
+	mustGetLineContaining(t, out, "some-pipe: unknown-type:")
+
+	var errs []snapshotfs.EntryError
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "snapshot", "errors", string(snaps[1].ID), "--json"), &errs)
+	require.Len(t, errs, 1)
+	require.True(t, errs[0].Ignored)
+
+	require.Empty(t, env.RunAndExpectSuccess(t, "snapshot", "errors", string(snaps[1].ID), "--no-include-ignored"))
+}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"fmt"
 	"sort"
 
 	"github.com/pkg/errors"
@@ -15,6 +16,10 @@ type commandSnapshotExpire struct {
 	snapshotExpireAll    bool
 	snapshotExpirePaths  []string
 	snapshotExpireDelete bool
+
+	dryRun dryRunFlags
+	jo     jsonOutput
+	out    textOutput
 }
 
 func (c *commandSnapshotExpire) setup(svc appServices, parent commandParent) {
@@ -23,6 +28,9 @@ func (c *commandSnapshotExpire) setup(svc appServices, parent commandParent) {
 	cmd.Flag("all", "Expire all snapshots").BoolVar(&c.snapshotExpireAll)
 	cmd.Arg("path", "Expire snapshots for given paths only").StringsVar(&c.snapshotExpirePaths)
 	cmd.Flag("delete", "Whether to actually delete snapshots").BoolVar(&c.snapshotExpireDelete)
+	c.dryRun.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
 	cmd.Action(svc.repositoryWriterAction(c.run))
 }
 
@@ -56,6 +64,14 @@ func (c *commandSnapshotExpire) run(ctx context.Context, rep repo.RepositoryWrit
 		return sources[i].String() < sources[j].String()
 	})
 
+	if c.dryRun.dryRun {
+		if c.snapshotExpireDelete {
+			return errors.New("--dry-run and --delete can't be used together")
+		}
+
+		return c.reportDryRun(ctx, rep, sources)
+	}
+
 	for _, src := range sources {
 		deleted, err := policy.ApplyRetentionPolicy(ctx, rep, src, c.snapshotExpireDelete)
 		if err != nil {
@@ -76,3 +92,34 @@ func (c *commandSnapshotExpire) run(ctx context.Context, rep repo.RepositoryWrit
 
 	return nil
 }
+
+func (c *commandSnapshotExpire) reportDryRun(ctx context.Context, rep repo.RepositoryWriter, sources []snapshot.SourceInfo) error {
+	r := &DryRunReport{Operation: "snapshot expiration"}
+
+	for _, src := range sources {
+		expired, err := policy.ApplyRetentionPolicy(ctx, rep, src, false)
+		if err != nil {
+			return errors.Wrapf(err, "error applying retention policy to %v", src)
+		}
+
+		manifests, err := snapshot.LoadSnapshots(ctx, rep, expired)
+		if err != nil {
+			return errors.Wrapf(err, "error loading snapshots of %v", src)
+		}
+
+		for _, m := range manifests {
+			var size int64
+
+			if m.RootEntry != nil && m.RootEntry.DirSummary != nil {
+				size = m.RootEntry.DirSummary.TotalFileSize
+			}
+
+			r.Snapshots++
+			r.add(&DryRunItem{Type: "snapshot", ID: string(m.ID), Bytes: size, Description: fmt.Sprintf("%v %v", src, c.out.formatTimestamp(m.StartTime.ToTime()))})
+		}
+	}
+
+	c.dryRun.output(&c.jo, &c.out, r)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+type commandSnapshotExplainRetention struct {
+	sources []string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotExplainRetention) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("explain-retention", "Explain which retention rules keep each snapshot of a source and which snapshots will expire.")
+	cmd.Arg("source", "Snapshot sources ('user@host:path' or a local path)").Required().StringsVar(&c.sources)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotExplainRetention) run(ctx context.Context, rep repo.Repository) error {
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	for _, s := range c.sources {
+		src, err := snapshot.ParseSourceInfo(s, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
+		if err != nil {
+			return errors.Wrapf(err, "unable to parse %q", s)
+		}
+
+		explanations, err := policy.ExplainRetentionPolicy(ctx, rep, src)
+		if err != nil {
+			return errors.Wrapf(err, "unable to explain retention of %v", src)
+		}
+
+		if c.jo.jsonOutput {
+			for _, e := range explanations {
+				jl.emit(e)
+			}
+
+			continue
+		}
+
+		c.printExplanations(src, explanations)
+	}
+
+	return nil
+}
+
+func (c *commandSnapshotExplainRetention) printExplanations(src snapshot.SourceInfo, explanations []*policy.RetentionExplanation) {
+	c.out.printStdout("%v\n", src)
+
+	if len(explanations) == 0 {
+		c.out.printStdout("  no snapshots\n")
+		return
+	}
+
+	var expired int
+
+	for _, e := range explanations {
+		m := e.Snapshot
+
+		status := "retained"
+
+		switch {
+		case e.Expired:
+			status = "EXPIRED, will be deleted by next 'kopia snapshot expire'"
+			expired++
+		case e.ExpiresAfter != nil:
+			status = "retained until at least " + c.out.formatTimestamp(*e.ExpiresAfter)
+		}
+
+		var subpath string
+		if m.Subpath != "" {
+			subpath = " subpath:" + m.Subpath
+		}
+
+		reasons := append(policy.CompactRetentionReasons(m.RetentionReasons), policy.CompactPins(m.Pins)...)
+
+		c.out.printStdout("  %v %v%v [%v] %v\n", c.out.formatTimestamp(m.StartTime.ToTime()), m.ID, subpath, strings.Join(reasons, ","), status)
+
+		for _, d := range e.Details {
+			c.out.printStdout("    %v\n", d)
+		}
+	}
+
+	c.out.printStdout("%v of %v snapshots will expire.\n", expired, len(explanations))
+}
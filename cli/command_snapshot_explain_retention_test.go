@@ -0,0 +1,41 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotExplainRetention(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", dir)
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "explain-retention", dir)
+	require.Contains(t, lines, "    latest-1: #1 of 10 latest snapshots kept")
+	require.Contains(t, lines, "    latest-2: #2 of 10 latest snapshots kept")
+	require.Equal(t, "0 of 2 snapshots will expire.", lines[len(lines)-1])
+
+	e.RunAndExpectSuccess(t, "policy", "set", dir, "--keep-latest=1", "--keep-hourly=0", "--keep-daily=0", "--keep-weekly=0", "--keep-monthly=0", "--keep-annual=0")
+
+	lines = e.RunAndExpectSuccess(t, "snapshot", "explain-retention", dir)
+	require.Contains(t, lines, "    latest: already keeping 1 latest snapshots")
+	require.Equal(t, "1 of 2 snapshots will expire.", lines[len(lines)-1])
+
+	var explanations []*policy.RetentionExplanation
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "explain-retention", dir, "--json"), &explanations)
+	require.Len(t, explanations, 2)
+	require.False(t, explanations[0].Expired)
+	require.True(t, explanations[1].Expired)
+}
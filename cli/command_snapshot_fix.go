@@ -31,6 +31,8 @@ type commonRewriteSnapshots struct {
 	commit             bool
 	parallel           int
 	invalidDirHandling string
+
+	out textOutput
 }
 
 const (
@@ -41,13 +43,13 @@ type commonRewriteSnapshots struct {
 )
 
 func (c *commonRewriteSnapshots) setup(svc appServices, cmd *kingpin.CmdClause) {
-	_ = svc
-
 	cmd.Flag("manifest-id", "Manifest IDs").StringsVar(&c.manifestIDs)
 	cmd.Flag("source", "Source to target (username@hostname:/path)").StringsVar(&c.sources)
 	cmd.Flag("commit", "Update snapshot manifests").BoolVar(&c.commit)
 	cmd.Flag("parallel", "Parallelism").IntVar(&c.parallel)
 	cmd.Flag("invalid-directory-handling", "Handling of invalid directories").Default(invalidEntryStub).EnumVar(&c.invalidDirHandling, invalidEntryFail, invalidEntryStub, invalidEntryKeep)
+
+	c.out.setup(svc)
 }
 
 func failedEntryCallback(rep repo.RepositoryWriter, enumVal string) snapshotfs.RewriteFailedEntryCallback {
@@ -91,7 +93,7 @@ func (c *commonRewriteSnapshots) rewriteMatchingSnapshots(ctx context.Context, r
 		log(ctx).Infof("Processing snapshot %v", mg[0].Source)
 
 		for _, man := range snapshot.SortByTime(mg, false) {
-			log(ctx).Debugf("  %v (%v)", formatTimestamp(man.StartTime.ToTime()), man.ID)
+			log(ctx).Debugf("  %v (%v)", c.out.formatTimestamp(man.StartTime.ToTime()), man.ID)
 
 			old := man.Clone()
 
@@ -101,7 +103,7 @@ func (c *commonRewriteSnapshots) rewriteMatchingSnapshots(ctx context.Context, r
 			}
 
 			if !changed {
-				log(ctx).Infof("  %v unchanged (%v)", formatTimestamp(man.StartTime.ToTime()), man.ID)
+				log(ctx).Infof("  %v unchanged (%v)", c.out.formatTimestamp(man.StartTime.ToTime()), man.ID)
 
 				continue
 			}
@@ -112,7 +114,7 @@ func (c *commonRewriteSnapshots) rewriteMatchingSnapshots(ctx context.Context, r
 				}
 			}
 
-			log(ctx).Infof("  %v replaced manifest from %v to %v", formatTimestamp(man.StartTime.ToTime()), old.ID, man.ID)
+			log(ctx).Infof("  %v replaced manifest from %v to %v", c.out.formatTimestamp(man.StartTime.ToTime()), old.ID, man.ID)
 			log(ctx).Infof("    diff %v %v", old.RootEntry.ObjectID, man.RootEntry.ObjectID)
 
 			if d := snapshotSizeDelta(old, man); d != "" {
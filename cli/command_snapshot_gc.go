@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot/snapshotgc"
+	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
+)
+
+type commandSnapshotGC struct {
+	delete       bool
+	estimateOnly bool
+	safety       maintenance.SafetyParameters
+
+	dryRun dryRunFlags
+
+	jo     jsonOutput
+	events jsonEvents
+	out    textOutput
+}
+
+func (c *commandSnapshotGC) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("gc", "Find contents no longer referenced by any snapshot and report how much space can be reclaimed.")
+	cmd.Flag("delete", "Delete unreferenced contents and reclaim space by running full maintenance").BoolVar(&c.delete)
+	cmd.Flag("estimate-only", "Quickly estimate reclaimable space using index timestamps instead of walking all snapshots").BoolVar(&c.estimateOnly)
+	safetyFlagVar(cmd, &c.safety)
+	c.dryRun.setup(cmd)
+	c.jo.setup(svc, cmd)
+	c.events.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandSnapshotGC) run(ctx context.Context, rep repo.DirectRepositoryWriter) (err error) {
+	if err := c.events.begin(); err != nil {
+		return err
+	}
+
+	defer func() { c.events.finish(err) }()
+
+	return c.runGC(ctx, rep)
+}
+
+func (c *commandSnapshotGC) runGC(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	if c.delete && c.estimateOnly {
+		return errors.New("--delete and --estimate-only can't be used together")
+	}
+
+	if c.delete && c.dryRun.dryRun {
+		return errors.New("--delete and --dry-run can't be used together")
+	}
+
+	if c.delete {
+		// full maintenance performs snapshot GC while holding the maintenance lock, followed by
+		// rewriting partially-used packs and deleting unreferenced blobs, which actually reclaims space.
+		//nolint:wrapcheck
+		return snapshotmaintenance.Run(ctx, rep, maintenance.ModeFull, false, c.safety)
+	}
+
+	if c.estimateOnly {
+		return c.runEstimate(ctx, rep)
+	}
+
+	st, err := snapshotgc.FindUnused(ctx, rep, c.safety, rep.Time())
+	if err != nil {
+		return errors.Wrap(err, "error finding unused contents")
+	}
+
+	c.events.emit(jsonEventResult, st)
+
+	if c.events.usesStdout() {
+		return nil
+	}
+
+	if c.dryRun.dryRun {
+		c.dryRun.output(&c.jo, &c.out, &DryRunReport{
+			Operation:    "snapshot garbage collection",
+			Contents:     int(st.UnusedCount),
+			ContentBytes: st.UnusedBytes,
+		})
+
+		return nil
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(st))
+		return nil
+	}
+
+	c.out.printStdout("In use:          %v contents (%v)\n", units.Count(int64(st.InUseCount)), units.BytesString(st.InUseBytes))
+	c.out.printStdout("System:          %v contents (%v)\n", units.Count(int64(st.SystemCount)), units.BytesString(st.SystemBytes))
+	c.out.printStdout("Too recent:      %v contents (%v)\n", units.Count(int64(st.TooRecentCount)), units.BytesString(st.TooRecentBytes))
+	c.out.printStdout("To undelete:     %v contents (%v)\n", units.Count(int64(st.UndeletedCount)), units.BytesString(st.UndeletedBytes))
+	c.out.printStdout("Reclaimable:     %v contents (%v)\n", units.Count(int64(st.UnusedCount)), units.BytesString(st.UnusedBytes))
+
+	if st.UnusedCount > 0 {
+		c.out.printStderr("\nPass --delete to delete unreferenced contents and reclaim space.\n")
+	}
+
+	return nil
+}
+
+func (c *commandSnapshotGC) runEstimate(ctx context.Context, rep repo.DirectRepository) error {
+	st, err := snapshotgc.EstimateUnused(ctx, rep, c.safety, rep.Time())
+	if err != nil {
+		return errors.Wrap(err, "error estimating unused contents")
+	}
+
+	c.events.emit(jsonEventResult, st)
+
+	if c.events.usesStdout() {
+		return nil
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(st))
+		return nil
+	}
+
+	c.out.printStdout("In use:          ~%v contents (%v)\n", units.Count(int64(st.InUseCount)), units.BytesString(st.InUseBytes))
+	c.out.printStdout("System:          %v contents (%v)\n", units.Count(int64(st.SystemCount)), units.BytesString(st.SystemBytes))
+	c.out.printStdout("Too recent:      %v contents (%v)\n", units.Count(int64(st.TooRecentCount)), units.BytesString(st.TooRecentBytes))
+	c.out.printStdout("Reclaimable:     ~%v contents (%v)\n", units.Count(int64(st.UnusedCount)), units.BytesString(st.UnusedBytes))
+
+	if st.BaselineTime.IsZero() {
+		c.out.printStderr("\nNo full garbage collection has been performed yet, the estimate may be inaccurate.\n")
+	} else {
+		c.out.printStderr("\nEstimate is based on garbage collection as of %v, run without --estimate-only for exact numbers.\n", c.out.formatTimestamp(st.BaselineTime))
+	}
+
+	return nil
+}
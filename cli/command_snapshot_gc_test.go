@@ -0,0 +1,74 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotgc"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotGC(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "a.txt"), []byte("some data to be garbage collected"), 0o644))
+
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+
+	var st snapshotgc.Stats
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--json"), &st)
+	require.Zero(t, st.UnusedCount)
+	require.Positive(t, st.InUseCount)
+
+	var est snapshotgc.EstimateStats
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--estimate-only", "--json"), &est)
+	require.Zero(t, est.UnusedCount)
+	require.Positive(t, est.InUseCount)
+
+	e.RunAndExpectFailure(t, "snapshot", "gc", "--estimate-only", "--delete")
+
+	e.RunAndExpectSuccess(t, "snapshot", "delete", string(man.ID), "--delete")
+
+	// with full safety, recently written contents are not subject to GC.
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--json"), &st)
+	require.Zero(t, st.UnusedCount)
+	require.Positive(t, st.TooRecentCount)
+
+	// dry run does not delete anything.
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--json"), &st)
+	require.Positive(t, st.UnusedCount)
+	require.Positive(t, st.UnusedBytes)
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--json"), &st)
+	require.Positive(t, st.UnusedCount)
+
+	e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none")
+
+	// contents deleted within the same second they were written get their deletion timestamp
+	// bumped into the future, which would keep them from being dropped right away, so run the
+	// deletion on a simulated clock that starts after all contents have been written.
+	gcTime := clock.Now().Add(time.Minute).UTC().Format(time.RFC3339)
+
+	e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--delete", "--deterministic-time="+gcTime)
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--safety=none", "--json"), &st)
+	require.Zero(t, st.UnusedCount)
+}
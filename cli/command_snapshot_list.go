@@ -37,8 +37,9 @@ type commandSnapshotList struct {
 	storageStats                     bool
 	reverseSort                      bool
 
-	jo  jsonOutput
-	out textOutput
+	jo    jsonOutput
+	out   textOutput
+	table tableOutput[*snapshot.Manifest]
 }
 
 func (c *commandSnapshotList) setup(svc appServices, parent commandParent) {
@@ -58,6 +59,7 @@ func (c *commandSnapshotList) setup(svc appServices, parent commandParent) {
 	cmd.Flag("max-results", "Maximum number of entries per source.").Short('n').IntVar(&c.maxResultsPerPath)
 	cmd.Flag("tags", "Tag filters to apply on the list items. Must be provided in the <key>:<value> format.").StringsVar(&c.snapshotListTags)
 	c.jo.setup(svc, cmd)
+	c.table.setup(cmd, c.tableColumns(), "source", "start", "id", "root", "size", "files", "dirs")
 	c.out.setup(svc)
 	cmd.Action(svc.repositoryReaderAction(c.run))
 }
@@ -120,6 +122,10 @@ func findManifestIDs(ctx context.Context, rep repo.Repository, source string, ta
 }
 
 func (c *commandSnapshotList) run(ctx context.Context, rep repo.Repository) error {
+	if err := c.table.validate(); err != nil {
+		return err
+	}
+
 	tags, err := getTags(c.snapshotListTags)
 	if err != nil {
 		return err
@@ -139,6 +145,10 @@ func (c *commandSnapshotList) run(ctx context.Context, rep repo.Repository) erro
 		return c.outputJSON(ctx, rep, manifests)
 	}
 
+	if c.table.enabled() {
+		return c.outputTable(ctx, rep, manifests)
+	}
+
 	return c.outputManifestGroups(ctx, rep, manifests, fullPath)
 }
 
@@ -168,7 +178,7 @@ func (c *commandSnapshotList) outputJSON(ctx context.Context, rep repo.Repositor
 			if err != nil {
 				log(ctx).Errorf("unable to determine effective policy for %v", src)
 			} else {
-				pol.RetentionPolicy.ComputeRetentionReasons(snapshotGroup)
+				computeRetentionReasons(pol, snapshotGroup)
 			}
 		}
 
@@ -184,6 +194,14 @@ func (c *commandSnapshotList) outputJSON(ctx context.Context, rep repo.Repositor
 	return nil
 }
 
+// computeRetentionReasons computes retention reasons independently for full snapshots
+// and snapshots of each subpath, the same way snapshot expiration does.
+func computeRetentionReasons(pol *policy.Policy, snapshots []*snapshot.Manifest) {
+	for _, g := range snapshot.GroupBySubpath(snapshots) {
+		pol.RetentionPolicy.ComputeRetentionReasons(g)
+	}
+}
+
 func (c *commandSnapshotList) shouldOutputSnapshotSource(rep repo.Repository, src snapshot.SourceInfo) bool {
 	if c.snapshotListShowAll {
 		return true
@@ -219,7 +237,7 @@ func (c *commandSnapshotList) outputManifestGroups(ctx context.Context, rep repo
 		if err != nil {
 			log(ctx).Errorf("unable to determine effective policy for %v", src)
 		} else {
-			pol.RetentionPolicy.ComputeRetentionReasons(snapshotGroup)
+			computeRetentionReasons(pol, snapshotGroup)
 		}
 
 		relPathParts, err := findRelativePathParts(snapshotGroup[0], path)
@@ -278,13 +296,13 @@ func (c *commandSnapshotList) outputManifestFromSingleSource(ctx context.Context
 	if err := c.iterateSnapshotsMaybeWithStorageStats(ctx, rep, manifests, func(m *snapshot.Manifest) error {
 		root, err := snapshotfs.SnapshotRoot(rep, m)
 		if err != nil {
-			c.out.printStdout("  %v <ERROR> %v\n", formatTimestamp(m.StartTime.ToTime()), err)
+			c.out.printStdout("  %v <ERROR> %v\n", c.out.formatTimestamp(m.StartTime.ToTime()), err)
 			return nil
 		}
 
 		ent, err := snapshotfs.GetNestedEntry(ctx, root, parts)
 		if err != nil {
-			c.out.printStdout("  %v <ERROR> %v\n", formatTimestamp(m.StartTime.ToTime()), err)
+			c.out.printStdout("  %v <ERROR> %v\n", c.out.formatTimestamp(m.StartTime.ToTime()), err)
 			return nil
 		}
 
@@ -372,13 +390,13 @@ func (c *commandSnapshotList) outputSnapshotRows(rows []*snapshotListRow) {
 			bits = append(bits, "pins:"+strings.Join(row.pins, ","))
 		}
 
-		row.color.Fprint(c.out.stdout(), fmt.Sprintf("  %v %v %v\n", formatTimestamp(row.firstStartTime), row.oid, strings.Join(bits, " "))) //nolint:errcheck
+		row.color.Fprint(c.out.stdout(), fmt.Sprintf("  %v %v %v\n", c.out.formatTimestamp(row.firstStartTime), row.oid, strings.Join(bits, " "))) //nolint:errcheck
 
 		if row.count > 1 {
 			c.out.printStdout(
 				"  + %v identical snapshots until %v\n",
 				row.count-1,
-				formatTimestamp(row.lastStartTime),
+				c.out.formatTimestamp(row.lastStartTime),
 			)
 		}
 	}
@@ -391,6 +409,15 @@ func (c *commandSnapshotList) entryBits(ctx context.Context, m *snapshot.Manifes
 		bits = append(bits, "incomplete:"+m.IncompleteReason)
 	}
 
+	if m.Subpath != "" {
+		bits = append(bits, "subpath:"+m.Subpath)
+	}
+
+	if len(m.Anomalies) > 0 {
+		bits = append(bits, "anomalous")
+		col = errorColor
+	}
+
 	var summary *fs.DirectorySummary
 
 	if dws, ok := ent.(fs.DirectoryWithSummary); ok {
@@ -417,7 +444,7 @@ func (c *commandSnapshotList) entryBits(ctx context.Context, m *snapshot.Manifes
 	}
 
 	if c.snapshotListShowModTime {
-		bits = append(bits, fmt.Sprintf("modified:%v", formatTimestamp(ent.ModTime())))
+		bits = append(bits, fmt.Sprintf("modified:%v", c.out.formatTimestamp(ent.ModTime())))
 	}
 
 	if c.snapshotListShowItemID {
@@ -450,6 +477,110 @@ func (c *commandSnapshotList) entryBits(ctx context.Context, m *snapshot.Manifes
 	return bits, col
 }
 
+func (c *commandSnapshotList) tableColumns() []tableColumn[*snapshot.Manifest] {
+	byTime := func(t func(m *snapshot.Manifest) fs.UTCTimestamp) func(a, b *snapshot.Manifest) bool {
+		return func(a, b *snapshot.Manifest) bool { return t(a).Before(t(b)) }
+	}
+
+	startTime := func(m *snapshot.Manifest) fs.UTCTimestamp { return m.StartTime }
+	endTime := func(m *snapshot.Manifest) fs.UTCTimestamp { return m.EndTime }
+	// summary of the root directory describes the entire snapshot, unlike upload stats which don't count cached files.
+	summary := func(m *snapshot.Manifest) fs.DirectorySummary {
+		if m.RootEntry != nil && m.RootEntry.DirSummary != nil {
+			return *m.RootEntry.DirSummary
+		}
+
+		return fs.DirectorySummary{
+			TotalFileSize:   atomic.LoadInt64(&m.Stats.TotalFileSize),
+			TotalFileCount:  int64(atomic.LoadInt32(&m.Stats.TotalFileCount)),
+			TotalDirCount:   int64(atomic.LoadInt32(&m.Stats.TotalDirectoryCount)),
+			FatalErrorCount: int(atomic.LoadInt32(&m.Stats.ErrorCount)),
+		}
+	}
+
+	totalSize := func(m *snapshot.Manifest) int64 { return summary(m).TotalFileSize }
+	fileCount := func(m *snapshot.Manifest) int64 { return summary(m).TotalFileCount }
+	dirCount := func(m *snapshot.Manifest) int64 { return summary(m).TotalDirCount }
+	errorCount := func(m *snapshot.Manifest) int { return summary(m).FatalErrorCount }
+
+	return []tableColumn[*snapshot.Manifest]{
+		{name: "source", value: func(m *snapshot.Manifest) string { return m.Source.String() }},
+		{name: "host", value: func(m *snapshot.Manifest) string { return m.Source.Host }},
+		{name: "user", value: func(m *snapshot.Manifest) string { return m.Source.UserName }},
+		{name: "path", value: func(m *snapshot.Manifest) string { return m.Source.Path }},
+		{name: "start", value: func(m *snapshot.Manifest) string { return c.out.formatTimestamp(m.StartTime.ToTime()) }, less: byTime(startTime)},
+		{name: "end", value: func(m *snapshot.Manifest) string { return c.out.formatTimestamp(m.EndTime.ToTime()) }, less: byTime(endTime)},
+		{
+			name:  "duration",
+			value: func(m *snapshot.Manifest) string { return c.out.formatDuration(m.EndTime.Sub(m.StartTime)) },
+			less:  func(a, b *snapshot.Manifest) bool { return a.EndTime.Sub(a.StartTime) < b.EndTime.Sub(b.StartTime) },
+		},
+		{name: "id", value: func(m *snapshot.Manifest) string { return string(m.ID) }},
+		{name: "root", value: func(m *snapshot.Manifest) string { return m.RootObjectID().String() }},
+		{
+			name: "size",
+			value: func(m *snapshot.Manifest) string {
+				return maybeHumanReadableBytes(c.snapshotListShowHumanReadable, totalSize(m))
+			},
+			less: func(a, b *snapshot.Manifest) bool { return totalSize(a) < totalSize(b) },
+		},
+		{
+			name:  "files",
+			value: func(m *snapshot.Manifest) string { return fmt.Sprintf("%v", fileCount(m)) },
+			less:  func(a, b *snapshot.Manifest) bool { return fileCount(a) < fileCount(b) },
+		},
+		{
+			name:  "dirs",
+			value: func(m *snapshot.Manifest) string { return fmt.Sprintf("%v", dirCount(m)) },
+			less:  func(a, b *snapshot.Manifest) bool { return dirCount(a) < dirCount(b) },
+		},
+		{
+			name:  "errors",
+			value: func(m *snapshot.Manifest) string { return fmt.Sprintf("%v", errorCount(m)) },
+			less:  func(a, b *snapshot.Manifest) bool { return errorCount(a) < errorCount(b) },
+		},
+		{name: "description", value: func(m *snapshot.Manifest) string { return m.Description }},
+		{name: "incomplete", value: func(m *snapshot.Manifest) string { return m.IncompleteReason }},
+		{name: "retention", value: func(m *snapshot.Manifest) string { return strings.Join(m.RetentionReasons, ",") }},
+		{name: "pins", value: func(m *snapshot.Manifest) string { return strings.Join(m.Pins, ",") }},
+	}
+}
+
+// outputTable outputs snapshots of all matching sources as a single table, one row per snapshot.
+func (c *commandSnapshotList) outputTable(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest) error {
+	var rows []*snapshot.Manifest
+
+	for _, snapshotGroup := range snapshot.GroupBySource(manifests) {
+		src := snapshotGroup[0].Source
+		if !c.shouldOutputSnapshotSource(rep, src) {
+			log(ctx).Debugf("skipping %v", src)
+			continue
+		}
+
+		pol, _, _, err := policy.GetEffectivePolicy(ctx, rep, src)
+		if err != nil {
+			log(ctx).Errorf("unable to determine effective policy for %v", src)
+		} else {
+			computeRetentionReasons(pol, snapshotGroup)
+		}
+
+		snapshotGroup = snapshot.SortByTime(snapshotGroup, c.reverseSort)
+		if c.maxResultsPerPath > 0 && len(snapshotGroup) > c.maxResultsPerPath {
+			snapshotGroup = snapshotGroup[len(snapshotGroup)-c.maxResultsPerPath:]
+		}
+
+		for _, m := range snapshotGroup {
+			if m.IncompleteReason != "" && !c.snapshotListIncludeIncomplete {
+				continue
+			}
+
+			rows = append(rows, m)
+		}
+	}
+
+	return c.table.render(c.out.stdout(), rows)
+}
+
 func deltaBytes(b int64) string {
 	if b > 0 {
 		return "(+" + units.BytesString(b) + ")"
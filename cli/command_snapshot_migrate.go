@@ -106,6 +106,7 @@ func (c *commandSnapshotMigrate) run(ctx context.Context, destRepo repo.Reposito
 
 		uploader := snapshotfs.NewUploader(destRepo)
 		uploader.Progress = c.svc.getProgress()
+		uploader.LowMemory = c.svc.IsLowMemory()
 		activeUploaders[s] = uploader
 		mu.Unlock()
 
@@ -137,21 +138,7 @@ func (c *commandSnapshotMigrate) run(ctx context.Context, destRepo repo.Reposito
 }
 
 func (c *commandSnapshotMigrate) openSourceRepo(ctx context.Context) (repo.Repository, error) {
-	pass, err := c.svc.passwordPersistenceStrategy().GetPassword(ctx, c.migrateSourceConfig)
-	if err != nil {
-		pass, err = c.svc.getPasswordFromFlags(ctx, false, false)
-	}
-
-	if err != nil {
-		return nil, errors.Wrap(err, "source repository password")
-	}
-
-	sourceRepo, err := repo.Open(ctx, c.migrateSourceConfig, pass, c.svc.optionsFromFlags(ctx))
-	if err != nil {
-		return nil, errors.Wrap(err, "can't open source repository")
-	}
-
-	return sourceRepo, nil
+	return openRepositoryWithConfig(ctx, c.svc, c.migrateSourceConfig)
 }
 
 func (c *commandSnapshotMigrate) migratePoliciesForSources(ctx context.Context, sourceRepo repo.Repository, destRepo repo.RepositoryWriter, sources []snapshot.SourceInfo) error {
@@ -250,7 +237,7 @@ func (c *commandSnapshotMigrate) migrateSingleSource(ctx context.Context, upload
 
 func (c *commandSnapshotMigrate) migrateSingleSourceSnapshot(ctx context.Context, uploader *snapshotfs.Uploader, sourceRepo repo.Repository, destRepo repo.RepositoryWriter, s snapshot.SourceInfo, m *snapshot.Manifest) error {
 	if m.IncompleteReason != "" {
-		log(ctx).Debugf("ignoring incomplete %v at %v", s, formatTimestamp(m.StartTime.ToTime()))
+		log(ctx).Debugf("ignoring incomplete %v at %v", s, c.out.formatTimestamp(m.StartTime.ToTime()))
 		return nil
 	}
 
@@ -265,11 +252,11 @@ func (c *commandSnapshotMigrate) migrateSingleSourceSnapshot(ctx context.Context
 	}
 
 	if existing != nil {
-		log(ctx).Infof("already migrated %v at %v", s, formatTimestamp(m.StartTime.ToTime()))
+		log(ctx).Infof("already migrated %v at %v", s, c.out.formatTimestamp(m.StartTime.ToTime()))
 		return nil
 	}
 
-	log(ctx).Infof("migrating snapshot of %v at %v", s, formatTimestamp(m.StartTime.ToTime()))
+	log(ctx).Infof("migrating snapshot of %v at %v", s, c.out.formatTimestamp(m.StartTime.ToTime()))
 
 	previous, err := findPreviousSnapshotManifest(ctx, destRepo, m.Source, &m.StartTime)
 	if err != nil {
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandSnapshotReference struct {
+	create  commandSnapshotReferenceCreate
+	list    commandSnapshotReferenceList
+	delete  commandSnapshotReferenceDelete
+	token   commandSnapshotReferenceToken
+	resolve commandSnapshotReferenceResolve
+}
+
+func (c *commandSnapshotReference) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("reference", "Manage references pinning snapshot roots, which can be shared with external systems.").Alias("ref")
+
+	c.create.setup(svc, cmd)
+	c.list.setup(svc, cmd)
+	c.delete.setup(svc, cmd)
+	c.token.setup(svc, cmd)
+	c.resolve.setup(svc, cmd)
+}
+
+type rootReferenceJSON struct {
+	ID    manifest.ID `json:"id"`
+	Token string      `json:"token,omitempty"`
+	*snapshotfs.RootReference
+}
+
+type commandSnapshotReferenceCreate struct {
+	root        string
+	description string
+	expireAfter time.Duration
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotReferenceCreate) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("create", "Pin the root of a tree and print a token referencing it.")
+	cmd.Arg("root", "Object ID, snapshot ID or root object ID optionally followed by path within the snapshot").Required().StringVar(&c.root)
+	cmd.Flag("description", "Free-form reference description.").StringVar(&c.description)
+	cmd.Flag("expire-after", "Expire the reference after the specified duration (0=never)").DurationVar(&c.expireAfter)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryWriteAction(c.run))
+}
+
+func (c *commandSnapshotReferenceCreate) run(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	oid, err := snapshotfs.ParseObjectIDWithPath(ctx, rep, c.root)
+	if err != nil {
+		return errors.Wrapf(err, "unable to parse %v", c.root)
+	}
+
+	var expireTime time.Time
+
+	if c.expireAfter < 0 {
+		return errors.Errorf("--expire-after must not be negative")
+	}
+
+	if c.expireAfter > 0 {
+		expireTime = rep.Time().Add(c.expireAfter).UTC()
+	}
+
+	r, err := snapshotfs.PinRoot(ctx, rep, oid, c.description, expireTime)
+	if err != nil {
+		return errors.Wrap(err, "unable to create reference")
+	}
+
+	token, err := snapshotfs.RootReferenceToken(rep, r)
+	if err != nil {
+		return errors.Wrap(err, "unable to create reference token")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(rootReferenceJSON{r.ID, token, r}))
+		return nil
+	}
+
+	log(ctx).Infof("Created reference %v to %v.", r.ID, r.RootObjectID)
+	c.out.printStdout("%v\n", token)
+
+	return nil
+}
+
+type commandSnapshotReferenceList struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotReferenceList) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("list", "List references pinning snapshot roots.").Alias("ls")
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.repositoryReaderAction(c.run))
+}
+
+func (c *commandSnapshotReferenceList) run(ctx context.Context, rep repo.Repository) error {
+	refs, err := snapshotfs.ListRootReferences(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to list references")
+	}
+
+	var jl jsonList
+
+	jl.begin(&c.jo)
+	defer jl.end()
+
+	for _, r := range refs {
+		if c.jo.jsonOutput {
+			jl.emit(rootReferenceJSON{r.ID, "", r})
+			continue
+		}
+
+		expires := "never"
+
+		switch {
+		case r.Expired(rep.Time()):
+			expires = "expired"
+		case !r.ExpireTime.IsZero():
+			expires = c.out.formatTimestamp(r.ExpireTime)
+		}
+
+		c.out.printStdout("%v %v %v %v expires:%v %v\n", r.ID, r.RootObjectID, c.out.formatTimestamp(r.CreateTime), r.CreatedBy, expires, r.Description)
+	}
+
+	return nil
+}
+
+type commandSnapshotReferenceDelete struct {
+	ids []string
+}
+
+func (c *commandSnapshotReferenceDelete) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("delete", "Delete references, which invalidates their tokens and allows their trees to be garbage-collected.").Alias("rm")
+	cmd.Arg("id", "Reference ID").Required().StringsVar(&c.ids)
+	cmd.Action(svc.repositoryWriterAction(c.run))
+}
+
+func (c *commandSnapshotReferenceDelete) run(ctx context.Context, rep repo.RepositoryWriter) error {
+	for _, id := range c.ids {
+		if err := snapshotfs.UnpinRoot(ctx, rep, manifest.ID(id)); err != nil {
+			return errors.Wrapf(err, "unable to delete reference %v", id)
+		}
+
+		log(ctx).Infof("Deleted reference %v.", id)
+	}
+
+	return nil
+}
+
+type commandSnapshotReferenceToken struct {
+	id string
+
+	out textOutput
+}
+
+func (c *commandSnapshotReferenceToken) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("token", "Print a new token for an existing reference.")
+	cmd.Arg("id", "Reference ID").Required().StringVar(&c.id)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandSnapshotReferenceToken) run(ctx context.Context, rep repo.DirectRepository) error {
+	r, err := snapshotfs.GetRootReference(ctx, rep, manifest.ID(c.id))
+	if err != nil {
+		return errors.Wrapf(err, "unable to get reference %v", c.id)
+	}
+
+	token, err := snapshotfs.RootReferenceToken(rep, r)
+	if err != nil {
+		return errors.Wrap(err, "unable to create reference token")
+	}
+
+	c.out.printStdout("%v\n", token)
+
+	return nil
+}
+
+type commandSnapshotReferenceResolve struct {
+	token string
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandSnapshotReferenceResolve) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("resolve", "Verify the reference token and print the root object ID it refers to.")
+	cmd.Arg("token", "Reference token").Required().StringVar(&c.token)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandSnapshotReferenceResolve) run(ctx context.Context, rep repo.DirectRepository) error {
+	r, err := snapshotfs.ResolveRootReferenceToken(ctx, rep, c.token)
+	if err != nil {
+		return errors.Wrap(err, "unable to resolve reference token")
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(rootReferenceJSON{r.ID, "", r}))
+		return nil
+	}
+
+	c.out.printStdout("%v\n", r.RootObjectID)
+
+	return nil
+}
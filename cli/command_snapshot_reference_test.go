@@ -0,0 +1,62 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotReference(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	var snaps []*cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", "--json"), &snaps)
+	require.Len(t, snaps, 1)
+
+	rootID := snaps[0].RootObjectID().String()
+
+	token := e.RunAndExpectSuccess(t, "snapshot", "reference", "create", rootID, "--description=shared")
+	require.Len(t, token, 1)
+
+	require.Equal(t, []string{rootID}, e.RunAndExpectSuccess(t, "snapshot", "reference", "resolve", token[0]))
+	e.RunAndExpectFailure(t, "snapshot", "reference", "resolve", token[0]+"x")
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "reference", "list")
+	require.Len(t, lines, 1)
+	require.Contains(t, lines[0], rootID)
+	require.Contains(t, lines[0], "expires:never")
+
+	var refs []map[string]any
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "reference", "list", "--json"), &refs)
+	require.Len(t, refs, 1)
+	require.Equal(t, "shared", refs[0]["description"])
+
+	refID := refs[0]["id"].(string)
+
+	// new tokens can be issued for existing references.
+	newToken := e.RunAndExpectSuccess(t, "snapshot", "reference", "token", refID)
+	require.Equal(t, []string{rootID}, e.RunAndExpectSuccess(t, "snapshot", "reference", "resolve", newToken[0]))
+
+	// references outlive snapshots.
+	e.RunAndExpectSuccess(t, "snapshot", "delete", string(snaps[0].ID), "--delete")
+	require.Equal(t, []string{rootID}, e.RunAndExpectSuccess(t, "snapshot", "reference", "resolve", token[0]))
+
+	e.RunAndExpectSuccess(t, "snapshot", "reference", "delete", refID)
+	e.RunAndExpectFailure(t, "snapshot", "reference", "resolve", token[0])
+	require.Empty(t, e.RunAndExpectSuccess(t, "snapshot", "reference", "list"))
+	e.RunAndExpectFailure(t, "snapshot", "reference", "delete", refID)
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandSnapshotVerificationReports struct {
+	jo  jsonOutput
+	out textOutput
+}
+
+type verificationReportJSON struct {
+	ID manifest.ID `json:"id"`
+	*snapshotfs.VerificationReport
+}
+
+func (c *commandSnapshotVerificationReports) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("verification-reports", "List verification reports saved in the repository and check their signatures.")
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandSnapshotVerificationReports) run(ctx context.Context, rep repo.DirectRepository) error {
+	reports, err := snapshotfs.ListVerificationReports(ctx, rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to list verification reports")
+	}
+
+	if c.jo.jsonOutput {
+		var jl jsonList
+
+		jl.begin(&c.jo)
+
+		for _, r := range reports {
+			jl.emit(verificationReportJSON{r.ID, r})
+		}
+
+		jl.end()
+	} else {
+		for _, r := range reports {
+			status := "OK"
+			if !r.Succeeded() {
+				status = "FAILED"
+			}
+
+			c.out.printStdout("#%-4v %v %v %v@%v snapshots:%v objects:%v failed:%v %v\n",
+				r.Sequence,
+				r.ID,
+				c.out.formatTimestamp(r.EndTime),
+				r.Username,
+				r.Hostname,
+				len(r.Snapshots),
+				r.Stats.Processed,
+				r.Stats.Failed,
+				status,
+			)
+		}
+	}
+
+	problems := snapshotfs.CheckVerificationReports(rep, reports)
+	for _, p := range problems {
+		log(ctx).Errorf("%v", p)
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("found %v problems with verification reports", len(problems))
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package cli_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestSnapshotVerificationReports(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+
+	require.Empty(t, e.RunAndExpectSuccess(t, "snapshot", "verification-reports"))
+
+	// reports are only saved when requested.
+	e.RunAndExpectSuccess(t, "snapshot", "verify")
+	require.Empty(t, e.RunAndExpectSuccess(t, "snapshot", "verification-reports"))
+
+	e.RunAndExpectSuccess(t, "snapshot", "verify", "--save-report")
+	e.RunAndExpectSuccess(t, "snapshot", "verify", "--save-report", "--verify-files-percent=100")
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "verification-reports")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], "#0")
+	require.Contains(t, lines[0], "snapshots:1")
+	require.Contains(t, lines[1], "#1")
+	require.Contains(t, lines[1], "OK")
+
+	var reports []map[string]any
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "verification-reports", "--json"), &reports)
+	require.Len(t, reports, 2)
+	require.Equal(t, reports[0]["signature"], reports[1]["previousSignature"])
+
+	// deleting a report breaks the chain.
+	e.RunAndExpectSuccess(t, "manifest", "rm", reports[0]["id"].(string))
+	e.RunAndExpectFailure(t, "snapshot", "verification-reports")
+}
@@ -2,13 +2,17 @@
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
 	"runtime"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/repo"
-	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/snapshotfs"
@@ -25,6 +29,21 @@ type commandSnapshotVerify struct {
 
 	fileQueueLength int
 	fileParallelism int
+
+	failureHistory          bool
+	failureHistoryRetention time.Duration
+	neighborWindow          time.Duration
+	blastRadiusReport       string
+	saveReport              bool
+
+	inventory blobInventoryFlags
+
+	historyFile func() string
+	isForensic  func() bool
+
+	events jsonEvents
+
+	out textOutput
 }
 
 func (c *commandSnapshotVerify) setup(svc appServices, parent commandParent) {
@@ -40,10 +59,35 @@ func (c *commandSnapshotVerify) setup(svc appServices, parent commandParent) {
 	cmd.Flag("file-queue-length", "Queue length for file verification").Default("20000").IntVar(&c.fileQueueLength)
 	cmd.Flag("file-parallelism", "Parallelism for file verification").IntVar(&c.fileParallelism)
 	cmd.Flag("verify-files-percent", "Randomly verify a percentage of files by downloading them [0.0 .. 100.0]").Default("0").Float64Var(&c.verifyCommandFilesPercent)
+	cmd.Flag("failure-history", "Remember pack blobs which failed verification and check them, their neighbors and related snapshots first next time").Default("true").BoolVar(&c.failureHistory)
+	cmd.Flag("failure-history-retention", "How long to remember verification failures").Default("720h").DurationVar(&c.failureHistoryRetention)
+	cmd.Flag("neighbor-window", "Pack blobs written within this time of a failed blob are considered potentially affected").Default("1h").DurationVar(&c.neighborWindow)
+	cmd.Flag("blast-radius-report", "Write JSON report of files affected by failed or suspect blobs to the provided file").StringVar(&c.blastRadiusReport)
+	cmd.Flag("save-report", "Save signed verification report in the repository").BoolVar(&c.saveReport)
+	c.inventory.setup(cmd)
+	c.historyFile = func() string { return svc.repositoryConfigFileName() + ".verify-failures.json" }
+	c.isForensic = svc.IsForensic
+	c.events.setup(svc, cmd)
 	cmd.Action(svc.repositoryReaderAction(c.run))
+
+	c.out.setup(svc)
+}
+
+func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) (err error) {
+	if err := c.events.begin(); err != nil {
+		return err
+	}
+
+	defer func() { c.events.finish(err) }()
+
+	return c.verify(ctx, rep)
 }
 
-func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) error {
+func (c *commandSnapshotVerify) verify(ctx context.Context, rep repo.Repository) error {
+	if c.saveReport && c.isForensic() {
+		return errors.New("--save-report is not allowed in forensic mode")
+	}
+
 	if c.verifyCommandAllSources {
 		log(ctx).Errorf("DEPRECATED: --all-sources flag has no effect and is the default when no sources are provided.")
 	}
@@ -59,32 +103,58 @@ func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) er
 		MaxErrors:          c.verifyCommandErrorThreshold,
 	}
 
+	history, err := c.loadFailureHistory(rep)
+	if err != nil {
+		return err
+	}
+
 	if dr, ok := rep.(repo.DirectRepository); ok {
-		blobMap, err := blob.ReadBlobMap(ctx, dr.BlobReader())
+		blobMap, err := c.inventory.readBlobMap(ctx, dr)
 		if err != nil {
 			return errors.Wrap(err, "unable to read blob map")
 		}
 
 		opts.BlobMap = blobMap
+		opts.SuspectBlobs = history.SuspectBlobs(blobMap, c.neighborWindow)
 	}
 
+	startTime := rep.Time()
+
 	v := snapshotfs.NewVerifier(ctx, rep, opts)
 	defer v.ShowFinalStats(ctx)
 
-	//nolint:wrapcheck
-	return v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
+	c.events.startProgressEvents(func() any { return v.Stats() })
+
+	if err := v.VerifySuspectBlobs(ctx); err != nil {
+		return errors.Wrap(err, "error verifying suspect blobs")
+	}
+
+	// snapshot root paths mapped to their sources.
+	roots := map[string]snapshot.SourceInfo{}
+
+	verifyErr := v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
 		manifests, err := c.loadSourceManifests(ctx, rep, c.verifyCommandSources)
 		if err != nil {
 			return err
 		}
 
+		// verify snapshots of sources which recently failed verification first.
+		sort.SliceStable(manifests, func(i, j int) bool {
+			_, fi := history.Sources[manifests[i].Source.String()]
+			_, fj := history.Sources[manifests[j].Source.String()]
+
+			return fi && !fj
+		})
+
 		for _, man := range manifests {
-			rootPath := fmt.Sprintf("%v@%v", man.Source, formatTimestamp(man.StartTime.ToTime()))
+			rootPath := fmt.Sprintf("%v@%v", man.Source, c.out.formatTimestamp(man.StartTime.ToTime()))
 
 			if man.RootEntry == nil {
 				continue
 			}
 
+			roots[rootPath] = man.Source
+
 			root, err := snapshotfs.SnapshotRoot(rep, man)
 			if err != nil {
 				return errors.Wrapf(err, "unable to get snapshot root: %q", rootPath)
@@ -119,6 +189,137 @@ func (c *commandSnapshotVerify) run(ctx context.Context, rep repo.Repository) er
 
 		return nil
 	})
+
+	if err := c.reportFailureDomains(ctx, rep, v, history, roots); err != nil {
+		log(ctx).Errorf("unable to report failure domains: %v", err)
+	}
+
+	for _, af := range v.AffectedFiles() {
+		if af.Error != "" {
+			c.events.emitError(af.Path, errors.New(af.Error))
+		}
+	}
+
+	c.events.emit(jsonEventResult, v.Stats())
+
+	if c.saveReport {
+		if err := c.saveVerificationReport(ctx, rep, v, startTime, roots, verifyErr); err != nil {
+			return err
+		}
+	}
+
+	//nolint:wrapcheck
+	return verifyErr
+}
+
+// saveVerificationReport stores signed summary of the verification in the repository.
+func (c *commandSnapshotVerify) saveVerificationReport(ctx context.Context, rep repo.Repository, v *snapshotfs.Verifier, startTime time.Time, roots map[string]snapshot.SourceInfo, verifyErr error) error {
+	dr, ok := rep.(repo.DirectRepository)
+	if !ok {
+		return errors.New("verification reports can only be saved when directly connected to the repository")
+	}
+
+	r := &snapshotfs.VerificationReport{
+		StartTime:          startTime.UTC(),
+		EndTime:            rep.Time().UTC(),
+		Hostname:           rep.ClientOptions().Hostname,
+		Username:           rep.ClientOptions().Username,
+		ObjectIDs:          append(append([]string(nil), c.verifyCommandDirObjectIDs...), c.verifyCommandFileObjectIDs...),
+		VerifyFilesPercent: c.verifyCommandFilesPercent,
+		Stats:              v.Stats(),
+		FailedBlobs:        v.FailedBlobs(),
+	}
+
+	for rootPath := range roots {
+		r.Snapshots = append(r.Snapshots, rootPath)
+	}
+
+	sort.Strings(r.Snapshots)
+
+	if verifyErr != nil {
+		r.Error = verifyErr.Error()
+	}
+
+	return errors.Wrap(repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{
+		Purpose: "snapshot verify",
+	}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		id, err := snapshotfs.SaveVerificationReport(ctx, w, r)
+		if err != nil {
+			return errors.Wrap(err, "unable to save verification report")
+		}
+
+		log(ctx).Infof("Saved verification report %v (#%v).", id, r.Sequence)
+
+		return nil
+	}), "unable to save verification report")
+}
+
+func (c *commandSnapshotVerify) loadFailureHistory(rep repo.Repository) (*snapshotfs.VerifyFailureHistory, error) {
+	if !c.failureHistory {
+		return snapshotfs.NewVerifyFailureHistory(), nil
+	}
+
+	h, err := snapshotfs.LoadVerifyFailureHistory(c.historyFile())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load verification failure history")
+	}
+
+	h.Expire(rep.Time().Add(-c.failureHistoryRetention))
+
+	return h, nil
+}
+
+// reportFailureDomains records failures in the history and reports files potentially affected by them.
+func (c *commandSnapshotVerify) reportFailureDomains(ctx context.Context, rep repo.Repository, v *snapshotfs.Verifier, history *snapshotfs.VerifyFailureHistory, roots map[string]snapshot.SourceInfo) error {
+	now := rep.Time()
+	affected := v.AffectedFiles()
+
+	for _, id := range v.FailedBlobs() {
+		history.Blobs[id] = now
+	}
+
+	var failedFiles int
+
+	for _, af := range affected {
+		if af.Error == "" {
+			continue
+		}
+
+		failedFiles++
+
+		for rootPath, src := range roots {
+			if af.Path == rootPath || strings.HasPrefix(af.Path, rootPath+"/") {
+				history.Sources[src.String()] = now
+			}
+		}
+	}
+
+	if len(affected) > 0 {
+		log(ctx).Warnf("%v file(s) failed verification, %v file(s) are stored in suspect blobs but were readable.", failedFiles, len(affected)-failedFiles)
+
+		for _, af := range affected {
+			if af.Error != "" {
+				log(ctx).Warnf("  affected: %v (blobs: %v)", af.Path, af.Blobs)
+			}
+		}
+	}
+
+	if c.blastRadiusReport != "" {
+		b, err := json.MarshalIndent(affected, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal report")
+		}
+
+		if err := os.WriteFile(c.blastRadiusReport, b, 0o600); err != nil { //nolint:gomnd
+			return errors.Wrap(err, "unable to write report")
+		}
+	}
+
+	if !c.failureHistory || c.isForensic() || len(history.Blobs)+len(history.Sources) == 0 {
+		return nil
+	}
+
+	return errors.Wrap(history.Save(c.historyFile()), "unable to save verification failure history")
 }
 
 func (c *commandSnapshotVerify) loadSourceManifests(ctx context.Context, rep repo.Repository, sources []string) ([]*snapshot.Manifest, error) {
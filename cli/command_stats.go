@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+type commandStats struct {
+	bySource bool
+	raw      bool
+
+	jo  jsonOutput
+	out textOutput
+}
+
+func (c *commandStats) setup(svc appServices, parent commandParent) {
+	cmd := parent.Command("stats", "Repository statistics computed from snapshots and indexes")
+	cmd.Flag("by-source", "Show how much data each snapshot source uniquely contributes versus shares with other sources").BoolVar(&c.bySource)
+	cmd.Flag("raw", "Raw numbers").Short('r').BoolVar(&c.raw)
+	c.jo.setup(svc, cmd)
+	c.out.setup(svc)
+	cmd.Action(svc.directRepositoryReadAction(c.run))
+}
+
+func (c *commandStats) run(ctx context.Context, rep repo.DirectRepository) error {
+	manifestIDs, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "error listing snapshots")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, manifestIDs)
+	if err != nil {
+		return errors.Wrap(err, "unable to load snapshots")
+	}
+
+	report, err := snapshotfs.CalculateSourceSharingStats(ctx, rep, manifests)
+	if err != nil {
+		return errors.Wrap(err, "error calculating statistics")
+	}
+
+	if !c.bySource {
+		report.Sources = nil
+	}
+
+	if c.jo.jsonOutput {
+		c.out.printStdout("%s\n", c.jo.jsonBytes(report))
+		return nil
+	}
+
+	sizeToString := units.BytesString
+	if c.raw {
+		sizeToString = func(l int64) string {
+			return strconv.FormatInt(l, 10)
+		}
+	}
+
+	c.out.printStdout("Snapshots: %v\n", len(manifests))
+	c.out.printStdout("Referenced by snapshots: %v contents (%v)\n", report.ReferencedContentCount, sizeToString(report.ReferencedContentBytes))
+	c.out.printStdout("Not referenced by snapshots: %v contents (%v)\n", report.UnreferencedContentCount, sizeToString(report.UnreferencedContentBytes))
+
+	if !c.bySource {
+		return nil
+	}
+
+	c.out.printStdout("By Source:\n")
+
+	for _, st := range report.Sources {
+		c.out.printStdout("  %v\n", st.Source)
+		c.out.printStdout("    snapshots: %v total: %v (%v contents)\n", st.SnapshotCount, sizeToString(st.ContentBytes), st.ContentCount)
+		c.out.printStdout("    unique:    %v (%v contents, %v)\n", sizeToString(st.UniqueContentBytes), st.UniqueContentCount, formatSharePercentage(st.UniqueContentBytes, report.ReferencedContentBytes))
+		c.out.printStdout("    shared:    %v (%v contents)\n", sizeToString(st.SharedContentBytes), st.SharedContentCount)
+	}
+
+	return nil
+}
+
+func formatSharePercentage(part, total int64) string {
+	if total == 0 {
+		return "0.0% of referenced"
+	}
+
+	return fmt.Sprintf("%.1f%% of referenced", 100*float64(part)/float64(total))
+}
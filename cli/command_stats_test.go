@@ -0,0 +1,52 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestStatsBySource(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	src1 := testutil.TempDirectory(t)
+	src2 := testutil.TempDirectory(t)
+
+	require.NoError(t, os.WriteFile(filepath.Join(src1, "shared"), []byte("shared-data"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src1, "file1"), []byte("data-1"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src2, "shared"), []byte("shared-data"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(src2, "file2"), []byte("data-22"), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", src1)
+	e.RunAndExpectSuccess(t, "snapshot", "create", src2)
+
+	var report snapshotfs.SourceSharingReport
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "stats", "--by-source", "--json"), &report)
+
+	require.Len(t, report.Sources, 2)
+
+	for _, st := range report.Sources {
+		require.Equal(t, 1, st.SnapshotCount)
+		require.Equal(t, int64(3), st.ContentCount)
+		require.Equal(t, int64(2), st.UniqueContentCount)
+		require.Equal(t, int64(1), st.SharedContentCount)
+	}
+
+	require.Equal(t, int64(5), report.ReferencedContentCount)
+
+	out := e.RunAndExpectSuccess(t, "stats", "--by-source")
+	require.Contains(t, out, "Snapshots: 2")
+	require.Contains(t, out, "By Source:")
+
+	require.NotContains(t, e.RunAndExpectSuccess(t, "stats"), "By Source:")
+}
@@ -49,6 +49,10 @@ func (c *App) onTerminate(f func()) {
 }
 
 func (c *App) openRepository(ctx context.Context, required bool) (repo.Repository, error) {
+	if c.sharedRepository != nil {
+		return withoutClose(c.sharedRepository), nil
+	}
+
 	if _, err := os.Stat(c.repositoryConfigFileName()); os.IsNotExist(err) {
 		if !required {
 			return nil, nil
@@ -78,6 +82,10 @@ func (c *App) optionsFromFlags(ctx context.Context) *repo.Options {
 		DisableInternalLog:  c.disableInternalLog,
 		UpgradeOwnerID:      c.upgradeOwnerID,
 		DoNotWaitForUpgrade: c.doNotWaitForUpgrade,
+		DataPassword:        c.dataPassword,
+		Forensic:            c.forensic,
+		LowMemory:           c.lowMemory,
+		TimeNowFunc:         c.timeNow,
 
 		// when a fatal error is encountered in the repository, run all registered callbacks
 		// and exit the program.
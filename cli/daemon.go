@@ -0,0 +1,573 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+)
+
+const (
+	daemonSocketSuffix      = ".sock"
+	daemonDialTimeout       = time.Second
+	daemonOutputChunkSize   = 32 << 10
+	daemonRequestRun        = "run"
+	daemonRequestStatus     = "status"
+	daemonRequestStop       = "stop"
+	daemonSocketPermissions = 0o600
+)
+
+// errDaemonPeerUnsupported is returned by daemonPeerUID on platforms where the user of the peer can't be determined.
+var errDaemonPeerUnsupported = errors.New("peer credentials are not supported")
+
+// daemonRequest is sent by the client as the first message on a daemon connection.
+type daemonRequest struct {
+	Command          string   `json:"command"`
+	ConfigFile       string   `json:"configFile"`
+	Args             []string `json:"args,omitempty"`
+	WorkingDirectory string   `json:"cwd,omitempty"`
+	Env              []string `json:"env,omitempty"`
+}
+
+// daemonInput is streamed by the client after the run request, it carries the standard input of the client.
+type daemonInput struct {
+	Stdin    []byte `json:"stdin,omitempty"`
+	StdinEOF bool   `json:"stdinEOF,omitempty"`
+}
+
+// daemonResponse is a message streamed back by the daemon, the last message has Done set.
+type daemonResponse struct {
+	Stdout []byte        `json:"stdout,omitempty"`
+	Stderr []byte        `json:"stderr,omitempty"`
+	Done   bool          `json:"done,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Status *daemonStatus `json:"status,omitempty"`
+}
+
+// daemonStatus describes the running daemon.
+type daemonStatus struct {
+	ConfigFile   string    `json:"configFile"`
+	PID          int       `json:"pid"`
+	StartTime    time.Time `json:"startTime"`
+	RequestCount int64     `json:"requestCount"`
+}
+
+// sharedRepository prevents commands executed by the daemon from closing the repository it holds.
+type sharedRepository struct {
+	repo.Repository
+}
+
+func (r sharedRepository) Close(ctx context.Context) error {
+	return nil
+}
+
+type sharedDirectRepository struct {
+	repo.DirectRepository
+}
+
+func (r sharedDirectRepository) Close(ctx context.Context) error {
+	return nil
+}
+
+func withoutClose(rep repo.Repository) repo.Repository {
+	if dr, ok := rep.(repo.DirectRepository); ok {
+		return sharedDirectRepository{dr}
+	}
+
+	return sharedRepository{rep}
+}
+
+// runLocally makes the command bypass the daemon when the provided condition is nil or returns true.
+// This is needed for commands that change the configuration of the client or read data from stdin.
+func (c *App) runLocally(cmd *kingpin.CmdClause, when func() bool) {
+	cmd.PreAction(func(*kingpin.ParseContext) error {
+		if when == nil || when() {
+			c.mustRunLocally = true
+		}
+
+		return nil
+	})
+}
+
+// absoluteConfigFileName returns the absolute path of the configuration file, which identifies the daemon.
+func (c *App) absoluteConfigFileName() string {
+	if p, err := filepath.Abs(c.repositoryConfigFileName()); err == nil {
+		return p
+	}
+
+	return c.repositoryConfigFileName()
+}
+
+func (c *App) daemonSocketPath() string {
+	if c.daemonSocket != "" {
+		return c.daemonSocket
+	}
+
+	return c.repositoryConfigFileName() + daemonSocketSuffix
+}
+
+// dialDaemon connects to the daemon serving the current configuration file.
+func (c *App) dialDaemon() (net.Conn, error) {
+	//nolint:wrapcheck
+	return net.DialTimeout("unix", c.daemonSocketPath(), daemonDialTimeout)
+}
+
+// sendDaemonRequest sends the request to the daemon and invokes the callback for each response until done.
+// When stdin is provided, it is forwarded to the daemon.
+func (c *App) sendDaemonRequest(conn net.Conn, req *daemonRequest, stdin io.Reader, callback func(resp *daemonResponse) error) error {
+	enc := json.NewEncoder(conn)
+
+	if err := enc.Encode(req); err != nil {
+		return errors.Wrap(err, "error sending daemon request")
+	}
+
+	if req.Command == daemonRequestRun {
+		go forwardDaemonInput(enc, stdin)
+	}
+
+	dec := json.NewDecoder(conn)
+
+	for {
+		var resp daemonResponse
+
+		if err := dec.Decode(&resp); err != nil {
+			return errors.Wrap(err, "error reading daemon response")
+		}
+
+		if err := callback(&resp); err != nil {
+			return err
+		}
+
+		if resp.Done {
+			if resp.Error != "" {
+				return errors.New(resp.Error)
+			}
+
+			return nil
+		}
+	}
+}
+
+// forwardDaemonInput sends the contents of stdin to the daemon followed by the end-of-file marker.
+// It may remain blocked reading stdin after the command completes, which is harmless since the client exits.
+func forwardDaemonInput(enc *json.Encoder, stdin io.Reader) {
+	if stdin != nil {
+		buf := make([]byte, daemonOutputChunkSize)
+
+		for {
+			n, err := stdin.Read(buf)
+			if n > 0 {
+				if enc.Encode(&daemonInput{Stdin: buf[0:n]}) != nil {
+					return
+				}
+			}
+
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	enc.Encode(&daemonInput{StdinEOF: true}) //nolint:errcheck
+}
+
+// maybeRunUsingDaemon forwards the current command to the daemon if requested and the daemon is running.
+// Returns false if the command must be executed locally.
+func (c *App) maybeRunUsingDaemon(ctx context.Context) (bool, error) {
+	if !c.useDaemon || c.mustRunLocally || c.sharedRepository != nil || len(c.cliArgs) == 0 {
+		return false, nil
+	}
+
+	conn, err := c.dialDaemon()
+	if err != nil {
+		log(ctx).Debugf("daemon not available, running locally: %v", err)
+		return false, nil
+	}
+
+	defer conn.Close() //nolint:errcheck
+
+	// close the connection on Ctrl-C, which interrupts the command in the daemon.
+	c.onTerminate(func() {
+		conn.Close() //nolint:errcheck,gosec
+	})
+
+	cwd, _ := os.Getwd()
+
+	req := &daemonRequest{
+		Command:          daemonRequestRun,
+		ConfigFile:       c.absoluteConfigFileName(),
+		Args:             c.cliArgs,
+		WorkingDirectory: cwd,
+		Env:              os.Environ(),
+	}
+
+	var started bool
+
+	err = c.sendDaemonRequest(conn, req, c.stdin(), func(resp *daemonResponse) error {
+		started = true
+
+		if _, err := c.stdout().Write(resp.Stdout); err != nil {
+			return errors.Wrap(err, "error writing output")
+		}
+
+		if _, err := c.Stderr().Write(resp.Stderr); err != nil {
+			return errors.Wrap(err, "error writing output")
+		}
+
+		return nil
+	})
+
+	if !started {
+		log(ctx).Debugf("daemon did not accept the command, running locally: %v", err)
+		return false, nil
+	}
+
+	return true, err
+}
+
+// daemon holds the repository shared by commands executed on behalf of clients.
+type daemon struct {
+	app        *App
+	configFile string
+	listener   net.Listener
+	startTime  time.Time
+
+	// commands are executed one at a time since they run in the working directory
+	// and with the environment of the client.
+	runMutex sync.Mutex
+
+	// +checklocks:runMutex
+	rep repo.Repository
+	// +checklocks:runMutex
+	configModTime time.Time
+
+	mu sync.Mutex
+	// +checklocks:mu
+	requestCount int64
+}
+
+func (d *daemon) serve(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+
+	for {
+		conn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer conn.Close() //nolint:errcheck
+
+			if err := d.handleConnection(ctx, conn); err != nil {
+				log(ctx).Debugf("error handling daemon connection: %v", err)
+			}
+		}()
+	}
+}
+
+func (d *daemon) handleConnection(ctx context.Context, conn net.Conn) error {
+	// the socket is only accessible to the current user, verify the peer where possible in case permissions were changed.
+	if err := checkDaemonPeer(conn); err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	var req daemonRequest
+
+	if err := dec.Decode(&req); err != nil {
+		return errors.Wrap(err, "invalid request")
+	}
+
+	if req.ConfigFile != d.configFile {
+		// close without responding, the client will run the command locally.
+		return errors.Errorf("configuration file mismatch: %v", req.ConfigFile)
+	}
+
+	switch req.Command {
+	case daemonRequestStatus:
+		d.mu.Lock()
+		st := &daemonStatus{
+			ConfigFile:   d.configFile,
+			PID:          os.Getpid(),
+			StartTime:    d.startTime,
+			RequestCount: d.requestCount,
+		}
+		d.mu.Unlock()
+
+		return errors.Wrap(enc.Encode(&daemonResponse{Done: true, Status: st}), "error sending response")
+
+	case daemonRequestStop:
+		d.listener.Close() //nolint:errcheck
+
+		return errors.Wrap(enc.Encode(&daemonResponse{Done: true}), "error sending response")
+
+	case daemonRequestRun:
+		// stdin of the command is fed from input messages, which also detect when the client goes away.
+		stdinReader, stdinWriter := io.Pipe()
+		disconnected := make(chan struct{})
+
+		go func() {
+			defer close(disconnected)
+
+			readDaemonInput(dec, stdinWriter)
+		}()
+
+		defer stdinReader.Close() //nolint:errcheck
+
+		return d.run(ctx, &req, stdinReader, enc, disconnected)
+
+	default:
+		return errors.Wrap(enc.Encode(&daemonResponse{Done: true, Error: "unsupported command: " + req.Command}), "error sending response")
+	}
+}
+
+// readDaemonInput writes stdin received from the client to the provided pipe until the client disconnects.
+// If the command does not read its stdin, the input is blocked until the command completes.
+func readDaemonInput(dec *json.Decoder, stdin *io.PipeWriter) {
+	for {
+		var in daemonInput
+
+		if err := dec.Decode(&in); err != nil {
+			stdin.CloseWithError(io.ErrUnexpectedEOF) //nolint:errcheck
+
+			return
+		}
+
+		if len(in.Stdin) > 0 {
+			stdin.Write(in.Stdin) //nolint:errcheck
+		}
+
+		if in.StdinEOF {
+			stdin.Close() //nolint:errcheck
+		}
+	}
+}
+
+func (d *daemon) run(ctx context.Context, req *daemonRequest, stdin io.Reader, enc *json.Encoder, disconnected <-chan struct{}) error {
+	d.runMutex.Lock()
+	defer d.runMutex.Unlock()
+
+	d.mu.Lock()
+	d.requestCount++
+	d.mu.Unlock()
+
+	var err error
+
+	if err = d.reopenIfConfigChanged(ctx); err == nil {
+		err = withClientProcessState(req, func() error {
+			return d.runCommand(ctx, req, stdin, enc, disconnected)
+		})
+	}
+
+	if err != nil {
+		return errors.Wrap(enc.Encode(&daemonResponse{Done: true, Error: err.Error()}), "error sending response")
+	}
+
+	return nil
+}
+
+// reopenIfConfigChanged reopens the repository when the configuration file has been modified since it was opened,
+// for example by commands which were executed locally.
+//
+// +checklocks:d.runMutex
+func (d *daemon) reopenIfConfigChanged(ctx context.Context) error {
+	st, err := os.Stat(d.configFile)
+	if err != nil {
+		return errors.Wrap(err, "unable to stat configuration file")
+	}
+
+	if st.ModTime().Equal(d.configModTime) {
+		if err := d.rep.Refresh(ctx); err != nil {
+			log(ctx).Warnf("unable to refresh repository: %v", err)
+		}
+
+		return nil
+	}
+
+	log(ctx).Infof("Configuration file changed, reopening repository.")
+
+	rep, err := d.app.openRepository(ctx, true)
+	if err != nil {
+		return errors.Wrap(err, "unable to reopen repository")
+	}
+
+	if err := d.rep.Close(ctx); err != nil {
+		log(ctx).Warnf("unable to close repository: %v", err)
+	}
+
+	d.rep = rep
+	d.configModTime = st.ModTime()
+
+	return nil
+}
+
+// withClientProcessState invokes the callback in the working directory and with the environment of the client,
+// so that relative paths, credentials and flags provided through environment variables are honored.
+// Both are process-wide, so they are restored before the next command is executed.
+func withClientProcessState(req *daemonRequest, cb func() error) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return errors.Wrap(err, "unable to get working directory")
+	}
+
+	if req.WorkingDirectory != "" {
+		if err := os.Chdir(req.WorkingDirectory); err != nil {
+			return errors.Wrap(err, "unable to change working directory")
+		}
+
+		defer os.Chdir(cwd) //nolint:errcheck
+	}
+
+	if req.Env != nil {
+		env := os.Environ()
+
+		setEnvironment(req.Env)
+		defer setEnvironment(env)
+	}
+
+	return cb()
+}
+
+func setEnvironment(env []string) {
+	os.Clearenv()
+
+	for _, kv := range env {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			os.Setenv(k, v) //nolint:errcheck
+		}
+	}
+}
+
+// checkDaemonPeer verifies that the peer connected to the daemon runs as the same user as the daemon.
+func checkDaemonPeer(conn net.Conn) error {
+	uid, err := daemonPeerUID(conn)
+	if errors.Is(err, errDaemonPeerUnsupported) {
+		// rely on permissions of the socket.
+		return nil
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "unable to determine user of the peer")
+	}
+
+	if uid != os.Getuid() {
+		return errors.Errorf("connection from user %v is not allowed", uid)
+	}
+
+	return nil
+}
+
+// runCommand executes the command and streams its output to the client.
+//
+// +checklocks:d.runMutex
+func (d *daemon) runCommand(ctx context.Context, req *daemonRequest, stdin io.Reader, enc *json.Encoder, disconnected <-chan struct{}) error {
+	a := d.app.newDaemonChildApp(d.rep)
+
+	kp := kingpin.New("kopia", "Kopia - Fast And Secure Open-Source Backup")
+	kp.Terminate(func(int) {})
+	kp.ErrorWriter(io.Discard)
+	kp.UsageWriter(io.Discard)
+
+	stdout, stderr, wait, interrupt := a.runInProcess(ctx, kp, stdin, req.Args)
+
+	var (
+		sendMutex sync.Mutex
+		sendErr   error
+		wg        sync.WaitGroup
+	)
+
+	send := func(resp *daemonResponse) {
+		sendMutex.Lock()
+		defer sendMutex.Unlock()
+
+		if sendErr == nil {
+			sendErr = enc.Encode(resp)
+		}
+	}
+
+	forward := func(r io.Reader, isStderr bool) {
+		defer wg.Done()
+
+		buf := make([]byte, daemonOutputChunkSize)
+
+		for {
+			n, err := r.Read(buf)
+			if n > 0 {
+				chunk := append([]byte(nil), buf[0:n]...)
+
+				if isStderr {
+					send(&daemonResponse{Stderr: chunk})
+				} else {
+					send(&daemonResponse{Stdout: chunk})
+				}
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}
+
+	wg.Add(2) //nolint:gomnd
+
+	go forward(stdout, false)
+	go forward(stderr, true)
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-disconnected:
+			interrupt(os.Interrupt)
+		case <-done:
+		}
+	}()
+
+	wg.Wait()
+
+	resp := &daemonResponse{Done: true}
+
+	if err := wait(); err != nil {
+		resp.Error = err.Error()
+	}
+
+	send(resp)
+
+	if sendErr != nil {
+		// the client went away, there's no one to report the error to.
+		log(ctx).Debugf("error sending response: %v", sendErr)
+	}
+
+	return nil
+}
+
+// newDaemonChildApp returns a new App that executes a single command on behalf of a daemon client
+// using the repository held by the daemon.
+func (c *App) newDaemonChildApp(rep repo.Repository) *App {
+	a := NewApp()
+	a.cliStorageProviders = c.cliStorageProviders
+	a.envNamePrefix = c.envNamePrefix
+	a.AdvancedCommands = c.AdvancedCommands
+	a.isInProcessTest = c.isInProcessTest
+	a.sharedRepository = rep
+
+	return a
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// daemonPeerUID returns the user ID of the process connected to the daemon.
+func daemonPeerUID(conn net.Conn) (int, error) {
+	uc, ok := conn.(*net.UnixConn)
+	if !ok {
+		return 0, errDaemonPeerUnsupported
+	}
+
+	raw, err := uc.SyscallConn()
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to get raw connection")
+	}
+
+	var (
+		cred    *unix.Ucred
+		credErr error
+	)
+
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return 0, errors.Wrap(err, "unable to access connection")
+	}
+
+	if credErr != nil {
+		return 0, errors.Wrap(credErr, "unable to get peer credentials")
+	}
+
+	return int(cred.Uid), nil
+}
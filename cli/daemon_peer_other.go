@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package cli
+
+import (
+	"net"
+)
+
+//nolint:revive
+func daemonPeerUID(conn net.Conn) (int, error) {
+	return 0, errDaemonPeerUnsupported
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package cli
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// daemonSocketUmask makes the daemon socket inaccessible to other users from the moment it is created.
+// The umask is process-wide, so it must not prevent the owner from accessing files created concurrently.
+const daemonSocketUmask = 0o077
+
+// listenDaemonSocket listens on the unix socket, which only the current user can access.
+func listenDaemonSocket(socketPath string) (net.Listener, error) {
+	old := syscall.Umask(daemonSocketUmask)
+	l, err := net.Listen("unix", socketPath)
+	syscall.Umask(old)
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to listen")
+	}
+
+	if err := os.Chmod(socketPath, daemonSocketPermissions); err != nil {
+		l.Close() //nolint:errcheck
+
+		return nil, errors.Wrap(err, "unable to set permissions on daemon socket")
+	}
+
+	return l, nil
+}
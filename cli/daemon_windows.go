@@ -0,0 +1,11 @@
+package cli
+
+import (
+	"net"
+)
+
+// listenDaemonSocket listens on the unix socket, which inherits access control of its parent directory.
+func listenDaemonSocket(socketPath string) (net.Listener, error) {
+	//nolint:wrapcheck
+	return net.Listen("unix", socketPath)
+}
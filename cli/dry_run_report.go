@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+// DryRunItem describes a single object affected by a destructive operation.
+type DryRunItem struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Bytes       int64  `json:"bytes"`
+	Description string `json:"description,omitempty"`
+}
+
+// DryRunReport describes the impact of a destructive operation invoked with --dry-run,
+// the JSON output of such commands.
+type DryRunReport struct {
+	Operation    string        `json:"operation"`
+	Snapshots    int           `json:"snapshots"`
+	Contents     int           `json:"contents"`
+	ContentBytes int64         `json:"contentBytes"`
+	Blobs        int           `json:"blobs"`
+	BlobBytes    int64         `json:"blobBytes"`
+	Items        []*DryRunItem `json:"items"`
+}
+
+func (r *DryRunReport) add(it *DryRunItem) {
+	r.Items = append(r.Items, it)
+}
+
+// addMaintenanceTask adds the impact of a maintenance task of the repository or its shard to the report.
+func (r *DryRunReport) addMaintenanceTask(shard string, t *maintenance.DryRunTask) {
+	id := string(t.Task)
+	if shard != "" {
+		id = shard + "/" + id
+	}
+
+	it := &DryRunItem{Type: "task", ID: id, Bytes: t.BlobBytes, Description: t.Skipped}
+	if t.Contents > 0 {
+		it.Bytes = t.ContentBytes
+	}
+
+	if t.Skipped == "" {
+		it.Description = fmt.Sprintf("%v contents (%v), %v blobs (%v)",
+			units.Count(int64(t.Contents)), units.BytesString(t.ContentBytes),
+			units.Count(int64(t.Blobs)), units.BytesString(t.BlobBytes))
+	}
+
+	r.Contents += t.Contents
+	r.ContentBytes += t.ContentBytes
+	r.Blobs += t.Blobs
+	r.BlobBytes += t.BlobBytes
+	r.add(it)
+}
+
+// dryRunFlags implements --dry-run flag, the report is printed using output of the command.
+type dryRunFlags struct {
+	dryRun  bool
+	verbose bool
+}
+
+func (c *dryRunFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("dry-run", "Do not change anything, only print a report of what would be affected").Short('n').BoolVar(&c.dryRun)
+	cmd.Flag("dry-run-verbose", "Include individual affected items in the dry-run report").BoolVar(&c.verbose)
+}
+
+// output prints the report either as JSON or in human-readable form.
+func (c *dryRunFlags) output(jo *jsonOutput, out *textOutput, r *DryRunReport) {
+	if r.Items == nil {
+		r.Items = []*DryRunItem{}
+	}
+
+	if jo.jsonOutput {
+		out.printStdout("%s\n", jo.jsonBytes(r))
+		return
+	}
+
+	out.printStdout("Dry run of %v, nothing was changed.\n", r.Operation)
+
+	if r.Snapshots > 0 {
+		out.printStdout("Snapshots affected: %v\n", units.Count(int64(r.Snapshots)))
+	}
+
+	out.printStdout("Contents affected:  %v (%v)\n", units.Count(int64(r.Contents)), units.BytesString(r.ContentBytes))
+	out.printStdout("Blobs affected:     %v (%v)\n", units.Count(int64(r.Blobs)), units.BytesString(r.BlobBytes))
+
+	if !c.verbose {
+		return
+	}
+
+	for _, it := range r.Items {
+		out.printStdout("  %v %v %v %v\n", it.Type, it.ID, units.BytesString(it.Bytes), it.Description)
+	}
+}
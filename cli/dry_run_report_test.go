@@ -0,0 +1,168 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/cli"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestDryRunReport(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file1"), []byte{1, 2, 3}, 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+	e.RunAndExpectSuccess(t, "policy", "set", srcdir, "--keep-latest=1", "--keep-hourly=0", "--keep-daily=0", "--keep-weekly=0", "--keep-monthly=0", "--keep-annual=0")
+
+	// snapshot expire
+	var r cli.DryRunReport
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "expire", srcdir, "--dry-run", "--json"), &r)
+	require.Equal(t, "snapshot expiration", r.Operation)
+	require.Equal(t, 1, r.Snapshots)
+	require.Len(t, r.Items, 1)
+	require.Equal(t, "snapshot", r.Items[0].Type)
+	require.Equal(t, int64(3), r.Items[0].Bytes)
+
+	require.Len(t, e.RunAndExpectSuccess(t, "snapshot", "list", srcdir, "--no-header"), 2)
+	e.RunAndExpectFailure(t, "snapshot", "expire", srcdir, "--dry-run", "--delete")
+
+	// index optimize, index blobs are compacted by the epoch manager so none are reported.
+	r = cli.DryRunReport{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "index", "optimize", "--dry-run", "--json"), &r)
+	require.Equal(t, "index compaction", r.Operation)
+	require.Zero(t, r.Blobs)
+	require.Zero(t, r.Contents)
+
+	// content rewrite
+	r = cli.DryRunReport{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "content", "rewrite", "--short", "--safety=none", "--dry-run", "--json"), &r)
+	require.Equal(t, "content rewrite", r.Operation)
+	require.NotZero(t, r.Contents)
+	require.NotZero(t, r.Blobs)
+	require.Len(t, r.Items, r.Contents)
+
+	// packs which keep other contents are not reported.
+	var contents []content.Info
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "content", "ls", "--json"), &contents)
+
+	contentsPerPack := map[blob.ID]int{}
+	for _, ci := range contents {
+		contentsPerPack[ci.PackBlobID]++
+	}
+
+	rewroteShared := false
+
+	for _, ci := range contents {
+		if contentsPerPack[ci.PackBlobID] < 2 || rewroteShared {
+			continue
+		}
+
+		r = cli.DryRunReport{}
+		testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "content", "rewrite", ci.ContentID.String(), "--safety=none", "--dry-run", "--json"), &r)
+		require.Equal(t, 1, r.Contents)
+		require.Zero(t, r.Blobs)
+
+		rewroteShared = true
+	}
+
+	require.True(t, rewroteShared, "no pack with multiple contents")
+
+	// blob gc
+	r = cli.DryRunReport{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "blob", "gc", "--dry-run", "--json"), &r)
+	require.Equal(t, "blob garbage collection", r.Operation)
+	require.NotNil(t, r.Items)
+	e.RunAndExpectFailure(t, "blob", "gc", "--dry-run", "--delete=yes")
+
+	// snapshot gc
+	r = cli.DryRunReport{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "gc", "--dry-run", "--json"), &r)
+	require.Equal(t, "snapshot garbage collection", r.Operation)
+	e.RunAndExpectFailure(t, "snapshot", "gc", "--dry-run", "--delete")
+
+	// maintenance run
+	staleFile := filepath.Join(e.RepoDir, "p1234.f.tmp.0123abcd")
+	require.NoError(t, os.WriteFile(staleFile, []byte{1, 2, 3}, 0o600))
+
+	old := clock.Now().Add(-2 * maintenance.MinTemporaryFileDeleteAge)
+	require.NoError(t, os.Chtimes(staleFile, old, old))
+
+	blobs := e.RunAndExpectSuccess(t, "blob", "ls")
+
+	r = cli.DryRunReport{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none", "--dry-run", "--json"), &r)
+	require.Equal(t, "maintenance", r.Operation)
+
+	tasks := map[string]*cli.DryRunItem{}
+	for _, it := range r.Items {
+		require.Equal(t, "task", it.Type)
+		tasks[it.ID] = it
+	}
+
+	require.Contains(t, tasks, maintenance.TaskSnapshotGarbageCollection)
+	require.Contains(t, tasks, maintenance.TaskRewriteContentsFull)
+	require.Equal(t, int64(3), tasks[maintenance.TaskDeleteTemporaryFiles].Bytes)
+	require.FileExists(t, staleFile)
+	require.Equal(t, blobs, e.RunAndExpectSuccess(t, "blob", "ls"))
+
+	// human-readable output
+	lines := e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--dry-run", "--dry-run-verbose")
+	require.Equal(t, "Dry run of maintenance, nothing was changed.", lines[0])
+	require.Len(t, lines, 3+len(tasks))
+}
+
+func TestIndexOptimizeDryRun(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir, "--format-version=1")
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file1"), []byte{1, 2, 3}, 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file2"), []byte{4, 5, 6}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	indexBlobs := e.RunAndExpectSuccess(t, "index", "ls")
+	require.Greater(t, len(indexBlobs), 1)
+
+	var r cli.DryRunReport
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "index", "optimize", "--dry-run", "--json"), &r)
+	require.Equal(t, len(indexBlobs), r.Blobs)
+	require.Len(t, r.Items, len(indexBlobs))
+	require.Equal(t, indexBlobs, e.RunAndExpectSuccess(t, "index", "ls"))
+
+	// only index blobs which would be compacted are reported.
+	r = cli.DryRunReport{}
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "index", "optimize", "--dry-run", "--json", "--max-small-blobs=1000"), &r)
+	require.Zero(t, r.Blobs)
+	require.Empty(t, r.Items)
+
+	e.RunAndExpectSuccess(t, "index", "optimize", "--max-small-blobs=1000")
+	require.Equal(t, indexBlobs, e.RunAndExpectSuccess(t, "index", "ls"))
+}
@@ -14,6 +14,13 @@
 // RunSubcommand executes the subcommand asynchronously in current process
 // with flags in an isolated CLI environment and returns standard output and standard error.
 func (c *App) RunSubcommand(ctx context.Context, kpapp *kingpin.Application, stdin io.Reader, argsAndFlags []string) (stdout, stderr io.Reader, wait func() error, interrupt func(os.Signal)) {
+	c.isInProcessTest = true
+
+	return c.runInProcess(ctx, kpapp, stdin, argsAndFlags)
+}
+
+// runInProcess executes the subcommand asynchronously in current process and returns standard output and standard error.
+func (c *App) runInProcess(ctx context.Context, kpapp *kingpin.Application, stdin io.Reader, argsAndFlags []string) (stdout, stderr io.Reader, wait func() error, interrupt func(os.Signal)) {
 	stdoutReader, stdoutWriter := io.Pipe()
 	stderrReader, stderrWriter := io.Pipe()
 
@@ -22,7 +29,6 @@ func (c *App) RunSubcommand(ctx context.Context, kpapp *kingpin.Application, std
 	c.stderrWriter = stderrWriter
 	c.rootctx = logging.WithLogger(ctx, logging.ToWriter(stderrWriter))
 	c.simulatedCtrlC = make(chan bool, 1)
-	c.isInProcessTest = true
 
 	releasable.Created("simulated-ctrl-c", c.simulatedCtrlC)
 
@@ -46,7 +52,13 @@ func (c *App) RunSubcommand(ctx context.Context, kpapp *kingpin.Application, std
 		defer stderrWriter.Close() //nolint:errcheck
 		defer stdoutWriter.Close() //nolint:errcheck
 
-		_, err := kpapp.Parse(argsAndFlags)
+		args, err := c.ExpandAliases(kpapp, argsAndFlags)
+		if err != nil {
+			resultErr <- err
+			return
+		}
+
+		_, err = kpapp.Parse(args)
 		if err != nil {
 			resultErr <- err
 			return
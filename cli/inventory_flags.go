@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/inventory"
+	"github.com/kopia/kopia/repo/content"
+)
+
+type blobInventoryFlags struct {
+	inventoryFile string
+	keyPrefix     string
+}
+
+func (c *blobInventoryFlags) setup(cmd *kingpin.CmdClause) {
+	cmd.Flag("inventory", "Use bucket inventory report in CSV format (S3 Inventory manifest.json or CSV listing) instead of listing blobs").ExistingFileVar(&c.inventoryFile)
+	cmd.Flag("inventory-key-prefix", "Prefix of object keys in the inventory under which the repository is stored").StringVar(&c.keyPrefix)
+}
+
+// load returns the inventory or nil if not requested.
+func (c *blobInventoryFlags) load(ctx context.Context) (*inventory.Inventory, error) {
+	if c.inventoryFile == "" {
+		return nil, nil
+	}
+
+	log(ctx).Infof("Loading inventory from %v...", c.inventoryFile)
+
+	inv, err := inventory.Load(ctx, c.inventoryFile, inventory.Options{KeyPrefix: c.keyPrefix})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load inventory")
+	}
+
+	log(ctx).Infof("Loaded %v blobs from inventory.", inv.Len())
+
+	return inv, nil
+}
+
+// readBlobMap returns the metadata of all blobs in the repository, using the inventory when provided.
+// Since the inventory may be older than the repository index, pack blobs referenced by the index
+// but missing from the inventory are looked up individually.
+func (c *blobInventoryFlags) readBlobMap(ctx context.Context, rep repo.DirectRepository) (map[blob.ID]blob.Metadata, error) {
+	inv, err := c.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv == nil {
+		return readBlobMap(ctx, rep)
+	}
+
+	blobMap := map[blob.ID]blob.Metadata{}
+
+	if base := rep.BaseRepository(); base != nil {
+		if blobMap, err = readBlobMap(ctx, base); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := inv.ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		blobMap[bm.BlobID] = bm
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error listing inventory")
+	}
+
+	var checked, found int
+
+	if err := rep.ContentReader().IteratePacks(ctx, content.IteratePackOptions{}, func(pi content.PackInfo) error {
+		if _, ok := blobMap[pi.PackID]; ok {
+			return nil
+		}
+
+		checked++
+
+		bm, err := rep.BlobReader().GetMetadata(ctx, pi.PackID)
+		if errors.Is(err, blob.ErrBlobNotFound) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrapf(err, "error getting metadata of %v", pi.PackID)
+		}
+
+		found++
+		blobMap[pi.PackID] = bm
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error reconciling inventory")
+	}
+
+	log(ctx).Infof("Checked %v pack blobs missing from the inventory, %v of them exist.", checked, found)
+
+	return blobMap, nil
+}
@@ -0,0 +1,62 @@
+package cli_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestVerifyAndGCWithInventory(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+	env.RunAndExpectSuccess(t, "snapshot", "create", testutil.TempDirectory(t))
+	env.RunAndExpectSuccess(t, "snapshot", "create", ".")
+
+	var blobs []blob.Metadata
+
+	testutil.MustParseJSONLines(t, env.RunAndExpectSuccess(t, "blob", "list", "--json"), &blobs)
+
+	var sb strings.Builder
+
+	sb.WriteString("bucket,name,size,updated\n")
+
+	skippedPack := false
+
+	for _, bm := range blobs {
+		// simulate pack blob written after the inventory was produced.
+		if !skippedPack && strings.HasPrefix(string(bm.BlobID), "p") {
+			skippedPack = true
+			continue
+		}
+
+		fmt.Fprintf(&sb, "b,%v,%v,%v\n", bm.BlobID, bm.Length, bm.Timestamp.UTC().Format(time.RFC3339Nano))
+	}
+
+	require.True(t, skippedPack)
+
+	// blob which no longer exists in the storage.
+	fmt.Fprintf(&sb, "b,pdeadbeef00000000000000000000000,100,2020-01-01T00:00:00Z\n")
+
+	inventoryFile := filepath.Join(t.TempDir(), "inventory.csv")
+	require.NoError(t, os.WriteFile(inventoryFile, []byte(sb.String()), 0o600))
+
+	env.RunAndExpectSuccess(t, "content", "verify", "--inventory", inventoryFile)
+	env.RunAndExpectSuccess(t, "snapshot", "verify", "--inventory", inventoryFile)
+
+	_, stderr := env.RunAndExpectSuccessWithErrOut(t, "blob", "gc", "--inventory", inventoryFile, "--delete=yes", "--safety=none")
+	mustGetLineContaining(t, stderr, "Deleted total 1 unreferenced blobs")
+
+	env.RunAndExpectSuccess(t, "snapshot", "verify")
+}
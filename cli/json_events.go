@@ -0,0 +1,302 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// Types of events emitted by long-running commands.
+const (
+	jsonEventStart    = "start"
+	jsonEventProgress = "progress"
+	jsonEventResult   = "result"
+	jsonEventError    = "error"
+	jsonEventFinish   = "finish"
+)
+
+// jsonEvent is a single line of newline-delimited JSON event output.
+type jsonEvent struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Type    string    `json:"type"`
+	Data    any       `json:"data,omitempty"`
+}
+
+type jsonEventFinishData struct {
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// jsonEvents streams progress and result events of long-running commands as newline-delimited JSON,
+// so that they can be tracked by orchestration systems in real time.
+type jsonEvents struct {
+	enabled  bool
+	file     string
+	interval time.Duration
+
+	command string
+	stdout  func() io.Writer
+
+	mu sync.Mutex
+	// +checklocks:mu
+	out io.Writer
+	// +checklocks:mu
+	closer io.Closer
+
+	stopProgress chan struct{} // +checklocksignore
+	progressDone chan struct{} // +checklocksignore
+}
+
+func (e *jsonEvents) setup(svc appServices, cmd *kingpin.CmdClause) {
+	cmd.Flag("json-events", "Stream progress and result events as newline-delimited JSON to stdout").BoolVar(&e.enabled)
+	cmd.Flag("json-events-file", "Stream progress and result events as newline-delimited JSON to the provided file").StringVar(&e.file)
+	cmd.Flag("json-events-interval", "How often to emit progress events").Hidden().Default("1s").DurationVar(&e.interval)
+
+	e.command = cmd.FullCommand()
+	e.stdout = svc.stdout
+}
+
+// begin opens the event output and emits the start event.
+func (e *jsonEvents) begin() error {
+	e.mu.Lock()
+
+	switch {
+	case e.file != "":
+		f, err := os.Create(e.file) //nolint:gosec
+		if err != nil {
+			e.mu.Unlock()
+			return errors.Wrap(err, "unable to create JSON events file")
+		}
+
+		e.out = f
+		e.closer = f
+
+	case e.enabled:
+		e.out = e.stdout()
+	}
+
+	e.mu.Unlock()
+
+	e.emit(jsonEventStart, nil)
+
+	return nil
+}
+
+// finish stops progress reporting, emits the finish event describing the outcome and closes the output.
+func (e *jsonEvents) finish(err error) {
+	e.stopProgressEvents()
+
+	d := jsonEventFinishData{Success: err == nil}
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	e.emit(jsonEventFinish, d)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closer != nil {
+		e.closer.Close() //nolint:errcheck
+		e.closer = nil
+	}
+
+	e.out = nil
+}
+
+// emit writes a single event, it's a no-op when events are not enabled.
+func (e *jsonEvents) emit(eventType string, data any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.out == nil {
+		return
+	}
+
+	b, err := json.Marshal(jsonEvent{
+		Time:    clock.Now().UTC(),
+		Command: e.command,
+		Type:    eventType,
+		Data:    data,
+	})
+	if err != nil {
+		panic("error serializing JSON, that should not happen: " + err.Error())
+	}
+
+	e.out.Write(append(b, '\n')) //nolint:errcheck
+}
+
+// emitError emits an error event which does not terminate the command.
+func (e *jsonEvents) emitError(path string, err error) {
+	e.emit(jsonEventError, struct {
+		Path  string `json:"path,omitempty"`
+		Error string `json:"error"`
+	}{path, err.Error()})
+}
+
+// usesStdout returns true if events are streamed to stdout, in which case commands should not
+// print other output there.
+func (e *jsonEvents) usesStdout() bool {
+	return e.enabled && e.file == ""
+}
+
+func (e *jsonEvents) isEnabled() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.out != nil
+}
+
+// startProgressEvents periodically emits progress events with data returned by the provided function.
+func (e *jsonEvents) startProgressEvents(progress func() any) {
+	if !e.isEnabled() || e.interval <= 0 || e.stopProgress != nil {
+		return
+	}
+
+	e.stopProgress = make(chan struct{})
+	e.progressDone = make(chan struct{})
+
+	go func(stop, done chan struct{}) {
+		defer close(done)
+
+		t := time.NewTicker(e.interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+
+			case <-t.C:
+				e.emit(jsonEventProgress, progress())
+			}
+		}
+	}(e.stopProgress, e.progressDone)
+}
+
+func (e *jsonEvents) stopProgressEvents() {
+	if e.stopProgress != nil {
+		close(e.stopProgress)
+		<-e.progressDone
+
+		e.stopProgress = nil
+		e.progressDone = nil
+	}
+}
+
+// jsonEventsUploadProgress forwards upload progress to the wrapped progress while keeping its own counters,
+// which are periodically emitted as progress events.
+type jsonEventsUploadProgress struct {
+	snapshotfs.UploadProgress
+
+	events *jsonEvents
+
+	hashedBytes         atomic.Int64
+	cachedBytes         atomic.Int64
+	uploadedBytes       atomic.Int64
+	estimatedTotalBytes atomic.Int64
+	estimatedFileCount  atomic.Int32
+	hashingFiles        atomic.Int32
+	hashedFiles         atomic.Int32
+	cachedFiles         atomic.Int32
+	ignoredErrors       atomic.Int32
+	fatalErrors         atomic.Int32
+}
+
+type uploadProgressCounters struct {
+	HashingFiles        int32 `json:"hashingFiles"`
+	HashedFiles         int32 `json:"hashedFiles"`
+	HashedBytes         int64 `json:"hashedBytes"`
+	CachedFiles         int32 `json:"cachedFiles"`
+	CachedBytes         int64 `json:"cachedBytes"`
+	UploadedBytes       int64 `json:"uploadedBytes"`
+	IgnoredErrors       int32 `json:"ignoredErrors"`
+	FatalErrors         int32 `json:"fatalErrors"`
+	EstimatedFileCount  int32 `json:"estimatedFileCount,omitempty"`
+	EstimatedTotalBytes int64 `json:"estimatedTotalBytes,omitempty"`
+}
+
+func (p *jsonEventsUploadProgress) counters() any {
+	return uploadProgressCounters{
+		HashingFiles:        p.hashingFiles.Load(),
+		HashedFiles:         p.hashedFiles.Load(),
+		HashedBytes:         p.hashedBytes.Load(),
+		CachedFiles:         p.cachedFiles.Load(),
+		CachedBytes:         p.cachedBytes.Load(),
+		UploadedBytes:       p.uploadedBytes.Load(),
+		IgnoredErrors:       p.ignoredErrors.Load(),
+		FatalErrors:         p.fatalErrors.Load(),
+		EstimatedFileCount:  p.estimatedFileCount.Load(),
+		EstimatedTotalBytes: p.estimatedTotalBytes.Load(),
+	}
+}
+
+func (p *jsonEventsUploadProgress) UploadStarted() {
+	p.hashedBytes.Store(0)
+	p.cachedBytes.Store(0)
+	p.uploadedBytes.Store(0)
+	p.estimatedTotalBytes.Store(0)
+	p.estimatedFileCount.Store(0)
+	p.hashingFiles.Store(0)
+	p.hashedFiles.Store(0)
+	p.cachedFiles.Store(0)
+	p.ignoredErrors.Store(0)
+	p.fatalErrors.Store(0)
+
+	p.UploadProgress.UploadStarted()
+}
+
+func (p *jsonEventsUploadProgress) EstimatedDataSize(fileCount int, totalBytes int64) {
+	p.estimatedFileCount.Store(int32(fileCount)) //nolint:gosec
+	p.estimatedTotalBytes.Store(totalBytes)
+
+	p.UploadProgress.EstimatedDataSize(fileCount, totalBytes)
+}
+
+func (p *jsonEventsUploadProgress) HashingFile(fname string) {
+	p.hashingFiles.Add(1)
+	p.UploadProgress.HashingFile(fname)
+}
+
+func (p *jsonEventsUploadProgress) FinishedHashingFile(fname string, numBytes int64) {
+	p.hashingFiles.Add(-1)
+	p.hashedFiles.Add(1)
+	p.UploadProgress.FinishedHashingFile(fname, numBytes)
+}
+
+func (p *jsonEventsUploadProgress) HashedBytes(numBytes int64) {
+	p.hashedBytes.Add(numBytes)
+	p.UploadProgress.HashedBytes(numBytes)
+}
+
+func (p *jsonEventsUploadProgress) CachedFile(fname string, numBytes int64) {
+	p.cachedFiles.Add(1)
+	p.cachedBytes.Add(numBytes)
+	p.UploadProgress.CachedFile(fname, numBytes)
+}
+
+func (p *jsonEventsUploadProgress) Error(path string, err error, isIgnored bool) {
+	if isIgnored {
+		p.ignoredErrors.Add(1)
+	} else {
+		p.fatalErrors.Add(1)
+	}
+
+	p.events.emitError(path, err)
+	p.UploadProgress.Error(path, err, isIgnored)
+}
+
+// uploaded receives the number of bytes uploaded to the repository, which is reported outside of the upload progress.
+func (p *jsonEventsUploadProgress) uploaded(numBytes int64) {
+	p.uploadedBytes.Add(numBytes)
+}
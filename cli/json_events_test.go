@@ -0,0 +1,116 @@
+package cli_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+type testJSONEvent struct {
+	Command string          `json:"command"`
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func parseJSONEvents(t *testing.T, lines []string) []testJSONEvent {
+	t.Helper()
+
+	var events []testJSONEvent
+
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		var ev testJSONEvent
+
+		require.NoError(t, json.Unmarshal([]byte(l), &ev), "invalid event: %v", l)
+
+		events = append(events, ev)
+	}
+
+	return events
+}
+
+func eventTypes(events []testJSONEvent) []string {
+	var result []string
+
+	for _, ev := range events {
+		if ev.Type != "progress" {
+			result = append(result, ev.Type)
+		}
+	}
+
+	return result
+}
+
+func TestJSONEvents(t *testing.T) {
+	t.Parallel()
+
+	env := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	env.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", env.RepoDir)
+
+	dir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("hello"), 0o600))
+
+	eventsFile := filepath.Join(testutil.TempDirectory(t), "events.ndjson")
+
+	env.RunAndExpectSuccess(t, "snapshot", "create", dir, "--json-events-file", eventsFile, "--json-events-interval", "1ms")
+
+	b, err := os.ReadFile(eventsFile)
+	require.NoError(t, err)
+
+	events := parseJSONEvents(t, strings.Split(string(b), "\n"))
+	require.Equal(t, []string{"start", "result", "finish"}, eventTypes(events))
+	require.Equal(t, "snapshot create", events[0].Command)
+	require.JSONEq(t, `{"success":true}`, string(events[len(events)-1].Data))
+
+	var result struct {
+		ID    string `json:"id"`
+		Stats struct {
+			FileCount int `json:"fileCount"`
+		} `json:"stats"`
+	}
+
+	for _, ev := range events {
+		if ev.Type == "result" {
+			require.NoError(t, json.Unmarshal(ev.Data, &result))
+		}
+	}
+
+	require.NotEmpty(t, result.ID)
+	require.Equal(t, 1, result.Stats.FileCount)
+
+	// events streamed to stdout.
+	events = parseJSONEvents(t, env.RunAndExpectSuccess(t, "snapshot", "verify", "--json-events"))
+	require.Equal(t, []string{"start", "result", "finish"}, eventTypes(events))
+
+	// with --json only events are written to stdout.
+	events = parseJSONEvents(t, env.RunAndExpectSuccess(t, "snapshot", "create", dir, "--json", "--json-events"))
+	require.Equal(t, []string{"start", "result", "finish"}, eventTypes(events))
+
+	events = parseJSONEvents(t, env.RunAndExpectSuccess(t, "restore", result.ID, testutil.TempDirectory(t), "--json-events"))
+	require.Equal(t, []string{"start", "result", "finish"}, eventTypes(events))
+	require.Equal(t, "restore", events[0].Command)
+
+	events = parseJSONEvents(t, env.RunAndExpectSuccess(t, "snapshot", "gc", "--json-events"))
+	require.Equal(t, []string{"start", "result", "finish"}, eventTypes(events))
+
+	// failure is reported in the finish event.
+	_, _ = env.RunAndExpectFailure(t, "snapshot", "create", filepath.Join(dir, "no-such-dir"), "--json-events-file", eventsFile)
+
+	b, err = os.ReadFile(eventsFile)
+	require.NoError(t, err)
+
+	events = parseJSONEvents(t, strings.Split(string(b), "\n"))
+	require.Equal(t, "finish", events[len(events)-1].Type)
+	require.Contains(t, string(events[len(events)-1].Data), `"success":false`)
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/kopia/kopia/internal/units"
+)
+
+const (
+	sizeUnitsSI  = "si"
+	sizeUnitsIEC = "iec"
+
+	timeFormatDefault = "default"
+	timeFormatRFC3339 = "rfc3339"
+
+	durationFormatHuman   = "human"
+	durationFormatSeconds = "seconds"
+)
+
+// outputFormatFlags controls how sizes, timestamps and durations are formatted by all commands.
+type outputFormatFlags struct {
+	sizeUnits      string
+	timeZone       string
+	timeFormat     string
+	durationFormat string
+}
+
+func (c *outputFormatFlags) setup(svc appServices, app *kingpin.Application) {
+	app.Flag("size-units", "Units used to display sizes: 'si' (KB, MB, ...) or 'iec' (KiB, MiB, ...)").Envar(svc.EnvName("KOPIA_SIZE_UNITS")).EnumVar(&c.sizeUnits, sizeUnitsSI, sizeUnitsIEC)
+	app.Flag("timezone", "Format time according to specified time zone (local, utc, original or time zone name)").Default("local").Envar(svc.EnvName("KOPIA_TIMEZONE")).StringVar(&c.timeZone)
+	app.Flag("time-format", "Format of displayed timestamps").Default(timeFormatDefault).Envar(svc.EnvName("KOPIA_TIME_FORMAT")).EnumVar(&c.timeFormat, timeFormatDefault, timeFormatRFC3339)
+	app.Flag("duration-format", "Format of displayed durations: 'human' (1h2m3s) or 'seconds' (3723)").Default(durationFormatHuman).Envar(svc.EnvName("KOPIA_DURATION_FORMAT")).EnumVar(&c.durationFormat, durationFormatHuman, durationFormatSeconds)
+}
+
+// apply applies the flags that affect formatting outside of this package.
+func (c *outputFormatFlags) apply() {
+	switch c.sizeUnits {
+	case sizeUnitsSI:
+		units.SetBytesStringBase2(false)
+	case sizeUnitsIEC:
+		units.SetBytesStringBase2(true)
+	default:
+		// use KOPIA_BYTES_STRING_BASE_2 environment variable.
+		units.ResetBytesString()
+	}
+}
+
+// formatTimestamp formats the provided timestamp, nil flags use the default format and local time zone.
+func (c *outputFormatFlags) formatTimestamp(ts time.Time) string {
+	if c != nil && c.timeFormat == timeFormatRFC3339 {
+		return c.convertTimezone(ts).Format(time.RFC3339)
+	}
+
+	return c.convertTimezone(ts).Format("2006-01-02 15:04:05 MST")
+}
+
+func (c *outputFormatFlags) formatTimestampPrecise(ts time.Time) string {
+	if c != nil && c.timeFormat == timeFormatRFC3339 {
+		return c.convertTimezone(ts).Format("2006-01-02T15:04:05.000Z07:00")
+	}
+
+	return c.convertTimezone(ts).Format("2006-01-02 15:04:05.000 MST")
+}
+
+func (c *outputFormatFlags) formatDuration(d time.Duration) string {
+	if c != nil && c.durationFormat == durationFormatSeconds {
+		return strconv.FormatInt(int64(d/time.Second), 10)
+	}
+
+	return d.Truncate(time.Second).String()
+}
+
+func (c *outputFormatFlags) convertTimezone(ts time.Time) time.Time {
+	tz := "local"
+	if c != nil && c.timeZone != "" {
+		tz = c.timeZone
+	}
+
+	switch tz {
+	case "local":
+		return ts.Local()
+	case "utc":
+		return ts.UTC()
+	case "original":
+		return ts
+	default:
+		loc, err := time.LoadLocation(tz)
+		if err == nil {
+			return ts.In(loc)
+		}
+
+		return ts
+	}
+}
@@ -0,0 +1,38 @@
+package cli_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+// not parallel since output formatting flags are global.
+func TestOutputFormatFlags(t *testing.T) {
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, testenv.NewInProcRunner(t))
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file1"), make([]byte, 3000), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir)
+
+	lines := e.RunAndExpectSuccess(t, "snapshot", "list", srcdir, "--size-units=iec", "--time-format=rfc3339", "--timezone=utc")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[1], " 2.9 KiB ")
+	require.Regexp(t, regexp.MustCompile(`^  \d{4}-\d\d-\d\dT\d\d:\d\d:\d\dZ `), lines[1])
+
+	lines = e.RunAndExpectSuccess(t, "snapshot", "list", srcdir, "--size-units=si", "--timezone=utc")
+	require.Contains(t, lines[1], " 3 KB ")
+	require.Regexp(t, regexp.MustCompile(`^  \d{4}-\d\d-\d\d \d\d:\d\d:\d\d UTC `), lines[1])
+
+	e.RunAndExpectFailure(t, "snapshot", "list", "--size-units=bad")
+	e.RunAndExpectFailure(t, "snapshot", "list", "--duration-format=bad")
+}
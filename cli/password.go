@@ -2,6 +2,8 @@
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -11,8 +13,12 @@
 	"golang.org/x/term"
 
 	"github.com/kopia/kopia/internal/passwordpersist"
+	"github.com/kopia/kopia/internal/shamir"
 )
 
+// length of random secrets protecting repositories whose password is split into Shamir shares.
+const shamirSecretLength = 32
+
 func askForNewRepositoryPassword(out io.Writer) (string, error) {
 	for {
 		p1, err := askPass(out, "Enter password to create new repository: ")
@@ -73,6 +79,9 @@ func (c *App) getPasswordFromFlags(ctx context.Context, isCreate, allowPersisten
 	case c.password != "":
 		// password provided via --password flag or KOPIA_PASSWORD environment variable
 		return strings.TrimSpace(c.password), nil
+	case len(c.shamirShares) > 0 && !isCreate:
+		// password reconstructed from shares provided via --shamir-share flags
+		return passwordFromShamirShares(c.shamirShares)
 	case isCreate:
 		// this is a new repository, ask for password
 		return askForNewRepositoryPassword(c.stdoutWriter)
@@ -92,6 +101,60 @@ func (c *App) getPasswordFromFlags(ctx context.Context, isCreate, allowPersisten
 	return askForExistingRepositoryPassword(c.stdoutWriter)
 }
 
+// newShamirPassword generates a random repository password and splits it into shares
+// according to the provided 'K/N' scheme.
+func newShamirPassword(scheme string) (string, []shamir.Share, error) {
+	k, n, err := shamir.ParseScheme(scheme)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "invalid --shamir")
+	}
+
+	secret := make([]byte, shamirSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, errors.Wrap(err, "unable to generate secret")
+	}
+
+	shares, err := shamir.Split(secret, n, k)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "unable to split secret")
+	}
+
+	return hex.EncodeToString(secret), shares, nil
+}
+
+// passwordFromShamirShares reconstructs repository password from textual shares.
+func passwordFromShamirShares(shareStrings []string) (string, error) {
+	var shares []shamir.Share
+
+	for i, str := range shareStrings {
+		s, err := shamir.ParseShare(str)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid share #%v", i+1)
+		}
+
+		shares = append(shares, s)
+	}
+
+	secret, err := shamir.Combine(shares)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to combine shares")
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+func printShamirShares(out *textOutput, shares []shamir.Share) {
+	out.printStderr("\nRepository password has been split into %v shares, any %v of which are required to open the repository.\n", len(shares), shares[0].Threshold)
+	out.printStderr("Give each share to a different person and store them securely, they will not be displayed again.\n")
+	out.printStderr("To open the repository pass the shares using --shamir-share flags.\n\n")
+
+	for _, s := range shares {
+		out.printStdout("share %v: %v\n", s.Index, s)
+	}
+
+	out.printStderr("\n")
+}
+
 // askPass presents a given prompt and asks the user for password.
 func askPass(out io.Writer, prompt string) (string, error) {
 	for i := 0; i < 5; i++ {
@@ -9,7 +9,6 @@
 	"io"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/pkg/errors"
 
@@ -19,9 +18,6 @@
 
 const oneHundredPercent = 100.0
 
-// TODO - remove this global.
-var timeZone = "local" //nolint:gochecknoglobals
-
 func showContentWithFlags(w io.Writer, rd io.Reader, unzip, indentJSON bool) error {
 	if unzip {
 		gz, err := gzip.NewReader(rd)
@@ -69,32 +65,6 @@ func maybeHumanReadableCount(enable bool, value int64) string {
 	return strconv.FormatInt(value, 10)
 }
 
-func formatTimestamp(ts time.Time) string {
-	return convertTimezone(ts).Format("2006-01-02 15:04:05 MST")
-}
-
-func formatTimestampPrecise(ts time.Time) string {
-	return convertTimezone(ts).Format("2006-01-02 15:04:05.000 MST")
-}
-
-func convertTimezone(ts time.Time) time.Time {
-	switch timeZone {
-	case "local":
-		return ts.Local()
-	case "utc":
-		return ts.UTC()
-	case "original":
-		return ts
-	default:
-		loc, err := time.LoadLocation(timeZone)
-		if err == nil {
-			return ts.In(loc)
-		}
-
-		return ts
-	}
-}
-
 func formatCompressionPercentage(original, compressed int64) string {
 	if compressed >= original {
 		return "0%"
@@ -107,6 +77,14 @@ func formatCompressionPercentage(original, compressed int64) string {
 	return fmt.Sprintf("%.1f%%", oneHundredPercent*(1-float64(compressed)/float64(original)))
 }
 
+func formatPercentage(part, total int64) string {
+	if total == 0 {
+		return "0%"
+	}
+
+	return fmt.Sprintf("%.1f%%", oneHundredPercent*float64(part)/float64(total))
+}
+
 func indentMultilineString(l, prefix string) string {
 	var lines []string
 
@@ -23,9 +23,16 @@ func (c *storageS3Flags) Setup(svc StorageProviderServices, cmd *kingpin.CmdClau
 	cmd.Flag("bucket", "Name of the S3 bucket").Required().StringVar(&c.s3options.BucketName)
 	cmd.Flag("endpoint", "Endpoint to use").Default("s3.amazonaws.com").StringVar(&c.s3options.Endpoint)
 	cmd.Flag("region", "S3 Region").Default("").StringVar(&c.s3options.Region)
-	cmd.Flag("access-key", "Access key ID (overrides AWS_ACCESS_KEY_ID environment variable)").Required().Envar(svc.EnvName("AWS_ACCESS_KEY_ID")).StringVar(&c.s3options.AccessKeyID)
-	cmd.Flag("secret-access-key", "Secret access key (overrides AWS_SECRET_ACCESS_KEY environment variable)").Required().Envar(svc.EnvName("AWS_SECRET_ACCESS_KEY")).StringVar(&c.s3options.SecretAccessKey)
+	cmd.Flag("transfer-acceleration", "Use S3 Transfer Acceleration endpoint (AWS only, must be enabled on the bucket)").BoolVar(&c.s3options.TransferAcceleration)
+	cmd.Flag("access-key", "Access key ID (overrides AWS_ACCESS_KEY_ID environment variable)").Envar(svc.EnvName("AWS_ACCESS_KEY_ID")).StringVar(&c.s3options.AccessKeyID)
+	cmd.Flag("secret-access-key", "Secret access key (overrides AWS_SECRET_ACCESS_KEY environment variable)").Envar(svc.EnvName("AWS_SECRET_ACCESS_KEY")).StringVar(&c.s3options.SecretAccessKey)
 	cmd.Flag("session-token", "Session token (overrides AWS_SESSION_TOKEN environment variable)").Envar(svc.EnvName("AWS_SESSION_TOKEN")).StringVar(&c.s3options.SessionToken)
+	cmd.Flag("role-arn", "ARN of the IAM role to assume").StringVar(&c.s3options.RoleARN)
+	cmd.Flag("role-session-name", "Session name used when assuming the role (default 'kopia')").StringVar(&c.s3options.RoleSessionName)
+	cmd.Flag("role-external-id", "External ID required by the role trust policy").StringVar(&c.s3options.RoleExternalID)
+	cmd.Flag("role-session-duration", "Duration of the temporary credentials obtained by assuming the role, at least 1h").DurationVar(&c.s3options.RoleSessionDuration)
+	cmd.Flag("web-identity-token-file", "File containing web identity token used to assume the role (e.g. in EKS with IAM roles for service accounts)").StringVar(&c.s3options.WebIdentityTokenFile)
+	cmd.Flag("sts-endpoint", "STS endpoint used to assume the role").StringVar(&c.s3options.STSEndpoint)
 	cmd.Flag("prefix", "Prefix to use for objects in the bucket. Put trailing slash (/) if you want to use prefix as directory. e.g my-backup-dir/ would put repository contents inside my-backup-dir directory").StringVar(&c.s3options.Prefix)
 	cmd.Flag("disable-tls", "Disable TLS security (HTTPS)").BoolVar(&c.s3options.DoNotUseTLS)
 	cmd.Flag("disable-tls-verification", "Disable TLS (HTTPS) certificate verification").BoolVar(&c.s3options.DoNotVerifyTLS)
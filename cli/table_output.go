@@ -0,0 +1,285 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/pkg/errors"
+)
+
+// tableColumn defines a column of tabular output of type T.
+type tableColumn[T any] struct {
+	name  string
+	value func(row T) string
+
+	// less compares rows by the column value, when nil, string values are compared.
+	less func(a, b T) bool
+}
+
+// tableOutput implements --columns, --sort-by and --no-header flags shared by list commands
+// and renders rows as an aligned table.
+type tableOutput[T any] struct {
+	columns  string
+	sortBy   string
+	noHeader bool
+
+	available      []tableColumn[T]
+	defaultColumns []string
+}
+
+func (c *tableOutput[T]) setup(cmd *kingpin.CmdClause, available []tableColumn[T], defaultColumns ...string) {
+	c.available = available
+	c.defaultColumns = defaultColumns
+
+	var names []string
+
+	for _, col := range available {
+		names = append(names, col.name)
+	}
+
+	cmd.Flag("columns", fmt.Sprintf("Output a table with comma-separated list of columns (%v)", strings.Join(names, ","))).PlaceHolder("COLUMNS").StringVar(&c.columns)
+	cmd.Flag("sort-by", "Output a table sorted by comma-separated list of columns, prefix the column with '-' to sort in descending order").PlaceHolder("COLUMNS").StringVar(&c.sortBy)
+	cmd.Flag("no-header", "Output a table without the header row").BoolVar(&c.noHeader)
+}
+
+// tableStreamBlockRows is the maximum number of rows buffered by tableStream to align columns.
+const tableStreamBlockRows = 1000
+
+// enabled returns true if any of the table flags was specified.
+func (c *tableOutput[T]) enabled() bool {
+	return c.columns != "" || c.sortBy != "" || c.noHeader
+}
+
+// sorted returns true if rows must be sorted, which requires all of them to be rendered at once.
+func (c *tableOutput[T]) sorted() bool {
+	return c.sortBy != ""
+}
+
+func (c *tableOutput[T]) findColumn(name string) (tableColumn[T], error) {
+	for _, col := range c.available {
+		if col.name == name {
+			return col, nil
+		}
+	}
+
+	return tableColumn[T]{}, errors.Errorf("unknown column %q", name)
+}
+
+func splitColumnNames(s string) []string {
+	var result []string
+
+	for _, n := range strings.Split(s, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			result = append(result, n)
+		}
+	}
+
+	return result
+}
+
+// validate checks that the flags refer to existing columns, so that errors are reported before doing any work.
+func (c *tableOutput[T]) validate() error {
+	for _, n := range splitColumnNames(c.columns) {
+		if _, err := c.findColumn(n); err != nil {
+			return err
+		}
+	}
+
+	for _, n := range splitColumnNames(c.sortBy) {
+		if _, err := c.findColumn(strings.TrimPrefix(n, "-")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *tableOutput[T]) sortRows(rows []T) error {
+	type sortKey struct {
+		col        tableColumn[T]
+		descending bool
+	}
+
+	var keys []sortKey
+
+	for _, n := range splitColumnNames(c.sortBy) {
+		col, err := c.findColumn(strings.TrimPrefix(n, "-"))
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, sortKey{col, strings.HasPrefix(n, "-")})
+	}
+
+	less := func(col tableColumn[T], a, b T) bool {
+		if col.less != nil {
+			return col.less(a, b)
+		}
+
+		return col.value(a) < col.value(b)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, k := range keys {
+			a, b := rows[i], rows[j]
+			if k.descending {
+				a, b = b, a
+			}
+
+			if less(k.col, a, b) {
+				return true
+			}
+
+			if less(k.col, b, a) {
+				return false
+			}
+		}
+
+		return false
+	})
+
+	return nil
+}
+
+func (c *tableOutput[T]) selectedColumns() ([]tableColumn[T], error) {
+	names := splitColumnNames(c.columns)
+	if len(names) == 0 {
+		names = c.defaultColumns
+	}
+
+	var cols []tableColumn[T]
+
+	for _, n := range names {
+		col, err := c.findColumn(n)
+		if err != nil {
+			return nil, err
+		}
+
+		cols = append(cols, col)
+	}
+
+	return cols, nil
+}
+
+func tableHeader[T any](cols []tableColumn[T]) []string {
+	var header []string
+
+	for _, col := range cols {
+		header = append(header, strings.ToUpper(col.name))
+	}
+
+	return header
+}
+
+// render sorts the provided rows and writes them as a table with selected columns.
+func (c *tableOutput[T]) render(w io.Writer, rows []T) error {
+	cols, err := c.selectedColumns()
+	if err != nil {
+		return err
+	}
+
+	if err := c.sortRows(rows); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) //nolint:gomnd
+
+	if !c.noHeader {
+		fmt.Fprintln(tw, strings.Join(tableHeader(cols), "\t")) //nolint:errcheck
+	}
+
+	for _, r := range rows {
+		var values []string
+
+		for _, col := range cols {
+			values = append(values, col.value(r))
+		}
+
+		fmt.Fprintln(tw, strings.Join(values, "\t")) //nolint:errcheck
+	}
+
+	return errors.Wrap(tw.Flush(), "error writing table")
+}
+
+// tableStream writes unsorted rows of a table as they are added, buffering at most tableStreamBlockRows
+// of them at a time. Columns are aligned within each block and are never narrower than in preceding blocks.
+type tableStream[T any] struct {
+	w      io.Writer
+	cols   []tableColumn[T]
+	widths []int
+	block  [][]string
+}
+
+// stream returns tableStream which writes rows with selected columns to the provided writer.
+func (c *tableOutput[T]) stream(w io.Writer) (*tableStream[T], error) {
+	cols, err := c.selectedColumns()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &tableStream[T]{
+		w:      w,
+		cols:   cols,
+		widths: make([]int, len(cols)),
+	}
+
+	if !c.noHeader {
+		s.block = append(s.block, tableHeader(cols))
+	}
+
+	return s, nil
+}
+
+func (s *tableStream[T]) add(row T) error {
+	var values []string
+
+	for _, col := range s.cols {
+		values = append(values, col.value(row))
+	}
+
+	s.block = append(s.block, values)
+
+	if len(s.block) >= tableStreamBlockRows {
+		return s.flush()
+	}
+
+	return nil
+}
+
+// flush writes buffered rows, padding cells the same way as render.
+func (s *tableStream[T]) flush() error {
+	for _, values := range s.block {
+		for i, v := range values {
+			s.widths[i] = max(s.widths[i], utf8.RuneCountInString(v))
+		}
+	}
+
+	var sb strings.Builder
+
+	for _, values := range s.block {
+		sb.Reset()
+
+		for i, v := range values {
+			sb.WriteString(v)
+
+			if i < len(values)-1 {
+				sb.WriteString(strings.Repeat(" ", s.widths[i]-utf8.RuneCountInString(v)+2)) //nolint:gomnd
+			}
+		}
+
+		sb.WriteString("\n")
+
+		if _, err := io.WriteString(s.w, sb.String()); err != nil {
+			return errors.Wrap(err, "error writing table")
+		}
+	}
+
+	s.block = s.block[:0]
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableStream(t *testing.T) {
+	var c tableOutput[string]
+
+	c.available = []tableColumn[string]{
+		{name: "value", value: func(r string) string { return r }},
+		{name: "len", value: func(r string) string { return strings.Repeat("x", len(r)) }},
+	}
+	c.defaultColumns = []string{"value", "len"}
+
+	var rows []string
+
+	for i := 0; i < 2*tableStreamBlockRows; i++ {
+		rows = append(rows, strings.Repeat("a", 1+i/tableStreamBlockRows*10))
+	}
+
+	// single block is rendered exactly like a sorted table.
+	var rendered, streamed bytes.Buffer
+
+	require.NoError(t, c.render(&rendered, rows[0:10]))
+
+	s, err := c.stream(&streamed)
+	require.NoError(t, err)
+
+	for _, r := range rows[0:10] {
+		require.NoError(t, s.add(r))
+	}
+
+	require.NoError(t, s.flush())
+	require.Equal(t, rendered.String(), streamed.String())
+
+	// later blocks are never narrower than earlier ones.
+	streamed.Reset()
+
+	s, err = c.stream(&streamed)
+	require.NoError(t, err)
+
+	for _, r := range rows {
+		require.NoError(t, s.add(r))
+	}
+
+	require.NoError(t, s.flush())
+
+	lines := strings.Split(strings.TrimSuffix(streamed.String(), "\n"), "\n")
+	require.Len(t, lines, len(rows)+1)
+	require.Equal(t, strings.Index(lines[1], "x"), strings.Index(lines[0], "LEN"))
+	require.Greater(t, strings.Index(lines[len(lines)-1], "x"), strings.Index(lines[1], "x"))
+}
@@ -0,0 +1,91 @@
+package cli_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/tests/testenv"
+)
+
+func TestTableOutput(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file1"), []byte{1, 2, 3}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--description=first")
+
+	require.NoError(t, os.WriteFile(filepath.Join(srcdir, "file2"), []byte{1, 2, 3, 4, 5}, 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--description=second")
+
+	// snapshot list
+	lines := e.RunAndExpectSuccess(t, "snapshot", "list", "--columns=description,size,files")
+	require.Len(t, lines, 3)
+	require.Equal(t, []string{"DESCRIPTION", "SIZE", "FILES"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"first", "3", "B", "1"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"second", "8", "B", "2"}, strings.Fields(lines[2]))
+
+	lines = e.RunAndExpectSuccess(t, "snapshot", "list", "--columns=description", "--sort-by=-size", "--no-header")
+	require.Equal(t, []string{"second", "first"}, lines)
+
+	lines = e.RunAndExpectSuccess(t, "snapshot", "list", "--no-header")
+	require.Len(t, lines, 2)
+	require.Contains(t, lines[0], srcdir)
+
+	e.RunAndExpectFailure(t, "snapshot", "list", "--columns=no-such-column")
+	e.RunAndExpectFailure(t, "snapshot", "list", "--sort-by=-no-such-column")
+
+	// policy list
+	e.RunAndExpectSuccess(t, "policy", "set", srcdir, "--keep-latest=5")
+
+	lines = e.RunAndExpectSuccess(t, "policy", "list", "--columns=path,target", "--sort-by=-target")
+	require.Equal(t, []string{"PATH", "TARGET"}, strings.Fields(lines[0]))
+	require.Len(t, lines, 3)
+	require.Equal(t, srcdir, strings.Fields(lines[1])[0])
+	require.Equal(t, []string{"(global)"}, strings.Fields(lines[2]))
+
+	// content list
+	lines = e.RunAndExpectSuccess(t, "content", "list", "--columns=id,size", "--sort-by=-size,id", "--no-header")
+	require.NotEmpty(t, lines)
+
+	allContents := e.RunAndExpectSuccess(t, "content", "list")
+	require.Len(t, lines, len(allContents))
+
+	lastSize := int64(math.MaxInt64)
+
+	for _, l := range lines {
+		f := strings.Fields(l)
+		require.Len(t, f, 2)
+
+		size, err := strconv.ParseInt(f[1], 10, 64)
+		require.NoError(t, err)
+		require.LessOrEqual(t, size, lastSize)
+
+		lastSize = size
+	}
+
+	// unsorted table is streamed with aligned columns.
+	lines = e.RunAndExpectSuccess(t, "content", "list", "--columns=id,size")
+	require.Len(t, lines, len(allContents)+1)
+	require.Equal(t, []string{"ID", "SIZE"}, strings.Fields(lines[0]))
+
+	sizeColumn := strings.Index(lines[0], "SIZE")
+
+	for _, l := range lines[1:] {
+		require.Equal(t, strings.Fields(l)[1], strings.TrimSpace(l[sizeColumn:]), "misaligned line: %q", l)
+	}
+
+	e.RunAndExpectFailure(t, "content", "list", "--columns=bad")
+}
@@ -238,6 +238,11 @@ func (c *App) maybePrintUpdateNotification(ctx context.Context) {
 		return
 	}
 
+	if c.forensic {
+		// checking for updates persists update state.
+		return
+	}
+
 	updatedVersion, err := c.maybeCheckForUpdates(ctx)
 	if err != nil {
 		log(ctx).Debugf("unable to check for updates: %v", err)
@@ -5,6 +5,7 @@
 	"context"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo/object"
 )
 
 // DirectoryCacher reads and potentially caches directory entries for a given directory.
@@ -49,6 +50,15 @@ type file struct {
 	fs.File
 }
 
+// ObjectID returns the object ID of the wrapped file or EmptyID if it's not backed by an object.
+func (f *file) ObjectID() object.ID {
+	if h, ok := f.File.(object.HasObjectID); ok {
+		return h.ObjectID()
+	}
+
+	return object.EmptyID
+}
+
 type symlink struct {
 	ctx *cacheContext
 	fs.Symlink
@@ -0,0 +1,19 @@
+package fs
+
+import "time"
+
+// EntryWithChangeTime is optionally implemented by entries which know the time of the last change
+// of their contents or metadata (ctime).
+type EntryWithChangeTime interface {
+	// ChangeTime returns the change time of the entry or zero time if it's not available.
+	ChangeTime() time.Time
+}
+
+// ChangeTime returns the change time of the provided entry or zero time if it's not available.
+func ChangeTime(e Entry) time.Time {
+	if c, ok := e.(EntryWithChangeTime); ok {
+		return c.ChangeTime()
+	}
+
+	return time.Time{}
+}
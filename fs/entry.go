@@ -28,6 +28,11 @@ type Entry interface {
 type OwnerInfo struct {
 	UserID  uint32 `json:"uid"`
 	GroupID uint32 `json:"gid"`
+
+	// UserName and GroupName are names corresponding to UserID and GroupID
+	// at the time the entry was read, empty if not known.
+	UserName  string `json:"user,omitempty"`
+	GroupName string `json:"group,omitempty"`
 }
 
 // DeviceInfo describes the device this filesystem entry is on.
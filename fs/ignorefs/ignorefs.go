@@ -4,6 +4,7 @@
 import (
 	"bufio"
 	"context"
+	"io"
 	"strings"
 	"sync"
 
@@ -25,13 +26,17 @@
 type ignoreContext struct {
 	parent *ignoreContext
 
-	onIgnore []IgnoreCallback
+	onIgnore        []IgnoreCallback
+	onIgnoreByOwner []IgnoreCallback
 
 	dotIgnoreFiles []string                  // which files to look for more ignore rules
 	matchers       []wcmatch.WildcardMatcher // current set of rules to ignore files
 	maxFileSize    int64                     // maximum size of file allowed
 
-	oneFileSystem bool // should we enter other mounted filesystems
+	oneFileSystem       bool // should we enter other mounted filesystems
+	ignoreReparsePoints bool // should we skip junctions and mount points instead of storing them as links
+
+	owners ownerFilter // users and groups whose files are included or excluded
 }
 
 func (c *ignoreContext) shouldIncludeByName(ctx context.Context, path string, e fs.Entry, policyTree *policy.Tree) bool {
@@ -62,6 +67,24 @@ func (c *ignoreContext) shouldIncludeByName(ctx context.Context, path string, e
 	return true
 }
 
+func (c *ignoreContext) shouldIncludeByOwner(ctx context.Context, path string, e fs.Entry, policyTree *policy.Tree) bool {
+	if c.owners.shouldInclude(e.Owner(), e.IsDir()) {
+		return true
+	}
+
+	path = strings.TrimPrefix(path, "./")
+
+	for _, oi := range c.onIgnore {
+		oi(ctx, path, e, policyTree)
+	}
+
+	for _, oi := range c.onIgnoreByOwner {
+		oi(ctx, path, e, policyTree)
+	}
+
+	return false
+}
+
 func (c *ignoreContext) shouldIncludeByDevice(e fs.Entry, parent *ignoreDirectory) bool {
 	if !c.oneFileSystem {
 		return true
@@ -70,6 +93,14 @@ func (c *ignoreContext) shouldIncludeByDevice(e fs.Entry, parent *ignoreDirector
 	return e.Device().Dev == parent.Device().Dev
 }
 
+func (c *ignoreContext) shouldIncludeByLinkType(e fs.Entry) bool {
+	if !c.ignoreReparsePoints {
+		return true
+	}
+
+	return fs.LinkType(e) == ""
+}
+
 type ignoreDirectory struct {
 	relativePath  string
 	parentContext *ignoreContext
@@ -148,6 +179,27 @@ func (d *ignoreDirectory) DirEntryOrNil(ctx context.Context) (*snapshot.DirEntry
 	return nil, nil
 }
 
+// MacOSMetadata implements fs.EntryWithMacOSMetadata.
+func (d *ignoreDirectory) MacOSMetadata(ctx context.Context) (*fs.MacOSMetadata, error) {
+	if me, ok := d.Directory.(fs.EntryWithMacOSMetadata); ok {
+		//nolint:wrapcheck
+		return me.MacOSMetadata(ctx)
+	}
+
+	//nolint:nilnil
+	return nil, nil
+}
+
+// OpenResourceFork implements fs.EntryWithMacOSMetadata.
+func (d *ignoreDirectory) OpenResourceFork(ctx context.Context) (io.ReadCloser, error) {
+	if me, ok := d.Directory.(fs.EntryWithMacOSMetadata); ok {
+		//nolint:wrapcheck
+		return me.OpenResourceFork(ctx)
+	}
+
+	return nil, errors.New("resource fork not available")
+}
+
 type ignoreDirIterator struct {
 	//nolint:containedctx
 	ctx         context.Context
@@ -226,6 +278,10 @@ func (d *ignoreDirectory) maybeWrappedChildEntry(ctx context.Context, ic *ignore
 		return nil, false
 	}
 
+	if !ic.shouldIncludeByOwner(ctx, s, e, d.policyTree) {
+		return nil, false
+	}
+
 	if maxSize := ic.maxFileSize; maxSize > 0 && e.Size() > maxSize {
 		return nil, false
 	}
@@ -234,6 +290,10 @@ func (d *ignoreDirectory) maybeWrappedChildEntry(ctx context.Context, ic *ignore
 		return nil, false
 	}
 
+	if !ic.shouldIncludeByLinkType(e) {
+		return nil, false
+	}
+
 	if dir, ok := e.(fs.Directory); ok {
 		id := ignoreDirectoryPool.Get().(*ignoreDirectory) //nolint:forcetypeassert
 
@@ -295,11 +355,14 @@ func (d *ignoreDirectory) buildContext(ctx context.Context) (*ignoreContext, err
 	}
 
 	newic := &ignoreContext{
-		parent:         d.parentContext,
-		onIgnore:       d.parentContext.onIgnore,
-		dotIgnoreFiles: effectiveDotIgnoreFiles,
-		maxFileSize:    d.parentContext.maxFileSize,
-		oneFileSystem:  d.parentContext.oneFileSystem,
+		parent:              d.parentContext,
+		onIgnore:            d.parentContext.onIgnore,
+		onIgnoreByOwner:     d.parentContext.onIgnoreByOwner,
+		dotIgnoreFiles:      effectiveDotIgnoreFiles,
+		maxFileSize:         d.parentContext.maxFileSize,
+		oneFileSystem:       d.parentContext.oneFileSystem,
+		ignoreReparsePoints: d.parentContext.ignoreReparsePoints,
+		owners:              d.parentContext.owners,
 	}
 
 	if pol != nil {
@@ -330,6 +393,8 @@ func (c *ignoreContext) overrideFromPolicy(fp *policy.FilesPolicy, dirPath strin
 	}
 
 	c.oneFileSystem = fp.OneFileSystem.OrDefault(false)
+	c.ignoreReparsePoints = fp.IgnoreReparsePoints.OrDefault(false)
+	c.owners.overrideFromPolicy(fp)
 
 	// append policy-level rules
 	for _, rule := range fp.IgnoreRules {
@@ -450,3 +515,13 @@ func ReportIgnoredFiles(f IgnoreCallback) Option {
 		}
 	}
 }
+
+// ReportFilesIgnoredByOwner returns an Option causing ignorefs to call the provided function whenever a file or directory
+// is ignored because of its owner user or group, in addition to callbacks provided using ReportIgnoredFiles.
+func ReportFilesIgnoredByOwner(f IgnoreCallback) Option {
+	return func(ic *ignoreContext) {
+		if f != nil {
+			ic.onIgnoreByOwner = append(ic.onIgnoreByOwner, f)
+		}
+	}
+}
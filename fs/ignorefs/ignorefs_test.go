@@ -246,6 +246,30 @@ func setupFilesystem(skipDefaultFiles bool) *mockfs.Directory {
 			"./src/some-src/f1",
 		},
 	},
+	{
+		desc: "junctions are kept by default",
+		setup: func(root *mockfs.Directory) {
+			root.AddSymlink("junction", `C:\elsewhere`, 0).SetLinkType(fs.LinkTypeJunction)
+			root.AddSymlink("symlink", "file1", 0)
+		},
+		addedFiles: []string{"./junction", "./symlink"},
+	},
+	{
+		desc: "policy with ignore-reparse-points",
+		policyTree: policy.BuildTree(map[string]*policy.Policy{
+			".": {
+				FilesPolicy: policy.FilesPolicy{
+					IgnoreReparsePoints: &trueValue,
+				},
+			},
+		}, policy.DefaultPolicy),
+		setup: func(root *mockfs.Directory) {
+			root.AddSymlink("junction", `C:\elsewhere`, 0).SetLinkType(fs.LinkTypeJunction)
+			root.Subdir("bin").AddSymlink("mount", `\\?\Volume{0}\`, 0).SetLinkType(fs.LinkTypeMountPoint)
+			root.AddSymlink("symlink", "file1", 0)
+		},
+		addedFiles: []string{"./symlink"},
+	},
 	{
 		desc: "absolut match",
 		setup: func(root *mockfs.Directory) {
@@ -512,6 +536,119 @@ func TestIgnoreFS(t *testing.T) {
 	}
 }
 
+func TestIgnoreFSByOwner(t *testing.T) {
+	alice := fs.OwnerInfo{UserID: 1000, GroupID: 100, UserName: "alice", GroupName: "users"}
+	bob := fs.OwnerInfo{UserID: 1001, GroupID: 100, UserName: "bob", GroupName: "users"}
+	daemon := fs.OwnerInfo{UserID: 2, GroupID: 2, UserName: "daemon", GroupName: "daemon"}
+
+	setup := func() *mockfs.Directory {
+		root := mockfs.NewDirectory()
+
+		root.AddFile("alice-file", dummyFileContents, 0).SetOwner(alice)
+		root.AddFile("bob-file", dummyFileContents, 0).SetOwner(bob)
+		root.AddFile("daemon-file", dummyFileContents, 0).SetOwner(daemon)
+
+		d := root.AddDir("bob-dir", 0)
+		d.SetOwner(bob)
+		d.AddFile("alice-file-in-bob-dir", dummyFileContents, 0).SetOwner(alice)
+
+		d = root.AddDir("alice-dir", 0)
+		d.SetOwner(alice)
+		d.AddFile("bob-file-in-alice-dir", dummyFileContents, 0).SetOwner(bob)
+		d.AddFile("daemon-file-in-alice-dir", dummyFileContents, 0).SetOwner(daemon)
+
+		return root
+	}
+
+	cases := []struct {
+		desc    string
+		fp      policy.FilesPolicy
+		subPol  *policy.FilesPolicy
+		want    []string
+		ignored int
+	}{
+		{
+			desc: "include owner by name",
+			fp:   policy.FilesPolicy{IncludeOwners: []string{"alice"}},
+			want: []string{
+				"./",
+				"./alice-dir/",
+				"./alice-file",
+				"./bob-dir/",
+				"./bob-dir/alice-file-in-bob-dir",
+			},
+			ignored: 4,
+		},
+		{
+			desc: "exclude owner by id",
+			fp:   policy.FilesPolicy{ExcludeOwners: []string{"1001"}},
+			want: []string{
+				"./",
+				"./alice-dir/",
+				"./alice-dir/daemon-file-in-alice-dir",
+				"./alice-file",
+				"./daemon-file",
+			},
+			ignored: 3,
+		},
+		{
+			desc: "include group, exclude owner",
+			fp:   policy.FilesPolicy{IncludeGroups: []string{"users"}, ExcludeOwners: []string{"bob"}},
+			want: []string{
+				"./",
+				"./alice-dir/",
+				"./alice-file",
+			},
+			ignored: 5,
+		},
+		{
+			desc:   "subdirectory policy overrides parent",
+			fp:     policy.FilesPolicy{ExcludeGroups: []string{"daemon"}},
+			subPol: &policy.FilesPolicy{ExcludeGroups: []string{"100"}},
+			want: []string{
+				"./",
+				"./alice-dir/",
+				"./alice-dir/daemon-file-in-alice-dir",
+				"./alice-file",
+				"./bob-dir/",
+				"./bob-dir/alice-file-in-bob-dir",
+				"./bob-file",
+			},
+			ignored: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.desc, func(t *testing.T) {
+			pols := map[string]*policy.Policy{
+				".": {FilesPolicy: tc.fp},
+			}
+
+			if tc.subPol != nil {
+				pols["./alice-dir"] = &policy.Policy{FilesPolicy: *tc.subPol}
+			}
+
+			var ignoredByOwner, ignored int
+
+			ifs := ignorefs.New(setup(), policy.BuildTree(pols, policy.DefaultPolicy),
+				ignorefs.ReportIgnoredFiles(func(ctx context.Context, path string, metadata fs.Entry, pol *policy.Tree) {
+					ignored++
+				}),
+				ignorefs.ReportFilesIgnoredByOwner(func(ctx context.Context, path string, metadata fs.Entry, pol *policy.Tree) {
+					ignoredByOwner++
+				}))
+
+			verifyDirectoryTree(t, ifs, tc.want)
+
+			if ignoredByOwner != tc.ignored || ignored != tc.ignored {
+				t.Errorf("unexpected number of ignored entries: %v/%v, want %v", ignoredByOwner, ignored, tc.ignored)
+			}
+		})
+	}
+}
+
 func addAndSubtractFiles(original, added, removed []string) []string {
 	m := map[string]bool{}
 	for _, ri := range removed {
@@ -0,0 +1,69 @@
+package ignorefs
+
+import (
+	"strconv"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+// ownerFilter selects entries based on their owner user and group, each specified by name or numeric ID.
+// Exclusions take precedence over inclusions, non-empty inclusion list excludes entries not matching it.
+// Inclusions only apply to files, so that files of included owners are found in directories owned by others,
+// while exclusions also prune directories.
+type ownerFilter struct {
+	includeOwners []string
+	excludeOwners []string
+	includeGroups []string
+	excludeGroups []string
+}
+
+func (f *ownerFilter) overrideFromPolicy(fp *policy.FilesPolicy) {
+	if len(fp.IncludeOwners) > 0 {
+		f.includeOwners = fp.IncludeOwners
+	}
+
+	if len(fp.ExcludeOwners) > 0 {
+		f.excludeOwners = fp.ExcludeOwners
+	}
+
+	if len(fp.IncludeGroups) > 0 {
+		f.includeGroups = fp.IncludeGroups
+	}
+
+	if len(fp.ExcludeGroups) > 0 {
+		f.excludeGroups = fp.ExcludeGroups
+	}
+}
+
+func (f *ownerFilter) shouldInclude(oi fs.OwnerInfo, isDir bool) bool {
+	if ownerMatches(f.excludeOwners, oi.UserID, oi.UserName) || ownerMatches(f.excludeGroups, oi.GroupID, oi.GroupName) {
+		return false
+	}
+
+	if isDir {
+		return true
+	}
+
+	if len(f.includeOwners) > 0 && !ownerMatches(f.includeOwners, oi.UserID, oi.UserName) {
+		return false
+	}
+
+	if len(f.includeGroups) > 0 && !ownerMatches(f.includeGroups, oi.GroupID, oi.GroupName) {
+		return false
+	}
+
+	return true
+}
+
+func ownerMatches(specs []string, id uint32, name string) bool {
+	idString := strconv.FormatUint(uint64(id), 10)
+
+	for _, s := range specs {
+		if s == idString || (name != "" && s == name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,26 @@
+package fs
+
+// Link types of Symlink entries which are not symbolic links.
+const (
+	LinkTypeJunction   = "junction"    // NTFS directory junction
+	LinkTypeMountPoint = "mount-point" // volume mounted in an NTFS directory
+)
+
+// SymlinkWithLinkType is optionally implemented by Symlink entries that represent links other than symbolic links,
+// such as Windows directory junctions and volume mount points. Such links are stored like symbolic links and never traversed.
+type SymlinkWithLinkType interface {
+	Symlink
+
+	// LinkType returns the type of the link or an empty string for symbolic links.
+	LinkType() string
+}
+
+// LinkType returns the type of link represented by the provided entry, or an empty string
+// if the entry is a symbolic link or not a link at all.
+func LinkType(e Entry) string {
+	if l, ok := e.(SymlinkWithLinkType); ok {
+		return l.LinkType()
+	}
+
+	return ""
+}
@@ -17,6 +17,7 @@ type filesystemEntry struct {
 	name       string
 	size       int64
 	mtimeNanos int64
+	ctimeNanos int64 // 0 if not available
 	mode       os.FileMode
 	owner      fs.OwnerInfo
 	device     fs.DeviceInfo
@@ -44,6 +45,14 @@ func (e *filesystemEntry) ModTime() time.Time {
 	return time.Unix(0, e.mtimeNanos)
 }
 
+func (e *filesystemEntry) ChangeTime() time.Time {
+	if e.ctimeNanos == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, e.ctimeNanos)
+}
+
 func (e *filesystemEntry) Sys() interface{} {
 	return nil
 }
@@ -70,6 +79,7 @@ type filesystemDirectory struct {
 
 type filesystemSymlink struct {
 	filesystemEntry
+	linkType string
 }
 
 type filesystemFile struct {
@@ -104,7 +114,7 @@ func (f *fileWithMetadata) Entry() (fs.Entry, error) {
 }
 
 func (fsf *filesystemFile) Open(ctx context.Context) (fs.Reader, error) {
-	f, err := os.Open(fsf.fullPath())
+	f, err := openForReading(fsf.fullPath())
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to open local file")
 	}
@@ -117,6 +127,10 @@ func (fsl *filesystemSymlink) Readlink(ctx context.Context) (string, error) {
 	return os.Readlink(fsl.fullPath())
 }
 
+func (fsl *filesystemSymlink) LinkType() string {
+	return fsl.linkType
+}
+
 func (e *filesystemErrorEntry) ErrorInfo() error {
 	return e.err
 }
@@ -155,8 +169,9 @@ func Directory(path string) (fs.Directory, error) {
 }
 
 var (
-	_ fs.Directory  = (*filesystemDirectory)(nil)
-	_ fs.File       = (*filesystemFile)(nil)
-	_ fs.Symlink    = (*filesystemSymlink)(nil)
-	_ fs.ErrorEntry = (*filesystemErrorEntry)(nil)
+	_ fs.Directory           = (*filesystemDirectory)(nil)
+	_ fs.File                = (*filesystemFile)(nil)
+	_ fs.Symlink             = (*filesystemSymlink)(nil)
+	_ fs.SymlinkWithLinkType = (*filesystemSymlink)(nil)
+	_ fs.ErrorEntry          = (*filesystemErrorEntry)(nil)
 )
@@ -0,0 +1,17 @@
+//go:build darwin || freebsd || netbsd
+// +build darwin freebsd netbsd
+
+package localfs
+
+import (
+	"os"
+	"syscall"
+)
+
+func platformSpecificChangeTimeNanos(fi os.FileInfo) int64 {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Ctimespec.Sec)*1e9 + int64(stat.Ctimespec.Nsec) //nolint:unconvert
+	}
+
+	return 0
+}
@@ -0,0 +1,17 @@
+//go:build !windows && !darwin && !freebsd && !netbsd
+// +build !windows,!darwin,!freebsd,!netbsd
+
+package localfs
+
+import (
+	"os"
+	"syscall"
+)
+
+func platformSpecificChangeTimeNanos(fi os.FileInfo) int64 {
+	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
+		return int64(stat.Ctim.Sec)*1e9 + int64(stat.Ctim.Nsec) //nolint:unconvert
+	}
+
+	return 0
+}
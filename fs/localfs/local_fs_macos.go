@@ -0,0 +1,20 @@
+package localfs
+
+import (
+	"context"
+	"io"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// MacOSMetadata implements fs.EntryWithMacOSMetadata, it returns nil on other operating systems.
+func (e *filesystemEntry) MacOSMetadata(ctx context.Context) (*fs.MacOSMetadata, error) {
+	return platformSpecificMacOSMetadata(e.fullPath())
+}
+
+// OpenResourceFork implements fs.EntryWithMacOSMetadata.
+func (e *filesystemEntry) OpenResourceFork(ctx context.Context) (io.ReadCloser, error) {
+	return platformSpecificOpenResourceFork(e.fullPath())
+}
+
+var _ fs.EntryWithMacOSMetadata = (*filesystemFile)(nil)
@@ -0,0 +1,91 @@
+package localfs
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+)
+
+const (
+	// ResourceForkSuffix is appended to the file path to access its resource fork.
+	ResourceForkSuffix = "/..namedfork/rsrc"
+
+	// FinderInfoAttribute is the name of extended attribute storing Finder information.
+	FinderInfoAttribute = "com.apple.FinderInfo"
+
+	// QuarantineAttribute is the name of extended attribute storing quarantine information.
+	QuarantineAttribute = "com.apple.quarantine"
+)
+
+func platformSpecificMacOSMetadata(path string) (*fs.MacOSMetadata, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to stat")
+	}
+
+	md := &fs.MacOSMetadata{}
+
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok {
+		md.BirthTime = unixTimespecToTime(st.Birthtimespec)
+		md.Flags = st.Flags
+	}
+
+	if fi.Mode()&os.ModeSymlink != 0 {
+		// extended attributes and resource forks of symbolic links are not captured.
+		return md, nil
+	}
+
+	if md.FinderInfo, err = getxattr(path, FinderInfoAttribute); err != nil {
+		return nil, err
+	}
+
+	q, err := getxattr(path, QuarantineAttribute)
+	if err != nil {
+		return nil, err
+	}
+
+	md.Quarantine = string(q)
+
+	if fi.Mode().IsRegular() {
+		if rst, err := os.Stat(path + ResourceForkSuffix); err == nil {
+			md.ResourceForkSize = rst.Size()
+		}
+	}
+
+	return md, nil
+}
+
+func platformSpecificOpenResourceFork(path string) (io.ReadCloser, error) {
+	return openForReading(path + ResourceForkSuffix)
+}
+
+// getxattr returns the value of the extended attribute or nil if it's not set.
+func getxattr(path, attr string) ([]byte, error) {
+	sz, err := unix.Getxattr(path, attr, nil)
+	if errors.Is(err, unix.ENOATTR) || errors.Is(err, unix.ENOTSUP) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get %v", attr)
+	}
+
+	buf := make([]byte, sz)
+
+	n, err := unix.Getxattr(path, attr, buf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to get %v", attr)
+	}
+
+	return buf[0:n], nil
+}
+
+func unixTimespecToTime(ts syscall.Timespec) time.Time {
+	return time.Unix(ts.Sec, ts.Nsec)
+}
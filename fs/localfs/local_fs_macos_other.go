@@ -0,0 +1,21 @@
+//go:build !darwin
+// +build !darwin
+
+package localfs
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+)
+
+//nolint:nilnil
+func platformSpecificMacOSMetadata(path string) (*fs.MacOSMetadata, error) {
+	return nil, nil
+}
+
+func platformSpecificOpenResourceFork(path string) (io.ReadCloser, error) {
+	return nil, errors.New("resource forks are only supported on macOS")
+}
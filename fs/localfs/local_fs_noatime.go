@@ -0,0 +1,26 @@
+package localfs
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+//nolint:gochecknoglobals
+var preserveAccessTimes atomic.Bool
+
+// PreserveAccessTimes enables or disables opening of files and directories without updating
+// their access times, where supported by the operating system.
+func PreserveAccessTimes(enabled bool) {
+	preserveAccessTimes.Store(enabled)
+}
+
+// openForReading opens the provided file or directory for reading, optionally without
+// updating its access time.
+func openForReading(path string) (*os.File, error) {
+	if preserveAccessTimes.Load() {
+		return openNoAccessTime(path)
+	}
+
+	//nolint:wrapcheck,gosec
+	return os.Open(path)
+}
@@ -0,0 +1,19 @@
+package localfs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func openNoAccessTime(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOATIME, 0) //nolint:gosec
+	if errors.Is(err, syscall.EPERM) {
+		// O_NOATIME is only permitted to the owner of the file or a privileged user.
+		//nolint:wrapcheck,gosec
+		return os.Open(path)
+	}
+
+	//nolint:wrapcheck
+	return f, err
+}
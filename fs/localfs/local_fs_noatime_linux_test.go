@@ -0,0 +1,48 @@
+package localfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+)
+
+func TestPreserveAccessTimes(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	PreserveAccessTimes(true)
+	defer PreserveAccessTimes(false)
+
+	dir := testutil.TempDirectory(t)
+	fname := filepath.Join(dir, "f")
+	require.NoError(t, os.WriteFile(fname, []byte("hello"), 0o600))
+
+	// access time older than modification time would be updated on read even with 'relatime'.
+	atime := time.Now().Add(-72 * time.Hour).Truncate(time.Second)
+	require.NoError(t, os.Chtimes(fname, atime, atime.Add(time.Hour)))
+
+	e, err := NewEntry(fname)
+	require.NoError(t, err)
+
+	r, err := e.(fs.File).Open(ctx)
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(b))
+	r.Close()
+
+	st, err := os.Stat(fname)
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert
+	require.Equal(t, atime.Unix(), st.Sys().(*syscall.Stat_t).Atim.Sec)
+}
@@ -8,6 +8,7 @@
 	"syscall"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/ownername"
 )
 
 func platformSpecificOwnerInfo(fi os.FileInfo) fs.OwnerInfo {
@@ -15,6 +16,8 @@ func platformSpecificOwnerInfo(fi os.FileInfo) fs.OwnerInfo {
 	if stat, ok := fi.Sys().(*syscall.Stat_t); ok {
 		oi.UserID = stat.Uid
 		oi.GroupID = stat.Gid
+		oi.UserName = ownername.UserName(stat.Uid)
+		oi.GroupName = ownername.GroupName(stat.Gid)
 	}
 
 	return oi
@@ -30,3 +33,10 @@ func platformSpecificDeviceInfo(fi os.FileInfo) fs.DeviceInfo {
 
 	return oi
 }
+
+// platformSpecificReparsePoint returns the entry type unchanged, reparse points only exist on Windows.
+//
+//nolint:revive
+func platformSpecificReparsePoint(fi os.FileInfo, fullPath string, mode os.FileMode) (os.FileMode, string) {
+	return mode, ""
+}
@@ -66,7 +66,7 @@ func (it *filesystemDirectoryIterator) Close() {
 func (fsd *filesystemDirectory) Iterate(ctx context.Context) (fs.DirectoryIterator, error) {
 	fullPath := fsd.fullPath()
 
-	f, direrr := os.Open(fullPath) //nolint:gosec
+	f, direrr := openForReading(fullPath)
 	if direrr != nil {
 		return nil, errors.Wrap(direrr, "unable to read directory")
 	}
@@ -137,25 +137,35 @@ func NewEntry(path string) (fs.Entry, error) {
 func entryFromDirEntry(fi os.FileInfo, prefix string) fs.Entry {
 	isplaceholder := strings.HasSuffix(fi.Name(), ShallowEntrySuffix)
 	maskedmode := fi.Mode() & os.ModeType
+	linkType := ""
+
+	if !isplaceholder {
+		// depending on their kind and Go version, reparse points can appear as symbolic links, directories
+		// or irregular files, classify them explicitly to avoid traversing junctions and mount points.
+		maskedmode, linkType = platformSpecificReparsePoint(fi, prefix+fi.Name(), maskedmode)
+	}
+
+	e := newEntry(fi, prefix)
+	e.mode = e.mode&^os.ModeType | maskedmode
 
 	switch {
 	case maskedmode == os.ModeDir && !isplaceholder:
-		return newFilesystemDirectory(newEntry(fi, prefix))
+		return newFilesystemDirectory(e)
 
 	case maskedmode == os.ModeDir && isplaceholder:
-		return newShallowFilesystemDirectory(newEntry(fi, prefix))
+		return newShallowFilesystemDirectory(e)
 
 	case maskedmode == os.ModeSymlink && !isplaceholder:
-		return newFilesystemSymlink(newEntry(fi, prefix))
+		return newFilesystemSymlink(e, linkType)
 
 	case maskedmode == 0 && !isplaceholder:
-		return newFilesystemFile(newEntry(fi, prefix))
+		return newFilesystemFile(e)
 
 	case maskedmode == 0 && isplaceholder:
-		return newShallowFilesystemFile(newEntry(fi, prefix))
+		return newShallowFilesystemFile(e)
 
 	default:
-		return newFilesystemErrorEntry(newEntry(fi, prefix), fs.ErrUnknown)
+		return newFilesystemErrorEntry(e, fs.ErrUnknown)
 	}
 }
 
@@ -166,6 +176,7 @@ func newEntry(fi os.FileInfo, prefix string) filesystemEntry {
 		TrimShallowSuffix(fi.Name()),
 		fi.Size(),
 		fi.ModTime().UnixNano(),
+		platformSpecificChangeTimeNanos(fi),
 		fi.Mode(),
 		platformSpecificOwnerInfo(fi),
 		platformSpecificDeviceInfo(fi),
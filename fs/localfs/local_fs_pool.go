@@ -34,9 +34,10 @@ func (fsd *filesystemDirectory) Close() {
 	filesystemDirectoryPool.Return(fsd)
 }
 
-func newFilesystemSymlink(e filesystemEntry) *filesystemSymlink {
+func newFilesystemSymlink(e filesystemEntry, linkType string) *filesystemSymlink {
 	fsd := filesystemSymlinkPool.Take()
 	fsd.filesystemEntry = e
+	fsd.linkType = linkType
 
 	return fsd
 }
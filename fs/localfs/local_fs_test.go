@@ -7,6 +7,7 @@
 	"path/filepath"
 	"runtime"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -301,3 +302,26 @@ func assertNoError(t *testing.T, err error) {
 		t.Errorf("err: %v", err)
 	}
 }
+
+func TestChangeTime(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("change time is not available on Windows")
+	}
+
+	fname := filepath.Join(testutil.TempDirectory(t), "f")
+	require.NoError(t, os.WriteFile(fname, []byte{1, 2, 3}, 0o600))
+
+	e, err := NewEntry(fname)
+	require.NoError(t, err)
+
+	defer e.Close()
+
+	st, err := os.Stat(fname)
+	require.NoError(t, err)
+
+	ct := fs.ChangeTime(e)
+	require.False(t, ct.IsZero())
+
+	// the file was just created, so its ctime and mtime are close to each other.
+	require.WithinDuration(t, st.ModTime(), ct, time.Minute)
+}
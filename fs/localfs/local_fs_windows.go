@@ -2,10 +2,17 @@
 
 import (
 	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
 
 	"github.com/kopia/kopia/fs"
 )
 
+// reparseTagNameSurrogate is set in tags of reparse points which refer to another named entity, such as links.
+const reparseTagNameSurrogate = 0x20000000
+
 //nolint:revive
 func platformSpecificOwnerInfo(fi os.FileInfo) fs.OwnerInfo {
 	return fs.OwnerInfo{}
@@ -15,3 +22,72 @@ func platformSpecificOwnerInfo(fi os.FileInfo) fs.OwnerInfo {
 func platformSpecificDeviceInfo(fi os.FileInfo) fs.DeviceInfo {
 	return fs.DeviceInfo{}
 }
+
+// platformSpecificReparsePoint classifies reparse points based on their tags:
+//
+//   - symbolic links are returned as such,
+//   - junctions and volume mount points are returned as symbolic links with the appropriate link type,
+//     so that they are stored as links and never traversed,
+//   - other name surrogates are reported as irregular (unsupported) entries,
+//   - remaining reparse points (deduplicated, cloud, compressed files, etc.) are accessed like regular
+//     files and directories.
+func platformSpecificReparsePoint(fi os.FileInfo, fullPath string, mode os.FileMode) (os.FileMode, string) {
+	attrs, ok := fi.Sys().(*syscall.Win32FileAttributeData)
+	if !ok || attrs.FileAttributes&windows.FILE_ATTRIBUTE_REPARSE_POINT == 0 {
+		return mode, ""
+	}
+
+	tag, err := reparseTag(fullPath)
+	if err != nil {
+		return mode, ""
+	}
+
+	switch {
+	case tag == windows.IO_REPARSE_TAG_SYMLINK:
+		return os.ModeSymlink, ""
+
+	case tag == windows.IO_REPARSE_TAG_MOUNT_POINT:
+		target, err := os.Readlink(fullPath)
+		if err == nil && strings.HasPrefix(target, `\\?\Volume{`) {
+			return os.ModeSymlink, fs.LinkTypeMountPoint
+		}
+
+		return os.ModeSymlink, fs.LinkTypeJunction
+
+	case tag&reparseTagNameSurrogate != 0:
+		return os.ModeIrregular, ""
+
+	case attrs.FileAttributes&windows.FILE_ATTRIBUTE_DIRECTORY != 0:
+		return os.ModeDir, ""
+
+	default:
+		return 0, ""
+	}
+}
+
+// reparseTag returns the reparse tag of the provided path, which must be a reparse point.
+func reparseTag(fullPath string) (uint32, error) {
+	p, err := windows.UTF16PtrFromString(fullPath)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	var fd windows.Win32finddata
+
+	h, err := windows.FindFirstFile(p, &fd)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	windows.FindClose(h) //nolint:errcheck
+
+	// for reparse points Reserved0 holds the reparse tag.
+	return fd.Reserved0, nil
+}
+
+// platformSpecificChangeTimeNanos returns 0, because change time is not reported by os.Lstat() on Windows.
+//
+//nolint:revive
+func platformSpecificChangeTimeNanos(fi os.FileInfo) int64 {
+	return 0
+}
@@ -0,0 +1,32 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// MacOSMetadata describes metadata specific to macOS filesystems.
+type MacOSMetadata struct {
+	BirthTime        time.Time // creation time of the entry
+	Flags            uint32    // BSD file flags (see chflags(2)), which include Finder 'hidden' flag
+	FinderInfo       []byte    // contents of 'com.apple.FinderInfo' extended attribute (Finder flags, type and creator codes)
+	Quarantine       string    // contents of 'com.apple.quarantine' extended attribute
+	ResourceForkSize int64     // length of the resource fork, 0 if the entry does not have one
+}
+
+// IsEmpty returns true if the metadata does not carry any information.
+func (m *MacOSMetadata) IsEmpty() bool {
+	return m == nil || (m.BirthTime.IsZero() && m.Flags == 0 && len(m.FinderInfo) == 0 && m.Quarantine == "" && m.ResourceForkSize == 0)
+}
+
+// EntryWithMacOSMetadata is optionally implemented by entries which can provide macOS-specific metadata.
+type EntryWithMacOSMetadata interface {
+	Entry
+
+	// MacOSMetadata returns macOS-specific metadata of the entry or nil if it's not available.
+	MacOSMetadata(ctx context.Context) (*MacOSMetadata, error)
+
+	// OpenResourceFork opens the resource fork of the entry for reading.
+	OpenResourceFork(ctx context.Context) (io.ReadCloser, error)
+}
@@ -0,0 +1,113 @@
+package resticfs
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/poly1305" //nolint:staticcheck
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	ivSize         = aes.BlockSize
+	macSize        = poly1305.TagSize
+	aesKeySize     = 32
+	macKeySizeK    = 16
+	macKeySizeR    = 16
+	ciphertextSize = ivSize + macSize
+)
+
+// ErrInvalidMAC is returned when the authentication code of the ciphertext does not match.
+var ErrInvalidMAC = errors.New("ciphertext verification failed")
+
+// keyFile is the JSON contents of a file in the keys/ directory of a restic repository.
+type keyFile struct {
+	KDF  string `json:"kdf"`
+	N    int    `json:"N"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+	Data []byte `json:"data"`
+}
+
+// cryptoKey holds the AES-256 encryption key and the Poly1305-AES authentication key.
+type cryptoKey struct {
+	MAC struct {
+		K []byte `json:"k"`
+		R []byte `json:"r"`
+	} `json:"mac"`
+	Encrypt []byte `json:"encrypt"`
+}
+
+func (k *cryptoKey) valid() bool {
+	return len(k.Encrypt) == aesKeySize && len(k.MAC.K) == macKeySizeK && len(k.MAC.R) == macKeySizeR
+}
+
+// userKeyFromPassword derives the key protecting the master key stored in the provided key file.
+func userKeyFromPassword(kf *keyFile, password string) (*cryptoKey, error) {
+	if kf.KDF != "scrypt" {
+		return nil, errors.Errorf("unsupported key derivation function: %v", kf.KDF)
+	}
+
+	derived, err := scrypt.Key([]byte(password), kf.Salt, kf.N, kf.R, kf.P, aesKeySize+macKeySizeK+macKeySizeR)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to derive key")
+	}
+
+	k := &cryptoKey{}
+	k.Encrypt = derived[0:aesKeySize]
+	k.MAC.K = derived[aesKeySize : aesKeySize+macKeySizeK]
+	k.MAC.R = derived[aesKeySize+macKeySizeK:]
+
+	return k, nil
+}
+
+func (k *cryptoKey) mac(iv, data []byte) ([]byte, error) {
+	c, err := aes.NewCipher(k.MAC.K)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create cipher")
+	}
+
+	var (
+		polyKey [32]byte
+		out     [macSize]byte
+	)
+
+	copy(polyKey[0:macKeySizeR], k.MAC.R)
+	c.Encrypt(polyKey[macKeySizeR:], iv)
+
+	poly1305.Sum(&out, data, &polyKey)
+
+	return out[:], nil
+}
+
+// decrypt verifies and decrypts data stored as IV || AES-256-CTR ciphertext || Poly1305-AES MAC.
+func (k *cryptoKey) decrypt(data []byte) ([]byte, error) {
+	if len(data) < ciphertextSize {
+		return nil, errors.Errorf("ciphertext too short")
+	}
+
+	iv := data[0:ivSize]
+	ct := data[ivSize : len(data)-macSize]
+
+	mac, err := k.mac(iv, ct)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(mac, data[len(data)-macSize:]) != 1 {
+		return nil, ErrInvalidMAC
+	}
+
+	block, err := aes.NewCipher(k.Encrypt)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create cipher")
+	}
+
+	plaintext := make([]byte, len(ct))
+	cipher.NewCTR(block, iv).XORKeyStream(plaintext, ct)
+
+	return plaintext, nil
+}
@@ -0,0 +1,300 @@
+package resticfs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/virtualfs"
+)
+
+const (
+	nodeTypeFile    = "file"
+	nodeTypeDir     = "dir"
+	nodeTypeSymlink = "symlink"
+
+	defaultDirMode = 0o755
+)
+
+// node is a single entry of a restic tree.
+type node struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Mode       os.FileMode `json:"mode,omitempty"`
+	ModTime    time.Time   `json:"mtime,omitempty"`
+	UID        uint32      `json:"uid"`
+	GID        uint32      `json:"gid"`
+	User       string      `json:"user,omitempty"`
+	Group      string      `json:"group,omitempty"`
+	DeviceID   uint64      `json:"device_id,omitempty"`
+	Size       uint64      `json:"size,omitempty"`
+	LinkTarget string      `json:"linktarget,omitempty"`
+	Content    []string    `json:"content"`
+	Subtree    string      `json:"subtree,omitempty"`
+}
+
+type tree struct {
+	Nodes []*node `json:"nodes"`
+}
+
+type entry struct {
+	rep  *Repository
+	node *node
+}
+
+func (e *entry) Name() string {
+	return e.node.Name
+}
+
+func (e *entry) IsDir() bool {
+	return e.node.Type == nodeTypeDir
+}
+
+func (e *entry) Mode() os.FileMode {
+	m := e.node.Mode
+
+	switch e.node.Type {
+	case nodeTypeDir:
+		m |= os.ModeDir
+	case nodeTypeSymlink:
+		m |= os.ModeSymlink
+	}
+
+	return m
+}
+
+func (e *entry) ModTime() time.Time {
+	return e.node.ModTime
+}
+
+func (e *entry) Size() int64 {
+	return int64(e.node.Size)
+}
+
+func (e *entry) Sys() interface{} {
+	return nil
+}
+
+func (e *entry) Owner() fs.OwnerInfo {
+	return fs.OwnerInfo{
+		UserID:    e.node.UID,
+		GroupID:   e.node.GID,
+		UserName:  e.node.User,
+		GroupName: e.node.Group,
+	}
+}
+
+func (e *entry) Device() fs.DeviceInfo {
+	return fs.DeviceInfo{Dev: e.node.DeviceID}
+}
+
+func (e *entry) LocalFilesystemPath() string {
+	return ""
+}
+
+func (e *entry) Close() {
+}
+
+type resticDirectory struct {
+	entry
+}
+
+func (d *resticDirectory) entries(ctx context.Context) ([]fs.Entry, error) {
+	b, err := d.rep.loadBlob(blobTypeTree, d.node.Subtree)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to load tree of %v", d.node.Name)
+	}
+
+	var t tree
+
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, errors.Wrapf(err, "unable to parse tree of %v", d.node.Name)
+	}
+
+	var result []fs.Entry
+
+	for _, n := range t.Nodes {
+		if e := d.rep.newEntry(n); e != nil {
+			result = append(result, e)
+		} else {
+			log(ctx).Debugf("skipping unsupported entry %v of type %v", n.Name, n.Type)
+		}
+	}
+
+	fs.Sort(result)
+
+	return result, nil
+}
+
+func (d *resticDirectory) Child(ctx context.Context, name string) (fs.Entry, error) {
+	entries, err := d.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if e := fs.FindByName(entries, name); e != nil {
+		return e, nil
+	}
+
+	return nil, fs.ErrEntryNotFound
+}
+
+func (d *resticDirectory) Iterate(ctx context.Context) (fs.DirectoryIterator, error) {
+	entries, err := d.entries(ctx)
+
+	return fs.StaticIterator(entries, err), nil
+}
+
+func (d *resticDirectory) SupportsMultipleIterations() bool {
+	return true
+}
+
+type resticFile struct {
+	entry
+}
+
+func (f *resticFile) Open(ctx context.Context) (fs.Reader, error) {
+	r := &fileReader{
+		f:      f,
+		curIdx: -1,
+	}
+
+	var offset int64
+
+	for _, id := range f.node.Content {
+		loc, err := f.rep.blobLocation(blobTypeData, id)
+		if err != nil {
+			return nil, err
+		}
+
+		r.offsets = append(r.offsets, offset)
+		offset += loc.plaintextLength()
+	}
+
+	r.length = offset
+
+	return r, nil
+}
+
+type resticSymlink struct {
+	entry
+}
+
+func (s *resticSymlink) Readlink(ctx context.Context) (string, error) {
+	return s.node.LinkTarget, nil
+}
+
+func (r *Repository) newEntry(n *node) fs.Entry {
+	switch n.Type {
+	case nodeTypeDir:
+		return &resticDirectory{entry{r, n}}
+	case nodeTypeFile:
+		return &resticFile{entry{r, n}}
+	case nodeTypeSymlink:
+		return &resticSymlink{entry{r, n}}
+	default:
+		return nil
+	}
+}
+
+// fileReader reads file contents by loading data blobs on demand.
+type fileReader struct {
+	f *resticFile
+
+	// starting offset of each data blob
+	offsets []int64
+	length  int64
+	pos     int64
+
+	curIdx  int
+	curData []byte
+}
+
+func (r *fileReader) Read(p []byte) (int, error) {
+	if r.pos >= r.length {
+		return 0, io.EOF
+	}
+
+	// find the last blob starting at or before the current position.
+	idx := sort.Search(len(r.offsets), func(i int) bool {
+		return r.offsets[i] > r.pos
+	}) - 1
+
+	if idx != r.curIdx {
+		b, err := r.f.rep.loadBlob(blobTypeData, r.f.node.Content[idx])
+		if err != nil {
+			return 0, err
+		}
+
+		r.curIdx = idx
+		r.curData = b
+	}
+
+	n := copy(p, r.curData[r.pos-r.offsets[idx]:])
+	r.pos += int64(n)
+
+	return n, nil
+}
+
+func (r *fileReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.length
+	default:
+		return 0, errors.Errorf("invalid whence: %v", whence)
+	}
+
+	if offset < 0 {
+		return 0, errors.Errorf("invalid offset: %v", offset)
+	}
+
+	r.pos = offset
+
+	return offset, nil
+}
+
+func (r *fileReader) Close() error {
+	return nil
+}
+
+func (r *fileReader) Entry() (fs.Entry, error) {
+	return r.f, nil
+}
+
+// SnapshotRoot returns the root directory of the provided snapshot.
+func (r *Repository) SnapshotRoot(s *Snapshot) fs.Directory {
+	return &resticDirectory{entry{r, &node{
+		Name:    s.ShortID(),
+		Type:    nodeTypeDir,
+		Mode:    defaultDirMode,
+		ModTime: s.Time,
+		Subtree: s.Tree,
+	}}}
+}
+
+// AllSnapshots returns a directory containing root directories of all snapshots named after their short IDs.
+func (r *Repository) AllSnapshots(ctx context.Context) (fs.Directory, error) {
+	snaps, err := r.ListSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fs.Entry
+
+	for _, s := range snaps {
+		entries = append(entries, r.SnapshotRoot(s))
+	}
+
+	fs.Sort(entries)
+
+	return virtualfs.NewStaticDirectory("restic", entries), nil
+}
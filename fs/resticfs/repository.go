@@ -0,0 +1,417 @@
+// Package resticfs provides experimental read-only access to restic repositories, exposing snapshots as fs entries,
+// which allows them to be browsed, mounted and restored using kopia.
+package resticfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/logging"
+)
+
+var log = logging.Module("kopia/resticfs")
+
+const (
+	configFileName  = "config"
+	keysDirName     = "keys"
+	snapshotsDir    = "snapshots"
+	indexDirName    = "index"
+	dataDirName     = "data"
+	unpackedVersion = 2
+
+	blobTypeData = "data"
+	blobTypeTree = "tree"
+)
+
+// ErrSnapshotNotFound is returned when the snapshot can't be found.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Config is the configuration of the restic repository.
+type Config struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+}
+
+// Snapshot describes a single restic snapshot.
+type Snapshot struct {
+	ID       string    `json:"-"`
+	Time     time.Time `json:"time"`
+	Tree     string    `json:"tree"`
+	Paths    []string  `json:"paths"`
+	Hostname string    `json:"hostname,omitempty"`
+	Username string    `json:"username,omitempty"`
+	Tags     []string  `json:"tags,omitempty"`
+}
+
+// ShortID returns the abbreviated snapshot ID as displayed by restic.
+func (s *Snapshot) ShortID() string {
+	const shortIDLength = 8
+
+	if len(s.ID) < shortIDLength {
+		return s.ID
+	}
+
+	return s.ID[0:shortIDLength]
+}
+
+type indexBlob struct {
+	ID                 string `json:"id"`
+	Type               string `json:"type"`
+	Offset             int64  `json:"offset"`
+	Length             int64  `json:"length"`
+	UncompressedLength int64  `json:"uncompressed_length,omitempty"`
+}
+
+type indexPack struct {
+	ID    string      `json:"id"`
+	Blobs []indexBlob `json:"blobs"`
+}
+
+type indexFile struct {
+	Packs []indexPack `json:"packs"`
+}
+
+type blobLocation struct {
+	packID             string
+	offset             int64
+	length             int64
+	uncompressedLength int64
+}
+
+// plaintextLength returns the length of the blob after decryption and decompression.
+func (l blobLocation) plaintextLength() int64 {
+	if l.uncompressedLength != 0 {
+		return l.uncompressedLength
+	}
+
+	return l.length - ciphertextSize
+}
+
+// Repository provides read-only access to a restic repository stored in a local directory.
+type Repository struct {
+	path   string
+	key    *cryptoKey
+	config Config
+
+	decoder *zstd.Decoder
+	blobs   map[string]blobLocation
+}
+
+// Config returns the repository configuration.
+func (r *Repository) Config() Config {
+	return r.config
+}
+
+// Close releases resources associated with the repository.
+func (r *Repository) Close() {
+	r.decoder.Close()
+}
+
+// Open opens the restic repository in the provided directory using the provided password.
+func Open(ctx context.Context, path, password string) (*Repository, error) {
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create decoder")
+	}
+
+	r := &Repository{
+		path:    path,
+		decoder: decoder,
+		blobs:   map[string]blobLocation{},
+	}
+
+	if err := r.open(ctx, password); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Repository) open(ctx context.Context, password string) error {
+	var err error
+
+	if r.key, err = r.findMasterKey(ctx, password); err != nil {
+		return err
+	}
+
+	if err := r.loadJSON(configFileName, &r.config); err != nil {
+		return errors.Wrap(err, "unable to read repository config")
+	}
+
+	if r.config.Version < 1 || r.config.Version > unpackedVersion {
+		return errors.Errorf("unsupported repository version: %v", r.config.Version)
+	}
+
+	return r.loadIndex(ctx)
+}
+
+func (r *Repository) findMasterKey(ctx context.Context, password string) (*cryptoKey, error) {
+	names, err := r.listFiles(keysDirName)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list keys")
+	}
+
+	for _, n := range names {
+		b, err := os.ReadFile(filepath.Join(r.path, keysDirName, n)) //nolint:gosec
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to read key %v", n)
+		}
+
+		var kf keyFile
+
+		if err := json.Unmarshal(b, &kf); err != nil {
+			log(ctx).Debugf("invalid key %v: %v", n, err)
+			continue
+		}
+
+		userKey, err := userKeyFromPassword(&kf, password)
+		if err != nil {
+			log(ctx).Debugf("unable to use key %v: %v", n, err)
+			continue
+		}
+
+		data, err := userKey.decrypt(kf.Data)
+		if err != nil {
+			// wrong password for this key
+			continue
+		}
+
+		mk := &cryptoKey{}
+		if err := json.Unmarshal(data, mk); err != nil || !mk.valid() {
+			return nil, errors.Errorf("invalid master key in %v", n)
+		}
+
+		return mk, nil
+	}
+
+	return nil, errors.New("invalid password or no repository keys found")
+}
+
+// listFiles returns names of files in the provided repository directory.
+func (r *Repository) listFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(r.path, dir))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read directory")
+	}
+
+	var result []string
+
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			result = append(result, e.Name())
+		}
+	}
+
+	return result, nil
+}
+
+// loadJSON reads, decrypts and parses a file stored outside of pack files.
+func (r *Repository) loadJSON(name string, v any) error {
+	b, err := os.ReadFile(filepath.Join(r.path, name)) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to read file")
+	}
+
+	if name != configFileName {
+		// all files except config are named after the hash of their contents.
+		if err := verifyHash(b, filepath.Base(name)); err != nil {
+			return err
+		}
+	}
+
+	plaintext, err := r.key.decrypt(b)
+	if err != nil {
+		return errors.Wrap(err, "unable to decrypt file")
+	}
+
+	plaintext, err = r.decompressUnpacked(plaintext)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(json.Unmarshal(plaintext, v), "unable to parse file")
+}
+
+// decompressUnpacked handles files written by repository format version 2, which start with a version byte
+// followed by zstd-compressed data. Uncompressed JSON files start with '{' or '['.
+func (r *Repository) decompressUnpacked(b []byte) ([]byte, error) {
+	if len(b) == 0 || b[0] == '{' || b[0] == '[' {
+		return b, nil
+	}
+
+	if b[0] != unpackedVersion {
+		return nil, errors.Errorf("unsupported file format version: %v", b[0])
+	}
+
+	out, err := r.decoder.DecodeAll(b[1:], nil)
+
+	return out, errors.Wrap(err, "unable to decompress file")
+}
+
+func (r *Repository) loadIndex(ctx context.Context) error {
+	names, err := r.listFiles(indexDirName)
+	if err != nil {
+		return errors.Wrap(err, "unable to list index files")
+	}
+
+	for _, n := range names {
+		var idx indexFile
+
+		if err := r.loadJSON(filepath.Join(indexDirName, n), &idx); err != nil {
+			return errors.Wrapf(err, "unable to load index %v", n)
+		}
+
+		for _, p := range idx.Packs {
+			for _, b := range p.Blobs {
+				r.blobs[b.Type+":"+b.ID] = blobLocation{
+					packID:             p.ID,
+					offset:             b.Offset,
+					length:             b.Length,
+					uncompressedLength: b.UncompressedLength,
+				}
+			}
+		}
+	}
+
+	log(ctx).Debugf("loaded %v index files with %v blobs", len(names), len(r.blobs))
+
+	return nil
+}
+
+func (r *Repository) blobLocation(blobType, id string) (blobLocation, error) {
+	loc, ok := r.blobs[blobType+":"+id]
+	if !ok {
+		return blobLocation{}, errors.Errorf("%v blob %v not found in index", blobType, id)
+	}
+
+	return loc, nil
+}
+
+// loadBlob reads, decrypts, decompresses and verifies the blob with the provided type and ID.
+func (r *Repository) loadBlob(blobType, id string) ([]byte, error) {
+	loc, err := r.blobLocation(blobType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(loc.packID) < 2 { //nolint:gomnd
+		return nil, errors.Errorf("invalid pack ID: %q", loc.packID)
+	}
+
+	f, err := os.Open(filepath.Join(r.path, dataDirName, loc.packID[0:2], loc.packID)) //nolint:gosec
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open pack")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	b := make([]byte, loc.length)
+
+	if _, err := f.ReadAt(b, loc.offset); err != nil {
+		return nil, errors.Wrapf(err, "unable to read blob %v from pack %v", id, loc.packID)
+	}
+
+	plaintext, err := r.key.decrypt(b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to decrypt blob %v", id)
+	}
+
+	if loc.uncompressedLength != 0 {
+		plaintext, err = r.decoder.DecodeAll(plaintext, make([]byte, 0, loc.uncompressedLength))
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to decompress blob %v", id)
+		}
+	}
+
+	if err := verifyHash(plaintext, id); err != nil {
+		return nil, errors.Wrapf(err, "invalid blob %v", id)
+	}
+
+	return plaintext, nil
+}
+
+func verifyHash(b []byte, id string) error {
+	h := sha256.Sum256(b)
+
+	if hex.EncodeToString(h[:]) != id {
+		return errors.Errorf("hash mismatch")
+	}
+
+	return nil
+}
+
+// ListSnapshots returns all snapshots in the repository ordered by time.
+func (r *Repository) ListSnapshots(ctx context.Context) ([]*Snapshot, error) {
+	names, err := r.listFiles(snapshotsDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list snapshots")
+	}
+
+	var result []*Snapshot
+
+	for _, n := range names {
+		s := &Snapshot{}
+
+		if err := r.loadJSON(filepath.Join(snapshotsDir, n), s); err != nil {
+			log(ctx).Errorf("unable to load snapshot %v: %v", n, err)
+			continue
+		}
+
+		s.ID = n
+
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+
+	return result, nil
+}
+
+// FindSnapshot returns the snapshot with the provided ID, unique ID prefix or "latest".
+func (r *Repository) FindSnapshot(ctx context.Context, idOrPrefix string) (*Snapshot, error) {
+	snaps, err := r.ListSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if idOrPrefix == "latest" {
+		if len(snaps) == 0 {
+			return nil, ErrSnapshotNotFound
+		}
+
+		return snaps[len(snaps)-1], nil
+	}
+
+	var found *Snapshot
+
+	for _, s := range snaps {
+		if !strings.HasPrefix(s.ID, idOrPrefix) {
+			continue
+		}
+
+		if found != nil {
+			return nil, errors.Errorf("ambiguous snapshot ID: %v", idOrPrefix)
+		}
+
+		found = s
+	}
+
+	if found == nil {
+		return nil, ErrSnapshotNotFound
+	}
+
+	return found, nil
+}
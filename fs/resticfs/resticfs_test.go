@@ -0,0 +1,380 @@
+package resticfs_test
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/poly1305" //nolint:staticcheck
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/resticfs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/snapshot/restore"
+)
+
+const testPassword = "s3cr3t"
+
+// testRepo writes a minimal restic repository using format version 2.
+type testRepo struct {
+	t    *testing.T
+	path string
+
+	encKey, macK, macR []byte
+
+	pack      bytes.Buffer
+	packBlobs []map[string]any
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+
+	return b
+}
+
+func hashOf(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+func encrypt(t *testing.T, encKey, macK, macR, plaintext []byte) []byte {
+	t.Helper()
+
+	iv := randomBytes(t, aes.BlockSize)
+
+	block, err := aes.NewCipher(encKey)
+	require.NoError(t, err)
+
+	ct := make([]byte, len(plaintext))
+	cipher.NewCTR(block, iv).XORKeyStream(ct, plaintext)
+
+	macCipher, err := aes.NewCipher(macK)
+	require.NoError(t, err)
+
+	var (
+		polyKey [32]byte
+		mac     [16]byte
+	)
+
+	copy(polyKey[0:16], macR)
+	macCipher.Encrypt(polyKey[16:], iv)
+	poly1305.Sum(&mac, ct, &polyKey)
+
+	return append(append(iv, ct...), mac[:]...)
+}
+
+func compress(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	enc, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	defer enc.Close()
+
+	return enc.EncodeAll(b, nil)
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+
+	r := &testRepo{
+		t:      t,
+		path:   testutil.TempDirectory(t),
+		encKey: randomBytes(t, 32),
+		macK:   randomBytes(t, 16),
+		macR:   randomBytes(t, 16),
+	}
+
+	for _, d := range []string{"keys", "snapshots", "index", "data", "locks"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(r.path, d), 0o700))
+	}
+
+	// key file protecting the master key with the password.
+	const scryptN, scryptR, scryptP = 1024, 8, 1
+
+	salt := randomBytes(t, 64)
+	derived, err := scrypt.Key([]byte(testPassword), salt, scryptN, scryptR, scryptP, 64)
+	require.NoError(t, err)
+
+	masterKey, err := json.Marshal(map[string]any{
+		"mac":     map[string]any{"k": r.macK, "r": r.macR},
+		"encrypt": r.encKey,
+	})
+	require.NoError(t, err)
+
+	keyFile, err := json.Marshal(map[string]any{
+		"created":  time.Now(),
+		"username": "user",
+		"hostname": "host",
+		"kdf":      "scrypt",
+		"N":        scryptN,
+		"r":        scryptR,
+		"p":        scryptP,
+		"salt":     salt,
+		"data":     encrypt(t, derived[0:32], derived[32:48], derived[48:64], masterKey),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(r.path, "keys", hashOf(keyFile)), keyFile, 0o600))
+
+	r.writeUnpacked("config", map[string]any{"version": 2, "id": hashOf(salt), "chunker_polynomial": "3ba7c5ab0ca6e3"}, true)
+
+	return r
+}
+
+func (r *testRepo) writeUnpacked(dir string, v any, compressed bool) string {
+	b, err := json.Marshal(v)
+	require.NoError(r.t, err)
+
+	if compressed {
+		b = append([]byte{2}, compress(r.t, b)...)
+	}
+
+	data := encrypt(r.t, r.encKey, r.macK, r.macR, b)
+	name := hashOf(data)
+
+	if dir == "config" {
+		require.NoError(r.t, os.WriteFile(filepath.Join(r.path, "config"), data, 0o600))
+		return ""
+	}
+
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.path, dir, name), data, 0o600))
+
+	return name
+}
+
+func (r *testRepo) addBlob(blobType string, plaintext []byte, compressed bool) string {
+	id := hashOf(plaintext)
+	info := map[string]any{"id": id, "type": blobType, "offset": r.pack.Len()}
+
+	payload := plaintext
+	if compressed {
+		payload = compress(r.t, plaintext)
+		info["uncompressed_length"] = len(plaintext)
+	}
+
+	data := encrypt(r.t, r.encKey, r.macK, r.macR, payload)
+	info["length"] = len(data)
+
+	r.pack.Write(data)
+	r.packBlobs = append(r.packBlobs, info)
+
+	return id
+}
+
+func (r *testRepo) addTree(nodes ...map[string]any) string {
+	b, err := json.Marshal(map[string]any{"nodes": nodes})
+	require.NoError(r.t, err)
+
+	return r.addBlob("tree", append(b, '\n'), true)
+}
+
+func (r *testRepo) finish() {
+	packID := hashOf(r.pack.Bytes())
+
+	require.NoError(r.t, os.MkdirAll(filepath.Join(r.path, "data", packID[0:2]), 0o700))
+	require.NoError(r.t, os.WriteFile(filepath.Join(r.path, "data", packID[0:2], packID), r.pack.Bytes(), 0o600))
+
+	r.writeUnpacked("index", map[string]any{
+		"packs": []any{map[string]any{"id": packID, "blobs": r.packBlobs}},
+	}, false)
+}
+
+func TestResticRepository(t *testing.T) {
+	ctx := testlogging.Context(t)
+	tr := newTestRepo(t)
+
+	mtime := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	part1 := bytes.Repeat([]byte("compressible "), 1000)
+	part2 := randomBytes(t, 5000)
+
+	subTree := tr.addTree(map[string]any{
+		"name": "nested.txt", "type": "file", "mode": 0o600, "mtime": mtime, "size": 5,
+		"content": []string{tr.addBlob("data", []byte("hello"), false)},
+	})
+
+	rootTree := tr.addTree(
+		map[string]any{
+			"name": "file.bin", "type": "file", "mode": 0o644, "mtime": mtime, "size": len(part1) + len(part2),
+			"uid": 1000, "gid": 1000, "user": "alice", "group": "users",
+			"content": []string{tr.addBlob("data", part1, true), tr.addBlob("data", part2, false)},
+		},
+		map[string]any{
+			"name": "empty", "type": "file", "mode": 0o644, "mtime": mtime, "content": nil,
+		},
+		map[string]any{
+			"name": "sub", "type": "dir", "mode": uint32(os.ModeDir | 0o750), "mtime": mtime, "subtree": subTree,
+		},
+		map[string]any{
+			"name": "link", "type": "symlink", "mode": uint32(os.ModeSymlink | 0o777), "mtime": mtime, "linktarget": "file.bin",
+		},
+		map[string]any{
+			"name": "fifo", "type": "fifo", "mode": uint32(os.ModeNamedPipe | 0o644), "mtime": mtime,
+		},
+	)
+
+	tr.finish()
+
+	snap1 := tr.writeUnpacked("snapshots", map[string]any{
+		"time": mtime, "tree": rootTree, "paths": []string{"/data"}, "hostname": "host", "username": "alice",
+	}, true)
+	snap2 := tr.writeUnpacked("snapshots", map[string]any{
+		"time": mtime.Add(time.Hour), "tree": subTree, "paths": []string{"/data/sub"}, "hostname": "host",
+	}, true)
+
+	_, err := resticfs.Open(ctx, tr.path, "wrong-password")
+	require.ErrorContains(t, err, "invalid password")
+
+	rep, err := resticfs.Open(ctx, tr.path, testPassword)
+	require.NoError(t, err)
+
+	defer rep.Close()
+
+	require.Equal(t, 2, rep.Config().Version)
+
+	snaps, err := rep.ListSnapshots(ctx)
+	require.NoError(t, err)
+	require.Len(t, snaps, 2)
+	require.Equal(t, snap1, snaps[0].ID)
+	require.Equal(t, []string{"/data"}, snaps[0].Paths)
+
+	latest, err := rep.FindSnapshot(ctx, "latest")
+	require.NoError(t, err)
+	require.Equal(t, snap2, latest.ID)
+
+	s, err := rep.FindSnapshot(ctx, snap1[0:8])
+	require.NoError(t, err)
+	require.Equal(t, snap1, s.ID)
+
+	_, err = rep.FindSnapshot(ctx, "zzz")
+	require.ErrorIs(t, err, resticfs.ErrSnapshotNotFound)
+
+	root := rep.SnapshotRoot(s)
+
+	entries, err := fs.GetAllEntries(ctx, root)
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	// unsupported entry types are skipped
+	require.Equal(t, []string{"empty", "file.bin", "link", "sub"}, names)
+
+	fe, err := root.Child(ctx, "file.bin")
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o644), fe.Mode())
+	require.Equal(t, mtime, fe.ModTime().UTC())
+	require.Equal(t, fs.OwnerInfo{UserID: 1000, GroupID: 1000, UserName: "alice", GroupName: "users"}, fe.Owner())
+
+	f, ok := fe.(fs.File)
+	require.True(t, ok)
+
+	rd, err := f.Open(ctx)
+	require.NoError(t, err)
+
+	data, err := io.ReadAll(rd)
+	require.NoError(t, err)
+	require.Equal(t, append(append([]byte(nil), part1...), part2...), data)
+
+	// seek into the second blob and back into the first one
+	_, err = rd.Seek(int64(len(part1)+10), io.SeekStart)
+	require.NoError(t, err)
+
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(rd, buf)
+	require.NoError(t, err)
+	require.Equal(t, part2[10:15], buf)
+
+	_, err = rd.Seek(-int64(len(part2))-3, io.SeekEnd)
+	require.NoError(t, err)
+
+	buf = make([]byte, 6)
+	_, err = io.ReadFull(rd, buf)
+	require.NoError(t, err)
+	require.Equal(t, append(append([]byte(nil), part1[len(part1)-3:]...), part2[0:3]...), buf)
+	require.NoError(t, rd.Close())
+
+	_, err = root.Child(ctx, "no-such-file")
+	require.ErrorIs(t, err, fs.ErrEntryNotFound)
+
+	all, err := rep.AllSnapshots(ctx)
+	require.NoError(t, err)
+
+	allEntries, err := fs.GetAllEntries(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, allEntries, 2)
+
+	// restore the snapshot using kopia restore
+	target := testutil.TempDirectory(t)
+	out := &restore.FilesystemOutput{TargetPath: target, SkipOwners: true}
+
+	require.NoError(t, out.Init(ctx))
+
+	st, err := restore.Entry(ctx, nil, out, root, restore.Options{RestoreDirEntryAtDepth: math.MaxInt32})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), st.RestoredFileCount)
+	require.Equal(t, int32(1), st.RestoredSymlinkCount)
+
+	restored, err := os.ReadFile(filepath.Join(target, "sub", "nested.txt"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("hello"), restored)
+
+	lt, err := os.Readlink(filepath.Join(target, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "file.bin", lt)
+}
+
+func TestResticRepository_CorruptedPack(t *testing.T) {
+	ctx := testlogging.Context(t)
+	tr := newTestRepo(t)
+
+	rootTree := tr.addTree(map[string]any{
+		"name": "f", "type": "file", "mode": 0o644, "size": 5,
+		"content": []string{tr.addBlob("data", []byte("hello"), false)},
+	})
+
+	// corrupt the data blob
+	tr.pack.Bytes()[20] ^= 1
+
+	tr.finish()
+	tr.writeUnpacked("snapshots", map[string]any{"time": time.Now(), "tree": rootTree, "paths": []string{"/"}}, false)
+
+	rep, err := resticfs.Open(ctx, tr.path, testPassword)
+	require.NoError(t, err)
+
+	defer rep.Close()
+
+	s, err := rep.FindSnapshot(ctx, "latest")
+	require.NoError(t, err)
+
+	fe, err := rep.SnapshotRoot(s).Child(ctx, "f")
+	require.NoError(t, err)
+
+	rd, err := fe.(fs.File).Open(ctx)
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(rd)
+	require.ErrorIs(t, err, resticfs.ErrInvalidMAC)
+}
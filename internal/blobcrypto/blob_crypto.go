@@ -81,3 +81,27 @@ func Decrypt(c Crypter, payload gather.Bytes, blobID blob.ID, output *gather.Wri
 
 	return nil
 }
+
+// Reencrypt decrypts the blob payload using one crypter and encrypts it using another one, the blob ID
+// remains the same as long as both crypters use the same hash function.
+func Reencrypt(from, to Crypter, payload gather.Bytes, blobID blob.ID, output *gather.WriteBuffer) error {
+	var plainText gather.WriteBuffer
+	defer plainText.Close()
+
+	if err := Decrypt(from, payload, blobID, &plainText); err != nil {
+		return err
+	}
+
+	iv, err := getIndexBlobIV(blobID)
+	if err != nil {
+		return errors.Wrap(err, "unable to get index blob IV")
+	}
+
+	output.Reset()
+
+	if err := to.Encryptor().Encrypt(plainText.Bytes(), iv, output); err != nil {
+		return errors.Wrapf(err, "error encrypting BLOB %v", blobID)
+	}
+
+	return nil
+}
@@ -293,6 +293,14 @@ func (s *eventuallyConsistentStorage) ExtendBlobRetention(ctx context.Context, b
 	return s.realStorage.ExtendBlobRetention(ctx, b, opts)
 }
 
+func (s *eventuallyConsistentStorage) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	return s.realStorage.ListTemporaryFiles(ctx, callback)
+}
+
+func (s *eventuallyConsistentStorage) DeleteTemporaryFile(ctx context.Context, name string) error {
+	return s.realStorage.DeleteTemporaryFile(ctx, name)
+}
+
 // NewEventuallyConsistentStorage returns an eventually-consistent storage wrapper on top
 // of provided storage.
 func NewEventuallyConsistentStorage(st blob.Storage, listSettleTime time.Duration, timeNow func() time.Time) blob.Storage {
@@ -132,4 +132,14 @@ func (s *FaultyStorage) ExtendBlobRetention(ctx context.Context, b blob.ID, opts
 	return s.base.ExtendBlobRetention(ctx, b, opts)
 }
 
+// ListTemporaryFiles implements blob.Storage.
+func (s *FaultyStorage) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	return s.base.ListTemporaryFiles(ctx, callback)
+}
+
+// DeleteTemporaryFile implements blob.Storage.
+func (s *FaultyStorage) DeleteTemporaryFile(ctx context.Context, name string) error {
+	return s.base.DeleteTemporaryFile(ctx, name)
+}
+
 var _ blob.Storage = (*FaultyStorage)(nil)
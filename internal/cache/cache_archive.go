@@ -0,0 +1,238 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+)
+
+// archiveManifestName is the name of the first entry in cache archive, describing its contents.
+const archiveManifestName = "kopia-cache-archive.json"
+
+// ArchivableCaches are subdirectories of the cache directory which can be exported and imported.
+//
+//nolint:gochecknoglobals
+var ArchivableCaches = []string{"metadata", "indexes", "contents"}
+
+// ErrArchiveRepositoryMismatch is returned when importing cache archive exported from a different repository.
+var ErrArchiveRepositoryMismatch = errors.New("cache archive was exported from a different repository")
+
+// ArchiveManifest describes the contents of cache archive.
+type ArchiveManifest struct {
+	RepositoryID string    `json:"repositoryID"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Caches       []string  `json:"caches"`
+}
+
+// ArchiveStats describes the number and size of files in the archive.
+type ArchiveStats struct {
+	Files      int
+	TotalBytes int64
+	Skipped    int
+}
+
+// ExportArchive writes gzip-compressed tar archive of the provided subdirectories of the cache directory,
+// which can be imported on another machine connected to the same repository. Cache entries are
+// protected using a key derived from the repository, not from the machine, so they remain valid there.
+func ExportArchive(ctx context.Context, w io.Writer, cacheDir string, caches []string, repositoryID []byte, now time.Time) (ArchiveStats, error) {
+	var stats ArchiveStats
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestBytes, err := json.Marshal(ArchiveManifest{
+		RepositoryID: hex.EncodeToString(repositoryID),
+		CreatedAt:    now,
+		Caches:       caches,
+	})
+	if err != nil {
+		return stats, errors.Wrap(err, "unable to marshal archive manifest")
+	}
+
+	if err := writeTarEntry(tw, archiveManifestName, now, manifestBytes); err != nil {
+		return stats, err
+	}
+
+	for _, c := range caches {
+		root := filepath.Join(cacheDir, c)
+
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return err
+			}
+
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(cacheDir, p)
+			if err != nil {
+				return errors.Wrap(err, "unable to determine relative path")
+			}
+
+			data, err := os.ReadFile(p) //nolint:gosec
+			if err != nil {
+				// cache entries may be concurrently evicted.
+				stats.Skipped++
+				return nil //nolint:nilerr
+			}
+
+			stats.Files++
+			stats.TotalBytes += int64(len(data))
+
+			return writeTarEntry(tw, filepath.ToSlash(rel), now, data)
+		})
+		if err != nil {
+			return stats, errors.Wrapf(err, "error exporting %v cache", c)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return stats, errors.Wrap(err, "unable to close tar writer")
+	}
+
+	return stats, errors.Wrap(gz.Close(), "unable to close gzip writer")
+}
+
+func writeTarEntry(tw *tar.Writer, name string, modTime time.Time, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     int64(len(data)),
+		Mode:     0o600, //nolint:gomnd
+		ModTime:  modTime,
+	}); err != nil {
+		return errors.Wrapf(err, "unable to write header of %v", name)
+	}
+
+	_, err := tw.Write(data)
+
+	return errors.Wrapf(err, "unable to write %v", name)
+}
+
+// ImportArchive extracts the cache archive produced by ExportArchive into the provided cache directory,
+// entries which already exist in the cache are left intact. Unless ignoreRepositoryID is set,
+// the archive must have been exported from the repository with the provided ID.
+func ImportArchive(ctx context.Context, r io.Reader, cacheDir string, repositoryID []byte, ignoreRepositoryID bool) (ArchiveStats, error) {
+	var stats ArchiveStats
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return stats, errors.Wrap(err, "invalid cache archive")
+	}
+
+	defer gz.Close() //nolint:errcheck
+
+	tr := tar.NewReader(gz)
+
+	man, err := readArchiveManifest(tr)
+	if err != nil {
+		return stats, err
+	}
+
+	if !ignoreRepositoryID && man.RepositoryID != hex.EncodeToString(repositoryID) {
+		return stats, ErrArchiveRepositoryMismatch
+	}
+
+	for _, c := range man.Caches {
+		if !slices.Contains(ArchivableCaches, c) {
+			return stats, errors.Errorf("unsupported cache %q in archive", c)
+		}
+	}
+
+	for {
+		if ctx.Err() != nil {
+			return stats, errors.Wrap(ctx.Err(), "import canceled")
+		}
+
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return stats, nil
+		}
+
+		if err != nil {
+			return stats, errors.Wrap(err, "error reading cache archive")
+		}
+
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		target, err := archiveEntryPath(cacheDir, h.Name, man.Caches)
+		if err != nil {
+			return stats, err
+		}
+
+		if _, err := os.Stat(target); err == nil {
+			stats.Skipped++
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), DirMode); err != nil {
+			return stats, errors.Wrap(err, "unable to create cache directory")
+		}
+
+		if err := atomicfile.Write(target, io.LimitReader(tr, h.Size)); err != nil {
+			return stats, errors.Wrapf(err, "unable to write %v", target)
+		}
+
+		stats.Files++
+		stats.TotalBytes += h.Size
+	}
+}
+
+func readArchiveManifest(tr *tar.Reader) (*ArchiveManifest, error) {
+	h, err := tr.Next()
+	if err != nil || h.Name != archiveManifestName {
+		return nil, errors.Errorf("not a kopia cache archive")
+	}
+
+	var man ArchiveManifest
+
+	if err := json.NewDecoder(tr).Decode(&man); err != nil {
+		return nil, errors.Wrap(err, "invalid cache archive manifest")
+	}
+
+	return &man, nil
+}
+
+// archiveEntryPath returns the local path of the archive entry, making sure it's within one of the caches.
+func archiveEntryPath(cacheDir, name string, caches []string) (string, error) {
+	clean := path.Clean(name)
+
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", errors.Errorf("invalid cache archive entry %q", name)
+	}
+
+	top, _, _ := strings.Cut(clean, "/")
+
+	for _, c := range caches {
+		if top == c && clean != c {
+			return filepath.Join(cacheDir, filepath.FromSlash(clean)), nil
+		}
+	}
+
+	return "", errors.Errorf("unexpected cache archive entry %q", name)
+}
@@ -0,0 +1,89 @@
+package cache_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/cache"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestExportImportArchive(t *testing.T) {
+	ctx := testlogging.Context(t)
+	src := t.TempDir()
+	dst := t.TempDir()
+	repoID := []byte{1, 2, 3}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "metadata", "p1"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "metadata", "p1", "p123.f"), []byte("meta"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "contents"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "contents", "c1"), []byte("data"), 0o600))
+
+	var buf bytes.Buffer
+
+	stats, err := cache.ExportArchive(ctx, &buf, src, []string{"metadata", "indexes"}, repoID, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Files)
+
+	_, err = cache.ImportArchive(ctx, bytes.NewReader(buf.Bytes()), dst, []byte{4, 5, 6}, false)
+	require.ErrorIs(t, err, cache.ErrArchiveRepositoryMismatch)
+
+	stats, err = cache.ImportArchive(ctx, bytes.NewReader(buf.Bytes()), dst, repoID, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Files)
+
+	b, err := os.ReadFile(filepath.Join(dst, "metadata", "p1", "p123.f"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("meta"), b)
+
+	_, err = os.Stat(filepath.Join(dst, "contents"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestImportArchiveRejectsInvalidEntries(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	for _, name := range []string{"../evil", "metadata/../../evil", "/etc/evil", "other/file", "metadata"} {
+		_, err := cache.ImportArchive(ctx, bytes.NewReader(makeArchive(t, `{"caches":["metadata"]}`, name)), t.TempDir(), nil, true)
+		require.Error(t, err, name)
+	}
+
+	_, err := cache.ImportArchive(ctx, bytes.NewReader(makeArchive(t, `{"caches":[".."]}`, "../x")), t.TempDir(), nil, true)
+	require.ErrorContains(t, err, "unsupported cache")
+
+	_, err = cache.ImportArchive(ctx, bytes.NewReader([]byte("not an archive")), t.TempDir(), nil, true)
+	require.Error(t, err)
+}
+
+func makeArchive(t *testing.T, manifest, entryName string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range []struct {
+		name string
+		data string
+	}{
+		{"kopia-cache-archive.json", manifest},
+		{entryName, "payload"},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: e.name, Size: int64(len(e.data)), Mode: 0o600}))
+		_, err := tw.Write([]byte(e.data))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
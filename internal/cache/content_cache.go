@@ -18,6 +18,7 @@ type ContentCache interface {
 	Close(ctx context.Context)
 	GetContent(ctx context.Context, contentID string, blobID blob.ID, offset, length int64, output *gather.WriteBuffer) error
 	PrefetchBlob(ctx context.Context, blobID blob.ID) error
+	PrefetchContentRanges(ctx context.Context, blobID blob.ID, contents []ContentRange) error
 	CacheStorage() Storage
 }
 
@@ -1,6 +1,7 @@
 package cache_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -89,3 +90,70 @@ func TestContentCacheForData_Passthrough(t *testing.T) {
 	require.NoError(t, dataCache.GetContent(ctx, "key1", "blob1", 0, 5, &tmp))
 	require.Equal(t, []byte{1, 2, 3, 4, 5}, tmp.ToByteSlice())
 }
+
+type getBlobCounter struct {
+	blob.Storage
+
+	getBlobCalls int
+}
+
+func (c *getBlobCounter) GetBlob(ctx context.Context, blobID blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	c.getBlobCalls++
+
+	//nolint:wrapcheck
+	return c.Storage.GetBlob(ctx, blobID, offset, length, output)
+}
+
+func TestContentCacheForData_PrefetchContentRanges(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	underlyingData := blobtesting.DataMap{}
+	underlying := &getBlobCounter{Storage: blobtesting.NewMapStorage(underlyingData, nil, nil)}
+
+	cacheData := blobtesting.DataMap{}
+	cacheStorage := blobtesting.NewMapStorage(cacheData, nil, nil).(cache.Storage)
+
+	dataCache, err := cache.NewContentCache(ctx, underlying, cache.Options{
+		Storage:    cacheStorage,
+		HMACSecret: []byte{1, 2, 3, 4},
+		Sweep: cache.SweepSettings{
+			MaxSizeBytes: 1e6,
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	defer dataCache.Close(ctx)
+
+	blobData := make([]byte, 2*cache.DefaultMaxRangeGap)
+	for i := range blobData {
+		blobData[i] = byte(i)
+	}
+
+	require.NoError(t, underlying.PutBlob(ctx, "blob1", gather.FromSlice(blobData), blob.PutOptions{}))
+
+	// first three contents are close to each other and will be fetched using a single request,
+	// the last one is too far away.
+	ranges := []cache.ContentRange{
+		{ContentID: "xkey3", Offset: 300, Length: 50},
+		{ContentID: "xkey1", Offset: 0, Length: 100},
+		{ContentID: "xkey2", Offset: 150, Length: 100},
+		{ContentID: "xkey4", Offset: 2*cache.DefaultMaxRangeGap - 10, Length: 10},
+	}
+
+	require.NoError(t, dataCache.PrefetchContentRanges(ctx, "blob1", ranges))
+	require.Equal(t, 2, underlying.getBlobCalls)
+
+	// all contents are now cached, prefetching again does not fetch anything.
+	require.NoError(t, dataCache.PrefetchContentRanges(ctx, "blob1", ranges))
+	require.Equal(t, 2, underlying.getBlobCalls)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	for _, r := range ranges {
+		require.NoError(t, dataCache.GetContent(ctx, r.ContentID, "blob1", r.Offset, r.Length, &tmp))
+		require.Equal(t, blobData[r.Offset:r.Offset+r.Length], tmp.ToByteSlice())
+	}
+
+	require.Equal(t, 2, underlying.getBlobCalls)
+}
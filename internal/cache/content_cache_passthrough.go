@@ -27,6 +27,13 @@ func (c passthroughContentCache) PrefetchBlob(ctx context.Context, blobID blob.I
 	return nil
 }
 
+func (c passthroughContentCache) PrefetchContentRanges(ctx context.Context, blobID blob.ID, contents []ContentRange) error {
+	_ = blobID
+	_ = contents
+
+	return nil
+}
+
 func (c passthroughContentCache) Sync(ctx context.Context, blobPrefix blob.ID) error {
 	_ = blobPrefix
 
@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/impossible"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+const (
+	// DefaultMaxRangeGap is the maximum number of unused bytes between two content ranges that
+	// will be fetched together in a single request.
+	DefaultMaxRangeGap = 256 << 10
+
+	// DefaultMaxRangeSpan is the maximum length of a single request fetching multiple content ranges.
+	DefaultMaxRangeSpan = 16 << 20
+)
+
+// ContentRange describes the location of a single content in a blob.
+type ContentRange struct {
+	ContentID string
+	Offset    int64
+	Length    int64
+}
+
+// rangeGroup is a set of content ranges that will be fetched together using a single GetBlob() call.
+type rangeGroup struct {
+	Offset   int64
+	Length   int64
+	Contents []ContentRange
+}
+
+// coalesceRanges groups the provided ranges so that ranges separated by no more than maxGap bytes
+// are fetched together as long as the fetched span does not exceed maxSpan bytes.
+func coalesceRanges(ranges []ContentRange, maxGap, maxSpan int64) []rangeGroup {
+	sorted := append([]ContentRange(nil), ranges...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+
+	var result []rangeGroup
+
+	for _, r := range sorted {
+		if n := len(result); n > 0 {
+			last := &result[n-1]
+			end := last.Offset + last.Length
+
+			newEnd := r.Offset + r.Length
+			if newEnd < end {
+				newEnd = end
+			}
+
+			if r.Offset-end <= maxGap && newEnd-last.Offset <= maxSpan {
+				last.Length = newEnd - last.Offset
+				last.Contents = append(last.Contents, r)
+
+				continue
+			}
+		}
+
+		result = append(result, rangeGroup{r.Offset, r.Length, []ContentRange{r}})
+	}
+
+	return result
+}
+
+// PrefetchContentRanges fetches the provided contents of a single blob into the cache, coalescing
+// ranges located close to each other into a single storage request, which significantly reduces
+// the number of requests for blobs with many small contents.
+func (c *contentCacheImpl) PrefetchContentRanges(ctx context.Context, blobID blob.ID, contents []ContentRange) error {
+	if c.fetchFullBlobs {
+		return c.PrefetchBlob(ctx, blobID)
+	}
+
+	// acquire shared lock on a blob, PrefetchBlob will acquire exclusive lock here.
+	c.pc.sharedLock(string(blobID))
+	defer c.pc.sharedUnlock(string(blobID))
+
+	var (
+		tmp     gather.WriteBuffer
+		missing []ContentRange
+	)
+
+	defer tmp.Close()
+
+	if c.pc.GetPartial(ctx, BlobIDCacheKey(blobID), 0, 1, &tmp) {
+		// full blob is already cached.
+		return nil
+	}
+
+	for _, cr := range contents {
+		tmp.Reset()
+
+		if !c.pc.GetFull(ctx, ContentIDCacheKey(cr.ContentID), &tmp) {
+			missing = append(missing, cr)
+		}
+	}
+
+	for _, g := range coalesceRanges(missing, DefaultMaxRangeGap, DefaultMaxRangeSpan) {
+		if err := c.fetchRangeGroup(ctx, blobID, g); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *contentCacheImpl) fetchRangeGroup(ctx context.Context, blobID blob.ID, g rangeGroup) error {
+	var data, section gather.WriteBuffer
+
+	defer data.Close()
+	defer section.Close()
+
+	if err := c.st.GetBlob(ctx, blobID, g.Offset, g.Length, &data); err != nil {
+		c.pc.reportMissError()
+
+		return errors.Wrapf(err, "failed to get blob with ID %s", blobID)
+	}
+
+	if int64(data.Length()) != g.Length {
+		return errors.Errorf("invalid length of range (offset=%v,length=%v) of blob %q: %v", g.Offset, g.Length, blobID, data.Length())
+	}
+
+	c.pc.reportMissBytes(int64(data.Length()))
+
+	for _, cr := range g.Contents {
+		section.Reset()
+
+		impossible.PanicOnError(data.AppendSectionTo(&section, int(cr.Offset-g.Offset), int(cr.Length)))
+
+		c.pc.exclusiveLock(cr.ContentID)
+		c.pc.Put(ctx, ContentIDCacheKey(cr.ContentID), section.Bytes())
+		c.pc.exclusiveUnlock(cr.ContentID)
+	}
+
+	return nil
+}
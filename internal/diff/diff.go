@@ -15,6 +15,8 @@
 	"github.com/kopia/kopia/internal/iocopy"
 	"github.com/kopia/kopia/repo/logging"
 	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
 const dirMode = 0o700
@@ -70,7 +72,30 @@ func (c *Comparer) compareDirectories(ctx context.Context, dir1, dir2 fs.Directo
 		}
 	}
 
-	return c.compareDirectoryEntries(ctx, entries1, entries2, parent)
+	return c.compareDirectoryEntries(ctx, entries1, entries2, c.tombstonesByName(ctx, dir2), parent)
+}
+
+// tombstonesByName returns entries recorded as deleted since the previous snapshot of the directory.
+// When comparing files those are not used, since their previous contents need to be loaded anyway.
+func (c *Comparer) tombstonesByName(ctx context.Context, dir fs.Directory) map[string]*snapshot.DirEntry {
+	td, ok := dir.(snapshotfs.DirectoryWithTombstones)
+	if !ok || c.DiffCommand != "" {
+		return nil
+	}
+
+	tombstones, err := td.Tombstones(ctx)
+	if err != nil {
+		log(ctx).Debugf("unable to read tombstones: %v", err)
+		return nil
+	}
+
+	result := map[string]*snapshot.DirEntry{}
+
+	for _, t := range tombstones {
+		result[t.Name] = t
+	}
+
+	return result
 }
 
 //nolint:gocyclo
@@ -206,7 +231,7 @@ func compareEntry(e1, e2 fs.Entry, fullpath string, out io.Writer) bool {
 	return equal
 }
 
-func (c *Comparer) compareDirectoryEntries(ctx context.Context, entries1, entries2 []fs.Entry, dirPath string) error {
+func (c *Comparer) compareDirectoryEntries(ctx context.Context, entries1, entries2 []fs.Entry, tombstones map[string]*snapshot.DirEntry, dirPath string) error {
 	e1byname := map[string]fs.Entry{}
 	for _, e1 := range entries1 {
 		e1byname[e1.Name()] = e1
@@ -225,6 +250,13 @@ func (c *Comparer) compareDirectoryEntries(ctx context.Context, entries1, entrie
 	for _, e1 := range entries1 {
 		entryName := e1.Name()
 		if _, ok := e1byname[entryName]; ok {
+			// deletions recorded as tombstones are reported without descending into previous contents
+			// of deleted directories.
+			if t := tombstones[entryName]; t != nil && t.Type == entryType(e1) {
+				c.outputTombstone(t, dirPath+"/"+entryName)
+				continue
+			}
+
 			if err := c.compareEntry(ctx, e1, nil, dirPath+"/"+entryName); err != nil {
 				return errors.Wrapf(err, "error comparing %v", entryName)
 			}
@@ -234,6 +266,23 @@ func (c *Comparer) compareDirectoryEntries(ctx context.Context, entries1, entrie
 	return nil
 }
 
+func (c *Comparer) outputTombstone(t *snapshot.DirEntry, path string) {
+	if t.Type == snapshot.EntryTypeDirectory {
+		c.output("removed directory %v\n", path)
+		return
+	}
+
+	c.output("removed file %v (%v bytes)\n", path, t.FileSize)
+}
+
+func entryType(e fs.Entry) snapshot.EntryType {
+	if h, ok := e.(snapshot.HasDirEntry); ok {
+		return h.DirEntry().Type
+	}
+
+	return ""
+}
+
 func (c *Comparer) compareFiles(ctx context.Context, f1, f2 fs.File, fname string) error {
 	if c.DiffCommand == "" {
 		return nil
@@ -358,22 +358,47 @@ func (e *Manager) cleanupWatermarks(ctx context.Context, cs CurrentSnapshot, p *
 
 // CleanupSupersededIndexes cleans up the indexes which have been superseded by compacted ones.
 func (e *Manager) CleanupSupersededIndexes(ctx context.Context) error {
-	cs, err := e.committedState(ctx, 0)
+	p, err := e.getParameters(ctx)
 	if err != nil {
 		return err
 	}
 
-	p, err := e.getParameters(ctx)
+	blobs, err := e.SupersededIndexBlobs(ctx)
 	if err != nil {
 		return err
 	}
 
+	var toDelete []blob.ID
+
+	for _, bm := range blobs {
+		toDelete = append(toDelete, bm.BlobID)
+	}
+
+	if err := blob.DeleteMultiple(ctx, e.st, toDelete, p.DeleteParallelism); err != nil {
+		return errors.Wrap(err, "unable to delete uncompacted blobs")
+	}
+
+	return nil
+}
+
+// SupersededIndexBlobs returns uncompacted index blobs which CleanupSupersededIndexes would delete.
+func (e *Manager) SupersededIndexBlobs(ctx context.Context) ([]blob.Metadata, error) {
+	cs, err := e.committedState(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := e.getParameters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	// find max timestamp recently written to the repository to establish storage clock.
 	// we will be deleting blobs whose timestamps are sufficiently old enough relative
 	// to this max time. This assumes that storage clock moves forward somewhat reasonably.
 	maxTime := e.maxCleanupTime(cs)
 	if maxTime.IsZero() {
-		return nil
+		return nil, nil
 	}
 
 	// only delete blobs if a suitable replacement exists and has been written sufficiently
@@ -388,24 +413,20 @@ func (e *Manager) CleanupSupersededIndexes(ctx context.Context) error {
 	// that was written sufficiently long ago.
 	blobs, err := blob.ListAllBlobs(ctx, e.st, UncompactedIndexBlobPrefix)
 	if err != nil {
-		return errors.Wrap(err, "error listing uncompacted blobs")
+		return nil, errors.Wrap(err, "error listing uncompacted blobs")
 	}
 
-	var toDelete []blob.ID
+	var result []blob.Metadata
 
 	for _, bm := range blobs {
 		if epoch, ok := epochNumberFromBlobID(bm.BlobID); ok {
 			if blobSetWrittenEarlyEnough(cs.SingleEpochCompactionSets[epoch], maxReplacementTime) {
-				toDelete = append(toDelete, bm.BlobID)
+				result = append(result, bm)
 			}
 		}
 	}
 
-	if err := blob.DeleteMultiple(ctx, e.st, toDelete, p.DeleteParallelism); err != nil {
-		return errors.Wrap(err, "unable to delete uncompacted blobs")
-	}
-
-	return nil
+	return result, nil
 }
 
 func blobSetWrittenEarlyEnough(replacementSet []blob.Metadata, maxReplacementTime time.Time) bool {
@@ -19,15 +19,22 @@
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/object"
 )
 
 var log = logging.Module("fuse")
 
 const fakeBlockSize = 4096
 
+// ObjectPrefetcher schedules objects to be prefetched into the cache in the background.
+type ObjectPrefetcher interface {
+	PrefetchObjects(objectIDs []object.ID, hint string)
+}
+
 type fuseNode struct {
 	gofusefs.Inode
-	entry fs.Entry
+	entry      fs.Entry
+	prefetcher ObjectPrefetcher
 }
 
 func goModeToUnixMode(mode os.FileMode) uint32 {
@@ -151,7 +158,7 @@ func (dir *fuseDirectoryNode) Lookup(ctx context.Context, fileName string, out *
 		Mode: entryToFuseMode(e),
 	}
 
-	n, err := newFuseNode(e)
+	n, err := newFuseNode(e, dir.prefetcher)
 	if err != nil {
 		return nil, syscall.EIO
 	}
@@ -175,6 +182,8 @@ func (dir *fuseDirectoryNode) Readdir(ctx context.Context) (gofusefs.DirStream,
 
 	defer iter.Close()
 
+	var fileObjectIDs []object.ID
+
 	cur, err := iter.Next(ctx)
 	for cur != nil {
 		result = append(result, fuse.DirEntry{
@@ -182,6 +191,10 @@ func (dir *fuseDirectoryNode) Readdir(ctx context.Context) (gofusefs.DirStream,
 			Mode: entryToFuseMode(cur),
 		})
 
+		if h, ok := cur.(object.HasObjectID); ok && !cur.IsDir() && h.ObjectID() != object.EmptyID {
+			fileObjectIDs = append(fileObjectIDs, h.ObjectID())
+		}
+
 		cur, err = iter.Next(ctx)
 	}
 
@@ -190,6 +203,11 @@ func (dir *fuseDirectoryNode) Readdir(ctx context.Context) (gofusefs.DirStream,
 		return nil, syscall.EIO
 	}
 
+	if dir.prefetcher != nil && len(fileObjectIDs) > 0 {
+		// the user is likely to read files in the directory they just listed.
+		dir.prefetcher.PrefetchObjects(fileObjectIDs, "")
+	}
+
 	return gofusefs.NewListDirStream(result), gofusefs.OK
 }
 
@@ -220,26 +238,32 @@ func entryToFuseMode(e fs.Entry) uint32 {
 	}
 }
 
-func newFuseNode(e fs.Entry) (gofusefs.InodeEmbedder, error) {
+func newFuseNode(e fs.Entry, prefetcher ObjectPrefetcher) (gofusefs.InodeEmbedder, error) {
 	switch e := e.(type) {
 	case fs.Directory:
-		return newDirectoryNode(e), nil
+		return newDirectoryNode(e, prefetcher), nil
 	case fs.File:
-		return &fuseFileNode{fuseNode{entry: e}}, nil
+		return &fuseFileNode{fuseNode{entry: e, prefetcher: prefetcher}}, nil
 	case fs.Symlink:
-		return &fuseSymlinkNode{fuseNode{entry: e}}, nil
+		return &fuseSymlinkNode{fuseNode{entry: e, prefetcher: prefetcher}}, nil
 	default:
 		return nil, errors.Errorf("entry type not supported: %v", e.Mode())
 	}
 }
 
-func newDirectoryNode(dir fs.Directory) gofusefs.InodeEmbedder {
-	return &fuseDirectoryNode{fuseNode{entry: dir}}
+func newDirectoryNode(dir fs.Directory, prefetcher ObjectPrefetcher) gofusefs.InodeEmbedder {
+	return &fuseDirectoryNode{fuseNode{entry: dir, prefetcher: prefetcher}}
 }
 
 // NewDirectoryNode returns FUSE Node for a given fs.Directory.
 func NewDirectoryNode(dir fs.Directory) gofusefs.InodeEmbedder {
-	return newDirectoryNode(dir)
+	return newDirectoryNode(dir, nil)
+}
+
+// NewDirectoryNodeWithPrefetcher returns FUSE Node for a given fs.Directory, which schedules
+// files to be prefetched using the provided prefetcher when directories are listed.
+func NewDirectoryNodeWithPrefetcher(dir fs.Directory, prefetcher ObjectPrefetcher) gofusefs.InodeEmbedder {
+	return newDirectoryNode(dir, prefetcher)
 }
 
 var (
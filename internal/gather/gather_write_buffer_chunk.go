@@ -142,6 +142,19 @@ func (a *chunkAllocator) releaseChunk(s []byte) {
 	}
 }
 
+func (a *chunkAllocator) setMaxFreeListSize(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.maxFreeListSize = n
+
+	if len(a.freeList) > n {
+		// drop references to the excess chunks so they can be garbage-collected.
+		clear(a.freeList[n:])
+		a.freeList = a.freeList[0:n]
+	}
+}
+
 func (a *chunkAllocator) dumpStats(ctx context.Context, prefix string) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -173,3 +186,11 @@ func DumpStats(ctx context.Context) {
 	typicalContiguousAllocator.dumpStats(ctx, "typical-contig")
 	maxContiguousAllocator.dumpStats(ctx, "contig")
 }
+
+// SetMaxFreeChunks limits the number of released chunks retained by the allocators for reuse,
+// maxChunks for the default allocator and maxContiguous for each of the contiguous ones.
+func SetMaxFreeChunks(maxChunks, maxContiguous int) {
+	defaultAllocator.setMaxFreeListSize(maxChunks)
+	typicalContiguousAllocator.setMaxFreeListSize(maxContiguous)
+	maxContiguousAllocator.setMaxFreeListSize(maxContiguous)
+}
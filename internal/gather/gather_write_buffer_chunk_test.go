@@ -66,6 +66,32 @@ func TestWriteBufferChunk(t *testing.T) {
 	}
 }
 
+func TestWriteBufferChunkMaxFreeListSize(t *testing.T) {
+	all := &chunkAllocator{
+		chunkSize:       100,
+		maxFreeListSize: 10,
+	}
+
+	var chunks [][]byte
+
+	for i := 0; i < 5; i++ {
+		chunks = append(chunks, all.allocChunk())
+	}
+
+	for _, ch := range chunks {
+		all.releaseChunk(ch)
+	}
+
+	require.Len(t, all.freeList, 5)
+
+	all.setMaxFreeListSize(2)
+	require.Len(t, all.freeList, 2)
+
+	all.releaseChunk(all.allocChunk())
+	all.releaseChunk(make([]byte, 0, all.chunkSize))
+	require.Len(t, all.freeList, 2)
+}
+
 func TestContigAllocatorChunkSize(t *testing.T) {
 	// verify that contiguous allocator has chunk size big enough for all splitter results
 	// + some minimal overhead.
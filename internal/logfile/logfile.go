@@ -112,12 +112,17 @@ func (c *loggingFlags) initialize(ctx *kingpin.ParseContext) error {
 		suffix = strings.ReplaceAll(c.FullCommand(), " ", "-")
 	}
 
-	rootLogger := zap.New(zapcore.NewTee(
-		c.setupConsoleCore(),
-		c.setupLogFileCore(now, suffix),
-	), zap.WithClock(zaplogutil.Clock()))
+	rootCore := c.setupConsoleCore()
+	contentCore := zapcore.NewNopCore()
 
-	contentLogger := zap.New(c.setupContentLogFileBackend(now, suffix), zap.WithClock(zaplogutil.Clock())).Sugar()
+	// forensic mode never writes log files.
+	if !c.cliApp.IsForensic() {
+		rootCore = zapcore.NewTee(rootCore, c.setupLogFileCore(now, suffix))
+		contentCore = c.setupContentLogFileBackend(now, suffix)
+	}
+
+	rootLogger := zap.New(rootCore, zap.WithClock(zaplogutil.Clock()))
+	contentLogger := zap.New(contentCore, zap.WithClock(zaplogutil.Clock())).Sugar()
 
 	c.cliApp.SetLoggerFactory(func(module string) logging.Logger {
 		if module == content.FormatLogModule {
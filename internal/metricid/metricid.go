@@ -40,6 +40,8 @@
 	"content_uploaded_bytes":                       33,
 	"content_write_bytes":                          34,
 	"content_write_duration_nanos":                 35,
+	"content_base_deduplicated":                    36,
+	"content_base_deduplicated_bytes":              37,
 	// add new items here, use consecutive values
 })
 
@@ -40,6 +40,11 @@ type entry struct {
 	modTime time.Time
 	owner   fs.OwnerInfo
 	device  fs.DeviceInfo
+
+	changeTime time.Time
+
+	macos        *fs.MacOSMetadata
+	resourceFork []byte
 }
 
 func (e *entry) Name() string {
@@ -70,6 +75,40 @@ func (e *entry) Owner() fs.OwnerInfo {
 	return e.owner
 }
 
+// SetModTime changes the modification time of the entry.
+func (e *entry) SetModTime(t time.Time) {
+	e.modTime = t
+}
+
+// ChangeTime implements fs.EntryWithChangeTime.
+func (e *entry) ChangeTime() time.Time {
+	return e.changeTime
+}
+
+// SetChangeTime changes the change time (ctime) of the entry.
+func (e *entry) SetChangeTime(t time.Time) {
+	e.changeTime = t
+}
+
+// SetOwner changes the owner of the entry.
+func (e *entry) SetOwner(oi fs.OwnerInfo) {
+	e.owner = oi
+}
+
+// SetMacOSMetadata changes macOS-specific metadata and resource fork of the entry.
+func (e *entry) SetMacOSMetadata(md *fs.MacOSMetadata, resourceFork []byte) {
+	e.macos = md
+	e.resourceFork = resourceFork
+}
+
+func (e *entry) MacOSMetadata(ctx context.Context) (*fs.MacOSMetadata, error) {
+	return e.macos, nil
+}
+
+func (e *entry) OpenResourceFork(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(e.resourceFork)), nil
+}
+
 func (e *entry) Device() fs.DeviceInfo {
 	return e.device
 }
@@ -325,6 +364,7 @@ type File struct {
 
 // SetContents changes the contents of a given file.
 func (imf *File) SetContents(b []byte) {
+	imf.size = int64(len(b))
 	imf.source = func() (ReaderSeekerCloser, error) {
 		return readerSeekerCloser{bytes.NewReader(b)}, nil
 	}
@@ -356,7 +396,8 @@ func (imf *File) Open(ctx context.Context) (fs.Reader, error) {
 type Symlink struct {
 	entry
 
-	target string
+	target   string
+	linkType string
 }
 
 // Readlink implements fs.Symlink interface.
@@ -364,6 +405,16 @@ func (imsl *Symlink) Readlink(ctx context.Context) (string, error) {
 	return imsl.target, nil
 }
 
+// SetLinkType sets the link type of the symlink, such as fs.LinkTypeJunction.
+func (imsl *Symlink) SetLinkType(linkType string) {
+	imsl.linkType = linkType
+}
+
+// LinkType implements fs.SymlinkWithLinkType interface.
+func (imsl *Symlink) LinkType() string {
+	return imsl.linkType
+}
+
 // NewDirectory returns new mock directory.
 func NewDirectory() *Directory {
 	return &Directory{
@@ -402,8 +453,9 @@ func (e *ErrorEntry) ErrorInfo() error {
 }
 
 var (
-	_ fs.Directory  = &Directory{}
-	_ fs.File       = &File{}
-	_ fs.Symlink    = &Symlink{}
-	_ fs.ErrorEntry = &ErrorEntry{}
+	_ fs.Directory           = &Directory{}
+	_ fs.File                = &File{}
+	_ fs.Symlink             = &Symlink{}
+	_ fs.SymlinkWithLinkType = &Symlink{}
+	_ fs.ErrorEntry          = &ErrorEntry{}
 )
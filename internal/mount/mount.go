@@ -5,6 +5,7 @@
 	"context"
 
 	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/object"
 )
 
 var log = logging.Module("mount")
@@ -25,4 +26,11 @@ type Options struct {
 	FuseAllowNonEmptyMount bool
 	// Use WebDAV even on platforms that support FUSE.
 	PreferWebDAV bool
+	// Prefetcher, if set, is used to prefetch files in directories listed by the user. Supported only on FUSE.
+	Prefetcher ObjectPrefetcher
+}
+
+// ObjectPrefetcher schedules objects to be prefetched into the cache in the background.
+type ObjectPrefetcher interface {
+	PrefetchObjects(objectIDs []object.ID, hint string)
 }
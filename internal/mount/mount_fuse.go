@@ -61,7 +61,7 @@ func Directory(ctx context.Context, entry fs.Directory, mountPoint string, mount
 		return newPosixWedavController(ctx, entry, mountPoint, isTempDir)
 	}
 
-	rootNode := fusemount.NewDirectoryNode(entry)
+	rootNode := fusemount.NewDirectoryNodeWithPrefetcher(entry, mountOptions.Prefetcher)
 
 	fuseServer, err := gofusefs.Mount(mountPoint, rootNode, mountOptions.toFuseMountOptions())
 	if err != nil {
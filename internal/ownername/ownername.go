@@ -0,0 +1,112 @@
+// Package ownername provides cached mapping between numeric user/group IDs and their names.
+package ownername
+
+import (
+	"os/user"
+	"strconv"
+	"sync"
+)
+
+//nolint:gochecknoglobals
+var (
+	userNames  sync.Map // uint32 -> string
+	groupNames sync.Map // uint32 -> string
+	userIDs    sync.Map // string -> lookupResult
+	groupIDs   sync.Map // string -> lookupResult
+)
+
+type lookupResult struct {
+	id uint32
+	ok bool
+}
+
+// UserName returns the name of the user with a given ID or empty string if not known.
+func UserName(uid uint32) string {
+	if v, ok := userNames.Load(uid); ok {
+		return v.(string) //nolint:forcetypeassert
+	}
+
+	var name string
+
+	if u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10)); err == nil {
+		name = u.Username
+	}
+
+	userNames.Store(uid, name)
+
+	return name
+}
+
+// GroupName returns the name of the group with a given ID or empty string if not known.
+func GroupName(gid uint32) string {
+	if v, ok := groupNames.Load(gid); ok {
+		return v.(string) //nolint:forcetypeassert
+	}
+
+	var name string
+
+	if g, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10)); err == nil {
+		name = g.Name
+	}
+
+	groupNames.Store(gid, name)
+
+	return name
+}
+
+// UserID returns the ID of the user with a given name.
+func UserID(name string) (uint32, bool) {
+	if v, ok := userIDs.Load(name); ok {
+		r := v.(lookupResult) //nolint:forcetypeassert
+		return r.id, r.ok
+	}
+
+	var r lookupResult
+
+	if u, err := user.Lookup(name); err == nil {
+		if id, err := strconv.ParseUint(u.Uid, 10, 32); err == nil {
+			r = lookupResult{uint32(id), true}
+		}
+	}
+
+	userIDs.Store(name, r)
+
+	return r.id, r.ok
+}
+
+// GroupID returns the ID of the group with a given name.
+func GroupID(name string) (uint32, bool) {
+	if v, ok := groupIDs.Load(name); ok {
+		r := v.(lookupResult) //nolint:forcetypeassert
+		return r.id, r.ok
+	}
+
+	var r lookupResult
+
+	if g, err := user.LookupGroup(name); err == nil {
+		if id, err := strconv.ParseUint(g.Gid, 10, 32); err == nil {
+			r = lookupResult{uint32(id), true}
+		}
+	}
+
+	groupIDs.Store(name, r)
+
+	return r.id, r.ok
+}
+
+// SetIDsForTesting makes the provided user and group names resolve to the provided IDs, which simulates
+// restoring on a system where the names map to different IDs.
+func SetIDsForTesting(userName string, uid uint32, groupName string, gid uint32) {
+	userIDs.Store(userName, lookupResult{uid, true})
+	groupIDs.Store(groupName, lookupResult{gid, true})
+}
+
+// ResetForTesting discards all cached mappings.
+func ResetForTesting() {
+	for _, m := range []*sync.Map{&userNames, &groupNames, &userIDs, &groupIDs} {
+		m.Range(func(k, _ interface{}) bool {
+			m.Delete(k)
+			return true
+		})
+	}
+}
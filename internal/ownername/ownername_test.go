@@ -0,0 +1,40 @@
+package ownername_test
+
+import (
+	"os/user"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/ownername"
+)
+
+func TestOwnerNameRoundTrip(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("numeric user IDs are not supported on Windows")
+	}
+
+	u, err := user.Current()
+	require.NoError(t, err)
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	require.NoError(t, err)
+
+	name := ownername.UserName(uint32(uid))
+	require.Equal(t, u.Username, name)
+
+	id, ok := ownername.UserID(name)
+	require.True(t, ok)
+	require.Equal(t, uint32(uid), id)
+
+	// second lookup is served from cache.
+	require.Equal(t, name, ownername.UserName(uint32(uid)))
+
+	_, ok = ownername.UserID("no-such-user-kopia-test")
+	require.False(t, ok)
+
+	_, ok = ownername.GroupID("no-such-group-kopia-test")
+	require.False(t, ok)
+}
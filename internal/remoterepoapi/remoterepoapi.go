@@ -52,3 +52,9 @@ type ApplyRetentionPolicyRequest struct {
 type ApplyRetentionPolicyResponse struct {
 	ManifestIDs []manifest.ID `json:"manifests"`
 }
+
+// ContentUploadStatus represents the status of a resumable content upload session.
+type ContentUploadStatus struct {
+	Offset   int64 `json:"offset"`             // number of bytes received so far
+	Complete bool  `json:"complete,omitempty"` // true when the content has been written
+}
@@ -0,0 +1,178 @@
+package repodiag
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// AuditLogBlobPrefix is a prefix given to storage operation audit logs stored in repository.
+const AuditLogBlobPrefix = "_audit_"
+
+const auditLogFlushThreshold = 1000
+
+// Storage operations recorded in the audit log.
+const (
+	AuditOpPut    = "put"
+	AuditOpDelete = "delete"
+)
+
+// AuditLogEntry describes a single storage operation performed by the client.
+type AuditLogEntry struct {
+	Time   time.Time `json:"t"`
+	Op     string    `json:"op"`
+	BlobID blob.ID   `json:"id"`
+	Length int64     `json:"len,omitempty"`
+}
+
+// AuditLogSegment is the contents of a single audit log blob. Segments of a session are numbered
+// consecutively starting at zero and the last segment written when the repository is closed is marked
+// as final, so that missing segments and sessions which were not closed cleanly can be detected.
+type AuditLogSegment struct {
+	Session  string          `json:"session"`
+	User     string          `json:"user"`
+	Sequence int             `json:"seq"`
+	Final    bool            `json:"final,omitempty"`
+	Entries  []AuditLogEntry `json:"entries"`
+}
+
+// AuditLog accumulates storage operations and periodically writes them as encrypted,
+// append-only blobs to the repository. Since the blobs are authenticated using the repository
+// encryption key, they can't be forged or altered by a party without access to the repository.
+type AuditLog struct {
+	// AuditLog records operations without a context, the context is used for asynchronous writes.
+	ctx context.Context //nolint:containedctx
+
+	writer   *Writer
+	timeFunc func() time.Time
+	user     string
+
+	flushThreshold int
+
+	mu        sync.Mutex
+	session   string
+	startTime time.Time
+	seq       int
+	entries   []AuditLogEntry
+	closed    bool
+}
+
+// Record adds the provided storage operation to the audit log.
+func (l *AuditLog) Record(op string, id blob.ID, length int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return
+	}
+
+	l.entries = append(l.entries, AuditLogEntry{
+		Time:   l.timeFunc().UTC(),
+		Op:     op,
+		BlobID: id,
+		Length: length,
+	})
+
+	if len(l.entries) >= l.flushThreshold {
+		l.writeSegmentLocked(false)
+	}
+}
+
+// Close writes the final segment of the audit log, subsequent operations are not recorded.
+func (l *AuditLog) Close(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		return nil
+	}
+
+	l.closed = true
+
+	// sessions that never modified the repository don't leave any trace.
+	if l.seq > 0 || len(l.entries) > 0 {
+		l.writeSegmentLocked(true)
+	}
+
+	return nil
+}
+
+func (l *AuditLog) writeSegmentLocked(final bool) {
+	seg := AuditLogSegment{
+		Session:  l.session,
+		User:     l.user,
+		Sequence: l.seq,
+		Final:    final,
+		Entries:  l.entries,
+	}
+
+	b, err := json.Marshal(seg)
+	if err != nil {
+		log(l.ctx).Warnf("unable to serialize audit log: %v", err)
+		return
+	}
+
+	prefix := blob.ID(fmt.Sprintf("%v%v_%v_%v_", AuditLogBlobPrefix, l.startTime.Format("20060102150405"), l.session, l.seq))
+
+	l.writer.EncryptAndWriteBlobAsync(l.ctx, prefix, gather.FromSlice(b), func() {})
+
+	l.seq++
+	l.entries = nil
+}
+
+// NewAuditLog creates a new AuditLog that writes to the repository using the provided writer.
+func NewAuditLog(ctx context.Context, w *Writer, user string, timeFunc func() time.Time) *AuditLog {
+	var rnd [8]byte
+
+	rand.Read(rnd[:]) //nolint:errcheck
+
+	return &AuditLog{
+		ctx:            ctx,
+		writer:         w,
+		timeFunc:       timeFunc,
+		user:           user,
+		flushThreshold: auditLogFlushThreshold,
+		session:        fmt.Sprintf("%x", rnd),
+		startTime:      timeFunc().UTC(),
+	}
+}
+
+type auditStorage struct {
+	blob.Storage
+
+	al *AuditLog
+}
+
+func (s *auditStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	if err := s.Storage.PutBlob(ctx, id, data, opts); err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	s.al.Record(AuditOpPut, id, int64(data.Length()))
+
+	return nil
+}
+
+func (s *auditStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	if err := s.Storage.DeleteBlob(ctx, id); err != nil {
+		//nolint:wrapcheck
+		return err
+	}
+
+	s.al.Record(AuditOpDelete, id, 0)
+
+	return nil
+}
+
+// NewAuditStorage returns a storage wrapper that records successful writes and deletions in the provided audit log.
+// The writer used by the audit log must write to the underlying storage, so that audit blobs themselves are not audited.
+func NewAuditStorage(st blob.Storage, al *AuditLog) blob.Storage {
+	return &auditStorage{Storage: st, al: al}
+}
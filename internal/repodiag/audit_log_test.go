@@ -0,0 +1,92 @@
+package repodiag_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobcrypto"
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/repodiag"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+func TestAuditLog(t *testing.T) {
+	d := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(d, nil, nil)
+	crypter := newStaticCrypter(t)
+	ctx := testlogging.Context(t)
+
+	w := repodiag.NewWriter(st, crypter)
+	al := repodiag.NewAuditLog(ctx, w, "user@host", clock.Now)
+	ast := repodiag.NewAuditStorage(st, al)
+
+	require.NoError(t, ast.PutBlob(ctx, "p1", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+	require.NoError(t, ast.PutBlob(ctx, "q2", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+	require.NoError(t, ast.DeleteBlob(ctx, "p1"))
+
+	// failed operations are not recorded.
+	require.Error(t, ast.GetBlob(ctx, "p1", 0, -1, &gather.WriteBuffer{}))
+
+	require.NoError(t, al.Close(ctx))
+	require.NoError(t, w.Wait(ctx))
+
+	// operations after close are not recorded
+	require.NoError(t, ast.DeleteBlob(ctx, "q2"))
+	require.NoError(t, w.Wait(ctx))
+
+	var auditBlobs []blob.ID
+
+	for id := range d {
+		if strings.HasPrefix(string(id), repodiag.AuditLogBlobPrefix) {
+			auditBlobs = append(auditBlobs, id)
+		}
+	}
+
+	require.Len(t, auditBlobs, 1)
+
+	var decrypted gather.WriteBuffer
+	defer decrypted.Close()
+
+	require.NoError(t, blobcrypto.Decrypt(crypter, gather.FromSlice(d[auditBlobs[0]]), auditBlobs[0], &decrypted))
+
+	var seg repodiag.AuditLogSegment
+
+	require.NoError(t, json.Unmarshal(decrypted.ToByteSlice(), &seg))
+	require.Equal(t, "user@host", seg.User)
+	require.Equal(t, 0, seg.Sequence)
+	require.True(t, seg.Final)
+	require.Len(t, seg.Entries, 3)
+
+	require.Equal(t, repodiag.AuditOpPut, seg.Entries[0].Op)
+	require.Equal(t, blob.ID("p1"), seg.Entries[0].BlobID)
+	require.Equal(t, int64(3), seg.Entries[0].Length)
+	require.Equal(t, repodiag.AuditOpPut, seg.Entries[1].Op)
+	require.Equal(t, blob.ID("q2"), seg.Entries[1].BlobID)
+	require.Equal(t, repodiag.AuditOpDelete, seg.Entries[2].Op)
+	require.Equal(t, blob.ID("p1"), seg.Entries[2].BlobID)
+
+	// tampering with the audit blob is detected.
+	tampered := append([]byte(nil), d[auditBlobs[0]]...)
+	tampered[len(tampered)-1] ^= 1
+
+	require.Error(t, blobcrypto.Decrypt(crypter, gather.FromSlice(tampered), auditBlobs[0], &decrypted))
+}
+
+func TestAuditLog_NoOperations(t *testing.T) {
+	d := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(d, nil, nil)
+	ctx := testlogging.Context(t)
+
+	w := repodiag.NewWriter(st, newStaticCrypter(t))
+	al := repodiag.NewAuditLog(ctx, w, "user@host", clock.Now)
+
+	require.NoError(t, al.Close(ctx))
+	require.NoError(t, w.Wait(ctx))
+	require.Empty(t, d)
+}
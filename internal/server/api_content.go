@@ -56,16 +56,21 @@ func handleContentInfo(ctx context.Context, rc requestContext) (interface{}, *ap
 }
 
 func handleContentPut(ctx context.Context, rc requestContext) (interface{}, *apiError) {
-	dr, ok := rc.rep.(repo.DirectRepositoryWriter)
-	if !ok {
-		return nil, repositoryNotWritableError()
-	}
-
 	cid, cerr := content.ParseID(rc.muxVar("contentID"))
 	if cerr != nil {
 		return nil, requestError(serverapi.ErrorMalformedRequest, "malformed content ID")
 	}
 
+	return writeReceivedContent(ctx, rc, cid, rc.body)
+}
+
+// writeReceivedContent writes the provided content data received from the client and verifies that it matches the expected content ID.
+func writeReceivedContent(ctx context.Context, rc requestContext, cid content.ID, data []byte) (interface{}, *apiError) {
+	dr, ok := rc.rep.(repo.DirectRepositoryWriter)
+	if !ok {
+		return nil, repositoryNotWritableError()
+	}
+
 	prefix := cid.Prefix()
 
 	if strings.HasPrefix(string(prefix), manifest.ContentPrefix) {
@@ -87,7 +92,7 @@ func handleContentPut(ctx context.Context, rc requestContext) (interface{}, *api
 		}
 	}
 
-	actualCID, err := dr.ContentManager().WriteContent(ctx, gather.FromSlice(rc.body), prefix, comp)
+	actualCID, err := dr.ContentManager().WriteContent(ctx, gather.FromSlice(data), prefix, comp)
 	if err != nil {
 		return nil, internalServerError(err)
 	}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/remoterepoapi"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+)
+
+const (
+	// maximum length of a content that can be uploaded in a resumable session.
+	maxContentUploadLength = 64 << 20
+
+	// maximum total length of contents being uploaded in all resumable sessions, session data
+	// is spooled to disk but this bounds disk usage and the number of open files.
+	maxContentUploadTotalLength = 256 << 20
+
+	// upload sessions without any activity for this long are discarded.
+	contentUploadSessionTTL = time.Hour
+)
+
+// errContentUploadBudgetExceeded is returned when an upload session can't be created because
+// sessions in progress already use up the length budget.
+var errContentUploadBudgetExceeded = errors.New("too many upload sessions")
+
+// contentUploadSession holds partially-uploaded content data spooled to a temporary file.
+type contentUploadSession struct {
+	length int64
+
+	mu sync.Mutex
+	// +checklocks:mu
+	f *os.File
+	// +checklocks:mu
+	received int64
+	// +checklocks:mu
+	lastActive time.Time
+}
+
+// close closes and removes the temporary file backing the session.
+func (us *contentUploadSession) close() {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if us.f == nil {
+		return
+	}
+
+	us.f.Close()           //nolint:errcheck
+	os.Remove(us.f.Name()) //nolint:errcheck
+
+	us.f = nil
+}
+
+// contentUploadSessions keeps track of resumable content uploads, which allows clients on unreliable
+// connections to resume uploading large contents from the last acknowledged offset.
+type contentUploadSessions struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	sessions map[string]*contentUploadSession
+	// +checklocks:mu
+	totalLength int64
+}
+
+// get returns the upload session for a given key and length or nil if there is none.
+func (s *contentUploadSessions) get(key string, length int64, now time.Time) *contentUploadSession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked(now)
+
+	if us := s.sessions[key]; us != nil && us.length == length {
+		return us
+	}
+
+	return nil
+}
+
+// getOrCreate returns the upload session for a given key, creating it if it does not exist
+// or has a different length.
+func (s *contentUploadSessions) getOrCreate(key string, length int64, now time.Time) (*contentUploadSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expireLocked(now)
+
+	if us := s.sessions[key]; us != nil {
+		if us.length == length {
+			return us, nil
+		}
+
+		s.removeLocked(key)
+	}
+
+	if s.totalLength+length > maxContentUploadTotalLength {
+		return nil, errContentUploadBudgetExceeded
+	}
+
+	f, err := os.CreateTemp("", "kopia-upload-")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create upload session file")
+	}
+
+	us := &contentUploadSession{length: length, f: f, lastActive: now}
+
+	if s.sessions == nil {
+		s.sessions = map[string]*contentUploadSession{}
+	}
+
+	s.sessions[key] = us
+	s.totalLength += length
+
+	return us, nil
+}
+
+// remove removes the session with a given key if it is the provided one.
+func (s *contentUploadSessions) remove(key string, us *contentUploadSession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sessions[key] == us {
+		s.removeLocked(key)
+	}
+}
+
+// closeAll discards all upload sessions.
+func (s *contentUploadSessions) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k := range s.sessions {
+		s.removeLocked(k)
+	}
+}
+
+// +checklocks:s.mu
+func (s *contentUploadSessions) expireLocked(now time.Time) {
+	for k, us := range s.sessions {
+		us.mu.Lock()
+		expired := now.Sub(us.lastActive) > contentUploadSessionTTL
+		us.mu.Unlock()
+
+		if expired {
+			s.removeLocked(k)
+		}
+	}
+}
+
+// +checklocks:s.mu
+func (s *contentUploadSessions) removeLocked(key string) {
+	us := s.sessions[key]
+	if us == nil {
+		return
+	}
+
+	delete(s.sessions, key)
+	s.totalLength -= us.length
+
+	us.close()
+}
+
+func (s *Server) getContentUploadSessions() *contentUploadSessions {
+	return &s.contentUploads
+}
+
+func contentUploadParams(rc requestContext) (key string, cid content.ID, length int64, aerr *apiError) {
+	cid, err := content.ParseID(rc.muxVar("contentID"))
+	if err != nil {
+		return "", cid, 0, requestError(serverapi.ErrorMalformedRequest, "malformed content ID")
+	}
+
+	length, err = strconv.ParseInt(rc.queryParam("length"), 10, 64)
+	if err != nil || length <= 0 || length > maxContentUploadLength {
+		return "", cid, 0, requestError(serverapi.ErrorMalformedRequest, "invalid length")
+	}
+
+	// sessions are per-user to prevent users from interfering with each other's uploads.
+	user, _, _ := rc.req.BasicAuth()
+
+	return user + "/" + cid.String(), cid, length, nil
+}
+
+func handleContentUploadStatus(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	if _, ok := rc.rep.(repo.DirectRepositoryWriter); !ok {
+		return nil, repositoryNotWritableError()
+	}
+
+	key, _, length, aerr := contentUploadParams(rc)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	us := rc.srv.getContentUploadSessions().get(key, length, clock.Now())
+	if us == nil {
+		return &remoterepoapi.ContentUploadStatus{Offset: 0}, nil
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	return &remoterepoapi.ContentUploadStatus{Offset: us.received}, nil
+}
+
+func handleContentUploadChunk(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	if _, ok := rc.rep.(repo.DirectRepositoryWriter); !ok {
+		return nil, repositoryNotWritableError()
+	}
+
+	key, cid, length, aerr := contentUploadParams(rc)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	offset, err := strconv.ParseInt(rc.queryParam("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return nil, requestError(serverapi.ErrorMalformedRequest, "invalid offset")
+	}
+
+	ups := rc.srv.getContentUploadSessions()
+
+	us, err := ups.getOrCreate(key, length, clock.Now())
+	if errors.Is(err, errContentUploadBudgetExceeded) {
+		// clients can retry later or upload the content using a regular PUT.
+		return nil, tooManyRequestsError(err.Error())
+	}
+
+	if err != nil {
+		return nil, internalServerError(err)
+	}
+
+	data, st, err := us.appendChunk(offset, rc.body)
+	if err != nil {
+		ups.remove(key, us)
+		return nil, internalServerError(err)
+	}
+
+	if st != nil {
+		return st, nil
+	}
+
+	ups.remove(key, us)
+
+	// the upload is complete, write content the same way as a regular PUT would.
+	if _, aerr := writeReceivedContent(ctx, rc, cid, data); aerr != nil {
+		return nil, aerr
+	}
+
+	log(ctx).Debugf("completed resumable upload of %v (%v bytes)", cid, length)
+
+	return &remoterepoapi.ContentUploadStatus{Offset: length, Complete: true}, nil
+}
+
+// appendChunk appends the chunk at a given offset to the session file, returning the upload status
+// if the upload is incomplete or the entire data once all of it has been received.
+func (us *contentUploadSession) appendChunk(offset int64, chunk []byte) ([]byte, *remoterepoapi.ContentUploadStatus, error) {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if us.f == nil {
+		return nil, nil, errors.New("upload session closed")
+	}
+
+	us.lastActive = clock.Now()
+
+	// chunks that don't start at the current offset are ignored, the client will resume from the returned offset.
+	if offset != us.received || offset+int64(len(chunk)) > us.length {
+		return nil, &remoterepoapi.ContentUploadStatus{Offset: us.received}, nil
+	}
+
+	if _, err := us.f.WriteAt(chunk, offset); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to write upload session file")
+	}
+
+	us.received += int64(len(chunk))
+
+	if us.received < us.length {
+		return nil, &remoterepoapi.ContentUploadStatus{Offset: us.received}, nil
+	}
+
+	data := make([]byte, us.length)
+	if _, err := us.f.ReadAt(data, 0); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to read upload session file")
+	}
+
+	return data, nil, nil
+}
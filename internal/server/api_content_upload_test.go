@@ -0,0 +1,206 @@
+package server_test
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/remoterepoapi"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/compression"
+	"github.com/kopia/kopia/repo/content"
+)
+
+func TestContentUploadResume(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	si := servertesting.StartServer(t, env, true)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             si.BaseURL,
+		TrustedServerCertificateFingerprint: si.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUsername + "@" + servertesting.TestHostname,
+		Password:                            servertesting.TestPassword,
+	})
+	require.NoError(t, err)
+
+	data := make([]byte, 3<<20)
+	cryptorand.Read(data)
+
+	// determine the content ID by writing the content directly, re-uploading it is harmless.
+	cid, err := env.RepositoryWriter.ContentManager().WriteContent(ctx, gather.FromSlice(data), "", content.NoCompression)
+	require.NoError(t, err)
+
+	baseURL := fmt.Sprintf("contents/%v/upload?length=%v", cid, len(data))
+	chunk := 1 << 20
+
+	var st remoterepoapi.ContentUploadStatus
+
+	require.NoError(t, cli.Get(ctx, baseURL, nil, &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: 0}, st)
+
+	// chunk at the wrong offset is ignored.
+	require.NoError(t, cli.Put(ctx, baseURL+"&offset=1048576", data[chunk:2*chunk], &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: 0}, st)
+
+	require.NoError(t, cli.Put(ctx, baseURL+"&offset=0", data[0:chunk], &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: int64(chunk)}, st)
+
+	// after reconnecting, the client learns how much data has been received.
+	require.NoError(t, cli.Get(ctx, baseURL, nil, &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: int64(chunk)}, st)
+
+	require.NoError(t, cli.Put(ctx, baseURL+"&offset=1048576", data[chunk:], &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: int64(len(data)), Complete: true}, st)
+
+	// the session is gone after completion.
+	st = remoterepoapi.ContentUploadStatus{}
+	require.NoError(t, cli.Get(ctx, baseURL, nil, &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: 0}, st)
+
+	// mismatched data is rejected when the upload completes.
+	bad := bytes.Repeat([]byte{1}, len(data))
+	require.Error(t, cli.Put(ctx, baseURL+"&offset=0", bad, &st))
+
+	// invalid lengths are rejected.
+	require.Error(t, cli.Get(ctx, fmt.Sprintf("contents/%v/upload?length=0", cid), nil, &st))
+	require.Error(t, cli.Get(ctx, fmt.Sprintf("contents/%v/upload", cid), nil, &st))
+}
+
+type contentWriter interface {
+	WriteContent(ctx context.Context, data gather.Bytes, prefix content.IDPrefix, comp compression.HeaderID) (content.ID, error)
+}
+
+func TestContentUploadResumable_RemoteRepository(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	si := servertesting.StartServer(t, env, true)
+	si.DisableGRPC = true
+
+	rep, err := servertesting.ConnectAndOpenAPIServer(t, ctx, si, repo.ClientOptions{
+		Username: servertesting.TestUsername,
+		Hostname: servertesting.TestHostname,
+	}, content.CachingOptions{
+		CacheDirectory: testutil.TempDirectory(t),
+	}, servertesting.TestPassword, &repo.Options{})
+	require.NoError(t, err)
+
+	defer rep.Close(ctx)
+
+	data := make([]byte, 5<<20)
+	cryptorand.Read(data)
+
+	var cid content.ID
+
+	require.NoError(t, repo.WriteSession(ctx, rep, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		var err error
+
+		cid, err = w.(contentWriter).WriteContent(ctx, gather.FromSlice(data), "", content.NoCompression)
+
+		return err
+	}))
+
+	require.NoError(t, env.Repository.Refresh(ctx))
+
+	got, err := env.Repository.(repo.DirectRepository).ContentReader().GetContent(ctx, cid)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestContentUploadResumable_FallbackWhenBudgetExceeded(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	si := servertesting.StartServer(t, env, true)
+	si.DisableGRPC = true
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             si.BaseURL,
+		TrustedServerCertificateFingerprint: si.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUsername + "@" + servertesting.TestHostname,
+		Password:                            servertesting.TestPassword,
+	})
+	require.NoError(t, err)
+
+	var st remoterepoapi.ContentUploadStatus
+
+	// use up the budget of upload sessions.
+	for i := 0; i < 4; i++ {
+		require.NoError(t, cli.Put(ctx, fmt.Sprintf("contents/%032x/upload?length=%v&offset=0", i, 64<<20), []byte{1, 2, 3}, &st))
+	}
+
+	rep, err := servertesting.ConnectAndOpenAPIServer(t, ctx, si, repo.ClientOptions{
+		Username: servertesting.TestUsername,
+		Hostname: servertesting.TestHostname,
+	}, content.CachingOptions{
+		CacheDirectory: testutil.TempDirectory(t),
+	}, servertesting.TestPassword, &repo.Options{})
+	require.NoError(t, err)
+
+	defer rep.Close(ctx)
+
+	data := make([]byte, 5<<20)
+	cryptorand.Read(data)
+
+	var cid content.ID
+
+	require.NoError(t, repo.WriteSession(ctx, rep, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		var err error
+
+		cid, err = w.(contentWriter).WriteContent(ctx, gather.FromSlice(data), "", content.NoCompression)
+
+		return err
+	}))
+
+	require.NoError(t, env.Repository.Refresh(ctx))
+
+	got, err := env.Repository.(repo.DirectRepository).ContentReader().GetContent(ctx, cid)
+	require.NoError(t, err)
+	require.Equal(t, data, got)
+}
+
+func TestContentUploadSessionLimits(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	si := servertesting.StartServer(t, env, true)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             si.BaseURL,
+		TrustedServerCertificateFingerprint: si.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUsername + "@" + servertesting.TestHostname,
+		Password:                            servertesting.TestPassword,
+	})
+	require.NoError(t, err)
+
+	const length = 64 << 20
+
+	uploadURL := func(i int) string {
+		return fmt.Sprintf("contents/%032x/upload?length=%v", i, length)
+	}
+
+	var st remoterepoapi.ContentUploadStatus
+
+	// sessions declaring large lengths use up the budget even though little data has been sent.
+	for i := 0; i < 4; i++ {
+		require.NoError(t, cli.Put(ctx, uploadURL(i)+"&offset=0", []byte{1, 2, 3}, &st))
+		require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: 3}, st)
+	}
+
+	// exceeding the budget is reported as a retryable error.
+	var hse apiclient.HTTPStatusError
+
+	require.ErrorAs(t, cli.Put(ctx, uploadURL(4)+"&offset=0", []byte{1, 2, 3}, &st), &hse)
+	require.Equal(t, http.StatusTooManyRequests, hse.HTTPStatusCode)
+
+	// checking status does not create sessions.
+	require.NoError(t, cli.Get(ctx, uploadURL(5), nil, &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: 0}, st)
+
+	require.NoError(t, cli.Get(ctx, uploadURL(0), nil, &st))
+	require.Equal(t, remoterepoapi.ContentUploadStatus{Offset: 3}, st)
+}
@@ -31,6 +31,10 @@ func accessDeniedError() *apiError {
 	return &apiError{http.StatusForbidden, serverapi.ErrorAccessDenied, "access is denied"}
 }
 
+func tooManyRequestsError(message string) *apiError {
+	return &apiError{http.StatusTooManyRequests, serverapi.ErrorTooManyRequests, message}
+}
+
 func repositoryNotWritableError() *apiError {
 	return internalServerError(errors.Errorf("repository is not writable"))
 }
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/repo"
+)
+
+func handleObjectsPrefetch(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	var req serverapi.PrefetchObjectsRequest
+
+	if err := json.Unmarshal(rc.body, &req); err != nil {
+		return nil, requestError(serverapi.ErrorMalformedRequest, "malformed request body")
+	}
+
+	if p := rc.srv.getPrefetcher(); p != nil {
+		p.PrefetchObjects(req.ObjectIDs, req.Hint)
+	}
+
+	return &serverapi.Empty{}, nil
+}
+
+func (s *Server) getPrefetcher() *repo.BackgroundPrefetcher {
+	s.serverMutex.RLock()
+	defer s.serverMutex.RUnlock()
+
+	return s.prefetcher
+}
@@ -0,0 +1,54 @@
+package server_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func TestObjectsPrefetchAPI(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	var oid object.ID
+
+	require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		ow := w.NewObjectWriter(ctx, object.WriterOptions{})
+		defer ow.Close()
+
+		if _, err := ow.Write([]byte("hello world")); err != nil {
+			return err
+		}
+
+		var err error
+
+		oid, err = ow.Result()
+
+		return err
+	}))
+
+	srvInfo := servertesting.StartServer(t, env, false)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             srvInfo.BaseURL,
+		TrustedServerCertificateFingerprint: srvInfo.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUIUsername,
+		Password:                            servertesting.TestUIPassword,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, cli.FetchCSRFTokenForTesting(ctx))
+
+	require.NoError(t, cli.Post(ctx, "objects/prefetch", &serverapi.PrefetchObjectsRequest{
+		ObjectIDs: []object.ID{oid},
+	}, &serverapi.Empty{}))
+
+	require.Error(t, cli.Post(ctx, "objects/prefetch", "not-a-request", &serverapi.Empty{}))
+}
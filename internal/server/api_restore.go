@@ -51,6 +51,10 @@ func handleRestore(ctx context.Context, rc requestContext) (interface{}, *apiErr
 
 	switch {
 	case req.Filesystem != nil:
+		if req.Filesystem.ConflictStrategy == restore.ConflictPrompt {
+			return nil, requestError(serverapi.ErrorMalformedRequest, "interactive conflict resolution is not supported")
+		}
+
 		if err := req.Filesystem.Init(ctx); err != nil {
 			return nil, internalServerError(err)
 		}
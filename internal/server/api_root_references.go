@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func handleRootReferenceCreate(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	var req serverapi.CreateRootReferenceRequest
+
+	if err := json.Unmarshal(rc.body, &req); err != nil {
+		return nil, requestError(serverapi.ErrorMalformedRequest, "malformed request")
+	}
+
+	dr, ok := rc.rep.(repo.DirectRepository)
+	if !ok {
+		return nil, requestError(serverapi.ErrorMalformedRequest, "root references require direct repository connection")
+	}
+
+	oid, err := snapshotfs.ParseObjectIDWithPath(ctx, dr, req.Root)
+	if err != nil {
+		return nil, requestError(serverapi.ErrorMalformedRequest, "invalid root: "+err.Error())
+	}
+
+	var resp *serverapi.RootReference
+
+	if err := repo.DirectWriteSession(ctx, dr, repo.WriteSessionOptions{
+		Purpose: "CreateRootReference",
+	}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		r, err := snapshotfs.PinRoot(ctx, w, oid, req.Description, req.ExpireTime)
+		if err != nil {
+			return errors.Wrap(err, "unable to pin root")
+		}
+
+		token, err := snapshotfs.RootReferenceToken(w, r)
+		if err != nil {
+			return errors.Wrap(err, "unable to create token")
+		}
+
+		resp = &serverapi.RootReference{ID: r.ID, Token: token, RootReference: r}
+
+		return nil
+	}); err != nil {
+		return nil, internalServerError(err)
+	}
+
+	return resp, nil
+}
+
+func handleRootReferenceList(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	refs, err := snapshotfs.ListRootReferences(ctx, rc.rep)
+	if err != nil {
+		return nil, internalServerError(err)
+	}
+
+	resp := &serverapi.RootReferencesResponse{
+		References: []*serverapi.RootReference{},
+	}
+
+	for _, r := range refs {
+		resp.References = append(resp.References, &serverapi.RootReference{ID: r.ID, RootReference: r})
+	}
+
+	return resp, nil
+}
+
+func handleRootReferenceDelete(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	id := manifest.ID(rc.muxVar("referenceID"))
+
+	if err := repo.WriteSession(ctx, rc.rep, repo.WriteSessionOptions{
+		Purpose: "DeleteRootReference",
+	}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		return snapshotfs.UnpinRoot(ctx, w, id)
+	}); err != nil {
+		if errors.Is(err, snapshotfs.ErrRootReferenceNotFound) {
+			return nil, notFoundError("root reference not found")
+		}
+
+		return nil, internalServerError(err)
+	}
+
+	return &serverapi.Empty{}, nil
+}
+
+// handleRootReferenceContents serves files and directory listings within the tree referenced by the token
+// to any authenticated user, the token itself grants access to the tree.
+func handleRootReferenceContents(ctx context.Context, rc requestContext) {
+	if rc.rep == nil {
+		http.Error(rc.w, "not connected", http.StatusBadRequest)
+		return
+	}
+
+	dr, ok := rc.rep.(repo.DirectRepository)
+	if !ok {
+		http.Error(rc.w, "root references require direct repository connection", http.StatusBadRequest)
+		return
+	}
+
+	r, err := snapshotfs.ResolveRootReferenceToken(ctx, dr, rc.muxVar("token"))
+	switch {
+	case errors.Is(err, snapshotfs.ErrRootReferenceNotFound), errors.Is(err, snapshotfs.ErrRootReferenceExpired):
+		http.Error(rc.w, err.Error(), http.StatusNotFound)
+		return
+
+	case err != nil:
+		log(ctx).Debugf("invalid root reference token: %v", err)
+		http.Error(rc.w, "access denied", http.StatusForbidden)
+
+		return
+	}
+
+	e, err := snapshotfs.GetNestedEntry(ctx, snapshotfs.RootReferenceEntry(ctx, dr, r), strings.Split(rc.queryParam("path"), "/"))
+	if err != nil {
+		http.Error(rc.w, "entry not found", http.StatusNotFound)
+		return
+	}
+
+	switch e := e.(type) {
+	case fs.Directory:
+		serveRootReferenceDirectory(ctx, rc, e)
+
+	case fs.File:
+		f, err := e.Open(ctx)
+		if err != nil {
+			http.Error(rc.w, "unable to open file", http.StatusInternalServerError)
+			return
+		}
+
+		defer f.Close() //nolint:errcheck
+
+		http.ServeContent(rc.w, rc.req, e.Name(), e.ModTime(), f)
+
+	default:
+		http.Error(rc.w, "unsupported entry type", http.StatusBadRequest)
+	}
+}
+
+func serveRootReferenceDirectory(ctx context.Context, rc requestContext, dir fs.Directory) {
+	resp := &serverapi.RootReferenceDirectoryResponse{
+		Entries: []*snapshot.DirEntry{},
+	}
+
+	if err := fs.IterateEntries(ctx, dir, func(ctx context.Context, e fs.Entry) error {
+		if h, ok := e.(snapshot.HasDirEntry); ok {
+			resp.Entries = append(resp.Entries, h.DirEntry())
+		}
+
+		return nil
+	}); err != nil {
+		http.Error(rc.w, "unable to read directory", http.StatusInternalServerError)
+		return
+	}
+
+	rc.w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(rc.w).Encode(resp); err != nil {
+		log(ctx).Errorf("error encoding response: %v", err)
+	}
+}
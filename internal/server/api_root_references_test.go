@@ -0,0 +1,101 @@
+package server_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/apiclient"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/internal/serverapi"
+	"github.com/kopia/kopia/internal/servertesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRootReferences(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	var man *snapshot.Manifest
+
+	require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{Purpose: "Test"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		dir := mockfs.NewDirectory()
+		dir.AddDir("sub", 0o755)
+		dir.AddFile("sub/file1", []byte{1, 2, 3}, 0o644)
+
+		var err error
+
+		man, err = snapshotfs.NewUploader(w).Upload(ctx, dir, nil, env.LocalPathSourceInfo("/dummy/path"))
+		require.NoError(t, err)
+
+		return nil
+	}))
+
+	srvInfo := servertesting.StartServer(t, env, false)
+
+	uiClient, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             srvInfo.BaseURL,
+		TrustedServerCertificateFingerprint: srvInfo.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUIUsername,
+		Password:                            servertesting.TestUIPassword,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, uiClient.FetchCSRFTokenForTesting(ctx))
+
+	// repository users can't manage references but can access trees using tokens.
+	repoClient, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             srvInfo.BaseURL,
+		TrustedServerCertificateFingerprint: srvInfo.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUsername + "@" + servertesting.TestHostname,
+		Password:                            servertesting.TestPassword,
+	})
+	require.NoError(t, err)
+
+	ref, err := serverapi.CreateRootReference(ctx, uiClient, &serverapi.CreateRootReferenceRequest{
+		Root:        man.RootObjectID().String(),
+		Description: "some reference",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, ref.Token)
+	require.Equal(t, man.RootObjectID(), ref.RootObjectID)
+
+	refs, err := serverapi.ListRootReferences(ctx, uiClient)
+	require.NoError(t, err)
+	require.Len(t, refs.References, 1)
+	require.Equal(t, ref.ID, refs.References[0].ID)
+
+	_, err = serverapi.ListRootReferences(ctx, repoClient)
+	require.Error(t, err)
+
+	b, err := serverapi.GetRootReferenceContents(ctx, repoClient, ref.Token, "")
+	require.NoError(t, err)
+
+	var dir serverapi.RootReferenceDirectoryResponse
+
+	require.NoError(t, json.Unmarshal(b, &dir))
+	require.Len(t, dir.Entries, 1)
+	require.Equal(t, "sub", dir.Entries[0].Name)
+
+	b, err = serverapi.GetRootReferenceContents(ctx, repoClient, ref.Token, "sub/file1")
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 2, 3}, b)
+
+	_, err = serverapi.GetRootReferenceContents(ctx, repoClient, ref.Token, "sub/no-such-file")
+	require.ErrorIs(t, err, apiclient.HTTPStatusError{HTTPStatusCode: 404, ErrorMessage: "404 Not Found"})
+
+	_, err = serverapi.GetRootReferenceContents(ctx, repoClient, ref.Token+"x", "")
+	require.ErrorIs(t, err, apiclient.HTTPStatusError{HTTPStatusCode: 403, ErrorMessage: "403 Forbidden"})
+
+	// deleting the reference revokes the token.
+	require.NoError(t, serverapi.DeleteRootReference(ctx, uiClient, ref.ID))
+
+	_, err = serverapi.GetRootReferenceContents(ctx, repoClient, ref.Token, "")
+	require.ErrorIs(t, err, apiclient.HTTPStatusError{HTTPStatusCode: 404, ErrorMessage: "404 Not Found"})
+
+	require.Error(t, serverapi.DeleteRootReference(ctx, uiClient, ref.ID))
+}
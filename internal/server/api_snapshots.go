@@ -12,6 +12,7 @@
 	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 )
 
 func handleListSnapshots(ctx context.Context, rc requestContext) (interface{}, *apiError) {
@@ -169,6 +170,27 @@ func handleEditSnapshots(ctx context.Context, rc requestContext) (interface{}, *
 	return snaps, nil
 }
 
+func handleSnapshotErrors(ctx context.Context, rc requestContext) (interface{}, *apiError) {
+	snap, err := snapshot.LoadSnapshot(ctx, rc.rep, manifest.ID(rc.muxVar("snapshotID")))
+	if errors.Is(err, snapshot.ErrSnapshotNotFound) {
+		return nil, notFoundError("snapshot not found")
+	}
+
+	if err != nil {
+		return nil, internalServerError(err)
+	}
+
+	em, err := snapshotfs.ReadErrorManifest(ctx, rc.rep, snap)
+	if err != nil {
+		return nil, internalServerError(err)
+	}
+
+	return &serverapi.SnapshotErrorsResponse{
+		Errors:  em.Entries,
+		Omitted: em.Omitted,
+	}, nil
+}
+
 func forAllSourceManagersMatchingURLFilter(ctx context.Context, managers map[snapshot.SourceInfo]*sourceManager, c func(s *sourceManager, ctx context.Context) serverapi.SourceActionResponse, values url.Values) (interface{}, *apiError) {
 	resp := &serverapi.MultipleSourceActionResponse{
 		Sources: map[string]serverapi.SourceActionResponse{},
@@ -258,6 +280,7 @@ func convertSnapshotManifest(m *snapshot.Manifest) *serverapi.Snapshot {
 		RootEntry:        m.RootObjectID().String(),
 		RetentionReasons: append([]string{}, m.RetentionReasons...),
 		Pins:             append([]string{}, m.Pins...),
+		Histograms:       m.Histograms,
 	}
 
 	if re := m.RootEntry; re != nil {
@@ -4,6 +4,7 @@
 	"context"
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 
 	"github.com/kopia/kopia/internal/apiclient"
@@ -256,3 +257,44 @@ func TestEditSnapshots(t *testing.T) {
 	require.EqualValues(t, []string{"pin2"}, updated[0].Pins)
 	require.EqualValues(t, newDesc2, updated[0].Description)
 }
+
+func TestSnapshotErrors(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	var id manifest.ID
+
+	require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{Purpose: "Test"}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		dir := mockfs.NewDirectory()
+
+		dir.AddFile("file1", []byte{1, 2, 3}, 0o644)
+		dir.AddErrorEntry("file2", 0o644, errors.New("some error"))
+
+		man, err := snapshotfs.NewUploader(w).Upload(ctx, dir, nil, env.LocalPathSourceInfo("/dummy/path"))
+		require.NoError(t, err)
+		id, err = snapshot.SaveSnapshot(ctx, w, man)
+		require.NoError(t, err)
+
+		return nil
+	}))
+
+	srvInfo := servertesting.StartServer(t, env, false)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             srvInfo.BaseURL,
+		TrustedServerCertificateFingerprint: srvInfo.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUIUsername,
+		Password:                            servertesting.TestUIPassword,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, cli.FetchCSRFTokenForTesting(ctx))
+
+	resp, err := serverapi.GetSnapshotErrors(ctx, cli, id)
+	require.NoError(t, err)
+	require.Equal(t, []snapshotfs.EntryError{
+		{Path: "file2", Phase: snapshotfs.ErrorPhaseReadEntry, Error: "some error"},
+	}, resp.Errors)
+
+	_, err = serverapi.GetSnapshotErrors(ctx, cli, "no-such-snapshot")
+	require.Error(t, err)
+}
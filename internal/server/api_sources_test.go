@@ -159,3 +159,50 @@ func TestSourceRefreshesAfterPolicy(t *testing.T) {
 
 	require.True(t, match)
 }
+
+func TestSourcesRefreshAfterExternalPolicyChange(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+	srvInfo := servertesting.StartServer(t, env, false)
+
+	cli, err := apiclient.NewKopiaAPIClient(apiclient.Options{
+		BaseURL:                             srvInfo.BaseURL,
+		TrustedServerCertificateFingerprint: srvInfo.TrustedServerCertificateFingerprint,
+		Username:                            servertesting.TestUIUsername,
+		Password:                            servertesting.TestUIPassword,
+	})
+
+	require.NoError(t, err)
+	require.NoError(t, cli.FetchCSRFTokenForTesting(ctx))
+
+	require.Empty(t, mustListSources(t, cli, &snapshot.SourceInfo{}))
+
+	si := env.LocalPathSourceInfo(testutil.TempDirectory(t))
+
+	// simulate another client defining a policy for a new source.
+	require.NoError(t, policy.SetPolicy(ctx, env.RepositoryWriter, si, &policy.Policy{}))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	waitForSourceCount(t, cli, 1)
+
+	// removing the policy should stop the source manager.
+	require.NoError(t, policy.RemovePolicy(ctx, env.RepositoryWriter, si))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	waitForSourceCount(t, cli, 0)
+}
+
+func waitForSourceCount(t *testing.T, cli *apiclient.KopiaAPIClient, want int) {
+	t.Helper()
+
+	deadline := clock.Now().Add(30 * time.Second)
+
+	for clock.Now().Before(deadline) {
+		if len(mustListSources(t, cli, &snapshot.SourceInfo{})) == want {
+			return
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	require.Len(t, mustListSources(t, cli, &snapshot.SourceInfo{}), want)
+}
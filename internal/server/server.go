@@ -93,6 +93,8 @@ type Server struct {
 	sourceManagers map[snapshot.SourceInfo]*sourceManager
 	// +checklocks:serverMutex
 	mounts map[object.ID]mount.Controller
+	// +checklocks:serverMutex
+	prefetcher *repo.BackgroundPrefetcher
 
 	taskmgr              *uitask.Manager
 	authCookieSigningKey []byte
@@ -106,6 +108,14 @@ type Server struct {
 	// +checklocks:serverMutex
 	nextRefreshTime time.Time
 
+	// +checklocks:serverMutex
+	nextPolicyWatchTime time.Time
+
+	// +checklocks:serverMutex
+	policyFingerprint string // fingerprint of policies and sources at the time of last sync
+
+	contentUploads contentUploadSessions
+
 	grpcServerState
 }
 
@@ -121,6 +131,7 @@ func (s *Server) SetupHTMLUIAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/snapshots", s.handleUI(handleListSnapshots)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/snapshots/delete", s.handleUI(handleDeleteSnapshots)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/snapshots/edit", s.handleUI(handleEditSnapshots)).Methods(http.MethodPost)
+	m.HandleFunc("/api/v1/snapshots/{snapshotID}/errors", s.handleUI(handleSnapshotErrors)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/policy", s.handleUI(handlePolicyGet)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/policy", s.handleUI(handlePolicyPut)).Methods(http.MethodPut)
 	m.HandleFunc("/api/v1/policy", s.handleUI(handlePolicyDelete)).Methods(http.MethodDelete)
@@ -128,6 +139,7 @@ func (s *Server) SetupHTMLUIAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/policies", s.handleUI(handlePolicyList)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/refresh", s.handleUI(handleRefresh)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/objects/{objectID}", s.requireAuth(csrfTokenNotRequired, handleObjectGet)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/objects/prefetch", s.handleUI(handleObjectsPrefetch)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/restore", s.handleUI(handleRestore)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/estimate", s.handleUI(handleEstimate)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/paths/resolve", s.handleUI(handlePathResolve)).Methods(http.MethodPost)
@@ -143,6 +155,11 @@ func (s *Server) SetupHTMLUIAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/repo/throttle", s.handleUI(handleRepoGetThrottle)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/repo/throttle", s.handleUI(handleRepoSetThrottle)).Methods(http.MethodPut)
 
+	m.HandleFunc("/api/v1/refs", s.handleUI(handleRootReferenceCreate)).Methods(http.MethodPost)
+	m.HandleFunc("/api/v1/refs", s.handleUI(handleRootReferenceList)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/refs/{referenceID}", s.handleUI(handleRootReferenceDelete)).Methods(http.MethodDelete)
+	m.HandleFunc("/api/v1/refs/{token}/contents", s.requireAuth(csrfTokenNotRequired, handleRootReferenceContents)).Methods(http.MethodGet)
+
 	m.HandleFunc("/api/v1/mounts", s.handleUI(handleMountCreate)).Methods(http.MethodPost)
 	m.HandleFunc("/api/v1/mounts/{rootObjectID}", s.handleUI(handleMountDelete)).Methods(http.MethodDelete)
 	m.HandleFunc("/api/v1/mounts/{rootObjectID}", s.handleUI(handleMountGet)).Methods(http.MethodGet)
@@ -167,6 +184,8 @@ func (s *Server) SetupRepositoryAPIHandlers(m *mux.Router) {
 	m.HandleFunc("/api/v1/contents/{contentID}", s.handleRepositoryAPI(requireContentAccess(auth.AccessLevelRead), handleContentInfo)).Methods(http.MethodGet).Queries("info", "1")
 	m.HandleFunc("/api/v1/contents/{contentID}", s.handleRepositoryAPI(requireContentAccess(auth.AccessLevelRead), handleContentGet)).Methods(http.MethodGet)
 	m.HandleFunc("/api/v1/contents/{contentID}", s.handleRepositoryAPI(requireContentAccess(auth.AccessLevelAppend), handleContentPut)).Methods(http.MethodPut)
+	m.HandleFunc("/api/v1/contents/{contentID}/upload", s.handleRepositoryAPI(requireContentAccess(auth.AccessLevelAppend), handleContentUploadStatus)).Methods(http.MethodGet)
+	m.HandleFunc("/api/v1/contents/{contentID}/upload", s.handleRepositoryAPI(requireContentAccess(auth.AccessLevelAppend), handleContentUploadChunk)).Methods(http.MethodPut)
 	m.HandleFunc("/api/v1/contents/prefetch", s.handleRepositoryAPI(requireContentAccess(auth.AccessLevelRead), handleContentPrefetch)).Methods(http.MethodPost)
 
 	m.HandleFunc("/api/v1/manifests/{manifestID}", s.handleRepositoryAPI(handlerWillCheckAuthorization, handleManifestGet)).Methods(http.MethodGet)
@@ -580,11 +599,19 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 
 		s.unmountAllLocked(ctx)
 
+		if s.prefetcher != nil {
+			s.prefetcher.Close()
+			s.prefetcher = nil
+		}
+
 		// close previous source managers
 		log(ctx).Debugf("stopping all source managers")
 		s.stopAllSourceManagersLocked(ctx)
 		log(ctx).Debugf("stopped all source managers")
 
+		// partial uploads are specific to the repository.
+		s.contentUploads.closeAll()
+
 		if err := s.rep.Close(ctx); err != nil {
 			return errors.Wrap(err, "unable to close previous repository")
 		}
@@ -614,6 +641,8 @@ func (s *Server) SetRepository(ctx context.Context, rep repo.Repository) error {
 		s.maint = nil
 	}
 
+	s.prefetcher = repo.NewBackgroundPrefetcher(ctx, s.rep, repo.BackgroundPrefetchOptions{})
+
 	s.sched = scheduler.Start(ctxutil.Detach(ctx), s.getSchedulerItems, scheduler.Options{
 		TimeNow:        clock.Now,
 		Debug:          s.options.DebugScheduler,
@@ -704,6 +733,15 @@ func (s *Server) syncSourcesLocked(ctx context.Context) error {
 		delete(s.sourceManagers, src)
 	}
 
+	if s.rep != nil {
+		fp, err := policyFingerprint(ctx, s.rep)
+		if err != nil {
+			return err
+		}
+
+		s.policyFingerprint = fp
+	}
+
 	s.refreshScheduler("sources refreshed")
 
 	return nil
@@ -812,6 +850,7 @@ type Options struct {
 	ConfigFile             string
 	ConnectOptions         *repo.ConnectOptions
 	RefreshInterval        time.Duration
+	PolicyWatchInterval    time.Duration // how often to check for policy changes made by other clients, 0 disables
 	MaxConcurrency         int
 	Authenticator          auth.Authenticator
 	Authorizer             auth.Authorizer
@@ -826,6 +865,7 @@ type Options struct {
 	UITitlePrefix          string
 	DebugScheduler         bool
 	MinMaintenanceInterval time.Duration
+	LowMemory              bool // reduce memory usage of snapshots at the expense of performance
 }
 
 // InitRepositoryFunc is a function that attempts to connect to/open repository.
@@ -1028,6 +1068,15 @@ func (s *Server) getSchedulerItems(ctx context.Context, now time.Time) []schedul
 		NextTime:    s.nextRefreshTime,
 	})
 
+	if s.options.PolicyWatchInterval > 0 {
+		// add a scheduled item to pick up policy changes made by other clients
+		result = append(result, scheduler.Item{
+			Description: "policy watch",
+			Trigger:     s.checkPolicyChangesAsync,
+			NextTime:    s.nextPolicyWatchTime,
+		})
+	}
+
 	if s.maint != nil {
 		// If we have a direct repository, add an item to run maintenance.
 		// If we're the owner then nextMaintenanceTime will be zero.
@@ -1060,6 +1109,11 @@ func (s *Server) getSchedulerItems(ctx context.Context, now time.Time) []schedul
 	return result
 }
 
+// isLowMemory returns true if snapshots should reduce memory usage at the expense of performance.
+func (s *Server) isLowMemory() bool {
+	return s.options.LowMemory
+}
+
 func (s *Server) refreshScheduler(reason string) {
 	select {
 	case s.schedulerRefresh <- reason:
@@ -1095,6 +1149,7 @@ func New(ctx context.Context, options *Options) (*Server, error) {
 		mounts:               map[object.ID]mount.Controller{},
 		authCookieSigningKey: []byte(options.AuthCookieSigningKey),
 		nextRefreshTime:      clock.Now().Add(options.RefreshInterval),
+		nextPolicyWatchTime:  clock.Now().Add(options.PolicyWatchInterval),
 		schedulerRefresh:     make(chan string, 1),
 	}
 
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+// policyFingerprint computes a fingerprint of all policy manifests and known snapshot sources
+// that changes whenever a policy is created, modified or deleted, or a new source appears.
+func policyFingerprint(ctx context.Context, rep repo.Repository) (string, error) {
+	policies, err := rep.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey: policy.ManifestType,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to list policies")
+	}
+
+	sources, err := snapshot.ListSources(ctx, rep)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to list sources")
+	}
+
+	var items []string
+
+	for _, md := range policies {
+		items = append(items, fmt.Sprintf("policy:%v:%v", md.ID, md.ModTime.UnixNano()))
+	}
+
+	for _, src := range sources {
+		items = append(items, "source:"+src.String())
+	}
+
+	sort.Strings(items)
+
+	h := sha256.New()
+
+	for _, it := range items {
+		fmt.Fprintln(h, it)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *Server) checkPolicyChangesAsync() {
+	// prevent policy check from being runnable until it completes.
+	s.serverMutex.Lock()
+	s.nextPolicyWatchTime = clock.Now().Add(s.options.PolicyWatchInterval)
+	s.serverMutex.Unlock()
+
+	go s.checkPolicyChanges()
+}
+
+// checkPolicyChanges detects changes to policies made by other clients and applies them
+// to the running source managers and scheduler without a full server restart.
+func (s *Server) checkPolicyChanges() {
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+
+	ctx := s.rootctx
+
+	if err := s.checkPolicyChangesLocked(ctx); err != nil {
+		log(ctx).Warnw("policy watch error", "err", err)
+	}
+}
+
+// +checklocks:s.serverMutex
+func (s *Server) checkPolicyChangesLocked(ctx context.Context) error {
+	if s.rep == nil {
+		return nil
+	}
+
+	s.nextPolicyWatchTime = clock.Now().Add(s.options.PolicyWatchInterval)
+
+	if err := s.rep.Refresh(ctx); err != nil {
+		return errors.Wrap(err, "unable to refresh repository")
+	}
+
+	fp, err := policyFingerprint(ctx, s.rep)
+	if err != nil {
+		return err
+	}
+
+	if fp == s.policyFingerprint {
+		return nil
+	}
+
+	log(ctx).Debugf("policies or sources changed, re-synchronizing sources")
+
+	if err := s.syncSourcesLocked(ctx); err != nil {
+		return errors.Wrap(err, "unable to sync sources")
+	}
+
+	return nil
+}
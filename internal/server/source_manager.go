@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,6 +30,7 @@
 type sourceManagerServerInterface interface {
 	runSnapshotTask(ctx context.Context, src snapshot.SourceInfo, inner func(ctx context.Context, ctrl uitask.Controller) error) error
 	refreshScheduler(reason string)
+	isLowMemory() bool
 }
 
 // sourceManager manages the state machine of each source
@@ -337,6 +339,7 @@ func (s *sourceManager) snapshotInternal(ctx context.Context, ctrl uitask.Contro
 	}, func(ctx context.Context, w repo.RepositoryWriter) error {
 		log(ctx).Debugf("uploading %v", s.src)
 		u := snapshotfs.NewUploader(w)
+		u.LowMemory = s.server.isLowMemory()
 
 		ctrl.OnCancel(u.Cancel)
 
@@ -375,11 +378,33 @@ func (s *sourceManager) snapshotInternal(ctx context.Context, ctrl uitask.Contro
 			}
 		}
 
+		pol := policyTree.EffectivePolicy()
+
+		anomalyActions, err := snapshotfs.DetectAnomalies(ctx, w, pol, manifestsSinceLastCompleteSnapshot, manifest)
+		if err != nil {
+			return errors.Wrap(err, "unable to detect anomalies")
+		}
+
+		for _, a := range manifest.Anomalies {
+			log(ctx).Warnf("snapshot of %v: %v", s.src, a)
+		}
+
+		if slices.Contains(anomalyActions, policy.AnomalyActionConfirm) {
+			// there is no way to ask for confirmation, the snapshot must be created manually.
+			return errors.Errorf("snapshot of %v has not been saved because of anomalies which require confirmation", s.src)
+		}
+
 		snapshotID, err := snapshot.SaveSnapshot(ctx, w, manifest)
 		if err != nil {
 			return errors.Wrap(err, "unable to save snapshot")
 		}
 
+		if slices.Contains(anomalyActions, policy.AnomalyActionNotify) {
+			if err := u.ExecuteSnapshotAnomalyAction(ctx, pol.Actions.SnapshotAnomaly, manifest); err != nil {
+				log(ctx).Errorf("unable to notify about snapshot anomalies: %v", err)
+			}
+		}
+
 		if _, err := policy.ApplyRetentionPolicy(ctx, w, s.src, true); err != nil {
 			return errors.Wrap(err, "unable to apply retention policy")
 		}
@@ -436,6 +461,11 @@ func (s *sourceManager) refreshStatus(ctx context.Context) {
 	if len(snaps) > 0 {
 		s.lastSnapshot = snaps[0]
 		for _, sn := range snaps {
+			// snapshots of a subtree of the source can't be used as a baseline for full snapshots.
+			if sn.Subpath != "" {
+				continue
+			}
+
 			s.manifestsSinceLastCompleteSnapshot = append(s.manifestsSinceLastCompleteSnapshot, sn)
 
 			// complete snapshot, end here
@@ -518,6 +548,11 @@ func (t *uitaskProgress) HashedBytes(numBytes int64) {
 	t.maybeReport()
 }
 
+// HashedFileBytes is emitted while hashing blocks of bytes of a given file.
+func (t *uitaskProgress) HashedFileBytes(fname string, numBytes int64) {
+	t.p.HashedFileBytes(fname, numBytes)
+}
+
 // Error is emitted when an error is encountered.
 func (t *uitaskProgress) Error(path string, err error, isIgnored bool) {
 	t.p.Error(path, err, isIgnored)
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -10,6 +11,7 @@
 	"github.com/kopia/kopia/internal/apiclient"
 	"github.com/kopia/kopia/internal/uitask"
 	"github.com/kopia/kopia/repo/blob/throttling"
+	"github.com/kopia/kopia/repo/manifest"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
@@ -164,6 +166,16 @@ func ListSnapshots(ctx context.Context, c *apiclient.KopiaAPIClient, src snapsho
 	return resp, nil
 }
 
+// GetSnapshotErrors returns errors encountered when creating the provided snapshot.
+func GetSnapshotErrors(ctx context.Context, c *apiclient.KopiaAPIClient, id manifest.ID) (*SnapshotErrorsResponse, error) {
+	resp := &SnapshotErrorsResponse{}
+	if err := c.Get(ctx, "snapshots/"+string(id)+"/errors", nil, resp); err != nil {
+		return nil, errors.Wrap(err, "GetSnapshotErrors")
+	}
+
+	return resp, nil
+}
+
 // ListPolicies lists the policies managed by the server for a given target filter.
 func ListPolicies(ctx context.Context, c *apiclient.KopiaAPIClient, match *snapshot.SourceInfo) (*PoliciesResponse, error) {
 	resp := &PoliciesResponse{}
@@ -220,6 +232,47 @@ func GetObject(ctx context.Context, c *apiclient.KopiaAPIClient, objectID string
 	return b, nil
 }
 
+// CreateRootReference pins the root of a tree and returns the reference with a token.
+func CreateRootReference(ctx context.Context, c *apiclient.KopiaAPIClient, req *CreateRootReferenceRequest) (*RootReference, error) {
+	resp := &RootReference{}
+	if err := c.Post(ctx, "refs", req, resp); err != nil {
+		return nil, errors.Wrap(err, "CreateRootReference")
+	}
+
+	return resp, nil
+}
+
+// ListRootReferences lists root references.
+func ListRootReferences(ctx context.Context, c *apiclient.KopiaAPIClient) (*RootReferencesResponse, error) {
+	resp := &RootReferencesResponse{}
+	if err := c.Get(ctx, "refs", nil, resp); err != nil {
+		return nil, errors.Wrap(err, "ListRootReferences")
+	}
+
+	return resp, nil
+}
+
+// DeleteRootReference deletes the root reference with the provided ID.
+func DeleteRootReference(ctx context.Context, c *apiclient.KopiaAPIClient, id manifest.ID) error {
+	if err := c.Delete(ctx, "refs/"+string(id), nil, nil, &Empty{}); err != nil {
+		return errors.Wrap(err, "DeleteRootReference")
+	}
+
+	return nil
+}
+
+// GetRootReferenceContents returns the contents of the file or the JSON-encoded RootReferenceDirectoryResponse
+// of the directory at the provided path within the tree referenced by the token.
+func GetRootReferenceContents(ctx context.Context, c *apiclient.KopiaAPIClient, token, path string) ([]byte, error) {
+	var b []byte
+
+	if err := c.Get(ctx, "refs/"+token+"/contents?path="+url.QueryEscape(path), nil, &b); err != nil {
+		return nil, errors.Wrap(err, "GetRootReferenceContents")
+	}
+
+	return b, nil
+}
+
 func matchSourceParameters(match *snapshot.SourceInfo) string {
 	if match == nil {
 		return ""
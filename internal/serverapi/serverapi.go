@@ -93,6 +93,7 @@ type Empty struct{
 	ErrorPathNotFound       APIErrorCode = "PATH_NOT_FOUND"
 	ErrorStorageConnection  APIErrorCode = "STORAGE_CONNECTION"
 	ErrorAccessDenied       APIErrorCode = "ACCESS_DENIED"
+	ErrorTooManyRequests    APIErrorCode = "TOO_MANY_REQUESTS"
 )
 
 // ErrorResponse represents error response.
@@ -175,6 +176,7 @@ type Snapshot struct {
 	RootEntry        string               `json:"rootID"`
 	RetentionReasons []string             `json:"retention"`
 	Pins             []string             `json:"pins"`
+	Histograms       *snapshot.Histograms `json:"histograms,omitempty"`
 }
 
 // SnapshotsResponse contains a list of snapshots.
@@ -184,6 +186,12 @@ type SnapshotsResponse struct {
 	UniqueCount     int         `json:"uniqueCount"`
 }
 
+// SnapshotErrorsResponse contains errors encountered when creating a snapshot.
+type SnapshotErrorsResponse struct {
+	Errors  []snapshotfs.EntryError `json:"errors"`
+	Omitted int                     `json:"omitted,omitempty"`
+}
+
 // DeleteSnapshotsRequest contains request to delete a number of snapshots and optionally the
 // entire snapshot source.
 type DeleteSnapshotsRequest struct {
@@ -205,6 +213,12 @@ type MountSnapshotRequest struct {
 	Root string `json:"root"`
 }
 
+// PrefetchObjectsRequest contains request to prefetch objects into the cache in the background.
+type PrefetchObjectsRequest struct {
+	ObjectIDs []object.ID `json:"objectIds"`
+	Hint      string      `json:"hint,omitempty"`
+}
+
 // UnmountSnapshotRequest contains request to unmount a snapshot.
 type UnmountSnapshotRequest struct {
 	Root string `json:"root"`
@@ -295,3 +309,28 @@ type UIPreferences struct {
 	FontSize               string `json:"fontSize"`               // Specifies the font size used by the UI
 	PageSize               int    `json:"pageSize"`               // A page size; the actual possible values will only be provided by the frontend
 }
+
+// CreateRootReferenceRequest contains request to pin the root of a tree and mint a token referencing it.
+type CreateRootReferenceRequest struct {
+	// object ID, snapshot ID or root object ID optionally followed by path within the snapshot.
+	Root        string    `json:"root"`
+	Description string    `json:"description,omitempty"`
+	ExpireTime  time.Time `json:"expireTime,omitempty"`
+}
+
+// RootReference describes a root reference along with an optional token.
+type RootReference struct {
+	ID    manifest.ID `json:"id"`
+	Token string      `json:"token,omitempty"`
+	*snapshotfs.RootReference
+}
+
+// RootReferencesResponse contains a list of root references.
+type RootReferencesResponse struct {
+	References []*RootReference `json:"references"`
+}
+
+// RootReferenceDirectoryResponse contains entries of a directory within a referenced tree.
+type RootReferenceDirectoryResponse struct {
+	Entries []*snapshot.DirEntry `json:"entries"`
+}
@@ -46,9 +46,10 @@ func StartServer(t *testing.T, env *repotesting.Environment, tls bool) *repo.API
 			auth.AuthenticateSingleUser(TestUsername+"@"+TestHostname, TestPassword),
 			auth.AuthenticateSingleUser(TestUIUsername, TestUIPassword),
 		),
-		RefreshInterval:   1 * time.Minute,
-		UIUser:            TestUIUsername,
-		UIPreferencesFile: filepath.Join(testutil.TempDirectory(t), "ui-pref.json"),
+		RefreshInterval:     1 * time.Minute,
+		PolicyWatchInterval: 1 * time.Second,
+		UIUser:              TestUIUsername,
+		UIPreferencesFile:   filepath.Join(testutil.TempDirectory(t), "ui-pref.json"),
 	})
 
 	require.NoError(t, err)
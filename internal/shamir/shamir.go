@@ -0,0 +1,234 @@
+// Package shamir implements Shamir's secret sharing over GF(256), which allows a secret to be split into
+// N shares such that any K of them can be used to reconstruct it, while fewer than K reveal nothing about it.
+package shamir
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxShares is the maximum number of shares a secret can be split into.
+const MaxShares = 255
+
+// Share is a single share of a split secret.
+type Share struct {
+	// Threshold is the number of shares required to reconstruct the secret.
+	Threshold int
+
+	// Index is the non-zero x coordinate of the share.
+	Index int
+
+	// Data contains share bytes, one for each byte of the secret.
+	Data []byte
+}
+
+// String returns textual representation of the share in the form 'threshold-index-data-checksum'.
+func (s Share) String() string {
+	d := hex.EncodeToString(s.Data)
+
+	return fmt.Sprintf("%v-%v-%v-%08x", s.Threshold, s.Index, d, shareChecksum(s.Threshold, s.Index, d))
+}
+
+func shareChecksum(threshold, index int, hexData string) uint32 {
+	return crc32.ChecksumIEEE([]byte(fmt.Sprintf("%v-%v-%v", threshold, index, hexData)))
+}
+
+// ParseShare parses the share from its textual representation produced by Share.String().
+func ParseShare(s string) (Share, error) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) != 4 { //nolint:gomnd
+		return Share{}, errors.New("malformed share")
+	}
+
+	threshold, err := strconv.Atoi(parts[0])
+	if err != nil || threshold < 1 || threshold > MaxShares {
+		return Share{}, errors.New("invalid share threshold")
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	if err != nil || index < 1 || index > MaxShares {
+		return Share{}, errors.New("invalid share index")
+	}
+
+	data, err := hex.DecodeString(parts[2])
+	if err != nil || len(data) == 0 {
+		return Share{}, errors.New("invalid share data")
+	}
+
+	checksum, err := strconv.ParseUint(parts[3], 16, 32)
+	if err != nil || uint32(checksum) != shareChecksum(threshold, index, parts[2]) {
+		return Share{}, errors.New("share checksum mismatch, the share may have been mistyped")
+	}
+
+	return Share{threshold, index, data}, nil
+}
+
+// Split splits the secret into n shares, any k of which are required to reconstruct it.
+func Split(secret []byte, n, k int) ([]Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("secret must not be empty")
+	}
+
+	if k < 1 || n < k || n > MaxShares {
+		return nil, errors.Errorf("invalid threshold %v of %v shares", k, n)
+	}
+
+	shares := make([]Share, n)
+	for i := range shares {
+		shares[i] = Share{Threshold: k, Index: i + 1, Data: make([]byte, len(secret))}
+	}
+
+	// coefficients of the random polynomial of degree k-1, the constant term is the secret byte.
+	coef := make([]byte, k)
+
+	for b, sb := range secret {
+		if _, err := rand.Read(coef[1:]); err != nil {
+			return nil, errors.Wrap(err, "unable to generate random coefficients")
+		}
+
+		coef[0] = sb
+
+		for i := range shares {
+			shares[i].Data[b] = evaluate(coef, byte(shares[i].Index))
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine reconstructs the secret from the provided shares, which must include at least the threshold number
+// of distinct shares of the same secret.
+func Combine(shares []Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+
+	threshold := shares[0].Threshold
+	length := len(shares[0].Data)
+	seen := map[int]bool{}
+
+	var use []Share
+
+	for _, s := range shares {
+		if s.Threshold != threshold || len(s.Data) != length {
+			return nil, errors.New("shares do not belong to the same secret")
+		}
+
+		if s.Index < 1 || s.Index > MaxShares {
+			return nil, errors.Errorf("invalid share index %v", s.Index)
+		}
+
+		if seen[s.Index] {
+			continue
+		}
+
+		seen[s.Index] = true
+
+		use = append(use, s)
+	}
+
+	if len(use) < threshold {
+		return nil, errors.Errorf("%v distinct shares are required, got %v", threshold, len(use))
+	}
+
+	use = use[0:threshold]
+
+	// Lagrange basis polynomials evaluated at x=0.
+	basis := make([]byte, len(use))
+
+	for i, si := range use {
+		num, den := byte(1), byte(1)
+
+		for j, sj := range use {
+			if i == j {
+				continue
+			}
+
+			num = mul(num, byte(sj.Index))
+			den = mul(den, byte(si.Index)^byte(sj.Index))
+		}
+
+		basis[i] = mul(num, inverse(den))
+	}
+
+	secret := make([]byte, length)
+
+	for b := range secret {
+		var v byte
+
+		for i, s := range use {
+			v ^= mul(s.Data[b], basis[i])
+		}
+
+		secret[b] = v
+	}
+
+	return secret, nil
+}
+
+// evaluate evaluates the polynomial with provided coefficients at x using Horner's method.
+func evaluate(coef []byte, x byte) byte {
+	var v byte
+
+	for i := len(coef) - 1; i >= 0; i-- {
+		v = mul(v, x) ^ coef[i]
+	}
+
+	return v
+}
+
+// mul multiplies two elements of GF(256) modulo the AES polynomial x^8+x^4+x^3+x+1.
+func mul(a, b byte) byte {
+	var p byte
+
+	for i := 0; i < 8; i++ {
+		// branch-free conditional addition and reduction.
+		p ^= -(b & 1) & a
+		a = (a << 1) ^ (-(a >> 7) & 0x1b) //nolint:gomnd
+		b >>= 1
+	}
+
+	return p
+}
+
+// inverse returns multiplicative inverse of a non-zero element of GF(256), computed as a^254.
+func inverse(a byte) byte {
+	result := byte(1)
+
+	for i := 0; i < 254; i++ {
+		result = mul(result, a)
+	}
+
+	return result
+}
+
+// ParseScheme parses the sharing scheme in the form 'K/N' meaning that the secret is split into N shares
+// any K of which are required to reconstruct it.
+func ParseScheme(s string) (k, n int, err error) {
+	ks, ns, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, errors.Errorf("invalid sharing scheme %q, expected K/N", s)
+	}
+
+	k, err = strconv.Atoi(ks)
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid threshold %q", ks)
+	}
+
+	n, err = strconv.Atoi(ns)
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid number of shares %q", ns)
+	}
+
+	if k < 1 || n < k || n > MaxShares {
+		return 0, 0, errors.Errorf("invalid sharing scheme %q, must satisfy 1 <= K <= N <= %v", s, MaxShares)
+	}
+
+	return k, n, nil
+}
@@ -0,0 +1,118 @@
+package shamir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCombine(t *testing.T) {
+	secret := []byte("some very secret repository password")
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	// any 3 shares reconstruct the secret.
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			for k := j + 1; k < 5; k++ {
+				got, err := Combine([]Share{shares[i], shares[j], shares[k]})
+				require.NoError(t, err)
+				require.Equal(t, secret, got)
+			}
+		}
+	}
+
+	// all shares work too.
+	got, err := Combine(shares)
+	require.NoError(t, err)
+	require.Equal(t, secret, got)
+
+	// two shares are not enough, duplicates do not count.
+	_, err = Combine([]Share{shares[0], shares[1]})
+	require.ErrorContains(t, err, "3 distinct shares are required, got 2")
+
+	_, err = Combine([]Share{shares[0], shares[1], shares[1]})
+	require.ErrorContains(t, err, "3 distinct shares are required, got 2")
+
+	// shares of different secrets can't be mixed.
+	other, err := Split([]byte("short"), 5, 3)
+	require.NoError(t, err)
+
+	_, err = Combine([]Share{shares[0], shares[1], other[2]})
+	require.ErrorContains(t, err, "shares do not belong to the same secret")
+}
+
+func TestSplitThresholdOne(t *testing.T) {
+	shares, err := Split([]byte{1, 2, 3}, 3, 1)
+	require.NoError(t, err)
+
+	for _, s := range shares {
+		require.Equal(t, []byte{1, 2, 3}, s.Data)
+	}
+}
+
+func TestSplitInvalid(t *testing.T) {
+	_, err := Split(nil, 3, 2)
+	require.Error(t, err)
+
+	_, err = Split([]byte{1}, 2, 3)
+	require.Error(t, err)
+
+	_, err = Split([]byte{1}, 256, 3)
+	require.Error(t, err)
+
+	_, err = Split([]byte{1}, 3, 0)
+	require.Error(t, err)
+}
+
+func TestShareString(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	for _, s := range shares {
+		p, err := ParseShare(s.String())
+		require.NoError(t, err)
+		require.Equal(t, s, p)
+	}
+
+	str := shares[0].String()
+
+	// flip a single hex digit of the data.
+	b := []byte(str)
+	if b[4] == '0' {
+		b[4] = '1'
+	} else {
+		b[4] = '0'
+	}
+
+	_, err = ParseShare(string(b))
+	require.ErrorContains(t, err, "checksum mismatch")
+
+	for _, invalid := range []string{"", "1-2-3", "x-1-00-00000000", "2-0-00-00000000", "2-1-zz-00000000", "2-1-00-zz"} {
+		_, err = ParseShare(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestField(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		require.Equal(t, byte(1), mul(byte(a), inverse(byte(a))))
+	}
+
+	// known product from FIPS-197.
+	require.Equal(t, byte(0xc1), mul(0x57, 0x83))
+}
+
+func TestParseScheme(t *testing.T) {
+	k, n, err := ParseScheme("3/5")
+	require.NoError(t, err)
+	require.Equal(t, 3, k)
+	require.Equal(t, 5, n)
+
+	for _, invalid := range []string{"", "3", "5/3", "0/3", "a/3", "3/b", "2/256"} {
+		_, _, err := ParseScheme(invalid)
+		require.Error(t, err, invalid)
+	}
+}
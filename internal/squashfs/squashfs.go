@@ -0,0 +1,676 @@
+// Package squashfs implements a writer of read-only SquashFS 4.0 filesystem images.
+//
+// The writer stores file data in gzip (zlib)-compressed blocks without fragments, extended
+// attributes or an export table, which is sufficient for the image to be mounted by Linux kernel
+// or extracted using standard tools.
+package squashfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	superblockSize = 96
+	magic          = 0x73717368
+
+	// BlockSize is the size of data blocks in the image.
+	BlockSize = 128 << 10
+	blockLog  = 17
+
+	metadataBlockSize      = 8192
+	metadataUncompressed   = 0x8000
+	dataBlockUncompressed  = 1 << 24
+	compressionGzip        = 1
+	flagNoFragments        = 0x10
+	flagNoXattrs           = 0x200
+	invalidTableStart      = 0xFFFFFFFFFFFFFFFF
+	invalidFragment        = 0xFFFFFFFF
+	invalidXattr           = 0xFFFFFFFF
+	imagePadding           = 4096
+	maxNameLength          = 256
+	maxEntriesPerDirHeader = 256
+	maxInodeNumberDelta    = 32767
+	idsPerMetadataBlock    = metadataBlockSize / 4 //nolint:gomnd
+
+	inodeTypeBasicDir     = 1
+	inodeTypeBasicFile    = 2
+	inodeTypeBasicSymlink = 3
+	inodeTypeExtDir       = 8
+	inodeTypeExtFile      = 9
+)
+
+// EntryInfo contains attributes of an entry added to the image.
+type EntryInfo struct {
+	Mode    os.FileMode
+	UserID  uint32
+	GroupID uint32
+	ModTime time.Time
+}
+
+type node struct {
+	name     string
+	info     EntryInfo
+	children map[string]*node
+	parent   *node
+
+	inodeType uint16 // basic inode type (directory, file or symlink)
+
+	// files
+	fileSize    int64
+	blocksStart int64
+	blockSizes  []uint32
+
+	// symlinks
+	target string
+
+	// directories, assigned when writing directory table.
+	listing dirListing
+
+	// assigned when writing inode table.
+	inodeNumber uint32
+	inodeBlock  uint32
+	inodeOffset uint16
+}
+
+// Writer writes SquashFS image to the provided output.
+type Writer struct {
+	out io.WriteSeeker
+	pos int64
+
+	root      *node
+	nodeCount int
+
+	ids   map[uint32]uint16
+	idTab []uint32
+
+	closed bool
+}
+
+// NewWriter returns a new Writer that writes the image to a given output, which must be positioned at the beginning.
+func NewWriter(out io.WriteSeeker) (*Writer, error) {
+	w := &Writer{
+		out: out,
+		root: &node{
+			inodeType: inodeTypeBasicDir,
+			children:  map[string]*node{},
+			info:      EntryInfo{Mode: os.ModeDir | 0o755}, //nolint:gomnd
+		},
+		nodeCount: 1,
+		ids:       map[uint32]uint16{},
+	}
+
+	// reserve space for superblock, which will be written on Close()
+	if err := w.write(make([]byte, superblockSize)); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Mkdir adds a directory with a given slash-separated relative path to the image.
+// Empty path refers to the root directory. Parent directory must already exist.
+func (w *Writer) Mkdir(relativePath string, info EntryInfo) error {
+	if relativePath == "" {
+		w.root.info = info
+		return nil
+	}
+
+	_, err := w.addNode(relativePath, &node{
+		inodeType: inodeTypeBasicDir,
+		info:      info,
+		children:  map[string]*node{},
+	})
+
+	return err
+}
+
+// Symlink adds a symbolic link with a given slash-separated relative path to the image.
+func (w *Writer) Symlink(relativePath, target string, info EntryInfo) error {
+	_, err := w.addNode(relativePath, &node{
+		inodeType: inodeTypeBasicSymlink,
+		info:      info,
+		target:    target,
+	})
+
+	return err
+}
+
+// WriteFile adds a file with a given slash-separated relative path to the image, reading its contents from the provided reader.
+func (w *Writer) WriteFile(relativePath string, r io.Reader, info EntryInfo) error {
+	n, err := w.addNode(relativePath, &node{
+		inodeType: inodeTypeBasicFile,
+		info:      info,
+	})
+	if err != nil {
+		return err
+	}
+
+	n.blocksStart = w.pos
+
+	buf := make([]byte, BlockSize)
+
+	for {
+		cnt, err := io.ReadFull(r, buf)
+		if cnt > 0 {
+			if werr := w.writeDataBlock(n, buf[0:cnt]); werr != nil {
+				return werr
+			}
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "error reading file contents")
+		}
+	}
+}
+
+func (w *Writer) writeDataBlock(n *node, data []byte) error {
+	n.fileSize += int64(len(data))
+
+	compressed, err := compress(data)
+	if err != nil {
+		return err
+	}
+
+	if len(compressed) < len(data) {
+		n.blockSizes = append(n.blockSizes, uint32(len(compressed)))
+		return w.write(compressed)
+	}
+
+	n.blockSizes = append(n.blockSizes, uint32(len(data))|dataBlockUncompressed)
+
+	return w.write(data)
+}
+
+func (w *Writer) addNode(relativePath string, n *node) (*node, error) {
+	if w.closed {
+		return nil, errors.Errorf("writer is closed")
+	}
+
+	dir, name := path.Split(relativePath)
+
+	if name == "" || name == "." || name == ".." || len(name) > maxNameLength {
+		return nil, errors.Errorf("invalid name: %q", relativePath)
+	}
+
+	parent, err := w.lookupDir(strings.TrimSuffix(dir, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	if parent.children[name] != nil {
+		return nil, errors.Errorf("duplicate entry: %q", relativePath)
+	}
+
+	n.name = name
+	n.parent = parent
+	parent.children[name] = n
+	w.nodeCount++
+
+	return n, nil
+}
+
+func (w *Writer) lookupDir(relativePath string) (*node, error) {
+	n := w.root
+
+	if relativePath == "" {
+		return n, nil
+	}
+
+	for _, p := range strings.Split(relativePath, "/") {
+		n = n.children[p]
+		if n == nil || n.inodeType != inodeTypeBasicDir {
+			return nil, errors.Errorf("parent directory not found: %q", relativePath)
+		}
+	}
+
+	return n, nil
+}
+
+// Close writes inode, directory and ID tables followed by the superblock. It does not close the underlying output.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+
+	w.closed = true
+
+	var (
+		inodes metadataWriter
+		dirs   metadataWriter
+	)
+
+	var nextInodeNumber uint32 = 1
+
+	assignInodeNumbers(w.root, &nextInodeNumber)
+
+	if err := w.writeInodes(w.root, &inodes, &dirs); err != nil {
+		return err
+	}
+
+	inodes.finish()
+	dirs.finish()
+
+	inodeTableStart := w.pos
+	if err := w.write(inodes.out.Bytes()); err != nil {
+		return err
+	}
+
+	directoryTableStart := w.pos
+	if err := w.write(dirs.out.Bytes()); err != nil {
+		return err
+	}
+
+	idTableStart, err := w.writeIDTable()
+	if err != nil {
+		return err
+	}
+
+	bytesUsed := w.pos
+
+	if pad := (imagePadding - bytesUsed%imagePadding) % imagePadding; pad > 0 {
+		if err := w.write(make([]byte, pad)); err != nil {
+			return err
+		}
+	}
+
+	sb := make([]byte, 0, superblockSize)
+	sb = binary.LittleEndian.AppendUint32(sb, magic)
+	sb = binary.LittleEndian.AppendUint32(sb, uint32(w.nodeCount))
+	sb = binary.LittleEndian.AppendUint32(sb, unixTime(w.root.info.ModTime))
+	sb = binary.LittleEndian.AppendUint32(sb, BlockSize)
+	sb = binary.LittleEndian.AppendUint32(sb, 0) // fragment count
+	sb = binary.LittleEndian.AppendUint16(sb, compressionGzip)
+	sb = binary.LittleEndian.AppendUint16(sb, blockLog)
+	sb = binary.LittleEndian.AppendUint16(sb, flagNoFragments|flagNoXattrs)
+	sb = binary.LittleEndian.AppendUint16(sb, uint16(len(w.idTab)))
+	sb = binary.LittleEndian.AppendUint16(sb, 4) //nolint:gomnd // major version
+	sb = binary.LittleEndian.AppendUint16(sb, 0) // minor version
+	sb = binary.LittleEndian.AppendUint64(sb, inodeRef(w.root))
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(bytesUsed))
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(idTableStart))
+	sb = binary.LittleEndian.AppendUint64(sb, invalidTableStart) // xattr table
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(inodeTableStart))
+	sb = binary.LittleEndian.AppendUint64(sb, uint64(directoryTableStart))
+	sb = binary.LittleEndian.AppendUint64(sb, invalidTableStart) // fragment table
+	sb = binary.LittleEndian.AppendUint64(sb, invalidTableStart) // export table
+
+	if _, err := w.out.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "unable to seek to superblock")
+	}
+
+	if _, err := w.out.Write(sb); err != nil {
+		return errors.Wrap(err, "unable to write superblock")
+	}
+
+	return nil
+}
+
+// assignInodeNumbers assigns inode numbers in post-order, so that the root directory gets the highest number.
+func assignInodeNumbers(n *node, next *uint32) {
+	for _, c := range sortedChildren(n) {
+		assignInodeNumbers(c, next)
+	}
+
+	n.inodeNumber = *next
+	*next++
+}
+
+// writeInodes writes inodes of the provided node and all its descendants in post-order, since
+// each directory listing must reference the inodes of its children.
+func (w *Writer) writeInodes(n *node, inodes, dirs *metadataWriter) error {
+	children := sortedChildren(n)
+
+	for _, c := range children {
+		if err := w.writeInodes(c, inodes, dirs); err != nil {
+			return err
+		}
+	}
+
+	if n.inodeType == inodeTypeBasicDir {
+		writeDirListing(n, children, dirs)
+	}
+
+	n.inodeBlock, n.inodeOffset = inodes.position()
+
+	b, err := w.inodeHeader(n)
+	if err != nil {
+		return err
+	}
+
+	switch n.inodeType {
+	case inodeTypeBasicDir:
+		b = w.appendDirInode(b, n, children)
+
+	case inodeTypeBasicFile:
+		b = appendFileInode(b, n)
+
+	case inodeTypeBasicSymlink:
+		b = binary.LittleEndian.AppendUint32(b, 1) // link count
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(n.target)))
+		b = append(b, n.target...)
+	}
+
+	inodes.write(b)
+
+	return nil
+}
+
+func (w *Writer) inodeHeader(n *node) ([]byte, error) {
+	typ := n.inodeType
+
+	uidIndex, err := w.idIndex(n.info.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	gidIndex, err := w.idIndex(n.info.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case typ == inodeTypeBasicFile && (n.blocksStart > 0xFFFFFFFF || n.fileSize > 0xFFFFFFFF):
+		typ = inodeTypeExtFile
+	case typ == inodeTypeBasicDir && needsExtendedDirInode(n):
+		typ = inodeTypeExtDir
+	}
+
+	b := make([]byte, 0, 64) //nolint:gomnd
+	b = binary.LittleEndian.AppendUint16(b, typ)
+	b = binary.LittleEndian.AppendUint16(b, permissionBits(n.info.Mode))
+	b = binary.LittleEndian.AppendUint16(b, uidIndex)
+	b = binary.LittleEndian.AppendUint16(b, gidIndex)
+	b = binary.LittleEndian.AppendUint32(b, unixTime(n.info.ModTime))
+	b = binary.LittleEndian.AppendUint32(b, n.inodeNumber)
+
+	return b, nil
+}
+
+func appendFileInode(b []byte, n *node) []byte {
+	if n.blocksStart > 0xFFFFFFFF || n.fileSize > 0xFFFFFFFF {
+		b = binary.LittleEndian.AppendUint64(b, uint64(n.blocksStart))
+		b = binary.LittleEndian.AppendUint64(b, uint64(n.fileSize))
+		b = binary.LittleEndian.AppendUint64(b, 0) // sparse bytes
+		b = binary.LittleEndian.AppendUint32(b, 1) // link count
+		b = binary.LittleEndian.AppendUint32(b, invalidFragment)
+		b = binary.LittleEndian.AppendUint32(b, 0) // fragment offset
+		b = binary.LittleEndian.AppendUint32(b, invalidXattr)
+	} else {
+		b = binary.LittleEndian.AppendUint32(b, uint32(n.blocksStart))
+		b = binary.LittleEndian.AppendUint32(b, invalidFragment)
+		b = binary.LittleEndian.AppendUint32(b, 0) // fragment offset
+		b = binary.LittleEndian.AppendUint32(b, uint32(n.fileSize))
+	}
+
+	for _, bs := range n.blockSizes {
+		b = binary.LittleEndian.AppendUint32(b, bs)
+	}
+
+	return b
+}
+
+// dirListing describes the location of the directory listing, which is written before the directory inode.
+type dirListing struct {
+	block  uint32
+	offset uint16
+	size   int
+}
+
+func needsExtendedDirInode(n *node) bool {
+	return n.listing.size+3 > 0xFFFF
+}
+
+func (w *Writer) appendDirInode(b []byte, n *node, children []*node) []byte {
+	l := n.listing
+
+	linkCount := uint32(2) //nolint:gomnd
+
+	for _, c := range children {
+		if c.inodeType == inodeTypeBasicDir {
+			linkCount++
+		}
+	}
+
+	parentInode := uint32(w.nodeCount + 1)
+	if n.parent != nil {
+		parentInode = n.parent.inodeNumber
+	}
+
+	if l.size+3 > 0xFFFF {
+		b = binary.LittleEndian.AppendUint32(b, linkCount)
+		b = binary.LittleEndian.AppendUint32(b, uint32(l.size+3)) //nolint:gomnd
+		b = binary.LittleEndian.AppendUint32(b, l.block)
+		b = binary.LittleEndian.AppendUint32(b, parentInode)
+		b = binary.LittleEndian.AppendUint16(b, 0) // index count
+		b = binary.LittleEndian.AppendUint16(b, l.offset)
+		b = binary.LittleEndian.AppendUint32(b, invalidXattr)
+
+		return b
+	}
+
+	b = binary.LittleEndian.AppendUint32(b, l.block)
+	b = binary.LittleEndian.AppendUint32(b, linkCount)
+	b = binary.LittleEndian.AppendUint16(b, uint16(l.size+3)) //nolint:gomnd
+	b = binary.LittleEndian.AppendUint16(b, l.offset)
+	b = binary.LittleEndian.AppendUint32(b, parentInode)
+
+	return b
+}
+
+// writeDirListing writes the listing of a directory, whose children inodes have already been written.
+func writeDirListing(n *node, children []*node, dirs *metadataWriter) {
+	block, offset := dirs.position()
+
+	var b []byte
+
+	for i := 0; i < len(children); {
+		base := children[i]
+
+		j := i
+		for j < len(children) && j-i < maxEntriesPerDirHeader &&
+			children[j].inodeBlock == base.inodeBlock &&
+			abs(int64(children[j].inodeNumber)-int64(base.inodeNumber)) <= maxInodeNumberDelta {
+			j++
+		}
+
+		b = binary.LittleEndian.AppendUint32(b, uint32(j-i-1))
+		b = binary.LittleEndian.AppendUint32(b, base.inodeBlock)
+		b = binary.LittleEndian.AppendUint32(b, base.inodeNumber)
+
+		for _, c := range children[i:j] {
+			b = binary.LittleEndian.AppendUint16(b, c.inodeOffset)
+			b = binary.LittleEndian.AppendUint16(b, uint16(int16(int64(c.inodeNumber)-int64(base.inodeNumber))))
+			b = binary.LittleEndian.AppendUint16(b, c.inodeType)
+			b = binary.LittleEndian.AppendUint16(b, uint16(len(c.name)-1))
+			b = append(b, c.name...)
+		}
+
+		i = j
+	}
+
+	dirs.write(b)
+
+	n.listing = dirListing{block, offset, len(b)}
+}
+
+func (w *Writer) writeIDTable() (int64, error) {
+	var blockPositions []int64
+
+	for i := 0; i < len(w.idTab); i += idsPerMetadataBlock {
+		end := i + idsPerMetadataBlock
+		if end > len(w.idTab) {
+			end = len(w.idTab)
+		}
+
+		var b []byte
+		for _, id := range w.idTab[i:end] {
+			b = binary.LittleEndian.AppendUint32(b, id)
+		}
+
+		var m metadataWriter
+
+		m.write(b)
+		m.finish()
+
+		blockPositions = append(blockPositions, w.pos)
+
+		if err := w.write(m.out.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+
+	idTableStart := w.pos
+
+	var index []byte
+	for _, p := range blockPositions {
+		index = binary.LittleEndian.AppendUint64(index, uint64(p))
+	}
+
+	return idTableStart, w.write(index)
+}
+
+func (w *Writer) idIndex(id uint32) (uint16, error) {
+	if ndx, ok := w.ids[id]; ok {
+		return ndx, nil
+	}
+
+	if len(w.idTab) > 0xFFFF {
+		return 0, errors.Errorf("too many distinct user and group IDs")
+	}
+
+	ndx := uint16(len(w.idTab))
+	w.ids[id] = ndx
+	w.idTab = append(w.idTab, id)
+
+	return ndx, nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.out.Write(b)
+	w.pos += int64(n)
+
+	return errors.Wrap(err, "write error")
+}
+
+// metadataWriter accumulates a stream of metadata blocks in memory.
+type metadataWriter struct {
+	out bytes.Buffer
+	cur []byte
+}
+
+// position returns the offset of the current metadata block relative to the start of the stream
+// and the offset within its uncompressed contents.
+func (m *metadataWriter) position() (block uint32, offset uint16) {
+	return uint32(m.out.Len()), uint16(len(m.cur))
+}
+
+func (m *metadataWriter) write(b []byte) {
+	m.cur = append(m.cur, b...)
+
+	for len(m.cur) >= metadataBlockSize {
+		m.flushBlock(m.cur[0:metadataBlockSize])
+		m.cur = append([]byte(nil), m.cur[metadataBlockSize:]...)
+	}
+}
+
+func (m *metadataWriter) finish() {
+	if len(m.cur) > 0 {
+		m.flushBlock(m.cur)
+		m.cur = nil
+	}
+}
+
+func (m *metadataWriter) flushBlock(data []byte) {
+	compressed, err := compress(data)
+	if err == nil && len(compressed) < len(data) {
+		m.out.Write(binary.LittleEndian.AppendUint16(nil, uint16(len(compressed)))) //nolint:errcheck
+		m.out.Write(compressed)                                                     //nolint:errcheck
+
+		return
+	}
+
+	m.out.Write(binary.LittleEndian.AppendUint16(nil, uint16(len(data))|metadataUncompressed)) //nolint:errcheck
+	m.out.Write(data)                                                                          //nolint:errcheck
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := zlib.NewWriter(&buf)
+
+	if _, err := zw.Write(data); err != nil {
+		return nil, errors.Wrap(err, "compression error")
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, errors.Wrap(err, "compression error")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func sortedChildren(n *node) []*node {
+	var result []*node
+
+	for _, c := range n.children {
+		result = append(result, c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].name < result[j].name
+	})
+
+	return result
+}
+
+func inodeRef(n *node) uint64 {
+	return uint64(n.inodeBlock)<<16 | uint64(n.inodeOffset) //nolint:gomnd
+}
+
+func permissionBits(m os.FileMode) uint16 {
+	p := uint16(m.Perm())
+
+	if m&os.ModeSetuid != 0 {
+		p |= 0o4000
+	}
+
+	if m&os.ModeSetgid != 0 {
+		p |= 0o2000
+	}
+
+	if m&os.ModeSticky != 0 {
+		p |= 0o1000
+	}
+
+	return p
+}
+
+func unixTime(t time.Time) uint32 {
+	if t.IsZero() || t.Unix() < 0 {
+		return 0
+	}
+
+	return uint32(t.Unix())
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+
+	return v
+}
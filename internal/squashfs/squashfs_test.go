@@ -0,0 +1,369 @@
+package squashfs
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	f, err := os.Create(t.TempDir() + "/image.sqfs")
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	mtime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	dirInfo := EntryInfo{Mode: os.ModeDir | 0o750, UserID: 0, GroupID: 0, ModTime: mtime}
+	fileInfo := EntryInfo{Mode: 0o640 | os.ModeSetuid, UserID: 1000, GroupID: 100, ModTime: mtime}
+
+	big := bytes.Repeat([]byte("0123456789"), 50000)
+
+	w, err := NewWriter(f)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Mkdir("", dirInfo))
+	require.NoError(t, w.Mkdir("sub", dirInfo))
+	require.NoError(t, w.Mkdir("sub/empty", dirInfo))
+	require.NoError(t, w.WriteFile("hello.txt", strings.NewReader("hello world"), fileInfo))
+	require.NoError(t, w.WriteFile("sub/big.bin", bytes.NewReader(big), fileInfo))
+	require.NoError(t, w.Symlink("link", "hello.txt", fileInfo))
+
+	// enough files to span multiple metadata blocks.
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, w.WriteFile(fmt.Sprintf("sub/f%04d", i), strings.NewReader(fmt.Sprint(i)), fileInfo))
+	}
+
+	require.Error(t, w.WriteFile("hello.txt", strings.NewReader(""), fileInfo), "duplicate")
+	require.Error(t, w.WriteFile("missing/file", strings.NewReader(""), fileInfo), "missing parent")
+	require.Error(t, w.Mkdir("hello.txt/x", dirInfo), "parent is not a directory")
+
+	require.NoError(t, w.Close())
+
+	st, err := f.Stat()
+	require.NoError(t, err)
+	require.Zero(t, st.Size()%imagePadding)
+
+	img := readTestImage(t, f)
+
+	require.Equal(t, []string{"hello.txt", "link", "sub"}, img.list(t, ""))
+	require.Len(t, img.list(t, "sub"), 1002)
+	require.Empty(t, img.list(t, "sub/empty"))
+
+	require.Equal(t, "hello world", string(img.contents(t, "hello.txt")))
+	require.Equal(t, big, img.contents(t, "sub/big.bin"))
+	require.Equal(t, "999", string(img.contents(t, "sub/f0999")))
+
+	in := img.lookup(t, "link")
+	require.Equal(t, uint16(inodeTypeBasicSymlink), in.typ)
+	require.Equal(t, "hello.txt", in.target)
+
+	in = img.lookup(t, "hello.txt")
+	require.Equal(t, uint16(0o4640), in.perm)
+	require.Equal(t, uint32(1000), img.ids[in.uidIndex])
+	require.Equal(t, uint32(100), img.ids[in.gidIndex])
+	require.Equal(t, uint32(mtime.Unix()), in.mtime)
+
+	in = img.lookup(t, "sub")
+	require.Equal(t, uint16(0o750), in.perm)
+}
+
+// TestWriterUnsquashfs verifies images using the reference implementation, which is independent of
+// the test reader below.
+func TestWriterUnsquashfs(t *testing.T) {
+	unsquashfs, err := exec.LookPath("unsquashfs")
+	if err != nil {
+		t.Skip("unsquashfs not available")
+	}
+
+	fname := filepath.Join(t.TempDir(), "image.sqfs")
+
+	f, err := os.Create(fname)
+	require.NoError(t, err)
+
+	mtime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	dirInfo := EntryInfo{Mode: os.ModeDir | 0o750, ModTime: mtime}
+	fileInfo := EntryInfo{Mode: 0o640, ModTime: mtime}
+
+	big := bytes.Repeat([]byte("0123456789"), 50000)
+
+	w, err := NewWriter(f)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Mkdir("", dirInfo))
+	require.NoError(t, w.Mkdir("sub", dirInfo))
+	require.NoError(t, w.Mkdir("sub/empty", dirInfo))
+	require.NoError(t, w.WriteFile("hello.txt", strings.NewReader("hello world"), fileInfo))
+	require.NoError(t, w.WriteFile("sub/big.bin", bytes.NewReader(big), fileInfo))
+	require.NoError(t, w.Symlink("link", "hello.txt", fileInfo))
+
+	for i := 0; i < 1000; i++ {
+		require.NoError(t, w.WriteFile(fmt.Sprintf("sub/f%04d", i), strings.NewReader(fmt.Sprint(i)), fileInfo))
+	}
+
+	require.NoError(t, w.Close())
+	require.NoError(t, f.Close())
+
+	out, err := exec.Command(unsquashfs, "-l", fname).CombinedOutput()
+	require.NoError(t, err, string(out))
+	require.Contains(t, string(out), "squashfs-root/sub/f0999")
+
+	dest := filepath.Join(t.TempDir(), "extracted")
+
+	out, err = exec.Command(unsquashfs, "-no-progress", "-d", dest, fname).CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	b, err := os.ReadFile(filepath.Join(dest, "hello.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(b))
+
+	b, err = os.ReadFile(filepath.Join(dest, "sub", "big.bin"))
+	require.NoError(t, err)
+	require.Equal(t, big, b)
+
+	target, err := os.Readlink(filepath.Join(dest, "link"))
+	require.NoError(t, err)
+	require.Equal(t, "hello.txt", target)
+
+	st, err := os.Stat(filepath.Join(dest, "hello.txt"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o640), st.Mode().Perm())
+	require.Equal(t, mtime, st.ModTime().UTC())
+
+	entries, err := os.ReadDir(filepath.Join(dest, "sub"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1002)
+}
+
+// testImage is a minimal SquashFS reader sufficient to verify images produced by the writer.
+type testImage struct {
+	data       []byte
+	inodeTable []byte // uncompressed
+	inodeMap   map[uint32]int
+	dirTable   []byte // uncompressed
+	dirMap     map[uint32]int
+	root       uint64
+	ids        []uint32
+}
+
+type testInode struct {
+	typ      uint16
+	perm     uint16
+	uidIndex uint16
+	gidIndex uint16
+	mtime    uint32
+
+	dirBlock  uint32
+	dirOffset uint16
+	dirSize   uint32
+
+	blocksStart uint32
+	fileSize    uint32
+	blockSizes  []uint32
+
+	target string
+}
+
+func readTestImage(t *testing.T, f *os.File) *testImage {
+	t.Helper()
+
+	data, err := io.ReadAll(io.NewSectionReader(f, 0, 1<<40))
+	require.NoError(t, err)
+
+	le := binary.LittleEndian
+
+	require.Equal(t, uint32(magic), le.Uint32(data[0:]))
+	require.Equal(t, uint32(BlockSize), le.Uint32(data[12:]))
+	require.Equal(t, uint16(4), le.Uint16(data[28:]))
+
+	img := &testImage{data: data, root: le.Uint64(data[32:])}
+
+	idTableStart := le.Uint64(data[48:])
+	inodeTableStart := le.Uint64(data[64:])
+	dirTableStart := le.Uint64(data[72:])
+
+	idCount := int(le.Uint16(data[26:]))
+	idBlock := le.Uint64(data[idTableStart:])
+
+	img.inodeTable, img.inodeMap = readMetadata(t, data[inodeTableStart:dirTableStart])
+	img.dirTable, img.dirMap = readMetadata(t, data[dirTableStart:idBlock])
+	ids, _ := readMetadata(t, data[idBlock:idTableStart])
+
+	for i := 0; i < idCount; i++ {
+		img.ids = append(img.ids, le.Uint32(ids[4*i:]))
+	}
+
+	return img
+}
+
+func readMetadata(t *testing.T, data []byte) (result []byte, blockMap map[uint32]int) {
+	t.Helper()
+
+	blockMap = map[uint32]int{}
+
+	for pos := 0; pos+2 <= len(data); {
+		hdr := binary.LittleEndian.Uint16(data[pos:])
+		size := int(hdr &^ metadataUncompressed)
+
+		blockMap[uint32(pos)] = len(result)
+		chunk := data[pos+2 : pos+2+size]
+
+		if hdr&metadataUncompressed == 0 {
+			zr, err := zlib.NewReader(bytes.NewReader(chunk))
+			require.NoError(t, err)
+
+			chunk, err = io.ReadAll(zr)
+			require.NoError(t, err)
+		}
+
+		result = append(result, chunk...)
+		pos += 2 + size
+	}
+
+	return result, blockMap
+}
+
+func (img *testImage) inode(t *testing.T, ref uint64) *testInode {
+	t.Helper()
+
+	le := binary.LittleEndian
+
+	start, ok := img.inodeMap[uint32(ref>>16)]
+	require.True(t, ok)
+
+	b := img.inodeTable[start+int(ref&0xFFFF):]
+
+	in := &testInode{
+		typ:      le.Uint16(b[0:]),
+		perm:     le.Uint16(b[2:]),
+		uidIndex: le.Uint16(b[4:]),
+		gidIndex: le.Uint16(b[6:]),
+		mtime:    le.Uint32(b[8:]),
+	}
+
+	b = b[16:]
+
+	switch in.typ {
+	case inodeTypeBasicDir:
+		in.dirBlock = le.Uint32(b[0:])
+		in.dirSize = uint32(le.Uint16(b[8:]))
+		in.dirOffset = le.Uint16(b[10:])
+
+	case inodeTypeBasicFile:
+		in.blocksStart = le.Uint32(b[0:])
+		in.fileSize = le.Uint32(b[12:])
+
+		for i := uint32(0); i < (in.fileSize+BlockSize-1)/BlockSize; i++ {
+			in.blockSizes = append(in.blockSizes, le.Uint32(b[16+4*i:]))
+		}
+
+	case inodeTypeBasicSymlink:
+		in.target = string(b[8 : 8+le.Uint32(b[4:])])
+
+	default:
+		t.Fatalf("unexpected inode type %v", in.typ)
+	}
+
+	return in
+}
+
+func (img *testImage) entries(t *testing.T, dir *testInode) map[string]uint64 {
+	t.Helper()
+
+	le := binary.LittleEndian
+
+	start, ok := img.dirMap[dir.dirBlock]
+	require.True(t, ok)
+
+	b := img.dirTable[start+int(dir.dirOffset) : start+int(dir.dirOffset)+int(dir.dirSize)-3]
+	result := map[string]uint64{}
+
+	for len(b) > 0 {
+		count := int(le.Uint32(b[0:])) + 1
+		inodeBlock := le.Uint32(b[4:])
+		b = b[12:]
+
+		for i := 0; i < count; i++ {
+			offset := le.Uint16(b[0:])
+			nameLen := int(le.Uint16(b[6:])) + 1
+			result[string(b[8:8+nameLen])] = uint64(inodeBlock)<<16 | uint64(offset)
+			b = b[8+nameLen:]
+		}
+	}
+
+	return result
+}
+
+func (img *testImage) lookup(t *testing.T, p string) *testInode {
+	t.Helper()
+
+	in := img.inode(t, img.root)
+
+	if p == "" {
+		return in
+	}
+
+	for _, part := range strings.Split(p, "/") {
+		ref, ok := img.entries(t, in)[part]
+		require.True(t, ok, "not found: %v", p)
+
+		in = img.inode(t, ref)
+	}
+
+	return in
+}
+
+func (img *testImage) list(t *testing.T, p string) []string {
+	t.Helper()
+
+	var result []string
+
+	for name := range img.entries(t, img.lookup(t, p)) {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func (img *testImage) contents(t *testing.T, p string) []byte {
+	t.Helper()
+
+	in := img.lookup(t, p)
+	require.Equal(t, uint16(inodeTypeBasicFile), in.typ)
+
+	var result []byte
+
+	pos := int(in.blocksStart)
+
+	for _, bs := range in.blockSizes {
+		size := int(bs &^ dataBlockUncompressed)
+		chunk := img.data[pos : pos+size]
+
+		if bs&dataBlockUncompressed == 0 {
+			zr, err := zlib.NewReader(bytes.NewReader(chunk))
+			require.NoError(t, err)
+
+			chunk, err = io.ReadAll(zr)
+			require.NoError(t, err)
+		}
+
+		result = append(result, chunk...)
+		pos += size
+	}
+
+	require.Len(t, result, int(in.fileSize))
+
+	return result
+}
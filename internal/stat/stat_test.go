@@ -40,3 +40,9 @@ func TestGetFileAllocSize(t *testing.T) {
 		t.Fatalf("invalid allocated file size %d, expected at least %d", s, size)
 	}
 }
+
+func TestGetFreeSpace(t *testing.T) {
+	s, err := GetFreeSpace(t.TempDir())
+	require.NoError(t, err)
+	require.Positive(t, s)
+}
@@ -5,7 +5,11 @@
 // common stat commands.
 package stat
 
-import "errors"
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
 
 var errNotImplemented = errors.New("not implemented")
 
@@ -23,3 +27,19 @@ func GetFileAllocSize(fname string) (uint64, error) {
 func GetBlockSize(path string) (uint64, error) {
 	return 0, errNotImplemented
 }
+
+// GetFreeSpace gets the number of bytes available to the current user on the disk containing the path.
+func GetFreeSpace(path string) (uint64, error) {
+	pathPtr, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	var free uint64
+
+	if err := windows.GetDiskFreeSpaceEx(pathPtr, &free, nil, nil); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return free, nil
+}
@@ -6,6 +6,7 @@
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 //nolint:gochecknoglobals
@@ -18,6 +19,18 @@
 	bytesStringBase2Envar = "KOPIA_BYTES_STRING_BASE_2"
 )
 
+// bytesStringMode selects units used by BytesString.
+type bytesStringMode int32
+
+const (
+	bytesStringFromEnvironment bytesStringMode = iota
+	bytesStringBase10
+	bytesStringBase2
+)
+
+//nolint:gochecknoglobals
+var bytesStringOverride atomic.Int32
+
 func niceNumber(f float64) string {
 	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.1f", f), "0"), ".")
 }
@@ -46,8 +59,31 @@ func BytesStringBase2(b int64) string {
 	return toDecimalUnitString(float64(b), 1024.0, base2UnitPrefixes, "B")
 }
 
-// BytesString formats the given value as bytes with the unit provided from the environment.
+// SetBytesStringBase2 selects base-2 (IEC) or base-10 (SI) units used by BytesString, overriding the environment.
+func SetBytesStringBase2(base2 bool) {
+	if base2 {
+		bytesStringOverride.Store(int32(bytesStringBase2))
+	} else {
+		bytesStringOverride.Store(int32(bytesStringBase10))
+	}
+}
+
+// ResetBytesString makes BytesString use units provided from the environment again.
+func ResetBytesString() {
+	bytesStringOverride.Store(int32(bytesStringFromEnvironment))
+}
+
+// BytesString formats the given value as bytes with the unit selected by SetBytesStringBase2
+// or provided from the environment.
 func BytesString(b int64) string {
+	switch bytesStringMode(bytesStringOverride.Load()) {
+	case bytesStringBase2:
+		return BytesStringBase2(b)
+	case bytesStringBase10:
+		return BytesStringBase10(b)
+	case bytesStringFromEnvironment:
+	}
+
 	if v, _ := strconv.ParseBool(os.Getenv(bytesStringBase2Envar)); v {
 		return BytesStringBase2(b)
 	}
@@ -94,3 +94,33 @@ func TestBytesString(t *testing.T) {
 		}
 	}
 }
+
+func TestBytesStringOverride(t *testing.T) {
+	defer ResetBytesString()
+
+	cases := []struct {
+		env      string
+		base2    bool
+		value    int64
+		expected string
+	}{
+		{"true", false, 1500, "1.5 KB"},
+		{"false", true, 1536, "1.5 KiB"},
+	}
+
+	for i, c := range cases {
+		t.Setenv(bytesStringBase2Envar, c.env)
+		SetBytesStringBase2(c.base2)
+
+		if actual := BytesString(c.value); actual != c.expected {
+			t.Errorf("case #%v failed for %v, expected: '%v', got '%v'", i, c.value, c.expected, actual)
+		}
+	}
+
+	t.Setenv(bytesStringBase2Envar, "true")
+	ResetBytesString()
+
+	if actual := BytesString(1536); actual != "1.5 KiB" {
+		t.Errorf("unexpected value after reset: %v", actual)
+	}
+}
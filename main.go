@@ -74,5 +74,9 @@ func main() {
 	kp.UsageTemplate(usageTemplate)
 
 	app.Attach(kp)
-	kingpin.MustParse(kp.Parse(os.Args[1:]))
+
+	args, err := app.ExpandAliases(kp, os.Args[1:])
+	kp.FatalIfError(err, "")
+
+	kingpin.MustParse(kp.Parse(args))
 }
@@ -4,7 +4,9 @@
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -20,6 +22,24 @@
 	"github.com/kopia/kopia/repo/object"
 )
 
+const (
+	// contents larger than this are uploaded in chunks using resumable upload sessions.
+	resumableUploadAboveSize = 2 << 20
+
+	// size of a single chunk of a resumable upload.
+	resumableUploadChunkSize = 1 << 20
+
+	// maximum number of failed chunk uploads before giving up.
+	maxResumableUploadFailures = 10
+
+	// delays between retries of failed chunk uploads, doubling after each failure.
+	resumableUploadRetryInitialDelay = 100 * time.Millisecond
+	resumableUploadRetryMaxDelay     = 10 * time.Second
+)
+
+// errResumableUploadUnsupported is returned when the server does not support resumable uploads.
+var errResumableUploadUnsupported = errors.New("resumable uploads not supported")
+
 // APIServerInfo is remote repository configuration stored in local configuration.
 type APIServerInfo struct {
 	BaseURL                             string `json:"url"`
@@ -36,6 +56,9 @@ type apiServerRepository struct {
 	wso                              WriteSessionOptions
 	afterFlush                       []RepositoryWriterCallback
 
+	// set when the server does not support resumable uploads.
+	resumableUploadUnsupported *atomic.Bool
+
 	*immutableServerRepositoryParameters // immutable parameters
 }
 
@@ -133,10 +156,6 @@ func (r *apiServerRepository) DeleteManifest(ctx context.Context, id manifest.ID
 	return errors.Wrap(r.cli.Delete(ctx, "manifests/"+string(id), manifest.ErrNotFound, nil, nil), "DeleteManifest")
 }
 
-func (r *apiServerRepository) Time() time.Time {
-	return clock.Now()
-}
-
 func (r *apiServerRepository) Refresh(ctx context.Context) error {
 	return nil
 }
@@ -242,12 +261,13 @@ func (r *apiServerRepository) WriteContent(ctx context.Context, data gather.Byte
 
 	r.wso.OnUpload(int64(data.Length()))
 
-	maybeCompression := ""
-	if comp != content.NoCompression {
-		maybeCompression = fmt.Sprintf("?compression=%x", comp)
+	if data.Length() >= resumableUploadAboveSize && !r.resumableUploadUnsupported.Load() {
+		err = r.writeContentResumable(ctx, contentID, data.ToByteSlice(), comp)
+	} else {
+		err = r.cli.Put(ctx, "contents/"+contentID.String()+compressionQueryParam(comp, "?"), data.ToByteSlice(), nil)
 	}
 
-	if err := r.cli.Put(ctx, "contents/"+contentID.String()+maybeCompression, data.ToByteSlice(), nil); err != nil {
+	if err != nil {
 		return content.EmptyID, errors.Wrapf(err, "error writing content %v", contentID)
 	}
 
@@ -259,6 +279,98 @@ func (r *apiServerRepository) WriteContent(ctx context.Context, data gather.Byte
 	return contentID, nil
 }
 
+// writeContentResumable uploads the content in chunks using a resumable upload session, so that
+// after a failure only the chunks not yet acknowledged by the server need to be resent.
+func (r *apiServerRepository) writeContentResumable(ctx context.Context, contentID content.ID, data []byte, comp compression.HeaderID) error {
+	baseURL := fmt.Sprintf("contents/%v/upload?length=%v", contentID, len(data))
+
+	var st remoterepoapi.ContentUploadStatus
+
+	if err := r.cli.Get(ctx, baseURL, errResumableUploadUnsupported, &st); err != nil {
+		if !errors.Is(err, errResumableUploadUnsupported) {
+			return errors.Wrap(err, "error getting upload status")
+		}
+
+		// server does not support resumable uploads, don't try again.
+		r.resumableUploadUnsupported.Store(true)
+
+		return errors.Wrap(r.cli.Put(ctx, "contents/"+contentID.String()+compressionQueryParam(comp, "?"), data, nil), "error uploading content")
+	}
+
+	var (
+		failures   = 0
+		retryDelay = resumableUploadRetryInitialDelay
+	)
+
+	// retryAfterFailure waits before the next attempt and returns false if the upload should be abandoned.
+	retryAfterFailure := func() bool {
+		failures++
+		if failures > maxResumableUploadFailures {
+			return false
+		}
+
+		if !clock.SleepInterruptibly(ctx, retryDelay) {
+			return false
+		}
+
+		retryDelay = min(2*retryDelay, resumableUploadRetryMaxDelay)
+
+		return true
+	}
+
+	for !st.Complete {
+		if st.Offset < 0 || st.Offset > int64(len(data)) {
+			return errors.Errorf("invalid upload offset %v", st.Offset)
+		}
+
+		end := st.Offset + resumableUploadChunkSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+
+		var next remoterepoapi.ContentUploadStatus
+
+		if err := r.cli.Put(ctx, fmt.Sprintf("%v&offset=%v%v", baseURL, st.Offset, compressionQueryParam(comp, "&")), data[st.Offset:end], &next); err != nil {
+			var hse apiclient.HTTPStatusError
+			if errors.As(err, &hse) && hse.HTTPStatusCode == http.StatusTooManyRequests {
+				// server can't accept another upload session right now, upload the content in a single request.
+				log(ctx).Debugf("resumable upload of %v not accepted, uploading in a single request: %v", contentID, err)
+
+				return errors.Wrap(r.cli.Put(ctx, "contents/"+contentID.String()+compressionQueryParam(comp, "?"), data, nil), "error uploading content")
+			}
+
+			if !retryAfterFailure() {
+				return errors.Wrap(err, "error uploading content chunk")
+			}
+
+			log(ctx).Debugf("error uploading chunk of %v at offset %v, resuming: %v", contentID, st.Offset, err)
+
+			// find out how much data the server has actually received before resuming.
+			if err := r.cli.Get(ctx, baseURL, nil, &next); err != nil {
+				log(ctx).Debugf("error getting upload status of %v: %v", contentID, err)
+				continue
+			}
+		} else if !next.Complete && next.Offset <= st.Offset {
+			// server did not accept the chunk, most likely because the session has expired.
+			if !retryAfterFailure() {
+				return errors.Errorf("upload of %v did not make progress at offset %v", contentID, st.Offset)
+			}
+		}
+
+		st = next
+	}
+
+	return nil
+}
+
+func compressionQueryParam(comp compression.HeaderID, separator string) string {
+	if comp == content.NoCompression {
+		return ""
+	}
+
+	return fmt.Sprintf("%vcompression=%x", separator, comp)
+}
+
 // UpdateDescription updates the description of a connected repository.
 func (r *apiServerRepository) UpdateDescription(d string) {
 	r.cliOpts.Description = d
@@ -319,6 +431,7 @@ func openRestAPIRepository(ctx context.Context, si *APIServerInfo, password stri
 	rr := &apiServerRepository{
 		immutableServerRepositoryParameters: par,
 		cli:                                 cli,
+		resumableUploadUnsupported:          new(atomic.Bool),
 		wso: WriteSessionOptions{
 			OnUpload: func(i int64) {},
 		},
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// PointInTimeViewOptions customizes CreatePointInTimeView.
+type PointInTimeViewOptions struct {
+	// UseStorageVersions uses previous versions of blobs kept by versioned storage, which allows
+	// recovering blobs deleted or overwritten after the point in time.
+	UseStorageVersions bool
+}
+
+// CreatePointInTimeView writes a configuration file connecting to the same repository as configFile,
+// which presents the repository as it was at the provided time. Manifests and indexes written after
+// that time are not visible. The view is read-only and does not use persistent caches.
+//
+// It returns true if the storage provider will present previous versions of blobs, otherwise the
+// view only hides blobs written after the provided time.
+func CreatePointInTimeView(configFile, viewConfigFile string, asOf time.Time, opt PointInTimeViewOptions) (usesStorageVersions bool, err error) {
+	lc, err := LoadConfigFromFile(configFile)
+	if err != nil {
+		return false, err
+	}
+
+	if lc.Storage == nil {
+		return false, errors.New("point-in-time views are only supported for direct repository connections")
+	}
+
+	if lc.AsOf != nil {
+		return false, errors.Errorf("%v is already a point-in-time view", configFile)
+	}
+
+	if abs, err := filepath.Abs(viewConfigFile); err == nil {
+		if absConfig, err := filepath.Abs(configFile); err == nil && abs == absConfig {
+			return false, errors.New("point-in-time view must be written to a different configuration file")
+		}
+	}
+
+	asOf = asOf.UTC()
+
+	lc.AsOf = &asOf
+	lc.Description = lc.Description + " as of " + asOf.Format(time.RFC3339)
+
+	applyAsOfOptions(lc)
+
+	if pit, ok := lc.Storage.Config.(blob.PointInTimeSupport); ok && opt.UseStorageVersions {
+		pit.SetPointInTime(asOf)
+
+		usesStorageVersions = true
+	}
+
+	if err := lc.writeToFile(viewConfigFile); err != nil {
+		return false, errors.Wrap(err, "unable to write point-in-time view configuration")
+	}
+
+	return usesStorageVersions, nil
+}
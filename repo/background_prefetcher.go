@@ -0,0 +1,174 @@
+package repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/ctxutil"
+	"github.com/kopia/kopia/repo/object"
+)
+
+const (
+	defaultPrefetchMaxObjectsPerSecond = 100
+	defaultPrefetchMaxPendingObjects   = 10000
+)
+
+// BackgroundPrefetchOptions provides options for BackgroundPrefetcher.
+type BackgroundPrefetchOptions struct {
+	// MaxObjectsPerSecond limits the rate at which objects are prefetched.
+	MaxObjectsPerSecond int
+
+	// MaxPendingObjects limits the number of objects waiting to be prefetched, requests exceeding it are dropped.
+	MaxPendingObjects int
+}
+
+// BackgroundPrefetcher warms the local cache in the background with objects which are likely to be read soon,
+// for example files in a directory which has just been opened by the user.
+type BackgroundPrefetcher struct {
+	rep     Repository
+	options BackgroundPrefetchOptions
+
+	mu sync.Mutex
+	// +checklocks:mu
+	pending []prefetchRequest
+	// +checklocks:mu
+	pendingIDs map[object.ID]bool
+	// +checklocks:mu
+	closed bool
+
+	wakeUp chan struct{}
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type prefetchRequest struct {
+	objectID object.ID
+	hint     string
+}
+
+// NewBackgroundPrefetcher creates a BackgroundPrefetcher for the provided repository.
+// The prefetcher must be closed before the repository.
+func NewBackgroundPrefetcher(ctx context.Context, rep Repository, opt BackgroundPrefetchOptions) *BackgroundPrefetcher {
+	if opt.MaxObjectsPerSecond <= 0 {
+		opt.MaxObjectsPerSecond = defaultPrefetchMaxObjectsPerSecond
+	}
+
+	if opt.MaxPendingObjects <= 0 {
+		opt.MaxPendingObjects = defaultPrefetchMaxPendingObjects
+	}
+
+	ctx, cancel := context.WithCancel(ctxutil.Detach(ctx))
+
+	p := &BackgroundPrefetcher{
+		rep:        rep,
+		options:    opt,
+		pendingIDs: map[object.ID]bool{},
+		wakeUp:     make(chan struct{}, 1),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go p.run(ctx)
+
+	return p
+}
+
+// PrefetchObjects schedules the provided objects to be prefetched into the local cache using the
+// provided hint (see Repository.PrefetchObjects) and returns immediately.
+// Objects which are already pending are ignored and objects exceeding the limit of pending objects are dropped.
+func (p *BackgroundPrefetcher) PrefetchObjects(objectIDs []object.ID, hint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	for _, oid := range objectIDs {
+		if len(p.pending) >= p.options.MaxPendingObjects {
+			break
+		}
+
+		if p.pendingIDs[oid] {
+			continue
+		}
+
+		p.pendingIDs[oid] = true
+		p.pending = append(p.pending, prefetchRequest{oid, hint})
+	}
+
+	select {
+	case p.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+// PendingCount returns the number of objects waiting to be prefetched.
+func (p *BackgroundPrefetcher) PendingCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.pending)
+}
+
+// Close stops the prefetcher discarding all pending requests.
+func (p *BackgroundPrefetcher) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.pending = nil
+	p.pendingIDs = map[object.ID]bool{}
+	p.mu.Unlock()
+
+	p.cancel()
+	<-p.done
+}
+
+// nextBatch removes and returns up to maxCount pending objects sharing the same hint.
+func (p *BackgroundPrefetcher) nextBatch(maxCount int) (oids []object.ID, hint string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.pending) > 0 && len(oids) < maxCount {
+		r := p.pending[0]
+		if len(oids) > 0 && r.hint != hint {
+			break
+		}
+
+		oids = append(oids, r.objectID)
+		hint = r.hint
+
+		p.pending = p.pending[1:]
+		delete(p.pendingIDs, r.objectID)
+	}
+
+	return oids, hint
+}
+
+func (p *BackgroundPrefetcher) run(ctx context.Context) {
+	defer close(p.done)
+
+	for {
+		oids, hint := p.nextBatch(p.options.MaxObjectsPerSecond)
+		if len(oids) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.wakeUp:
+				continue
+			}
+		}
+
+		t0 := clock.Now()
+
+		if _, err := p.rep.PrefetchObjects(ctx, oids, hint); err != nil {
+			log(ctx).Debugf("error prefetching objects: %v", err)
+		}
+
+		// rate-limit by waiting until a second has elapsed since the batch has started.
+		if !clock.SleepInterruptibly(ctx, time.Second-clock.Now().Sub(t0)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package repo_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+)
+
+type prefetchRecordingRepository struct {
+	repo.Repository
+
+	mu      sync.Mutex
+	batches [][]object.ID
+	hints   []string
+}
+
+func (r *prefetchRecordingRepository) PrefetchObjects(_ context.Context, objectIDs []object.ID, hint string) ([]content.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, objectIDs)
+	r.hints = append(r.hints, hint)
+
+	return nil, nil
+}
+
+func (r *prefetchRecordingRepository) prefetched() (batches [][]object.ID, hints []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([][]object.ID(nil), r.batches...), append([]string(nil), r.hints...)
+}
+
+func mustParseObjectIDs(t *testing.T, ids ...string) []object.ID {
+	t.Helper()
+
+	var result []object.ID
+
+	for _, id := range ids {
+		oid, err := object.ParseID(id)
+		require.NoError(t, err)
+
+		result = append(result, oid)
+	}
+
+	return result
+}
+
+func TestBackgroundPrefetcher(t *testing.T) {
+	ctx := testlogging.Context(t)
+	rep := &prefetchRecordingRepository{}
+
+	p := repo.NewBackgroundPrefetcher(ctx, rep, repo.BackgroundPrefetchOptions{
+		MaxObjectsPerSecond: 3,
+	})
+	defer p.Close()
+
+	oids := mustParseObjectIDs(t,
+		"1234567890abcdef1234567890abcdef",
+		"2234567890abcdef1234567890abcdef",
+		"3234567890abcdef1234567890abcdef",
+		"4234567890abcdef1234567890abcdef",
+		"5234567890abcdef1234567890abcdef",
+	)
+
+	p.PrefetchObjects(oids[0:4], "")
+	p.PrefetchObjects(oids[2:4], "") // already pending, ignored
+	p.PrefetchObjects(oids[4:5], "contents")
+
+	require.Eventually(t, func() bool {
+		return p.PendingCount() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		batches, _ := rep.prefetched()
+		return len(batches) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	batches, hints := rep.prefetched()
+
+	// batches are limited to the rate limit and don't mix hints.
+	require.Equal(t, [][]object.ID{oids[0:3], oids[3:4], oids[4:5]}, batches)
+	require.Equal(t, []string{"", "", "contents"}, hints)
+}
+
+func TestBackgroundPrefetcherLimits(t *testing.T) {
+	ctx := testlogging.Context(t)
+	rep := &prefetchRecordingRepository{}
+
+	p := repo.NewBackgroundPrefetcher(ctx, rep, repo.BackgroundPrefetchOptions{
+		MaxObjectsPerSecond: 1,
+		MaxPendingObjects:   2,
+	})
+
+	oids := mustParseObjectIDs(t,
+		"1234567890abcdef1234567890abcdef",
+		"2234567890abcdef1234567890abcdef",
+		"3234567890abcdef1234567890abcdef",
+		"4234567890abcdef1234567890abcdef",
+	)
+
+	p.PrefetchObjects(oids, "")
+	require.LessOrEqual(t, p.PendingCount(), 2)
+
+	p.Close()
+
+	// requests after close are ignored.
+	p.PrefetchObjects(oids, "")
+	require.Equal(t, 0, p.PendingCount())
+
+	batches, _ := rep.prefetched()
+	require.LessOrEqual(t, len(batches), 1)
+}
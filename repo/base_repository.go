@@ -0,0 +1,213 @@
+package repo
+
+import (
+	"context"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/layered"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+	"github.com/kopia/kopia/repo/encryption"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/hashing"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+// LinkedRepositoryManifestType is the type of manifests recorded in a base repository for each repository
+// linked to it. While any linked repository is recorded, maintenance of the base repository keeps all its pack blobs,
+// since they may be referenced by the linked repositories.
+const LinkedRepositoryManifestType = "linkedRepository"
+
+const linkedRepositoryIDLabel = "linkedRepositoryID"
+
+// LinkedRepository describes a repository linked to the base repository.
+type LinkedRepository struct {
+	UniqueID    string    `json:"uniqueID"`
+	Description string    `json:"description,omitempty"`
+	CreatedBy   string    `json:"createdBy"`
+	CreateTime  time.Time `json:"createTime"`
+}
+
+// LinkRepository records in the base repository that the repository with the provided unique ID references its contents.
+func LinkRepository(ctx context.Context, base DirectRepositoryWriter, linkedUniqueID []byte, description string) error {
+	if err := checkBaseRepositoryFormat(base); err != nil {
+		return err
+	}
+
+	l := &LinkedRepository{
+		UniqueID:    hex.EncodeToString(linkedUniqueID),
+		Description: description,
+		CreatedBy:   base.ClientOptions().UsernameAtHost(),
+		CreateTime:  base.Time().UTC(),
+	}
+
+	_, err := base.PutManifest(ctx, map[string]string{
+		manifest.TypeLabelKey:   LinkedRepositoryManifestType,
+		linkedRepositoryIDLabel: l.UniqueID,
+	}, l)
+
+	return errors.Wrap(err, "unable to record linked repository")
+}
+
+// ListLinkedRepositories returns the manifests of repositories linked to the provided base repository.
+func ListLinkedRepositories(ctx context.Context, base Repository) ([]*manifest.EntryMetadata, error) {
+	entries, err := base.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey: LinkedRepositoryManifestType,
+	})
+
+	return entries, errors.Wrap(err, "unable to find linked repositories")
+}
+
+// UnlinkRepository removes the record of the linked repository with the provided hex-encoded unique ID from the
+// base repository. Once no repositories are linked, maintenance of the base repository deletes unreferenced
+// pack blobs again, which makes contents referenced only by the unlinked repository unreadable.
+func UnlinkRepository(ctx context.Context, base RepositoryWriter, linkedUniqueID string) error {
+	entries, err := base.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey:   LinkedRepositoryManifestType,
+		linkedRepositoryIDLabel: linkedUniqueID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to find linked repositories")
+	}
+
+	if len(entries) == 0 {
+		return errors.Errorf("repository %v is not linked to this repository", linkedUniqueID)
+	}
+
+	for _, e := range entries {
+		if err := base.DeleteManifest(ctx, e.ID); err != nil {
+			return errors.Wrap(err, "unable to remove linked repository")
+		}
+	}
+
+	return nil
+}
+
+func checkBaseRepositoryFormat(base DirectRepository) error {
+	fm := base.FormatManager()
+
+	if fm.GetDataKey() != nil {
+		return errors.New("base repository must not use a separate data key")
+	}
+
+	if fm.GetEncryptionKeyID() != 0 {
+		return errors.New("base repository must not have its encryption key changed")
+	}
+
+	return nil
+}
+
+// checkLinkedToBase verifies that the repository being opened is recorded as linked in the base repository,
+// which ensures maintenance of the base repository keeps pack blobs referenced by it.
+func checkLinkedToBase(ctx context.Context, fmgr *format.Manager, base DirectRepository) error {
+	if err := checkBaseRepositoryFormat(base); err != nil {
+		return err
+	}
+
+	mp, err := fmgr.GetMutableParameters(ctx)
+	if err != nil {
+		return errors.Wrap(err, "mutable parameters")
+	}
+
+	if mp.IndexVersion < index.Version2 {
+		return errors.New("linked repository must use index version 2 or newer")
+	}
+
+	entries, err := base.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey:   LinkedRepositoryManifestType,
+		linkedRepositoryIDLabel: hex.EncodeToString(fmgr.UniqueID()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to find linked repositories")
+	}
+
+	if len(entries) == 0 {
+		return errors.New("repository is not linked to the base repository")
+	}
+
+	return nil
+}
+
+// openBaseRepository opens the base repository linked to the repository being opened in read-only mode.
+func openBaseRepository(ctx context.Context, configFile string, lc *LocalConfig, password string, options *Options) (DirectRepository, error) {
+	baseConfigFile := lc.BaseRepositoryConfigFile
+
+	if baseConfigFile == configFile {
+		return nil, errors.New("repository can't be linked to itself")
+	}
+
+	blc, err := LoadConfigFromFile(baseConfigFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load base repository configuration")
+	}
+
+	if blc.Storage == nil {
+		return nil, errors.New("base repository must be connected directly to the storage")
+	}
+
+	blc.ReadOnly = true
+
+	baseOptions := *options
+	baseOptions.BeforeFlush = nil
+
+	if options.Forensic {
+		applyForensicOptions(blc, &baseOptions)
+	}
+
+	r, err := openDirect(ctx, baseConfigFile, blc, password, &baseOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open base repository")
+	}
+
+	//nolint:forcetypeassert
+	return r.(DirectRepository), nil
+}
+
+// withBaseRepository returns storage that reads pack blobs missing from the provided storage from the base repository.
+func withBaseRepository(st blob.Storage, base DirectRepository) blob.Storage {
+	if base == nil {
+		return st
+	}
+
+	return layered.NewWrapper(st, base.BlobReader(), content.PackBlobIDPrefixes)
+}
+
+// linkedFormatProvider hashes contents the same way as the base repository, so that contents have the same IDs in
+// both repositories, and decrypts contents referenced from the base repository using its master key, which
+// is only held in memory while the base repository is open.
+type linkedFormatProvider struct {
+	format.Provider
+
+	base format.Provider
+}
+
+func (p linkedFormatProvider) GetHashFunction() string {
+	return p.base.GetHashFunction()
+}
+
+func (p linkedFormatProvider) HashFunc() hashing.HashFunc {
+	return p.base.HashFunc()
+}
+
+func (p linkedFormatProvider) EncryptorForKeyID(keyID byte) (encryption.Encryptor, error) {
+	if keyID == content.BaseRepositoryEncryptionKeyID {
+		//nolint:wrapcheck
+		return p.base.EncryptorForKeyID(0)
+	}
+
+	//nolint:wrapcheck
+	return p.Provider.EncryptorForKeyID(keyID)
+}
+
+// withBaseFormat returns format provider of the repository linked to the provided base repository.
+func withBaseFormat(fmgr *format.Manager, base DirectRepository) format.Provider {
+	if base == nil {
+		return fmgr
+	}
+
+	return linkedFormatProvider{fmgr, base.FormatManager()}
+}
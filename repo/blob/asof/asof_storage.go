@@ -0,0 +1,79 @@
+// Package asof implements a storage wrapper which hides blobs written after a point in time.
+package asof
+
+import (
+	"context"
+	"time"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+type asOfStorage struct {
+	blob.Storage
+
+	asOf          time.Time
+	alwaysVisible map[blob.ID]bool
+}
+
+func (s *asOfStorage) visible(bm blob.Metadata) bool {
+	return s.alwaysVisible[bm.BlobID] || !bm.Timestamp.After(s.asOf)
+}
+
+func (s *asOfStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	bm, err := s.Storage.GetMetadata(ctx, id)
+	if err != nil {
+		//nolint:wrapcheck
+		return blob.Metadata{}, err
+	}
+
+	if !s.visible(bm) {
+		return blob.Metadata{}, blob.ErrBlobNotFound
+	}
+
+	return bm, nil
+}
+
+func (s *asOfStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	if !s.alwaysVisible[id] {
+		if _, err := s.GetMetadata(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	//nolint:wrapcheck
+	return s.Storage.GetBlob(ctx, id, offset, length, output)
+}
+
+func (s *asOfStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	//nolint:wrapcheck
+	return s.Storage.ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+		if !s.visible(bm) {
+			return nil
+		}
+
+		return callback(bm)
+	})
+}
+
+func (s *asOfStorage) DisplayName() string {
+	return s.Storage.DisplayName() + " as of " + s.asOf.Format(time.RFC3339)
+}
+
+// NewWrapper returns a Storage wrapper that hides blobs whose timestamps are after the provided time,
+// except for the provided blobs which are always visible.
+//
+// Blobs which were overwritten or deleted after that time can only be recovered by storage providers
+// which keep previous versions of blobs, see blob.PointInTimeSupport.
+func NewWrapper(wrapped blob.Storage, asOf time.Time, alwaysVisible ...blob.ID) blob.Storage {
+	s := &asOfStorage{
+		Storage:       wrapped,
+		asOf:          asOf,
+		alwaysVisible: map[blob.ID]bool{},
+	}
+
+	for _, id := range alwaysVisible {
+		s.alwaysVisible[id] = true
+	}
+
+	return s
+}
@@ -0,0 +1,56 @@
+package asof_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/asof"
+)
+
+func TestAsOfStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+
+	put := func(id blob.ID, ts time.Time) {
+		require.NoError(t, st.PutBlob(ctx, id, gather.FromSlice([]byte(id)), blob.PutOptions{SetModTime: ts}))
+	}
+
+	put("old", t0)
+	put("exact", t0.Add(time.Hour))
+	put("new", t0.Add(2*time.Hour))
+	put("format", t0.Add(3*time.Hour))
+
+	v := asof.NewWrapper(st, t0.Add(time.Hour), "format")
+
+	var listed []blob.ID
+
+	require.NoError(t, v.ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		listed = append(listed, bm.BlobID)
+		return nil
+	}))
+	require.ElementsMatch(t, []blob.ID{"old", "exact", "format"}, listed)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, v.GetBlob(ctx, "old", 0, -1, &tmp))
+	require.NoError(t, v.GetBlob(ctx, "format", 0, -1, &tmp))
+	require.ErrorIs(t, v.GetBlob(ctx, "new", 0, -1, &tmp), blob.ErrBlobNotFound)
+
+	_, err := v.GetMetadata(ctx, "new")
+	require.ErrorIs(t, err, blob.ErrBlobNotFound)
+
+	_, err = v.GetMetadata(ctx, "exact")
+	require.NoError(t, err)
+
+	require.Contains(t, v.DisplayName(), "as of 2024-01-01T01:00:00Z")
+}
@@ -60,6 +60,16 @@ func (s beforeOp) DeleteBlob(ctx context.Context, id blob.ID) error {
 	return s.Storage.DeleteBlob(ctx, id) //nolint:wrapcheck
 }
 
+func (s beforeOp) DeleteTemporaryFile(ctx context.Context, name string) error {
+	if s.onDeleteBlob != nil {
+		if err := s.onDeleteBlob(ctx); err != nil {
+			return err
+		}
+	}
+
+	return s.Storage.DeleteTemporaryFile(ctx, name) //nolint:wrapcheck
+}
+
 // NewWrapper creates a wrapped storage interface for data operations that need
 // to run a callback before the actual operation.
 func NewWrapper(wrapped blob.Storage, onGetBlob onGetBlobCallback, onGetMetadata, onDeleteBlob callback, onPutBlob onPutBlobCallback) blob.Storage {
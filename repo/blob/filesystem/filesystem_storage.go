@@ -334,6 +334,14 @@ func (fs *fsStorage) DisplayName() string {
 	return fmt.Sprintf("Filesystem: %v", fs.RootPath)
 }
 
+func (fs *fsStorage) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	return fs.ListIncompleteFiles(ctx, callback)
+}
+
+func (fs *fsStorage) DeleteTemporaryFile(ctx context.Context, name string) error {
+	return fs.DeleteIncompleteFile(ctx, name)
+}
+
 // New creates new filesystem-backed storage in a specified directory.
 func New(ctx context.Context, opts *Options, isCreate bool) (blob.Storage, error) {
 	var err error
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
@@ -178,6 +179,61 @@ func TestFilesystemStorageDirectoryShards(t *testing.T) {
 	require.FileExists(t, filepath.Join(dataDir, "someb", "lo", "b1234567812345678.f"))
 }
 
+func TestFilesystemStorageTemporaryFiles(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	dataDir := testutil.TempDirectory(t)
+
+	st, err := New(ctx, &Options{
+		Path: dataDir,
+		Options: sharded.Options{
+			DirectoryShards: []int{5, 2},
+		},
+	}, true)
+	require.NoError(t, err)
+
+	defer st.Close(ctx)
+
+	require.NoError(t, st.PutBlob(ctx, "someblob1234567812345678", gather.FromSlice([]byte{1, 2, 3}), blob.PutOptions{}))
+
+	// simulate temporary files left behind by interrupted writes.
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "someb", "lo", "b1234567812345678.f.tmp.0123abcd"), []byte{1, 2}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "other.f.tmp.ff"), []byte{1, 2, 3, 4}, 0o600))
+
+	// files not matching the naming convention are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "other.f.tmp.xyz"), []byte{1}, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "other.tmp.12"), []byte{1}, 0o600))
+
+	var found []blob.TemporaryFile
+
+	require.NoError(t, st.ListTemporaryFiles(ctx, func(tf blob.TemporaryFile) error {
+		found = append(found, tf)
+		return nil
+	}))
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Name < found[j].Name })
+
+	require.Len(t, found, 2)
+	require.Equal(t, "other.f.tmp.ff", found[0].Name)
+	require.Equal(t, int64(4), found[0].Length)
+	require.Equal(t, "someb/lo/b1234567812345678.f.tmp.0123abcd", found[1].Name)
+	require.Equal(t, int64(2), found[1].Length)
+
+	for _, tf := range found {
+		require.NoError(t, st.DeleteTemporaryFile(ctx, tf.Name))
+	}
+
+	require.NoFileExists(t, filepath.Join(dataDir, "other.f.tmp.ff"))
+	require.NoFileExists(t, filepath.Join(dataDir, "someb", "lo", "b1234567812345678.f.tmp.0123abcd"))
+	require.FileExists(t, filepath.Join(dataDir, "someb", "lo", "b1234567812345678.f"))
+
+	// only temporary files can be deleted.
+	require.Error(t, st.DeleteTemporaryFile(ctx, "someb/lo/b1234567812345678.f"))
+	require.Error(t, st.DeleteTemporaryFile(ctx, "../other.f.tmp.ff"))
+}
+
 func TestFileStorage_GetBlob_RetriesOnReadError(t *testing.T) {
 	t.Parallel()
 
@@ -6,6 +6,7 @@
 	"io/fs"
 	"os"
 	"testing"
+	"time"
 
 	gcsclient "cloud.google.com/go/storage"
 	"github.com/stretchr/testify/require"
@@ -78,3 +79,38 @@ func TestGCSStorageCredentialsHelpers(t *testing.T) {
 		require.NotNil(t, ts)
 	})
 }
+
+func TestGenerationToRestore(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	gen := func(g int64, created, deleted time.Duration) *gcsclient.ObjectAttrs {
+		oa := &gcsclient.ObjectAttrs{Name: "blob", Generation: g, Created: t0.Add(created)}
+		if deleted != 0 {
+			oa.Deleted = t0.Add(deleted)
+		}
+
+		return oa
+	}
+
+	// generation 1 was overwritten by 2, which was deleted.
+	gens := []*gcsclient.ObjectAttrs{gen(1, 0, time.Hour), gen(2, time.Hour, 3*time.Hour)}
+
+	oa, ok := generationToRestore(gens, t0.Add(30*time.Minute))
+	require.True(t, ok)
+	require.EqualValues(t, 1, oa.Generation)
+
+	oa, ok = generationToRestore(gens, t0.Add(2*time.Hour))
+	require.True(t, ok)
+	require.EqualValues(t, 2, oa.Generation)
+
+	// deleted before, or created after the point in time.
+	_, ok = generationToRestore(gens, t0.Add(4*time.Hour))
+	require.False(t, ok)
+
+	_, ok = generationToRestore(gens, t0.Add(-time.Hour))
+	require.False(t, ok)
+
+	// live generation exists.
+	_, ok = generationToRestore(append(gens, gen(3, 4*time.Hour, 0)), t0.Add(2*time.Hour))
+	require.False(t, ok)
+}
@@ -166,17 +166,7 @@ func (gcs *gcsStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback f
 
 	oa, err := lst.Next()
 	for err == nil {
-		bm := blob.Metadata{
-			BlobID:    blob.ID(oa.Name[len(gcs.Prefix):]),
-			Length:    oa.Size,
-			Timestamp: oa.Created,
-		}
-
-		if t, ok := timestampmeta.FromValue(oa.Metadata[timeMapKey]); ok {
-			bm.Timestamp = t
-		}
-
-		if cberr := callback(bm); cberr != nil {
+		if cberr := callback(gcs.toBlobMetadata(oa)); cberr != nil {
 			return cberr
 		}
 
@@ -190,6 +180,20 @@ func (gcs *gcsStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback f
 	return nil
 }
 
+func (gcs *gcsStorage) toBlobMetadata(oa *gcsclient.ObjectAttrs) blob.Metadata {
+	bm := blob.Metadata{
+		BlobID:    blob.ID(oa.Name[len(gcs.Prefix):]),
+		Length:    oa.Size,
+		Timestamp: oa.Created,
+	}
+
+	if t, ok := timestampmeta.FromValue(oa.Metadata[timeMapKey]); ok {
+		bm.Timestamp = t
+	}
+
+	return bm
+}
+
 func (gcs *gcsStorage) ConnectionInfo() blob.ConnectionInfo {
 	return blob.ConnectionInfo{
 		Type:   gcsStorageType,
@@ -0,0 +1,95 @@
+package gcs
+
+import (
+	"context"
+	"time"
+
+	gcsclient "cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// UndeleteBlobs implements blob.UndeleteSupport by restoring noncurrent generations of objects in
+// versioned buckets which were live at time t.
+func (gcs *gcsStorage) UndeleteBlobs(ctx context.Context, prefix blob.ID, t time.Time, dryRun bool, cb func(bm blob.Metadata) error) error {
+	lst := gcs.bucket.Objects(ctx, &gcsclient.Query{
+		Prefix:   gcs.getObjectNameString(prefix),
+		Versions: true,
+	})
+
+	var (
+		previousName string
+		generations  []*gcsclient.ObjectAttrs
+		pending      []*gcsclient.ObjectAttrs
+	)
+
+	addPending := func() {
+		if oa, ok := generationToRestore(generations, t); ok {
+			pending = append(pending, oa)
+		}
+	}
+
+	oa, err := lst.Next()
+	for err == nil {
+		if oa.Name != previousName {
+			addPending()
+
+			previousName = oa.Name
+			generations = generations[:0]
+		}
+
+		generations = append(generations, oa)
+
+		oa, err = lst.Next()
+	}
+
+	if !errors.Is(err, iterator.Done) {
+		return errors.Wrap(err, "unable to list object generations")
+	}
+
+	addPending()
+
+	for _, oa := range pending {
+		if err := cb(gcs.toBlobMetadata(oa)); err != nil {
+			return err
+		}
+
+		if dryRun {
+			continue
+		}
+
+		src := gcs.bucket.Object(oa.Name).Generation(oa.Generation)
+		dst := gcs.bucket.Object(oa.Name).If(gcsclient.Conditions{DoesNotExist: true})
+
+		if _, err := dst.CopierFrom(src).Run(ctx); err != nil {
+			return errors.Wrapf(err, "unable to restore generation %v of %v", oa.Generation, oa.Name)
+		}
+	}
+
+	return nil
+}
+
+// generationToRestore returns the generation of an object which was live at time t, if the object
+// is currently deleted.
+func generationToRestore(generations []*gcsclient.ObjectAttrs, t time.Time) (*gcsclient.ObjectAttrs, bool) {
+	var result *gcsclient.ObjectAttrs
+
+	for _, oa := range generations {
+		if oa.Deleted.IsZero() {
+			// live generation exists.
+			return nil, false
+		}
+
+		if oa.Created.After(t) || !oa.Deleted.After(t) {
+			continue
+		}
+
+		if result == nil || oa.Created.After(result.Created) {
+			result = oa
+		}
+	}
+
+	return result, result != nil
+}
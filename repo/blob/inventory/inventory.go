@@ -0,0 +1,350 @@
+// Package inventory supports listing blobs using bucket inventory reports (such as S3 Inventory or
+// GCS Storage Insights) instead of issuing LIST requests against the storage provider.
+//
+// Only CSV reports are supported, reports in Parquet or ORC format must be configured to use CSV instead.
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/logging"
+)
+
+var log = logging.Module("inventory")
+
+// ErrUnsupportedFormat is returned when the inventory report is not in CSV format.
+var ErrUnsupportedFormat = errors.New("unsupported inventory file format, only CSV is supported")
+
+// columnarFormatMagic are the leading bytes of inventory data files in formats other than CSV.
+//
+//nolint:gochecknoglobals
+var columnarFormatMagic = map[string][]byte{
+	"Parquet": []byte("PAR1"),
+	"ORC":     []byte("ORC"),
+}
+
+// s3DefaultSchema is the column order of S3 Inventory CSV files produced without a manifest.
+const s3DefaultSchema = "Bucket, Key, Size, LastModifiedDate"
+
+// Inventory is a point-in-time listing of blobs loaded from an inventory report.
+type Inventory struct {
+	blobs []blob.Metadata // sorted by BlobID
+}
+
+// Options controls how inventory reports are interpreted.
+type Options struct {
+	// KeyPrefix is the prefix of object keys under which the repository is stored, it is removed from keys
+	// and objects not matching it are ignored.
+	KeyPrefix string
+}
+
+// s3Manifest is a subset of the manifest.json file describing S3 Inventory report.
+type s3Manifest struct {
+	FileFormat string `json:"fileFormat"`
+	FileSchema string `json:"fileSchema"`
+	Files      []struct {
+		Key string `json:"key"`
+	} `json:"files"`
+}
+
+// columns holds indexes of columns holding relevant values.
+type columns struct {
+	key, size, modified int
+	escapedKeys         bool
+}
+
+// Load loads the inventory from the provided file, which can be either S3 Inventory 'manifest.json'
+// (with data files downloaded to the same directory or its 'data' subdirectory) or a CSV file
+// (optionally gzip-compressed) - either S3 Inventory data file or a listing with a header row naming
+// the columns, such as GCS Storage Insights report.
+func Load(ctx context.Context, fname string, opt Options) (*Inventory, error) {
+	inv := &Inventory{}
+
+	if strings.HasSuffix(strings.ToLower(fname), ".json") {
+		if err := inv.loadS3Manifest(fname, opt); err != nil {
+			return nil, err
+		}
+	} else if err := inv.loadCSVFile(fname, nil, opt); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(inv.blobs, func(i, j int) bool {
+		return inv.blobs[i].BlobID < inv.blobs[j].BlobID
+	})
+
+	log(ctx).Debugf("loaded %v blobs from inventory %v", len(inv.blobs), fname)
+
+	return inv, nil
+}
+
+func (inv *Inventory) loadS3Manifest(fname string, opt Options) error {
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to read inventory manifest")
+	}
+
+	var m s3Manifest
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return errors.Wrap(err, "invalid inventory manifest")
+	}
+
+	if !strings.EqualFold(m.FileFormat, "CSV") {
+		return errors.Wrapf(ErrUnsupportedFormat, "inventory manifest uses %q", m.FileFormat)
+	}
+
+	cols, err := s3Columns(m.FileSchema)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(fname)
+
+	for _, f := range m.Files {
+		dataFile, err := findDataFile(dir, path.Base(f.Key))
+		if err != nil {
+			return err
+		}
+
+		if err := inv.loadCSVFile(dataFile, cols, opt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findDataFile(dir, baseName string) (string, error) {
+	for _, candidate := range []string{
+		filepath.Join(dir, baseName),
+		filepath.Join(dir, "data", baseName),
+	} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", errors.Errorf("inventory data file %v not found in %v", baseName, dir)
+}
+
+// s3Columns returns column indexes based on S3 Inventory schema, such as "Bucket, Key, Size, LastModifiedDate".
+func s3Columns(schema string) (*columns, error) {
+	var names []string
+
+	for _, n := range strings.Split(schema, ",") {
+		names = append(names, strings.TrimSpace(n))
+	}
+
+	cols := columnsFromHeader(names)
+	if cols == nil {
+		return nil, errors.Errorf("inventory schema %q must include Key, Size and LastModifiedDate", schema)
+	}
+
+	// S3 Inventory reports URL-encode object keys.
+	cols.escapedKeys = true
+
+	return cols, nil
+}
+
+// columnsFromHeader returns column indexes based on names found in the header row or nil if any are missing.
+func columnsFromHeader(header []string) *columns {
+	cols := &columns{-1, -1, -1, false}
+
+	for i, name := range header {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "key", "name":
+			cols.key = i
+		case "size", "content-length":
+			cols.size = i
+		case "lastmodifieddate", "updated", "last-modified":
+			cols.modified = i
+		}
+	}
+
+	if cols.key < 0 || cols.size < 0 || cols.modified < 0 {
+		return nil
+	}
+
+	return cols
+}
+
+func (inv *Inventory) loadCSVFile(fname string, cols *columns, opt Options) error {
+	f, err := os.Open(fname) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to open inventory file")
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	var r io.Reader = f
+
+	if strings.HasSuffix(strings.ToLower(fname), ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return errors.Wrapf(err, "unable to decompress %v", fname)
+		}
+
+		defer gz.Close() //nolint:errcheck
+
+		r = gz
+	}
+
+	br := bufio.NewReader(r)
+
+	if err := checkNotColumnar(br); err != nil {
+		return errors.Wrapf(err, "error loading inventory from %v", fname)
+	}
+
+	return errors.Wrapf(inv.loadCSV(br, cols, opt), "error loading inventory from %v", fname)
+}
+
+// checkNotColumnar returns ErrUnsupportedFormat if the data starts with a signature of a columnar format.
+func checkNotColumnar(br *bufio.Reader) error {
+	for format, magic := range columnarFormatMagic {
+		if b, _ := br.Peek(len(magic)); bytes.Equal(b, magic) {
+			return errors.Wrapf(ErrUnsupportedFormat, "inventory data is in %v format", format)
+		}
+	}
+
+	return nil
+}
+
+func (inv *Inventory) loadCSV(r io.Reader, cols *columns, opt Options) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "malformed CSV")
+		}
+
+		if cols == nil {
+			if cols = columnsFromHeader(rec); cols != nil {
+				// header row
+				continue
+			}
+
+			// no header, assume S3 Inventory data file with default schema.
+			cols, _ = s3Columns(s3DefaultSchema)
+		}
+
+		bm, ok, err := parseRecord(rec, cols, opt)
+		if err != nil {
+			return errors.Wrapf(err, "line %v", line)
+		}
+
+		if ok {
+			inv.blobs = append(inv.blobs, bm)
+		}
+	}
+}
+
+func parseRecord(rec []string, cols *columns, opt Options) (blob.Metadata, bool, error) {
+	if cols.key >= len(rec) || cols.size >= len(rec) || cols.modified >= len(rec) {
+		return blob.Metadata{}, false, errors.Errorf("too few columns")
+	}
+
+	key := rec[cols.key]
+
+	if cols.escapedKeys {
+		k, err := url.QueryUnescape(key)
+		if err != nil {
+			return blob.Metadata{}, false, errors.Wrapf(err, "invalid key %q", key)
+		}
+
+		key = k
+	}
+
+	if !strings.HasPrefix(key, opt.KeyPrefix) {
+		return blob.Metadata{}, false, nil
+	}
+
+	key = strings.TrimPrefix(key, opt.KeyPrefix)
+	if key == "" || strings.HasSuffix(key, "/") {
+		// directory placeholders
+		return blob.Metadata{}, false, nil
+	}
+
+	length, err := strconv.ParseInt(rec[cols.size], 10, 64)
+	if err != nil {
+		return blob.Metadata{}, false, errors.Wrapf(err, "invalid size of %q", key)
+	}
+
+	ts, err := time.Parse(time.RFC3339Nano, rec[cols.modified])
+	if err != nil {
+		return blob.Metadata{}, false, errors.Wrapf(err, "invalid modification time of %q", key)
+	}
+
+	return blob.Metadata{
+		BlobID:    blob.ID(key),
+		Length:    length,
+		Timestamp: ts,
+	}, true, nil
+}
+
+// Len returns the number of blobs in the inventory.
+func (inv *Inventory) Len() int {
+	return len(inv.blobs)
+}
+
+// ListBlobs invokes the provided callback for each blob in the inventory with the provided prefix.
+func (inv *Inventory) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	start := sort.Search(len(inv.blobs), func(i int) bool {
+		return inv.blobs[i].BlobID >= prefix
+	})
+
+	for _, bm := range inv.blobs[start:] {
+		if !strings.HasPrefix(string(bm.BlobID), string(prefix)) {
+			break
+		}
+
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, "listing canceled")
+		}
+
+		if err := callback(bm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// inventoryStorage serves blob listings from the inventory and all other operations from the underlying storage.
+type inventoryStorage struct {
+	blob.Storage
+
+	inv *Inventory
+}
+
+func (s inventoryStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
+	return s.inv.ListBlobs(ctx, prefix, callback)
+}
+
+// NewWrapper returns a Storage wrapper that lists blobs using the provided inventory instead of the underlying storage.
+func NewWrapper(wrapped blob.Storage, inv *Inventory) blob.Storage {
+	return inventoryStorage{wrapped, inv}
+}
@@ -0,0 +1,119 @@
+package inventory_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/inventory"
+)
+
+func TestLoadS3Manifest(t *testing.T) {
+	ctx := testlogging.Context(t)
+	dir := t.TempDir()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(`"bucket","repo/p1234","100","2023-05-01T10:00:00.000Z","x"
+"bucket","repo/q5678","200","2023-05-02T10:00:00.000Z","x"
+"bucket","repo/kopia.repository","300","2023-05-03T10:00:00.000Z","x"
+"bucket","repo/dir/","0","2023-05-03T10:00:00.000Z","x"
+"bucket","other/p9999","400","2023-05-04T10:00:00.000Z","x"
+`))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "data"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data", "abcd.csv.gz"), buf.Bytes(), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifest.json"), []byte(`{
+  "fileFormat": "CSV",
+  "fileSchema": "Bucket, Key, Size, LastModifiedDate, ETag",
+  "files": [{"key": "inventory/bucket/config/data/abcd.csv.gz"}]
+}`), 0o600))
+
+	inv, err := inventory.Load(ctx, filepath.Join(dir, "manifest.json"), inventory.Options{KeyPrefix: "repo/"})
+	require.NoError(t, err)
+	require.Equal(t, 3, inv.Len())
+
+	all, err := blob.ListAllBlobs(ctx, inventory.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), inv), "")
+	require.NoError(t, err)
+	require.Equal(t, []blob.ID{"kopia.repository", "p1234", "q5678"}, blob.IDsFromMetadata(all))
+	require.Equal(t, int64(100), all[1].Length)
+	require.Equal(t, time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC), all[1].Timestamp)
+
+	ps, err := blob.ListAllBlobs(ctx, inventory.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), inv), "p")
+	require.NoError(t, err)
+	require.Equal(t, []blob.ID{"p1234"}, blob.IDsFromMetadata(ps))
+}
+
+func TestLoadS3ManifestUnsupportedFormat(t *testing.T) {
+	ctx := testlogging.Context(t)
+	fname := filepath.Join(t.TempDir(), "manifest.json")
+
+	require.NoError(t, os.WriteFile(fname, []byte(`{"fileFormat":"Parquet","fileSchema":"message s3.inventory {}","files":[]}`), 0o600))
+
+	_, err := inventory.Load(ctx, fname, inventory.Options{})
+	require.ErrorIs(t, err, inventory.ErrUnsupportedFormat)
+
+	// data files in columnar formats are rejected instead of being parsed as CSV.
+	fname = filepath.Join(t.TempDir(), "inventory.parquet")
+
+	require.NoError(t, os.WriteFile(fname, []byte("PAR1\x15\x04\x15\x10"), 0o600))
+
+	_, err = inventory.Load(ctx, fname, inventory.Options{})
+	require.ErrorIs(t, err, inventory.ErrUnsupportedFormat)
+}
+
+func TestLoadCSVWithHeader(t *testing.T) {
+	ctx := testlogging.Context(t)
+	fname := filepath.Join(t.TempDir(), "listing.csv")
+
+	require.NoError(t, os.WriteFile(fname, []byte(`bucket,name,size,updated
+b,q1,10,2023-05-01T10:00:00Z
+b,p2,20,2023-05-01T11:00:00.123Z
+`), 0o600))
+
+	inv, err := inventory.Load(ctx, fname, inventory.Options{})
+	require.NoError(t, err)
+
+	all, err := blob.ListAllBlobs(ctx, inventory.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{}, nil, nil), inv), "")
+	require.NoError(t, err)
+	require.Equal(t, []blob.ID{"p2", "q1"}, blob.IDsFromMetadata(all))
+
+	require.NoError(t, os.WriteFile(fname, []byte("b,q1,not-a-number,2023-05-01T10:00:00Z\n"), 0o600))
+
+	_, err = inventory.Load(ctx, fname, inventory.Options{})
+	require.ErrorContains(t, err, "invalid size")
+}
+
+func TestWrapperPassesThroughReads(t *testing.T) {
+	ctx := testlogging.Context(t)
+	fname := filepath.Join(t.TempDir(), "listing.csv")
+
+	require.NoError(t, os.WriteFile(fname, nil, 0o600))
+
+	inv, err := inventory.Load(ctx, fname, inventory.Options{})
+	require.NoError(t, err)
+
+	st := inventory.NewWrapper(blobtesting.NewMapStorage(blobtesting.DataMap{"a": []byte{1, 2, 3}}, nil, nil), inv)
+
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	require.NoError(t, st.GetBlob(ctx, "a", 0, -1, &tmp))
+	require.Equal(t, []byte{1, 2, 3}, tmp.ToByteSlice())
+
+	all, err := blob.ListAllBlobs(ctx, st, "")
+	require.NoError(t, err)
+	require.Empty(t, all)
+}
@@ -0,0 +1,59 @@
+// Package layered implements wrapper around blob.Storage that reads blobs missing from it from a base storage.
+package layered
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// layeredStorage reads blobs with one of the provided prefixes which are not found in the wrapped storage
+// from the base storage. All mutations and listings only apply to the wrapped storage.
+type layeredStorage struct {
+	blob.Storage
+	base     blob.Reader
+	prefixes []blob.ID
+}
+
+func (s layeredStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	err := s.Storage.GetBlob(ctx, id, offset, length, output)
+	if !errors.Is(err, blob.ErrBlobNotFound) || !s.hasBasePrefix(id) {
+		//nolint:wrapcheck
+		return err
+	}
+
+	output.Reset()
+
+	//nolint:wrapcheck
+	return s.base.GetBlob(ctx, id, offset, length, output)
+}
+
+func (s layeredStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	bm, err := s.Storage.GetMetadata(ctx, id)
+	if !errors.Is(err, blob.ErrBlobNotFound) || !s.hasBasePrefix(id) {
+		//nolint:wrapcheck
+		return bm, err
+	}
+
+	//nolint:wrapcheck
+	return s.base.GetMetadata(ctx, id)
+}
+
+func (s layeredStorage) hasBasePrefix(id blob.ID) bool {
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(string(id), string(p)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewWrapper returns a Storage wrapper that reads blobs with the provided prefixes that are missing from
+// the wrapped storage from the base.
+func NewWrapper(wrapped blob.Storage, base blob.Reader, prefixes []blob.ID) blob.Storage {
+	return layeredStorage{wrapped, base, prefixes}
+}
@@ -235,6 +235,52 @@ func (s *loggingStorage) ExtendBlobRetention(ctx context.Context, b blob.ID, opt
 	return err
 }
 
+func (s *loggingStorage) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	ctx, span := tracer.Start(ctx, "ListTemporaryFiles")
+	defer span.End()
+
+	s.beginConcurrency()
+	defer s.endConcurrency()
+
+	timer := timetrack.StartTimer()
+	cnt := 0
+	err := s.base.ListTemporaryFiles(ctx, func(tf blob.TemporaryFile) error {
+		cnt++
+		return callback(tf)
+	})
+	dt := timer.Elapsed()
+
+	s.logger.Debugw(s.prefix+"ListTemporaryFiles",
+		"resultCount", cnt,
+		"error", s.translateError(err),
+		"duration", dt,
+	)
+
+	//nolint:wrapcheck
+	return err
+}
+
+func (s *loggingStorage) DeleteTemporaryFile(ctx context.Context, name string) error {
+	ctx, span := tracer.Start(ctx, "DeleteTemporaryFile")
+	defer span.End()
+
+	s.beginConcurrency()
+	defer s.endConcurrency()
+
+	timer := timetrack.StartTimer()
+	err := s.base.DeleteTemporaryFile(ctx, name)
+	dt := timer.Elapsed()
+
+	s.logger.Debugw(s.prefix+"DeleteTemporaryFile",
+		"name", name,
+		"error", s.translateError(err),
+		"duration", dt,
+	)
+
+	//nolint:wrapcheck
+	return err
+}
+
 func (s *loggingStorage) translateError(err error) interface{} {
 	if err == nil {
 		return nil
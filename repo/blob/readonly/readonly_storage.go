@@ -52,6 +52,16 @@ func (s readonlyStorage) ListBlobs(ctx context.Context, prefix blob.ID, callback
 	return s.base.ListBlobs(ctx, prefix, callback)
 }
 
+func (s readonlyStorage) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	//nolint:wrapcheck
+	return s.base.ListTemporaryFiles(ctx, callback)
+}
+
+//nolint:revive
+func (s readonlyStorage) DeleteTemporaryFile(ctx context.Context, name string) error {
+	return ErrReadonly
+}
+
 func (s readonlyStorage) Close(ctx context.Context) error {
 	//nolint:wrapcheck
 	return s.base.Close(ctx)
@@ -5,6 +5,7 @@
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/kopia/kopia/internal/retry"
 	"github.com/kopia/kopia/repo"
@@ -47,6 +48,17 @@ func (s retryingStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
 	}, isRetriable)
 }
 
+// UndeleteBlobs implements blob.UndeleteSupport by forwarding to the underlying storage, if supported.
+func (s retryingStorage) UndeleteBlobs(ctx context.Context, prefix blob.ID, t time.Time, dryRun bool, cb func(bm blob.Metadata) error) error {
+	us, ok := s.Storage.(blob.UndeleteSupport)
+	if !ok {
+		return blob.ErrUndeleteUnsupported
+	}
+
+	//nolint:wrapcheck
+	return us.UndeleteBlobs(ctx, prefix, t, dryRun, cb)
+}
+
 // NewWrapper returns a Storage wrapper that adds retry loop around all operations of the underlying storage.
 func NewWrapper(wrapped blob.Storage) blob.Storage {
 	return &retryingStorage{Storage: wrapped}
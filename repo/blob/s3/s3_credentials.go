@@ -0,0 +1,161 @@
+package s3
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultSTSEndpoint     = "https://sts.amazonaws.com"
+	defaultRoleSessionName = "kopia"
+
+	// minRoleSessionDuration is the shortest session the STS client requests, shorter durations would be
+	// silently extended to it.
+	minRoleSessionDuration = time.Hour
+)
+
+// newCredentials returns credentials used to sign S3 requests. Temporary credentials obtained by assuming
+// a role are refreshed automatically before they expire, so they can be used by long-running operations.
+func newCredentials(opt *Options) (*credentials.Credentials, error) {
+	base := credentials.NewChainCredentials(
+		[]credentials.Provider{
+			&credentials.Static{
+				Value: credentials.Value{
+					AccessKeyID:     opt.AccessKeyID,
+					SecretAccessKey: opt.SecretAccessKey,
+					SessionToken:    opt.SessionToken,
+					SignerType:      credentials.SignatureV4,
+				},
+			},
+			&credentials.EnvAWS{},
+			&credentials.IAM{
+				Client: &http.Client{
+					Transport: http.DefaultTransport,
+				},
+			},
+		},
+	)
+
+	if opt.RoleSessionDuration != 0 && opt.RoleSessionDuration < minRoleSessionDuration {
+		return nil, errors.Errorf("role session duration must be at least %v", minRoleSessionDuration)
+	}
+
+	if opt.WebIdentityTokenFile != "" {
+		if opt.RoleARN == "" {
+			return nil, errors.New("role ARN must be specified when using web identity token")
+		}
+
+		// AssumeRoleWithWebIdentity does not accept external ID and the STS client always generates the session name.
+		if opt.RoleExternalID != "" || opt.RoleSessionName != "" {
+			return nil, errors.New("role external ID and session name are not supported when using web identity token")
+		}
+
+		return credentials.New(&credentials.STSWebIdentity{
+			Client: &http.Client{
+				Transport: http.DefaultTransport,
+			},
+			STSEndpoint: stsEndpoint(opt),
+			RoleARN:     opt.RoleARN,
+			GetWebIDTokenExpiry: func() (*credentials.WebIdentityToken, error) {
+				// the token file is re-read on each refresh since it's periodically rotated (e.g. by EKS).
+				token, err := os.ReadFile(opt.WebIdentityTokenFile)
+				if err != nil {
+					return nil, errors.Wrap(err, "unable to read web identity token")
+				}
+
+				return &credentials.WebIdentityToken{
+					Token:  strings.TrimSpace(string(token)),
+					Expiry: int(opt.RoleSessionDuration.Seconds()),
+				}, nil
+			},
+		}), nil
+	}
+
+	if opt.RoleARN != "" {
+		return credentials.New(&assumeRoleProvider{
+			base: base,
+			opt:  opt,
+		}), nil
+	}
+
+	if opt.RoleExternalID != "" || opt.RoleSessionName != "" {
+		return nil, errors.New("role ARN must be specified")
+	}
+
+	return base, nil
+}
+
+func stsEndpoint(opt *Options) string {
+	if opt.STSEndpoint != "" {
+		return opt.STSEndpoint
+	}
+
+	if opt.Region != "" {
+		return "https://sts." + opt.Region + ".amazonaws.com"
+	}
+
+	return defaultSTSEndpoint
+}
+
+func roleSessionName(opt *Options) string {
+	if opt.RoleSessionName != "" {
+		return opt.RoleSessionName
+	}
+
+	return defaultRoleSessionName
+}
+
+// assumeRoleProvider obtains temporary credentials by assuming the role using base credentials,
+// which themselves may be temporary and are fetched again on each refresh.
+type assumeRoleProvider struct {
+	base *credentials.Credentials
+	opt  *Options
+
+	current *credentials.STSAssumeRole
+}
+
+func (p *assumeRoleProvider) Retrieve() (credentials.Value, error) {
+	v, err := p.base.Get()
+	if err != nil {
+		return credentials.Value{}, errors.Wrap(err, "unable to get credentials to assume role")
+	}
+
+	if v.AccessKeyID == "" || v.SecretAccessKey == "" {
+		return credentials.Value{}, errors.New("no credentials available to assume role")
+	}
+
+	sts := &credentials.STSAssumeRole{
+		Client: &http.Client{
+			Transport: http.DefaultTransport,
+		},
+		STSEndpoint: stsEndpoint(p.opt),
+		Options: credentials.STSAssumeRoleOptions{
+			AccessKey:       v.AccessKeyID,
+			SecretKey:       v.SecretAccessKey,
+			SessionToken:    v.SessionToken,
+			Location:        p.opt.Region,
+			DurationSeconds: int(p.opt.RoleSessionDuration.Seconds()),
+			RoleARN:         p.opt.RoleARN,
+			RoleSessionName: roleSessionName(p.opt),
+			ExternalID:      p.opt.RoleExternalID,
+		},
+	}
+
+	result, err := sts.Retrieve()
+	if err != nil {
+		return credentials.Value{}, errors.Wrapf(err, "unable to assume role %v", p.opt.RoleARN)
+	}
+
+	p.current = sts
+
+	return result, nil
+}
+
+func (p *assumeRoleProvider) IsExpired() bool {
+	return p.current == nil || p.current.IsExpired()
+}
@@ -0,0 +1,191 @@
+package s3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSTS is a minimal STS server issuing credentials which expire after the configured duration.
+type fakeSTS struct {
+	mu       sync.Mutex
+	validFor time.Duration
+	requests []map[string]string
+}
+
+func (s *fakeSTS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req := map[string]string{}
+	for k := range r.Form {
+		req[k] = r.Form.Get(k)
+	}
+
+	s.requests = append(s.requests, req)
+
+	action := req["Action"]
+
+	fmt.Fprintf(w, `<%vResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <%vResult>
+    <Credentials>
+      <AccessKeyId>temp-key-%v</AccessKeyId>
+      <SecretAccessKey>temp-secret</SecretAccessKey>
+      <SessionToken>temp-token</SessionToken>
+      <Expiration>%v</Expiration>
+    </Credentials>
+  </%vResult>
+</%vResponse>`, action, action, len(s.requests), time.Now().Add(s.validFor).UTC().Format(time.RFC3339), action, action)
+}
+
+func (s *fakeSTS) setValidFor(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.validFor = d
+}
+
+func (s *fakeSTS) getRequests() []map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]map[string]string(nil), s.requests...)
+}
+
+func TestAssumeRoleCredentials(t *testing.T) {
+	sts := &fakeSTS{validFor: -time.Minute}
+	srv := httptest.NewServer(sts)
+
+	defer srv.Close()
+
+	creds, err := newCredentials(&Options{
+		AccessKeyID:         "base-key",
+		SecretAccessKey:     "base-secret",
+		Region:              "us-west-2",
+		RoleARN:             "arn:aws:iam::123456789012:role/backup",
+		RoleSessionName:     "kopia",
+		RoleExternalID:      "my-external-id",
+		RoleSessionDuration: 2 * time.Hour,
+		STSEndpoint:         srv.URL,
+	})
+	require.NoError(t, err)
+
+	v, err := creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "temp-key-1", v.AccessKeyID)
+	require.Equal(t, "temp-token", v.SessionToken)
+
+	reqs := sts.getRequests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "AssumeRole", reqs[0]["Action"])
+	require.Equal(t, "arn:aws:iam::123456789012:role/backup", reqs[0]["RoleArn"])
+	require.Equal(t, "kopia", reqs[0]["RoleSessionName"])
+	require.Equal(t, "my-external-id", reqs[0]["ExternalId"])
+	require.Equal(t, "7200", reqs[0]["DurationSeconds"])
+
+	// expired credentials are refreshed automatically.
+	v, err = creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "temp-key-2", v.AccessKeyID)
+
+	// valid credentials are reused.
+	sts.setValidFor(time.Hour)
+
+	creds.Expire()
+
+	v, err = creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "temp-key-3", v.AccessKeyID)
+
+	v, err = creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "temp-key-3", v.AccessKeyID)
+	require.Len(t, sts.getRequests(), 3)
+}
+
+func TestWebIdentityCredentials(t *testing.T) {
+	sts := &fakeSTS{validFor: -time.Minute}
+	srv := httptest.NewServer(sts)
+
+	defer srv.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-1\n"), 0o600))
+
+	creds, err := newCredentials(&Options{
+		RoleARN:              "arn:aws:iam::123456789012:role/backup",
+		WebIdentityTokenFile: tokenFile,
+		STSEndpoint:          srv.URL,
+	})
+	require.NoError(t, err)
+
+	v, err := creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "temp-key-1", v.AccessKeyID)
+
+	// rotated token is picked up on refresh.
+	require.NoError(t, os.WriteFile(tokenFile, []byte("token-2\n"), 0o600))
+
+	v, err = creds.Get()
+	require.NoError(t, err)
+	require.Equal(t, "temp-key-2", v.AccessKeyID)
+
+	reqs := sts.getRequests()
+	require.Len(t, reqs, 2)
+	require.Equal(t, "AssumeRoleWithWebIdentity", reqs[0]["Action"])
+	require.Equal(t, "token-1", reqs[0]["WebIdentityToken"])
+	require.Equal(t, "token-2", reqs[1]["WebIdentityToken"])
+	require.Equal(t, "arn:aws:iam::123456789012:role/backup", reqs[1]["RoleArn"])
+}
+
+func TestInvalidRoleOptions(t *testing.T) {
+	_, err := newCredentials(&Options{WebIdentityTokenFile: "some-file"})
+	require.ErrorContains(t, err, "role ARN must be specified")
+
+	_, err = newCredentials(&Options{RoleExternalID: "some-id"})
+	require.ErrorContains(t, err, "role ARN must be specified")
+
+	_, err = newCredentials(&Options{RoleARN: "some-role", WebIdentityTokenFile: "some-file", RoleSessionName: "some-name"})
+	require.ErrorContains(t, err, "not supported when using web identity token")
+
+	_, err = newCredentials(&Options{RoleARN: "some-role", WebIdentityTokenFile: "some-file", RoleExternalID: "some-id"})
+	require.ErrorContains(t, err, "not supported when using web identity token")
+
+	_, err = newCredentials(&Options{RoleARN: "some-role", RoleSessionDuration: 15 * time.Minute})
+	require.ErrorContains(t, err, "role session duration must be at least 1h")
+}
+
+func TestAssumeRoleDefaultSessionName(t *testing.T) {
+	sts := &fakeSTS{validFor: time.Hour}
+	srv := httptest.NewServer(sts)
+
+	defer srv.Close()
+
+	creds, err := newCredentials(&Options{
+		AccessKeyID:     "base-key",
+		SecretAccessKey: "base-secret",
+		RoleARN:         "arn:aws:iam::123456789012:role/backup",
+		STSEndpoint:     srv.URL,
+	})
+	require.NoError(t, err)
+
+	_, err = creds.Get()
+	require.NoError(t, err)
+
+	reqs := sts.getRequests()
+	require.Len(t, reqs, 1)
+	require.Equal(t, "kopia", reqs[0]["RoleSessionName"])
+	require.Equal(t, "3600", reqs[0]["DurationSeconds"])
+}
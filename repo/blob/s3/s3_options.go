@@ -23,11 +23,32 @@ type Options struct {
 	SecretAccessKey string `json:"secretAccessKey" kopia:"sensitive"`
 	SessionToken    string `json:"sessionToken"    kopia:"sensitive"`
 
+	// TransferAcceleration enables S3 Transfer Acceleration, only supported for AWS endpoints.
+	TransferAcceleration bool `json:"transferAcceleration,omitempty"`
+
 	// Region is an optional region to pass in authorization header.
 	Region string `json:"region,omitempty"`
 
+	// RoleARN is an optional ARN of the IAM role to assume using STS. The role is assumed with the web identity
+	// token when WebIdentityTokenFile is set, otherwise with the credentials above, environment or instance metadata.
+	// RoleSessionName defaults to "kopia" and RoleSessionDuration, if set, must be at least one hour. Session name
+	// and external ID can't be used with the web identity token.
+	RoleARN              string        `json:"roleARN,omitempty"`
+	RoleSessionName      string        `json:"roleSessionName,omitempty"`
+	RoleExternalID       string        `json:"roleExternalID,omitempty"`
+	RoleSessionDuration  time.Duration `json:"roleSessionDuration,omitempty"`
+	WebIdentityTokenFile string        `json:"webIdentityTokenFile,omitempty"`
+
+	// STSEndpoint overrides the URL of the STS service used to assume the role.
+	STSEndpoint string `json:"stsEndpoint,omitempty"`
+
 	throttling.Limits
 
 	// PointInTime specifies a view of the (versioned) store at that time
 	PointInTime *time.Time `json:"pointInTime,omitempty"`
 }
+
+// SetPointInTime implements blob.PointInTimeSupport.
+func (o *Options) SetPointInTime(t time.Time) {
+	o.PointInTime = &t
+}
@@ -14,6 +14,7 @@
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
 	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/internal/clock"
@@ -26,6 +27,8 @@
 const (
 	s3storageType   = "s3"
 	latestVersionID = ""
+
+	transferAccelerationEndpoint = "s3-accelerate.amazonaws.com"
 )
 
 type s3Storage struct {
@@ -342,24 +345,10 @@ func New(ctx context.Context, opt *Options, isCreate bool) (blob.Storage, error)
 }
 
 func newStorage(ctx context.Context, opt *Options) (*s3Storage, error) {
-	creds := credentials.NewChainCredentials(
-		[]credentials.Provider{
-			&credentials.Static{
-				Value: credentials.Value{
-					AccessKeyID:     opt.AccessKeyID,
-					SecretAccessKey: opt.SecretAccessKey,
-					SessionToken:    opt.SessionToken,
-					SignerType:      credentials.SignatureV4,
-				},
-			},
-			&credentials.EnvAWS{},
-			&credentials.IAM{
-				Client: &http.Client{
-					Transport: http.DefaultTransport,
-				},
-			},
-		},
-	)
+	creds, err := newCredentials(opt)
+	if err != nil {
+		return nil, err
+	}
 
 	return newStorageWithCredentials(ctx, creds, opt)
 }
@@ -388,6 +377,14 @@ func newStorageWithCredentials(ctx context.Context, creds *credentials.Credentia
 		return nil, errors.Wrap(err, "unable to create client")
 	}
 
+	if opt.TransferAcceleration {
+		if !s3utils.IsAmazonEndpoint(*cli.EndpointURL()) {
+			return nil, errors.Errorf("transfer acceleration is not supported by endpoint %q", opt.Endpoint)
+		}
+
+		cli.SetS3TransferAccelerate(transferAccelerationEndpoint)
+	}
+
 	s := s3Storage{
 		Options:       *opt,
 		cli:           cli,
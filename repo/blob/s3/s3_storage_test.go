@@ -831,3 +831,18 @@ func customCredentialsAndProvider(accessKey, secretKey, roleARN, region string)
 
 	return credentials.New(cp), cp
 }
+
+func TestTransferAccelerationRequiresAWSEndpoint(t *testing.T) {
+	t.Parallel()
+
+	ctx := testlogging.Context(t)
+
+	_, err := New(ctx, &Options{
+		Endpoint:             "localhost:9000",
+		AccessKeyID:          minioRootAccessKeyID,
+		SecretAccessKey:      minioRootSecretAccessKey,
+		BucketName:           minioBucketName,
+		TransferAcceleration: true,
+	}, false)
+	require.ErrorContains(t, err, "transfer acceleration is not supported")
+}
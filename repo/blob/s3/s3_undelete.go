@@ -0,0 +1,80 @@
+package s3
+
+import (
+	"context"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// UndeleteBlobs implements blob.UndeleteSupport by copying versions of blobs which were live at time t
+// over the delete markers of blobs which are currently deleted.
+func (s *s3Storage) UndeleteBlobs(ctx context.Context, prefix blob.ID, t time.Time, dryRun bool, cb func(bm blob.Metadata) error) error {
+	var (
+		previousID blob.ID
+		vs         []versionMetadata
+		pending    []versionMetadata
+	)
+
+	addPending := func() {
+		if v, ok := versionToRestore(vs, t); ok {
+			pending = append(pending, v)
+		}
+	}
+
+	if err := s.listBlobVersions(ctx, prefix, func(vm versionMetadata) error {
+		if vm.BlobID != previousID {
+			addPending()
+
+			previousID = vm.BlobID
+			vs = vs[:0]
+		}
+
+		vs = append(vs, vm)
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "could not list blob versions")
+	}
+
+	addPending()
+
+	for _, v := range pending {
+		if err := cb(v.Metadata); err != nil {
+			return err
+		}
+
+		if dryRun {
+			continue
+		}
+
+		objectName := s.getObjectNameString(v.BlobID)
+
+		if _, err := s.cli.CopyObject(ctx, minio.CopyDestOptions{
+			Bucket: s.BucketName,
+			Object: objectName,
+		}, minio.CopySrcOptions{
+			Bucket:    s.BucketName,
+			Object:    objectName,
+			VersionID: v.Version,
+		}); err != nil {
+			return errors.Wrapf(err, "unable to restore version %v of %v", v.Version, v.BlobID)
+		}
+	}
+
+	return nil
+}
+
+// versionToRestore returns the version of a blob which was live at time t, if the blob is currently deleted.
+// Versions written after t are not restored, which is why the live version is copied instead of removing
+// delete markers. Assumes entries in vs are versions of a single blob in descending timestamp order.
+func versionToRestore(vs []versionMetadata, t time.Time) (versionMetadata, bool) {
+	if len(vs) == 0 || !vs[0].IsDeleteMarker {
+		return versionMetadata{}, false
+	}
+
+	return newestAtUnlessDeleted(vs, t)
+}
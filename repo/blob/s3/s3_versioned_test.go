@@ -518,6 +518,44 @@ func TestNewestAtUnlessDeleted_AllDeleted(t *testing.T) {
 	}
 }
 
+func TestVersionToRestore(t *testing.T) {
+	t.Parallel()
+
+	base := clock.Now().UTC().Truncate(time.Second)
+	vs := makeVersionsMetadata(t, blob.ID("blobfux"), 6, base)
+
+	// not currently deleted
+	_, ok := versionToRestore(vs, vs[3].Timestamp)
+	require.False(t, ok)
+
+	// the newest version is a deletion marker preceded by a version written after vs[3].
+	vs[0].IsDeleteMarker = true
+	vs[2].IsDeleteMarker = true
+
+	v, ok := versionToRestore(vs, vs[3].Timestamp)
+	require.True(t, ok)
+	require.Equal(t, vs[3], v)
+
+	v, ok = versionToRestore(vs, vs[1].Timestamp)
+	require.True(t, ok)
+	require.Equal(t, vs[1], v)
+
+	v, ok = versionToRestore(vs, vs[5].Timestamp)
+	require.True(t, ok)
+	require.Equal(t, vs[5], v)
+
+	// already deleted at that time
+	_, ok = versionToRestore(vs, vs[2].Timestamp)
+	require.False(t, ok)
+
+	// did not exist at that time
+	_, ok = versionToRestore(vs, vs[5].Timestamp.Add(-time.Second))
+	require.False(t, ok)
+
+	_, ok = versionToRestore(nil, base)
+	require.False(t, ok)
+}
+
 func testListAllVersions(ctx context.Context, tb testing.TB, s *s3Storage, prefix blob.ID, want []versionMetadata) {
 	tb.Helper()
 
@@ -340,6 +340,14 @@ func (s *sftpStorage) DisplayName() string {
 	return fmt.Sprintf("SFTP %v@%v", o.Username, o.Host)
 }
 
+func (s *sftpStorage) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	return s.ListIncompleteFiles(ctx, callback)
+}
+
+func (s *sftpStorage) DeleteTemporaryFile(ctx context.Context, name string) error {
+	return s.DeleteIncompleteFile(ctx, name)
+}
+
 func (s *sftpStorage) Close(ctx context.Context) error {
 	s.Impl.(*sftpImpl).rec.CloseActiveConnection(ctx) //nolint:forcetypeassert
 	return nil
@@ -283,3 +283,84 @@ func New(impl Impl, rootPath string, opt Options, isCreate bool) Storage {
 		Options:  opt,
 	}
 }
+
+// temporaryFileInfix separates the name of the complete blob file and the random suffix in names of
+// temporary files written by providers before they are atomically renamed.
+const temporaryFileInfix = CompleteBlobSuffix + ".tmp."
+
+func isTemporaryFileName(name string) bool {
+	p := strings.LastIndex(name, temporaryFileInfix)
+	if p <= 0 {
+		return false
+	}
+
+	suffix := name[p+len(temporaryFileInfix):]
+	if suffix == "" {
+		return false
+	}
+
+	for _, c := range suffix {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ListIncompleteFiles reports temporary files left behind by interrupted writes of blobs, which are named
+// after the blob file followed by ".tmp." and a random hexadecimal suffix. Reported names are relative to the root path.
+func (s *Storage) ListIncompleteFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	var walkDir func(directory, relative string) error
+
+	walkDir = func(directory, relative string) error {
+		entries, err := s.Impl.ReadDir(ctx, directory)
+		if err != nil {
+			return errors.Wrap(err, "error reading directory")
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				if err := walkDir(directory+"/"+e.Name(), relative+e.Name()+"/"); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if !isTemporaryFileName(e.Name()) {
+				continue
+			}
+
+			if err := callback(blob.TemporaryFile{
+				Name:      relative + e.Name(),
+				Length:    e.Size(),
+				Timestamp: e.ModTime(),
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return walkDir(s.RootPath, "")
+}
+
+// DeleteIncompleteFile deletes the temporary file with the provided name as reported by ListIncompleteFiles.
+func (s *Storage) DeleteIncompleteFile(ctx context.Context, name string) error {
+	for _, p := range strings.Split(name, "/") {
+		if p == "" || p == "." || p == ".." {
+			return errors.Errorf("invalid temporary file name: %q", name)
+		}
+	}
+
+	if !isTemporaryFileName(path.Base(name)) {
+		return errors.Errorf("not a temporary file: %q", name)
+	}
+
+	fullPath := s.RootPath + "/" + name
+
+	//nolint:wrapcheck
+	return s.Impl.DeleteBlobInPath(ctx, path.Dir(fullPath), fullPath)
+}
@@ -45,6 +45,12 @@
 // function on a storage implementation that does not have the intended functionality.
 var ErrUnsupportedObjectLock = errors.New("object locking unsupported")
 
+// ErrUndeleteUnsupported is returned by storage which does not keep previous versions of deleted blobs.
+var ErrUndeleteUnsupported = errors.New("undelete is not supported by storage")
+
+// ErrTemporaryFilesUnsupported is returned when deleting temporary files in storage which does not use them.
+var ErrTemporaryFilesUnsupported = errors.New("storage does not use temporary files")
+
 // Bytes encapsulates a sequence of bytes, possibly stored in a non-contiguous buffers,
 // which can be written sequentially or treated as a io.Reader.
 type Bytes interface {
@@ -170,6 +176,16 @@ func (s DefaultProviderImplementation) FlushCaches(context.Context) error {
 	return nil
 }
 
+// ListTemporaryFiles complies with the Storage interface.
+func (s DefaultProviderImplementation) ListTemporaryFiles(context.Context, func(TemporaryFile) error) error {
+	return nil
+}
+
+// DeleteTemporaryFile complies with the Storage interface.
+func (s DefaultProviderImplementation) DeleteTemporaryFile(context.Context, string) error {
+	return ErrTemporaryFilesUnsupported
+}
+
 // GetCapacity complies with the Storage interface.
 func (s DefaultProviderImplementation) GetCapacity(context.Context) (Capacity, error) {
 	return Capacity{}, ErrNotAVolume
@@ -215,6 +231,35 @@ type Storage interface {
 	// IsReadOnly returns whether this Storage is in read-only mode. When in
 	// read-only mode all mutation operations will fail.
 	IsReadOnly() bool
+
+	// ListTemporaryFiles invokes the provided callback for each temporary file left behind by interrupted
+	// writes, which is not visible through ListBlobs. Providers which don't use temporary files report nothing.
+	ListTemporaryFiles(ctx context.Context, cb func(tf TemporaryFile) error) error
+
+	// DeleteTemporaryFile deletes the temporary file with the provided name, as reported by ListTemporaryFiles.
+	DeleteTemporaryFile(ctx context.Context, name string) error
+}
+
+// TemporaryFile describes a temporary file left behind by an interrupted write of a blob.
+type TemporaryFile struct {
+	Name      string    `json:"name"`
+	Length    int64     `json:"length"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// PointInTimeSupport is implemented by configuration of storage providers which can present versioned
+// storage as it was at a point in time, including blobs which were overwritten or deleted since.
+type PointInTimeSupport interface {
+	SetPointInTime(t time.Time)
+}
+
+// UndeleteSupport is implemented by storage providers which keep previous versions of blobs and
+// can restore blobs that have been deleted.
+type UndeleteSupport interface {
+	// UndeleteBlobs restores blobs with the provided prefix which existed at the provided time and are
+	// currently deleted. The callback is invoked with the metadata of each blob as it was at that time,
+	// before it is restored. When dryRun is true, the blobs are only reported.
+	UndeleteBlobs(ctx context.Context, prefix ID, t time.Time, dryRun bool, cb func(bm Metadata) error) error
 }
 
 // ID is a string that represents blob identifier.
@@ -142,6 +142,16 @@ func (s *blobMetrics) ExtendBlobRetention(ctx context.Context, id blob.ID, opts
 	return err
 }
 
+func (s *blobMetrics) ListTemporaryFiles(ctx context.Context, callback func(tf blob.TemporaryFile) error) error {
+	//nolint:wrapcheck
+	return s.base.ListTemporaryFiles(ctx, callback)
+}
+
+func (s *blobMetrics) DeleteTemporaryFile(ctx context.Context, name string) error {
+	//nolint:wrapcheck
+	return s.base.DeleteTemporaryFile(ctx, name)
+}
+
 func (s *blobMetrics) ListBlobs(ctx context.Context, prefix blob.ID, callback func(blob.Metadata) error) error {
 	timer := timetrack.StartTimer()
 	cnt := int64(0)
@@ -0,0 +1,265 @@
+// Package storagetest provides a conformance test suite for blob.Storage implementations.
+//
+// Third-party storage providers can run the suite from their tests to verify that the storage
+// behaves the way repositories expect:
+//
+//	func TestConformance(t *testing.T) {
+//		st := newMyStorage(t)
+//		storagetest.Run(context.Background(), t, st, storagetest.Options{})
+//	}
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// DefaultLargeBlobSize is the default size of the blob written by the large blob tests.
+const DefaultLargeBlobSize = 20 << 20
+
+// maxTimestampDifference is the maximum difference between timestamps of the same blob reported
+// by different methods, some providers round timestamps or return them with different precision.
+const maxTimestampDifference = time.Minute
+
+// Options customizes the conformance suite.
+type Options struct {
+	// PutOptions are used for all writes, when DoNotRecreate is set overwrites are expected to fail.
+	PutOptions blob.PutOptions
+
+	// Prefix is prepended to IDs of all blobs written by the suite, which allows running it against
+	// non-empty storage. The storage must not contain any other blobs with this prefix.
+	Prefix blob.ID
+
+	// LargeBlobSize is the size of the blob written by the large blob tests, defaults to DefaultLargeBlobSize.
+	LargeBlobSize int
+
+	// SkipLargeBlobs disables large blob tests, for example for storage with small size limits.
+	SkipLargeBlobs bool
+}
+
+// Run runs all conformance tests against the provided storage. The tests write, overwrite and
+// delete blobs with IDs starting with Options.Prefix and remove the blobs they have written when done.
+//
+//nolint:thelper
+func Run(ctx context.Context, t *testing.T, st blob.Storage, opt Options) {
+	rec := &recordingStorage{Storage: st, written: map[blob.ID]bool{}}
+	s := &suite{ctx: ctx, st: rec, opt: opt}
+
+	defer rec.cleanup(ctx, t)
+
+	t.Run("Basic", func(t *testing.T) {
+		blobtesting.VerifyStorage(ctx, t, &prefixStorage{rec, opt.Prefix}, opt.PutOptions)
+	})
+	t.Run("Consistency", s.testConsistency)
+	t.Run("Ordering", s.testOrdering)
+	t.Run("Ranges", s.testRanges)
+
+	if !opt.SkipLargeBlobs {
+		t.Run("LargeBlobs", s.testLargeBlobs)
+	}
+}
+
+type suite struct {
+	ctx context.Context //nolint:containedctx
+	st  blob.Storage
+	opt Options
+}
+
+func (s *suite) id(name string) blob.ID {
+	return s.opt.Prefix + blob.ID(name)
+}
+
+func (s *suite) put(t *testing.T, id blob.ID, data []byte) {
+	t.Helper()
+
+	require.NoErrorf(t, s.st.PutBlob(s.ctx, id, gather.FromSlice(data), s.opt.PutOptions), "PutBlob(%v)", id)
+}
+
+func (s *suite) get(t *testing.T, id blob.ID, offset, length int64) ([]byte, error) {
+	t.Helper()
+
+	var buf gather.WriteBuffer
+	defer buf.Close()
+
+	err := s.st.GetBlob(s.ctx, id, offset, length, &buf)
+
+	return buf.ToByteSlice(), err //nolint:wrapcheck
+}
+
+func (s *suite) requireContents(t *testing.T, id blob.ID, want []byte) {
+	t.Helper()
+
+	got, err := s.get(t, id, 0, -1)
+	require.NoErrorf(t, err, "GetBlob(%v)", id)
+	require.Truef(t, bytes.Equal(got, want), "GetBlob(%v) returned %v bytes, want %v", id, len(got), len(want))
+}
+
+func (s *suite) requireNotFound(t *testing.T, id blob.ID) {
+	t.Helper()
+
+	_, err := s.get(t, id, 0, -1)
+	require.ErrorIsf(t, err, blob.ErrBlobNotFound, "GetBlob(%v)", id)
+
+	_, err = s.st.GetMetadata(s.ctx, id)
+	require.ErrorIsf(t, err, blob.ErrBlobNotFound, "GetMetadata(%v)", id)
+}
+
+func (s *suite) list(t *testing.T, prefix blob.ID) map[blob.ID]blob.Metadata {
+	t.Helper()
+
+	result := map[blob.ID]blob.Metadata{}
+
+	require.NoError(t, s.st.ListBlobs(s.ctx, prefix, func(bm blob.Metadata) error {
+		require.NotContainsf(t, result, bm.BlobID, "blob %v listed more than once", bm.BlobID)
+		result[bm.BlobID] = bm
+
+		return nil
+	}))
+
+	return result
+}
+
+func requireTimestampsClose(t *testing.T, id blob.ID, got, want time.Time) {
+	t.Helper()
+
+	d := got.Sub(want)
+	if d < 0 {
+		d = -d
+	}
+
+	require.LessOrEqualf(t, d, maxTimestampDifference, "timestamps of %v differ: %v and %v", id, got, want)
+}
+
+func randomBytes(t *testing.T, n int) []byte {
+	t.Helper()
+
+	b := make([]byte, n)
+
+	_, err := rand.Read(b)
+	require.NoError(t, err)
+
+	return b
+}
+
+// testConsistency verifies that blobs can be read, described and listed immediately after they are written.
+func (s *suite) testConsistency(t *testing.T) {
+	for _, size := range []int{0, 1, 1000, 100000} {
+		id := s.id("consistency-" + strconv.Itoa(size))
+		data := randomBytes(t, size)
+
+		s.requireNotFound(t, id)
+
+		var modTime time.Time
+
+		opt := s.opt.PutOptions
+		opt.GetModTime = &modTime
+
+		require.NoError(t, s.st.PutBlob(s.ctx, id, gather.FromSlice(data), opt))
+		require.Falsef(t, modTime.IsZero(), "PutBlob(%v) did not return modification time", id)
+
+		s.requireContents(t, id, data)
+
+		bm, err := s.st.GetMetadata(s.ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, id, bm.BlobID)
+		require.Equal(t, int64(size), bm.Length)
+		requireTimestampsClose(t, id, bm.Timestamp, modTime)
+
+		listed, ok := s.list(t, id)[id]
+		require.Truef(t, ok, "blob %v not listed after writing", id)
+		require.Equal(t, bm.Length, listed.Length)
+		requireTimestampsClose(t, id, listed.Timestamp, bm.Timestamp)
+	}
+}
+
+// testOrdering verifies that the most recent write of a blob is returned and that timestamps
+// of blobs written later are not earlier.
+func (s *suite) testOrdering(t *testing.T) {
+	if s.opt.PutOptions.DoNotRecreate {
+		t.Skip("overwrites are not allowed")
+	}
+
+	id := s.id("ordering")
+
+	var last time.Time
+
+	for i := 0; i < 5; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, i+1)
+
+		s.put(t, id, data)
+		s.requireContents(t, id, data)
+
+		bm, err := s.st.GetMetadata(s.ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(data)), bm.Length)
+
+		require.Falsef(t, bm.Timestamp.Before(last.Add(-maxTimestampDifference)), "timestamp went back from %v to %v", last, bm.Timestamp)
+
+		last = bm.Timestamp
+	}
+}
+
+// testRanges verifies partial reads and rejection of invalid ranges.
+func (s *suite) testRanges(t *testing.T) {
+	id := s.id("ranges")
+	data := randomBytes(t, 1000)
+
+	s.put(t, id, data)
+
+	for _, r := range []struct{ offset, length int64 }{
+		{0, 0},
+		{0, 1},
+		{0, 500},
+		{500, 500},
+		{999, 1},
+		{123, 456},
+	} {
+		got, err := s.get(t, id, r.offset, r.length)
+		require.NoErrorf(t, err, "GetBlob(%v,%v,%v)", id, r.offset, r.length)
+		require.Equalf(t, data[r.offset:r.offset+r.length], got, "GetBlob(%v,%v,%v)", id, r.offset, r.length)
+	}
+
+	for _, r := range []struct{ offset, length int64 }{
+		{-3, 1},
+		{1000, 3},
+		{999, 3},
+		{1001, 3},
+	} {
+		_, err := s.get(t, id, r.offset, r.length)
+		require.Errorf(t, err, "GetBlob(%v,%v,%v) succeeded for invalid range", id, r.offset, r.length)
+	}
+}
+
+// testLargeBlobs verifies that large blobs round-trip and can be read partially.
+func (s *suite) testLargeBlobs(t *testing.T) {
+	size := s.opt.LargeBlobSize
+	if size <= 0 {
+		size = DefaultLargeBlobSize
+	}
+
+	id := s.id("large")
+	data := randomBytes(t, size)
+
+	s.put(t, id, data)
+	s.requireContents(t, id, data)
+
+	tail := int64(size) - 1000
+
+	got, err := s.get(t, id, tail, 1000)
+	require.NoError(t, err)
+	require.Equal(t, data[tail:], got)
+
+	bm, err := s.st.GetMetadata(s.ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, int64(size), bm.Length)
+}
@@ -0,0 +1,87 @@
+package storagetest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobtesting"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/repo/blob/storagetest"
+)
+
+func TestMapStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+
+	storagetest.Run(ctx, t, blobtesting.NewMapStorage(data, nil, nil), storagetest.Options{})
+
+	require.Empty(t, data, "blobs were not cleaned up")
+}
+
+func TestMapStorage_Prefix(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{
+		"unrelated": []byte{1, 2, 3},
+	}
+
+	storagetest.Run(ctx, t, blobtesting.NewMapStorage(data, nil, nil), storagetest.Options{
+		Prefix:         "conformance-",
+		SkipLargeBlobs: true,
+	})
+
+	require.Equal(t, blobtesting.DataMap{"unrelated": []byte{1, 2, 3}}, data)
+}
+
+func TestVersionedMapStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	storagetest.Run(ctx, t, blobtesting.NewVersionedMapStorage(nil), storagetest.Options{
+		PutOptions: blob.PutOptions{
+			RetentionMode:   blob.Governance,
+			RetentionPeriod: 24 * time.Hour,
+		},
+		LargeBlobSize: 1 << 20,
+	})
+}
+
+func TestFilesystemStorage(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st, err := filesystem.New(ctx, &filesystem.Options{
+		Path: testutil.TempDirectory(t),
+	}, true)
+	require.NoError(t, err)
+
+	defer st.Close(ctx)
+
+	storagetest.Run(ctx, t, st, storagetest.Options{})
+}
+
+func TestFilesystemStorage_CleanupKeepsOtherBlobs(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	st, err := filesystem.New(ctx, &filesystem.Options{
+		Path: testutil.TempDirectory(t),
+	}, true)
+	require.NoError(t, err)
+
+	defer st.Close(ctx)
+
+	require.NoError(t, st.PutBlob(ctx, "zzz-unrelated", gather.FromSlice([]byte{1}), blob.PutOptions{}))
+
+	storagetest.Run(ctx, t, st, storagetest.Options{
+		Prefix:         "conformance-",
+		SkipLargeBlobs: true,
+	})
+
+	all, err := blob.ListAllBlobs(ctx, st, "")
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	require.Equal(t, blob.ID("zzz-unrelated"), all[0].BlobID)
+}
@@ -0,0 +1,88 @@
+package storagetest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// recordingStorage records IDs of blobs written through it, so that only they are removed after the tests.
+type recordingStorage struct {
+	blob.Storage
+
+	mu      sync.Mutex
+	written map[blob.ID]bool
+}
+
+func (s *recordingStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	s.mu.Lock()
+	s.written[id] = true
+	s.mu.Unlock()
+
+	//nolint:wrapcheck
+	return s.Storage.PutBlob(ctx, id, data, opts)
+}
+
+//nolint:thelper
+func (s *recordingStorage) cleanup(ctx context.Context, t *testing.T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id := range s.written {
+		if err := s.Storage.DeleteBlob(ctx, id); err != nil && !errors.Is(err, blob.ErrBlobNotFound) {
+			t.Logf("unable to delete %v: %v", id, err)
+		}
+	}
+}
+
+// prefixStorage exposes blobs with IDs starting with the prefix as if the prefix was not there, which allows
+// tests expecting empty storage to run against storage containing other blobs.
+type prefixStorage struct {
+	blob.Storage
+
+	prefix blob.ID
+}
+
+func (s *prefixStorage) GetBlob(ctx context.Context, id blob.ID, offset, length int64, output blob.OutputBuffer) error {
+	//nolint:wrapcheck
+	return s.Storage.GetBlob(ctx, s.prefix+id, offset, length, output)
+}
+
+func (s *prefixStorage) GetMetadata(ctx context.Context, id blob.ID) (blob.Metadata, error) {
+	bm, err := s.Storage.GetMetadata(ctx, s.prefix+id)
+	bm.BlobID = s.unprefixed(bm.BlobID)
+
+	return bm, err //nolint:wrapcheck
+}
+
+func (s *prefixStorage) PutBlob(ctx context.Context, id blob.ID, data blob.Bytes, opts blob.PutOptions) error {
+	//nolint:wrapcheck
+	return s.Storage.PutBlob(ctx, s.prefix+id, data, opts)
+}
+
+func (s *prefixStorage) DeleteBlob(ctx context.Context, id blob.ID) error {
+	//nolint:wrapcheck
+	return s.Storage.DeleteBlob(ctx, s.prefix+id)
+}
+
+func (s *prefixStorage) ExtendBlobRetention(ctx context.Context, id blob.ID, opts blob.ExtendOptions) error {
+	//nolint:wrapcheck
+	return s.Storage.ExtendBlobRetention(ctx, s.prefix+id, opts)
+}
+
+func (s *prefixStorage) ListBlobs(ctx context.Context, prefix blob.ID, cb func(bm blob.Metadata) error) error {
+	//nolint:wrapcheck
+	return s.Storage.ListBlobs(ctx, s.prefix+prefix, func(bm blob.Metadata) error {
+		bm.BlobID = s.unprefixed(bm.BlobID)
+		return cb(bm)
+	})
+}
+
+func (s *prefixStorage) unprefixed(id blob.ID) blob.ID {
+	return blob.ID(strings.TrimPrefix(string(id), string(s.prefix)))
+}
@@ -14,6 +14,8 @@
 	HeaderZstdBetterCompression HeaderID = 0x1102
 	HeaderZstdBestCompression   HeaderID = 0x1103
 
+	headerZstdSeekable HeaderID = 0x1110
+
 	headerS2Default   HeaderID = 0x1200
 	headerS2Better    HeaderID = 0x1201
 	headerS2Parallel4 HeaderID = 0x1202
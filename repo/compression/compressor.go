@@ -22,6 +22,18 @@ type Compressor interface {
 	Decompress(output io.Writer, input io.Reader, withHeader bool) error
 }
 
+// SeekableReader provides random access to compressed data which only decompresses the parts
+// containing the requested range.
+type SeekableReader interface {
+	io.ReaderAt
+	Length() int64
+}
+
+// seekableCompressor is implemented by compressors which store data in independently compressed frames.
+type seekableCompressor interface {
+	openSeekable(data []byte) (SeekableReader, error)
+}
+
 // maps of registered compressors by header ID and name.
 //
 //nolint:gochecknoglobals
@@ -79,6 +91,32 @@ func DecompressByHeader(output io.Writer, input io.Reader) error {
 	return errors.Wrap(compressor.Decompress(output, input, false), "error decompressing")
 }
 
+// OpenSeekable returns SeekableReader for the provided data including compression header
+// if it was compressed using a compressor which supports random access.
+func OpenSeekable(data []byte) (SeekableReader, bool, error) {
+	if len(data) < compressionHeaderSize {
+		return nil, false, errors.Errorf("compressed data too short")
+	}
+
+	sc, ok := ByHeaderID[HeaderID(binary.BigEndian.Uint32(data[0:compressionHeaderSize]))].(seekableCompressor)
+	if !ok {
+		return nil, false, nil
+	}
+
+	r, err := sc.openSeekable(data[compressionHeaderSize:])
+	if err != nil {
+		return nil, false, err
+	}
+
+	return r, true, nil
+}
+
+// IsSeekable returns true if the provided compressor supports random access to compressed data.
+func IsSeekable(c Compressor) bool {
+	_, ok := c.(seekableCompressor)
+	return ok
+}
+
 func mustSucceed(err error) {
 	if err != nil {
 		panic("unexpected error: " + err.Error())
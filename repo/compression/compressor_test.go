@@ -4,9 +4,13 @@
 	"bytes"
 	"crypto/rand"
 	"fmt"
+	"io"
 	"sort"
 	"testing"
 
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+
 	"github.com/kopia/kopia/internal/testutil"
 )
 
@@ -86,6 +90,72 @@ func TestCompressor(t *testing.T) {
 
 const benchmarkDataSize = 10000000
 
+func TestZstdSeekable(t *testing.T) {
+	comp := newZstdSeekableCompressor(0x7777, zstd.SpeedFastest, 1000)
+
+	data := make([]byte, 10500)
+	for i := range data {
+		data[i] = byte(i % 77)
+	}
+
+	var cData bytes.Buffer
+
+	require.NoError(t, comp.Compress(&cData, bytes.NewReader(data)))
+	require.Less(t, cData.Len(), len(data))
+
+	// seekable data is a valid zstd stream.
+	var dData bytes.Buffer
+
+	require.NoError(t, comp.Decompress(&dData, bytes.NewReader(cData.Bytes()), true))
+	require.Equal(t, data, dData.Bytes())
+
+	r, err := comp.(seekableCompressor).openSeekable(cData.Bytes()[compressionHeaderSize:])
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), r.Length())
+
+	for _, tc := range []struct {
+		offset int64
+		length int
+	}{
+		{0, 10},
+		{990, 20},    // spans two frames
+		{1500, 3000}, // spans multiple frames
+		{10490, 10},
+	} {
+		buf := make([]byte, tc.length)
+
+		n, err := r.ReadAt(buf, tc.offset)
+		require.NoError(t, err)
+		require.Equal(t, tc.length, n)
+		require.Equal(t, data[tc.offset:tc.offset+int64(tc.length)], buf)
+	}
+
+	buf := make([]byte, 20)
+
+	n, err := r.ReadAt(buf, 10490)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 10, n)
+
+	// corrupted seek table is detected.
+	corrupted := bytes.Clone(cData.Bytes())
+	corrupted[len(corrupted)-1] ^= 1
+
+	_, err = comp.(seekableCompressor).openSeekable(corrupted[compressionHeaderSize:])
+	require.Error(t, err)
+
+	// only seekable compressors support random access.
+	require.True(t, IsSeekable(comp))
+	require.False(t, IsSeekable(ByName["zstd"]))
+
+	var zData bytes.Buffer
+
+	require.NoError(t, ByName["zstd"].Compress(&zData, bytes.NewReader(data)))
+
+	_, ok, err := OpenSeekable(zData.Bytes())
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
 func BenchmarkCompressor(b *testing.B) {
 	compressibleData := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, benchmarkDataSize/10)
 	zeroData := make([]byte, benchmarkDataSize)
@@ -0,0 +1,276 @@
+package compression
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Seekable zstd format stores data in independently compressed frames followed by a seek table
+// in a skippable frame, as described in
+// https://github.com/facebook/zstd/blob/dev/contrib/seekable_format/zstd_seekable_compression_format.md
+//
+// The result is a valid zstd stream, which can be decompressed as a whole, but also allows
+// decompressing only the frames which contain the requested range.
+const (
+	zstdSeekableFrameSize = 256 << 10
+
+	zstdSkippableFrameMagic = 0x184D2A5E
+	zstdSeekableMagic       = 0x8F92EAB1
+
+	zstdSkippableHeaderSize  = 8
+	zstdSeekTableEntrySize   = 8
+	zstdSeekTableFooterSize  = 9
+	zstdSeekTableDescriptor  = 0
+	zstdSeekTableChecksumBit = 0x80
+)
+
+//nolint:gochecknoglobals
+var zstdSeekableDecoderPool = sync.Pool{
+	New: func() interface{} {
+		d, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+		mustSucceed(err)
+		return d
+	},
+}
+
+func init() {
+	RegisterCompressor("zstd-seekable", newZstdSeekableCompressor(headerZstdSeekable, zstd.SpeedDefault, zstdSeekableFrameSize))
+}
+
+func newZstdSeekableCompressor(id HeaderID, level zstd.EncoderLevel, frameSize int) Compressor {
+	return &zstdSeekableCompressor{
+		zstdCompressor: newZstdCompressor(id, level).(*zstdCompressor), //nolint:forcetypeassert
+		frameSize:      frameSize,
+	}
+}
+
+// zstdSeekableCompressor compresses data using the seekable zstd format, decompression of the entire
+// stream is handled by the regular zstd decoder.
+type zstdSeekableCompressor struct {
+	*zstdCompressor
+
+	frameSize int
+}
+
+func (c *zstdSeekableCompressor) Compress(output io.Writer, input io.Reader) error {
+	if _, err := output.Write(c.header); err != nil {
+		return errors.Wrap(err, "unable to write header")
+	}
+
+	//nolint:forcetypeassert
+	w := c.pool.Get().(*zstd.Encoder)
+	defer c.pool.Put(w)
+
+	var (
+		seekTable bytes.Buffer
+		numFrames uint32
+	)
+
+	buf := make([]byte, c.frameSize)
+	frame := make([]byte, 0, c.frameSize)
+
+	for {
+		n, err := io.ReadFull(input, buf)
+		if n > 0 {
+			frame = w.EncodeAll(buf[0:n], frame[:0])
+
+			if _, werr := output.Write(frame); werr != nil {
+				return errors.Wrap(werr, "unable to write frame")
+			}
+
+			writeUint32LE(&seekTable, uint32(len(frame)))
+			writeUint32LE(&seekTable, uint32(n))
+
+			numFrames++
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "error reading input")
+		}
+	}
+
+	var trailer bytes.Buffer
+
+	writeUint32LE(&trailer, zstdSkippableFrameMagic)
+	writeUint32LE(&trailer, uint32(seekTable.Len()+zstdSeekTableFooterSize))
+	trailer.Write(seekTable.Bytes())
+	writeUint32LE(&trailer, numFrames)
+	trailer.WriteByte(zstdSeekTableDescriptor)
+	writeUint32LE(&trailer, zstdSeekableMagic)
+
+	if _, err := output.Write(trailer.Bytes()); err != nil {
+		return errors.Wrap(err, "unable to write seek table")
+	}
+
+	return nil
+}
+
+func (c *zstdSeekableCompressor) openSeekable(data []byte) (SeekableReader, error) {
+	if len(data) < zstdSkippableHeaderSize+zstdSeekTableFooterSize {
+		return nil, errors.Errorf("seekable zstd data too short")
+	}
+
+	footer := data[len(data)-zstdSeekTableFooterSize:]
+	if binary.LittleEndian.Uint32(footer[5:]) != zstdSeekableMagic {
+		return nil, errors.Errorf("invalid seekable zstd magic")
+	}
+
+	if footer[4]&zstdSeekTableChecksumBit != 0 {
+		return nil, errors.Errorf("seekable zstd checksums are not supported")
+	}
+
+	numFrames := int64(binary.LittleEndian.Uint32(footer[0:4]))
+
+	seekTableSize := numFrames*zstdSeekTableEntrySize + zstdSeekTableFooterSize
+	if seekTableSize+zstdSkippableHeaderSize > int64(len(data)) {
+		return nil, errors.Errorf("invalid seekable zstd frame count: %v", numFrames)
+	}
+
+	frameStart := int64(len(data)) - seekTableSize - zstdSkippableHeaderSize
+	skippableHeader := data[frameStart : frameStart+zstdSkippableHeaderSize]
+
+	if binary.LittleEndian.Uint32(skippableHeader[0:4]) != zstdSkippableFrameMagic || int64(binary.LittleEndian.Uint32(skippableHeader[4:8])) != seekTableSize {
+		return nil, errors.Errorf("invalid seekable zstd seek table")
+	}
+
+	r := &zstdSeekableReader{data: data}
+
+	var compressedOffset, decompressedOffset int64
+
+	entries := data[frameStart+zstdSkippableHeaderSize:]
+
+	for i := int64(0); i < numFrames; i++ {
+		e := entries[i*zstdSeekTableEntrySize:]
+
+		f := zstdSeekableFrame{
+			compressedOffset:   compressedOffset,
+			compressedSize:     int64(binary.LittleEndian.Uint32(e[0:4])),
+			decompressedOffset: decompressedOffset,
+			decompressedSize:   int64(binary.LittleEndian.Uint32(e[4:8])),
+		}
+
+		compressedOffset += f.compressedSize
+		decompressedOffset += f.decompressedSize
+
+		r.frames = append(r.frames, f)
+	}
+
+	if compressedOffset != frameStart {
+		return nil, errors.Errorf("seekable zstd seek table does not match data")
+	}
+
+	r.length = decompressedOffset
+
+	return r, nil
+}
+
+type zstdSeekableFrame struct {
+	compressedOffset   int64
+	compressedSize     int64
+	decompressedOffset int64
+	decompressedSize   int64
+}
+
+// zstdSeekableReader decompresses frames on demand and caches the most recently used one,
+// so that sequential reads don't decompress the same frame repeatedly.
+type zstdSeekableReader struct {
+	data   []byte
+	frames []zstdSeekableFrame
+	length int64
+
+	mu               sync.Mutex
+	cachedFrameIndex int
+	cachedFrameData  []byte
+}
+
+func (r *zstdSeekableReader) Length() int64 {
+	return r.length
+}
+
+func (r *zstdSeekableReader) ReadAt(buffer []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errors.Errorf("invalid negative offset %v", offset)
+	}
+
+	readBytes := 0
+
+	for readBytes < len(buffer) && offset < r.length {
+		index := r.findFrame(offset)
+
+		frameData, err := r.frameData(index)
+		if err != nil {
+			return readBytes, err
+		}
+
+		n := copy(buffer[readBytes:], frameData[offset-r.frames[index].decompressedOffset:])
+		readBytes += n
+		offset += int64(n)
+	}
+
+	if readBytes < len(buffer) {
+		return readBytes, io.EOF
+	}
+
+	return readBytes, nil
+}
+
+func (r *zstdSeekableReader) findFrame(offset int64) int {
+	left, right := 0, len(r.frames)-1
+
+	for left < right {
+		middle := (left + right + 1) / 2 //nolint:gomnd
+
+		if r.frames[middle].decompressedOffset <= offset {
+			left = middle
+		} else {
+			right = middle - 1
+		}
+	}
+
+	return left
+}
+
+func (r *zstdSeekableReader) frameData(index int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cachedFrameData != nil && r.cachedFrameIndex == index {
+		return r.cachedFrameData, nil
+	}
+
+	f := r.frames[index]
+
+	//nolint:forcetypeassert
+	dec := zstdSeekableDecoderPool.Get().(*zstd.Decoder)
+	defer zstdSeekableDecoderPool.Put(dec)
+
+	b, err := dec.DecodeAll(r.data[f.compressedOffset:f.compressedOffset+f.compressedSize], make([]byte, 0, f.decompressedSize))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decompressing frame %v", index)
+	}
+
+	if int64(len(b)) != f.decompressedSize {
+		return nil, errors.Errorf("unexpected length of frame %v: %v, expected %v", index, len(b), f.decompressedSize)
+	}
+
+	r.cachedFrameIndex = index
+	r.cachedFrameData = b
+
+	return b, nil
+}
+
+func writeUint32LE(b *bytes.Buffer, v uint32) {
+	var tmp [4]byte
+
+	binary.LittleEndian.PutUint32(tmp[:], v)
+	b.Write(tmp[:])
+}
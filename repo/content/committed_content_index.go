@@ -42,6 +42,9 @@ type committedContentIndex struct {
 	v1PerContentOverhead func() int
 	formatProvider       format.Provider
 
+	// when set, small indexes are not combined into a single in-memory index.
+	disableCombining bool
+
 	// fetchOne loads one index blob
 	fetchOne func(ctx context.Context, blobID blob.ID, output *gather.WriteBuffer) error
 
@@ -240,6 +243,10 @@ func (c *committedContentIndex) use(ctx context.Context, indexFiles []blob.ID, i
 }
 
 func (c *committedContentIndex) combineSmallIndexes(ctx context.Context, m index.Merged) (index.Merged, error) {
+	if c.disableCombining {
+		return m, nil
+	}
+
 	var toKeep, toMerge index.Merged
 
 	for _, ndx := range m {
@@ -367,6 +374,7 @@ func newCommittedContentIndex(caching *CachingOptions,
 	v1PerContentOverhead func() int,
 	formatProvider format.Provider,
 	permissiveCacheLoading bool,
+	disableCombining bool,
 	fetchOne func(ctx context.Context, blobID blob.ID, output *gather.WriteBuffer) error,
 	log logging.Logger,
 	minSweepAge time.Duration,
@@ -389,6 +397,7 @@ func newCommittedContentIndex(caching *CachingOptions,
 		inUse:                  map[blob.ID]index.Index{},
 		v1PerContentOverhead:   v1PerContentOverhead,
 		formatProvider:         formatProvider,
+		disableCombining:       disableCombining,
 		fetchOne:               fetchOne,
 		log:                    log,
 	}
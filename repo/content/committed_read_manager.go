@@ -25,6 +25,7 @@
 	"github.com/kopia/kopia/repo/blob/sharded"
 	"github.com/kopia/kopia/repo/compression"
 	"github.com/kopia/kopia/repo/content/indexblob"
+	"github.com/kopia/kopia/repo/encryption"
 	"github.com/kopia/kopia/repo/format"
 	"github.com/kopia/kopia/repo/hashing"
 	"github.com/kopia/kopia/repo/logging"
@@ -89,6 +90,7 @@ type SharedManager struct {
 	// exclusive lock will be acquired during compaction or refresh.
 	indexesLock            sync.RWMutex
 	permissiveCacheLoading bool
+	lowMemory              bool
 
 	// maybeRefreshIndexes() will call Refresh() after this point in ime.
 	// +checklocks:indexesLock
@@ -96,6 +98,12 @@ type SharedManager struct {
 
 	format format.Provider
 
+	// encryptor for data contents when the repository uses a separate data key, nil if the key is not available.
+	dataEncryptor encryption.Encryptor
+
+	// index of the linked base repository, whose contents are referenced instead of being uploaded.
+	baseIndex BaseContentIndex
+
 	checkInvariantsOnUnlock bool
 	minPreambleLength       int
 	maxPreambleLength       int
@@ -195,7 +203,7 @@ func (sm *SharedManager) attemptReadPackFileLocalIndex(ctx context.Context, pack
 	}
 
 	return errors.Wrap(
-		sm.decryptAndVerify(encryptedLocalIndexBytes.Bytes(), postamble.localIndexIV, output),
+		sm.decryptAndVerify(sm.format.Encryptor(), encryptedLocalIndexBytes.Bytes(), postamble.localIndexIV, output),
 		"unable to decrypt local index")
 }
 
@@ -289,22 +297,22 @@ func (sm *SharedManager) decryptContentAndVerify(payload gather.Bytes, bi Info,
 
 	iv := getPackedContentIV(hashBuf[:0], bi.GetContentID())
 
-	// reserved for future use
-	if k := bi.GetEncryptionKeyID(); k != 0 {
-		return errors.Errorf("unsupported encryption key ID: %v", k)
+	enc, err := sm.encryptorForContent(bi.GetContentID(), bi.GetEncryptionKeyID())
+	if err != nil {
+		return err
 	}
 
 	h := bi.GetCompressionHeaderID()
 	if h == 0 {
 		return errors.Wrapf(
-			sm.decryptAndVerify(payload, iv, output),
+			sm.decryptAndVerify(enc, payload, iv, output),
 			"invalid checksum at %v offset %v length %v/%v", bi.GetPackBlobID(), bi.GetPackOffset(), bi.GetPackedLength(), payload.Length())
 	}
 
 	var tmp gather.WriteBuffer
 	defer tmp.Close()
 
-	if err := sm.decryptAndVerify(payload, iv, &tmp); err != nil {
+	if err := sm.decryptAndVerify(enc, payload, iv, &tmp); err != nil {
 		return errors.Wrapf(err, "invalid checksum at %v offset %v length %v/%v", bi.GetPackBlobID(), bi.GetPackOffset(), bi.GetPackedLength(), payload.Length())
 	}
 
@@ -324,10 +332,26 @@ func (sm *SharedManager) decryptContentAndVerify(payload gather.Bytes, bi Info,
 	return nil
 }
 
-func (sm *SharedManager) decryptAndVerify(encrypted gather.Bytes, iv []byte, output *gather.WriteBuffer) error {
+// encryptorForContent returns the encryptor for the given content. When the repository uses a separate data key,
+// it's only used for data contents, while metadata contents (which are always prefixed) use the repository master key
+// identified by the provided key ID.
+func (sm *SharedManager) encryptorForContent(contentID ID, keyID byte) (encryption.Encryptor, error) {
+	if contentID.HasPrefix() || sm.format.GetDataKey() == nil {
+		//nolint:wrapcheck
+		return sm.format.EncryptorForKeyID(keyID)
+	}
+
+	if sm.dataEncryptor == nil {
+		return nil, ErrDataKeyUnavailable
+	}
+
+	return sm.dataEncryptor, nil
+}
+
+func (sm *SharedManager) decryptAndVerify(enc encryption.Encryptor, encrypted gather.Bytes, iv []byte, output *gather.WriteBuffer) error {
 	t0 := timetrack.StartTimer()
 
-	if err := sm.format.Encryptor().Decrypt(encrypted, iv, output); err != nil {
+	if err := enc.Decrypt(encrypted, iv, output); err != nil {
 		sm.Stats.foundInvalidContent()
 		return errors.Wrap(err, "decrypt")
 	}
@@ -454,11 +478,12 @@ func (sm *SharedManager) setupCachesAndIndexManagers(ctx context.Context, cachin
 		return errors.Wrap(err, "unable to initialize content cache")
 	}
 
+	// in low-memory mode only the requested ranges of metadata blobs are fetched instead of buffering entire blobs.
 	metadataCache, err := cache.NewContentCache(ctx, sm.st, cache.Options{
 		BaseCacheDirectory: caching.CacheDirectory,
 		CacheSubDir:        "metadata",
 		HMACSecret:         caching.HMACSecret,
-		FetchFullBlobs:     true,
+		FetchFullBlobs:     !sm.lowMemory,
 		Sweep:              metadataCacheSizeSweepSettings(caching),
 	}, mr)
 	if err != nil {
@@ -528,6 +553,7 @@ func(ctx context.Context, blobIDs []blob.ID, outputPrefix blob.ID) error {
 		sm.format.Encryptor().Overhead,
 		sm.format,
 		sm.permissiveCacheLoading,
+		sm.lowMemory,
 		enc.GetEncryptedBlob,
 		sm.namedLogger("committed-content-index"),
 		caching.MinIndexSweepAge.DurationOrDefault(DefaultIndexCacheSweepAge))
@@ -618,16 +644,27 @@ func NewSharedManager(ctx context.Context, st blob.Storage, prov format.Provider
 		timeNow:                 opts.TimeNow,
 		format:                  prov,
 		permissiveCacheLoading:  opts.PermissiveCacheLoading,
+		lowMemory:               opts.LowMemory,
 		minPreambleLength:       defaultMinPreambleLength,
 		maxPreambleLength:       defaultMaxPreambleLength,
 		paddingUnit:             defaultPaddingUnit,
 		checkInvariantsOnUnlock: os.Getenv("KOPIA_VERIFY_INVARIANTS") != "",
 		repoLogManager:          repoLogManager,
 		contextLogger:           logging.Module(FormatLogModule)(ctx),
+		baseIndex:               opts.BaseContentIndex,
 
 		metricsStruct: initMetricsStruct(mr),
 	}
 
+	if prov.GetDataKey() != nil && opts.DataPassword != "" {
+		de, err := format.NewDataEncryptor(prov, opts.DataPassword)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to open data key")
+		}
+
+		sm.dataEncryptor = de
+	}
+
 	if !opts.DisableInternalLog {
 		sm.internalLogger = sm.repoLogManager.NewLogger()
 	}
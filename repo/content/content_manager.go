@@ -63,6 +63,10 @@
 // ErrContentNotFound is returned when content is not found.
 var ErrContentNotFound = errors.New("content not found")
 
+// ErrDataKeyUnavailable is returned when accessing data contents of a repository that uses a separate data key
+// without providing the data password.
+var ErrDataKeyUnavailable = errors.New("data contents are encrypted with a separate data key, which requires the data password")
+
 // WriteManager builds content-addressable storage with encryption, deduplication and packaging on top of BLOB store.
 type WriteManager struct {
 	revision            atomic.Int64 // changes on each local write
@@ -250,22 +254,41 @@ func (bm *WriteManager) maybeRetryWritingFailedPacksUnlocked(ctx context.Context
 }
 
 func (bm *WriteManager) addToPackUnlocked(ctx context.Context, contentID ID, data gather.Bytes, isDeleted bool, comp compression.HeaderID, previousWriteTime int64, mp format.MutableParameters) error {
-	// see if the current index is old enough to cause automatic flush.
-	if err := bm.maybeFlushBasedOnTimeUnlocked(ctx); err != nil {
-		return errors.Wrap(err, "unable to flush old pending writes")
-	}
-
-	prefix := packPrefixForContentID(contentID)
-
 	var compressedAndEncrypted gather.WriteBuffer
 	defer compressedAndEncrypted.Close()
 
+	// contents are always encrypted using the current master key, the key ID is recorded in the index.
+	keyID := bm.format.GetEncryptionKeyID()
+
 	// encrypt and compress before taking lock
-	actualComp, err := bm.maybeCompressAndEncryptDataForPacking(data, contentID, comp, &compressedAndEncrypted, mp)
+	actualComp, err := bm.maybeCompressAndEncryptDataForPacking(data, contentID, comp, keyID, &compressedAndEncrypted, mp)
 	if err != nil {
 		return errors.Wrapf(err, "unable to encrypt %q", contentID)
 	}
 
+	return bm.addEncryptedToPackUnlocked(ctx, compressedAndEncrypted.Bytes(), Info{
+		Deleted:             isDeleted,
+		ContentID:           contentID,
+		FormatVersion:       byte(mp.Version),
+		OriginalLength:      uint32(data.Length()),
+		CompressionHeaderID: actualComp,
+		EncryptionKeyID:     keyID,
+	}, previousWriteTime, mp)
+}
+
+// addEncryptedToPackUnlocked adds already compressed and encrypted content payload to a pending pack.
+// The provided info must have the content ID, deletion status, format version, original length, compression
+// and encryption key ID set.
+func (bm *WriteManager) addEncryptedToPackUnlocked(ctx context.Context, payload gather.Bytes, info Info, previousWriteTime int64, mp format.MutableParameters) error {
+	// see if the current index is old enough to cause automatic flush.
+	err := bm.maybeFlushBasedOnTimeUnlocked(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to flush old pending writes")
+	}
+
+	contentID := info.ContentID
+	prefix := packPrefixForContentID(contentID)
+
 	bm.lock()
 
 	if previousWriteTime < 0 {
@@ -305,22 +328,15 @@ func (bm *WriteManager) addToPackUnlocked(ctx context.Context, contentID ID, dat
 		return errors.Wrap(err, "unable to create pending pack")
 	}
 
-	info := Info{
-		Deleted:          isDeleted,
-		ContentID:        contentID,
-		PackBlobID:       pp.packBlobID,
-		PackOffset:       uint32(pp.currentPackData.Length()),
-		TimestampSeconds: bm.contentWriteTime(previousWriteTime),
-		FormatVersion:    byte(mp.Version),
-		OriginalLength:   uint32(data.Length()),
-	}
+	info.PackBlobID = pp.packBlobID
+	info.PackOffset = uint32(pp.currentPackData.Length())
+	info.TimestampSeconds = bm.contentWriteTime(previousWriteTime)
 
-	if _, err := compressedAndEncrypted.Bytes().WriteTo(pp.currentPackData); err != nil {
+	if _, err := payload.WriteTo(pp.currentPackData); err != nil {
 		bm.unlock(ctx)
 		return errors.Wrapf(err, "unable to append %q to pack data", contentID)
 	}
 
-	info.CompressionHeaderID = actualComp
 	info.PackedLength = uint32(pp.currentPackData.Length()) - info.PackOffset
 
 	pp.currentPackItems[contentID] = info
@@ -675,6 +691,22 @@ func (bm *WriteManager) getContentDataAndInfo(ctx context.Context, contentID ID,
 	return bi, nil
 }
 
+func (bm *WriteManager) getContentPayloadAndInfo(ctx context.Context, contentID ID, output *gather.WriteBuffer) (Info, error) {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	pp, bi, err := bm.getContentInfoReadLocked(ctx, contentID)
+	if err != nil {
+		return Info{}, err
+	}
+
+	if err := bm.getContentPayloadReadLocked(ctx, pp, bi, output); err != nil {
+		return Info{}, err
+	}
+
+	return bi, nil
+}
+
 // UndeleteContent rewrites the content with the given ID if the content exists
 // and is mark deleted. If the content exists and is not marked deleted, this
 // operation is a no-op.
@@ -700,6 +732,10 @@ func (bm *WriteManager) rewriteContent(ctx context.Context, contentID ID, onlyRe
 	defer data.Close()
 
 	bi, err := bm.getContentDataAndInfo(ctx, contentID, &data)
+	if errors.Is(err, ErrDataKeyUnavailable) {
+		return bm.rewriteEncryptedContent(ctx, contentID, onlyRewriteDeleted, mp)
+	}
+
 	if err != nil {
 		return errors.Wrap(err, "unable to get content data and info")
 	}
@@ -717,6 +753,37 @@ func (bm *WriteManager) rewriteContent(ctx context.Context, contentID ID, onlyRe
 	return bm.addToPackUnlocked(ctx, contentID, data.Bytes(), isDeleted, bi.GetCompressionHeaderID(), bi.GetTimestampSeconds(), mp)
 }
 
+// rewriteEncryptedContent rewrites the content by copying its encrypted payload, which does not require
+// the ability to decrypt it. This allows maintenance of data contents without access to the data key.
+func (bm *WriteManager) rewriteEncryptedContent(ctx context.Context, contentID ID, onlyRewriteDeleted bool, mp format.MutableParameters) error {
+	var payload gather.WriteBuffer
+	defer payload.Close()
+
+	bi, err := bm.getContentPayloadAndInfo(ctx, contentID, &payload)
+	if err != nil {
+		return errors.Wrap(err, "unable to get content payload and info")
+	}
+
+	isDeleted := bi.GetDeleted()
+
+	if onlyRewriteDeleted {
+		if !isDeleted {
+			return nil
+		}
+
+		isDeleted = false
+	}
+
+	return bm.addEncryptedToPackUnlocked(ctx, payload.Bytes(), Info{
+		Deleted:             isDeleted,
+		ContentID:           contentID,
+		FormatVersion:       bi.GetFormatVersion(),
+		OriginalLength:      bi.GetOriginalLength(),
+		CompressionHeaderID: bi.GetCompressionHeaderID(),
+		EncryptionKeyID:     bi.GetEncryptionKeyID(),
+	}, bi.GetTimestampSeconds(), mp)
+}
+
 func packPrefixForContentID(contentID ID) blob.ID {
 	if contentID.HasPrefix() {
 		return PackBlobIDPrefixSpecial
@@ -780,6 +847,14 @@ func (bm *WriteManager) SupportsContentCompression(ctx context.Context) (bool, e
 // WriteContent saves a given content of data to a pack group with a provided name and returns a contentID
 // that's based on the contents of data written.
 func (bm *WriteManager) WriteContent(ctx context.Context, data gather.Bytes, prefix index.IDPrefix, comp compression.HeaderID) (ID, error) {
+	contentID, _, err := bm.WriteContentWithStatus(ctx, data, prefix, comp)
+
+	return contentID, err
+}
+
+// WriteContentWithStatus is like WriteContent but also returns whether the content was already present
+// in the repository, in which case no new data was written.
+func (bm *WriteManager) WriteContentWithStatus(ctx context.Context, data gather.Bytes, prefix index.IDPrefix, comp compression.HeaderID) (contentID ID, deduplicated bool, err error) {
 	t0 := timetrack.StartTimer()
 	defer func() {
 		bm.writeContentBytes.Observe(int64(data.Length()), t0.Elapsed())
@@ -787,22 +862,22 @@ func (bm *WriteManager) WriteContent(ctx context.Context, data gather.Bytes, pre
 
 	mp, mperr := bm.format.GetMutableParameters(ctx)
 	if mperr != nil {
-		return EmptyID, errors.Wrap(mperr, "mutable parameters")
+		return EmptyID, false, errors.Wrap(mperr, "mutable parameters")
 	}
 
 	if err := bm.maybeRetryWritingFailedPacksUnlocked(ctx); err != nil {
-		return EmptyID, err
+		return EmptyID, false, err
 	}
 
 	if err := prefix.ValidateSingle(); err != nil {
-		return EmptyID, errors.Wrap(err, "invalid prefix")
+		return EmptyID, false, errors.Wrap(err, "invalid prefix")
 	}
 
 	var hashOutput [hashing.MaxHashSize]byte
 
-	contentID, err := IDFromHash(prefix, bm.hashData(hashOutput[:0], data))
+	contentID, err = IDFromHash(prefix, bm.hashData(hashOutput[:0], data))
 	if err != nil {
-		return EmptyID, errors.Wrap(err, "invalid hash")
+		return EmptyID, false, errors.Wrap(err, "invalid hash")
 	}
 
 	previousWriteTime := int64(-1)
@@ -823,18 +898,30 @@ func (bm *WriteManager) WriteContent(ctx context.Context, data gather.Bytes, pre
 			bm.deduplicatedContents.Add(1)
 			bm.deduplicatedBytes.Add(int64(data.Length()))
 
-			return contentID, nil
+			return contentID, true, nil
 		}
 
 		previousWriteTime = bi.GetTimestampSeconds()
 
 		logbuf.AppendString(" previously-deleted:")
 		logbuf.AppendInt64(previousWriteTime)
+	} else if errors.Is(err, ErrContentNotFound) {
+		referenced, berr := bm.maybeReferenceBaseContent(ctx, contentID)
+		if berr != nil {
+			return EmptyID, false, berr
+		}
+
+		if referenced {
+			bm.baseDeduplicatedContents.Add(1)
+			bm.baseDeduplicatedBytes.Add(int64(data.Length()))
+
+			return contentID, true, nil
+		}
 	}
 
 	bm.log.Debugf(logbuf.String())
 
-	return contentID, bm.addToPackUnlocked(ctx, contentID, data, false, comp, previousWriteTime, mp)
+	return contentID, false, bm.addToPackUnlocked(ctx, contentID, data, false, comp, previousWriteTime, mp)
 }
 
 // GetContent gets the contents of a given content. If the content is not found returns ErrContentNotFound.
@@ -950,6 +1037,9 @@ type ManagerOptions struct {
 	TimeNow                func() time.Time // Time provider
 	DisableInternalLog     bool
 	PermissiveCacheLoading bool
+	DataPassword           string           // password protecting the separate data key, if any
+	BaseContentIndex       BaseContentIndex // index of the linked base repository, if any
+	LowMemory              bool             // don't combine small indexes in memory or buffer entire metadata blobs
 }
 
 // CloneOrDefault returns a clone of provided ManagerOptions or default empty struct if nil.
@@ -0,0 +1,53 @@
+package content
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// BaseRepositoryEncryptionKeyID is the encryption key ID of index entries referencing contents stored in the
+// linked base repository, which are decrypted using the master key of the base repository. The key is never
+// stored in the linked repository, so such contents can only be read while the base repository is open.
+const BaseRepositoryEncryptionKeyID byte = 0xFE
+
+// BaseContentIndex provides read-only access to the content index of a linked base repository.
+// Contents present in the base repository are referenced from the local index instead of being uploaded again,
+// which requires the linked repository to hash contents the same way as the base repository.
+type BaseContentIndex interface {
+	ContentInfo(ctx context.Context, contentID ID) (Info, error)
+}
+
+// maybeReferenceBaseContent adds index entry pointing at the pack blob of the base repository
+// if the provided content exists there, returns true if the content has been referenced.
+func (bm *WriteManager) maybeReferenceBaseContent(ctx context.Context, contentID ID) (bool, error) {
+	if bm.baseIndex == nil {
+		return false, nil
+	}
+
+	bi, err := bm.baseIndex.ContentInfo(ctx, contentID)
+	if errors.Is(err, ErrContentNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, errors.Wrapf(err, "error looking up content %v in base repository", contentID)
+	}
+
+	// only contents encrypted using the original master key of the base repository are referenced.
+	if bi.Deleted || bi.EncryptionKeyID != 0 {
+		return false, nil
+	}
+
+	bi.TimestampSeconds = bm.timeNow().Unix()
+	bi.EncryptionKeyID = BaseRepositoryEncryptionKeyID
+
+	bm.lock()
+	bm.packIndexBuilder.Add(bi)
+	bm.revision.Add(1)
+	bm.unlock(ctx)
+
+	bm.log.Debugf("referenced-base-content %v in %v", contentID, bi.PackBlobID)
+
+	return true, nil
+}
@@ -61,6 +61,17 @@ func (sm *SharedManager) CompactIndexes(ctx context.Context, opt indexblob.Compa
 	return nil
 }
 
+// IndexBlobsToCompact returns index blobs which CompactIndexes would merge with the provided options.
+func (sm *SharedManager) IndexBlobsToCompact(ctx context.Context, opt indexblob.CompactOptions) ([]indexblob.Metadata, error) {
+	ibm, err := sm.indexBlobManager(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:wrapcheck
+	return ibm.IndexBlobsToCompact(ctx, opt)
+}
+
 // ParseIndexBlob loads entries in a given index blob and returns them.
 func ParseIndexBlob(blobID blob.ID, encrypted gather.Bytes, crypter blobcrypto.Crypter) ([]Info, error) {
 	var data gather.WriteBuffer
@@ -229,6 +229,12 @@ func(ci Info) error {
 
 // IterateUnreferencedBlobs returns the list of unreferenced storage blobs.
 func (bm *WriteManager) IterateUnreferencedBlobs(ctx context.Context, blobPrefixes []blob.ID, parallellism int, callback func(blob.Metadata) error) error {
+	return bm.IterateUnreferencedBlobsIn(ctx, bm.st, blobPrefixes, parallellism, callback)
+}
+
+// IterateUnreferencedBlobsIn returns the list of unreferenced blobs, as listed by the provided storage,
+// which may be a point-in-time listing of the repository storage, such as bucket inventory.
+func (bm *WriteManager) IterateUnreferencedBlobsIn(ctx context.Context, listing blob.Storage, blobPrefixes []blob.ID, parallellism int, callback func(blob.Metadata) error) error {
 	usedPacks, err := bigmap.NewSet(ctx)
 	if err != nil {
 		return errors.Wrap(err, "new set")
@@ -274,7 +280,7 @@ func(pi PackInfo) error {
 
 	bm.log.Debugf("scanning prefixes %v", prefixes)
 
-	if err := blob.IterateAllPrefixesInParallel(ctx, parallellism, bm.st, prefixes,
+	if err := blob.IterateAllPrefixesInParallel(ctx, parallellism, listing, prefixes,
 		func(bm blob.Metadata) error {
 			if usedPacks.Contains([]byte(bm.BlobID)) {
 				return nil
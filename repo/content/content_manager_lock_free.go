@@ -24,7 +24,7 @@
 
 const indexBlobCompactionWarningThreshold = 1000
 
-func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes, contentID ID, comp compression.HeaderID, output *gather.WriteBuffer, mp format.MutableParameters) (compression.HeaderID, error) {
+func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes, contentID ID, comp compression.HeaderID, keyID byte, output *gather.WriteBuffer, mp format.MutableParameters) (compression.HeaderID, error) {
 	var hashOutput [hashing.MaxHashSize]byte
 
 	iv := getPackedContentIV(hashOutput[:0], contentID)
@@ -73,9 +73,14 @@ func (sm *SharedManager) maybeCompressAndEncryptDataForPacking(data gather.Bytes
 
 	sm.afterCompressionBytes.Add(int64(data.Length()))
 
+	enc, err := sm.encryptorForContent(contentID, keyID)
+	if err != nil {
+		return NoCompression, err
+	}
+
 	t1 := timetrack.StartTimer()
 
-	if err := sm.format.Encryptor().Encrypt(data, iv, output); err != nil {
+	if err := enc.Encrypt(data, iv, output); err != nil {
 		return NoCompression, errors.Wrap(err, "unable to encrypt")
 	}
 
@@ -108,17 +113,26 @@ func (sm *SharedManager) getContentDataReadLocked(ctx context.Context, pp *pendi
 	var payload gather.WriteBuffer
 	defer payload.Close()
 
+	if err := sm.getContentPayloadReadLocked(ctx, pp, bi, &payload); err != nil {
+		return err
+	}
+
+	return sm.decryptContentAndVerify(payload.Bytes(), bi, output)
+}
+
+// getContentPayloadReadLocked gets the encrypted (and possibly compressed) payload of the content.
+func (sm *SharedManager) getContentPayloadReadLocked(ctx context.Context, pp *pendingPackInfo, bi Info, payload *gather.WriteBuffer) error {
 	if pp != nil && pp.packBlobID == bi.GetPackBlobID() {
 		// we need to use a lock here in case somebody else writes to the pack at the same time.
-		if err := pp.currentPackData.AppendSectionTo(&payload, int(bi.GetPackOffset()), int(bi.GetPackedLength())); err != nil {
+		if err := pp.currentPackData.AppendSectionTo(payload, int(bi.GetPackOffset()), int(bi.GetPackedLength())); err != nil {
 			// should never happen
 			return errors.Wrap(err, "error appending pending content data to buffer")
 		}
-	} else if err := sm.getCacheForContentID(bi.GetContentID()).GetContent(ctx, contentCacheKeyForInfo(bi), bi.GetPackBlobID(), int64(bi.GetPackOffset()), int64(bi.GetPackedLength()), &payload); err != nil {
+	} else if err := sm.getCacheForContentID(bi.GetContentID()).GetContent(ctx, contentCacheKeyForInfo(bi), bi.GetPackBlobID(), int64(bi.GetPackOffset()), int64(bi.GetPackedLength()), payload); err != nil {
 		return errors.Wrap(err, "error getting cached content")
 	}
 
-	return sm.decryptContentAndVerify(payload.Bytes(), bi, output)
+	return nil
 }
 
 func (sm *SharedManager) preparePackDataContent(mp format.MutableParameters, pp *pendingPackInfo) (index.Builder, error) {
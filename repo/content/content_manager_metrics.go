@@ -30,6 +30,9 @@ type metricsStruct struct {
 
 	deduplicatedBytes    *metrics.Counter
 	deduplicatedContents *metrics.Counter
+
+	baseDeduplicatedBytes    *metrics.Counter
+	baseDeduplicatedContents *metrics.Counter
 }
 
 func initMetricsStruct(mr *metrics.Registry) metricsStruct {
@@ -40,6 +43,9 @@ func initMetricsStruct(mr *metrics.Registry) metricsStruct {
 		deduplicatedContents:    mr.CounterInt64("content_deduplicated", "Number of contents deduplicated.", nil),
 		deduplicatedBytes:       mr.CounterInt64("content_deduplicated_bytes", "Number of bytes deduplicated.", nil),
 
+		baseDeduplicatedContents: mr.CounterInt64("content_base_deduplicated", "Number of contents referenced from the base repository.", nil),
+		baseDeduplicatedBytes:    mr.CounterInt64("content_base_deduplicated_bytes", "Number of bytes referenced from the base repository.", nil),
+
 		writeContentBytes: mr.Throughput("content_write", "WriteContent throughput (before deduplication)", nil),
 		hashedBytes:       mr.Throughput("content_hashed", "Hashing throughput.", nil),
 
@@ -217,6 +217,35 @@ func (s *contentManagerSuite) TestContentManagerDedupesPendingAndUncommittedCont
 	}
 }
 
+func (s *contentManagerSuite) TestContentManagerWriteContentWithStatus(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := blobtesting.DataMap{}
+	st := blobtesting.NewMapStorage(data, nil, nil)
+	bm := s.newTestContentManager(t, st)
+
+	defer bm.CloseShared(ctx)
+
+	cid1, deduplicated, err := bm.WriteContentWithStatus(ctx, gather.FromSlice([]byte("foo")), "", NoCompression)
+	require.NoError(t, err)
+	require.False(t, deduplicated)
+
+	// pending content is deduplicated.
+	cid2, deduplicated, err := bm.WriteContentWithStatus(ctx, gather.FromSlice([]byte("foo")), "", NoCompression)
+	require.NoError(t, err)
+	require.True(t, deduplicated)
+	require.Equal(t, cid1, cid2)
+
+	require.NoError(t, bm.Flush(ctx))
+
+	_, deduplicated, err = bm.WriteContentWithStatus(ctx, gather.FromSlice([]byte("foo")), "", NoCompression)
+	require.NoError(t, err)
+	require.True(t, deduplicated)
+
+	_, deduplicated, err = bm.WriteContentWithStatus(ctx, gather.FromSlice([]byte("bar")), "", NoCompression)
+	require.NoError(t, err)
+	require.False(t, deduplicated)
+}
+
 func (s *contentManagerSuite) TestContentManagerEmpty(t *testing.T) {
 	ctx := testlogging.Context(t)
 	data := blobtesting.DataMap{}
@@ -6,6 +6,7 @@
 	"strings"
 	"sync"
 
+	"github.com/kopia/kopia/internal/cache"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo/blob"
 )
@@ -83,6 +84,9 @@ func (bm *WriteManager) PrefetchContents(ctx context.Context, contentIDs []ID, h
 	type work struct {
 		blobID    blob.ID
 		contentID ID
+
+		// when set, contents of the blob are fetched using as few range requests as possible.
+		blobContents []Info
 	}
 
 	workCh := make(chan work)
@@ -93,9 +97,12 @@ type work struct {
 		defer close(workCh)
 
 		for b, infos := range contentsByBlob {
-			if o.shouldPrefetchEntireBlob(infos) {
+			switch {
+			case o.shouldPrefetchEntireBlob(infos):
 				workCh <- work{blobID: b}
-			} else {
+			case len(infos) > 1:
+				workCh <- work{blobID: b, blobContents: infos}
+			default:
 				for _, bi := range infos {
 					workCh <- work{contentID: bi.GetContentID()}
 				}
@@ -114,6 +121,10 @@ type work struct {
 
 			for w := range workCh {
 				switch {
+				case len(w.blobContents) > 0:
+					if err := bm.prefetchContentRanges(ctx, w.blobID, w.blobContents); err != nil {
+						bm.log.Debugw("error prefetching content ranges", "blobID", w.blobID, "err", err)
+					}
 				case strings.HasPrefix(string(w.blobID), string(PackBlobIDPrefixRegular)):
 					if err := bm.contentCache.PrefetchBlob(ctx, w.blobID); err != nil {
 						bm.log.Debugw("error prefetching data blob", "blobID", w.blobID, "err", err)
@@ -137,3 +148,20 @@ type work struct {
 
 	return prefetched
 }
+
+// prefetchContentRanges fetches the provided contents of a single pack blob into the cache,
+// retrieving multiple contents using a single request where possible.
+func (bm *WriteManager) prefetchContentRanges(ctx context.Context, blobID blob.ID, infos []Info) error {
+	ranges := make([]cache.ContentRange, 0, len(infos))
+
+	for _, bi := range infos {
+		ranges = append(ranges, cache.ContentRange{
+			ContentID: contentCacheKeyForInfo(bi),
+			Offset:    int64(bi.GetPackOffset()),
+			Length:    int64(bi.GetPackedLength()),
+		})
+	}
+
+	//nolint:wrapcheck
+	return bm.getCacheForContentID(infos[0].GetContentID()).PrefetchContentRanges(ctx, blobID, ranges)
+}
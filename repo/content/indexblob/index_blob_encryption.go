@@ -39,7 +39,24 @@ func (m *EncryptionManager) GetEncryptedBlob(ctx context.Context, blobID blob.ID
 		return errors.Wrap(err, "getContent")
 	}
 
-	return errors.Wrap(blobcrypto.Decrypt(m.crypter, payload.Bytes(), blobID, output), "decrypt blob")
+	if blobcrypto.Decrypt(m.crypter, payload.Bytes(), blobID, output) == nil {
+		return nil
+	}
+
+	// the cached blob may have been re-encrypted in the storage after encryption key change, fetch it again.
+	payload.Reset()
+
+	if err := m.st.GetBlob(ctx, blobID, 0, -1, &payload); err != nil {
+		return errors.Wrap(err, "getContent")
+	}
+
+	if err := blobcrypto.Decrypt(m.crypter, payload.Bytes(), blobID, output); err != nil {
+		return errors.Wrap(err, "decrypt blob")
+	}
+
+	m.indexBlobCache.Put(ctx, string(blobID), payload.Bytes())
+
+	return nil
 }
 
 // EncryptAndWriteBlob encrypts and writes the provided data into a blob,
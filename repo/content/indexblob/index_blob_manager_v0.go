@@ -150,18 +150,11 @@ func (m *ManagerV0) Invalidate() {
 // Compact performs compaction of index blobs by merging smaller ones into larger
 // and registering compaction and cleanup blobs in the repository.
 func (m *ManagerV0) Compact(ctx context.Context, opt CompactOptions) error {
-	indexBlobs, _, err := m.ListActiveIndexBlobs(ctx)
+	blobsToCompact, err := m.IndexBlobsToCompact(ctx, opt)
 	if err != nil {
-		return errors.Wrap(err, "error listing active index blobs")
-	}
-
-	mp, mperr := m.formattingOptions.GetMutableParameters(ctx)
-	if mperr != nil {
-		return errors.Wrap(mperr, "mutable parameters")
+		return err
 	}
 
-	blobsToCompact := m.getBlobsToCompact(indexBlobs, opt, mp)
-
 	if err := m.compactIndexBlobs(ctx, blobsToCompact, opt); err != nil {
 		return errors.Wrap(err, "error performing compaction")
 	}
@@ -173,6 +166,31 @@ func (m *ManagerV0) Compact(ctx context.Context, opt CompactOptions) error {
 	return nil
 }
 
+// IndexBlobsToCompact returns the index blobs which would be merged by Compact with the provided options.
+func (m *ManagerV0) IndexBlobsToCompact(ctx context.Context, opt CompactOptions) ([]Metadata, error) {
+	indexBlobs, _, err := m.ListActiveIndexBlobs(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing active index blobs")
+	}
+
+	mp, mperr := m.formattingOptions.GetMutableParameters(ctx)
+	if mperr != nil {
+		return nil, errors.Wrap(mperr, "mutable parameters")
+	}
+
+	blobsToCompact := m.getBlobsToCompact(indexBlobs, opt, mp)
+	if !shouldCompactIndexBlobs(blobsToCompact, opt) {
+		return nil, nil
+	}
+
+	return blobsToCompact, nil
+}
+
+// shouldCompactIndexBlobs returns true if compacting the provided index blobs changes anything.
+func shouldCompactIndexBlobs(indexBlobs []Metadata, opt CompactOptions) bool {
+	return len(indexBlobs) > 1 || !opt.DropDeletedBefore.IsZero() || len(opt.DropContents) > 0
+}
+
 func (m *ManagerV0) registerCompaction(ctx context.Context, inputs, outputs []blob.Metadata, maxEventualConsistencySettleTime time.Duration) error {
 	logEntryBytes, err := json.Marshal(&compactionLogEntry{
 		InputMetadata:  inputs,
@@ -480,7 +498,7 @@ func (m *ManagerV0) getBlobsToCompact(indexBlobs []Metadata, opt CompactOptions,
 }
 
 func (m *ManagerV0) compactIndexBlobs(ctx context.Context, indexBlobs []Metadata, opt CompactOptions) error {
-	if len(indexBlobs) <= 1 && opt.DropDeletedBefore.IsZero() && len(opt.DropContents) == 0 {
+	if !shouldCompactIndexBlobs(indexBlobs, opt) {
 		return nil
 	}
 
@@ -66,6 +66,12 @@ func (m *ManagerV1) Compact(ctx context.Context, opt CompactOptions) error {
 	return errors.Wrap(m.epochMgr.AdvanceDeletionWatermark(ctx, opt.DropDeletedBefore), "error advancing deletion watermark")
 }
 
+// IndexBlobsToCompact returns no blobs, since index blobs are compacted by the epoch manager and Compact
+// only advances the deletion watermark.
+func (m *ManagerV1) IndexBlobsToCompact(ctx context.Context, opt CompactOptions) ([]Metadata, error) {
+	return nil, nil
+}
+
 // CompactEpoch compacts the provided index blobs and writes a new set of blobs.
 func (m *ManagerV1) CompactEpoch(ctx context.Context, blobIDs []blob.ID, outputPrefix blob.ID) error {
 	tmpbld := make(index.Builder)
@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/feature"
+)
+
+// encryptionKeyRotationFeature is required to open repositories whose master key has been changed,
+// since contents may be encrypted using different master keys identified in the index.
+const encryptionKeyRotationFeature feature.Feature = "encryption-key-rotation"
+
+// ChangeEncryptionKey generates a new master key used to encrypt new contents and blobs of the repository.
+// Previous master keys are retained until all contents using them are re-encrypted.
+func ChangeEncryptionKey(ctx context.Context, rep DirectRepositoryWriter) error {
+	fm := rep.FormatManager()
+
+	required, err := fm.RequiredFeatures(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to get required features")
+	}
+
+	required = append([]feature.Required(nil), required...)
+
+	if !hasRequiredFeature(required, encryptionKeyRotationFeature) {
+		required = append(required, feature.Required{
+			Feature: encryptionKeyRotationFeature,
+			IfNotUnderstood: feature.IfNotUnderstood{
+				Message: "The repository master key has been changed.",
+			},
+		})
+	}
+
+	return errors.Wrap(fm.ChangeEncryptionKey(ctx, required), "unable to change encryption key")
+}
+
+func hasRequiredFeature(required []feature.Required, f feature.Feature) bool {
+	for _, r := range required {
+		if r.Feature == f {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,12 @@
+package format
+
+import "time"
+
+// AutoTuneResult describes the parameters that were selected automatically based on benchmarks
+// performed when the repository was created, along with the rationale for each choice.
+type AutoTuneResult struct {
+	Time                 time.Time `json:"time"`
+	Compression          string    `json:"compression,omitempty"`          // compression recommended for the global policy
+	MaxParallelFileReads int       `json:"maxParallelFileReads,omitempty"` // parallelism recommended for the global policy
+	Rationale            []string  `json:"rationale,omitempty"`
+}
@@ -0,0 +1,105 @@
+package format
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/feature"
+)
+
+// MinClientVersionFeature is the required feature added to repositories which enforce minimum client version,
+// it prevents clients that predate version gating from opening such repositories.
+const MinClientVersionFeature feature.Feature = "min-client-version"
+
+// ErrClientVersionTooOld is returned when attempting to write to a repository using a client that is older
+// than the minimum version required by the repository.
+var ErrClientVersionTooOld = errors.New("client version is too old to write to this repository")
+
+// clientVersion is a parsed release version in the form of [major, minor, patch].
+type clientVersion [3]int
+
+func (v clientVersion) less(other clientVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] < other[i]
+		}
+	}
+
+	return false
+}
+
+// parseClientVersion parses release versions such as "0.15.0", "v0.15.2" or "v0.16.0-rc1",
+// pre-release suffixes are ignored.
+func parseClientVersion(s string) (clientVersion, bool) {
+	var v clientVersion
+
+	s = strings.TrimPrefix(s, "v")
+	s, _, _ = strings.Cut(s, "-")
+
+	parts := strings.Split(s, ".")
+	if len(parts) != len(v) {
+		return v, false
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+
+		v[i] = n
+	}
+
+	return v, true
+}
+
+// CheckClientVersion returns ErrClientVersionTooOld (wrapped with guidance for users) when the provided
+// client version is older than the minimum version. Development builds, whose version is not a release
+// version, are never considered too old.
+func CheckClientVersion(minVersion, version string) error {
+	if minVersion == "" {
+		return nil
+	}
+
+	minv, ok := parseClientVersion(minVersion)
+	if !ok {
+		return errors.Errorf("invalid minimum client version %q", minVersion)
+	}
+
+	v, ok := parseClientVersion(version)
+	if !ok {
+		return nil
+	}
+
+	if v.less(minv) {
+		return errors.Wrapf(ErrClientVersionTooOld, "repository requires kopia %v or newer to write, this is %v, please upgrade", minVersion, version)
+	}
+
+	return nil
+}
+
+// WithMinClientVersionFeature returns the list of required features updated to require MinClientVersionFeature
+// when minimum client version is set, or without it otherwise.
+func WithMinClientVersionFeature(required []feature.Required, minVersion string) []feature.Required {
+	var result []feature.Required
+
+	for _, r := range required {
+		if r.Feature != MinClientVersionFeature {
+			result = append(result, r)
+		}
+	}
+
+	if minVersion != "" {
+		result = append(result, feature.Required{
+			Feature: MinClientVersionFeature,
+			IfNotUnderstood: feature.IfNotUnderstood{
+				Message:          "This repository requires a minimum version of kopia to protect its format.",
+				UpgradeToVersion: minVersion,
+			},
+		})
+	}
+
+	return result
+}
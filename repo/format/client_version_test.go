@@ -0,0 +1,54 @@
+package format_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/feature"
+	"github.com/kopia/kopia/repo/format"
+)
+
+func TestCheckClientVersion(t *testing.T) {
+	cases := []struct {
+		minVersion string
+		version    string
+		wantErr    bool
+	}{
+		{"", "0.1.0", false},
+		{"0.15.0", "0.15.0", false},
+		{"0.15.0", "v0.15.1", false},
+		{"0.15.0", "0.16.0-rc1", false},
+		{"0.15.0", "1.0.0", false},
+		{"v0.15.0", "0.14.9", true},
+		{"0.15.0", "v0.9.0", true},
+		{"1.2.3", "1.2.2", true},
+		{"0.15.0", "v0-unofficial", false},
+	}
+
+	for _, tc := range cases {
+		err := format.CheckClientVersion(tc.minVersion, tc.version)
+		if tc.wantErr {
+			require.ErrorIs(t, err, format.ErrClientVersionTooOld, "%v vs %v", tc.minVersion, tc.version)
+		} else {
+			require.NoError(t, err, "%v vs %v", tc.minVersion, tc.version)
+		}
+	}
+
+	require.Error(t, format.CheckClientVersion("latest", "0.1.0"))
+}
+
+func TestWithMinClientVersionFeature(t *testing.T) {
+	other := feature.Required{Feature: "other"}
+
+	rf := format.WithMinClientVersionFeature([]feature.Required{other}, "0.15.0")
+	require.Len(t, rf, 2)
+	require.Equal(t, format.MinClientVersionFeature, rf[1].Feature)
+	require.Equal(t, "0.15.0", rf[1].IfNotUnderstood.UpgradeToVersion)
+
+	rf = format.WithMinClientVersionFeature(rf, "0.16.0")
+	require.Len(t, rf, 2)
+	require.Equal(t, "0.16.0", rf[1].IfNotUnderstood.UpgradeToVersion)
+
+	require.Equal(t, []feature.Required{other}, format.WithMinClientVersionFeature(rf, ""))
+}
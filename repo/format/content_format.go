@@ -21,6 +21,12 @@ type ContentFormat struct {
 	MutableParameters
 
 	EnablePasswordChange bool `json:"enablePasswordChange"` // disables replication of kopia.repository blob in packs
+
+	DataKey *DataKeyFormat `json:"dataKey,omitempty"` // separate key used to encrypt data contents, metadata uses MasterKey
+
+	EncryptionKeyID        byte                    `json:"encryptionKeyID,omitempty"`                    // ID of MasterKey recorded in index entries, changes on key rotation
+	PreviousEncryptionKeys []PreviousEncryptionKey `json:"previousEncryptionKeys,omitempty"`             // master keys replaced by key rotation
+	KeyDerivationKey       []byte                  `json:"keyDerivationKey,omitempty" kopia:"sensitive"` // original master key used to derive purpose-specific keys after key rotation
 }
 
 // ResolveFormatVersion applies format options parameters based on the format version.
@@ -62,6 +68,8 @@ type MutableParameters struct {
 	MaxPackSize     int              `json:"maxPackSize,omitempty"`     // maximum size of a pack object
 	IndexVersion    int              `json:"indexVersion,omitempty"`    // force particular index format version (1,2,..)
 	EpochParameters epoch.Parameters `json:"epochParameters,omitempty"` // epoch manager parameters
+
+	MinClientVersion string `json:"minClientVersion,omitempty"` // oldest version of kopia allowed to write to the repository
 }
 
 // Validate validates the parameters.
@@ -82,6 +90,12 @@ func (v *MutableParameters) Validate() error {
 		return errors.Wrap(err, "invalid epoch parameters")
 	}
 
+	if v.MinClientVersion != "" {
+		if _, ok := parseClientVersion(v.MinClientVersion); !ok {
+			return errors.Errorf("invalid minimum client version %q, must be in the form 'X.Y.Z'", v.MinClientVersion)
+		}
+	}
+
 	return nil
 }
 
@@ -95,6 +109,11 @@ func (f *ContentFormat) GetMasterKey() []byte {
 	return f.MasterKey
 }
 
+// GetDataKey implements FormattingOptionsProvider.
+func (f *ContentFormat) GetDataKey() *DataKeyFormat {
+	return f.DataKey
+}
+
 // GetECCAlgorithm implements ecc.Parameters.
 func (f *ContentFormat) GetECCAlgorithm() string {
 	return f.ECC
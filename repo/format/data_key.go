@@ -0,0 +1,126 @@
+package format
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/crypto"
+	"github.com/kopia/kopia/repo/ecc"
+	"github.com/kopia/kopia/repo/encryption"
+)
+
+const (
+	dataKeyLength     = 32
+	dataKeySaltLength = 32
+)
+
+// ErrInvalidDataPassword is returned when the password protecting the data key is invalid.
+var ErrInvalidDataPassword = errors.Errorf("invalid data password") // +checklocksignore
+
+// DataKeyFormat describes the key used to encrypt file contents in repositories where metadata
+// (manifests, directories and indirect object indexes) and data are encrypted using separate keys.
+//
+// The data key is protected by a separate data password, so users who only know the repository password
+// can list snapshots, browse directories and run maintenance, but can't decrypt file contents.
+type DataKeyFormat struct {
+	KeyDerivationAlgorithm string `json:"keyAlgo"`
+	Salt                   []byte `json:"salt"`
+	EncryptedKey           []byte `json:"encryptedKey"`
+}
+
+// NewDataKeyFormat generates a new random data key and protects it with the provided password.
+func NewDataKeyFormat(dataPassword, keyDerivationAlgorithm string) (*DataKeyFormat, error) {
+	f := &DataKeyFormat{
+		KeyDerivationAlgorithm: keyDerivationAlgorithm,
+		Salt:                   make([]byte, dataKeySaltLength),
+	}
+
+	key := make([]byte, dataKeyLength)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.Wrap(err, "error generating data key")
+	}
+
+	if _, err := io.ReadFull(rand.Reader, f.Salt); err != nil {
+		return nil, errors.Wrap(err, "error generating salt")
+	}
+
+	protectionKey, err := crypto.DeriveKeyFromPassword(dataPassword, f.Salt, f.KeyDerivationAlgorithm)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to derive data key protection key")
+	}
+
+	f.EncryptedKey, err = crypto.EncryptAes256Gcm(key, protectionKey, f.Salt)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to encrypt data key")
+	}
+
+	return f, nil
+}
+
+// DecryptKey returns the data key protected with the provided password.
+func (f *DataKeyFormat) DecryptKey(dataPassword string) ([]byte, error) {
+	protectionKey, err := crypto.DeriveKeyFromPassword(dataPassword, f.Salt, f.KeyDerivationAlgorithm)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to derive data key protection key")
+	}
+
+	key, err := crypto.DecryptAes256Gcm(f.EncryptedKey, protectionKey, f.Salt)
+	if err != nil {
+		return nil, ErrInvalidDataPassword
+	}
+
+	return key, nil
+}
+
+// keyParameters implements encryption.Parameters for keys other than the current master key.
+type keyParameters struct {
+	algorithm string
+	key       []byte
+}
+
+func (p keyParameters) GetEncryptionAlgorithm() string {
+	return p.algorithm
+}
+
+func (p keyParameters) GetMasterKey() []byte {
+	return p.key
+}
+
+// NewDataEncryptor returns the encryptor for data contents of the repository which uses a separate data key.
+func NewDataEncryptor(p Provider, dataPassword string) (encryption.Encryptor, error) {
+	dk := p.GetDataKey()
+	if dk == nil {
+		return nil, errors.Errorf("repository does not use a separate data key")
+	}
+
+	key, err := dk.DecryptKey(dataPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := encryption.CreateEncryptor(keyParameters{p.GetEncryptionAlgorithm(), key})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create data encryptor")
+	}
+
+	return wrapWithECC(e, p)
+}
+
+func wrapWithECC(e encryption.Encryptor, p ecc.Parameters) (encryption.Encryptor, error) {
+	if p.GetECCAlgorithm() == "" || p.GetECCOverheadPercent() <= 0 {
+		return e, nil
+	}
+
+	eccEncryptor, err := ecc.CreateEncryptor(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create ECC")
+	}
+
+	return &encryptorWrapper{
+		impl: e,
+		next: eccEncryptor,
+	}, nil
+}
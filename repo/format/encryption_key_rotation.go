@@ -0,0 +1,184 @@
+package format
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/feature"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo/content/index"
+	"github.com/kopia/kopia/repo/encryption"
+)
+
+// maxEncryptionKeyID is the largest key ID that can be recorded in the index, 0xFF is reserved as invalid.
+const maxEncryptionKeyID = 0xFE
+
+// PreviousEncryptionKey is a master key replaced by key rotation, which is still needed to decrypt contents
+// and blobs written before the rotation until they are all re-encrypted using the current key.
+type PreviousEncryptionKey struct {
+	KeyID       byte      `json:"keyID"`
+	MasterKey   []byte    `json:"masterKey" kopia:"sensitive"`
+	RotatedTime time.Time `json:"rotatedTime"`
+}
+
+// GetEncryptionKeyID returns the ID of the current master key, which is recorded in index entries of contents.
+func (f *ContentFormat) GetEncryptionKeyID() byte {
+	return f.EncryptionKeyID
+}
+
+// GetPreviousEncryptionKeys returns master keys replaced by key rotation.
+func (f *ContentFormat) GetPreviousEncryptionKeys() []PreviousEncryptionKey {
+	return f.PreviousEncryptionKeys
+}
+
+// GetKeyDerivationKey returns the key used to derive purpose-specific keys, which is the original master key
+// and does not change on key rotation, so that blobs and tokens protected using derived keys remain valid.
+func (f *ContentFormat) GetKeyDerivationKey() []byte {
+	if f.KeyDerivationKey != nil {
+		return f.KeyDerivationKey
+	}
+
+	return f.MasterKey
+}
+
+// keyRotationEncryptor encrypts using the current master key and decrypts using any of the master keys,
+// which allows reading blobs written before key rotation.
+type keyRotationEncryptor struct {
+	current  encryption.Encryptor
+	previous []encryption.Encryptor
+}
+
+func (e *keyRotationEncryptor) Encrypt(plainText gather.Bytes, contentID []byte, output *gather.WriteBuffer) error {
+	//nolint:wrapcheck
+	return e.current.Encrypt(plainText, contentID, output)
+}
+
+func (e *keyRotationEncryptor) Decrypt(cipherText gather.Bytes, contentID []byte, output *gather.WriteBuffer) error {
+	err := e.current.Decrypt(cipherText, contentID, output)
+	if err == nil {
+		return nil
+	}
+
+	for _, p := range e.previous {
+		output.Reset()
+
+		if p.Decrypt(cipherText, contentID, output) == nil {
+			return nil
+		}
+	}
+
+	//nolint:wrapcheck
+	return err
+}
+
+func (e *keyRotationEncryptor) Overhead() int {
+	return e.current.Overhead()
+}
+
+// createEncryptorsByKeyID returns encryptors for the current and previous master keys indexed by key ID.
+func createEncryptorsByKeyID(f *ContentFormat, current encryption.Encryptor) (map[byte]encryption.Encryptor, error) {
+	result := map[byte]encryption.Encryptor{
+		f.EncryptionKeyID: current,
+	}
+
+	for _, pk := range f.PreviousEncryptionKeys {
+		e, err := encryption.CreateEncryptor(keyParameters{f.Encryption, pk.MasterKey})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to create encryptor for key %v", pk.KeyID)
+		}
+
+		e, err = wrapWithECC(e, f)
+		if err != nil {
+			return nil, err
+		}
+
+		result[pk.KeyID] = e
+	}
+
+	return result, nil
+}
+
+// ChangeEncryptionKey generates a new master key used to encrypt new contents and blobs and rewrites
+// `kopia.repository`. The old master key is retained until DestroyPreviousEncryptionKeys() is called.
+func (m *Manager) ChangeEncryptionKey(ctx context.Context, requiredFeatures []feature.Required) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := *m.repoConfig
+	cf := &m.repoConfig.ContentFormat
+
+	if cf.Version < FormatVersion2 || cf.IndexVersion < index.Version2 {
+		return errors.Errorf("changing encryption key requires repository format version 2 or newer")
+	}
+
+	if cf.DataKey != nil {
+		return errors.Errorf("changing encryption key is not supported for repositories using a separate data key")
+	}
+
+	if cf.EncryptionKeyID >= maxEncryptionKeyID {
+		return errors.Errorf("maximum number of encryption key changes reached")
+	}
+
+	newKey := make([]byte, len(cf.MasterKey))
+	if _, err := io.ReadFull(rand.Reader, newKey); err != nil {
+		return errors.Wrap(err, "unable to generate master key")
+	}
+
+	if cf.KeyDerivationKey == nil {
+		cf.KeyDerivationKey = cf.MasterKey
+	}
+
+	cf.PreviousEncryptionKeys = append(append([]PreviousEncryptionKey(nil), cf.PreviousEncryptionKeys...), PreviousEncryptionKey{
+		KeyID:       cf.EncryptionKeyID,
+		MasterKey:   cf.MasterKey,
+		RotatedTime: m.timeNow(),
+	})
+	cf.MasterKey = newKey
+	cf.EncryptionKeyID++
+	m.repoConfig.RequiredFeatures = requiredFeatures
+
+	if err := m.updateRepoConfigAndProviderLocked(ctx); err != nil {
+		*m.repoConfig = previous
+		return err
+	}
+
+	return nil
+}
+
+// DestroyPreviousEncryptionKeys removes master keys replaced by key rotation from `kopia.repository`.
+// The caller must ensure that no contents or blobs encrypted using those keys remain in use.
+// The key used to derive purpose-specific keys is retained.
+func (m *Manager) DestroyPreviousEncryptionKeys(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.repoConfig.ContentFormat.PreviousEncryptionKeys
+	m.repoConfig.ContentFormat.PreviousEncryptionKeys = nil
+
+	if err := m.updateRepoConfigAndProviderLocked(ctx); err != nil {
+		m.repoConfig.ContentFormat.PreviousEncryptionKeys = previous
+		return err
+	}
+
+	return nil
+}
+
+// +checklocks:m.mu
+func (m *Manager) updateRepoConfigAndProviderLocked(ctx context.Context) error {
+	prov, err := NewFormattingOptionsProvider(&m.repoConfig.ContentFormat, nil)
+	if err != nil {
+		return errors.Wrap(err, "error creating format provider")
+	}
+
+	if err := m.updateRepoConfigLocked(ctx); err != nil {
+		return err
+	}
+
+	m.current = prov
+
+	return nil
+}
@@ -227,14 +227,58 @@ func (m *Manager) HashFunc() hashing.HashFunc {
 	return m.immutable.HashFunc()
 }
 
-// Encryptor returns the resolved encryptor.
+// Encryptor returns the resolved encryptor, which uses the current master key.
 func (m *Manager) Encryptor() encryption.Encryptor {
-	return m.immutable.Encryptor()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.current.Encryptor()
 }
 
-// GetMasterKey gets the master key.
+// EncryptorForKeyID returns the encryptor using the master key with the provided ID.
+func (m *Manager) EncryptorForKeyID(keyID byte) (encryption.Encryptor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	//nolint:wrapcheck
+	return m.current.EncryptorForKeyID(keyID)
+}
+
+// GetMasterKey gets the current master key.
 func (m *Manager) GetMasterKey() []byte {
-	return m.immutable.GetMasterKey()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.current.GetMasterKey()
+}
+
+// GetEncryptionKeyID gets the ID of the current master key.
+func (m *Manager) GetEncryptionKeyID() byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.current.GetEncryptionKeyID()
+}
+
+// GetPreviousEncryptionKeys gets master keys replaced by key rotation.
+func (m *Manager) GetPreviousEncryptionKeys() []PreviousEncryptionKey {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.current.GetPreviousEncryptionKeys()
+}
+
+// GetKeyDerivationKey gets the key used to derive purpose-specific keys.
+func (m *Manager) GetKeyDerivationKey() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.current.GetKeyDerivationKey()
+}
+
+// GetDataKey gets the data key format, if any.
+func (m *Manager) GetDataKey() *DataKeyFormat {
+	return m.immutable.GetDataKey()
 }
 
 // SupportsPasswordChange returns true if the repository supports password change.
@@ -290,6 +334,18 @@ func (m *Manager) RequiredFeatures(ctx context.Context) ([]feature.Required, err
 	return m.repoConfig.RequiredFeatures, nil
 }
 
+// AutoTune returns the results of automatic parameter tuning performed when the repository was created, if any.
+func (m *Manager) AutoTune(ctx context.Context) (*AutoTuneResult, error) {
+	if err := m.maybeRefreshNotLocked(ctx); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.repoConfig.AutoTune, nil
+}
+
 // LoadedTime gets the time when the config was last reloaded.
 func (m *Manager) LoadedTime() time.Time {
 	m.mu.RLock()
@@ -358,6 +414,12 @@ func (m *Manager) ScrubbedContentFormat() ContentFormat {
 	cf := m.repoConfig.ContentFormat
 	cf.MasterKey = nil
 	cf.HMACSecret = nil
+	cf.PreviousEncryptionKeys = nil
+
+	for _, pk := range m.repoConfig.PreviousEncryptionKeys {
+		pk.MasterKey = nil
+		cf.PreviousEncryptionKeys = append(cf.PreviousEncryptionKeys, pk)
+	}
 
 	return cf
 }
@@ -412,6 +474,12 @@ func NewManagerWithCache(
 	return m, err
 }
 
+// WrapStorage wraps the storage used to read and write format blobs, for example to audit writes.
+// It must be called before the manager is used concurrently.
+func (m *Manager) WrapStorage(wrap func(st blob.Storage) blob.Storage) {
+	m.blobs = wrap(m.blobs)
+}
+
 // ErrAlreadyInitialized indicates that repository has already been initialized.
 var ErrAlreadyInitialized = errors.Errorf("repository already initialized")
 
@@ -62,6 +62,21 @@ type Provider interface {
 	SupportsPasswordChange() bool
 	GetMasterKey() []byte
 
+	// GetDataKey returns the data key format when data contents are encrypted using a key separate from metadata.
+	GetDataKey() *DataKeyFormat
+
+	// GetEncryptionKeyID returns the ID of the current master key.
+	GetEncryptionKeyID() byte
+
+	// GetPreviousEncryptionKeys returns master keys replaced by key rotation.
+	GetPreviousEncryptionKeys() []PreviousEncryptionKey
+
+	// GetKeyDerivationKey returns the key used to derive purpose-specific keys, which does not change on key rotation.
+	GetKeyDerivationKey() []byte
+
+	// EncryptorForKeyID returns the encryptor using the master key with the provided ID.
+	EncryptorForKeyID(keyID byte) (encryption.Encryptor, error)
+
 	RepositoryFormatBytes(ctx context.Context) ([]byte, error)
 }
 
@@ -71,6 +86,8 @@ type formattingOptionsProvider struct {
 	h           hashing.HashFunc
 	e           encryption.Encryptor
 	formatBytes []byte
+
+	encryptorsByKeyID map[byte]encryption.Encryptor
 }
 
 // NewFormattingOptionsProvider validates the provided formatting options and returns static
@@ -112,16 +129,9 @@ func NewFormattingOptionsProvider(f0 *ContentFormat, formatBytes []byte) (Provid
 		return nil, errors.Wrap(err, "unable to create encryptor")
 	}
 
-	if f.GetECCAlgorithm() != "" && f.GetECCOverheadPercent() > 0 {
-		eccEncryptor, err := ecc.CreateEncryptor(f) //nolint:govet
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to create ECC")
-		}
-
-		e = &encryptorWrapper{
-			impl: e,
-			next: eccEncryptor,
-		}
+	e, err = wrapWithECC(e, f)
+	if err != nil {
+		return nil, err
 	}
 
 	contentID := h(nil, gather.FromSlice(nil))
@@ -134,12 +144,29 @@ func NewFormattingOptionsProvider(f0 *ContentFormat, formatBytes []byte) (Provid
 		return nil, errors.Wrap(err, "invalid encryptor")
 	}
 
+	encryptorsByKeyID, err := createEncryptorsByKeyID(f, e)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(encryptorsByKeyID) > 1 {
+		kre := &keyRotationEncryptor{current: e}
+
+		for _, pk := range f.PreviousEncryptionKeys {
+			kre.previous = append(kre.previous, encryptorsByKeyID[pk.KeyID])
+		}
+
+		e = kre
+	}
+
 	return &formattingOptionsProvider{
 		ContentFormat: f,
 
 		h:           h,
 		e:           e,
 		formatBytes: formatBytes,
+
+		encryptorsByKeyID: encryptorsByKeyID,
 	}, nil
 }
 
@@ -147,6 +174,15 @@ func (f *formattingOptionsProvider) Encryptor() encryption.Encryptor {
 	return f.e
 }
 
+func (f *formattingOptionsProvider) EncryptorForKeyID(keyID byte) (encryption.Encryptor, error) {
+	e := f.encryptorsByKeyID[keyID]
+	if e == nil {
+		return nil, errors.Errorf("unknown encryption key ID: %v", keyID)
+	}
+
+	return e, nil
+}
+
 func (f *formattingOptionsProvider) HashFunc() hashing.HashFunc {
 	return f.h
 }
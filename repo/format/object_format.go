@@ -1,6 +1,10 @@
 package format
 
 // ObjectFormat describes the format of objects in a repository.
+//
+// Splitter names starting with "DYNAMIC-" use content-defined chunking based on a rolling hash,
+// which keeps cut points stable across small edits, while "FIXED-" splitters produce fixed-size blocks.
+// Repositories which don't specify a splitter use legacy fixed-size 4MB blocks.
 type ObjectFormat struct {
 	Splitter string `json:"splitter,omitempty"` // splitter used to break objects into pieces of content
 }
@@ -16,6 +16,7 @@ type RepositoryConfig struct {
 
 	UpgradeLock      *UpgradeLockIntent `json:"upgradeLock,omitempty"`
 	RequiredFeatures []feature.Required `json:"requiredFeatures,omitempty"`
+	AutoTune         *AutoTuneResult    `json:"autoTune,omitempty"`
 }
 
 // EncryptedRepositoryConfig contains the configuration of repository that's persisted in encrypted format.
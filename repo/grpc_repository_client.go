@@ -16,7 +16,6 @@
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
-	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/ctxutil"
 	"github.com/kopia/kopia/internal/gather"
 	apipb "github.com/kopia/kopia/internal/grpcapi"
@@ -468,10 +467,6 @@ func (r *grpcInnerSession) ApplyRetentionPolicy(ctx context.Context, sourcePath
 	return nil, errNoSessionResponse()
 }
 
-func (r *grpcRepositoryClient) Time() time.Time {
-	return clock.Now()
-}
-
 func (r *grpcRepositoryClient) Refresh(ctx context.Context) error {
 	return nil
 }
@@ -9,6 +9,7 @@
 
 	"github.com/pkg/errors"
 
+	"github.com/kopia/kopia/internal/feature"
 	"github.com/kopia/kopia/repo/blob"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/ecc"
@@ -32,15 +33,23 @@
 	masterKeyLength  = 32
 )
 
+// separateDataKeyFeature is required to open repositories which encrypt data contents using a separate data key.
+const separateDataKeyFeature feature.Feature = "separate-data-key"
+
 // NewRepositoryOptions specifies options that apply to newly created repositories.
 // All fields are optional, when not provided, reasonable defaults will be used.
 type NewRepositoryOptions struct {
-	UniqueID        []byte               `json:"uniqueID"` // force the use of particular unique ID
-	BlockFormat     format.ContentFormat `json:"blockFormat"`
-	DisableHMAC     bool                 `json:"disableHMAC"`
-	ObjectFormat    format.ObjectFormat  `json:"objectFormat"` // object format
-	RetentionMode   blob.RetentionMode   `json:"retentionMode,omitempty"`
-	RetentionPeriod time.Duration        `json:"retentionPeriod,omitempty"`
+	UniqueID        []byte                 `json:"uniqueID"` // force the use of particular unique ID
+	BlockFormat     format.ContentFormat   `json:"blockFormat"`
+	DisableHMAC     bool                   `json:"disableHMAC"`
+	ObjectFormat    format.ObjectFormat    `json:"objectFormat"` // object format
+	RetentionMode   blob.RetentionMode     `json:"retentionMode,omitempty"`
+	RetentionPeriod time.Duration          `json:"retentionPeriod,omitempty"`
+	AutoTune        *format.AutoTuneResult `json:"autoTune,omitempty"` // results of automatic parameter tuning
+
+	// DataPassword, when set, causes data contents to be encrypted using a separate key protected
+	// with this password, while metadata is encrypted using the repository master key.
+	DataPassword string `json:"-"`
 }
 
 // Initialize creates initial repository data structures in the specified storage with given credentials.
@@ -113,12 +122,28 @@ func repositoryObjectFormatFromOptions(opt *NewRepositoryOptions) (*format.Repos
 		ObjectFormat: format.ObjectFormat{
 			Splitter: applyDefaultString(opt.ObjectFormat.Splitter, splitter.DefaultAlgorithm),
 		},
+		AutoTune: opt.AutoTune,
 	}
 
 	if opt.DisableHMAC {
 		f.HMACSecret = nil
 	}
 
+	if opt.DataPassword != "" {
+		dk, err := format.NewDataKeyFormat(opt.DataPassword, format.DefaultKeyDerivationAlgorithm)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create data key")
+		}
+
+		f.DataKey = dk
+		f.RequiredFeatures = append(f.RequiredFeatures, feature.Required{
+			Feature: separateDataKeyFeature,
+			IfNotUnderstood: feature.IfNotUnderstood{
+				Message: "The repository encrypts data using a separate data key.",
+			},
+		})
+	}
+
 	if fv == format.FormatVersion1 || f.ContentFormat.ECCOverheadPercent == 0 {
 		f.ContentFormat.ECC = ""
 		f.ContentFormat.ECCOverheadPercent = 0
@@ -39,6 +39,16 @@ type ClientOptions struct {
 	FormatBlobCacheDuration time.Duration `json:"formatBlobCacheDuration,omitempty"`
 
 	Throttling *throttling.Limits `json:"throttlingLimits,omitempty"`
+
+	// BaseRepositoryConfigFile is the configuration file of the base repository whose contents
+	// are referenced instead of being uploaded, the base repository must use the same password.
+	BaseRepositoryConfigFile string `json:"baseRepositoryConfigFile,omitempty"`
+
+	// AuditLog enables recording of storage writes and deletions made by this client in the repository itself.
+	AuditLog bool `json:"auditLog,omitempty"`
+
+	// AsOf makes the connection a read-only view of the repository as it was at the provided time.
+	AsOf *time.Time `json:"asOf,omitempty"`
 }
 
 // ApplyDefaults returns a copy of ClientOptions with defaults filled out.
@@ -80,6 +90,10 @@ func (o ClientOptions) Override(other ClientOptions) ClientOptions {
 		o.ReadOnly = other.ReadOnly
 	}
 
+	if other.BaseRepositoryConfigFile != "" {
+		o.BaseRepositoryConfigFile = other.BaseRepositoryConfigFile
+	}
+
 	return o
 }
 
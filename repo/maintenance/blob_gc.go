@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/pkg/errors"
@@ -11,6 +12,7 @@
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/inventory"
 	"github.com/kopia/kopia/repo/content"
 )
 
@@ -20,6 +22,10 @@ type DeleteUnreferencedBlobsOptions struct {
 	Prefix       blob.ID
 	DryRun       bool
 	NotAfterTime time.Time
+
+	// Inventory, when set, is used to find blobs instead of listing the storage. Blobs written after the
+	// inventory was produced are not listed in it and won't be deleted.
+	Inventory *inventory.Inventory
 }
 
 // DeleteUnreferencedBlobs deletes o was created after maintenance startederenced by index entries.
@@ -60,6 +66,48 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 	// iterate unreferenced blobs and count them + optionally send to the channel to be deleted
 	log(ctx).Infof("Looking for unreferenced blobs...")
 
+	if err := FindUnreferencedBlobs(ctx, rep, opt, safety, func(bm blob.Metadata) error {
+		unreferenced.Add(bm.Length)
+
+		if !opt.DryRun {
+			unused <- bm
+		}
+
+		return nil
+	}); err != nil {
+		close(unused)
+
+		return 0, err
+	}
+
+	close(unused)
+
+	unreferencedCount, unreferencedSize := unreferenced.Approximate()
+	log(ctx).Debugf("Found %v blobs to delete (%v)", unreferencedCount, units.BytesString(unreferencedSize))
+
+	// wait for all delete workers to finish.
+	if err := eg.Wait(); err != nil {
+		return 0, errors.Wrap(err, "worker error")
+	}
+
+	if opt.DryRun {
+		return int(unreferencedCount), nil
+	}
+
+	del, cnt := deleted.Approximate()
+
+	log(ctx).Infof("Deleted total %v unreferenced blobs (%v)", del, units.BytesString(cnt))
+
+	return int(del), nil
+}
+
+// FindUnreferencedBlobs invokes the callback for each blob which is not referenced by index entries and
+// which DeleteUnreferencedBlobs would delete with the provided options.
+func FindUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteUnreferencedBlobsOptions, safety SafetyParameters, callback func(bm blob.Metadata) error) error {
+	if opt.Parallel == 0 {
+		opt.Parallel = 16
+	}
+
 	var prefixes []blob.ID
 	if p := opt.Prefix; p != "" {
 		prefixes = append(prefixes, p)
@@ -67,9 +115,18 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 		prefixes = append(prefixes, content.PackBlobIDPrefixRegular, content.PackBlobIDPrefixSpecial, content.BlobIDPrefixSession)
 	}
 
+	prefixes, err := withoutLinkedPackPrefixes(ctx, rep, prefixes)
+	if err != nil {
+		return err
+	}
+
+	if len(prefixes) == 0 {
+		return nil
+	}
+
 	activeSessions, err := rep.ContentManager().ListActiveSessions(ctx)
 	if err != nil {
-		return 0, errors.Wrap(err, "unable to load active sessions")
+		return errors.Wrap(err, "unable to load active sessions")
 	}
 
 	cutoffTime := opt.NotAfterTime
@@ -84,9 +141,16 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 
 	cutoffTime = cutoffTime.Add(cutoffTimeSlack)
 
+	listing := rep.BlobStorage()
+	if opt.Inventory != nil {
+		log(ctx).Infof("Using inventory of %v blobs instead of listing the storage.", opt.Inventory.Len())
+
+		listing = inventory.NewWrapper(listing, opt.Inventory)
+	}
+
 	// iterate all pack blobs + session blobs and keep ones that are too young or
 	// belong to alive sessions.
-	if err := rep.ContentManager().IterateUnreferencedBlobs(ctx, prefixes, opt.Parallel, func(bm blob.Metadata) error {
+	if err := rep.ContentManager().IterateUnreferencedBlobsIn(ctx, listing, prefixes, opt.Parallel, func(bm blob.Metadata) error {
 		if bm.Timestamp.After(cutoffTime) {
 			log(ctx).Debugf("  preserving %v because it was created after maintenance started", bm.BlobID)
 			return nil
@@ -105,34 +169,36 @@ func DeleteUnreferencedBlobs(ctx context.Context, rep repo.DirectRepositoryWrite
 			}
 		}
 
-		unreferenced.Add(bm.Length)
-
-		if !opt.DryRun {
-			unused <- bm
-		}
-
-		return nil
+		return callback(bm)
 	}); err != nil {
-		return 0, errors.Wrap(err, "error looking for unreferenced blobs")
+		return errors.Wrap(err, "error looking for unreferenced blobs")
 	}
 
-	close(unused)
-
-	unreferencedCount, unreferencedSize := unreferenced.Approximate()
-	log(ctx).Debugf("Found %v blobs to delete (%v)", unreferencedCount, units.BytesString(unreferencedSize))
+	return nil
+}
 
-	// wait for all delete workers to finish.
-	if err := eg.Wait(); err != nil {
-		return 0, errors.Wrap(err, "worker error")
+// withoutLinkedPackPrefixes removes pack blob prefixes from the provided list when other repositories are linked
+// to the repository, since their indexes may reference pack blobs no longer referenced by the repository itself.
+func withoutLinkedPackPrefixes(ctx context.Context, rep repo.DirectRepository, prefixes []blob.ID) ([]blob.ID, error) {
+	linked, err := repo.ListLinkedRepositories(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list linked repositories")
 	}
 
-	if opt.DryRun {
-		return int(unreferencedCount), nil
+	if len(linked) == 0 {
+		return prefixes, nil
 	}
 
-	del, cnt := deleted.Approximate()
+	var result []blob.ID
 
-	log(ctx).Infof("Deleted total %v unreferenced blobs (%v)", del, units.BytesString(cnt))
+	for _, p := range prefixes {
+		if slices.Contains(content.PackBlobIDPrefixes, blob.ID(p[0:1])) {
+			log(ctx).Infof("Preserving pack blobs with prefix %q because %v repositories are linked to this repository, see 'kopia repository linked unlink'.", p, len(linked))
+			continue
+		}
 
-	return int(del), nil
+		result = append(result, p)
+	}
+
+	return result, nil
 }
@@ -7,7 +7,6 @@
 
 	"github.com/pkg/errors"
 
-	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
@@ -44,7 +43,7 @@ func defaultLogRetention() LogRetentionOptions {
 // CleanupLogs deletes old logs blobs beyond certain age, total size or count.
 func CleanupLogs(ctx context.Context, rep repo.DirectRepositoryWriter, opt LogRetentionOptions) ([]blob.Metadata, error) {
 	if opt.TimeFunc == nil {
-		opt.TimeFunc = clock.Now
+		opt.TimeFunc = rep.Time
 	}
 
 	allLogBlobs, err := blob.ListAllBlobs(ctx, rep.BlobStorage(), "_")
@@ -0,0 +1,352 @@
+package maintenance
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/blobcrypto"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/repodiag"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+	"github.com/kopia/kopia/repo/format"
+)
+
+// reencryptContentsBatchBytes is the maximum amount of contents re-encrypted during each full maintenance
+// after the encryption key has been changed.
+const reencryptContentsBatchBytes = 10 << 30
+
+// ReencryptContentsOptions provides options for ReencryptContents.
+type ReencryptContentsOptions struct {
+	// MetadataOnly limits re-encryption to metadata contents (manifests, directories, etc.)
+	MetadataOnly bool
+
+	// MaxBytes limits the amount of contents re-encrypted in one run, 0 means unlimited.
+	MaxBytes int64
+
+	Parallel int
+}
+
+// reencryptedBlobPrefixes are prefixes of blobs other than index blobs which are encrypted using the master key
+// and are re-encrypted in place when previous keys are destroyed.
+var reencryptedBlobPrefixes = []blob.ID{repodiag.LogBlobPrefix, repodiag.AuditLogBlobPrefix}
+
+// EncryptionKeyUsage describes the number of contents, pack blobs, index blobs and log blobs encrypted using a master key.
+type EncryptionKeyUsage struct {
+	KeyID          byte       `json:"keyID"`
+	Current        bool       `json:"current"`
+	RotatedTime    *time.Time `json:"rotatedTime,omitempty"`
+	ContentCount   int        `json:"contentCount"`
+	ContentBytes   int64      `json:"contentBytes"`
+	PackBlobCount  int        `json:"packBlobCount"`
+	IndexBlobCount int        `json:"indexBlobCount"`
+	LogBlobCount   int        `json:"logBlobCount"` // text and audit logs
+
+	// Retired is true for previous keys which are no longer used to encrypt any contents, index or log blobs,
+	// which means they can be safely destroyed.
+	Retired bool `json:"retired"`
+}
+
+// ReencryptionProgress returns the percentage of content bytes encrypted using the current master key.
+func ReencryptionProgress(usage []EncryptionKeyUsage) float64 {
+	var current, total int64
+
+	for _, u := range usage {
+		total += u.ContentBytes
+
+		if u.Current {
+			current += u.ContentBytes
+		}
+	}
+
+	if total == 0 {
+		return 100 //nolint:gomnd
+	}
+
+	return 100 * float64(current) / float64(total) //nolint:gomnd
+}
+
+// ReencryptContents rewrites contents encrypted using master keys replaced by key rotation,
+// which encrypts them using the current master key.
+func ReencryptContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *ReencryptContentsOptions, safety SafetyParameters) error {
+	f := rep.ContentReader().ContentFormat()
+	if len(f.GetPreviousEncryptionKeys()) == 0 {
+		return nil
+	}
+
+	currentKeyID := f.GetEncryptionKeyID()
+
+	rng := index.AllIDs
+	if opt.MetadataOnly {
+		rng = index.AllPrefixedIDs
+	}
+
+	var (
+		contentIDs []content.ID
+		totalBytes int64
+	)
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{
+		Range:          rng,
+		IncludeDeleted: true,
+	}, func(ci content.Info) error {
+		// contents referenced from the base repository are not encrypted using keys of this repository.
+		if ci.GetEncryptionKeyID() == currentKeyID || ci.GetEncryptionKeyID() == content.BaseRepositoryEncryptionKeyID {
+			return nil
+		}
+
+		if opt.MaxBytes > 0 && totalBytes >= opt.MaxBytes {
+			return nil
+		}
+
+		contentIDs = append(contentIDs, ci.GetContentID())
+		totalBytes += int64(ci.GetPackedLength())
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error iterating contents")
+	}
+
+	if len(contentIDs) == 0 {
+		return nil
+	}
+
+	log(ctx).Infof("Re-encrypting %v contents (%v) using the current encryption key...", len(contentIDs), units.BytesString(totalBytes))
+
+	return RewriteContents(ctx, rep, &RewriteContentsOptions{
+		ContentIDs: contentIDs,
+		Parallel:   opt.Parallel,
+	}, safety)
+}
+
+// GetEncryptionKeyUsage returns the number of contents, pack blobs and index blobs encrypted using each of the
+// master keys, including previous keys which are no longer in use.
+func GetEncryptionKeyUsage(ctx context.Context, rep repo.DirectRepository) ([]EncryptionKeyUsage, error) {
+	f := rep.ContentReader().ContentFormat()
+	currentKeyID := f.GetEncryptionKeyID()
+
+	usage := map[byte]*EncryptionKeyUsage{
+		currentKeyID: {KeyID: currentKeyID, Current: true},
+	}
+
+	for _, pk := range f.GetPreviousEncryptionKeys() {
+		rotated := pk.RotatedTime
+		usage[pk.KeyID] = &EncryptionKeyUsage{KeyID: pk.KeyID, RotatedTime: &rotated}
+	}
+
+	// pack blobs may contain contents encrypted using different keys, they're counted once for each key.
+	packs := map[byte]map[blob.ID]struct{}{}
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{
+		IncludeDeleted: true,
+	}, func(ci content.Info) error {
+		if ci.GetEncryptionKeyID() == content.BaseRepositoryEncryptionKeyID {
+			return nil
+		}
+
+		u := usage[ci.GetEncryptionKeyID()]
+		if u == nil {
+			u = &EncryptionKeyUsage{KeyID: ci.GetEncryptionKeyID()}
+			usage[ci.GetEncryptionKeyID()] = u
+		}
+
+		u.ContentCount++
+		u.ContentBytes += int64(ci.GetPackedLength())
+
+		p := packs[u.KeyID]
+		if p == nil {
+			p = map[blob.ID]struct{}{}
+			packs[u.KeyID] = p
+		}
+
+		p[ci.GetPackBlobID()] = struct{}{}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	if len(usage) > 1 {
+		if err := visitBlobsWithPreviousKeys(ctx, rep, func(bm blob.Metadata, keyID byte, _ gather.Bytes) error {
+			if isIndexBlob(bm.BlobID) {
+				usage[keyID].IndexBlobCount++
+			} else {
+				usage[keyID].LogBlobCount++
+			}
+
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	var result []EncryptionKeyUsage
+
+	for _, u := range usage {
+		u.PackBlobCount = len(packs[u.KeyID])
+		u.Retired = !u.Current && u.ContentCount == 0 && u.IndexBlobCount == 0 && u.LogBlobCount == 0
+		result = append(result, *u)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].KeyID < result[j].KeyID
+	})
+
+	return result, nil
+}
+
+// DestroyPreviousEncryptionKeys removes master keys replaced by key rotation from the repository after
+// verifying that no contents are encrypted using them. Index and log blobs still encrypted using previous keys
+// are re-encrypted in place using the current key.
+func DestroyPreviousEncryptionKeys(ctx context.Context, rep repo.DirectRepositoryWriter) error {
+	f := rep.ContentReader().ContentFormat()
+
+	previousKeys := f.GetPreviousEncryptionKeys()
+	if len(previousKeys) == 0 {
+		return errors.New("repository does not have any previous encryption keys")
+	}
+
+	// other clients may not have noticed the key change yet and could still be writing using previous keys.
+	for _, pk := range previousKeys {
+		if safeTime := pk.RotatedTime.Add(format.DefaultRepositoryBlobCacheDuration + maxClockSkew); rep.Time().Before(safeTime) {
+			return errors.Errorf("encryption key was changed too recently, previous keys can be destroyed after %v", safeTime.Local())
+		}
+	}
+
+	if err := rep.ContentManager().Refresh(ctx); err != nil {
+		return errors.Wrap(err, "error refreshing indexes")
+	}
+
+	usage, err := GetEncryptionKeyUsage(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range usage {
+		if !u.Current && u.ContentCount > 0 {
+			return errors.Errorf("%v contents are still encrypted using previous keys, run full maintenance to re-encrypt them", u.ContentCount)
+		}
+	}
+
+	current, err := currentKeyCrypter(f)
+	if err != nil {
+		return err
+	}
+
+	if err := visitBlobsWithPreviousKeys(ctx, rep, func(bm blob.Metadata, _ byte, payload gather.Bytes) error {
+		var reencrypted gather.WriteBuffer
+		defer reencrypted.Close()
+
+		if err := blobcrypto.Reencrypt(f, current, payload, bm.BlobID, &reencrypted); err != nil {
+			return errors.Wrapf(err, "unable to re-encrypt blob %v", bm.BlobID)
+		}
+
+		log(ctx).Debugf("re-encrypting blob %v", bm.BlobID)
+
+		return errors.Wrapf(rep.BlobStorage().PutBlob(ctx, bm.BlobID, reencrypted.Bytes(), blob.PutOptions{}), "unable to write blob %v", bm.BlobID)
+	}); err != nil {
+		return err
+	}
+
+	return errors.Wrap(rep.FormatManager().DestroyPreviousEncryptionKeys(ctx), "unable to destroy previous encryption keys")
+}
+
+func currentKeyCrypter(f format.Provider) (blobcrypto.Crypter, error) {
+	enc, err := f.EncryptorForKeyID(f.GetEncryptionKeyID())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get current encryptor")
+	}
+
+	return blobcrypto.StaticCrypter{Hash: f.HashFunc(), Encryption: enc}, nil
+}
+
+// visitBlobsWithPreviousKeys invokes the callback for each active index blob and each log blob which can't be
+// decrypted using the current master key with the ID of the key used to encrypt it and its encrypted payload.
+func visitBlobsWithPreviousKeys(ctx context.Context, rep repo.DirectRepository, cb func(bm blob.Metadata, keyID byte, payload gather.Bytes) error) error {
+	f := rep.ContentReader().ContentFormat()
+
+	current, err := currentKeyCrypter(f)
+	if err != nil {
+		return err
+	}
+
+	indexBlobs, err := rep.IndexBlobs(ctx, false)
+	if err != nil {
+		return errors.Wrap(err, "error listing index blobs")
+	}
+
+	blobs := make([]blob.Metadata, 0, len(indexBlobs))
+
+	for _, ib := range indexBlobs {
+		blobs = append(blobs, ib.Metadata)
+	}
+
+	for _, prefix := range reencryptedBlobPrefixes {
+		if err := rep.BlobReader().ListBlobs(ctx, prefix, func(bm blob.Metadata) error {
+			blobs = append(blobs, bm)
+			return nil
+		}); err != nil {
+			return errors.Wrapf(err, "error listing %v blobs", prefix)
+		}
+	}
+
+	var payload, tmp gather.WriteBuffer
+	defer payload.Close()
+	defer tmp.Close()
+
+	for _, bm := range blobs {
+		if err := rep.BlobReader().GetBlob(ctx, bm.BlobID, 0, -1, &payload); err != nil {
+			return errors.Wrapf(err, "error reading blob %v", bm.BlobID)
+		}
+
+		if blobcrypto.Decrypt(current, payload.Bytes(), bm.BlobID, &tmp) == nil {
+			continue
+		}
+
+		keyID, err := previousKeyForBlob(f, payload.Bytes(), bm.BlobID)
+		if err != nil {
+			return err
+		}
+
+		if err := cb(bm, keyID, payload.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isIndexBlob(blobID blob.ID) bool {
+	for _, prefix := range reencryptedBlobPrefixes {
+		if strings.HasPrefix(string(blobID), string(prefix)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func previousKeyForBlob(f format.Provider, payload gather.Bytes, blobID blob.ID) (byte, error) {
+	var tmp gather.WriteBuffer
+	defer tmp.Close()
+
+	for _, pk := range f.GetPreviousEncryptionKeys() {
+		enc, err := f.EncryptorForKeyID(pk.KeyID)
+		if err != nil {
+			return 0, errors.Wrap(err, "unable to get encryptor")
+		}
+
+		if blobcrypto.Decrypt(blobcrypto.StaticCrypter{Hash: f.HashFunc(), Encryption: enc}, payload, blobID, &tmp) == nil {
+			return pk.KeyID, nil
+		}
+	}
+
+	return 0, errors.Errorf("unable to decrypt blob %v using any encryption key", blobID)
+}
@@ -0,0 +1,227 @@
+package maintenance_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/blobcrypto"
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/internal/repodiag"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func (s *formatSpecificTestSuite) TestChangeEncryptionKey(t *testing.T) {
+	ta := faketime.NewClockTimeWithOffset(0)
+
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ta.NowFunc()
+		},
+	})
+
+	written := map[object.ID]string{}
+
+	writeObjects := func(prefix content.IDPrefix) {
+		for i := 0; i < 3; i++ {
+			data := fmt.Sprintf("%v-%v-%v", prefix, i, ta.NowFunc()())
+
+			require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.RepositoryWriter) error {
+				ow := w.NewObjectWriter(ctx, object.WriterOptions{Prefix: prefix})
+				fmt.Fprint(ow, data)
+
+				oid, err := ow.Result()
+				written[oid] = data
+
+				return err
+			}))
+		}
+	}
+
+	writeObjects("")
+	writeObjects("k")
+
+	err := repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		return repo.ChangeEncryptionKey(ctx, w)
+	})
+
+	if s.formatVersion == format.FormatVersion1 {
+		require.ErrorContains(t, err, "requires repository format version 2 or newer")
+		return
+	}
+
+	require.NoError(t, err)
+
+	writeObjects("")
+
+	usage := getEncryptionKeyUsage(ctx, t, env)
+	require.Len(t, usage, 2)
+	require.Equal(t, 6, usage[0].ContentCount)
+	require.Equal(t, 3, usage[1].ContentCount)
+	require.True(t, usage[1].Current)
+	require.NotZero(t, usage[0].IndexBlobCount)
+	require.NotZero(t, usage[0].PackBlobCount)
+	require.NotNil(t, usage[0].RotatedTime)
+	require.Nil(t, usage[1].RotatedTime)
+	require.False(t, usage[0].Retired)
+	require.Less(t, maintenance.ReencryptionProgress(usage), 50.0)
+
+	// metadata contents are re-encrypted first.
+	require.NoError(t, repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		return maintenance.ReencryptContents(ctx, w, &maintenance.ReencryptContentsOptions{MetadataOnly: true}, maintenance.SafetyNone)
+	}))
+
+	usage = getEncryptionKeyUsage(ctx, t, env)
+	require.Equal(t, 3, usage[0].ContentCount)
+	require.Equal(t, 6, usage[1].ContentCount)
+
+	destroyKeys := func() error {
+		return repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+			return maintenance.DestroyPreviousEncryptionKeys(ctx, w)
+		})
+	}
+
+	require.ErrorContains(t, destroyKeys(), "encryption key was changed too recently")
+
+	ta.Advance(time.Hour)
+
+	require.ErrorContains(t, destroyKeys(), "3 contents are still encrypted using previous keys")
+
+	require.NoError(t, repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		return maintenance.ReencryptContents(ctx, w, &maintenance.ReencryptContentsOptions{}, maintenance.SafetyNone)
+	}))
+
+	usage = getEncryptionKeyUsage(ctx, t, env)
+	require.Zero(t, usage[0].ContentCount)
+	require.Zero(t, usage[0].PackBlobCount)
+	require.InDelta(t, 100.0, maintenance.ReencryptionProgress(usage), 0.001)
+
+	require.NoError(t, destroyKeys())
+	require.Empty(t, env.RepositoryWriter.ContentReader().ContentFormat().GetPreviousEncryptionKeys())
+
+	env.MustReopen(t, func(o *repo.Options) {
+		o.TimeNowFunc = ta.NowFunc()
+	})
+
+	usage = getEncryptionKeyUsage(ctx, t, env)
+	require.Len(t, usage, 1)
+	require.Equal(t, 9, usage[0].ContentCount)
+	require.Zero(t, usage[0].IndexBlobCount)
+
+	for oid, want := range written {
+		r, err := env.Repository.OpenObject(ctx, oid)
+		require.NoError(t, err)
+
+		b := make([]byte, len(want))
+		_, err = r.Read(b)
+		require.NoError(t, err)
+		require.Equal(t, want, string(b))
+		r.Close()
+	}
+}
+
+func (s *formatSpecificTestSuite) TestChangeEncryptionKeyThenMaintenance(t *testing.T) {
+	if s.formatVersion == format.FormatVersion1 {
+		t.Skip("changing encryption key requires format version 2 or newer")
+	}
+
+	ta := faketime.NewClockTimeWithOffset(0)
+
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ta.NowFunc()
+		},
+	})
+
+	runFullMaintenance := func() {
+		t.Helper()
+
+		require.NoError(t, maintenance.RunExclusive(ctx, env.RepositoryWriter, maintenance.ModeFull, true, func(ctx context.Context, runParams maintenance.RunParameters) error {
+			return maintenance.Run(ctx, runParams, maintenance.SafetyNone)
+		}))
+	}
+
+	require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.RepositoryWriter) error {
+		ow := w.NewObjectWriter(ctx, object.WriterOptions{})
+		fmt.Fprint(ow, "some data")
+
+		_, err := ow.Result()
+
+		return err
+	}))
+
+	// maintenance schedule and log blobs are written using the original key.
+	runFullMaintenance()
+
+	var encrypted gather.WriteBuffer
+	defer encrypted.Close()
+
+	logBlobID, err := blobcrypto.Encrypt(env.RepositoryWriter.ContentReader().ContentFormat(), gather.FromSlice([]byte("log data")), repodiag.LogBlobPrefix+"20260101000000_1234", "", &encrypted)
+	require.NoError(t, err)
+	require.NoError(t, env.RepositoryWriter.BlobStorage().PutBlob(ctx, logBlobID, encrypted.Bytes(), blob.PutOptions{}))
+
+	derivedKey := env.RepositoryWriter.DeriveKey([]byte("test"), 32)
+
+	require.NoError(t, repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		return repo.ChangeEncryptionKey(ctx, w)
+	}))
+
+	env.MustReopen(t, func(o *repo.Options) {
+		o.TimeNowFunc = ta.NowFunc()
+	})
+
+	// keys derived for maintenance schedule, root references and verification reports don't change.
+	require.Equal(t, derivedKey, env.RepositoryWriter.DeriveKey([]byte("test"), 32))
+
+	_, err = maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+
+	ta.Advance(time.Hour)
+	runFullMaintenance()
+
+	usage := getEncryptionKeyUsage(ctx, t, env)
+	require.Len(t, usage, 2)
+	require.Zero(t, usage[0].ContentCount)
+	require.Equal(t, 1, usage[0].LogBlobCount)
+	require.False(t, usage[0].Retired)
+
+	require.NoError(t, repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		return maintenance.DestroyPreviousEncryptionKeys(ctx, w)
+	}))
+
+	env.MustReopen(t, func(o *repo.Options) {
+		o.TimeNowFunc = ta.NowFunc()
+	})
+
+	_, err = maintenance.GetSchedule(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+
+	runFullMaintenance()
+
+	var payload, decrypted gather.WriteBuffer
+	defer payload.Close()
+	defer decrypted.Close()
+
+	require.NoError(t, env.RepositoryWriter.BlobReader().GetBlob(ctx, logBlobID, 0, -1, &payload))
+	require.NoError(t, blobcrypto.Decrypt(env.RepositoryWriter.ContentReader().ContentFormat(), payload.Bytes(), logBlobID, &decrypted))
+	require.Equal(t, "log data", string(decrypted.ToByteSlice()))
+}
+
+func getEncryptionKeyUsage(ctx context.Context, t *testing.T, env *repotesting.Environment) []maintenance.EncryptionKeyUsage {
+	t.Helper()
+
+	usage, err := maintenance.GetEncryptionKeyUsage(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+
+	return usage
+}
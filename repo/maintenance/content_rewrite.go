@@ -43,6 +43,17 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 	}
 
 	if opt.ShortPacks {
+		// rewritten packs would never be deleted, see withoutLinkedPackPrefixes().
+		linked, err := repo.ListLinkedRepositories(ctx, rep)
+		if err != nil {
+			return errors.Wrap(err, "unable to list linked repositories")
+		}
+
+		if len(linked) > 0 {
+			log(ctx).Infof("Not rewriting contents from short packs because %v repositories are linked to this repository.", len(linked))
+			return nil
+		}
+
 		log(ctx).Infof("Rewriting contents from short packs...")
 	} else {
 		log(ctx).Infof("Rewriting contents...")
@@ -125,6 +136,94 @@ func RewriteContents(ctx context.Context, rep repo.DirectRepositoryWriter, opt *
 	return errors.Errorf("failed to rewrite %v contents", failedCount)
 }
 
+// FindContentsToRewrite invokes the callback for each content which RewriteContents would rewrite
+// with the provided options.
+func FindContentsToRewrite(ctx context.Context, rep repo.DirectRepository, opt *RewriteContentsOptions, safety SafetyParameters, callback func(ci content.Info) error) error {
+	if opt == nil {
+		return errors.Errorf("missing options")
+	}
+
+	if opt.ShortPacks {
+		// RewriteContents does not rewrite anything when other repositories are linked.
+		linked, err := repo.ListLinkedRepositories(ctx, rep)
+		if err != nil {
+			return errors.Wrap(err, "unable to list linked repositories")
+		}
+
+		if len(linked) > 0 {
+			return nil
+		}
+	}
+
+	var (
+		firstErr error
+		seen     = map[content.ID]bool{}
+	)
+
+	// keep consuming contents until the channel is closed, so that the producer does not block.
+	for c := range getContentToRewrite(ctx, rep, opt) {
+		if firstErr != nil {
+			continue
+		}
+
+		if c.err != nil {
+			firstErr = c.err
+			continue
+		}
+
+		if seen[c.GetContentID()] || rep.Time().Sub(c.Timestamp()) < safety.RewriteMinAge {
+			continue
+		}
+
+		seen[c.GetContentID()] = true
+
+		firstErr = callback(c.Info)
+	}
+
+	return firstErr
+}
+
+// FindPacksVacatedByRewrite invokes the callback for each pack blob which is no longer referenced by any index entries
+// once the provided contents are rewritten, because all contents in the pack, including deleted ones, are among them.
+func FindPacksVacatedByRewrite(ctx context.Context, rep repo.DirectRepository, rewritten []content.Info, callback func(bm blob.Metadata) error) error {
+	rewrittenPerPack := map[blob.ID]int{}
+
+	for _, ci := range rewritten {
+		rewrittenPerPack[ci.GetPackBlobID()]++
+	}
+
+	if len(rewrittenPerPack) == 0 {
+		return nil
+	}
+
+	var vacated []blob.ID
+
+	if err := rep.ContentReader().IteratePacks(ctx, content.IteratePackOptions{
+		IncludePacksWithOnlyDeletedContent: true,
+	}, func(pi content.PackInfo) error {
+		if rewrittenPerPack[pi.PackID] == pi.ContentCount {
+			vacated = append(vacated, pi.PackID)
+		}
+
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "error iterating packs")
+	}
+
+	for _, packID := range vacated {
+		bm, err := rep.BlobReader().GetMetadata(ctx, packID)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get metadata of pack %v", packID)
+		}
+
+		if err := callback(bm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func getContentToRewrite(ctx context.Context, rep repo.DirectRepository, opt *RewriteContentsOptions) <-chan contentInfoOrError {
 	ch := make(chan contentInfoOrError)
 
@@ -202,6 +301,11 @@ func(pi content.PackInfo) error {
 				return nil
 			}
 
+			// packs of the linked base repository are referenced in place and never rewritten.
+			if len(pi.ContentInfos) > 0 && pi.ContentInfos[0].GetEncryptionKeyID() == content.BaseRepositoryEncryptionKeyID {
+				return nil
+			}
+
 			prefix := pi.PackID[0:1]
 
 			packNumberByPrefix[prefix]++
@@ -6,13 +6,13 @@
 	"github.com/kopia/kopia/repo/content/indexblob"
 )
 
+const maxSmallBlobsForIndexCompaction = 8
+
 // runTaskIndexCompactionQuick rewrites index blobs to reduce their count but does not drop any contents.
 func runTaskIndexCompactionQuick(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
 	return ReportRun(ctx, runParams.rep, TaskIndexCompaction, s, func() error {
 		log(ctx).Infof("Compacting indexes...")
 
-		const maxSmallBlobsForIndexCompaction = 8
-
 		//nolint:wrapcheck
 		return runParams.rep.ContentManager().CompactIndexes(ctx, indexblob.CompactOptions{
 			MaxSmallBlobs:                    maxSmallBlobsForIndexCompaction,
@@ -0,0 +1,378 @@
+package maintenance
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/content/index"
+	"github.com/kopia/kopia/repo/content/indexblob"
+)
+
+// DryRunTask describes the impact a single maintenance task would have, as reported by DryRun.
+type DryRunTask struct {
+	Task         TaskType `json:"task"`
+	Skipped      string   `json:"skipped,omitempty"`
+	Contents     int      `json:"contents"`
+	ContentBytes int64    `json:"contentBytes"`
+	Blobs        int      `json:"blobs"`
+	BlobBytes    int64    `json:"blobBytes"`
+}
+
+func (t *DryRunTask) addContent(ci content.Info) {
+	t.Contents++
+	t.ContentBytes += int64(ci.GetPackedLength())
+}
+
+func (t *DryRunTask) addBlob(bm blob.Metadata) {
+	t.Blobs++
+	t.BlobBytes += bm.Length
+}
+
+// dryRun holds the state of maintenance dry run, the schedule is never persisted
+// and only records simulated runs of tasks, so that following tasks make the same decisions
+// they would make during actual maintenance.
+type dryRun struct {
+	rep    repo.DirectRepositoryWriter
+	params *Params
+	s      *Schedule
+	safety SafetyParameters
+	tasks  []*DryRunTask
+
+	// packs which are no longer referenced once contents are rewritten by this run.
+	vacatedPacks []blob.Metadata
+}
+
+func (d *dryRun) skip(task TaskType, reason string) {
+	d.tasks = append(d.tasks, &DryRunTask{Task: task, Skipped: reason})
+}
+
+func (d *dryRun) run(task TaskType, find func(t *DryRunTask) error) error {
+	t := &DryRunTask{Task: task}
+
+	if err := find(t); err != nil {
+		return err
+	}
+
+	d.tasks = append(d.tasks, t)
+
+	now := d.rep.Time()
+	d.s.ReportRun(task, RunInfo{Start: now, End: now, Success: true})
+
+	return nil
+}
+
+// DryRun returns the impact of each task of maintenance in the provided mode if it ran now, without changing
+// anything in the repository. Snapshot GC is performed by the caller before full maintenance, so its impact
+// is reported by the provided snapshotGC function, which is only invoked in full mode.
+//
+// Tasks take into account the effects of tasks which run before them, such as packs orphaned by content rewrite,
+// except for contents marked as deleted or undeleted by snapshot GC, which are not reflected in the number of
+// contents dropped from the index.
+func DryRun(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mode, safety SafetyParameters, snapshotGC func(ctx context.Context, t *DryRunTask) error) ([]*DryRunTask, error) {
+	p, err := GetParams(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get maintenance params")
+	}
+
+	s, err := GetSchedule(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get schedule")
+	}
+
+	d := &dryRun{rep: rep, params: p, s: s, safety: safety}
+
+	switch mode {
+	case ModeQuick:
+		err = d.quickMaintenance(ctx)
+
+	case ModeFull:
+		err = d.fullMaintenance(ctx, snapshotGC)
+
+	default:
+		return nil, errors.Errorf("unsupported mode %q", mode)
+	}
+
+	return d.tasks, err
+}
+
+func (d *dryRun) quickMaintenance(ctx context.Context) error {
+	if shouldDeleteTemporaryFiles(d.rep.Time(), d.s, d.safety) {
+		if err := d.deleteTemporaryFiles(ctx); err != nil {
+			return err
+		}
+	} else {
+		d.skip(TaskDeleteTemporaryFiles, "temporary files were looked for recently")
+	}
+
+	_, ok, emerr := d.rep.ContentManager().EpochManager(ctx)
+	if ok {
+		return nil
+	}
+
+	if emerr != nil {
+		return errors.Wrap(emerr, "epoch manager")
+	}
+
+	if shouldQuickRewriteContents(d.s, d.safety) {
+		if err := d.rewriteContents(ctx, TaskRewriteContentsQuick, &RewriteContentsOptions{
+			ContentIDRange: index.AllPrefixedIDs,
+			PackPrefix:     content.PackBlobIDPrefixSpecial,
+			ShortPacks:     true,
+		}); err != nil {
+			return err
+		}
+	} else {
+		d.skip(TaskRewriteContentsQuick, "previous content rewrite has not been finalized yet")
+	}
+
+	if shouldDeleteOrphanedPacks(d.rep.Time(), d.s, d.safety) {
+		var err error
+
+		if hadRecentFullRewrite(d.s) {
+			err = d.deleteOrphanedBlobs(ctx, TaskDeleteOrphanedBlobsFull, "")
+		} else {
+			err = d.deleteOrphanedBlobs(ctx, TaskDeleteOrphanedBlobsQuick, content.PackBlobIDPrefixSpecial)
+		}
+
+		if err != nil {
+			return err
+		}
+	} else {
+		d.skip(TaskDeleteOrphanedBlobsQuick, "not enough time has passed since previous content rewrite")
+	}
+
+	if err := d.compactIndexes(ctx); err != nil {
+		return err
+	}
+
+	return d.cleanupLogs(ctx)
+}
+
+func (d *dryRun) fullMaintenance(ctx context.Context, snapshotGC func(ctx context.Context, t *DryRunTask) error) error {
+	if snapshotGC != nil {
+		if err := d.run(TaskSnapshotGarbageCollection, func(t *DryRunTask) error {
+			return snapshotGC(ctx, t)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if shouldFullRewriteContents(d.s, d.safety) {
+		if err := d.rewriteContents(ctx, TaskRewriteContentsFull, &RewriteContentsOptions{
+			ContentIDRange: index.AllIDs,
+			ShortPacks:     true,
+		}); err != nil {
+			return err
+		}
+	} else {
+		d.skip(TaskRewriteContentsFull, "previous content rewrite has not been finalized yet")
+	}
+
+	if err := d.dropDeletedContents(ctx); err != nil {
+		return err
+	}
+
+	if shouldDeleteOrphanedPacks(d.rep.Time(), d.s, d.safety) {
+		if err := d.deleteOrphanedBlobs(ctx, TaskDeleteOrphanedBlobsFull, ""); err != nil {
+			return err
+		}
+	} else {
+		d.skip(TaskDeleteOrphanedBlobsFull, "not enough time has passed since previous content rewrite")
+	}
+
+	if d.params.ExtendObjectLocks {
+		if err := d.extendBlobRetentionTime(ctx); err != nil {
+			return err
+		}
+	} else {
+		d.skip(TaskExtendBlobRetentionTimeFull, "extending object lock retention-period is disabled")
+	}
+
+	if err := d.cleanupEpochManager(ctx); err != nil {
+		return err
+	}
+
+	if err := d.deleteTemporaryFiles(ctx); err != nil {
+		return err
+	}
+
+	return d.cleanupLogs(ctx)
+}
+
+func (d *dryRun) extendBlobRetentionTime(ctx context.Context) error {
+	return d.run(TaskExtendBlobRetentionTimeFull, func(t *DryRunTask) error {
+		cnt, err := ExtendBlobRetentionTime(ctx, d.rep, ExtendBlobRetentionTimeOptions{DryRun: true})
+		t.Blobs = cnt
+
+		return err
+	})
+}
+
+func (d *dryRun) cleanupEpochManager(ctx context.Context) error {
+	em, ok, emerr := d.rep.ContentManager().EpochManager(ctx)
+	if emerr != nil {
+		return errors.Wrap(emerr, "epoch manager")
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return d.run(TaskCleanupEpochManager, func(t *DryRunTask) error {
+		blobs, err := em.SupersededIndexBlobs(ctx)
+		if err != nil {
+			return errors.Wrap(err, "error finding superseded index blobs")
+		}
+
+		for _, bm := range blobs {
+			t.addBlob(bm)
+		}
+
+		return nil
+	})
+}
+
+func (d *dryRun) deleteTemporaryFiles(ctx context.Context) error {
+	return d.run(TaskDeleteTemporaryFiles, func(t *DryRunTask) error {
+		st, err := DeleteStaleTemporaryFiles(ctx, d.rep, DeleteTemporaryFilesOptions{DryRun: true}, d.safety)
+		if err != nil {
+			return err
+		}
+
+		t.Blobs = st.Found
+		t.BlobBytes = st.FoundBytes
+
+		return nil
+	})
+}
+
+func (d *dryRun) rewriteContents(ctx context.Context, task TaskType, opt *RewriteContentsOptions) error {
+	return d.run(task, func(t *DryRunTask) error {
+		var rewritten []content.Info
+
+		if err := FindContentsToRewrite(ctx, d.rep, opt, d.safety, func(ci content.Info) error {
+			t.addContent(ci)
+			rewritten = append(rewritten, ci)
+
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "error finding contents to rewrite")
+		}
+
+		// vacated packs are deleted by orphaned blob deletion, which reports them.
+		return FindPacksVacatedByRewrite(ctx, d.rep, rewritten, func(bm blob.Metadata) error {
+			d.vacatedPacks = append(d.vacatedPacks, bm)
+			return nil
+		})
+	})
+}
+
+func (d *dryRun) deleteOrphanedBlobs(ctx context.Context, task TaskType, prefix blob.ID) error {
+	return d.run(task, func(t *DryRunTask) error {
+		opt := DeleteUnreferencedBlobsOptions{Prefix: prefix}
+
+		if err := FindUnreferencedBlobs(ctx, d.rep, opt, d.safety, func(bm blob.Metadata) error {
+			t.addBlob(bm)
+			return nil
+		}); err != nil {
+			return errors.Wrap(err, "error finding unreferenced blobs")
+		}
+
+		now := d.rep.Time()
+
+		for _, bm := range d.vacatedPacks {
+			if strings.HasPrefix(string(bm.BlobID), string(prefix)) && now.Sub(bm.Timestamp) >= d.safety.BlobDeleteMinAge {
+				t.addBlob(bm)
+			}
+		}
+
+		d.vacatedPacks = nil
+
+		return nil
+	})
+}
+
+func (d *dryRun) compactIndexes(ctx context.Context) error {
+	return d.run(TaskIndexCompaction, func(t *DryRunTask) error {
+		indexBlobs, err := d.rep.ContentManager().IndexBlobsToCompact(ctx, indexblob.CompactOptions{
+			MaxSmallBlobs: maxSmallBlobsForIndexCompaction,
+		})
+		if err != nil {
+			return errors.Wrap(err, "error finding index blobs to compact")
+		}
+
+		for _, ib := range indexBlobs {
+			t.addBlob(ib.Metadata)
+		}
+
+		return nil
+	})
+}
+
+func (d *dryRun) dropDeletedContents(ctx context.Context) error {
+	safeDropTime := d.rep.Time()
+	if d.safety.RequireTwoGCCycles {
+		safeDropTime = findSafeDropTime(d.s.Runs[TaskSnapshotGarbageCollection], d.safety)
+	}
+
+	if safeDropTime.IsZero() {
+		d.skip(TaskDropDeletedContentsFull, "not enough time has passed since previous successful snapshot GC")
+		return nil
+	}
+
+	return d.run(TaskDropDeletedContentsFull, func(t *DryRunTask) error {
+		indexBlobs, err := d.rep.ContentManager().IndexBlobsToCompact(ctx, indexblob.CompactOptions{
+			AllIndexes:        true,
+			DropDeletedBefore: safeDropTime,
+		})
+		if err != nil {
+			return errors.Wrap(err, "error finding index blobs to compact")
+		}
+
+		for _, ib := range indexBlobs {
+			t.addBlob(ib.Metadata)
+		}
+
+		return errors.Wrap(findContentsDeletedBefore(ctx, d.rep, safeDropTime, func(ci content.Info) error {
+			t.addContent(ci)
+			return nil
+		}), "error finding deleted contents")
+	})
+}
+
+func (d *dryRun) cleanupLogs(ctx context.Context) error {
+	return d.run(TaskCleanupLogs, func(t *DryRunTask) error {
+		opt := d.params.LogRetention.OrDefault()
+		opt.DryRun = true
+
+		logs, err := CleanupLogs(ctx, d.rep, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, bm := range logs {
+			t.addBlob(bm)
+		}
+
+		return nil
+	})
+}
+
+// findContentsDeletedBefore invokes the callback for each content which is dropped from the index
+// when compacting indexes with the provided DropDeletedBefore time.
+func findContentsDeletedBefore(ctx context.Context, rep repo.DirectRepository, dropDeletedBefore time.Time, callback func(ci content.Info) error) error {
+	//nolint:wrapcheck
+	return rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		if !ci.GetDeleted() || !ci.Timestamp().Before(dropDeletedBefore) {
+			return nil
+		}
+
+		return callback(ci)
+	})
+}
@@ -0,0 +1,86 @@
+package maintenance_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/object"
+)
+
+func (s *formatSpecificTestSuite) TestDryRun(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, s.formatVersion)
+
+	// create several short packs for each prefix.
+	for i := 0; i < 3; i++ {
+		for _, prefix := range []content.IDPrefix{"", "k"} {
+			require.NoError(t, repo.WriteSession(ctx, env.Repository, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.RepositoryWriter) error {
+				ow := w.NewObjectWriter(ctx, object.WriterOptions{Prefix: prefix})
+				fmt.Fprintf(ow, "%v", uuid.NewString())
+				_, err := ow.Result()
+				return err
+			}))
+		}
+	}
+
+	packsBefore := map[blob.ID]bool{}
+
+	require.NoError(t, env.RepositoryWriter.BlobStorage().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		packsBefore[bm.BlobID] = true
+		return nil
+	}))
+
+	var tasks []*maintenance.DryRunTask
+
+	require.NoError(t, repo.DirectWriteSession(ctx, env.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, w repo.DirectRepositoryWriter) error {
+		var err error
+
+		tasks, err = maintenance.DryRun(ctx, w, maintenance.ModeFull, maintenance.SafetyNone, nil)
+
+		return err
+	}))
+
+	byTask := map[maintenance.TaskType]*maintenance.DryRunTask{}
+	for _, tsk := range tasks {
+		byTask[tsk.Task] = tsk
+	}
+
+	require.NotZero(t, byTask[maintenance.TaskRewriteContentsFull].Contents)
+	require.Zero(t, byTask[maintenance.TaskRewriteContentsFull].Blobs)
+	require.NotZero(t, byTask[maintenance.TaskDeleteOrphanedBlobsFull].Blobs)
+
+	// dry run does not change anything.
+	require.NoError(t, env.RepositoryWriter.BlobStorage().ListBlobs(ctx, "", func(bm blob.Metadata) error {
+		require.True(t, packsBefore[bm.BlobID], "unexpected blob %v", bm.BlobID)
+		return nil
+	}))
+
+	require.NoError(t, maintenance.RunExclusive(ctx, env.RepositoryWriter, maintenance.ModeFull, true, func(ctx context.Context, runParams maintenance.RunParameters) error {
+		return maintenance.Run(ctx, runParams, maintenance.SafetyNone)
+	}))
+
+	// packs deleted by maintenance are the ones reported by the dry run, including packs orphaned by the rewrite.
+	orphanPrefixes := append([]blob.ID{content.BlobIDPrefixSession}, content.PackBlobIDPrefixes...)
+	deletedPacks := 0
+
+	for blobID := range packsBefore {
+		if !slices.Contains(orphanPrefixes, blobID[0:1]) {
+			continue
+		}
+
+		if _, err := env.RepositoryWriter.BlobStorage().GetMetadata(ctx, blobID); err != nil {
+			deletedPacks++
+		}
+	}
+
+	require.Equal(t, byTask[maintenance.TaskDeleteOrphanedBlobsFull].Blobs, deletedPacks)
+}
@@ -19,8 +19,9 @@
 type Params struct {
 	Owner string `json:"owner"`
 
-	QuickCycle CycleParams `json:"quick"`
-	FullCycle  CycleParams `json:"full"`
+	QuickCycle  CycleParams       `json:"quick"`
+	FullCycle   CycleParams       `json:"full"`
+	VerifyCycle VerifyCycleParams `json:"verify"`
 
 	LogRetention LogRetentionOptions `json:"logRetention"`
 
@@ -43,6 +44,12 @@ func DefaultParams() Params {
 			Enabled:  true,
 			Interval: 1 * time.Hour,
 		},
+		VerifyCycle: VerifyCycleParams{
+			CycleParams: CycleParams{
+				Enabled:  false,
+				Interval: DefaultVerifyInterval,
+			},
+		},
 		LogRetention: defaultLogRetention(),
 		// Don't attempt to extend object locks by default. This option may not be
 		// supported by all storage providers or blob implementations (currently
@@ -58,6 +65,18 @@ type CycleParams struct {
 	Interval time.Duration `json:"interval"`
 }
 
+// DefaultVerifyInterval is the default interval between scheduled verifications of snapshots.
+const DefaultVerifyInterval = 7 * 24 * time.Hour
+
+// VerifyCycleParams specifies parameters for scheduled verification of snapshots, which is performed
+// as part of maintenance when due.
+type VerifyCycleParams struct {
+	CycleParams
+
+	// percentage of files which are downloaded and verified in full
+	FilesPercent float64 `json:"filesPercent,omitempty"`
+}
+
 // HasParams determines whether repository-wide maintenance parameters have been set.
 func HasParams(ctx context.Context, rep repo.Repository) (bool, error) {
 	md, err := manifestIDs(ctx, rep)
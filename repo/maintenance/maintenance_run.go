@@ -9,7 +9,6 @@
 	"github.com/gofrs/flock"
 	"github.com/pkg/errors"
 
-	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/content"
 	"github.com/kopia/kopia/repo/content/index"
@@ -29,6 +28,9 @@
 	ModeQuick Mode = "quick"
 	ModeFull  Mode = "full"
 	ModeAuto  Mode = "auto" // run either quick of full if required by schedule
+
+	// ModeVerify only performs scheduled verification of snapshots, without any other maintenance.
+	ModeVerify Mode = "verify"
 )
 
 // TaskType identifies the type of a maintenance task.
@@ -46,6 +48,8 @@
 	TaskExtendBlobRetentionTimeFull = "extend-blob-retention-time"
 	TaskCleanupLogs                 = "cleanup-logs"
 	TaskCleanupEpochManager         = "cleanup-epoch-manager"
+	TaskVerifySnapshots             = "verify-snapshots"
+	TaskDeleteTemporaryFiles        = "delete-temporary-files"
 )
 
 // shouldRun returns Mode if repository is due for periodic maintenance.
@@ -84,10 +88,20 @@ func shouldRun(ctx context.Context, rep repo.DirectRepository, p *Params) (Mode,
 		log(ctx).Debugf("quick maintenance cycle not enabled")
 	}
 
+	if isVerificationDue(rep, p, s) {
+		log(ctx).Debugf("due for verification")
+		return ModeVerify, nil
+	}
+
 	return ModeNone, nil
 }
 
-func updateSchedule(ctx context.Context, runParams RunParameters) error {
+// isVerificationDue determines whether scheduled verification of snapshots should be performed.
+func isVerificationDue(rep repo.DirectRepository, p *Params, s *Schedule) bool {
+	return p.VerifyCycle.Enabled && !rep.Time().Before(s.NextVerifyTime)
+}
+
+func updateSchedule(ctx context.Context, runParams *RunParameters) error {
 	rep := runParams.rep
 	p := runParams.Params
 
@@ -104,17 +118,25 @@ func updateSchedule(ctx context.Context, runParams RunParameters) error {
 		log(ctx).Debugf("scheduling next full cycle at %v", s.NextFullMaintenanceTime)
 		log(ctx).Debugf("scheduling next quick cycle at %v", s.NextQuickMaintenanceTime)
 
-		return SetSchedule(ctx, rep, s)
-
 	case ModeQuick:
 		log(ctx).Debugf("scheduling next quick cycle at %v", s.NextQuickMaintenanceTime)
 		s.NextQuickMaintenanceTime = rep.Time().Add(p.QuickCycle.Interval)
 
-		return SetSchedule(ctx, rep, s)
+	case ModeVerify:
+		// verification is scheduled below
 
 	default:
 		return nil
 	}
+
+	// verification is performed together with any maintenance when due.
+	if isVerificationDue(rep, p, s) {
+		runParams.Verify = true
+		s.NextVerifyTime = rep.Time().Add(p.VerifyCycle.Interval)
+		log(ctx).Debugf("scheduling next verification at %v", s.NextVerifyTime)
+	}
+
+	return SetSchedule(ctx, rep, s)
 }
 
 // RunParameters passes essential parameters for maintenance.
@@ -127,6 +149,10 @@ type RunParameters struct {
 
 	Params *Params
 
+	// Verify indicates that scheduled verification of snapshots is due and must be performed
+	// by the caller using VerifyCycle parameters.
+	Verify bool
+
 	// timestamp of the last update of maintenance schedule blob
 	MaintenanceStartTime time.Time
 }
@@ -187,11 +213,11 @@ func RunExclusive(ctx context.Context, rep repo.DirectRepositoryWriter, mode Mod
 
 	defer l.Unlock() //nolint:errcheck
 
-	runParams := RunParameters{rep, mode, p, time.Time{}}
+	runParams := RunParameters{rep, mode, p, false, time.Time{}}
 
 	// update schedule so that we don't run the maintenance again immediately if
 	// this process crashes.
-	if err = updateSchedule(ctx, runParams); err != nil {
+	if err = updateSchedule(ctx, &runParams); err != nil {
 		return errors.Wrap(err, "error updating maintenance schedule")
 	}
 
@@ -241,12 +267,28 @@ func Run(ctx context.Context, runParams RunParameters, safety SafetyParameters)
 	case ModeFull:
 		return runFullMaintenance(ctx, runParams, safety)
 
+	case ModeVerify:
+		// nothing to do, verification is performed by the caller.
+		return nil
+
 	default:
 		return errors.Errorf("unknown mode %q", runParams.Mode)
 	}
 }
 
 func runQuickMaintenance(ctx context.Context, runParams RunParameters, safety SafetyParameters) error {
+	s, err := GetSchedule(ctx, runParams.rep)
+	if err != nil {
+		return errors.Wrap(err, "unable to get schedule")
+	}
+
+	// temporary files are left behind by crashed writes regardless of index format, so clean them up first.
+	if shouldDeleteTemporaryFiles(runParams.rep.Time(), s, safety) {
+		if err := runTaskDeleteTemporaryFiles(ctx, runParams, s, safety); err != nil {
+			return errors.Wrap(err, "error deleting temporary files")
+		}
+	}
+
 	_, ok, emerr := runParams.rep.ContentManager().EpochManager(ctx)
 	if ok {
 		log(ctx).Debugf("quick maintenance not required for epoch manager")
@@ -257,11 +299,6 @@ func runQuickMaintenance(ctx context.Context, runParams RunParameters, safety Sa
 		return errors.Wrap(emerr, "epoch manager")
 	}
 
-	s, err := GetSchedule(ctx, runParams.rep)
-	if err != nil {
-		return errors.Wrap(err, "unable to get schedule")
-	}
-
 	if shouldQuickRewriteContents(s, safety) {
 		// find 'q' packs that are less than 80% full and rewrite contents in them into
 		// new consolidated packs, orphaning old packs in the process.
@@ -291,7 +328,7 @@ func runQuickMaintenance(ctx context.Context, runParams RunParameters, safety Sa
 			return errors.Wrap(err, "error deleting unreferenced metadata blobs")
 		}
 	} else {
-		notDeletingOrphanedBlobs(ctx, s, safety)
+		notDeletingOrphanedBlobs(ctx, runParams.rep.Time(), s, safety)
 	}
 
 	// consolidate many smaller indexes into fewer larger ones.
@@ -311,8 +348,8 @@ func notRewritingContents(ctx context.Context) {
 	log(ctx).Infof("Previous content rewrite has not been finalized yet, waiting until the next blob deletion.")
 }
 
-func notDeletingOrphanedBlobs(ctx context.Context, s *Schedule, safety SafetyParameters) {
-	left := nextBlobDeleteTime(s, safety).Sub(clock.Now()).Truncate(time.Second)
+func notDeletingOrphanedBlobs(ctx context.Context, now time.Time, s *Schedule, safety SafetyParameters) {
+	left := nextBlobDeleteTime(s, safety).Sub(now).Truncate(time.Second)
 
 	log(ctx).Infof("Skipping blob deletion because not enough time has passed yet (%v left).", left)
 }
@@ -376,9 +413,16 @@ func runTaskRewriteContentsQuick(ctx context.Context, runParams RunParameters, s
 
 func runTaskRewriteContentsFull(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
 	return ReportRun(ctx, runParams.rep, TaskRewriteContentsFull, s, func() error {
-		return RewriteContents(ctx, runParams.rep, &RewriteContentsOptions{
+		if err := RewriteContents(ctx, runParams.rep, &RewriteContentsOptions{
 			ContentIDRange: index.AllIDs,
 			ShortPacks:     true,
+		}, safety); err != nil {
+			return err
+		}
+
+		// after the encryption key change, lazily re-encrypt contents in batches.
+		return ReencryptContents(ctx, runParams.rep, &ReencryptContentsOptions{
+			MaxBytes: reencryptContentsBatchBytes,
 		}, safety)
 	})
 }
@@ -409,6 +453,14 @@ func runTaskExtendBlobRetentionTimeFull(ctx context.Context, runParams RunParame
 	})
 }
 
+func runTaskDeleteTemporaryFiles(ctx context.Context, runParams RunParameters, s *Schedule, safety SafetyParameters) error {
+	return ReportRunWithStats(ctx, runParams.rep, TaskDeleteTemporaryFiles, s, func() (any, error) {
+		return DeleteStaleTemporaryFiles(ctx, runParams.rep, DeleteTemporaryFilesOptions{
+			NotAfterTime: runParams.MaintenanceStartTime,
+		}, safety)
+	})
+}
+
 func runFullMaintenance(ctx context.Context, runParams RunParameters, safety SafetyParameters) error {
 	s, err := GetSchedule(ctx, runParams.rep)
 	if err != nil {
@@ -437,7 +489,7 @@ func runFullMaintenance(ctx context.Context, runParams RunParameters, safety Saf
 			return errors.Wrap(err, "error deleting unreferenced blobs")
 		}
 	} else {
-		notDeletingOrphanedBlobs(ctx, s, safety)
+		notDeletingOrphanedBlobs(ctx, runParams.rep.Time(), s, safety)
 	}
 
 	// extend retention-time on supported storage.
@@ -453,6 +505,10 @@ func runFullMaintenance(ctx context.Context, runParams RunParameters, safety Saf
 		return errors.Wrap(err, "error cleaning up epoch manager")
 	}
 
+	if err := runTaskDeleteTemporaryFiles(ctx, runParams, s, safety); err != nil {
+		return errors.Wrap(err, "error deleting temporary files")
+	}
+
 	// clean up logs last
 	if err := runTaskCleanupLogs(ctx, runParams, s); err != nil {
 		return errors.Wrap(err, "error cleaning up logs")
@@ -461,6 +517,12 @@ func runFullMaintenance(ctx context.Context, runParams RunParameters, safety Saf
 	return nil
 }
 
+// shouldDeleteTemporaryFiles returns true if temporary files were not looked for recently,
+// since listing them requires walking the entire storage.
+func shouldDeleteTemporaryFiles(now time.Time, s *Schedule, safety SafetyParameters) bool {
+	return !maxEndTime(s.Runs[TaskDeleteTemporaryFiles]).Add(safety.TemporaryFileDeleteMinAge).After(now)
+}
+
 // shouldRewriteContents returns true if it's currently ok to rewrite contents.
 // since each content rewrite will require deleting of orphaned blobs after some time passes,
 // we don't want to starve blob deletion by constantly doing rewrites.
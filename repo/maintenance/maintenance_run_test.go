@@ -240,3 +240,25 @@ func TestFindSafeDropTime(t *testing.T) {
 		}
 	}
 }
+
+func TestShouldDeleteTemporaryFiles(t *testing.T) {
+	now := t1315
+
+	require.True(t, shouldDeleteTemporaryFiles(now, &Schedule{}, SafetyFull))
+	require.True(t, shouldDeleteTemporaryFiles(now, &Schedule{
+		Runs: map[TaskType][]RunInfo{
+			TaskDeleteTemporaryFiles: {{End: t0900, Success: true}},
+		},
+	}, SafetyNone))
+	require.False(t, shouldDeleteTemporaryFiles(now, &Schedule{
+		Runs: map[TaskType][]RunInfo{
+			TaskDeleteTemporaryFiles: {{End: t0900, Success: true}},
+		},
+	}, SafetyFull))
+	require.True(t, shouldDeleteTemporaryFiles(now, &Schedule{
+		Runs: map[TaskType][]RunInfo{
+			// failed runs are retried.
+			TaskDeleteTemporaryFiles: {{End: t0900, Success: false}},
+		},
+	}, SafetyFull))
+}
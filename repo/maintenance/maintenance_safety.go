@@ -33,6 +33,9 @@ type SafetyParameters struct {
 
 	// Minimum time that must pass after content rewrite before we delete orphaned blobs.
 	MinRewriteToOrphanDeletionDelay time.Duration
+
+	// Delete temporary files left by interrupted writes above this age, never less than MinTemporaryFileDeleteAge.
+	TemporaryFileDeleteMinAge time.Duration
 }
 
 // Supported safety levels.
@@ -51,6 +54,7 @@ type SafetyParameters struct {
 		SessionExpirationAge:             0,
 		RequireTwoGCCycles:               false,
 		DisableEventualConsistencySafety: true,
+		TemporaryFileDeleteMinAge:        MinTemporaryFileDeleteAge,
 	}
 
 	// SafetyFull has default safety parameters which allow safe GC concurrent with snapshotting
@@ -64,5 +68,6 @@ type SafetyParameters struct {
 		SessionExpirationAge:            96 * time.Hour, //nolint:gomnd
 		RequireTwoGCCycles:              true,
 		MinRewriteToOrphanDeletionDelay: time.Hour,
+		TemporaryFileDeleteMinAge:       24 * time.Hour, //nolint:gomnd
 	}
 )
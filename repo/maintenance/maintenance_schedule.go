@@ -10,7 +10,6 @@
 
 	"github.com/pkg/errors"
 
-	"github.com/kopia/kopia/internal/clock"
 	"github.com/kopia/kopia/internal/gather"
 	"github.com/kopia/kopia/repo"
 	"github.com/kopia/kopia/repo/blob"
@@ -36,14 +35,22 @@ type RunInfo struct {
 	End     time.Time `json:"end"`
 	Success bool      `json:"success,omitempty"`
 	Error   string    `json:"error,omitempty"`
+
+	// Stats contains statistics reported by the task, if any.
+	Stats json.RawMessage `json:"stats,omitempty"`
 }
 
 // Schedule keeps track of scheduled maintenance times.
 type Schedule struct {
 	NextFullMaintenanceTime  time.Time `json:"nextFullMaintenance"`
 	NextQuickMaintenanceTime time.Time `json:"nextQuickMaintenance"`
+	NextVerifyTime           time.Time `json:"nextVerify,omitempty"`
 
 	Runs map[TaskType][]RunInfo `json:"runs"`
+
+	// SnapshotGCBaseline is an opaque state recorded by snapshot garbage collection,
+	// which allows estimating unused contents without walking all snapshots.
+	SnapshotGCBaseline json.RawMessage `json:"snapshotGCBaseline,omitempty"`
 }
 
 // ReportRun adds the provided run information to the history and discards oldest entried.
@@ -95,7 +102,7 @@ func TimeToAttemptNextMaintenance(ctx context.Context, rep repo.DirectRepository
 	if mp.FullCycle.Enabled {
 		nextMaintenanceTime = ms.NextFullMaintenanceTime
 		if nextMaintenanceTime.IsZero() {
-			nextMaintenanceTime = clock.Now()
+			nextMaintenanceTime = rep.Time()
 		}
 	}
 
@@ -103,7 +110,16 @@ func TimeToAttemptNextMaintenance(ctx context.Context, rep repo.DirectRepository
 		if nextMaintenanceTime.IsZero() || ms.NextQuickMaintenanceTime.Before(nextMaintenanceTime) {
 			nextMaintenanceTime = ms.NextQuickMaintenanceTime
 			if nextMaintenanceTime.IsZero() {
-				nextMaintenanceTime = clock.Now()
+				nextMaintenanceTime = rep.Time()
+			}
+		}
+	}
+
+	if mp.VerifyCycle.Enabled {
+		if nextMaintenanceTime.IsZero() || ms.NextVerifyTime.Before(nextMaintenanceTime) {
+			nextMaintenanceTime = ms.NextVerifyTime
+			if nextMaintenanceTime.IsZero() {
+				nextMaintenanceTime = rep.Time()
 			}
 		}
 	}
@@ -181,6 +197,13 @@ func SetSchedule(ctx context.Context, rep repo.DirectRepositoryWriter, s *Schedu
 
 // ReportRun reports timing of a maintenance run and persists it in repository.
 func ReportRun(ctx context.Context, rep repo.DirectRepositoryWriter, taskType TaskType, s *Schedule, run func() error) error {
+	return ReportRunWithStats(ctx, rep, taskType, s, func() (any, error) {
+		return nil, run()
+	})
+}
+
+// ReportRunWithStats reports timing and statistics returned by a maintenance run and persists them in repository.
+func ReportRunWithStats(ctx context.Context, rep repo.DirectRepositoryWriter, taskType TaskType, s *Schedule, run func() (any, error)) error {
 	if s == nil {
 		var err error
 
@@ -194,10 +217,19 @@ func ReportRun(ctx context.Context, rep repo.DirectRepositoryWriter, taskType Ta
 		Start: rep.Time(),
 	}
 
-	runErr := run()
+	stats, runErr := run()
 
 	ri.End = rep.Time()
 
+	if stats != nil {
+		b, err := json.Marshal(stats)
+		if err != nil {
+			return errors.Wrap(err, "unable to marshal maintenance run statistics")
+		}
+
+		ri.Stats = b
+	}
+
 	if runErr != nil {
 		ri.Error = runErr.Error()
 	} else {
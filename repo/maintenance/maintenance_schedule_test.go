@@ -88,6 +88,35 @@ func TestTimeToAttemptNextMaintenance(t *testing.T) {
 			},
 			want: now.Add(2 * time.Hour),
 		},
+		{
+			desc: "verification first",
+			params: maintenance.Params{
+				Owner:       env.Repository.ClientOptions().UsernameAtHost(),
+				QuickCycle:  maintenance.CycleParams{Enabled: true},
+				FullCycle:   maintenance.CycleParams{Enabled: true},
+				VerifyCycle: maintenance.VerifyCycleParams{CycleParams: maintenance.CycleParams{Enabled: true}},
+			},
+			sched: maintenance.Schedule{
+				NextFullMaintenanceTime:  now.Add(2 * time.Hour),
+				NextQuickMaintenanceTime: now.Add(3 * time.Hour),
+				NextVerifyTime:           now.Add(1 * time.Hour),
+			},
+			want: now.Add(1 * time.Hour),
+		},
+		{
+			desc: "verification disabled",
+			params: maintenance.Params{
+				Owner:      env.Repository.ClientOptions().UsernameAtHost(),
+				QuickCycle: maintenance.CycleParams{Enabled: true},
+				FullCycle:  maintenance.CycleParams{Enabled: true},
+			},
+			sched: maintenance.Schedule{
+				NextFullMaintenanceTime:  now.Add(2 * time.Hour),
+				NextQuickMaintenanceTime: now.Add(3 * time.Hour),
+				NextVerifyTime:           now.Add(1 * time.Hour),
+			},
+			want: now.Add(2 * time.Hour),
+		},
 		{
 			desc: "both disabled",
 			params: maintenance.Params{
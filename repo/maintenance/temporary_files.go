@@ -0,0 +1,90 @@
+package maintenance
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+)
+
+// MinTemporaryFileDeleteAge is the minimum age of temporary files to be deleted regardless of safety level,
+// since files that are younger may still be written to.
+const MinTemporaryFileDeleteAge = time.Hour
+
+// DeleteTemporaryFilesOptions provides options for deletion of stale temporary files.
+type DeleteTemporaryFilesOptions struct {
+	DryRun       bool
+	NotAfterTime time.Time
+}
+
+// DeleteTemporaryFilesStats contains statistics about deleted temporary files.
+type DeleteTemporaryFilesStats struct {
+	Found        int   `json:"found"`
+	FoundBytes   int64 `json:"foundBytes"`
+	Deleted      int   `json:"deleted"`
+	DeletedBytes int64 `json:"deletedBytes"`
+	Preserved    int   `json:"preserved"`
+}
+
+// DeleteStaleTemporaryFiles deletes temporary files left in the storage by writes that were interrupted
+// before the blob was completed, for example due to a crash. Such files are never referenced by indexes,
+// since they don't have a valid blob ID, but to avoid deleting files that are still being written, only files
+// older than TemporaryFileDeleteMinAge are deleted.
+func DeleteStaleTemporaryFiles(ctx context.Context, rep repo.DirectRepositoryWriter, opt DeleteTemporaryFilesOptions, safety SafetyParameters) (DeleteTemporaryFilesStats, error) {
+	var result DeleteTemporaryFilesStats
+
+	cutoffTime := opt.NotAfterTime
+	if cutoffTime.IsZero() {
+		cutoffTime = rep.Time()
+	}
+
+	minAge := max(safety.TemporaryFileDeleteMinAge, MinTemporaryFileDeleteAge)
+
+	var stale []blob.TemporaryFile
+
+	log(ctx).Infof("Looking for stale temporary files...")
+
+	if err := rep.BlobStorage().ListTemporaryFiles(ctx, func(tf blob.TemporaryFile) error {
+		if age := cutoffTime.Sub(tf.Timestamp); age < minAge {
+			log(ctx).Debugf("  preserving temporary file %v because it's too new (age: %v<%v)", tf.Name, age, minAge)
+
+			result.Preserved++
+
+			return nil
+		}
+
+		result.Found++
+		result.FoundBytes += tf.Length
+
+		stale = append(stale, tf)
+
+		return nil
+	}); err != nil {
+		return result, errors.Wrap(err, "error listing temporary files")
+	}
+
+	log(ctx).Infof("Found %v stale temporary files (%v), preserved %v recent ones.", result.Found, units.BytesString(result.FoundBytes), result.Preserved)
+
+	if opt.DryRun {
+		return result, nil
+	}
+
+	for _, tf := range stale {
+		if err := rep.BlobStorage().DeleteTemporaryFile(ctx, tf.Name); err != nil {
+			return result, errors.Wrapf(err, "unable to delete temporary file %q", tf.Name)
+		}
+
+		result.Deleted++
+		result.DeletedBytes += tf.Length
+	}
+
+	if result.Deleted > 0 {
+		log(ctx).Infof("Deleted %v stale temporary files (%v).", result.Deleted, units.BytesString(result.DeletedBytes))
+	}
+
+	return result, nil
+}
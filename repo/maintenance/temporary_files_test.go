@@ -0,0 +1,96 @@
+package maintenance_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/clock"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/internal/testutil"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob/filesystem"
+	"github.com/kopia/kopia/repo/maintenance"
+)
+
+func TestDeleteStaleTemporaryFiles(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	dataDir := testutil.TempDirectory(t)
+	configFile := filepath.Join(testutil.TempDirectory(t), "repository.config")
+
+	st, err := filesystem.New(ctx, &filesystem.Options{Path: dataDir}, true)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Initialize(ctx, st, &repo.NewRepositoryOptions{}, "password"))
+	require.NoError(t, repo.Connect(ctx, configFile, st, "password", nil))
+
+	rep, err := repo.Open(ctx, configFile, "password", nil)
+	require.NoError(t, err)
+
+	defer rep.Close(ctx)
+
+	_, w, err := rep.(repo.DirectRepository).NewDirectWriter(ctx, repo.WriteSessionOptions{Purpose: "test"})
+	require.NoError(t, err)
+
+	defer w.Close(ctx)
+
+	staleFile := filepath.Join(dataDir, "p1234.f.tmp.0123abcd")
+	recentFile := filepath.Join(dataDir, "p5678.f.tmp.4567cdef")
+	inFlightFile := filepath.Join(dataDir, "p9abc.f.tmp.89abcdef")
+
+	require.NoError(t, os.WriteFile(staleFile, []byte{1, 2, 3}, 0o600))
+	require.NoError(t, os.WriteFile(recentFile, []byte{1, 2}, 0o600))
+	require.NoError(t, os.WriteFile(inFlightFile, []byte{1}, 0o600))
+
+	old := clock.Now().Add(-2 * maintenance.SafetyFull.TemporaryFileDeleteMinAge)
+	require.NoError(t, os.Chtimes(staleFile, old, old))
+
+	recent := clock.Now().Add(-2 * maintenance.MinTemporaryFileDeleteAge)
+	require.NoError(t, os.Chtimes(recentFile, recent, recent))
+
+	// dry run only reports stale files.
+	stats, err := maintenance.DeleteStaleTemporaryFiles(ctx, w, maintenance.DeleteTemporaryFilesOptions{DryRun: true}, maintenance.SafetyFull)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Found)
+	require.Equal(t, int64(3), stats.FoundBytes)
+	require.Equal(t, 2, stats.Preserved)
+	require.Equal(t, 0, stats.Deleted)
+	require.FileExists(t, staleFile)
+
+	stats, err = maintenance.DeleteStaleTemporaryFiles(ctx, w, maintenance.DeleteTemporaryFilesOptions{}, maintenance.SafetyFull)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.Deleted)
+	require.Equal(t, int64(3), stats.DeletedBytes)
+	require.NoFileExists(t, staleFile)
+	require.FileExists(t, recentFile)
+
+	// without safety, older temporary files are deleted as part of quick maintenance,
+	// but files which may still be written to are preserved.
+	require.NoError(t, maintenance.RunExclusive(ctx, w, maintenance.ModeQuick, true, func(ctx context.Context, runParams maintenance.RunParameters) error {
+		return maintenance.Run(ctx, runParams, maintenance.SafetyNone)
+	}))
+
+	require.NoFileExists(t, recentFile)
+	require.FileExists(t, inFlightFile)
+
+	sched, err := maintenance.GetSchedule(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, sched.Runs[maintenance.TaskDeleteTemporaryFiles], 1)
+	require.True(t, sched.Runs[maintenance.TaskDeleteTemporaryFiles][0].Success)
+
+	var runStats maintenance.DeleteTemporaryFilesStats
+
+	require.NoError(t, json.Unmarshal(sched.Runs[maintenance.TaskDeleteTemporaryFiles][0].Stats, &runStats))
+	require.Equal(t, maintenance.DeleteTemporaryFilesStats{
+		Found:        1,
+		FoundBytes:   2,
+		Deleted:      1,
+		DeletedBytes: 2,
+		Preserved:    1,
+	}, runStats)
+}
@@ -0,0 +1,109 @@
+package object
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// Limits enforced when reading objects, which protect readers from corrupt or maliciously crafted
+// repositories encoding objects that would expand to enormous amounts of work or memory.
+const (
+	// MaxIndirectionDepth is the maximum number of indirection levels ('I' prefixes) of an object ID.
+	// Writers only produce more than one level for extremely large objects.
+	MaxIndirectionDepth = 4
+
+	// MaxSectionNesting is the maximum nesting depth of indirect objects referenced from entries of other indirect objects.
+	// Current writers always produce flat index entries.
+	MaxSectionNesting = 4
+
+	// MaxInMemoryExpansion is the maximum total number of bytes chunks, index objects and decompressed contents
+	// are allowed to occupy in memory at the same time while reading an object, including all objects nested in it.
+	MaxInMemoryExpansion = 4 << 30
+)
+
+var (
+	// ErrIndirectionTooDeep is returned when an object ID has too many levels of indirection.
+	ErrIndirectionTooDeep = errors.New("object indirection too deep")
+
+	// ErrSectionNestingTooDeep is returned when indirect objects are nested too deeply.
+	ErrSectionNestingTooDeep = errors.New("object section nesting too deep")
+
+	// ErrExpansionTooLarge is returned when reading an object would require too much memory.
+	ErrExpansionTooLarge = errors.New("object expands to too much data")
+
+	// ErrInvalidIndirectObject is returned when an index of an indirect object is malformed.
+	ErrInvalidIndirectObject = errors.New("invalid indirect object")
+)
+
+// checkObjectLimits verifies that an object found at the provided section nesting depth can be safely read.
+func checkObjectLimits(oid ID, nesting int) error {
+	if int(oid.indirection) > MaxIndirectionDepth {
+		return errors.Wrapf(ErrIndirectionTooDeep, "%v levels in %v", oid.indirection, oid)
+	}
+
+	if oid.indirection > 0 && nesting > MaxSectionNesting {
+		return errors.Wrapf(ErrSectionNestingTooDeep, "%v levels at %v", nesting, oid)
+	}
+
+	return nil
+}
+
+// validateSeekTable ensures that the entries of an indirect object are contiguous and non-negative.
+func validateSeekTable(seekTable []IndirectObjectEntry) error {
+	var expectedStart int64
+
+	for i, e := range seekTable {
+		if e.Start != expectedStart || e.Length < 0 || e.endOffset() < e.Start {
+			return errors.Wrapf(ErrInvalidIndirectObject, "unexpected entry %v at %v (length %v), expected start %v", i, e.Start, e.Length, expectedStart)
+		}
+
+		expectedStart = e.endOffset()
+	}
+
+	return nil
+}
+
+// limitedWriter is an io.Writer which fails with ErrExpansionTooLarge after receiving more than the provided number of bytes.
+type limitedWriter struct {
+	remaining int64
+	w         io.Writer
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining {
+		return 0, ErrExpansionTooLarge
+	}
+
+	l.remaining -= int64(len(p))
+
+	//nolint:wrapcheck
+	return l.w.Write(p)
+}
+
+// expansionBudget tracks the number of bytes held in memory by a reader and all readers nested in it.
+type expansionBudget struct {
+	used atomic.Int64
+}
+
+// reserve reserves the provided number of bytes, failing with ErrExpansionTooLarge if that would exceed MaxInMemoryExpansion.
+func (b *expansionBudget) reserve(n int64) error {
+	if b.used.Add(n) > MaxInMemoryExpansion {
+		b.used.Add(-n)
+
+		return errors.Wrapf(ErrExpansionTooLarge, "reading %v more bytes would exceed %v bytes in memory", n, int64(MaxInMemoryExpansion))
+	}
+
+	return nil
+}
+
+// release releases bytes previously reserved.
+func (b *expansionBudget) release(n int64) {
+	b.used.Add(-n)
+}
+
+// remaining returns the number of bytes that can still be reserved.
+func (b *expansionBudget) remaining() int64 {
+	return MaxInMemoryExpansion - b.used.Load()
+}
@@ -23,6 +23,7 @@
 type Reader interface {
 	io.Reader
 	io.Seeker
+	io.ReaderAt
 	io.Closer
 	Length() int64
 }
@@ -39,6 +40,12 @@ type contentManager interface {
 	WriteContent(ctx context.Context, data gather.Bytes, prefix content.IDPrefix, comp compression.HeaderID) (content.ID, error)
 }
 
+// deduplicationReportingContentManager is implemented by content managers which can report whether
+// written contents were already present in the repository.
+type deduplicationReportingContentManager interface {
+	WriteContentWithStatus(ctx context.Context, data gather.Bytes, prefix content.IDPrefix, comp compression.HeaderID) (content.ID, bool, error)
+}
+
 // Manager implements a content-addressable storage on top of blob storage.
 type Manager struct {
 	Format format.ObjectFormat
@@ -55,6 +62,8 @@ func (om *Manager) NewWriter(ctx context.Context, opt WriterOptions) Writer {
 	w.om = om
 	w.splitter = om.newSplitter()
 	w.description = opt.Description
+	w.descriptionRecorder = opt.DescriptionRecorder
+	w.onContentWritten = opt.OnContentWritten
 	w.prefix = opt.Prefix
 	w.compressor = compression.ByName[opt.Compressor]
 	w.totalLength = 0
@@ -189,7 +198,7 @@ func PrefetchBackingContents(ctx context.Context, contentMgr contentManager, obj
 	tracker := &contentIDTracker{}
 
 	for _, oid := range objectIDs {
-		if err := iterateBackingContents(ctx, contentMgr, oid, tracker, noop); err != nil && !errors.Is(err, ErrObjectNotFound) && !errors.Is(err, content.ErrContentNotFound) {
+		if err := iterateBackingContents(ctx, contentMgr, oid, 0, &expansionBudget{}, tracker, noop); err != nil && !errors.Is(err, ErrObjectNotFound) && !errors.Is(err, content.ErrContentNotFound) {
 			return nil, err
 		}
 	}
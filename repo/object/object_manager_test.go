@@ -445,6 +445,87 @@ func TestIndirection(t *testing.T) {
 	}
 }
 
+func TestSplitterDeduplicationAfterInsertion(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	original := make([]byte, 4<<20)
+	rand.New(rand.NewSource(1)).Read(original)
+
+	// insert a single byte close to the beginning.
+	modified := append(append(append([]byte(nil), original[0:1000]...), 0x42), original[1000:]...)
+
+	cases := []struct {
+		splitter       string
+		maxNewContents int
+		minNewContents int
+	}{
+		// all contents after the insertion point are different.
+		{"FIXED-128K", 1000, 30},
+		// cut points resynchronize shortly after the insertion, only the affected contents and the index are new.
+		{"DYNAMIC-128K-BUZHASH", 3, 1},
+		{"DYNAMIC-128K-RABINKARP", 3, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.splitter, func(t *testing.T) {
+			data := map[content.ID][]byte{}
+
+			om, err := NewObjectManager(ctx, &fakeContentManager{data: data}, format.ObjectFormat{Splitter: tc.splitter}, nil)
+			require.NoError(t, err)
+
+			w := om.NewWriter(ctx, WriterOptions{})
+			_, err = w.Write(original)
+			require.NoError(t, err)
+
+			oid1, err := w.Result()
+			require.NoError(t, err)
+			require.Equal(t, 1, indirectionLevel(oid1))
+
+			before := len(data)
+
+			w = om.NewWriter(ctx, WriterOptions{})
+			_, err = w.Write(modified)
+			require.NoError(t, err)
+
+			oid2, err := w.Result()
+			require.NoError(t, err)
+			require.Equal(t, 1, indirectionLevel(oid2))
+
+			// exclude the new index content
+			newContents := len(data) - before - 1
+
+			require.GreaterOrEqual(t, newContents, tc.minNewContents)
+			require.LessOrEqual(t, newContents, tc.maxNewContents)
+		})
+	}
+}
+
+func TestLegacySplitterDefaultsToFixed(t *testing.T) {
+	ctx := testlogging.Context(t)
+	data := map[content.ID][]byte{}
+
+	// repositories that don't specify a splitter use fixed-size 4MB blocks.
+	om, err := NewObjectManager(ctx, &fakeContentManager{data: data}, format.ObjectFormat{}, nil)
+	require.NoError(t, err)
+
+	w := om.NewWriter(ctx, WriterOptions{})
+	_, err = w.Write(make([]byte, 9<<20))
+	require.NoError(t, err)
+
+	oid, err := w.Result()
+	require.NoError(t, err)
+
+	indexObjectID, ok := oid.IndexObjectID()
+	require.True(t, ok)
+
+	entries, err := LoadIndexObject(ctx, om.contentMgr, indexObjectID)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	require.Equal(t, int64(4<<20), entries[0].Length)
+	require.Equal(t, int64(4<<20), entries[1].Length)
+	require.Equal(t, int64(1<<20), entries[2].Length)
+}
+
 func indirectionLevel(oid ID) int {
 	indexObjectID, ok := oid.IndexObjectID()
 	if !ok {
@@ -660,6 +741,117 @@ func TestReaderStoredBlockNotFound(t *testing.T) {
 	}
 }
 
+func writeIndexObject(t *testing.T, om *Manager, entries []IndirectObjectEntry) ID {
+	t.Helper()
+
+	b, err := json.Marshal(indirectObject{StreamID: "kopia:indirect", Entries: entries})
+	require.NoError(t, err)
+
+	return IndirectObjectID(mustWriteObject(t, om, b, ""))
+}
+
+func TestReaderLimits(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, _, om := setupTest(t, nil)
+
+	payload := []byte("hello world")
+	direct := mustWriteObject(t, om, payload, "")
+
+	// too many indirection levels in the object ID.
+	tooDeep := direct
+	for i := 0; i <= MaxIndirectionDepth; i++ {
+		tooDeep = IndirectObjectID(tooDeep)
+	}
+
+	_, err := Open(ctx, om.contentMgr, tooDeep)
+	require.ErrorIs(t, err, ErrIndirectionTooDeep)
+
+	_, err = VerifyObject(ctx, om.contentMgr, tooDeep)
+	require.ErrorIs(t, err, ErrIndirectionTooDeep)
+
+	// sections nested up to the limit can be read.
+	nested := direct
+	for i := 0; i <= MaxSectionNesting; i++ {
+		nested = writeIndexObject(t, om, []IndirectObjectEntry{{Length: int64(len(payload)), Object: nested}})
+	}
+
+	verify(ctx, t, om.contentMgr, nested, payload, "nested")
+
+	_, err = VerifyObject(ctx, om.contentMgr, nested)
+	require.NoError(t, err)
+
+	nested = writeIndexObject(t, om, []IndirectObjectEntry{{Length: int64(len(payload)), Object: nested}})
+
+	r, err := Open(ctx, om.contentMgr, nested)
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, ErrSectionNestingTooDeep)
+
+	_, err = VerifyObject(ctx, om.contentMgr, nested)
+	require.ErrorIs(t, err, ErrSectionNestingTooDeep)
+
+	// non-contiguous entries.
+	_, err = Open(ctx, om.contentMgr, writeIndexObject(t, om, []IndirectObjectEntry{
+		{Length: int64(len(payload)), Object: direct},
+		{Start: int64(len(payload)) + 1, Length: int64(len(payload)), Object: direct},
+	}))
+	require.ErrorIs(t, err, ErrInvalidIndirectObject)
+
+	_, err = Open(ctx, om.contentMgr, writeIndexObject(t, om, []IndirectObjectEntry{{Length: -1, Object: direct}}))
+	require.ErrorIs(t, err, ErrInvalidIndirectObject)
+
+	// chunk claiming enormous length is not allocated.
+	r, err = Open(ctx, om.contentMgr, writeIndexObject(t, om, []IndirectObjectEntry{{Length: MaxInMemoryExpansion + 1, Object: direct}}))
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, ErrExpansionTooLarge)
+
+	// compressed content expanding beyond the length declared in the index.
+	compressed := mustWriteObject(t, om, make([]byte, 1<<20), "gzip")
+	require.True(t, compressed.compression)
+
+	r, err = Open(ctx, om.contentMgr, writeIndexObject(t, om, []IndirectObjectEntry{{Length: 100, Object: compressed}}))
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, ErrExpansionTooLarge)
+}
+
+func TestReaderExpansionBudget(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, _, om := setupTest(t, nil)
+
+	payload := bytes.Repeat([]byte{1}, 1000)
+	direct := mustWriteObject(t, om, payload, "")
+	nested := writeIndexObject(t, om, []IndirectObjectEntry{{Length: int64(len(payload)), Object: direct}})
+	top := writeIndexObject(t, om, []IndirectObjectEntry{{Length: int64(len(payload)), Object: nested}})
+
+	// memory held at all nesting levels counts towards the same budget.
+	budget := &expansionBudget{}
+
+	r, err := openNested(ctx, om.contentMgr, top, -1, 0, budget)
+	require.NoError(t, err)
+
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, payload, got)
+	require.NoError(t, r.Close())
+	require.Zero(t, budget.used.Load(), "everything is released on close")
+
+	// each chunk fits, but the chunk together with the nested one it's read from does not.
+	budget.used.Store(MaxInMemoryExpansion - 3*int64(len(payload)))
+
+	r, err = openNested(ctx, om.contentMgr, top, -1, 0, budget)
+	require.NoError(t, err)
+
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, ErrExpansionTooLarge)
+	require.NoError(t, r.Close())
+	require.Equal(t, MaxInMemoryExpansion-3*int64(len(payload)), budget.used.Load(), "failed reads release reservations")
+}
+
 func TestEndToEndReadAndSeek(t *testing.T) {
 	for _, asyncWrites := range []int{0, 4, 8} {
 		asyncWrites := asyncWrites
@@ -813,10 +1005,67 @@ func verify(ctx context.Context, t *testing.T, cr contentReader, objectID ID, ex
 			if !bytes.Equal(expected, got) {
 				t.Errorf("incorrect data read for %v: expected: %x, got: %x", testCaseID, expected, got)
 			}
+
+			gotAt := make([]byte, sampleSize)
+
+			if n, err := reader.ReadAt(gotAt, int64(seekOffset)); err != nil || n != sampleSize {
+				t.Errorf("invalid data from ReadAt: n=%v, expected=%v, err:%v", n, sampleSize, err)
+			}
+
+			if !bytes.Equal(expected, gotAt) {
+				t.Errorf("incorrect data from ReadAt for %v: expected: %x, got: %x", testCaseID, expected, gotAt)
+			}
 		}
 	}
 }
 
+func TestReadAtIndirect(t *testing.T) {
+	ctx := testlogging.Context(t)
+	_, _, om := setupTest(t, nil)
+
+	randomData := make([]byte, 200000)
+	cryptorand.Read(randomData)
+
+	writer := om.NewWriter(ctx, WriterOptions{})
+	writer.(*objectWriter).splitter = splitter.Fixed(1000)()
+
+	_, err := writer.Write(randomData)
+	require.NoError(t, err)
+
+	oid, err := writer.Result()
+	require.NoError(t, err)
+	require.Positive(t, indirectionLevel(oid))
+
+	r, err := Open(ctx, om.contentMgr, oid)
+	require.NoError(t, err)
+
+	// read spanning multiple chunks
+	buf := make([]byte, 2500)
+	n, err := r.ReadAt(buf, 1500)
+	require.NoError(t, err)
+	require.Equal(t, 2500, n)
+	require.Equal(t, randomData[1500:4000], buf)
+
+	// ReadAt does not affect the current position
+	pos, err := r.Seek(0, io.SeekCurrent)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), pos)
+
+	// partial read at the end of the object
+	n, err = r.ReadAt(buf, int64(len(randomData))-100)
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 100, n)
+	require.Equal(t, randomData[len(randomData)-100:], buf[0:n])
+
+	// read past end
+	n, err = r.ReadAt(buf, int64(len(randomData)))
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 0, n)
+
+	_, err = r.ReadAt(buf, -1)
+	require.Error(t, err)
+}
+
 //nolint:gocyclo
 func TestSeek(t *testing.T) {
 	ctx := testlogging.Context(t)
@@ -956,3 +1205,64 @@ func TestWriterFailure_OnCompression(t *testing.T) {
 	_, err := w.Write(bytes.Repeat([]byte{1, 2, 3, 4}, 1e6))
 	require.ErrorIs(t, err, errSomeError)
 }
+
+func TestSeekableCompression(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	// content manager supports compression, but seekable compression is still performed by the object layer.
+	compressionIDs := map[content.ID]compression.HeaderID{}
+	_, _, om := setupTest(t, compressionIDs)
+
+	data := makeMaybeCompressibleData(1000000, true)
+
+	writer := om.NewWriter(ctx, WriterOptions{Compressor: "zstd-seekable"})
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+
+	oid, err := writer.Result()
+	require.NoError(t, err)
+
+	cid, compressed, ok := oid.ContentID()
+	require.True(t, ok)
+	require.True(t, compressed, "seekable compression must be performed in the object layer")
+	require.Equal(t, content.NoCompression, compressionIDs[cid])
+
+	r, err := Open(ctx, om.contentMgr, oid)
+	require.NoError(t, err)
+
+	sr, ok := r.(*seekableReader)
+	require.True(t, ok, "unexpected reader type %T", r)
+	require.Equal(t, int64(len(data)), sr.Length())
+
+	buf := make([]byte, 1000)
+
+	n, err := r.ReadAt(buf, 600000)
+	require.NoError(t, err)
+	require.Equal(t, len(buf), n)
+	require.Equal(t, data[600000:601000], buf)
+
+	payload, err := om.contentMgr.GetContent(ctx, cid)
+	require.NoError(t, err)
+
+	// compressed payload is held in memory and reserved against the budget until the reader is closed.
+	budget := &expansionBudget{}
+
+	r, err = openNested(ctx, om.contentMgr, oid, -1, 0, budget)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(payload)), budget.used.Load())
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+	require.Zero(t, budget.used.Load(), "everything is released on close")
+
+	budget.used.Store(MaxInMemoryExpansion - int64(len(payload)) + 1)
+
+	_, err = openNested(ctx, om.contentMgr, oid, -1, 0, budget)
+	require.ErrorIs(t, err, ErrExpansionTooLarge)
+	require.Equal(t, MaxInMemoryExpansion-int64(len(payload))+1, budget.used.Load(), "failed opens release reservations")
+
+	// the entire object can still be decompressed by header.
+	var b bytes.Buffer
+
+	require.NoError(t, compression.DecompressByHeader(&b, bytes.NewReader(payload)))
+	require.Equal(t, data, b.Bytes())
+}
@@ -5,6 +5,7 @@
 	"context"
 	"encoding/json"
 	"io"
+	"unsafe"
 
 	"github.com/pkg/errors"
 
@@ -22,7 +23,7 @@ func Open(ctx context.Context, r contentReader, objectID ID) (Reader, error) {
 func VerifyObject(ctx context.Context, cr contentReader, oid ID) ([]content.ID, error) {
 	tracker := &contentIDTracker{}
 
-	if err := iterateBackingContents(ctx, cr, oid, tracker, func(contentID content.ID) error {
+	if err := iterateBackingContents(ctx, cr, oid, 0, &expansionBudget{}, tracker, func(contentID content.ID) error {
 		if _, err := cr.ContentInfo(ctx, contentID); err != nil {
 			return errors.Wrapf(err, "error getting content info for %v", contentID)
 		}
@@ -42,6 +43,10 @@ type objectReader struct {
 	cr contentReader
 
 	seekTable []IndirectObjectEntry
+	nesting   int // section nesting depth of this object
+
+	budget            *expansionBudget // shared by all readers nested in the same top-level reader
+	seekTableReserved int64
 
 	currentPosition int64 // Overall position in the objectReader
 	totalLength     int64 // Overall length
@@ -51,6 +56,9 @@ type objectReader struct {
 	currentChunkPosition int    // Read position in the current chunk
 }
 
+// indirectObjectEntrySize is the in-memory size of a single seek table entry.
+const indirectObjectEntrySize = int64(unsafe.Sizeof(IndirectObjectEntry{}))
+
 func (r *objectReader) Read(buffer []byte) (int, error) {
 	readBytes := 0
 	remaining := len(buffer)
@@ -105,25 +113,40 @@ func (r *objectReader) Read(buffer []byte) (int, error) {
 func (r *objectReader) openCurrentChunk() error {
 	st := r.seekTable[r.currentChunkIndex]
 
-	rd, err := openAndAssertLength(r.ctx, r.cr, st.Object, st.Length)
+	if err := r.budget.reserve(st.Length); err != nil {
+		return errors.Wrapf(err, "chunk %v of length %v", st.Object, st.Length)
+	}
+
+	b, err := r.readChunk(st)
 	if err != nil {
+		r.budget.release(st.Length)
 		return err
 	}
 
+	r.currentChunkData = b
+	r.currentChunkPosition = 0
+
+	return nil
+}
+
+func (r *objectReader) readChunk(st IndirectObjectEntry) ([]byte, error) {
+	rd, err := openNested(r.ctx, r.cr, st.Object, st.Length, r.nesting+1, r.budget)
+	if err != nil {
+		return nil, err
+	}
+
 	defer rd.Close() //nolint:errcheck
 
 	b := make([]byte, st.Length)
 	if _, err := io.ReadFull(rd, b); err != nil {
-		return errors.Wrap(err, "error reading chunk")
+		return nil, errors.Wrap(err, "error reading chunk")
 	}
 
-	r.currentChunkData = b
-	r.currentChunkPosition = 0
-
-	return nil
+	return b, nil
 }
 
 func (r *objectReader) closeCurrentChunk() {
+	r.budget.release(int64(len(r.currentChunkData)))
 	r.currentChunkData = nil
 }
 
@@ -160,8 +183,8 @@ func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
 	}
 
 	if offset >= r.totalLength {
+		r.closeCurrentChunk()
 		r.currentChunkIndex = len(r.seekTable)
-		r.currentChunkData = nil
 		r.currentPosition = offset
 
 		return offset, nil
@@ -191,7 +214,72 @@ func (r *objectReader) Seek(offset int64, whence int) (int64, error) {
 	return r.currentPosition, nil
 }
 
+// ReadAt implements io.ReaderAt. It only loads chunks that overlap the requested range
+// (resolving nested indirect objects lazily) and does not affect the current read position,
+// so it's safe to call concurrently with other ReadAt calls.
+func (r *objectReader) ReadAt(buffer []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, errors.Errorf("invalid negative offset %v", offset)
+	}
+
+	if offset >= r.totalLength {
+		return 0, io.EOF
+	}
+
+	index, err := r.findChunkIndexForOffset(offset)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid offset %v", offset)
+	}
+
+	readBytes := 0
+
+	for readBytes < len(buffer) && index < len(r.seekTable) {
+		st := r.seekTable[index]
+
+		n, err := r.readChunkAt(st, buffer[readBytes:], offset+int64(readBytes)-st.Start)
+		readBytes += n
+
+		if err != nil {
+			return readBytes, err
+		}
+
+		index++
+	}
+
+	if readBytes < len(buffer) {
+		return readBytes, io.EOF
+	}
+
+	return readBytes, nil
+}
+
+// readChunkAt reads the portion of the given chunk starting at the provided offset within the chunk.
+func (r *objectReader) readChunkAt(st IndirectObjectEntry, buffer []byte, chunkOffset int64) (int, error) {
+	rd, err := openNested(r.ctx, r.cr, st.Object, st.Length, r.nesting+1, r.budget)
+	if err != nil {
+		return 0, err
+	}
+
+	defer rd.Close() //nolint:errcheck
+
+	if remaining := st.Length - chunkOffset; int64(len(buffer)) > remaining {
+		buffer = buffer[0:remaining]
+	}
+
+	n, err := rd.ReadAt(buffer, chunkOffset)
+	if errors.Is(err, io.EOF) && n == len(buffer) {
+		err = nil
+	}
+
+	return n, errors.Wrap(err, "error reading chunk")
+}
+
 func (r *objectReader) Close() error {
+	r.closeCurrentChunk()
+
+	r.budget.release(r.seekTableReserved)
+	r.seekTableReserved = 0
+
 	return nil
 }
 
@@ -200,38 +288,67 @@ func (r *objectReader) Length() int64 {
 }
 
 func openAndAssertLength(ctx context.Context, cr contentReader, objectID ID, assertLength int64) (Reader, error) {
+	return openNested(ctx, cr, objectID, assertLength, 0, &expansionBudget{})
+}
+
+// openNested opens the object found at the provided section nesting depth, memory held by the reader
+// is accounted for in the provided budget until it's closed.
+func openNested(ctx context.Context, cr contentReader, objectID ID, assertLength int64, nesting int, budget *expansionBudget) (Reader, error) {
+	if err := checkObjectLimits(objectID, nesting); err != nil {
+		return nil, err
+	}
+
 	if indexObjectID, ok := objectID.IndexObjectID(); ok {
-		// recursively calls openAndAssertLength
-		seekTable, err := LoadIndexObject(ctx, cr, indexObjectID)
+		// recursively calls openNested
+		seekTable, err := loadIndexObject(ctx, cr, indexObjectID, nesting, budget)
 		if err != nil {
 			return nil, err
 		}
 
-		totalLength := seekTable[len(seekTable)-1].endOffset()
+		seekTableReserved := int64(len(seekTable)) * indirectObjectEntrySize
+		if err := budget.reserve(seekTableReserved); err != nil {
+			return nil, errors.Wrapf(err, "index object %v", indexObjectID)
+		}
+
+		var totalLength int64
+
+		if len(seekTable) > 0 {
+			totalLength = seekTable[len(seekTable)-1].endOffset()
+		}
 
 		return &objectReader{
-			ctx:         ctx,
-			cr:          cr,
-			seekTable:   seekTable,
-			totalLength: totalLength,
+			ctx:               ctx,
+			cr:                cr,
+			seekTable:         seekTable,
+			nesting:           nesting,
+			budget:            budget,
+			seekTableReserved: seekTableReserved,
+			totalLength:       totalLength,
 		}, nil
 	}
 
-	return newRawReader(ctx, cr, objectID, assertLength)
+	return newRawReader(ctx, cr, objectID, assertLength, budget)
 }
 
-func iterateIndirectObjectContents(ctx context.Context, cr contentReader, indexObjectID ID, tracker *contentIDTracker, callbackFunc func(contentID content.ID) error) error {
-	if err := iterateBackingContents(ctx, cr, indexObjectID, tracker, callbackFunc); err != nil {
+func iterateIndirectObjectContents(ctx context.Context, cr contentReader, indexObjectID ID, nesting int, budget *expansionBudget, tracker *contentIDTracker, callbackFunc func(contentID content.ID) error) error {
+	if err := iterateBackingContents(ctx, cr, indexObjectID, nesting, budget, tracker, callbackFunc); err != nil {
 		return errors.Wrap(err, "unable to read index")
 	}
 
-	seekTable, err := LoadIndexObject(ctx, cr, indexObjectID)
+	seekTable, err := loadIndexObject(ctx, cr, indexObjectID, nesting, budget)
 	if err != nil {
 		return err
 	}
 
+	seekTableReserved := int64(len(seekTable)) * indirectObjectEntrySize
+	if err := budget.reserve(seekTableReserved); err != nil {
+		return errors.Wrapf(err, "index object %v", indexObjectID)
+	}
+
+	defer budget.release(seekTableReserved)
+
 	for _, m := range seekTable {
-		err := iterateBackingContents(ctx, cr, m.Object, tracker, callbackFunc)
+		err := iterateBackingContents(ctx, cr, m.Object, nesting+1, budget, tracker, callbackFunc)
 		if err != nil {
 			return err
 		}
@@ -240,9 +357,13 @@ func iterateIndirectObjectContents(ctx context.Context, cr contentReader, indexO
 	return nil
 }
 
-func iterateBackingContents(ctx context.Context, r contentReader, oid ID, tracker *contentIDTracker, callbackFunc func(contentID content.ID) error) error {
+func iterateBackingContents(ctx context.Context, r contentReader, oid ID, nesting int, budget *expansionBudget, tracker *contentIDTracker, callbackFunc func(contentID content.ID) error) error {
+	if err := checkObjectLimits(oid, nesting); err != nil {
+		return err
+	}
+
 	if indexObjectID, ok := oid.IndexObjectID(); ok {
-		return iterateIndirectObjectContents(ctx, r, indexObjectID, tracker, callbackFunc)
+		return iterateIndirectObjectContents(ctx, r, indexObjectID, nesting, budget, tracker, callbackFunc)
 	}
 
 	if contentID, _, ok := oid.ContentID(); ok {
@@ -265,22 +386,34 @@ type indirectObject struct {
 
 // LoadIndexObject returns entries comprising index object.
 func LoadIndexObject(ctx context.Context, cr contentReader, indexObjectID ID) ([]IndirectObjectEntry, error) {
-	r, err := openAndAssertLength(ctx, cr, indexObjectID, -1)
+	return loadIndexObject(ctx, cr, indexObjectID, 0, &expansionBudget{})
+}
+
+func loadIndexObject(ctx context.Context, cr contentReader, indexObjectID ID, nesting int, budget *expansionBudget) ([]IndirectObjectEntry, error) {
+	r, err := openNested(ctx, cr, indexObjectID, -1, nesting, budget)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Close() //nolint:errcheck
 
+	if r.Length() > budget.remaining() {
+		return nil, errors.Wrapf(ErrExpansionTooLarge, "index object %v of length %v", indexObjectID, r.Length())
+	}
+
 	var ind indirectObject
 
 	if err := json.NewDecoder(r).Decode(&ind); err != nil {
 		return nil, errors.Wrap(err, "invalid indirect object")
 	}
 
+	if err := validateSeekTable(ind.Entries); err != nil {
+		return nil, errors.Wrapf(err, "index object %v", indexObjectID)
+	}
+
 	return ind.Entries, nil
 }
 
-func newRawReader(ctx context.Context, cr contentReader, objectID ID, assertLength int64) (Reader, error) {
+func newRawReader(ctx context.Context, cr contentReader, objectID ID, assertLength int64, budget *expansionBudget) (Reader, error) {
 	contentID, compressed, ok := objectID.ContentID()
 	if !ok {
 		return nil, errors.Errorf("unsupported object ID: %v", objectID)
@@ -296,28 +429,78 @@ func newRawReader(ctx context.Context, cr contentReader, objectID ID, assertLeng
 	}
 
 	if compressed {
-		var b bytes.Buffer
+		if sr, ok, err := compression.OpenSeekable(payload); ok || err != nil {
+			if err != nil {
+				return nil, errors.Wrap(err, "decompression error")
+			}
 
-		if err = compression.DecompressByHeader(&b, bytes.NewReader(payload)); err != nil {
-			return nil, errors.Wrap(err, "decompression error")
+			return newSeekableObjectReader(contentID, sr, int64(len(payload)), assertLength, budget)
 		}
 
-		payload = b.Bytes()
+		if payload, err = decompressWithinBudget(contentID, payload, assertLength, budget); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := budget.reserve(int64(len(payload))); err != nil {
+		return nil, errors.Wrapf(err, "content %v", contentID)
 	}
 
 	if assertLength != -1 && int64(len(payload)) != assertLength {
+		budget.release(int64(len(payload)))
 		return nil, errors.Errorf("unexpected chunk length %v, expected %v", len(payload), assertLength)
 	}
 
-	return newObjectReaderWithData(payload), nil
+	return &readerWithData{
+		Reader: bytes.NewReader(payload),
+		length: int64(len(payload)),
+		budget: budget,
+	}, nil
+}
+
+// decompressWithinBudget decompresses the payload, failing if it expands beyond the asserted length
+// or the remaining budget.
+func decompressWithinBudget(contentID content.ID, payload []byte, assertLength int64, budget *expansionBudget) ([]byte, error) {
+	var b bytes.Buffer
+
+	// compressed payload is held in memory while decompressing.
+	if err := budget.reserve(int64(len(payload))); err != nil {
+		return nil, errors.Wrapf(err, "content %v", contentID)
+	}
+
+	defer budget.release(int64(len(payload)))
+
+	maxLength := budget.remaining()
+	if assertLength != -1 && assertLength < maxLength {
+		maxLength = assertLength
+	}
+
+	if err := compression.DecompressByHeader(&limitedWriter{maxLength, &b}, bytes.NewReader(payload)); err != nil {
+		if errors.Is(err, ErrExpansionTooLarge) {
+			return nil, errors.Wrapf(ErrExpansionTooLarge, "content %v decompresses to more than %v bytes", contentID, maxLength)
+		}
+
+		return nil, errors.Wrap(err, "decompression error")
+	}
+
+	return b.Bytes(), nil
 }
 
 type readerWithData struct {
-	io.ReadSeeker
+	*bytes.Reader
 	length int64
+
+	budget *expansionBudget // nil if not accounted for
+	closed bool
 }
 
 func (rwd *readerWithData) Close() error {
+	if rwd.budget != nil && !rwd.closed {
+		rwd.budget.release(rwd.length)
+	}
+
+	rwd.closed = true
+
 	return nil
 }
 
@@ -325,9 +508,53 @@ func (rwd *readerWithData) Length() int64 {
 	return rwd.length
 }
 
+// newSeekableObjectReader returns a reader which decompresses only the parts of the content
+// which are actually read. The compressed payload is held in memory until the reader is closed.
+func newSeekableObjectReader(contentID content.ID, sr compression.SeekableReader, payloadLength, assertLength int64, budget *expansionBudget) (Reader, error) {
+	if sr.Length() > MaxInMemoryExpansion {
+		return nil, errors.Wrapf(ErrExpansionTooLarge, "content %v decompresses to more than %v bytes", contentID, int64(MaxInMemoryExpansion))
+	}
+
+	if assertLength != -1 && sr.Length() != assertLength {
+		return nil, errors.Errorf("unexpected chunk length %v, expected %v", sr.Length(), assertLength)
+	}
+
+	if err := budget.reserve(payloadLength); err != nil {
+		return nil, errors.Wrapf(err, "content %v", contentID)
+	}
+
+	return &seekableReader{
+		SectionReader: io.NewSectionReader(sr, 0, sr.Length()),
+		reserved:      payloadLength,
+		budget:        budget,
+	}, nil
+}
+
+type seekableReader struct {
+	*io.SectionReader
+
+	reserved int64 // number of bytes of compressed payload reserved against the budget
+	budget   *expansionBudget
+	closed   bool
+}
+
+func (r *seekableReader) Close() error {
+	if !r.closed {
+		r.budget.release(r.reserved)
+	}
+
+	r.closed = true
+
+	return nil
+}
+
+func (r *seekableReader) Length() int64 {
+	return r.Size()
+}
+
 func newObjectReaderWithData(data []byte) Reader {
 	return &readerWithData{
-		ReadSeeker: bytes.NewReader(data),
-		length:     int64(len(data)),
+		Reader: bytes.NewReader(data),
+		length: int64(len(data)),
 	}
 }
@@ -80,7 +80,9 @@ type objectWriter struct {
 	indirectIndex          []IndirectObjectEntry
 	indirectIndexBuf       [4]IndirectObjectEntry // small buffer so that we avoid allocations most of the time
 
-	description string
+	description         string
+	descriptionRecorder DescriptionRecorder
+	onContentWritten    func(numBytes int64, deduplicated bool)
 
 	splitter splitter.Splitter
 
@@ -193,8 +195,9 @@ func (w *objectWriter) prepareAndWriteContentChunk(chunkID int, data gather.Byte
 		return errors.Wrap(err, "supports content compression")
 	}
 
-	// do not compress in this layer, instead pass comp to the content manager.
-	if scc && w.compressor != nil {
+	// do not compress in this layer, instead pass comp to the content manager, unless the compressor
+	// supports random access, which requires compressed bytes to be available to the object reader.
+	if scc && w.compressor != nil && !compression.IsSeekable(w.compressor) {
 		comp = w.compressor.HeaderID()
 		objectComp = nil
 	}
@@ -205,7 +208,7 @@ func (w *objectWriter) prepareAndWriteContentChunk(chunkID int, data gather.Byte
 		return errors.Wrap(err, "unable to prepare content bytes")
 	}
 
-	contentID, err := w.om.contentMgr.WriteContent(w.ctx, contentBytes, w.prefix, comp)
+	contentID, err := w.writeContent(contentBytes, comp, int64(data.Length()))
 	if err != nil {
 		return errors.Wrapf(err, "unable to write content chunk %v of %v: %v", chunkID, w.description, err)
 	}
@@ -218,6 +221,23 @@ func (w *objectWriter) prepareAndWriteContentChunk(chunkID int, data gather.Byte
 	return nil
 }
 
+func (w *objectWriter) writeContent(contentBytes gather.Bytes, comp compression.HeaderID, numBytes int64) (content.ID, error) {
+	if w.onContentWritten != nil {
+		if dr, ok := w.om.contentMgr.(deduplicationReportingContentManager); ok {
+			contentID, deduplicated, err := dr.WriteContentWithStatus(w.ctx, contentBytes, w.prefix, comp)
+			if err == nil {
+				w.onContentWritten(numBytes, deduplicated)
+			}
+
+			//nolint:wrapcheck
+			return contentID, err
+		}
+	}
+
+	//nolint:wrapcheck
+	return w.om.contentMgr.WriteContent(w.ctx, contentBytes, w.prefix, comp)
+}
+
 func (w *objectWriter) saveError(err error) error {
 	if err != nil {
 		// store write error so that we fail at Result() later.
@@ -265,7 +285,12 @@ func (w *objectWriter) Result() (ID, error) {
 		}
 	}
 
-	return w.checkpointLocked()
+	oid, err := w.checkpointLocked()
+	if err == nil && w.descriptionRecorder != nil && oid != EmptyID {
+		w.descriptionRecorder.RecordObjectDescription(oid, w.description)
+	}
+
+	return oid, err
 }
 
 // Checkpoint returns object ID which represents portion of the object that has already been written.
@@ -334,10 +359,24 @@ func writeIndirectObject(w io.Writer, entries []IndirectObjectEntry) error {
 	return nil
 }
 
+// DescriptionRecorder receives descriptions of objects as they are written, which allows them to be persisted
+// so that bare object IDs can later be traced back to their origin.
+type DescriptionRecorder interface {
+	RecordObjectDescription(oid ID, description string)
+}
+
 // WriterOptions can be passed to Repository.NewWriter().
 type WriterOptions struct {
 	Description string
 	Prefix      content.IDPrefix // empty string or a single-character ('g'..'z')
 	Compressor  compression.Name
 	AsyncWrites int // allow up to N content writes to be asynchronous
+
+	// DescriptionRecorder, when set, is notified with the description of the object once its ID is known.
+	DescriptionRecorder DescriptionRecorder
+
+	// OnContentWritten, when set, is invoked with the number of bytes of each content written to the object
+	// and whether that content was already present in the repository. It is only invoked when the content
+	// manager is able to report deduplication.
+	OnContentWritten func(numBytes int64, deduplicated bool)
 }
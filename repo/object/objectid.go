@@ -15,6 +15,10 @@
 //  1. In a single content block, this is the most common case for small objects.
 //  2. In a series of content blocks with an indirect block pointing at them (multiple indirections are allowed).
 //     This is used for larger files. Object IDs using indirect blocks start with "I"
+//
+// Object IDs of contents which were compressed before being stored have "Z" prefix, the compression
+// method itself is recorded in the compression header of the content payload, so readers can
+// decompress it without any additional information. Object IDs without the prefix are never compressed.
 type ID struct {
 	cid         content.ID
 	indirection byte
@@ -82,6 +82,20 @@ func TestString(t *testing.T) {
 	}
 }
 
+func TestCompressedIDRoundTrip(t *testing.T) {
+	for _, s := range []string{"abcd", "Zabcd", "Zxabcd", "IIabcd", "Ixabcd"} {
+		id := mustParseID(t, s)
+		require.Equal(t, s, id.String())
+
+		_, compressed, ok := id.ContentID()
+		require.Equal(t, s[0] == 'Z', ok && compressed, s)
+	}
+
+	id := Compressed(DirectObjectID(mustParseID(t, "xabcd").cid))
+	require.Equal(t, "Zxabcd", id.String())
+	require.Equal(t, id, mustParseID(t, id.String()))
+}
+
 func mustParseID(t *testing.T, s string) ID {
 	t.Helper()
 
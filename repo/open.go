@@ -20,6 +20,7 @@
 	"github.com/kopia/kopia/internal/repodiag"
 	"github.com/kopia/kopia/internal/retry"
 	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/blob/asof"
 	"github.com/kopia/kopia/repo/blob/beforeop"
 	loggingwrapper "github.com/kopia/kopia/repo/blob/logging"
 	"github.com/kopia/kopia/repo/blob/readonly"
@@ -47,6 +48,9 @@
 var supportedFeatures = []feature.Feature{
 	"index-v1",
 	"index-v2",
+	separateDataKeyFeature,
+	encryptionKeyRotationFeature,
+	format.MinClientVersionFeature,
 }
 
 // throttlingWindow is the duration window during which the throttling token bucket fully replenishes.
@@ -75,6 +79,12 @@ type Options struct {
 
 	OnFatalError func(err error) // function to invoke when repository encounters a fatal error, usually invokes os.Exit
 
+	DataPassword string // password protecting the separate data key, without it only metadata can be accessed
+
+	Forensic bool // open the repository read-only without writing to local caches, configuration or internal logs
+
+	LowMemory bool // reduce memory usage at the expense of performance, for devices with little RAM
+
 	// test-only flags
 	TestOnlyIgnoreMissingRequiredFeatures bool // ignore missing features
 }
@@ -125,6 +135,14 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 		return nil, ErrCannotWriteToRepoConnectionWithPermissiveCacheLoading
 	}
 
+	if options.Forensic {
+		applyForensicOptions(lc, options)
+	}
+
+	if lc.AsOf != nil {
+		applyAsOfOptions(lc)
+	}
+
 	if lc.APIServer != nil {
 		return openAPIServer(ctx, lc.APIServer, lc.ClientOptions, lc.Caching, password, options)
 	}
@@ -132,6 +150,28 @@ func Open(ctx context.Context, configFile, password string, options *Options) (r
 	return openDirect(ctx, configFile, lc, password, options)
 }
 
+// applyForensicOptions adjusts the connection so that opening the repository performs no writes,
+// neither to the storage nor to the local machine. Caches are kept in memory only.
+func applyForensicOptions(lc *LocalConfig, options *Options) {
+	lc.ReadOnly = true
+
+	caching := lc.Caching.CloneOrDefault()
+	caching.CacheDirectory = ""
+	lc.Caching = caching
+
+	options.DisableInternalLog = true
+}
+
+// applyAsOfOptions adjusts the connection to present the repository as of a point in time. The view
+// is read-only and caches are kept in memory, so that they are never shared with the current repository state.
+func applyAsOfOptions(lc *LocalConfig) {
+	lc.ReadOnly = true
+
+	caching := lc.Caching.CloneOrDefault()
+	caching.CacheDirectory = ""
+	lc.Caching = caching
+}
+
 func getContentCacheOrNil(ctx context.Context, opt *content.CachingOptions, password string, mr *metrics.Registry, timeNow func() time.Time) (*cache.PersistentCache, error) {
 	opt = opt.CloneOrDefault()
 
@@ -195,6 +235,7 @@ func(ctx context.Context) error {
 		metricsRegistry:  mr,
 		refCountedCloser: closer,
 		beforeFlush:      options.BeforeFlush,
+		timeNow:          defaultTime(options.TimeNowFunc),
 	}
 
 	if si.DisableGRPC {
@@ -219,15 +260,34 @@ func openDirect(ctx context.Context, configFile string, lc *LocalConfig, passwor
 		st = loggingwrapper.NewWrapper(st, log(ctx), "[STORAGE] ")
 	}
 
+	if lc.AsOf != nil {
+		st = asof.NewWrapper(st, *lc.AsOf, format.KopiaRepositoryBlobID, format.KopiaBlobCfgBlobID)
+	}
+
 	if lc.ReadOnly {
 		st = readonly.NewWrapper(st)
 	}
 
 	cliOpts := lc.ApplyDefaults(ctx, "Repository in "+st.DisplayName())
 
-	r, err := openWithConfig(ctx, st, cliOpts, password, options, lc.Caching, configFile)
+	var base DirectRepository
+
+	if lc.BaseRepositoryConfigFile != "" {
+		base, err = openBaseRepository(ctx, configFile, lc, password, options)
+		if err != nil {
+			st.Close(ctx) //nolint:errcheck
+			return nil, err
+		}
+	}
+
+	r, err := openWithConfig(ctx, st, cliOpts, password, options, lc.Caching, configFile, base)
 	if err != nil {
 		st.Close(ctx) //nolint:errcheck
+
+		if base != nil {
+			base.Close(ctx) //nolint:errcheck
+		}
+
 		return nil, err
 	}
 
@@ -237,12 +297,14 @@ func openDirect(ctx context.Context, configFile string, lc *LocalConfig, passwor
 // openWithConfig opens the repository with a given configuration, avoiding the need for a config file.
 //
 //nolint:funlen,gocyclo
-func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions, password string, options *Options, cacheOpts *content.CachingOptions, configFile string) (DirectRepository, error) {
+func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions, password string, options *Options, cacheOpts *content.CachingOptions, configFile string, base DirectRepository) (DirectRepository, error) {
 	cacheOpts = cacheOpts.CloneOrDefault()
 	cmOpts := &content.ManagerOptions{
 		TimeNow:                defaultTime(options.TimeNowFunc),
 		DisableInternalLog:     options.DisableInternalLog,
 		PermissiveCacheLoading: cliOpts.PermissiveCacheLoading,
+		DataPassword:           options.DataPassword,
+		LowMemory:              options.LowMemory,
 	}
 
 	mr := metrics.NewRegistry()
@@ -258,6 +320,22 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		return nil, err
 	}
 
+	if err := checkClientVersion(ctx, fmgr); err != nil {
+		if !errors.Is(err, format.ErrClientVersionTooOld) {
+			return nil, err
+		}
+
+		log(ctx).Warnf("%v, writes to the repository will be refused.", err)
+	}
+
+	if base != nil {
+		if err := checkLinkedToBase(ctx, fmgr, base); err != nil {
+			return nil, err
+		}
+
+		cmOpts.BaseContentIndex = base.ContentReader()
+	}
+
 	if fmgr.SupportsPasswordChange() {
 		cacheOpts.HMACSecret = crypto.DeriveKeyFromMasterKey(fmgr.GetHmacSecret(), fmgr.UniqueID(), localCacheIntegrityPurpose, localCacheIntegrityHMACSecretLength)
 	} else {
@@ -276,6 +354,10 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 	}
 
 	throttler.OnUpdate(func(l throttling.Limits) error {
+		if options.Forensic {
+			return errors.New("unable to persist throttling limits in forensic mode")
+		}
+
 		lc2, err2 := LoadConfigFromFile(configFile)
 		if err2 != nil {
 			return err2
@@ -323,10 +405,29 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		st = upgradeLockMonitor(fmgr, options.UpgradeOwnerID, st, cmOpts.TimeNow, options.OnFatalError, options.TestOnlyIgnoreMissingRequiredFeatures)
 	}
 
+	// refuse writes from clients older than the minimum version required by the repository.
+	st = clientVersionMonitor(fmgr, st)
+
+	// pack blobs referenced from the base repository are read from its storage.
+	st = withBaseRepository(st, base)
+
 	dw := repodiag.NewWriter(st, fmgr)
 	logManager := repodiag.NewLogManager(ctx, dw)
 
-	scm, ferr := content.NewSharedManager(ctx, st, fmgr, cacheOpts, cmOpts, logManager, mr)
+	var auditLog *repodiag.AuditLog
+
+	if cliOpts.AuditLog && !cliOpts.ReadOnly {
+		// audit blobs are written by dw directly to the underlying storage, so they are not audited themselves.
+		auditLog = repodiag.NewAuditLog(ctx, dw, cliOpts.UsernameAtHost(), cmOpts.TimeNow)
+		st = repodiag.NewAuditStorage(st, auditLog)
+
+		// format blobs are written by the format manager using its own storage.
+		fmgr.WrapStorage(func(fst blob.Storage) blob.Storage {
+			return repodiag.NewAuditStorage(fst, auditLog)
+		})
+	}
+
+	scm, ferr := content.NewSharedManager(ctx, st, withBaseFormat(fmgr, base), cacheOpts, cmOpts, logManager, mr)
 	if ferr != nil {
 		return nil, errors.Wrap(ferr, "unable to create shared content manager")
 	}
@@ -346,12 +447,22 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 		return nil, errors.Wrap(ferr, "unable to open manifests")
 	}
 
-	closer := newRefCountedCloser(
-		scm.CloseShared,
+	closeFuncs := []closeFunc{scm.CloseShared}
+
+	if auditLog != nil {
+		// the final audit segment must be written after all pending blobs have been flushed.
+		closeFuncs = append(closeFuncs, auditLog.Close)
+	}
+
+	closer := newRefCountedCloser(append(closeFuncs,
 		dw.Wait,
 		mr.Close,
 		st.Close,
-	)
+	)...)
+
+	if base != nil {
+		closer.registerEarlyCloseFunc(base.Close)
+	}
 
 	dr := &directRepository{
 		cmgr:  cm,
@@ -370,6 +481,7 @@ func openWithConfig(ctx context.Context, st blob.Storage, cliOpts ClientOptions,
 			metricsRegistry:  mr,
 			refCountedCloser: closer,
 			beforeFlush:      options.BeforeFlush,
+			base:             base,
 		},
 	}
 
@@ -398,6 +510,26 @@ func handleMissingRequiredFeatures(ctx context.Context, fmgr *format.Manager, ig
 	return nil
 }
 
+func checkClientVersion(ctx context.Context, fmgr *format.Manager) error {
+	mp, err := fmgr.GetMutableParameters(ctx)
+	if err != nil {
+		return errors.Wrap(err, "mutable parameters")
+	}
+
+	//nolint:wrapcheck
+	return format.CheckClientVersion(mp.MinClientVersion, BuildVersion)
+}
+
+func clientVersionMonitor(fmgr *format.Manager, st blob.Storage) blob.Storage {
+	return beforeop.NewWrapper(st, nil, nil,
+		func(ctx context.Context) error {
+			return checkClientVersion(ctx, fmgr)
+		},
+		func(ctx context.Context, _ blob.ID, _ *blob.PutOptions) error {
+			return checkClientVersion(ctx, fmgr)
+		})
+}
+
 func wrapLockingStorage(st blob.Storage, r format.BlobStorageConfiguration) blob.Storage {
 	// collect prefixes that need to be locked on put
 	prefixes := GetLockingStoragePrefixes()
@@ -69,6 +69,7 @@ type DirectRepository interface {
 
 	ObjectFormat() format.ObjectFormat
 	FormatManager() *format.Manager
+	BaseRepository() DirectRepository
 	BlobReader() blob.Reader
 	BlobVolume() blob.Volume
 	ContentReader() content.Reader
@@ -107,6 +108,7 @@ type immutableDirectRepositoryParameters struct {
 	throttler       throttling.SettableThrottler
 	metricsRegistry *metrics.Registry
 	beforeFlush     []RepositoryWriterCallback
+	base            DirectRepository
 
 	*refCountedCloser
 }
@@ -138,9 +140,10 @@ type directRepository struct {
 }
 
 // DeriveKey derives encryption key of the provided length from the master key.
+// Derived keys do not change when the master key is rotated.
 func (r *directRepository) DeriveKey(purpose []byte, keyLength int) []byte {
 	if r.cmgr.ContentFormat().SupportsPasswordChange() {
-		return crypto.DeriveKeyFromMasterKey(r.cmgr.ContentFormat().GetMasterKey(), r.UniqueID(), purpose, keyLength)
+		return crypto.DeriveKeyFromMasterKey(r.cmgr.ContentFormat().GetKeyDerivationKey(), r.UniqueID(), purpose, keyLength)
 	}
 
 	// version of kopia <v0.9 had a bug where certain keys were derived directly from
@@ -377,6 +380,11 @@ func (r *directRepository) FormatManager() *format.Manager {
 	return r.fmgr
 }
 
+// BaseRepository returns the linked base repository whose contents are referenced, nil if not linked.
+func (r *directRepository) BaseRepository() DirectRepository {
+	return r.base
+}
+
 // OnSuccessfulFlush registers the provided callback to be invoked after flush succeeds.
 func (r *directRepository) OnSuccessfulFlush(callback RepositoryWriterCallback) {
 	r.afterFlush = append(r.afterFlush, callback)
@@ -954,3 +954,139 @@ func mustParseObjectID(t *testing.T, s string) object.ID {
 
 	return id
 }
+
+func TestSeparateDataKey(t *testing.T) {
+	const dataPassword = "data-password"
+
+	ctx, env := repotesting.NewEnvironment(t, format.FormatVersion3, repotesting.Options{
+		NewRepositoryOptions: func(nro *repo.NewRepositoryOptions) {
+			nro.DataPassword = dataPassword
+		},
+		OpenOptions: func(o *repo.Options) {
+			o.DataPassword = dataPassword
+		},
+	})
+
+	data := bytes.Repeat([]byte{1, 2, 3, 4}, 1000)
+	metadata := bytes.Repeat([]byte{5, 6, 7, 8}, 1000)
+
+	dataOID := writeObject(ctx, t, env.RepositoryWriter, data, "data")
+
+	w := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{Prefix: "k"})
+	_, err := w.Write(metadata)
+	require.NoError(t, err)
+
+	metadataOID, err := w.Result()
+	require.NoError(t, err)
+
+	manID, err := env.RepositoryWriter.PutManifest(ctx, map[string]string{"type": "test"}, "some-manifest")
+	require.NoError(t, err)
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	verify(ctx, t, env.RepositoryWriter, dataOID, data, "data")
+	verify(ctx, t, env.RepositoryWriter, metadataOID, metadata, "metadata")
+
+	// wrong data password prevents the repository from opening.
+	_, err = repo.Open(ctx, env.ConfigFile(), env.Password, &repo.Options{DataPassword: "wrong"})
+	require.ErrorIs(t, err, format.ErrInvalidDataPassword)
+
+	// without the data password, metadata can be accessed but data contents can't be read or written.
+	restricted := env.MustOpenAnother(t)
+
+	verify(ctx, t, restricted, metadataOID, metadata, "metadata")
+
+	var man string
+
+	_, err = restricted.GetManifest(ctx, manID, &man)
+	require.NoError(t, err)
+	require.Equal(t, "some-manifest", man)
+
+	r, err := restricted.OpenObject(ctx, dataOID)
+	if err == nil {
+		_, err = io.ReadAll(r)
+	}
+
+	require.ErrorIs(t, err, content.ErrDataKeyUnavailable)
+
+	w = restricted.NewObjectWriter(ctx, object.WriterOptions{})
+	_, err = w.Write([]byte{9, 9, 9})
+	require.NoError(t, err)
+
+	_, err = w.Result()
+	require.ErrorIs(t, err, content.ErrDataKeyUnavailable)
+
+	// maintenance can rewrite data contents by copying them without decrypting.
+	cid, _, ok := dataOID.ContentID()
+	require.True(t, ok)
+
+	ci0, err := restricted.ContentInfo(ctx, cid)
+	require.NoError(t, err)
+
+	rdw := restricted.(repo.DirectRepositoryWriter)
+	require.NoError(t, rdw.ContentManager().RewriteContent(ctx, cid))
+	require.NoError(t, rdw.Flush(ctx))
+
+	env.MustReopen(t, func(o *repo.Options) {
+		o.DataPassword = dataPassword
+	})
+
+	ci, err := env.RepositoryWriter.ContentInfo(ctx, cid)
+	require.NoError(t, err)
+	require.NotEqual(t, ci0.GetPackBlobID(), ci.GetPackBlobID())
+
+	verify(ctx, t, env.RepositoryWriter, dataOID, data, "data")
+
+	// data key is independent of the repository master key.
+	mk := env.RepositoryWriter.ContentReader().ContentFormat().GetMasterKey()
+	dk, err := env.RepositoryWriter.ContentReader().ContentFormat().GetDataKey().DecryptKey(dataPassword)
+	require.NoError(t, err)
+	require.NotEqual(t, mk, dk)
+}
+
+//nolint:paralleltest
+func TestMinClientVersion(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	fm := env.RepositoryWriter.FormatManager()
+
+	mp, err := fm.GetMutableParameters(ctx)
+	require.NoError(t, err)
+
+	blobcfg, err := fm.BlobCfgBlob(ctx)
+	require.NoError(t, err)
+
+	required, err := fm.RequiredFeatures(ctx)
+	require.NoError(t, err)
+
+	mp.MinClientVersion = "0.10.0"
+	require.NoError(t, fm.SetParameters(ctx, mp, blobcfg, format.WithMinClientVersionFeature(required, mp.MinClientVersion)))
+
+	oldVersion := repo.BuildVersion
+	defer func() { repo.BuildVersion = oldVersion }()
+
+	// outdated client can open the repository, but not write to it.
+	repo.BuildVersion = "0.9.5"
+
+	env.MustReopen(t)
+
+	writer := env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{})
+	_, err = writer.Write([]byte("the quick brown fox jumps over the lazy dog"))
+	require.NoError(t, err)
+
+	_, err = writer.Result()
+	require.ErrorIs(t, err, format.ErrClientVersionTooOld)
+
+	repo.BuildVersion = "v0.10.1"
+
+	env.MustReopen(t)
+
+	writer = env.RepositoryWriter.NewObjectWriter(ctx, object.WriterOptions{})
+	_, err = writer.Write([]byte("the quick brown fox jumps over the lazy dog"))
+	require.NoError(t, err)
+
+	_, err = writer.Result()
+	require.NoError(t, err)
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+}
@@ -1,6 +1,8 @@
 package repo
 
 import (
+	"time"
+
 	"github.com/kopia/kopia/internal/cache"
 	"github.com/kopia/kopia/internal/metrics"
 	"github.com/kopia/kopia/repo/format"
@@ -15,10 +17,16 @@ type immutableServerRepositoryParameters struct {
 	metricsRegistry *metrics.Registry
 	contentCache    *cache.PersistentCache
 	beforeFlush     []RepositoryWriterCallback
+	timeNow         func() time.Time
 
 	*refCountedCloser
 }
 
+// Time returns the current time according to the clock used by the repository.
+func (r *immutableServerRepositoryParameters) Time() time.Time {
+	return r.timeNow()
+}
+
 // Metrics provides access to the metrics registry.
 func (r *immutableServerRepositoryParameters) Metrics() *metrics.Registry {
 	return r.metricsRegistry
@@ -0,0 +1,54 @@
+package snapshot
+
+import "sync"
+
+// MaxClassifications is the maximum number of classification tags retained in a snapshot manifest.
+const MaxClassifications = 100
+
+// ClassificationBuilder accumulates classification tags of files and builds per-tag totals.
+// It is safe for concurrent use.
+type ClassificationBuilder struct {
+	mu sync.Mutex
+
+	// +checklocks:mu
+	tags map[string]*CategoryTotals
+}
+
+// AddFile records the file of a given size with the provided classification tags.
+func (b *ClassificationBuilder) AddFile(tags []string, size int64) {
+	if len(tags) == 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tags == nil {
+		b.tags = map[string]*CategoryTotals{}
+	}
+
+	seen := map[string]bool{}
+
+	for _, t := range tags {
+		if t == "" || seen[t] {
+			continue
+		}
+
+		seen[t] = true
+
+		addToCategory(b.tags, t, size)
+	}
+}
+
+// Build returns totals of files with each classification tag, ordered by decreasing total size,
+// or nil if no files have been classified.
+func (b *ClassificationBuilder) Build() []*CategoryTotals {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.tags) == 0 {
+		return nil
+	}
+
+	return topCategories(b.tags, MaxClassifications)
+}
@@ -0,0 +1,26 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestClassificationBuilder(t *testing.T) {
+	var b snapshot.ClassificationBuilder
+
+	b.AddFile(nil, 100)
+	require.Nil(t, b.Build())
+
+	b.AddFile([]string{"media"}, 100)
+	b.AddFile([]string{"source-code", "contains-PII", "source-code", ""}, 10)
+	b.AddFile([]string{"contains-PII"}, 5)
+
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: "media", Count: 1, TotalSize: 100},
+		{Name: "contains-PII", Count: 2, TotalSize: 15},
+		{Name: "source-code", Count: 1, TotalSize: 10},
+	}, b.Build())
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsortedDirEntries is returned when directory entries are not in canonical order.
+var ErrUnsortedDirEntries = errors.New("directory entries are not in canonical order")
+
+// CompareDirEntries defines the canonical order of entries in a directory manifest.
+// Directories are sorted before all other entries and within each group entries are ordered
+// byte-wise by their UTF-8 names, which preserves case and does not apply any normalization.
+// Returns a negative number when a < b, zero when a == b and a positive number when a > b.
+func CompareDirEntries(a, b *DirEntry) int {
+	if aDir, bDir := a.Type == EntryTypeDirectory, b.Type == EntryTypeDirectory; aDir != bDir {
+		if aDir {
+			return -1
+		}
+
+		return 1
+	}
+
+	return strings.Compare(a.Name, b.Name)
+}
+
+// SortDirEntries sorts the provided entries in canonical order.
+func SortDirEntries(entries []*DirEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return CompareDirEntries(entries[i], entries[j]) < 0
+	})
+}
+
+// ValidateDirEntriesOrder ensures that entries are in strictly increasing canonical order,
+// which also guarantees that there are no duplicate entries.
+func ValidateDirEntriesOrder(entries []*DirEntry) error {
+	for i := 1; i < len(entries); i++ {
+		if CompareDirEntries(entries[i-1], entries[i]) >= 0 {
+			return errors.Wrapf(ErrUnsortedDirEntries, "entry %q at position %v", entries[i].Name, i)
+		}
+	}
+
+	return nil
+}
+
+// FindDirEntry uses binary search to find an entry with a given name among entries
+// sorted in canonical order. Returns nil if not found.
+func FindDirEntry(entries []*DirEntry, name string) *DirEntry {
+	// index of first non-directory entry.
+	firstNonDir := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Type != EntryTypeDirectory
+	})
+
+	if e := findByName(entries[0:firstNonDir], name); e != nil {
+		return e
+	}
+
+	return findByName(entries[firstNonDir:], name)
+}
+
+func findByName(entries []*DirEntry, name string) *DirEntry {
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name >= name
+	})
+
+	if i < len(entries) && entries[i].Name == name {
+		return entries[i]
+	}
+
+	return nil
+}
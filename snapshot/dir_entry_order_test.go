@@ -0,0 +1,54 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestSortDirEntries(t *testing.T) {
+	entries := []*snapshot.DirEntry{
+		{Name: "b", Type: snapshot.EntryTypeFile},
+		{Name: "a", Type: snapshot.EntryTypeFile},
+		{Name: "B", Type: snapshot.EntryTypeFile},
+		{Name: "z", Type: snapshot.EntryTypeDirectory},
+		{Name: "é", Type: snapshot.EntryTypeFile},
+		{Name: "A", Type: snapshot.EntryTypeDirectory},
+		{Name: "s", Type: snapshot.EntryTypeSymlink},
+	}
+
+	require.ErrorIs(t, snapshot.ValidateDirEntriesOrder(entries), snapshot.ErrUnsortedDirEntries)
+
+	snapshot.SortDirEntries(entries)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+
+	// directories first, then byte-wise ordering of UTF-8 names.
+	require.Equal(t, []string{"A", "z", "B", "a", "b", "s", "é"}, names)
+	require.NoError(t, snapshot.ValidateDirEntriesOrder(entries))
+
+	for _, e := range entries {
+		require.Equal(t, e, snapshot.FindDirEntry(entries, e.Name))
+	}
+
+	require.Nil(t, snapshot.FindDirEntry(entries, "no-such-entry"))
+	require.Nil(t, snapshot.FindDirEntry(nil, "a"))
+}
+
+func TestValidateDirEntriesOrder_Duplicates(t *testing.T) {
+	require.ErrorIs(t, snapshot.ValidateDirEntriesOrder([]*snapshot.DirEntry{
+		{Name: "a", Type: snapshot.EntryTypeFile},
+		{Name: "a", Type: snapshot.EntryTypeFile},
+	}), snapshot.ErrUnsortedDirEntries)
+
+	// same name is allowed for a directory and a non-directory since they are in different groups.
+	require.NoError(t, snapshot.ValidateDirEntriesOrder([]*snapshot.DirEntry{
+		{Name: "a", Type: snapshot.EntryTypeDirectory},
+		{Name: "a", Type: snapshot.EntryTypeFile},
+	}))
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"sort"
+	"sync"
+)
+
+// MaxDirectoryUploadStats is the maximum number of top-level directories with upload statistics retained in a snapshot manifest.
+const MaxDirectoryUploadStats = 100
+
+// DirectoryUploadStats represents the number of bytes of files in a top-level directory which were newly written
+// to the repository and which were already present in it, either because the file was unchanged or its contents
+// were deduplicated.
+type DirectoryUploadStats struct {
+	Path              string `json:"path"`
+	NewBytes          int64  `json:"newBytes"`
+	DeduplicatedBytes int64  `json:"deduplicatedBytes"`
+}
+
+// DirectoryUploadStatsBuilder accumulates per-directory upload statistics. It is safe for concurrent use.
+type DirectoryUploadStatsBuilder struct {
+	mu sync.Mutex
+
+	// +checklocks:mu
+	dirs map[string]*DirectoryUploadStats
+}
+
+// Add records the provided number of bytes of a file with a given relative path.
+func (b *DirectoryUploadStatsBuilder) Add(relativePath string, numBytes int64, deduplicated bool) {
+	dir := topLevelDirectory(relativePath)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.dirs == nil {
+		b.dirs = map[string]*DirectoryUploadStats{}
+	}
+
+	s := b.dirs[dir]
+	if s == nil {
+		s = &DirectoryUploadStats{Path: dir}
+		b.dirs[dir] = s
+	}
+
+	if deduplicated {
+		s.DeduplicatedBytes += numBytes
+	} else {
+		s.NewBytes += numBytes
+	}
+}
+
+// Build returns statistics of directories ordered by decreasing number of new bytes, or nil if nothing
+// has been recorded.
+func (b *DirectoryUploadStatsBuilder) Build() []*DirectoryUploadStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.dirs) == 0 {
+		return nil
+	}
+
+	var result []*DirectoryUploadStats
+
+	for _, s := range b.dirs {
+		v := *s
+		result = append(result, &v)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if l, r := result[i].NewBytes, result[j].NewBytes; l != r {
+			return l > r
+		}
+
+		if l, r := result[i].DeduplicatedBytes, result[j].DeduplicatedBytes; l != r {
+			return l > r
+		}
+
+		return result[i].Path < result[j].Path
+	})
+
+	if len(result) > MaxDirectoryUploadStats {
+		result = result[:MaxDirectoryUploadStats]
+	}
+
+	return result
+}
@@ -0,0 +1,27 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestDirectoryUploadStatsBuilder(t *testing.T) {
+	var b snapshot.DirectoryUploadStatsBuilder
+
+	require.Nil(t, b.Build())
+
+	b.Add("file.txt", 5, false)
+	b.Add("photos/2024/a.jpg", 100, true)
+	b.Add("photos/b.jpg", 10, false)
+	b.Add("src/main.go", 10, false)
+	b.Add("src/lib/lib.go", 20, true)
+
+	require.Equal(t, []*snapshot.DirectoryUploadStats{
+		{Path: "photos", NewBytes: 10, DeduplicatedBytes: 100},
+		{Path: "src", NewBytes: 10, DeduplicatedBytes: 20},
+		{Path: ".", NewBytes: 5},
+	}, b.Build())
+}
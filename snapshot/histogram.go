@@ -0,0 +1,170 @@
+package snapshot
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Limits on the number of categories retained in snapshot histograms.
+const (
+	MaxHistogramExtensions     = 20
+	MaxHistogramTopDirectories = 10
+)
+
+// fileSizeBucketLimits are upper bounds (inclusive) of file size histogram buckets.
+// Files larger than the last limit are counted in an additional bucket with MaxSize == -1 (no upper bound).
+//
+//nolint:gochecknoglobals
+var fileSizeBucketLimits = []int64{
+	0,
+	1 << 10,
+	16 << 10,
+	256 << 10,
+	1 << 20,
+	16 << 20,
+	256 << 20,
+	1 << 30,
+}
+
+// Histograms describes the distribution of files in a snapshot, useful for capacity planning.
+type Histograms struct {
+	FileSizes      []*SizeBucket     `json:"fileSizes,omitempty"`
+	Extensions     []*CategoryTotals `json:"extensions,omitempty"`
+	TopDirectories []*CategoryTotals `json:"topDirectories,omitempty"`
+}
+
+// SizeBucket represents the number and total size of files whose size falls into a particular range.
+type SizeBucket struct {
+	// MinSize is the exclusive lower bound of file sizes in the bucket, MaxSize is the inclusive upper bound.
+	// MaxSize == -1 means no upper bound.
+	MinSize   int64 `json:"minSize"`
+	MaxSize   int64 `json:"maxSize"`
+	Count     int64 `json:"count"`
+	TotalSize int64 `json:"totalSize"`
+}
+
+// CategoryTotals represents the number and total size of files in a category (such as file extension or directory).
+type CategoryTotals struct {
+	Name      string `json:"name"`
+	Count     int64  `json:"count"`
+	TotalSize int64  `json:"totalSize"`
+}
+
+// HistogramBuilder accumulates file information and builds Histograms. It is safe for concurrent use.
+type HistogramBuilder struct {
+	mu sync.Mutex
+
+	// +checklocks:mu
+	sizes []SizeBucket
+	// +checklocks:mu
+	extensions map[string]*CategoryTotals
+	// +checklocks:mu
+	topDirectories map[string]*CategoryTotals
+}
+
+// AddFile records the file with a given relative path and size.
+func (b *HistogramBuilder) AddFile(relativePath string, size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sizes == nil {
+		b.sizes = make([]SizeBucket, len(fileSizeBucketLimits)+1)
+		b.extensions = map[string]*CategoryTotals{}
+		b.topDirectories = map[string]*CategoryTotals{}
+	}
+
+	bucket := sort.Search(len(fileSizeBucketLimits), func(i int) bool {
+		return size <= fileSizeBucketLimits[i]
+	})
+
+	b.sizes[bucket].Count++
+	b.sizes[bucket].TotalSize += size
+
+	addToCategory(b.extensions, strings.ToLower(path.Ext(relativePath)), size)
+
+	addToCategory(b.topDirectories, topLevelDirectory(relativePath), size)
+}
+
+// topLevelDirectory returns the name of the top-level directory containing the file with a given relative path,
+// or "." for files in the root directory.
+func topLevelDirectory(relativePath string) string {
+	if dir, _, ok := strings.Cut(relativePath, "/"); ok {
+		return dir
+	}
+
+	return "."
+}
+
+// Build returns Histograms for all files added so far or nil if no files have been added.
+func (b *HistogramBuilder) Build() *Histograms {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sizes == nil {
+		return nil
+	}
+
+	h := &Histograms{
+		Extensions:     topCategories(b.extensions, MaxHistogramExtensions),
+		TopDirectories: topCategories(b.topDirectories, MaxHistogramTopDirectories),
+	}
+
+	for i, s := range b.sizes {
+		if s.Count == 0 {
+			continue
+		}
+
+		bucket := s
+
+		bucket.MinSize = -1
+		if i > 0 {
+			bucket.MinSize = fileSizeBucketLimits[i-1]
+		}
+
+		bucket.MaxSize = -1
+		if i < len(fileSizeBucketLimits) {
+			bucket.MaxSize = fileSizeBucketLimits[i]
+		}
+
+		h.FileSizes = append(h.FileSizes, &bucket)
+	}
+
+	return h
+}
+
+func addToCategory(m map[string]*CategoryTotals, name string, size int64) {
+	c := m[name]
+	if c == nil {
+		c = &CategoryTotals{Name: name}
+		m[name] = c
+	}
+
+	c.Count++
+	c.TotalSize += size
+}
+
+// topCategories returns up to maxCount categories with the largest total size.
+func topCategories(m map[string]*CategoryTotals, maxCount int) []*CategoryTotals {
+	var result []*CategoryTotals
+
+	for _, c := range m {
+		v := *c
+		result = append(result, &v)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if l, r := result[i].TotalSize, result[j].TotalSize; l != r {
+			return l > r
+		}
+
+		return result[i].Name < result[j].Name
+	})
+
+	if len(result) > maxCount {
+		result = result[:maxCount]
+	}
+
+	return result
+}
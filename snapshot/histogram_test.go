@@ -0,0 +1,56 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestHistogramBuilder(t *testing.T) {
+	var b snapshot.HistogramBuilder
+
+	require.Nil(t, b.Build())
+
+	b.AddFile("a/x.TXT", 0)
+	b.AddFile("a/y.txt", 1000)
+	b.AddFile("b/c/z.jpg", 2<<20)
+	b.AddFile("top.jpg", 5<<30)
+
+	h := b.Build()
+
+	require.Equal(t, []*snapshot.SizeBucket{
+		{MinSize: -1, MaxSize: 0, Count: 1, TotalSize: 0},
+		{MinSize: 0, MaxSize: 1 << 10, Count: 1, TotalSize: 1000},
+		{MinSize: 1 << 20, MaxSize: 16 << 20, Count: 1, TotalSize: 2 << 20},
+		{MinSize: 1 << 30, MaxSize: -1, Count: 1, TotalSize: 5 << 30},
+	}, h.FileSizes)
+
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: ".jpg", Count: 2, TotalSize: 5<<30 + 2<<20},
+		{Name: ".txt", Count: 2, TotalSize: 1000},
+	}, h.Extensions)
+
+	require.Equal(t, []*snapshot.CategoryTotals{
+		{Name: ".", Count: 1, TotalSize: 5 << 30},
+		{Name: "b", Count: 1, TotalSize: 2 << 20},
+		{Name: "a", Count: 2, TotalSize: 1000},
+	}, h.TopDirectories)
+}
+
+func TestHistogramBuilder_TopCategoriesLimit(t *testing.T) {
+	var b snapshot.HistogramBuilder
+
+	for i := 0; i < 100; i++ {
+		b.AddFile(fmt.Sprintf("dir%v/file.ext%v", i, i), int64(i))
+	}
+
+	h := b.Build()
+
+	require.Len(t, h.Extensions, snapshot.MaxHistogramExtensions)
+	require.Len(t, h.TopDirectories, snapshot.MaxHistogramTopDirectories)
+	require.Equal(t, "dir99", h.TopDirectories[0].Name)
+	require.Equal(t, ".ext99", h.Extensions[0].Name)
+}
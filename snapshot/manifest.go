@@ -25,6 +25,21 @@ type Manifest struct {
 	Stats            Stats  `json:"stats,omitempty"`
 	IncompleteReason string `json:"incomplete,omitempty"`
 
+	// when not empty, the snapshot only contains the subtree of the source at the given slash-separated relative path.
+	Subpath string `json:"subpath,omitempty"`
+
+	// distribution of file sizes, extensions and top-level directories.
+	Histograms *Histograms `json:"histograms,omitempty"`
+
+	// number and total size of files with each classification tag assigned during upload.
+	Classifications []*CategoryTotals `json:"classifications,omitempty"`
+
+	// identifies the configuration of classifiers which assigned classifications.
+	ClassifiersID string `json:"classifiersID,omitempty"`
+
+	// number of new and deduplicated bytes of files in each top-level directory.
+	DirectoryUploadStats []*DirectoryUploadStats `json:"directoryUploadStats,omitempty"`
+
 	RootEntry *DirEntry `json:"rootEntry"`
 
 	RetentionReasons []string `json:"-"`
@@ -36,6 +51,15 @@ type Manifest struct {
 
 	// list of manually-defined pins which prevent the snapshot from being deleted.
 	Pins []string `json:"pins,omitempty"`
+
+	// descriptions of suspicious changes detected when the snapshot was created.
+	Anomalies []string `json:"anomalies,omitempty"`
+
+	// object holding human-readable descriptions of directory objects written by the snapshot.
+	ObjectDescriptions *object.ID `json:"objectDescriptions,omitempty"`
+
+	// object holding the list of all errors encountered when the snapshot was created.
+	ErrorManifest *object.ID `json:"errorManifest,omitempty"`
 }
 
 // UpdatePins updates pins in the provided manifest.
@@ -125,8 +149,65 @@ type DirEntry struct {
 	ModTime     fs.UTCTimestamp      `json:"mtime,omitempty"`
 	UserID      uint32               `json:"uid,omitempty"`
 	GroupID     uint32               `json:"gid,omitempty"`
+	UserName    string               `json:"user,omitempty"`
+	GroupName   string               `json:"group,omitempty"`
 	ObjectID    object.ID            `json:"obj,omitempty"`
 	DirSummary  *fs.DirectorySummary `json:"summ,omitempty"`
+	MacOS       *MacOSMetadata       `json:"macos,omitempty"`
+	LinkType    string               `json:"linkType,omitempty"` // type of symlink entries which aren't symbolic links, such as fs.LinkTypeJunction
+	ChangeTime  fs.UTCTimestamp      `json:"ctime,omitempty"`    // only recorded when used for change detection
+
+	// Classifications of the file assigned during upload, reused for unchanged files in subsequent snapshots.
+	Classifications []string `json:"cls,omitempty"`
+}
+
+// MacOSMetadata represents macOS-specific metadata of a directory entry.
+type MacOSMetadata struct {
+	BirthTime        fs.UTCTimestamp `json:"btime,omitempty"`
+	Flags            uint32          `json:"flags,omitempty"`
+	FinderInfo       []byte          `json:"finderInfo,omitempty"`
+	Quarantine       string          `json:"quarantine,omitempty"`
+	ResourceFork     *object.ID      `json:"rsrc,omitempty"`
+	ResourceForkSize int64           `json:"rsrcSize,omitempty"`
+}
+
+// ToFS returns the metadata as fs.MacOSMetadata.
+func (m *MacOSMetadata) ToFS() *fs.MacOSMetadata {
+	if m == nil {
+		return nil
+	}
+
+	result := &fs.MacOSMetadata{
+		Flags:            m.Flags,
+		FinderInfo:       m.FinderInfo,
+		Quarantine:       m.Quarantine,
+		ResourceForkSize: m.ResourceForkSize,
+	}
+
+	if m.BirthTime != 0 {
+		result.BirthTime = m.BirthTime.ToTime()
+	}
+
+	return result
+}
+
+// MacOSMetadataFromFS converts fs.MacOSMetadata into MacOSMetadata, returns nil if there's nothing to store.
+func MacOSMetadataFromFS(md *fs.MacOSMetadata) *MacOSMetadata {
+	if md.IsEmpty() {
+		return nil
+	}
+
+	result := &MacOSMetadata{
+		Flags:      md.Flags,
+		FinderInfo: md.FinderInfo,
+		Quarantine: md.Quarantine,
+	}
+
+	if !md.BirthTime.IsZero() {
+		result.BirthTime = fs.UTCTimestampFromTime(md.BirthTime)
+	}
+
+	return result
 }
 
 // Clone returns a clone of the entry.
@@ -139,6 +220,12 @@ func (e *DirEntry) Clone() *DirEntry {
 		e2.DirSummary = &s2
 	}
 
+	if m := e2.MacOS; m != nil {
+		m2 := *m
+
+		e2.MacOS = &m2
+	}
+
 	return &e2
 }
 
@@ -154,12 +241,16 @@ type HasDirEntryOrNil interface {
 }
 
 // DirManifest represents serialized contents of a directory.
-// The entries are sorted lexicographically and summary only refers to properties of
+// The entries are sorted in canonical order (see CompareDirEntries) and summary only refers to properties of
 // entries, so directory with the same contents always serializes to exactly the same JSON.
 type DirManifest struct {
 	StreamType string               `json:"stream"` // legacy
 	Entries    []*DirEntry          `json:"entries"`
 	Summary    *fs.DirectorySummary `json:"summary"`
+
+	// Tombstones optionally describes entries which were present in the previous snapshot of the directory
+	// but have since been deleted. Tombstones don't reference any objects.
+	Tombstones []*DirEntry `json:"tombstones,omitempty"`
 }
 
 // RootObjectID returns the ID of a root object.
@@ -236,6 +327,25 @@ func GroupBySource(manifests []*Manifest) [][]*Manifest {
 	return result
 }
 
+// GroupBySubpath returns a slice of slices, such that each result item contains manifests with the same Subpath.
+func GroupBySubpath(manifests []*Manifest) [][]*Manifest {
+	resultMap := map[string][]*Manifest{}
+	for _, m := range manifests {
+		resultMap[m.Subpath] = append(resultMap[m.Subpath], m)
+	}
+
+	var result [][]*Manifest
+	for _, v := range resultMap {
+		result = append(result, v)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i][0].Subpath < result[j][0].Subpath
+	})
+
+	return result
+}
+
 // SortByTime returns a slice of manifests sorted by start time.
 func SortByTime(manifests []*Manifest, reverse bool) []*Manifest {
 	result := append([]*Manifest(nil), manifests...)
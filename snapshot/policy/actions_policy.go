@@ -11,12 +11,16 @@ type ActionsPolicy struct {
 	// commands run once before and after each snapshot root (can be inherited).
 	BeforeSnapshotRoot *ActionCommand `json:"beforeSnapshotRoot,omitempty"`
 	AfterSnapshotRoot  *ActionCommand `json:"afterSnapshotRoot,omitempty"`
+
+	// command runs when an anomaly is detected in a snapshot of the source (can be inherited).
+	SnapshotAnomaly *ActionCommand `json:"snapshotAnomaly,omitempty"`
 }
 
 // ActionsPolicyDefinition specifies which policy definition provided the value of a particular field.
 type ActionsPolicyDefinition struct {
 	BeforeSnapshotRoot snapshot.SourceInfo `json:"beforeSnapshotRoot,omitempty"`
 	AfterSnapshotRoot  snapshot.SourceInfo `json:"afterSnapshotRoot,omitempty"`
+	SnapshotAnomaly    snapshot.SourceInfo `json:"snapshotAnomaly,omitempty"`
 }
 
 // ActionCommand configures a action command.
@@ -36,6 +40,7 @@ type ActionCommand struct {
 func (p *ActionsPolicy) Merge(src ActionsPolicy, def *ActionsPolicyDefinition, si snapshot.SourceInfo) {
 	mergeActionCommand(&p.BeforeSnapshotRoot, src.BeforeSnapshotRoot, &def.BeforeSnapshotRoot, si)
 	mergeActionCommand(&p.AfterSnapshotRoot, src.AfterSnapshotRoot, &def.AfterSnapshotRoot, si)
+	mergeActionCommand(&p.SnapshotAnomaly, src.SnapshotAnomaly, &def.SnapshotAnomaly, si)
 }
 
 // MergeNonInheritable copies non-inheritable properties from the provided actions policy.
@@ -0,0 +1,95 @@
+package policy
+
+import "github.com/kopia/kopia/snapshot"
+
+// AnomalyAction specifies what happens when a snapshot with suspicious changes is detected.
+type AnomalyAction string
+
+// Supported anomaly actions.
+const (
+	AnomalyActionNone    AnomalyAction = "none"    // do not analyze snapshots
+	AnomalyActionWarn    AnomalyAction = "warn"    // log a warning and mark the snapshot as anomalous
+	AnomalyActionConfirm AnomalyAction = "confirm" // ask the user before saving the snapshot
+	AnomalyActionNotify  AnomalyAction = "notify"  // mark the snapshot and run the snapshot-anomaly action command
+)
+
+// SupportedAnomalyActions is a list of supported anomaly actions.
+//
+//nolint:gochecknoglobals
+var SupportedAnomalyActions = []AnomalyAction{
+	AnomalyActionNone,
+	AnomalyActionWarn,
+	AnomalyActionConfirm,
+	AnomalyActionNotify,
+}
+
+// NewAnomalyAction provides an AnomalyAction pointer.
+func NewAnomalyAction(a AnomalyAction) *AnomalyAction {
+	return &a
+}
+
+// OrDefault returns the anomaly action or the provided default.
+func (a *AnomalyAction) OrDefault(def AnomalyAction) AnomalyAction {
+	if a == nil {
+		return def
+	}
+
+	return *a
+}
+
+// AnomalyDetectionPolicy describes how snapshots are analyzed for changes indicating ransomware activity,
+// such as mass modifications of files, files whose contents suddenly look encrypted and renamed extensions,
+// and for changes exceeding the expected change rate of the source.
+type AnomalyDetectionPolicy struct {
+	RansomwareAction *AnomalyAction `json:"ransomwareAction,omitempty"`
+
+	// the analysis is skipped for sources with fewer files in the previous snapshot.
+	MinFiles *OptionalInt `json:"minFiles,omitempty"`
+
+	// maximum number of modified files whose contents are sampled to detect entropy changes.
+	SampleFiles *OptionalInt `json:"sampleFiles,omitempty"`
+
+	// percentages above which the individual indicators are reported.
+	ChangedFilesPercent     *OptionalInt `json:"changedFilesPercent,omitempty"`
+	EntropyIncreasePercent  *OptionalInt `json:"entropyIncreasePercent,omitempty"`
+	ExtensionChangesPercent *OptionalInt `json:"extensionChangesPercent,omitempty"`
+
+	// action taken when a snapshot exceeds the expected size of new or modified files or number of changed files.
+	ChangeRateAction *AnomalyAction `json:"changeRateAction,omitempty"`
+	MaxNewBytes      *OptionalInt64 `json:"maxNewBytes,omitempty"`
+	MaxChangedFiles  *OptionalInt   `json:"maxChangedFiles,omitempty"`
+}
+
+// AnomalyDetectionPolicyDefinition specifies which policy definition provided the value of a particular field.
+type AnomalyDetectionPolicyDefinition struct {
+	RansomwareAction        snapshot.SourceInfo `json:"ransomwareAction,omitempty"`
+	MinFiles                snapshot.SourceInfo `json:"minFiles,omitempty"`
+	SampleFiles             snapshot.SourceInfo `json:"sampleFiles,omitempty"`
+	ChangedFilesPercent     snapshot.SourceInfo `json:"changedFilesPercent,omitempty"`
+	EntropyIncreasePercent  snapshot.SourceInfo `json:"entropyIncreasePercent,omitempty"`
+	ExtensionChangesPercent snapshot.SourceInfo `json:"extensionChangesPercent,omitempty"`
+	ChangeRateAction        snapshot.SourceInfo `json:"changeRateAction,omitempty"`
+	MaxNewBytes             snapshot.SourceInfo `json:"maxNewBytes,omitempty"`
+	MaxChangedFiles         snapshot.SourceInfo `json:"maxChangedFiles,omitempty"`
+}
+
+// Merge applies default values from the provided policy.
+func (p *AnomalyDetectionPolicy) Merge(src AnomalyDetectionPolicy, def *AnomalyDetectionPolicyDefinition, si snapshot.SourceInfo) {
+	mergeAnomalyAction(&p.RansomwareAction, src.RansomwareAction, &def.RansomwareAction, si)
+	mergeOptionalInt(&p.MinFiles, src.MinFiles, &def.MinFiles, si)
+	mergeOptionalInt(&p.SampleFiles, src.SampleFiles, &def.SampleFiles, si)
+	mergeOptionalInt(&p.ChangedFilesPercent, src.ChangedFilesPercent, &def.ChangedFilesPercent, si)
+	mergeOptionalInt(&p.EntropyIncreasePercent, src.EntropyIncreasePercent, &def.EntropyIncreasePercent, si)
+	mergeOptionalInt(&p.ExtensionChangesPercent, src.ExtensionChangesPercent, &def.ExtensionChangesPercent, si)
+	mergeAnomalyAction(&p.ChangeRateAction, src.ChangeRateAction, &def.ChangeRateAction, si)
+	mergeOptionalInt64(&p.MaxNewBytes, src.MaxNewBytes, &def.MaxNewBytes, si)
+	mergeOptionalInt(&p.MaxChangedFiles, src.MaxChangedFiles, &def.MaxChangedFiles, si)
+}
+
+func mergeAnomalyAction(target **AnomalyAction, src *AnomalyAction, def *snapshot.SourceInfo, si snapshot.SourceInfo) {
+	if *target == nil && src != nil {
+		v := *src
+		*target = &v
+		*def = si
+	}
+}
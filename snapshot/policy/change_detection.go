@@ -0,0 +1,113 @@
+package policy
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+// ChangeDetectionMode specifies which attributes of files are compared with previous snapshots
+// to determine whether files have changed and need to be hashed again.
+type ChangeDetectionMode byte
+
+// Change detection modes.
+const (
+	ChangeDetectionModTimeAndSize ChangeDetectionMode = iota // modification time and size (default)
+	ChangeDetectionModTime                                   // modification time only
+	ChangeDetectionChangeTime                                // change time (ctime) and size, ignoring modification time
+	ChangeDetectionAlwaysHash                                // never reuse previous snapshots, always hash contents
+)
+
+// Change detection mode strings.
+const (
+	ChangeDetectionModTimeAndSizeString = "mtime-size"
+	ChangeDetectionModTimeString        = "mtime"
+	ChangeDetectionChangeTimeString     = "ctime"
+	ChangeDetectionAlwaysHashString     = "hash"
+)
+
+// ChangeDetectionModeStrings contains all valid change detection mode strings.
+//
+//nolint:gochecknoglobals
+var ChangeDetectionModeStrings = []string{
+	ChangeDetectionModTimeAndSizeString,
+	ChangeDetectionModTimeString,
+	ChangeDetectionChangeTimeString,
+	ChangeDetectionAlwaysHashString,
+}
+
+// NewChangeDetectionMode provides a ChangeDetectionMode pointer.
+func NewChangeDetectionMode(m ChangeDetectionMode) *ChangeDetectionMode {
+	return &m
+}
+
+// ParseChangeDetectionMode parses the change detection mode string.
+func ParseChangeDetectionMode(s string) (ChangeDetectionMode, bool) {
+	for i, v := range ChangeDetectionModeStrings {
+		if v == s {
+			return ChangeDetectionMode(i), true
+		}
+	}
+
+	return ChangeDetectionModTimeAndSize, false
+}
+
+// OrDefault returns the change detection mode or the provided default.
+func (m *ChangeDetectionMode) OrDefault(def ChangeDetectionMode) ChangeDetectionMode {
+	if m == nil {
+		return def
+	}
+
+	return *m
+}
+
+func (m ChangeDetectionMode) String() string {
+	if int(m) < len(ChangeDetectionModeStrings) {
+		return ChangeDetectionModeStrings[m]
+	}
+
+	return ChangeDetectionModTimeAndSizeString
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m ChangeDetectionMode) MarshalJSON() ([]byte, error) {
+	if int(m) >= len(ChangeDetectionModeStrings) {
+		return nil, errors.Errorf("invalid change detection mode: %d", m)
+	}
+
+	//nolint:wrapcheck
+	return json.Marshal(ChangeDetectionModeStrings[m])
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *ChangeDetectionMode) UnmarshalJSON(b []byte) error {
+	var s string
+
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.Wrap(err, "error unmarshaling change detection mode")
+	}
+
+	v, ok := ParseChangeDetectionMode(s)
+	if !ok {
+		return errors.Errorf("invalid change detection mode: %q", s)
+	}
+
+	*m = v
+
+	return nil
+}
+
+var (
+	_ json.Marshaler   = ChangeDetectionModTimeAndSize
+	_ json.Unmarshaler = (*ChangeDetectionMode)(nil)
+)
+
+func mergeChangeDetectionMode(target **ChangeDetectionMode, src *ChangeDetectionMode, def *snapshot.SourceInfo, si snapshot.SourceInfo) {
+	if *target == nil && src != nil {
+		v := *src
+		*target = &v
+		*def = si
+	}
+}
@@ -0,0 +1,33 @@
+package policy_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestChangeDetectionModeJSON(t *testing.T) {
+	for i, s := range policy.ChangeDetectionModeStrings {
+		m := policy.ChangeDetectionMode(i)
+
+		b, err := json.Marshal(policy.UploadPolicy{ChangeDetection: &m})
+		require.NoError(t, err)
+		require.JSONEq(t, `{"changeDetection":"`+s+`"}`, string(b))
+
+		var up policy.UploadPolicy
+
+		require.NoError(t, json.Unmarshal(b, &up))
+		require.Equal(t, m, up.ChangeDetection.OrDefault(policy.ChangeDetectionAlwaysHash))
+	}
+
+	var up policy.UploadPolicy
+
+	require.Error(t, json.Unmarshal([]byte(`{"changeDetection":"no-such-mode"}`), &up))
+	require.Error(t, json.Unmarshal([]byte(`{"changeDetection":1}`), &up))
+
+	_, err := json.Marshal(policy.ChangeDetectionMode(100))
+	require.Error(t, err)
+}
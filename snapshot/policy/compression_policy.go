@@ -3,6 +3,9 @@
 import (
 	"path/filepath"
 	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/repo/compression"
@@ -11,13 +14,50 @@
 
 // CompressionPolicy specifies compression policy.
 type CompressionPolicy struct {
-	CompressorName        compression.Name `json:"compressorName,omitempty"`
-	OnlyCompress          []string         `json:"onlyCompress,omitempty"`
-	NoParentOnlyCompress  bool             `json:"noParentOnlyCompress,omitempty"`
-	NeverCompress         []string         `json:"neverCompress,omitempty"`
-	NoParentNeverCompress bool             `json:"noParentNeverCompress,omitempty"`
-	MinSize               int64            `json:"minSize,omitempty"`
-	MaxSize               int64            `json:"maxSize,omitempty"`
+	CompressorName        compression.Name  `json:"compressorName,omitempty"`
+	OnlyCompress          []string          `json:"onlyCompress,omitempty"`
+	NoParentOnlyCompress  bool              `json:"noParentOnlyCompress,omitempty"`
+	NeverCompress         []string          `json:"neverCompress,omitempty"`
+	NoParentNeverCompress bool              `json:"noParentNeverCompress,omitempty"`
+	MinSize               int64             `json:"minSize,omitempty"`
+	MaxSize               int64             `json:"maxSize,omitempty"`
+	Rules                 []CompressionRule `json:"rules,omitempty"`
+	NoParentRules         bool              `json:"noParentRules,omitempty"`
+}
+
+// CompressionRule selects the compressor for files whose names match the pattern, such as '*.sql'.
+// Compressor "none" disables compression of matching files.
+type CompressionRule struct {
+	Pattern        string           `json:"pattern"`
+	CompressorName compression.Name `json:"compressor"`
+}
+
+// String returns the string representation of the rule as accepted by ParseCompressionRule.
+func (r CompressionRule) String() string {
+	return r.Pattern + ":" + string(r.CompressorName)
+}
+
+// ParseCompressionRule parses a compression rule in the form 'pattern:compressor'.
+func ParseCompressionRule(s string) (CompressionRule, error) {
+	p := strings.LastIndex(s, ":")
+	if p <= 0 || p == len(s)-1 {
+		return CompressionRule{}, errors.Errorf("invalid compression rule %q, expected 'pattern:compressor'", s)
+	}
+
+	r := CompressionRule{
+		Pattern:        s[0:p],
+		CompressorName: compression.Name(s[p+1:]),
+	}
+
+	if _, err := filepath.Match(r.Pattern, ""); err != nil {
+		return CompressionRule{}, errors.Wrapf(err, "invalid pattern in compression rule %q", s)
+	}
+
+	if _, ok := compression.ByName[r.CompressorName]; !ok && r.CompressorName != "none" {
+		return CompressionRule{}, errors.Errorf("unknown compressor in compression rule %q", s)
+	}
+
+	return r, nil
 }
 
 // CompressionPolicyDefinition specifies which policy definition provided the value of a particular field.
@@ -27,6 +67,7 @@ type CompressionPolicyDefinition struct {
 	NeverCompress  snapshot.SourceInfo `json:"neverCompress,omitempty"`
 	MinSize        snapshot.SourceInfo `json:"minSize,omitempty"`
 	MaxSize        snapshot.SourceInfo `json:"maxSize,omitempty"`
+	Rules          snapshot.SourceInfo `json:"rules,omitempty"`
 }
 
 // CompressorForFile returns compression name to be used for compressing a given file according to policy, using attributes such as name or size.
@@ -34,15 +75,26 @@ func (p *CompressionPolicy) CompressorForFile(e fs.Entry) compression.Name {
 	ext := filepath.Ext(e.Name())
 	size := e.Size()
 
-	if p.CompressorName == "none" {
+	if v := p.MinSize; v > 0 && size < v {
 		return ""
 	}
 
-	if v := p.MinSize; v > 0 && size < v {
+	if v := p.MaxSize; v > 0 && size > v {
 		return ""
 	}
 
-	if v := p.MaxSize; v > 0 && size > v {
+	// the first matching rule takes precedence over all other settings.
+	for _, r := range p.Rules {
+		if matched, _ := filepath.Match(r.Pattern, e.Name()); matched {
+			if r.CompressorName == "none" {
+				return ""
+			}
+
+			return r.CompressorName
+		}
+	}
+
+	if p.CompressorName == "none" {
 		return ""
 	}
 
@@ -65,6 +117,37 @@ func (p *CompressionPolicy) Merge(src CompressionPolicy, def *CompressionPolicyD
 
 	mergeStrings(&p.OnlyCompress, &p.NoParentOnlyCompress, src.OnlyCompress, src.NoParentOnlyCompress, &def.OnlyCompress, si)
 	mergeStrings(&p.NeverCompress, &p.NoParentNeverCompress, src.NeverCompress, src.NoParentNeverCompress, &def.NeverCompress, si)
+	mergeCompressionRules(&p.Rules, &p.NoParentRules, src.Rules, src.NoParentRules, &def.Rules, si)
+}
+
+// mergeCompressionRules appends rules of the parent policy after the rules defined so far,
+// so that rules defined closer to the target are evaluated first. Rules for patterns that
+// are already present are ignored.
+func mergeCompressionRules(target *[]CompressionRule, targetNoParent *bool, src []CompressionRule, noParent bool, def *snapshot.SourceInfo, si snapshot.SourceInfo) {
+	if *targetNoParent {
+		// merges prevented
+		return
+	}
+
+	existing := map[string]bool{}
+
+	for _, r := range *target {
+		existing[r.Pattern] = true
+	}
+
+	for _, r := range src {
+		if existing[r.Pattern] {
+			continue
+		}
+
+		*target = append(*target, r)
+		*def = si
+	}
+
+	if noParent {
+		// prevent future merges.
+		*targetNoParent = noParent
+	}
 }
 
 func isInSortedSlice(s string, slice []string) bool {
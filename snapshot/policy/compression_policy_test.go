@@ -0,0 +1,65 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/repo/compression"
+)
+
+func TestCompressorForFile_Rules(t *testing.T) {
+	dir := mockfs.NewDirectory()
+
+	p := &CompressionPolicy{
+		CompressorName: "zstd",
+		NeverCompress:  []string{".sql"},
+		MinSize:        2,
+		Rules: []CompressionRule{
+			{Pattern: "*.sql", CompressorName: "zstd-better-compression"},
+			{Pattern: "*.mp4", CompressorName: "none"},
+			{Pattern: "dump-*", CompressorName: "s2-default"},
+		},
+	}
+
+	cases := []struct {
+		name string
+		size int
+		want compression.Name
+	}{
+		{"db.sql", 10, "zstd-better-compression"},
+		{"movie.mp4", 10, ""},
+		{"dump-1.sql", 10, "zstd-better-compression"},
+		{"dump-1.txt", 10, "s2-default"},
+		{"file.txt", 10, "zstd"},
+		{"tiny.sql", 1, ""},
+	}
+
+	for _, tc := range cases {
+		f := dir.AddFile(tc.name, make([]byte, tc.size), 0o644)
+		require.Equal(t, tc.want, p.CompressorForFile(f), tc.name)
+	}
+
+	// rules can enable compression even if it's disabled otherwise.
+	p.CompressorName = "none"
+
+	require.Equal(t, compression.Name("zstd-better-compression"), p.CompressorForFile(dir.AddFile("x.sql", make([]byte, 10), 0o644)))
+	require.Equal(t, compression.Name(""), p.CompressorForFile(dir.AddFile("x.txt", make([]byte, 10), 0o644)))
+}
+
+func TestParseCompressionRule(t *testing.T) {
+	r, err := ParseCompressionRule("*.sql:zstd-fastest")
+	require.NoError(t, err)
+	require.Equal(t, CompressionRule{Pattern: "*.sql", CompressorName: "zstd-fastest"}, r)
+	require.Equal(t, "*.sql:zstd-fastest", r.String())
+
+	r, err = ParseCompressionRule("*.mp4:none")
+	require.NoError(t, err)
+	require.Equal(t, CompressionRule{Pattern: "*.mp4", CompressorName: "none"}, r)
+
+	for _, bad := range []string{"", "*.sql", "*.sql:", ":zstd", "*.sql:no-such-compressor", "[:zstd"} {
+		_, err := ParseCompressionRule(bad)
+		require.Error(t, err, bad)
+	}
+}
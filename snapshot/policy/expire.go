@@ -50,12 +50,16 @@ func getExpiredSnapshots(ctx context.Context, rep repo.Repository, snapshots []*
 	var toDelete []manifest.ID
 
 	for _, snapshotGroup := range snapshot.GroupBySource(snapshots) {
-		td, err := getExpiredSnapshotsForSource(ctx, rep, snapshotGroup)
-		if err != nil {
-			return nil, err
-		}
+		// snapshots of subtrees of the source are retained independently of full snapshots,
+		// so that frequent partial snapshots don't cause full snapshots to expire.
+		for _, subpathGroup := range snapshot.GroupBySubpath(snapshotGroup) {
+			td, err := getExpiredSnapshotsForSource(ctx, rep, subpathGroup)
+			if err != nil {
+				return nil, err
+			}
 
-		toDelete = append(toDelete, td...)
+			toDelete = append(toDelete, td...)
+		}
 	}
 
 	return toDelete, nil
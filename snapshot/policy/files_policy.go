@@ -11,6 +11,17 @@ type FilesPolicy struct {
 	IgnoreCacheDirectories *OptionalBool `json:"ignoreCacheDirs,omitempty"`
 	MaxFileSize            int64         `json:"maxFileSize,omitempty"`
 	OneFileSystem          *OptionalBool `json:"oneFileSystem,omitempty"`
+	IgnoreReparsePoints    *OptionalBool `json:"ignoreReparsePoints,omitempty"`
+
+	// Users and groups whose files are included or excluded, specified by name or numeric ID.
+	IncludeOwners []string `json:"includeOwners,omitempty"`
+	ExcludeOwners []string `json:"excludeOwners,omitempty"`
+	IncludeGroups []string `json:"includeGroups,omitempty"`
+	ExcludeGroups []string `json:"excludeGroups,omitempty"`
+
+	// When true, owners are restored by user and group names captured at snapshot time,
+	// taking precedence over numeric IDs.
+	RestoreOwnersByName *OptionalBool `json:"restoreOwnersByName,omitempty"`
 }
 
 // FilesPolicyDefinition specifies which policy definition provided the value of a particular field.
@@ -22,6 +33,12 @@ type FilesPolicyDefinition struct {
 	IgnoreCacheDirectories snapshot.SourceInfo `json:"ignoreCacheDirs,omitempty"`
 	MaxFileSize            snapshot.SourceInfo `json:"maxFileSize,omitempty"`
 	OneFileSystem          snapshot.SourceInfo `json:"oneFileSystem,omitempty"`
+	IgnoreReparsePoints    snapshot.SourceInfo `json:"ignoreReparsePoints,omitempty"`
+	IncludeOwners          snapshot.SourceInfo `json:"includeOwners,omitempty"`
+	ExcludeOwners          snapshot.SourceInfo `json:"excludeOwners,omitempty"`
+	IncludeGroups          snapshot.SourceInfo `json:"includeGroups,omitempty"`
+	ExcludeGroups          snapshot.SourceInfo `json:"excludeGroups,omitempty"`
+	RestoreOwnersByName    snapshot.SourceInfo `json:"restoreOwnersByName,omitempty"`
 }
 
 // Merge applies default values from the provided policy.
@@ -33,4 +50,10 @@ func (p *FilesPolicy) Merge(src FilesPolicy, def *FilesPolicyDefinition, si snap
 	mergeOptionalBool(&p.IgnoreCacheDirectories, src.IgnoreCacheDirectories, &def.IgnoreCacheDirectories, si)
 	mergeInt64(&p.MaxFileSize, src.MaxFileSize, &def.MaxFileSize, si)
 	mergeOptionalBool(&p.OneFileSystem, src.OneFileSystem, &def.OneFileSystem, si)
+	mergeOptionalBool(&p.IgnoreReparsePoints, src.IgnoreReparsePoints, &def.IgnoreReparsePoints, si)
+	mergeStringsReplace(&p.IncludeOwners, src.IncludeOwners, &def.IncludeOwners, si)
+	mergeStringsReplace(&p.ExcludeOwners, src.ExcludeOwners, &def.ExcludeOwners, si)
+	mergeStringsReplace(&p.IncludeGroups, src.IncludeGroups, &def.IncludeGroups, si)
+	mergeStringsReplace(&p.ExcludeGroups, src.ExcludeGroups, &def.ExcludeGroups, si)
+	mergeOptionalBool(&p.RestoreOwnersByName, src.RestoreOwnersByName, &def.RestoreOwnersByName, si)
 }
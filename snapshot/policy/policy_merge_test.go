@@ -20,6 +20,7 @@
 	"SchedulingPolicyDefinition.NoParentTimesOfDay":     true, // special
 	"CompressionPolicyDefinition.NoParentOnlyCompress":  true,
 	"CompressionPolicyDefinition.NoParentNeverCompress": true,
+	"CompressionPolicyDefinition.NoParentRules":         true,
 }
 
 func TestPolicyDefinition(t *testing.T) {
@@ -156,6 +157,18 @@ func testPolicyMergeSingleField(t *testing.T, fieldName string, typ reflect.Type
 		v0 = reflect.ValueOf((*policy.OSSnapshotMode)(nil))
 		v1 = reflect.ValueOf(policy.NewOSSnapshotMode(policy.OSSnapshotNever))
 		v2 = reflect.ValueOf(policy.NewOSSnapshotMode(policy.OSSnapshotAlways))
+	case "*policy.AnomalyAction":
+		v0 = reflect.ValueOf((*policy.AnomalyAction)(nil))
+		v1 = reflect.ValueOf(policy.NewAnomalyAction(policy.AnomalyActionWarn))
+		v2 = reflect.ValueOf(policy.NewAnomalyAction(policy.AnomalyActionConfirm))
+	case "[]policy.CompressionRule":
+		v0 = reflect.ValueOf([]policy.CompressionRule{})
+		v1 = reflect.ValueOf([]policy.CompressionRule{{Pattern: "*.sql", CompressorName: "zstd"}})
+		v2 = reflect.ValueOf([]policy.CompressionRule{{Pattern: "*.mp4", CompressorName: "none"}})
+	case "*policy.ChangeDetectionMode":
+		v0 = reflect.ValueOf((*policy.ChangeDetectionMode)(nil))
+		v1 = reflect.ValueOf(policy.NewChangeDetectionMode(policy.ChangeDetectionModTime))
+		v2 = reflect.ValueOf(policy.NewChangeDetectionMode(policy.ChangeDetectionChangeTime))
 
 	default:
 		t.Fatalf("unhandled case: %v - %v - please update test", fieldName, typ)
@@ -224,6 +237,7 @@ func disableParentMerging(p *policy.Policy) {
 	// test those cases separately.
 	p.CompressionPolicy.NoParentNeverCompress = true
 	p.CompressionPolicy.NoParentOnlyCompress = true
+	p.CompressionPolicy.NoParentRules = true
 	p.SchedulingPolicy.NoParentTimesOfDay = true
 }
 
@@ -278,6 +292,38 @@ func TestPolicyMergeOnlyCompressIncludingParents(t *testing.T) {
 	require.Equal(t, want.String(), result.String())
 }
 
+func TestPolicyMergeCompressionRulesIncludingParents(t *testing.T) {
+	p0 := &policy.Policy{
+		CompressionPolicy: policy.CompressionPolicy{
+			Rules: []policy.CompressionRule{{Pattern: "*.sql", CompressorName: "zstd-fastest"}},
+		},
+	}
+
+	p1 := &policy.Policy{
+		CompressionPolicy: policy.CompressionPolicy{
+			Rules: []policy.CompressionRule{
+				{Pattern: "*.mp4", CompressorName: "none"},
+				{Pattern: "*.sql", CompressorName: "zstd-better-compression"},
+			},
+		},
+	}
+
+	result, _ := policy.MergePolicies([]*policy.Policy{p0, p1}, p0.Target())
+
+	// rules of the child come first and override parent rules for the same pattern.
+	require.Equal(t, []policy.CompressionRule{
+		{Pattern: "*.sql", CompressorName: "zstd-fastest"},
+		{Pattern: "*.mp4", CompressorName: "none"},
+	}, result.CompressionPolicy.Rules)
+
+	p0.CompressionPolicy.NoParentRules = true
+	result, _ = policy.MergePolicies([]*policy.Policy{p0, p1}, p0.Target())
+
+	require.Equal(t, []policy.CompressionRule{
+		{Pattern: "*.sql", CompressorName: "zstd-fastest"},
+	}, result.CompressionPolicy.Rules)
+}
+
 func TestPolicyMergeTimesOfDayIncludingParents(t *testing.T) {
 	tod0 := policy.TimeOfDay{Hour: 10}
 	tod1 := policy.TimeOfDay{Hour: 11}
@@ -65,6 +65,18 @@
 
 		// upload large files in chunks of 2 GiB
 		ParallelUploadAboveSize: newOptionalInt64(2 << 30), //nolint:gomnd
+
+		RecordDeletedEntries: NewOptionalBool(false),
+	}
+
+	defaultAnomalyDetectionPolicy = AnomalyDetectionPolicy{
+		RansomwareAction:        NewAnomalyAction(AnomalyActionNone),
+		MinFiles:                newOptionalInt(100), //nolint:gomnd
+		SampleFiles:             newOptionalInt(100), //nolint:gomnd
+		ChangedFilesPercent:     newOptionalInt(50),  //nolint:gomnd
+		EntropyIncreasePercent:  newOptionalInt(50),  //nolint:gomnd
+		ExtensionChangesPercent: newOptionalInt(25),  //nolint:gomnd
+		ChangeRateAction:        NewAnomalyAction(AnomalyActionNotify),
 	}
 
 	// DefaultPolicy is a default policy returned by policy tree in absence of other policies.
@@ -78,6 +90,7 @@
 		Actions:             defaultActionsPolicy,
 		OSSnapshotPolicy:    defaultOSSnapshotPolicy,
 		UploadPolicy:        defaultUploadPolicy,
+		AnomalyDetection:    defaultAnomalyDetectionPolicy,
 	}
 
 	// DefaultDefinition provides the Definition for the default policy.
@@ -0,0 +1,125 @@
+package policy
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// RetentionExplanation describes how the retention policy applies to a single snapshot.
+type RetentionExplanation struct {
+	Snapshot *snapshot.Manifest `json:"snapshot"`
+
+	// retention reasons computed by the retention policy, same as in 'kopia snapshot list'.
+	RetentionReasons []string `json:"retentionReasons"`
+
+	// human-readable details of how each retention rule applies to the snapshot.
+	Details []string `json:"details"`
+
+	// true if the snapshot is not retained by any rule or pin and will be deleted by the next expiration.
+	Expired bool `json:"expired"`
+
+	// time after which time-based rules stop keeping the snapshot, assuming newer snapshots continue to be taken,
+	// not set for snapshots retained only by the number of latest snapshots or pins.
+	ExpiresAfter *time.Time `json:"expiresAfter,omitempty"`
+}
+
+// ExplainRetention computes retention reasons for snapshots of a single source and subpath and explains
+// how each of the retention rules applies to them. Returns explanations ordered from the most recent snapshot.
+func (r *RetentionPolicy) ExplainRetention(manifests []*snapshot.Manifest) []*RetentionExplanation {
+	byManifest := map[*snapshot.Manifest]*RetentionExplanation{}
+
+	for _, m := range manifests {
+		byManifest[m] = &RetentionExplanation{Snapshot: m}
+	}
+
+	r.computeRetentionReasons(manifests, func(s *snapshot.Manifest, detail string) {
+		byManifest[s].Details = append(byManifest[s].Details, detail)
+	})
+
+	var result []*RetentionExplanation
+
+	for _, m := range snapshot.SortByTime(manifests, true) {
+		e := byManifest[m]
+
+		for _, p := range m.Pins {
+			e.Details = append(e.Details, "pinned: "+p)
+		}
+
+		e.RetentionReasons = m.RetentionReasons
+		e.Expired = len(m.RetentionReasons) == 0 && len(m.Pins) == 0
+
+		if len(m.Pins) == 0 {
+			e.ExpiresAfter = r.expiresAfter(m)
+		}
+
+		result = append(result, e)
+	}
+
+	return result
+}
+
+// expiresAfter returns the latest time at which time-based retention rules keeping the snapshot stop applying.
+func (r *RetentionPolicy) expiresAfter(m *snapshot.Manifest) *time.Time {
+	var result *time.Time
+
+	for _, reason := range m.RetentionReasons {
+		prefix, _ := prefixSuffix(reason)
+
+		var t time.Time
+
+		st := m.StartTime.ToTime()
+
+		switch prefix {
+		case "hourly":
+			t = st.Add(time.Duration(r.KeepHourly.OrDefault(0)) * time.Hour)
+		case "daily":
+			t = st.AddDate(0, 0, r.KeepDaily.OrDefault(0))
+		case "weekly":
+			t = st.AddDate(0, 0, 7*r.KeepWeekly.OrDefault(0)) //nolint:gomnd
+		case "monthly":
+			t = st.AddDate(0, r.KeepMonthly.OrDefault(0), 0)
+		case "annual":
+			t = st.AddDate(r.KeepAnnual.OrDefault(0), 0, 0)
+		default:
+			// snapshots kept as latest or incomplete expire after newer snapshots are taken.
+			continue
+		}
+
+		if result == nil || t.After(*result) {
+			result = &t
+		}
+	}
+
+	return result
+}
+
+// ExplainRetentionPolicy explains how the effective retention policy applies to all snapshots of the given source.
+func ExplainRetentionPolicy(ctx context.Context, rep repo.Repository, sourceInfo snapshot.SourceInfo) ([]*RetentionExplanation, error) {
+	snapshots, err := snapshot.ListSnapshots(ctx, rep, sourceInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing snapshots")
+	}
+
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+
+	pol, _, _, err := GetEffectivePolicy(ctx, rep, sourceInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*RetentionExplanation
+
+	// snapshots of subtrees of the source are retained independently, just like in ApplyRetentionPolicy.
+	for _, subpathGroup := range snapshot.GroupBySubpath(snapshots) {
+		result = append(result, pol.RetentionPolicy.ExplainRetention(subpathGroup)...)
+	}
+
+	return result, nil
+}
@@ -44,6 +44,16 @@ type RetentionPolicyDefinition struct {
 // ComputeRetentionReasons computes the reasons why each snapshot is retained, based on
 // the settings in retention policy and stores them in RetentionReason field.
 func (r *RetentionPolicy) ComputeRetentionReasons(manifests []*snapshot.Manifest) {
+	r.computeRetentionReasons(manifests, nil)
+}
+
+// computeRetentionReasons implements ComputeRetentionReasons, the optional explain function receives
+// human-readable details of how each retention rule was applied to each snapshot.
+func (r *RetentionPolicy) computeRetentionReasons(manifests []*snapshot.Manifest, explain func(s *snapshot.Manifest, detail string)) {
+	if explain == nil {
+		explain = func(*snapshot.Manifest, string) {}
+	}
+
 	if len(manifests) == 0 {
 		return
 	}
@@ -91,7 +101,7 @@ func (r *RetentionPolicy) ComputeRetentionReasons(manifests []*snapshot.Manifest
 	// apply retention reasons to complete snapshots
 	for i, s := range sorted {
 		if s.IncompleteReason == "" {
-			s.RetentionReasons = r.getRetentionReasons(i, s, cutoff, ids, idCounters)
+			s.RetentionReasons = r.getRetentionReasons(i, s, cutoff, ids, idCounters, explain)
 		} else {
 			s.RetentionReasons = []string{}
 		}
@@ -108,10 +118,17 @@ func (r *RetentionPolicy) ComputeRetentionReasons(manifests []*snapshot.Manifest
 		// retain incomplete snapshots below certain age and below maximum count.
 		if age < retainIncompleteSnapshotsYoungerThan || i < retainIncompleteSnapshotMinimumCount {
 			s.RetentionReasons = append(s.RetentionReasons, "incomplete")
+			explain(s, fmt.Sprintf("incomplete: one of %v most recent incomplete snapshots or younger than %v", retainIncompleteSnapshotMinimumCount, retainIncompleteSnapshotsYoungerThan))
 		} else {
 			break
 		}
 	}
+
+	for _, s := range sorted {
+		if s.IncompleteReason != "" && len(s.RetentionReasons) == 0 {
+			explain(s, "incomplete: superseded by newer snapshots")
+		}
+	}
 }
 
 // EffectiveKeepLatest returns the number of "latest" snapshots to keep. If all
@@ -124,7 +141,7 @@ func (r *RetentionPolicy) EffectiveKeepLatest() *OptionalInt {
 	return r.KeepLatest
 }
 
-func (r *RetentionPolicy) getRetentionReasons(i int, s *snapshot.Manifest, cutoff *cutoffTimes, ids map[string]bool, idCounters map[string]int) []string {
+func (r *RetentionPolicy) getRetentionReasons(i int, s *snapshot.Manifest, cutoff *cutoffTimes, ids map[string]bool, idCounters map[string]int, explain func(s *snapshot.Manifest, detail string)) []string {
 	if s.IncompleteReason != "" {
 		return nil
 	}
@@ -152,15 +169,17 @@ func (r *RetentionPolicy) getRetentionReasons(i int, s *snapshot.Manifest, cutof
 	}
 
 	for _, c := range cases {
-		if c.max == nil {
+		if c.max == nil || *c.max == 0 {
 			continue
 		}
 
 		if s.StartTime.ToTime().Before(c.cutoffTime) {
+			explain(s, fmt.Sprintf("%v: older than %v", c.timePeriodType, c.cutoffTime.Format(time.RFC3339)))
 			continue
 		}
 
 		if _, exists := ids[c.timePeriodID]; exists {
+			explain(s, fmt.Sprintf("%v: newer snapshot exists for %v", c.timePeriodType, c.timePeriodID))
 			continue
 		}
 
@@ -168,6 +187,9 @@ func (r *RetentionPolicy) getRetentionReasons(i int, s *snapshot.Manifest, cutof
 			ids[c.timePeriodID] = true
 			idCounters[c.timePeriodType]++
 			keepReasons = append(keepReasons, fmt.Sprintf("%v-%v", c.timePeriodType, idCounters[c.timePeriodType]))
+			explain(s, explainKept(c.timePeriodType, c.timePeriodID, idCounters[c.timePeriodType], int(*c.max)))
+		} else {
+			explain(s, fmt.Sprintf("%v: already keeping %v %v snapshots", c.timePeriodType, *c.max, c.timePeriodType))
 		}
 	}
 
@@ -176,6 +198,19 @@ func (r *RetentionPolicy) getRetentionReasons(i int, s *snapshot.Manifest, cutof
 	return keepReasons
 }
 
+func explainKept(timePeriodType, timePeriodID string, n, limit int) string {
+	limitStr := strconv.Itoa(limit)
+	if limit == math.MaxInt {
+		limitStr = "unlimited"
+	}
+
+	if timePeriodType == "latest" {
+		return fmt.Sprintf("latest-%v: #%v of %v latest snapshots kept", n, n, limitStr)
+	}
+
+	return fmt.Sprintf("%v-%v: most recent snapshot of %v, #%v of %v %v snapshots kept", timePeriodType, n, timePeriodID, n, limitStr, timePeriodType)
+}
+
 type cutoffTimes struct {
 	annual  time.Time
 	monthly time.Time
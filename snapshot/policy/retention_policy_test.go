@@ -243,3 +243,83 @@ func TestCompactRetentionrRasons(t *testing.T) {
 		require.Equal(t, tc.want, CompactRetentionReasons(tc.input))
 	}
 }
+
+func TestSimulateRetention(t *testing.T) {
+	rp := &RetentionPolicy{
+		KeepLatest:  newOptionalInt(3),
+		KeepDaily:   newOptionalInt(7),
+		KeepMonthly: newOptionalInt(12),
+		KeepAnnual:  newOptionalInt(3),
+	}
+
+	retained, err := rp.SimulateRetention(RetentionSimulationOptions{
+		StartTime:        time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Duration:         5 * 365 * 24 * time.Hour,
+		SnapshotInterval: time.Hour,
+	})
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+
+	for _, m := range retained {
+		for _, r := range m.RetentionReasons {
+			counts[strings.Split(r, "-")[0]]++
+		}
+	}
+
+	require.Equal(t, map[string]int{"latest": 3, "daily": 7, "monthly": 12, "annual": 3}, counts)
+	require.Equal(t, "2024-12-30T00:00:00Z", retained[0].StartTime.Format(time.RFC3339))
+
+	_, err = (&RetentionPolicy{}).SimulateRetention(RetentionSimulationOptions{SnapshotInterval: time.Hour, Duration: time.Hour})
+	require.ErrorContains(t, err, "does not expire any snapshots")
+
+	_, err = rp.SimulateRetention(RetentionSimulationOptions{SnapshotInterval: time.Second, Duration: 365 * 24 * time.Hour})
+	require.ErrorContains(t, err, "too many simulated snapshots")
+}
+
+func TestExplainRetention(t *testing.T) {
+	rp := &RetentionPolicy{
+		KeepLatest: newOptionalInt(1),
+		KeepDaily:  newOptionalInt(2),
+	}
+
+	var manifests []*snapshot.Manifest
+
+	for _, ts := range []string{"2019-12-01T12:00:00Z", "2020-01-01T12:00:00Z", "2020-01-02T12:00:00Z", "2020-01-03T10:00:00Z", "2020-01-03T12:00:00Z"} {
+		st, err := time.Parse(time.RFC3339, ts)
+		require.NoError(t, err)
+
+		manifests = append(manifests, &snapshot.Manifest{StartTime: fs.UTCTimestampFromTime(st)})
+	}
+
+	manifests[0].Pins = []string{"keep-me"}
+
+	ex := rp.ExplainRetention(manifests)
+	require.Len(t, ex, 5)
+
+	require.Equal(t, manifests[4], ex[0].Snapshot)
+	require.False(t, ex[0].Expired)
+	require.Equal(t, []string{"latest-1", "daily-1"}, ex[0].RetentionReasons)
+	require.Equal(t, []string{
+		"latest-1: #1 of 1 latest snapshots kept",
+		"daily-1: most recent snapshot of 2020-01-03, #1 of 2 daily snapshots kept",
+	}, ex[0].Details)
+	require.Equal(t, "2020-01-05T12:00:00Z", ex[0].ExpiresAfter.Format(time.RFC3339))
+
+	require.True(t, ex[1].Expired)
+	require.Equal(t, []string{
+		"latest: already keeping 1 latest snapshots",
+		"daily: newer snapshot exists for 2020-01-03",
+	}, ex[1].Details)
+
+	require.False(t, ex[2].Expired)
+	require.Equal(t, "2020-01-04T12:00:00Z", ex[2].ExpiresAfter.Format(time.RFC3339))
+
+	require.True(t, ex[3].Expired)
+	require.Contains(t, ex[3].Details, "daily: already keeping 2 daily snapshots")
+
+	require.False(t, ex[4].Expired)
+	require.Nil(t, ex[4].ExpiresAfter)
+	require.Contains(t, ex[4].Details, "daily: older than 2020-01-01T12:00:00Z")
+	require.Contains(t, ex[4].Details, "pinned: keep-me")
+}
@@ -0,0 +1,66 @@
+package policy
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// maxSimulatedSnapshots is the maximum number of snapshots created by a single retention simulation.
+const maxSimulatedSnapshots = 100000
+
+// RetentionSimulationOptions describes the simulated snapshot schedule.
+type RetentionSimulationOptions struct {
+	StartTime        time.Time
+	Duration         time.Duration
+	SnapshotInterval time.Duration
+}
+
+// SimulateRetention fast-forwards simulated time from the start time until the end of the duration,
+// creating a snapshot every interval and applying the retention policy after each one, just like
+// 'kopia snapshot create' does. Returns the snapshots retained at the end of the simulation, most recent first.
+func (r *RetentionPolicy) SimulateRetention(opt RetentionSimulationOptions) ([]*snapshot.Manifest, error) {
+	if opt.SnapshotInterval <= 0 {
+		return nil, errors.Errorf("snapshot interval must be positive")
+	}
+
+	if r.EffectiveKeepLatest().OrDefault(0) == math.MaxInt {
+		return nil, errors.Errorf("retention policy does not expire any snapshots")
+	}
+
+	if opt.Duration/opt.SnapshotInterval > maxSimulatedSnapshots {
+		return nil, errors.Errorf("too many simulated snapshots, maximum is %v", maxSimulatedSnapshots)
+	}
+
+	var retained []*snapshot.Manifest
+
+	endTime := opt.StartTime.Add(opt.Duration)
+
+	for i, now := 0, opt.StartTime; !now.After(endTime); i, now = i+1, now.Add(opt.SnapshotInterval) {
+		retained = append(retained, &snapshot.Manifest{
+			ID:        manifest.ID(strconv.Itoa(i)),
+			StartTime: fs.UTCTimestampFromTime(now),
+			EndTime:   fs.UTCTimestampFromTime(now),
+		})
+
+		r.ComputeRetentionReasons(retained)
+
+		kept := retained[:0]
+
+		for _, m := range retained {
+			if len(m.RetentionReasons) > 0 {
+				kept = append(kept, m)
+			}
+		}
+
+		retained = kept
+	}
+
+	return snapshot.SortByTime(retained, true), nil
+}
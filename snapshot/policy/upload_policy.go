@@ -11,6 +11,12 @@ type UploadPolicy struct {
 	MaxParallelSnapshots    *OptionalInt   `json:"maxParallelSnapshots,omitempty"`
 	MaxParallelFileReads    *OptionalInt   `json:"maxParallelFileReads,omitempty"`
 	ParallelUploadAboveSize *OptionalInt64 `json:"parallelUploadAboveSize,omitempty"`
+	RecordDeletedEntries    *OptionalBool  `json:"recordDeletedEntries,omitempty"`
+	MaxSnapshotSize         *OptionalInt64 `json:"maxSnapshotSize,omitempty"`
+	MaxSnapshotSizeWarnOnly *OptionalBool  `json:"maxSnapshotSizeWarnOnly,omitempty"`
+
+	// ChangeDetection selects attributes used to determine whether files have changed since previous snapshots.
+	ChangeDetection *ChangeDetectionMode `json:"changeDetection,omitempty"`
 }
 
 // UploadPolicyDefinition specifies which policy definition provided the value of a particular field.
@@ -18,6 +24,10 @@ type UploadPolicyDefinition struct {
 	MaxParallelSnapshots    snapshot.SourceInfo `json:"maxParallelSnapshots,omitempty"`
 	MaxParallelFileReads    snapshot.SourceInfo `json:"maxParallelFileReads,omitempty"`
 	ParallelUploadAboveSize snapshot.SourceInfo `json:"parallelUploadAboveSize,omitempty"`
+	RecordDeletedEntries    snapshot.SourceInfo `json:"recordDeletedEntries,omitempty"`
+	MaxSnapshotSize         snapshot.SourceInfo `json:"maxSnapshotSize,omitempty"`
+	MaxSnapshotSizeWarnOnly snapshot.SourceInfo `json:"maxSnapshotSizeWarnOnly,omitempty"`
+	ChangeDetection         snapshot.SourceInfo `json:"changeDetection,omitempty"`
 }
 
 // Merge applies default values from the provided policy.
@@ -25,6 +35,10 @@ func (p *UploadPolicy) Merge(src UploadPolicy, def *UploadPolicyDefinition, si s
 	mergeOptionalInt(&p.MaxParallelSnapshots, src.MaxParallelSnapshots, &def.MaxParallelSnapshots, si)
 	mergeOptionalInt(&p.MaxParallelFileReads, src.MaxParallelFileReads, &def.MaxParallelFileReads, si)
 	mergeOptionalInt64(&p.ParallelUploadAboveSize, src.ParallelUploadAboveSize, &def.ParallelUploadAboveSize, si)
+	mergeOptionalBool(&p.RecordDeletedEntries, src.RecordDeletedEntries, &def.RecordDeletedEntries, si)
+	mergeOptionalInt64(&p.MaxSnapshotSize, src.MaxSnapshotSize, &def.MaxSnapshotSize, si)
+	mergeOptionalBool(&p.MaxSnapshotSizeWarnOnly, src.MaxSnapshotSizeWarnOnly, &def.MaxSnapshotSizeWarnOnly, si)
+	mergeChangeDetectionMode(&p.ChangeDetection, src.ChangeDetection, &def.ChangeDetection, si)
 }
 
 // ValidateUploadPolicy returns an error if manual field is set along with Upload fields.
@@ -33,5 +47,9 @@ func ValidateUploadPolicy(si snapshot.SourceInfo, p UploadPolicy) error {
 		return errors.Errorf("max parallel snapshots cannot be specified for paths, only global, username@hostname or @hostname")
 	}
 
+	if p.MaxSnapshotSize != nil && *p.MaxSnapshotSize < 0 {
+		return errors.Errorf("max snapshot size cannot be negative")
+	}
+
 	return nil
 }
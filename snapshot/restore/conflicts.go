@@ -0,0 +1,213 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// ConflictStrategy determines how restore handles files which already exist in the target.
+type ConflictStrategy string
+
+// Supported conflict strategies.
+const (
+	// ConflictOverwrite replaces existing files with restored ones.
+	ConflictOverwrite ConflictStrategy = "overwrite"
+
+	// ConflictKeepExisting leaves existing files intact.
+	ConflictKeepExisting ConflictStrategy = "keep-existing"
+
+	// ConflictKeepNewer replaces existing files only when the restored file is newer.
+	ConflictKeepNewer ConflictStrategy = "keep-newer"
+
+	// ConflictKeepBoth leaves existing files intact and restores files next to them with a suffix.
+	ConflictKeepBoth ConflictStrategy = "keep-both"
+
+	// ConflictPrompt asks ConflictResolver to decide about each conflict.
+	ConflictPrompt ConflictStrategy = "prompt"
+)
+
+// ConflictStrategies lists names of all supported conflict strategies.
+//
+//nolint:gochecknoglobals
+var ConflictStrategies = []string{
+	string(ConflictOverwrite),
+	string(ConflictKeepExisting),
+	string(ConflictKeepNewer),
+	string(ConflictKeepBoth),
+	string(ConflictPrompt),
+}
+
+// DefaultKeepBothSuffix is the suffix added to names of files restored next to existing ones.
+const DefaultKeepBothSuffix = ".restored"
+
+// maxKeepBothAttempts is the maximum number of numbered names tried when restoring next to existing files.
+const maxKeepBothAttempts = 1000
+
+// Conflict describes an existing file that conflicts with a file being restored and how it was resolved.
+type Conflict struct {
+	Path            string           `json:"path"`
+	ExistingSize    int64            `json:"existingSize"`
+	ExistingModTime time.Time        `json:"existingModTime"`
+	RestoredSize    int64            `json:"restoredSize"`
+	RestoredModTime time.Time        `json:"restoredModTime"`
+	Resolution      ConflictStrategy `json:"resolution"`
+	RestoredAs      string           `json:"restoredAs,omitempty"`
+}
+
+// ConflictResolver decides about a single conflict when using ConflictPrompt strategy, it must return
+// ConflictOverwrite, ConflictKeepExisting, ConflictKeepNewer or ConflictKeepBoth.
+type ConflictResolver func(ctx context.Context, c Conflict) (ConflictStrategy, error)
+
+// conflictTracker records resolved conflicts and names reserved for files restored next to existing ones.
+type conflictTracker struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	conflicts []Conflict
+	// +checklocks:mu
+	reservedNames map[string]bool
+}
+
+// Conflicts returns all conflicts encountered so far, in the order in which they were resolved.
+func (o *FilesystemOutput) Conflicts() []Conflict {
+	if o.conflicts == nil {
+		return nil
+	}
+
+	o.conflicts.mu.Lock()
+	defer o.conflicts.mu.Unlock()
+
+	return append([]Conflict(nil), o.conflicts.conflicts...)
+}
+
+// resolveFileConflict determines where the file should be restored when targetPath already exists.
+// It returns an empty path if the existing file should be kept intact.
+func (o *FilesystemOutput) resolveFileConflict(ctx context.Context, relativePath, targetPath string, f fs.File) (string, error) {
+	if o.ConflictStrategy == "" {
+		return targetPath, nil
+	}
+
+	t := o.conflicts
+	if t == nil {
+		return "", errors.New("filesystem output not initialized")
+	}
+
+	st, err := os.Lstat(targetPath)
+	if os.IsNotExist(err) {
+		return targetPath, nil
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "failed to stat "+targetPath)
+	}
+
+	if !st.Mode().IsRegular() {
+		// only conflicts between files are resolved, other types fail when writing.
+		return targetPath, nil
+	}
+
+	c := Conflict{
+		Path:            relativePath,
+		ExistingSize:    st.Size(),
+		ExistingModTime: st.ModTime(),
+		RestoredSize:    f.Size(),
+		RestoredModTime: f.ModTime(),
+	}
+
+	// resolution is serialized so that prompts don't interleave and numbered names don't collide.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	strategy := o.ConflictStrategy
+
+	if strategy == ConflictPrompt {
+		if o.ConflictResolver == nil {
+			return "", errors.New("conflict resolver not provided")
+		}
+
+		strategy, err = o.ConflictResolver(ctx, c)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to resolve conflict for %v", relativePath)
+		}
+	}
+
+	if strategy == ConflictKeepNewer {
+		strategy = ConflictKeepExisting
+
+		if f.ModTime().After(st.ModTime()) {
+			strategy = ConflictOverwrite
+		}
+	}
+
+	result := ""
+
+	switch strategy {
+	case ConflictOverwrite:
+		result = targetPath
+
+	case ConflictKeepExisting:
+		log(ctx).Debugf("Keeping existing file: %v", targetPath)
+
+	case ConflictKeepBoth:
+		result, err = t.keepBothPath(targetPath, o.KeepBothSuffix)
+		if err != nil {
+			return "", err
+		}
+
+		c.RestoredAs, _ = filepath.Rel(o.TargetPath, result)
+		c.RestoredAs = filepath.ToSlash(c.RestoredAs)
+
+	default:
+		return "", errors.Errorf("invalid conflict resolution %q for %v", strategy, relativePath)
+	}
+
+	c.Resolution = strategy
+	t.conflicts = append(t.conflicts, c)
+
+	return result, nil
+}
+
+// keepBothPath returns the first name derived from targetPath by adding the suffix before the extension
+// which does not exist yet, such as "report.restored.txt" or "report.restored-2.txt".
+//
+// +checklocks:t.mu
+func (t *conflictTracker) keepBothPath(targetPath, suffix string) (string, error) {
+	if suffix == "" {
+		suffix = DefaultKeepBothSuffix
+	}
+
+	ext := filepath.Ext(targetPath)
+	base := strings.TrimSuffix(targetPath, ext)
+
+	for i := 1; i <= maxKeepBothAttempts; i++ {
+		candidate := base + suffix + ext
+		if i > 1 {
+			candidate = fmt.Sprintf("%v%v-%v%v", base, suffix, i, ext)
+		}
+
+		if t.reservedNames[candidate] {
+			continue
+		}
+
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			if t.reservedNames == nil {
+				t.reservedNames = map[string]bool{}
+			}
+
+			// the file is written after the lock is released, make sure it's not handed out again.
+			t.reservedNames[candidate] = true
+
+			return candidate, nil
+		}
+	}
+
+	return "", errors.Errorf("unable to find available name for %v", targetPath)
+}
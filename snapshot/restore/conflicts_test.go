@@ -0,0 +1,129 @@
+package restore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestConflictStrategies(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	src := mockfs.NewDirectory()
+	f := src.AddFile("file.txt", []byte("restored"), 0o644)
+
+	veryOld := mockfs.DefaultModTime.Add(-time.Hour)
+
+	cases := []struct {
+		strategy        ConflictStrategy
+		existingModTime time.Time
+		wantContents    map[string]string
+		wantResolution  ConflictStrategy
+	}{
+		{
+			strategy:       ConflictOverwrite,
+			wantContents:   map[string]string{"file.txt": "restored"},
+			wantResolution: ConflictOverwrite,
+		},
+		{
+			strategy:       ConflictKeepExisting,
+			wantContents:   map[string]string{"file.txt": "existing"},
+			wantResolution: ConflictKeepExisting,
+		},
+		{
+			strategy:       ConflictKeepNewer,
+			wantContents:   map[string]string{"file.txt": "existing"},
+			wantResolution: ConflictKeepExisting,
+		},
+		{
+			strategy:        ConflictKeepNewer,
+			existingModTime: veryOld,
+			wantContents:    map[string]string{"file.txt": "restored"},
+			wantResolution:  ConflictOverwrite,
+		},
+		{
+			strategy: ConflictKeepBoth,
+			wantContents: map[string]string{
+				"file.txt":          "existing",
+				"file.restored.txt": "restored",
+			},
+			wantResolution: ConflictKeepBoth,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(string(tc.strategy), func(t *testing.T) {
+			td := t.TempDir()
+			existing := filepath.Join(td, "file.txt")
+
+			require.NoError(t, os.WriteFile(existing, []byte("existing"), 0o600))
+
+			if !tc.existingModTime.IsZero() {
+				require.NoError(t, os.Chtimes(existing, tc.existingModTime, tc.existingModTime))
+			}
+
+			o := &FilesystemOutput{
+				TargetPath:       td,
+				ConflictStrategy: tc.strategy,
+				SkipOwners:       true,
+			}
+
+			require.NoError(t, o.Init(ctx))
+			require.NoError(t, o.WriteFile(ctx, "file.txt", f))
+
+			for name, want := range tc.wantContents {
+				got, err := os.ReadFile(filepath.Join(td, name))
+				require.NoError(t, err)
+				require.Equal(t, want, string(got))
+			}
+
+			conflicts := o.Conflicts()
+			require.Len(t, conflicts, 1)
+			require.Equal(t, "file.txt", conflicts[0].Path)
+			require.Equal(t, tc.wantResolution, conflicts[0].Resolution)
+		})
+	}
+}
+
+func TestConflictPrompt(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	src := mockfs.NewDirectory()
+	f := src.AddFile("file.txt", []byte("restored"), 0o644)
+
+	td := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file.txt"), []byte("existing"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "file.restored.txt"), []byte("existing"), 0o600))
+
+	var prompted []Conflict
+
+	o := &FilesystemOutput{
+		TargetPath:       td,
+		ConflictStrategy: ConflictPrompt,
+		SkipOwners:       true,
+		ConflictResolver: func(ctx context.Context, c Conflict) (ConflictStrategy, error) {
+			prompted = append(prompted, c)
+			return ConflictKeepBoth, nil
+		},
+	}
+
+	require.NoError(t, o.Init(ctx))
+	require.NoError(t, o.WriteFile(ctx, "file.txt", f))
+
+	require.Len(t, prompted, 1)
+	require.Equal(t, int64(len("existing")), prompted[0].ExistingSize)
+	require.Equal(t, int64(len("restored")), prompted[0].RestoredSize)
+
+	got, err := os.ReadFile(filepath.Join(td, "file.restored-2.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "restored", string(got))
+	require.Equal(t, "file.restored-2.txt", o.Conflicts()[0].RestoredAs)
+}
@@ -0,0 +1,151 @@
+package restore
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// maxDeferredMetadataEntries is the maximum number of entries whose metadata is held in memory,
+// when it is reached, metadata of all finished entries is applied before restore continues.
+const maxDeferredMetadataEntries = 100000
+
+// deferredMetadata accumulates metadata of restored entries to be applied after all contents have been written.
+type deferredMetadata struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	entries []deferredMetadataEntry
+
+	// applyMu serializes application of batches, so that directories are never updated before
+	// entries of an earlier batch.
+	applyMu sync.Mutex
+}
+
+type deferredMetadataEntry struct {
+	path string
+	e    fs.Entry
+}
+
+// add adds the entry and returns all accumulated entries when the limit has been reached.
+func (d *deferredMetadata) add(path string, e fs.Entry) []deferredMetadataEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.entries = append(d.entries, deferredMetadataEntry{path, e})
+
+	if len(d.entries) < maxDeferredMetadataEntries {
+		return nil
+	}
+
+	return d.takeLocked()
+}
+
+// take returns and removes all accumulated entries.
+func (d *deferredMetadata) take() []deferredMetadataEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.takeLocked()
+}
+
+// +checklocks:d.mu
+func (d *deferredMetadata) takeLocked() []deferredMetadataEntry {
+	entries := d.entries
+	d.entries = nil
+
+	return entries
+}
+
+// setOrDeferMetadata applies metadata of the provided entry to the target path, unless DeferMetadata is set,
+// in which case it will be applied when the output is closed or when too many entries have been deferred.
+// Metadata is only deferred for entries which have been fully restored, so it can be applied at any time.
+func (o *FilesystemOutput) setOrDeferMetadata(ctx context.Context, targetPath string, e fs.Entry) error {
+	if o.deferred != nil {
+		if batch := o.deferred.add(targetPath, e); batch != nil {
+			return o.applyMetadataBatch(ctx, batch)
+		}
+
+		return nil
+	}
+
+	return o.setMetadata(ctx, targetPath, e)
+}
+
+func (o *FilesystemOutput) setMetadata(ctx context.Context, targetPath string, e fs.Entry) error {
+	if err := o.setAttributes(targetPath, e, os.FileMode(0)); err != nil {
+		return errors.Wrap(err, "error setting attributes")
+	}
+
+	if isSymlink(e) {
+		return nil
+	}
+
+	if err := o.setMacOSMetadata(ctx, targetPath, e); err != nil {
+		return errors.Wrap(err, "error setting macOS metadata")
+	}
+
+	return nil
+}
+
+// applyDeferredMetadata applies all remaining deferred metadata.
+func (o *FilesystemOutput) applyDeferredMetadata(ctx context.Context) error {
+	return o.applyMetadataBatch(ctx, o.deferred.take())
+}
+
+// applyMetadataBatch applies metadata of the provided entries. Files and symlinks are handled in parallel first,
+// followed by directories from the deepest ones, so that restrictive permissions or modification times
+// of directories are not affected by changes to their contents.
+func (o *FilesystemOutput) applyMetadataBatch(ctx context.Context, entries []deferredMetadataEntry) error {
+	o.deferred.applyMu.Lock()
+	defer o.deferred.applyMu.Unlock()
+
+	var files, dirs []deferredMetadataEntry
+
+	for _, de := range entries {
+		if de.e.IsDir() {
+			dirs = append(dirs, de)
+		} else {
+			files = append(files, de)
+		}
+	}
+
+	var eg errgroup.Group
+
+	eg.SetLimit(runtime.NumCPU())
+
+	for _, de := range files {
+		de := de
+
+		eg.Go(func() error {
+			return errors.Wrap(o.setMetadata(ctx, de.path, de.e), de.path)
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return errors.Wrap(err, "error applying file metadata")
+	}
+
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return pathDepth(dirs[i].path) > pathDepth(dirs[j].path)
+	})
+
+	for _, de := range dirs {
+		if err := o.setMetadata(ctx, de.path, de.e); err != nil {
+			return errors.Wrapf(err, "error applying directory metadata to %v", de.path)
+		}
+	}
+
+	return nil
+}
+
+func pathDepth(p string) int {
+	return strings.Count(p, string(os.PathSeparator))
+}
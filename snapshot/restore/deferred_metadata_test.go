@@ -0,0 +1,117 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestDeferredMetadata_Bounded(t *testing.T) {
+	var d deferredMetadata
+
+	for i := 0; i < maxDeferredMetadataEntries-1; i++ {
+		require.Nil(t, d.add(fmt.Sprintf("f%v", i), nil))
+	}
+
+	require.Len(t, d.add("last", nil), maxDeferredMetadataEntries)
+	require.Empty(t, d.take())
+}
+
+func BenchmarkRestoreSmallFiles(b *testing.B) {
+	const (
+		numDirs     = 20
+		filesPerDir = 250
+	)
+
+	root := mockfs.NewDirectory()
+
+	for i := 0; i < numDirs; i++ {
+		d := root.AddDir(fmt.Sprintf("dir%v", i), 0o755)
+
+		for j := 0; j < filesPerDir; j++ {
+			d.AddFile(fmt.Sprintf("file%v", j), []byte("small file contents"), 0o644)
+		}
+	}
+
+	cases := []struct {
+		name string
+		opts FilesystemOutput
+	}{
+		{"Default", FilesystemOutput{}},
+		{"Atomic", FilesystemOutput{WriteFilesAtomically: true}},
+		{"DeferMetadata", FilesystemOutput{DeferMetadata: true}},
+		{"AtomicDeferMetadata", FilesystemOutput{WriteFilesAtomically: true, DeferMetadata: true}},
+	}
+
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			ctx := testlogging.ContextWithLevel(b, testlogging.LevelError)
+
+			for i := 0; i < b.N; i++ {
+				o := tc.opts
+				o.TargetPath = b.TempDir()
+				o.SkipOwners = true
+
+				if err := o.Init(ctx); err != nil {
+					b.Fatal(err)
+				}
+
+				if err := restoreMockTree(ctx, &o, root); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(numDirs*filesPerDir*b.N)/b.Elapsed().Seconds(), "files/s")
+		})
+	}
+}
+
+// restoreMockTree restores the two-level tree to the output, directories are restored in parallel
+// like restore.Entry does.
+func restoreMockTree(ctx context.Context, o *FilesystemOutput, root *mockfs.Directory) error {
+	dirs, err := fs.GetAllEntries(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	var eg errgroup.Group
+
+	eg.SetLimit(runtime.NumCPU())
+
+	for _, de := range dirs {
+		d := de.(fs.Directory) //nolint:forcetypeassert
+
+		eg.Go(func() error {
+			if err := o.BeginDirectory(ctx, d.Name(), d); err != nil {
+				return err
+			}
+
+			files, err := fs.GetAllEntries(ctx, d)
+			if err != nil {
+				return err
+			}
+
+			for _, f := range files {
+				if err := o.WriteFile(ctx, d.Name()+"/"+f.Name(), f.(fs.File)); err != nil { //nolint:forcetypeassert
+					return err
+				}
+			}
+
+			return o.FinishDirectory(ctx, d.Name(), d)
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return o.Close(ctx)
+}
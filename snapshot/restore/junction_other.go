@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package restore
+
+import "os"
+
+// createJunction creates a symbolic link in place of a junction, which can only be created on Windows.
+func createJunction(target, linkPath string) error {
+	//nolint:wrapcheck
+	return os.Symlink(target, linkPath)
+}
@@ -0,0 +1,95 @@
+package restore
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+)
+
+// mountPointReparseHeaderSize is the size of REPARSE_DATA_BUFFER fields preceding the path buffer
+// of a mount point (ReparseTag, ReparseDataLength, Reserved and four name offsets and lengths).
+const mountPointReparseHeaderSize = 16
+
+// createJunction creates a directory junction (or a volume mount point, if the target is a volume)
+// at linkPath pointing at the provided absolute target.
+func createJunction(target, linkPath string) error {
+	linkPath = atomicfile.MaybePrefixLongFilenameOnWindows(linkPath)
+
+	if err := os.Mkdir(linkPath, 0o700); err != nil { //nolint:gomnd
+		return errors.Wrap(err, "unable to create junction directory")
+	}
+
+	if err := setMountPointReparseData(linkPath, target); err != nil {
+		os.Remove(linkPath) //nolint:errcheck
+
+		return err
+	}
+
+	return nil
+}
+
+func setMountPointReparseData(linkPath, target string) error {
+	fn, err := windows.UTF16PtrFromString(linkPath)
+	if err != nil {
+		return errors.Wrap(err, "UTF16PtrFromString")
+	}
+
+	h, err := windows.CreateFile(
+		fn, windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING,
+		windows.FILE_FLAG_OPEN_REPARSE_POINT|windows.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return errors.Wrapf(err, "CreateFile error on %v", linkPath)
+	}
+
+	defer windows.CloseHandle(h) //nolint:errcheck
+
+	buf := mountPointReparseData(target)
+
+	var bytesReturned uint32
+
+	if err := windows.DeviceIoControl(h, windows.FSCTL_SET_REPARSE_POINT, &buf[0], uint32(len(buf)), nil, 0, &bytesReturned, nil); err != nil {
+		return errors.Wrap(err, "unable to set reparse point")
+	}
+
+	return nil
+}
+
+// mountPointReparseData returns REPARSE_DATA_BUFFER describing a mount point with the provided target.
+func mountPointReparseData(target string) []byte {
+	substituteName := target
+	if strings.HasPrefix(substituteName, `\\?\`) {
+		substituteName = substituteName[len(`\\?\`):]
+	}
+
+	substitute := utf16.Encode([]rune(`\??\` + substituteName))
+	printName := utf16.Encode([]rune(target))
+
+	// both names are NUL-terminated, but the terminators are not included in their lengths.
+	pathBuffer := make([]uint16, 0, len(substitute)+len(printName)+2) //nolint:gomnd
+	pathBuffer = append(pathBuffer, substitute...)
+	pathBuffer = append(pathBuffer, 0)
+	pathBuffer = append(pathBuffer, printName...)
+	pathBuffer = append(pathBuffer, 0)
+
+	buf := make([]byte, mountPointReparseHeaderSize+2*len(pathBuffer)) //nolint:gomnd
+	le := binary.LittleEndian
+
+	le.PutUint32(buf[0:], windows.IO_REPARSE_TAG_MOUNT_POINT)
+	le.PutUint16(buf[4:], uint16(len(buf)-8))             //nolint:gomnd,gosec // ReparseDataLength excludes the 8-byte header
+	le.PutUint16(buf[8:], 0)                              // SubstituteNameOffset
+	le.PutUint16(buf[10:], uint16(2*len(substitute)))     //nolint:gomnd,gosec
+	le.PutUint16(buf[12:], uint16(2*(len(substitute)+1))) //nolint:gomnd,gosec // PrintNameOffset
+	le.PutUint16(buf[14:], uint16(2*len(printName)))      //nolint:gomnd,gosec
+
+	for i, c := range pathBuffer {
+		le.PutUint16(buf[mountPointReparseHeaderSize+2*i:], c) //nolint:gomnd
+	}
+
+	return buf
+}
@@ -7,6 +7,7 @@
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -15,16 +16,22 @@
 	"github.com/kopia/kopia/fs/localfs"
 	"github.com/kopia/kopia/internal/atomicfile"
 	"github.com/kopia/kopia/internal/iocopy"
+	"github.com/kopia/kopia/internal/ownername"
 	"github.com/kopia/kopia/internal/sparsefile"
 	"github.com/kopia/kopia/internal/stat"
+	"github.com/kopia/kopia/repo/blob/throttling"
 	"github.com/kopia/kopia/snapshot"
 )
 
 const (
 	outputDirMode                     = 0o700 // default mode to create directories in before setting their ACLs
 	maxTimeDeltaToConsiderFileTheSame = 2 * time.Second
+	writeThrottlingWindow             = time.Second
 )
 
+// errTmpfileNotSupported is returned by writeUsingTmpfile when unnamed temporary files can't be used.
+var errTmpfileNotSupported = errors.New("unnamed temporary files are not supported")
+
 // streamCopier is a generic function type to perform the actual copying of data bits
 // from a source stream to a destination stream.
 type streamCopier func(io.WriteSeeker, io.Reader) (int64, error)
@@ -48,12 +55,18 @@ func getStreamCopier(ctx context.Context, targetpath string, sparse bool) (strea
 		log(ctx).Debugf("sparse copying is not supported on Windows, falling back to regular copying")
 	}
 
-	// Wrap iocopy.Copy to conform to StreamCopier type.
+	// Wrap iocopy.Copy to conform to StreamCopier type, hiding ReadFrom() of files which would
+	// otherwise allocate a new copy buffer for each restored file instead of using shared ones.
 	return func(w io.WriteSeeker, r io.Reader) (int64, error) {
-		return iocopy.Copy(w, r) //nolint:wrapcheck
+		return iocopy.Copy(writerOnly{w}, r) //nolint:wrapcheck
 	}, nil
 }
 
+// writerOnly hides all methods of the wrapped writer other than Write().
+type writerOnly struct {
+	io.Writer
+}
+
 // FilesystemOutput contains the options for outputting a file system tree.
 type FilesystemOutput struct {
 	// TargetPath for restore.
@@ -72,6 +85,20 @@ type FilesystemOutput struct {
 	// error instead.
 	OverwriteSymlinks bool `json:"overwriteSymlinks"`
 
+	// JunctionsAsSymlinks when set to true causes Windows junctions and mount points to be restored
+	// as symbolic links. Junctions can only be created on Windows and are restored as symbolic links elsewhere.
+	JunctionsAsSymlinks bool `json:"junctionsAsSymlinks"`
+
+	// ConflictStrategy determines how files which already exist in the target are handled,
+	// when empty OverwriteFiles is used instead.
+	ConflictStrategy ConflictStrategy `json:"conflictStrategy,omitempty"`
+
+	// KeepBothSuffix is added to names of files restored next to existing ones, defaults to DefaultKeepBothSuffix.
+	KeepBothSuffix string `json:"keepBothSuffix,omitempty"`
+
+	// ConflictResolver decides about each conflict when using ConflictPrompt strategy.
+	ConflictResolver ConflictResolver `json:"-"`
+
 	// IgnorePermissionErrors causes restore to ignore errors due to invalid permissions.
 	IgnorePermissionErrors bool `json:"ignorePermissionErrors"`
 
@@ -81,6 +108,10 @@ type FilesystemOutput struct {
 	// SkipOwners when set to true causes restore to skip restoring owner information.
 	SkipOwners bool `json:"skipOwners"`
 
+	// MapOwnersByName when set to true causes restore to map owners by user and group names
+	// captured at snapshot time, falling back to numeric IDs for names that don't exist locally.
+	MapOwnersByName bool `json:"mapOwnersByName"`
+
 	// SkipPermissions when set to true causes restore to skip restoring permission information.
 	SkipPermissions bool `json:"skipPermissions"`
 
@@ -90,9 +121,28 @@ type FilesystemOutput struct {
 	// WriteSparseFiles when set to true, write contents as sparse files, minimizing allocated disk space.
 	WriteSparseFiles bool `json:"writeSparseFiles"`
 
+	// MaxWriteBytesPerSecond limits the rate at which file contents are written, 0 means unlimited.
+	MaxWriteBytesPerSecond float64 `json:"maxWriteBytesPerSecond,omitempty"`
+
+	// DeferMetadata when set to true causes owners, permissions and modification times to be applied in a
+	// separate parallel pass, in batches of fully restored entries.
+	DeferMetadata bool `json:"deferMetadata"`
+
+	// deferred holds metadata to be applied on Close() when DeferMetadata is set, it's shared with shallow outputs.
+	deferred *deferredMetadata `json:"-"`
+
+	// writeThrottler limits the write throughput when MaxWriteBytesPerSecond is set.
+	writeThrottler throttling.Throttler `json:"-"`
+
 	// copier is the StreamCopier to use for copying the actual bit stream to output.
 	// It is assigned at runtime based on the target filesystem and restore options.
 	copier streamCopier `json:"-"`
+
+	// conflicts records conflicts resolved using ConflictStrategy, it's shared with shallow outputs.
+	conflicts *conflictTracker `json:"-"`
+
+	// createdDirs records directories created by this restore, it's shared with shallow outputs.
+	createdDirs *sync.Map `json:"-"`
 }
 
 // Init initializes the internal members of the filesystem writer output.
@@ -104,6 +154,21 @@ func (o *FilesystemOutput) Init(ctx context.Context) error {
 	}
 
 	o.copier = c
+	o.conflicts = &conflictTracker{}
+	o.createdDirs = &sync.Map{}
+
+	if o.DeferMetadata {
+		o.deferred = &deferredMetadata{}
+	}
+
+	if o.MaxWriteBytesPerSecond > 0 {
+		t, err := throttling.NewThrottler(throttling.Limits{UploadBytesPerSecond: o.MaxWriteBytesPerSecond}, writeThrottlingWindow, 0)
+		if err != nil {
+			return errors.Wrap(err, "unable to create write throttler")
+		}
+
+		o.writeThrottler = t
+	}
 
 	return nil
 }
@@ -127,8 +192,12 @@ func (o *FilesystemOutput) BeginDirectory(ctx context.Context, relativePath stri
 // FinishDirectory implements restore.Output interface.
 func (o *FilesystemOutput) FinishDirectory(ctx context.Context, relativePath string, e fs.Directory) error {
 	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
-	if err := o.setAttributes(path, e, os.FileMode(0)); err != nil {
-		return errors.Wrap(err, "error setting attributes")
+	if err := o.setOrDeferMetadata(ctx, path, e); err != nil {
+		return err
+	}
+
+	if o.isCreatedDirectory(filepath.Dir(path)) {
+		return nil
 	}
 
 	return SafeRemoveAll(path)
@@ -143,20 +212,44 @@ func (o *FilesystemOutput) WriteDirEntry(ctx context.Context, relativePath strin
 
 // Close implements restore.Output interface.
 func (o *FilesystemOutput) Close(ctx context.Context) error {
+	if o.deferred != nil {
+		return o.applyDeferredMetadata(ctx)
+	}
+
 	return nil
 }
 
 // WriteFile implements restore.Output interface.
 func (o *FilesystemOutput) WriteFile(ctx context.Context, relativePath string, f fs.File) error {
 	log(ctx).Debugf("WriteFile %v (%v bytes) %v, %v", filepath.Join(o.TargetPath, relativePath), f.Size(), f.Mode(), f.ModTime())
+
 	path := filepath.Join(o.TargetPath, filepath.FromSlash(relativePath))
 
-	if err := o.copyFileContent(ctx, path, f); err != nil {
+	// files in directories created by this restore can't conflict with existing files or shallow placeholders.
+	fresh := o.isCreatedDirectory(filepath.Dir(path))
+
+	if !fresh {
+		var err error
+
+		if path, err = o.resolveFileConflict(ctx, relativePath, path, f); err != nil {
+			return err
+		}
+
+		if path == "" {
+			return nil
+		}
+	}
+
+	if err := o.copyFileContent(ctx, path, f, fresh); err != nil {
 		return errors.Wrap(err, "error creating file")
 	}
 
-	if err := o.setAttributes(path, f, os.FileMode(0)); err != nil {
-		return errors.Wrap(err, "error setting attributes")
+	if err := o.setOrDeferMetadata(ctx, path, f); err != nil {
+		return err
+	}
+
+	if fresh {
+		return nil
 	}
 
 	return SafeRemoveAll(path)
@@ -216,15 +309,15 @@ func (o *FilesystemOutput) CreateSymlink(ctx context.Context, relativePath strin
 		return errors.Errorf("unable to create symlink, %q already exists and is not a symlink", path)
 	}
 
-	if err := os.Symlink(targetPath, path); err != nil {
+	if lt := fs.LinkType(e); lt != "" && !o.JunctionsAsSymlinks {
+		if err := createJunction(targetPath, path); err != nil {
+			return errors.Wrapf(err, "error creating %v", lt)
+		}
+	} else if err := os.Symlink(targetPath, path); err != nil {
 		return errors.Wrap(err, "error creating symlink")
 	}
 
-	if err := o.setAttributes(path, e, os.FileMode(0)); err != nil {
-		return errors.Wrap(err, "error setting attributes")
-	}
-
-	return nil
+	return o.setOrDeferMetadata(ctx, path, e)
 }
 
 func fileIsSymlink(st os.FileInfo) bool {
@@ -268,8 +361,8 @@ func (o *FilesystemOutput) setAttributes(targetPath string, e fs.Entry, modclear
 	// Set owner user and group from e
 	// On Windows Chown is not supported. fs.OwnerInfo collected on Windows will always
 	// be zero-value for UID and GID, so the Chown operation is not performed.
-	if o.shouldUpdateOwner(le, e) {
-		if err = o.maybeIgnorePermissionError(osChown(targetPath, int(e.Owner().UserID), int(e.Owner().GroupID))); err != nil {
+	if uid, gid := o.targetOwner(e.Owner()); o.shouldUpdateOwner(le, uid, gid) {
+		if err = o.maybeIgnorePermissionError(osChown(targetPath, int(uid), int(gid))); err != nil {
 			return errors.Wrap(err, "could not change owner/group for "+targetPath)
 		}
 	}
@@ -303,7 +396,30 @@ func (o *FilesystemOutput) maybeIgnorePermissionError(err error) error {
 	return err
 }
 
-func (o *FilesystemOutput) shouldUpdateOwner(local, remote fs.Entry) bool {
+// targetOwner returns the numeric user and group ID to restore for the given owner.
+func (o *FilesystemOutput) targetOwner(oi fs.OwnerInfo) (uid, gid uint32) {
+	uid, gid = oi.UserID, oi.GroupID
+
+	if !o.MapOwnersByName {
+		return uid, gid
+	}
+
+	if oi.UserName != "" {
+		if id, ok := ownername.UserID(oi.UserName); ok {
+			uid = id
+		}
+	}
+
+	if oi.GroupName != "" {
+		if id, ok := ownername.GroupID(oi.GroupName); ok {
+			gid = id
+		}
+	}
+
+	return uid, gid
+}
+
+func (o *FilesystemOutput) shouldUpdateOwner(local fs.Entry, uid, gid uint32) bool {
 	if o.SkipOwners {
 		return false
 	}
@@ -312,7 +428,7 @@ func (o *FilesystemOutput) shouldUpdateOwner(local, remote fs.Entry) bool {
 		return false
 	}
 
-	return local.Owner() != remote.Owner()
+	return local.Owner().UserID != uid || local.Owner().GroupID != gid
 }
 
 func (o *FilesystemOutput) shouldUpdatePermissions(local, remote fs.Entry, modclear os.FileMode) bool {
@@ -336,6 +452,16 @@ func isWindows() bool {
 }
 
 func (o *FilesystemOutput) createDirectory(ctx context.Context, path string) error {
+	// in the common case the parent directory has just been created and is empty,
+	// so try creating the directory right away, which saves a stat() call.
+	if err := os.Mkdir(path, outputDirMode); err == nil {
+		if o.createdDirs != nil {
+			o.createdDirs.Store(path, true)
+		}
+
+		return nil
+	}
+
 	switch st, err := os.Stat(path); {
 	case os.IsNotExist(err):
 		//nolint:wrapcheck
@@ -357,8 +483,24 @@ func (o *FilesystemOutput) createDirectory(ctx context.Context, path string) err
 	}
 }
 
-func write(targetPath string, r fs.Reader, size int64, c streamCopier) error {
-	f, err := os.OpenFile(targetPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gosec,gomnd
+// isCreatedDirectory returns true if the directory was created by this restore and was therefore initially empty.
+func (o *FilesystemOutput) isCreatedDirectory(path string) bool {
+	if o.createdDirs == nil {
+		return false
+	}
+
+	_, ok := o.createdDirs.Load(path)
+
+	return ok
+}
+
+func write(targetPath string, r io.Reader, size int64, c streamCopier, exclusive bool) error {
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if exclusive {
+		flags |= os.O_EXCL
+	}
+
+	f, err := os.OpenFile(targetPath, flags, 0o600) //nolint:gosec,gomnd
 	if err != nil {
 		return err //nolint:wrapcheck
 	}
@@ -384,17 +526,35 @@ func write(targetPath string, r fs.Reader, size int64, c streamCopier) error {
 	return nil
 }
 
-func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath string, f fs.File) error {
+// checkExistingFile returns true if the target file exists and may be overwritten, or an error if it must not be.
+func (o *FilesystemOutput) checkExistingFile(ctx context.Context, targetPath string) (bool, error) {
 	switch _, err := os.Stat(targetPath); {
-	case os.IsNotExist(err): // copy file below
+	case os.IsNotExist(err):
+		return false, nil
 	case err == nil:
-		if !o.OverwriteFiles {
-			return errors.Errorf("unable to create %q, it already exists", targetPath)
+		if !o.OverwriteFiles && o.ConflictStrategy == "" {
+			return false, errors.Errorf("unable to create %q, it already exists", targetPath)
 		}
 
 		log(ctx).Debugf("Overwriting existing file: %v", targetPath)
+
+		return true, nil
 	default:
-		return errors.Wrap(err, "failed to stat "+targetPath)
+		return false, errors.Wrap(err, "failed to stat "+targetPath)
+	}
+}
+
+// copyFileContent writes the contents of the file to targetPath. When fresh is set, the file is being created
+// in a directory created by this restore, so the existence check is skipped and left to the filesystem.
+func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath string, f fs.File, fresh bool) error {
+	var exists bool
+
+	if !fresh {
+		var err error
+
+		if exists, err = o.checkExistingFile(ctx, targetPath); err != nil {
+			return err
+		}
 	}
 
 	r, err := f.Open(ctx)
@@ -406,12 +566,32 @@ func (o *FilesystemOutput) copyFileContent(ctx context.Context, targetPath strin
 	log(ctx).Debugf("copying file contents to: %v", targetPath)
 	targetPath = atomicfile.MaybePrefixLongFilenameOnWindows(targetPath)
 
+	var src io.Reader = r
+
+	if o.writeThrottler != nil {
+		src = &throttledReader{ctx, r, o.writeThrottler}
+	}
+
 	if o.WriteFilesAtomically {
+		if !exists {
+			// new files are written as unnamed temporary files where supported, which saves creating and renaming a named one.
+			if err := writeUsingTmpfile(targetPath, src, f.Size(), o.copier); !errors.Is(err, errTmpfileNotSupported) {
+				return err
+			}
+		}
+
+		// renaming replaces existing files, so check for them if it was skipped.
+		if fresh {
+			if _, err := o.checkExistingFile(ctx, targetPath); err != nil {
+				return err
+			}
+		}
+
 		//nolint:wrapcheck
-		return atomicfile.Write(targetPath, r)
+		return atomicfile.Write(targetPath, src)
 	}
 
-	return write(targetPath, r, f.Size(), o.copier)
+	return write(targetPath, src, f.Size(), o.copier, fresh)
 }
 
 func isEmptyDirectory(name string) (bool, error) {
@@ -0,0 +1,199 @@
+package restore
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+)
+
+func TestTargetOwner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("numeric user IDs are not supported on Windows")
+	}
+
+	u, err := user.Current()
+	require.NoError(t, err)
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	require.NoError(t, err)
+
+	oi := fs.OwnerInfo{
+		UserID:    12345,
+		GroupID:   23456,
+		UserName:  u.Username,
+		GroupName: "no-such-group-kopia-test",
+	}
+
+	byID := &FilesystemOutput{}
+	gotUID, gotGID := byID.targetOwner(oi)
+	require.Equal(t, uint32(12345), gotUID)
+	require.Equal(t, uint32(23456), gotGID)
+
+	byName := &FilesystemOutput{MapOwnersByName: true}
+	gotUID, gotGID = byName.targetOwner(oi)
+	require.Equal(t, uint32(uid), gotUID)
+
+	// unknown group name falls back to numeric ID.
+	require.Equal(t, uint32(23456), gotGID)
+}
+
+func TestCreateSymlink_Junction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("junctions are restored as symbolic links only on other operating systems")
+	}
+
+	ctx := testlogging.Context(t)
+	dir := mockfs.NewDirectory()
+	dir.AddSymlink("junction", "/some/target", 0o777).SetLinkType(fs.LinkTypeJunction)
+
+	e, err := dir.Child(ctx, "junction")
+	require.NoError(t, err)
+
+	o := &FilesystemOutput{TargetPath: t.TempDir(), SkipOwners: true}
+	require.NoError(t, o.CreateSymlink(ctx, "junction", e.(fs.Symlink)))
+
+	target, err := os.Readlink(filepath.Join(o.TargetPath, "junction"))
+	require.NoError(t, err)
+	require.Equal(t, "/some/target", target)
+}
+
+func TestDeferMetadata(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not fully supported on Windows")
+	}
+
+	ctx := testlogging.Context(t)
+	mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	root := mockfs.NewDirectory()
+	sub := root.AddDir("sub", 0o500)
+	sub.SetModTime(mtime)
+	sub.AddFile("f1", []byte("hello"), 0o400).SetModTime(mtime)
+	sub.AddSymlink("l1", "f1", 0o777)
+
+	o := &FilesystemOutput{TargetPath: t.TempDir(), SkipOwners: true, DeferMetadata: true}
+	require.NoError(t, o.Init(ctx))
+
+	require.NoError(t, o.BeginDirectory(ctx, "sub", sub))
+
+	for _, name := range []string{"f1", "l1"} {
+		e, err := sub.Child(ctx, name)
+		require.NoError(t, err)
+
+		switch e := e.(type) {
+		case fs.File:
+			require.NoError(t, o.WriteFile(ctx, "sub/"+name, e))
+		case fs.Symlink:
+			require.NoError(t, o.CreateSymlink(ctx, "sub/"+name, e))
+		}
+	}
+
+	require.NoError(t, o.FinishDirectory(ctx, "sub", sub))
+
+	subPath := filepath.Join(o.TargetPath, "sub")
+	filePath := filepath.Join(subPath, "f1")
+
+	// nothing has been applied yet.
+	st, err := os.Stat(filePath)
+	require.NoError(t, err)
+	require.NotEqual(t, mtime, st.ModTime().UTC())
+
+	require.NoError(t, o.Close(ctx))
+
+	st, err = os.Stat(filePath)
+	require.NoError(t, err)
+	require.Equal(t, mtime, st.ModTime().UTC())
+	require.Equal(t, os.FileMode(0o400), st.Mode().Perm())
+
+	st, err = os.Stat(subPath)
+	require.NoError(t, err)
+	require.Equal(t, mtime, st.ModTime().UTC())
+	require.Equal(t, os.FileMode(0o500), st.Mode().Perm())
+
+	// allow the directory to be cleaned up.
+	require.NoError(t, os.Chmod(subPath, 0o700))
+}
+
+func TestWriteFilesAtomically(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	dir := mockfs.NewDirectory()
+	dir.AddFile("f1", []byte("hello"), 0o600)
+	dir.AddFile("f2", []byte("world"), 0o600)
+
+	o := &FilesystemOutput{TargetPath: t.TempDir(), SkipOwners: true, WriteFilesAtomically: true, OverwriteFiles: true}
+	require.NoError(t, o.Init(ctx))
+
+	// f2 already exists and is replaced.
+	require.NoError(t, os.WriteFile(filepath.Join(o.TargetPath, "f2"), []byte("old contents"), 0o600))
+
+	for _, name := range []string{"f1", "f2"} {
+		e, err := dir.Child(ctx, name)
+		require.NoError(t, err)
+		require.NoError(t, o.WriteFile(ctx, name, e.(fs.File)))
+	}
+
+	require.NoError(t, o.Close(ctx))
+
+	b, err := os.ReadFile(filepath.Join(o.TargetPath, "f1"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(b))
+
+	b, err = os.ReadFile(filepath.Join(o.TargetPath, "f2"))
+	require.NoError(t, err)
+	require.Equal(t, "world", string(b))
+
+	entries, err := os.ReadDir(o.TargetPath)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+}
+
+func TestWriteFile_MapOwnersByName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("numeric user IDs are not supported on Windows")
+	}
+
+	ctx := testlogging.Context(t)
+
+	u, err := user.Current()
+	require.NoError(t, err)
+
+	g, err := user.LookupGroupId(u.Gid)
+	require.NoError(t, err)
+
+	dir := mockfs.NewDirectory()
+
+	// numeric IDs captured at snapshot time do not exist here, but the names do.
+	dir.AddFile("f1", []byte("hello"), 0o600).SetOwner(fs.OwnerInfo{
+		UserID:    12345,
+		GroupID:   23456,
+		UserName:  u.Username,
+		GroupName: g.Name,
+	})
+
+	o := &FilesystemOutput{TargetPath: t.TempDir(), MapOwnersByName: true}
+	require.NoError(t, o.Init(ctx))
+
+	e, err := dir.Child(ctx, "f1")
+	require.NoError(t, err)
+	require.NoError(t, o.WriteFile(ctx, "f1", e.(fs.File)))
+	require.NoError(t, o.Close(ctx))
+
+	le, err := localfs.NewEntry(filepath.Join(o.TargetPath, "f1"))
+	require.NoError(t, err)
+
+	oi := le.Owner()
+	require.Equal(t, u.Uid, strconv.FormatUint(uint64(oi.UserID), 10))
+	require.Equal(t, u.Gid, strconv.FormatUint(uint64(oi.GroupID), 10))
+}
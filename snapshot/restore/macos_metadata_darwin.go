@@ -0,0 +1,89 @@
+package restore
+
+import (
+	"context"
+	"os"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/localfs"
+	"github.com/kopia/kopia/internal/iocopy"
+)
+
+// setMacOSMetadata restores macOS-specific metadata of the entry, it must be called after setAttributes().
+func (o *FilesystemOutput) setMacOSMetadata(ctx context.Context, targetPath string, e fs.Entry) error {
+	me, ok := e.(fs.EntryWithMacOSMetadata)
+	if !ok || isSymlink(e) {
+		return nil
+	}
+
+	md, err := me.MacOSMetadata(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to get macOS metadata")
+	}
+
+	if md.IsEmpty() {
+		return nil
+	}
+
+	if md.ResourceForkSize > 0 && !e.IsDir() {
+		if err := writeResourceFork(ctx, targetPath, me); err != nil {
+			return err
+		}
+	}
+
+	if len(md.FinderInfo) > 0 {
+		if err := unix.Setxattr(targetPath, localfs.FinderInfoAttribute, md.FinderInfo, 0); err != nil {
+			return errors.Wrap(err, "unable to restore Finder info")
+		}
+	}
+
+	if md.Quarantine != "" {
+		if err := unix.Setxattr(targetPath, localfs.QuarantineAttribute, []byte(md.Quarantine), 0); err != nil {
+			return errors.Wrap(err, "unable to restore quarantine attribute")
+		}
+	}
+
+	if !md.BirthTime.IsZero() && !o.SkipTimes {
+		// setting modification time earlier than the birth time moves the birth time back,
+		// after which the modification time is restored again.
+		if err := o.maybeIgnorePermissionError(os.Chtimes(targetPath, md.BirthTime, md.BirthTime)); err != nil {
+			return errors.Wrap(err, "unable to restore birth time")
+		}
+
+		if err := o.maybeIgnorePermissionError(os.Chtimes(targetPath, e.ModTime(), e.ModTime())); err != nil {
+			return errors.Wrap(err, "could not change mod time")
+		}
+	}
+
+	if md.Flags != 0 {
+		// flags are restored last, since they may prevent further modifications of the entry.
+		if err := o.maybeIgnorePermissionError(unix.Chflags(targetPath, int(md.Flags))); err != nil {
+			return errors.Wrap(err, "unable to restore file flags")
+		}
+	}
+
+	return nil
+}
+
+func writeResourceFork(ctx context.Context, targetPath string, me fs.EntryWithMacOSMetadata) error {
+	r, err := me.OpenResourceFork(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to open resource fork")
+	}
+	defer r.Close() //nolint:errcheck
+
+	f, err := os.OpenFile(targetPath+localfs.ResourceForkSuffix, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, "unable to create resource fork")
+	}
+	defer f.Close() //nolint:errcheck
+
+	if _, err := iocopy.Copy(f, r); err != nil {
+		return errors.Wrap(err, "unable to write resource fork")
+	}
+
+	return errors.Wrap(f.Close(), "unable to close resource fork")
+}
@@ -0,0 +1,17 @@
+//go:build !darwin
+// +build !darwin
+
+package restore
+
+import (
+	"context"
+
+	"github.com/kopia/kopia/fs"
+)
+
+// setMacOSMetadata is a no-op on operating systems other than macOS, where such metadata can't be represented.
+//
+//nolint:revive
+func (o *FilesystemOutput) setMacOSMetadata(ctx context.Context, targetPath string, e fs.Entry) error {
+	return nil
+}
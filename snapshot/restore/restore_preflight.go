@@ -0,0 +1,157 @@
+package restore
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/stat"
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo/blob/throttling"
+)
+
+// ErrInsufficientDiskSpace is returned when the restore target does not have enough free space.
+var ErrInsufficientDiskSpace = errors.New("insufficient disk space")
+
+// EstimateRequiredSpace returns the number of bytes restoring the provided entry will add to the target,
+// based on the directory summaries recorded in the snapshot. Files already present in the target are
+// subtracted when they will be skipped in incremental mode or kept, and overwritten files only need
+// the space exceeding their existing size.
+func (o *FilesystemOutput) EstimateRequiredSpace(ctx context.Context, e fs.Entry, incremental bool) (int64, error) {
+	total, err := totalFileSize(ctx, e)
+	if err != nil {
+		return 0, err
+	}
+
+	reused, err := o.reusedSpace(ctx, e, "", incremental)
+	if err != nil {
+		return 0, err
+	}
+
+	return max(total-reused, 0), nil
+}
+
+func totalFileSize(ctx context.Context, e fs.Entry) (int64, error) {
+	if d, ok := e.(fs.DirectoryWithSummary); ok {
+		s, err := d.Summary(ctx)
+		if err != nil {
+			return 0, errors.Wrap(err, "error getting directory summary")
+		}
+
+		if s != nil {
+			return s.TotalFileSize, nil
+		}
+	}
+
+	return e.Size(), nil
+}
+
+// reusedSpace returns the number of bytes of files in the snapshot that don't need new space in the target,
+// only directories existing in both the snapshot and the target are visited.
+func (o *FilesystemOutput) reusedSpace(ctx context.Context, e fs.Entry, relativePath string, incremental bool) (int64, error) {
+	st, err := os.Lstat(filepath.Join(o.TargetPath, relativePath))
+	if err != nil {
+		// entries missing in the target don't reuse any space.
+		return 0, nil //nolint:nilerr
+	}
+
+	switch e := e.(type) {
+	case fs.Directory:
+		if !st.IsDir() {
+			return 0, nil
+		}
+
+		var total int64
+
+		err := fs.IterateEntries(ctx, e, func(ctx context.Context, child fs.Entry) error {
+			n, err := o.reusedSpace(ctx, child, path.Join(relativePath, child.Name()), incremental)
+			total += n
+
+			return err
+		})
+
+		return total, errors.Wrapf(err, "error reading directory %v", relativePath)
+
+	case fs.File:
+		if !st.Mode().IsRegular() {
+			return 0, nil
+		}
+
+		if incremental && o.FileExists(ctx, relativePath, e) {
+			return e.Size(), nil
+		}
+
+		switch o.ConflictStrategy {
+		case ConflictKeepExisting:
+			return e.Size(), nil
+
+		case ConflictOverwrite, ConflictKeepNewer:
+			return min(st.Size(), e.Size()), nil
+
+		case "":
+			if o.OverwriteFiles {
+				return min(st.Size(), e.Size()), nil
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// CheckDiskSpace returns ErrInsufficientDiskSpace when the filesystem where the target path will be created
+// has less than the required number of bytes available.
+func (o *FilesystemOutput) CheckDiskSpace(ctx context.Context, required int64) error {
+	p := nearestExistingPath(o.TargetPath)
+
+	free, err := stat.GetFreeSpace(p)
+	if err != nil {
+		return errors.Wrapf(err, "unable to determine free space of %v", p)
+	}
+
+	if required > 0 && uint64(required) > free {
+		return errors.Wrapf(ErrInsufficientDiskSpace, "restore requires %v but only %v is available on %v",
+			units.BytesString(required), units.BytesString(int64(free)), p)
+	}
+
+	log(ctx).Debugf("restore requires %v, %v available on %v", required, free, p)
+
+	return nil
+}
+
+// nearestExistingPath returns the provided path or its closest ancestor that exists.
+func nearestExistingPath(p string) string {
+	for {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+
+		parent := filepath.Dir(p)
+		if parent == p {
+			return p
+		}
+
+		p = parent
+	}
+}
+
+// throttledReader limits the rate of reading from the underlying reader,
+// which in turn limits the rate at which the data is written.
+type throttledReader struct {
+	ctx context.Context //nolint:containedctx
+	r   io.Reader
+	t   throttling.Throttler
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.t.BeforeUpload(r.ctx, int64(n))
+	}
+
+	return n, err //nolint:wrapcheck
+}
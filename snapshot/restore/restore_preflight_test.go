@@ -0,0 +1,105 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/blob/throttling"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	td := t.TempDir()
+
+	// target directory does not exist yet, free space is checked on its closest existing parent.
+	o := &FilesystemOutput{TargetPath: filepath.Join(td, "a", "b")}
+	require.Equal(t, td, nearestExistingPath(o.TargetPath))
+
+	require.NoError(t, o.CheckDiskSpace(ctx, 0))
+	require.NoError(t, o.CheckDiskSpace(ctx, 1000))
+	require.ErrorIs(t, o.CheckDiskSpace(ctx, math.MaxInt64), ErrInsufficientDiskSpace)
+}
+
+type dirWithSummary struct {
+	*mockfs.Directory
+	summary *fs.DirectorySummary
+}
+
+func (d dirWithSummary) Summary(ctx context.Context) (*fs.DirectorySummary, error) {
+	return d.summary, nil
+}
+
+func TestEstimateRequiredSpace(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	root := mockfs.NewDirectory()
+	root.AddFile("a", bytes.Repeat([]byte{1}, 100), 0o644)
+	root.AddDir("sub", 0o755).AddFile("b", bytes.Repeat([]byte{2}, 200), 0o644)
+
+	e := dirWithSummary{root, &fs.DirectorySummary{TotalFileSize: 300}}
+
+	td := t.TempDir()
+
+	estimate := func(o *FilesystemOutput, incremental bool) int64 {
+		t.Helper()
+
+		o.TargetPath = td
+
+		n, err := o.EstimateRequiredSpace(ctx, e, incremental)
+		require.NoError(t, err)
+
+		return n
+	}
+
+	// nothing exists in the target yet.
+	require.Equal(t, int64(300), estimate(&FilesystemOutput{OverwriteFiles: true}, false))
+
+	// 'a' matches the snapshot, 'sub/b' is smaller and will be overwritten.
+	require.NoError(t, os.WriteFile(filepath.Join(td, "a"), bytes.Repeat([]byte{1}, 100), 0o600))
+	require.NoError(t, os.Chtimes(filepath.Join(td, "a"), mockfs.DefaultModTime, mockfs.DefaultModTime))
+	require.NoError(t, os.Mkdir(filepath.Join(td, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "sub", "b"), bytes.Repeat([]byte{2}, 50), 0o600))
+
+	require.Equal(t, int64(200), estimate(&FilesystemOutput{}, true))
+	require.Equal(t, int64(150), estimate(&FilesystemOutput{OverwriteFiles: true}, false))
+	require.Equal(t, int64(150), estimate(&FilesystemOutput{OverwriteFiles: true}, true))
+	require.Equal(t, int64(300), estimate(&FilesystemOutput{ConflictStrategy: ConflictKeepBoth}, false))
+	require.Equal(t, int64(0), estimate(&FilesystemOutput{ConflictStrategy: ConflictKeepExisting}, false))
+}
+
+func TestThrottledReader(t *testing.T) {
+	ctx := testlogging.Context(t)
+
+	const (
+		bytesPerSecond = 1 << 20
+		dataLength     = bytesPerSecond / 4
+	)
+
+	thr, err := throttling.NewThrottler(throttling.Limits{UploadBytesPerSecond: bytesPerSecond}, writeThrottlingWindow, 0)
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte{1}, dataLength)
+
+	start := time.Now() //nolint:forbidigo
+
+	var buf bytes.Buffer
+
+	_, err = io.Copy(&buf, &throttledReader{ctx, bytes.NewReader(data), thr})
+	require.NoError(t, err)
+	require.Equal(t, data, buf.Bytes())
+
+	//nolint:forbidigo
+	require.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
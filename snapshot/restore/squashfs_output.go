@@ -0,0 +1,115 @@
+package restore
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/squashfs"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// SquashFSOutput contains the options for outputting a file system tree to a read-only SquashFS image,
+// which can be mounted or inspected without restoring individual files.
+type SquashFSOutput struct {
+	w  io.Closer
+	sw *squashfs.Writer
+}
+
+// Parallelizable implements restore.Output interface.
+func (o *SquashFSOutput) Parallelizable() bool {
+	return false
+}
+
+// BeginDirectory implements restore.Output interface.
+func (o *SquashFSOutput) BeginDirectory(ctx context.Context, relativePath string, d fs.Directory) error {
+	return errors.Wrap(o.sw.Mkdir(relativePath, squashFSEntryInfo(d)), "error adding directory to image")
+}
+
+// FinishDirectory implements restore.Output interface.
+//
+//nolint:revive
+func (o *SquashFSOutput) FinishDirectory(ctx context.Context, relativePath string, e fs.Directory) error {
+	return nil
+}
+
+// WriteDirEntry implements restore.Output interface.
+//
+//nolint:revive
+func (o *SquashFSOutput) WriteDirEntry(ctx context.Context, relativePath string, de *snapshot.DirEntry, e fs.Directory) error {
+	return nil
+}
+
+// Close implements restore.Output interface.
+func (o *SquashFSOutput) Close(ctx context.Context) error {
+	if err := o.sw.Close(); err != nil {
+		return errors.Wrap(err, "error closing squashfs image")
+	}
+
+	//nolint:wrapcheck
+	return o.w.Close()
+}
+
+// WriteFile implements restore.Output interface.
+func (o *SquashFSOutput) WriteFile(ctx context.Context, relativePath string, f fs.File) error {
+	r, err := f.Open(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error opening file")
+	}
+	defer r.Close() //nolint:errcheck
+
+	return errors.Wrap(o.sw.WriteFile(relativePath, r, squashFSEntryInfo(f)), "error adding file to image")
+}
+
+// FileExists implements restore.Output interface.
+//
+//nolint:revive
+func (o *SquashFSOutput) FileExists(ctx context.Context, relativePath string, f fs.File) bool {
+	return false
+}
+
+// CreateSymlink implements restore.Output interface.
+func (o *SquashFSOutput) CreateSymlink(ctx context.Context, relativePath string, l fs.Symlink) error {
+	target, err := l.Readlink(ctx)
+	if err != nil {
+		return errors.Wrap(err, "error reading link target")
+	}
+
+	return errors.Wrap(o.sw.Symlink(relativePath, target, squashFSEntryInfo(l)), "error adding symlink to image")
+}
+
+// SymlinkExists implements restore.Output interface.
+//
+//nolint:revive
+func (o *SquashFSOutput) SymlinkExists(ctx context.Context, relativePath string, l fs.Symlink) bool {
+	return false
+}
+
+func squashFSEntryInfo(e fs.Entry) squashfs.EntryInfo {
+	mode := e.Mode()
+	if e.IsDir() {
+		mode |= os.ModeDir
+	}
+
+	return squashfs.EntryInfo{
+		Mode:    mode,
+		UserID:  e.Owner().UserID,
+		GroupID: e.Owner().GroupID,
+		ModTime: e.ModTime(),
+	}
+}
+
+// NewSquashFSOutput creates new SquashFS image writer output. The output must be positioned at the beginning of the file.
+func NewSquashFSOutput(w io.WriteSeeker, c io.Closer) (*SquashFSOutput, error) {
+	sw, err := squashfs.NewWriter(w)
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing squashfs image")
+	}
+
+	return &SquashFSOutput{c, sw}, nil
+}
+
+var _ Output = (*SquashFSOutput)(nil)
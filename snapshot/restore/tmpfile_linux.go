@@ -0,0 +1,53 @@
+package restore
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// procSelfFDAvailable determines whether procfs, which is required to link temporary files, is available.
+var procSelfFDAvailable = sync.OnceValue(func() bool {
+	_, err := os.Stat("/proc/self/fd")
+	return err == nil
+})
+
+// writeUsingTmpfile writes a new file as an unnamed temporary file created with O_TMPFILE in the target
+// directory and links it under its final name once complete, so partially written files never become visible.
+// It returns errTmpfileNotSupported without consuming the reader if the target filesystem does not support it.
+func writeUsingTmpfile(targetPath string, r io.Reader, size int64, c streamCopier) error {
+	if !procSelfFDAvailable() {
+		return errTmpfileNotSupported
+	}
+
+	fd, err := unix.Open(filepath.Dir(targetPath), unix.O_TMPFILE|unix.O_RDWR|unix.O_CLOEXEC, 0o600) //nolint:gomnd
+	if err != nil {
+		if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.EISDIR) || errors.Is(err, unix.EINVAL) {
+			return errTmpfileNotSupported
+		}
+
+		return errors.Wrap(err, "unable to create temporary file")
+	}
+
+	f := os.NewFile(uintptr(fd), targetPath)
+	defer f.Close() //nolint:errcheck
+
+	if err := f.Truncate(size); err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	if _, err := c(f, r); err != nil {
+		return errors.Wrapf(err, "cannot write data to file %q", targetPath)
+	}
+
+	if err := unix.Linkat(unix.AT_FDCWD, fmt.Sprintf("/proc/self/fd/%v", fd), unix.AT_FDCWD, targetPath, unix.AT_SYMLINK_FOLLOW); err != nil {
+		return errors.Wrapf(err, "unable to link %q", targetPath)
+	}
+
+	return f.Close() //nolint:wrapcheck
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+// +build !linux
+
+package restore
+
+import (
+	"io"
+)
+
+//nolint:revive
+func writeUsingTmpfile(targetPath string, r io.Reader, size int64, c streamCopier) error {
+	return errTmpfileNotSupported
+}
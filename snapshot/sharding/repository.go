@@ -0,0 +1,291 @@
+package sharding
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// Repository routes reads of a sharded repository. Snapshots of sources are read from shards holding them,
+// all other manifests such as policies are read from the primary repository. Objects and contents are looked
+// up in the primary repository first and then in shards.
+//
+// Writers created using NewWriter() only write to the primary repository.
+type Repository struct {
+	repo.Repository // primary
+
+	cfg    *Config
+	shards []namedRepository
+}
+
+type namedRepository struct {
+	name string
+	rep  repo.Repository
+}
+
+// NewRepository returns a repository which routes reads to the primary repository and the provided shards.
+// Closing the returned repository closes the shards but not the primary repository.
+func NewRepository(primary repo.Repository, cfg *Config, shards map[string]repo.Repository) *Repository {
+	r := &Repository{Repository: primary, cfg: cfg}
+
+	for name, rep := range shards {
+		r.shards = append(r.shards, namedRepository{name, rep})
+	}
+
+	sort.Slice(r.shards, func(i, j int) bool {
+		return r.shards[i].name < r.shards[j].name
+	})
+
+	return r
+}
+
+// all returns the primary repository followed by all shards.
+func (r *Repository) all() []repo.Repository {
+	result := []repo.Repository{r.Repository}
+
+	for _, s := range r.shards {
+		result = append(result, s.rep)
+	}
+
+	return result
+}
+
+// snapshotRepositories returns repositories which may hold snapshots matching the provided labels.
+func (r *Repository) snapshotRepositories(labels map[string]string) ([]repo.Repository, error) {
+	if _, ok := labels[snapshot.PathLabel]; !ok {
+		return r.all(), nil
+	}
+
+	name, ok := r.cfg.Placement(sourceInfoFromLabels(labels))
+	if !ok {
+		// sources snapshotted without sharding may be in any repository.
+		return r.all(), nil
+	}
+
+	if name == PrimaryShard {
+		return []repo.Repository{r.Repository}, nil
+	}
+
+	for _, s := range r.shards {
+		if s.name == name {
+			return []repo.Repository{s.rep}, nil
+		}
+	}
+
+	return nil, errors.Errorf("shard %q is not open", name)
+}
+
+// FindManifests implements repo.Repository.
+func (r *Repository) FindManifests(ctx context.Context, labels map[string]string) ([]*manifest.EntryMetadata, error) {
+	if labels[manifest.TypeLabelKey] != snapshot.ManifestType {
+		//nolint:wrapcheck
+		return r.Repository.FindManifests(ctx, labels)
+	}
+
+	reps, err := r.snapshotRepositories(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*manifest.EntryMetadata
+
+	for _, rep := range reps {
+		entries, err := rep.FindManifests(ctx, labels)
+		if err != nil {
+			return nil, errors.Wrap(err, "error finding manifests")
+		}
+
+		result = append(result, entries...)
+	}
+
+	return result, nil
+}
+
+// GetManifest implements repo.Repository.
+func (r *Repository) GetManifest(ctx context.Context, id manifest.ID, data interface{}) (*manifest.EntryMetadata, error) {
+	for _, rep := range r.all() {
+		em, err := rep.GetManifest(ctx, id, data)
+		if !errors.Is(err, manifest.ErrNotFound) {
+			return em, err //nolint:wrapcheck
+		}
+	}
+
+	return nil, manifest.ErrNotFound
+}
+
+// OpenObject implements repo.Repository.
+func (r *Repository) OpenObject(ctx context.Context, id object.ID) (object.Reader, error) {
+	for _, rep := range r.all() {
+		or, err := rep.OpenObject(ctx, id)
+		if !isNotFound(err) {
+			return or, err //nolint:wrapcheck
+		}
+	}
+
+	return nil, errors.Wrapf(object.ErrObjectNotFound, "object %v not found in any shard", id)
+}
+
+// VerifyObject implements repo.Repository.
+func (r *Repository) VerifyObject(ctx context.Context, id object.ID) ([]content.ID, error) {
+	for _, rep := range r.all() {
+		cids, err := rep.VerifyObject(ctx, id)
+		if !isNotFound(err) {
+			return cids, err //nolint:wrapcheck
+		}
+	}
+
+	return nil, errors.Wrapf(object.ErrObjectNotFound, "object %v not found in any shard", id)
+}
+
+// ContentInfo implements repo.Repository.
+func (r *Repository) ContentInfo(ctx context.Context, contentID content.ID) (content.Info, error) {
+	for _, rep := range r.all() {
+		ci, err := rep.ContentInfo(ctx, contentID)
+		if !errors.Is(err, content.ErrContentNotFound) {
+			return ci, err //nolint:wrapcheck
+		}
+	}
+
+	return content.Info{}, content.ErrContentNotFound
+}
+
+// PrefetchContents implements repo.Repository.
+func (r *Repository) PrefetchContents(ctx context.Context, contentIDs []content.ID, hint string) []content.ID {
+	var result []content.ID
+
+	for _, rep := range r.all() {
+		result = append(result, rep.PrefetchContents(ctx, contentIDs, hint)...)
+	}
+
+	return result
+}
+
+// PrefetchObjects implements repo.Repository.
+func (r *Repository) PrefetchObjects(ctx context.Context, objectIDs []object.ID, hint string) ([]content.ID, error) {
+	var result []content.ID
+
+	for _, rep := range r.all() {
+		cids, err := rep.PrefetchObjects(ctx, objectIDs, hint)
+		if err != nil && !isNotFound(err) {
+			return nil, errors.Wrap(err, "error prefetching objects")
+		}
+
+		result = append(result, cids...)
+	}
+
+	return result, nil
+}
+
+// Refresh implements repo.Repository.
+func (r *Repository) Refresh(ctx context.Context) error {
+	for _, rep := range r.all() {
+		if err := rep.Refresh(ctx); err != nil {
+			return errors.Wrap(err, "error refreshing repository")
+		}
+	}
+
+	return nil
+}
+
+// Close closes all shards, the primary repository is left open.
+func (r *Repository) Close(ctx context.Context) error {
+	var lastErr error
+
+	for _, s := range r.shards {
+		if err := s.rep.Close(ctx); err != nil {
+			lastErr = errors.Wrapf(err, "error closing shard %v", s.name)
+		}
+	}
+
+	return lastErr
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, object.ErrObjectNotFound) || errors.Is(err, content.ErrContentNotFound)
+}
+
+// sourceWriter writes snapshots of a source held in a shard, while policies and other manifests are read from
+// and written to the primary repository.
+type sourceWriter struct {
+	repo.RepositoryWriter // shard
+
+	primary repo.RepositoryWriter
+}
+
+// NewSourceWriter returns a writer which writes snapshots and their data to the provided shard and all other
+// manifests, such as policies, to the primary repository.
+func NewSourceWriter(shard, primary repo.RepositoryWriter) repo.RepositoryWriter {
+	return &sourceWriter{shard, primary}
+}
+
+func (w *sourceWriter) writerFor(labels map[string]string) repo.RepositoryWriter {
+	if labels[manifest.TypeLabelKey] == snapshot.ManifestType {
+		return w.RepositoryWriter
+	}
+
+	return w.primary
+}
+
+// FindManifests implements repo.Repository.
+func (w *sourceWriter) FindManifests(ctx context.Context, labels map[string]string) ([]*manifest.EntryMetadata, error) {
+	//nolint:wrapcheck
+	return w.writerFor(labels).FindManifests(ctx, labels)
+}
+
+// GetManifest implements repo.Repository.
+func (w *sourceWriter) GetManifest(ctx context.Context, id manifest.ID, data interface{}) (*manifest.EntryMetadata, error) {
+	em, err := w.RepositoryWriter.GetManifest(ctx, id, data)
+	if errors.Is(err, manifest.ErrNotFound) {
+		//nolint:wrapcheck
+		return w.primary.GetManifest(ctx, id, data)
+	}
+
+	return em, err //nolint:wrapcheck
+}
+
+// PutManifest implements repo.RepositoryWriter.
+func (w *sourceWriter) PutManifest(ctx context.Context, labels map[string]string, payload interface{}) (manifest.ID, error) {
+	//nolint:wrapcheck
+	return w.writerFor(labels).PutManifest(ctx, labels, payload)
+}
+
+// ReplaceManifests implements repo.RepositoryWriter.
+func (w *sourceWriter) ReplaceManifests(ctx context.Context, labels map[string]string, payload interface{}) (manifest.ID, error) {
+	//nolint:wrapcheck
+	return w.writerFor(labels).ReplaceManifests(ctx, labels, payload)
+}
+
+// DeleteManifest implements repo.RepositoryWriter.
+func (w *sourceWriter) DeleteManifest(ctx context.Context, id manifest.ID) error {
+	var payload json.RawMessage
+
+	_, err := w.RepositoryWriter.GetManifest(ctx, id, &payload)
+	if errors.Is(err, manifest.ErrNotFound) {
+		//nolint:wrapcheck
+		return w.primary.DeleteManifest(ctx, id)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "unable to look up manifest %v", id)
+	}
+
+	//nolint:wrapcheck
+	return w.RepositoryWriter.DeleteManifest(ctx, id)
+}
+
+// Flush implements repo.RepositoryWriter.
+func (w *sourceWriter) Flush(ctx context.Context) error {
+	if err := w.RepositoryWriter.Flush(ctx); err != nil {
+		return errors.Wrap(err, "error flushing shard")
+	}
+
+	return errors.Wrap(w.primary.Flush(ctx), "error flushing primary repository")
+}
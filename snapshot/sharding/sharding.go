@@ -0,0 +1,359 @@
+// Package sharding assigns snapshot sources to one of multiple repositories, which allows installations
+// with very large numbers of sources to spread their data and metadata across repositories while
+// using a single connection.
+//
+// The list of shards and placements of sources are stored in the primary repository, which is the
+// repository the connection was made to, so that all clients agree on where each source is held.
+// Policies are always stored in the primary repository. Since each client connects to repositories
+// on its own, configuration files used to open shards are stored locally next to the configuration
+// of the primary repository.
+package sharding
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// PrimaryShard is the name of the shard which is the repository the connection was made to.
+const PrimaryShard = "primary"
+
+// Manifest types used to store sharding configuration in the primary repository.
+const (
+	ConfigManifestType    = "shardingConfig"
+	PlacementManifestType = "shardPlacement"
+)
+
+// shardLabel is the label of placement manifests holding the name of the shard.
+const shardLabel = "shard"
+
+// connectionsFileSuffix is appended to the name of the repository configuration file to get the name
+// of the file with configuration files of shards.
+const connectionsFileSuffix = ".shards"
+
+// Shard describes a single repository holding a subset of sources.
+type Shard struct {
+	Name string `json:"name"`
+
+	// UniqueID is the hex-encoded unique ID of the repository, used to verify that clients connect to the right shard.
+	UniqueID string `json:"uniqueID,omitempty"`
+
+	// Draining shards don't receive new sources and existing sources are moved away from them during rebalancing.
+	Draining bool `json:"draining,omitempty"`
+}
+
+// Config describes repositories which hold snapshots of sources in addition to the primary repository.
+type Config struct {
+	Shards []Shard `json:"shards"`
+
+	// Placements records shards holding existing sources, which takes precedence over hash-based
+	// placement, so that adding shards does not move sources until they are rebalanced.
+	Placements map[string]string `json:"-"`
+}
+
+// Move describes a source that needs to be moved between shards when rebalancing.
+type Move struct {
+	Source snapshot.SourceInfo `json:"source"`
+	From   string              `json:"from"`
+	To     string              `json:"to"`
+}
+
+// LoadConfig loads sharding configuration stored in the primary repository, repositories without
+// shards return empty configuration.
+func LoadConfig(ctx context.Context, rep repo.Repository) (*Config, error) {
+	c := &Config{}
+
+	entries, err := rep.FindManifests(ctx, map[string]string{manifest.TypeLabelKey: ConfigManifestType})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find sharding configuration")
+	}
+
+	if em := manifest.PickLatestID(entries); em != "" {
+		if _, err := rep.GetManifest(ctx, em, c); err != nil {
+			return nil, errors.Wrap(err, "unable to load sharding configuration")
+		}
+	}
+
+	placements, err := rep.FindManifests(ctx, map[string]string{manifest.TypeLabelKey: PlacementManifestType})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find source placements")
+	}
+
+	// when placement was recorded concurrently, the latest one wins.
+	sort.Slice(placements, func(i, j int) bool {
+		return placements[i].ModTime.Before(placements[j].ModTime)
+	})
+
+	for _, em := range placements {
+		c.SetPlacement(sourceInfoFromLabels(em.Labels), em.Labels[shardLabel])
+	}
+
+	return c, nil
+}
+
+// SaveShards stores the list of shards in the primary repository.
+func SaveShards(ctx context.Context, w repo.RepositoryWriter, c *Config) error {
+	_, err := w.ReplaceManifests(ctx, map[string]string{manifest.TypeLabelKey: ConfigManifestType}, c)
+
+	return errors.Wrap(err, "unable to save sharding configuration")
+}
+
+// SavePlacement records in the primary repository that the source is held in the provided shard.
+func SavePlacement(ctx context.Context, w repo.RepositoryWriter, c *Config, si snapshot.SourceInfo, shard string) error {
+	labels := map[string]string{
+		manifest.TypeLabelKey:  PlacementManifestType,
+		snapshot.HostnameLabel: si.Host,
+		snapshot.UsernameLabel: si.UserName,
+		snapshot.PathLabel:     si.Path,
+	}
+
+	existing, err := w.FindManifests(ctx, labels)
+	if err != nil {
+		return errors.Wrap(err, "unable to find source placement")
+	}
+
+	for _, em := range existing {
+		if err := w.DeleteManifest(ctx, em.ID); err != nil {
+			return errors.Wrap(err, "unable to delete previous source placement")
+		}
+	}
+
+	labels[shardLabel] = shard
+
+	if _, err := w.PutManifest(ctx, labels, struct{}{}); err != nil {
+		return errors.Wrap(err, "unable to save source placement")
+	}
+
+	c.SetPlacement(si, shard)
+
+	return nil
+}
+
+func sourceInfoFromLabels(labels map[string]string) snapshot.SourceInfo {
+	return snapshot.SourceInfo{Host: labels[snapshot.HostnameLabel], UserName: labels[snapshot.UsernameLabel], Path: labels[snapshot.PathLabel]}
+}
+
+// IsSharded returns true if any shards have been added besides the primary one.
+func (c *Config) IsSharded() bool {
+	return len(c.Shards) > 0
+}
+
+// Shard returns the shard with the provided name.
+func (c *Config) Shard(name string) (Shard, bool) {
+	for _, s := range c.Shards {
+		if s.Name == name {
+			return s, true
+		}
+	}
+
+	return Shard{}, false
+}
+
+// AddShard adds a new shard with the provided name and repository unique ID.
+func (c *Config) AddShard(name, uniqueID string) error {
+	if name == "" || name == PrimaryShard {
+		return errors.Errorf("invalid shard name %q", name)
+	}
+
+	if _, ok := c.Shard(name); ok {
+		return errors.Errorf("shard %q already exists", name)
+	}
+
+	c.Shards = append(c.Shards, Shard{Name: name, UniqueID: uniqueID})
+
+	return nil
+}
+
+// RemoveShard removes the provided shard if it does not hold any sources, otherwise the shard is marked
+// as draining and false is returned, the shard will be removed once rebalancing moves all sources away.
+func (c *Config) RemoveShard(name string) (removed bool, err error) {
+	if _, ok := c.Shard(name); !ok {
+		return false, errors.Errorf("shard %q not found", name)
+	}
+
+	if c.SourceCount(name) > 0 {
+		for i := range c.Shards {
+			if c.Shards[i].Name == name {
+				c.Shards[i].Draining = true
+			}
+		}
+
+		return false, nil
+	}
+
+	var remaining []Shard
+
+	for _, s := range c.Shards {
+		if s.Name != name {
+			remaining = append(remaining, s)
+		}
+	}
+
+	c.Shards = remaining
+
+	return true, nil
+}
+
+// SourceCount returns the number of sources placed in the provided shard.
+func (c *Config) SourceCount(name string) int {
+	cnt := 0
+
+	for _, s := range c.Placements {
+		if s == name {
+			cnt++
+		}
+	}
+
+	return cnt
+}
+
+// activeShardNames returns names of all shards which can receive new sources, including the primary shard.
+func (c *Config) activeShardNames() []string {
+	result := []string{PrimaryShard}
+
+	for _, s := range c.Shards {
+		if !s.Draining {
+			result = append(result, s.Name)
+		}
+	}
+
+	return result
+}
+
+// HashPlacement returns the shard to which the source is assigned by consistent hashing.
+// Rendezvous hashing is used, so adding a shard only moves sources to the new shard and
+// removing a shard only moves sources away from it.
+func (c *Config) HashPlacement(si snapshot.SourceInfo) string {
+	var (
+		best      string
+		bestScore uint64
+	)
+
+	for _, name := range c.activeShardNames() {
+		h := sha256.Sum256([]byte(name + "\x00" + si.String()))
+
+		if score := binary.BigEndian.Uint64(h[:]); best == "" || score > bestScore {
+			best, bestScore = name, score
+		}
+	}
+
+	return best
+}
+
+// Placement returns the shard where the source was placed, if any.
+func (c *Config) Placement(si snapshot.SourceInfo) (string, bool) {
+	s, ok := c.Placements[si.String()]
+
+	return s, ok
+}
+
+// Locate returns the shard holding the provided source, which is where the source was placed
+// previously or where consistent hashing places it.
+func (c *Config) Locate(si snapshot.SourceInfo) string {
+	if s, ok := c.Placement(si); ok {
+		return s
+	}
+
+	return c.HashPlacement(si)
+}
+
+// SetPlacement records in memory that the source is held in the provided shard, use SavePlacement to persist it.
+func (c *Config) SetPlacement(si snapshot.SourceInfo, shard string) {
+	if c.Placements == nil {
+		c.Placements = map[string]string{}
+	}
+
+	c.Placements[si.String()] = shard
+}
+
+// PlanRebalance returns moves needed to place all sources where consistent hashing assigns them.
+func (c *Config) PlanRebalance() ([]Move, error) {
+	var result []Move
+
+	for src, shard := range c.Placements {
+		si, err := snapshot.ParseSourceInfo(src, "", "")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid source %q", src)
+		}
+
+		if want := c.HashPlacement(si); want != shard {
+			result = append(result, Move{Source: si, From: shard, To: want})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Source.String() < result[j].Source.String()
+	})
+
+	return result, nil
+}
+
+// RemoveDrainedShards removes draining shards that no longer hold any sources and returns their names.
+func (c *Config) RemoveDrainedShards() []string {
+	var (
+		remaining []Shard
+		removed   []string
+	)
+
+	for _, s := range c.Shards {
+		if s.Draining && c.SourceCount(s.Name) == 0 {
+			removed = append(removed, s.Name)
+			continue
+		}
+
+		remaining = append(remaining, s)
+	}
+
+	c.Shards = remaining
+
+	return removed
+}
+
+// Connections maps names of shards to configuration files used by this client to open them.
+type Connections map[string]string
+
+// ConnectionsFileName returns the name of the file with shard connections for the provided repository configuration file.
+func ConnectionsFileName(repoConfigFile string) string {
+	return repoConfigFile + connectionsFileSuffix
+}
+
+// LoadConnections loads shard connections from the provided file, if the file does not exist,
+// no connections are returned.
+func LoadConnections(fname string) (Connections, error) {
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if os.IsNotExist(err) {
+		return Connections{}, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read shard connections")
+	}
+
+	c := Connections{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, errors.Wrap(err, "invalid shard connections")
+	}
+
+	return c, nil
+}
+
+// Save writes shard connections to the provided file.
+func (c Connections) Save(fname string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal shard connections")
+	}
+
+	return errors.Wrap(atomicfile.Write(fname, bytes.NewReader(b)), "unable to write shard connections")
+}
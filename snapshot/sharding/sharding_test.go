@@ -0,0 +1,227 @@
+package sharding_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/format"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/sharding"
+)
+
+func testSources(n int) []snapshot.SourceInfo {
+	var result []snapshot.SourceInfo
+
+	for i := 0; i < n; i++ {
+		result = append(result, snapshot.SourceInfo{
+			UserName: "user",
+			Host:     fmt.Sprintf("host%v", i),
+			Path:     "/data",
+		})
+	}
+
+	return result
+}
+
+func TestHashPlacement(t *testing.T) {
+	c := &sharding.Config{}
+	sources := testSources(1000)
+
+	for _, si := range sources {
+		require.Equal(t, sharding.PrimaryShard, c.HashPlacement(si))
+	}
+
+	require.NoError(t, c.AddShard("s1", ""))
+	require.NoError(t, c.AddShard("s2", ""))
+	require.Error(t, c.AddShard("s2", ""))
+	require.Error(t, c.AddShard(sharding.PrimaryShard, ""))
+
+	before := map[snapshot.SourceInfo]string{}
+	counts := map[string]int{}
+
+	for _, si := range sources {
+		before[si] = c.HashPlacement(si)
+		counts[before[si]]++
+	}
+
+	// sources are spread roughly evenly.
+	for _, name := range []string{sharding.PrimaryShard, "s1", "s2"} {
+		require.Greater(t, counts[name], 250, name)
+	}
+
+	require.NoError(t, c.AddShard("s3", ""))
+
+	// adding a shard only moves sources to the new shard.
+	moved := 0
+
+	for _, si := range sources {
+		if after := c.HashPlacement(si); after != before[si] {
+			require.Equal(t, "s3", after)
+
+			moved++
+		}
+	}
+
+	require.Greater(t, moved, 150)
+	require.Less(t, moved, 350)
+}
+
+func TestPlacementAndRebalance(t *testing.T) {
+	c := &sharding.Config{}
+	sources := testSources(100)
+
+	// existing sources are pinned to the primary shard.
+	for _, si := range sources {
+		c.SetPlacement(si, sharding.PrimaryShard)
+	}
+
+	require.NoError(t, c.AddShard("s1", ""))
+
+	for _, si := range sources {
+		require.Equal(t, sharding.PrimaryShard, c.Locate(si))
+	}
+
+	moves, err := c.PlanRebalance()
+	require.NoError(t, err)
+	require.NotEmpty(t, moves)
+
+	for _, m := range moves {
+		require.Equal(t, sharding.PrimaryShard, m.From)
+		require.Equal(t, "s1", m.To)
+		c.SetPlacement(m.Source, m.To)
+	}
+
+	moves, err = c.PlanRebalance()
+	require.NoError(t, err)
+	require.Empty(t, moves)
+
+	// shard holding sources is drained before removal.
+	removed, err := c.RemoveShard("s1")
+	require.NoError(t, err)
+	require.False(t, removed)
+
+	moves, err = c.PlanRebalance()
+	require.NoError(t, err)
+	require.Len(t, moves, c.SourceCount("s1"))
+
+	for _, m := range moves {
+		require.Equal(t, "s1", m.From)
+		require.Equal(t, sharding.PrimaryShard, m.To)
+		c.SetPlacement(m.Source, m.To)
+	}
+
+	require.Equal(t, []string{"s1"}, c.RemoveDrainedShards())
+	require.False(t, c.IsSharded())
+}
+
+func TestConfigSaveLoad(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, format.FormatVersion3)
+
+	c, err := sharding.LoadConfig(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.False(t, c.IsSharded())
+
+	si := testSources(1)[0]
+
+	require.NoError(t, c.AddShard("s1", "abcd"))
+	require.NoError(t, sharding.SaveShards(ctx, env.RepositoryWriter, c))
+	require.NoError(t, sharding.SavePlacement(ctx, env.RepositoryWriter, c, si, sharding.PrimaryShard))
+	require.NoError(t, sharding.SavePlacement(ctx, env.RepositoryWriter, c, si, "s1"))
+
+	c2, err := sharding.LoadConfig(ctx, env.RepositoryWriter)
+	require.NoError(t, err)
+	require.Equal(t, c, c2)
+	require.Equal(t, "s1", c2.Locate(si))
+}
+
+func TestConnectionsSaveLoad(t *testing.T) {
+	fname := sharding.ConnectionsFileName(filepath.Join(t.TempDir(), "repository.config"))
+
+	c, err := sharding.LoadConnections(fname)
+	require.NoError(t, err)
+	require.Empty(t, c)
+
+	c["s1"] = "s1.config"
+	require.NoError(t, c.Save(fname))
+
+	c2, err := sharding.LoadConnections(fname)
+	require.NoError(t, err)
+	require.Equal(t, c, c2)
+}
+
+func TestRepositoryRouting(t *testing.T) {
+	ctx, primary := repotesting.NewEnvironment(t, format.FormatVersion3)
+	_, shard := repotesting.NewEnvironment(t, format.FormatVersion3)
+
+	sources := testSources(2)
+	inPrimary, inShard := sources[0], sources[1]
+
+	cfg := &sharding.Config{}
+	require.NoError(t, cfg.AddShard("s1", ""))
+	require.NoError(t, sharding.SavePlacement(ctx, primary.RepositoryWriter, cfg, inPrimary, sharding.PrimaryShard))
+	require.NoError(t, sharding.SavePlacement(ctx, primary.RepositoryWriter, cfg, inShard, "s1"))
+
+	writeSnapshot := func(w repo.RepositoryWriter, si snapshot.SourceInfo) object.ID {
+		t.Helper()
+
+		ow := w.NewObjectWriter(ctx, object.WriterOptions{})
+		_, err := ow.Write([]byte(si.String()))
+		require.NoError(t, err)
+
+		oid, err := ow.Result()
+		require.NoError(t, err)
+
+		_, err = snapshot.SaveSnapshot(ctx, w, &snapshot.Manifest{Source: si, RootEntry: &snapshot.DirEntry{ObjectID: oid}})
+		require.NoError(t, err)
+
+		return oid
+	}
+
+	writeSnapshot(primary.RepositoryWriter, inPrimary)
+
+	// policies of sources held in shards are written to the primary repository.
+	sw := sharding.NewSourceWriter(shard.RepositoryWriter, primary.RepositoryWriter)
+	shardOID := writeSnapshot(sw, inShard)
+	require.NoError(t, policy.SetPolicy(ctx, sw, inShard, &policy.Policy{}))
+	require.NoError(t, sw.Flush(ctx))
+
+	_, err := policy.GetDefinedPolicy(ctx, primary.RepositoryWriter, inShard)
+	require.NoError(t, err)
+
+	r := sharding.NewRepository(primary.RepositoryWriter, cfg, map[string]repo.Repository{"s1": shard.RepositoryWriter})
+
+	all, err := snapshot.ListSources(ctx, r)
+	require.NoError(t, err)
+	require.ElementsMatch(t, sources, all)
+
+	for _, si := range sources {
+		snaps, err := snapshot.ListSnapshots(ctx, r, si)
+		require.NoError(t, err)
+		require.Len(t, snaps, 1)
+
+		// snapshot IDs are resolved in any shard.
+		_, err = snapshot.LoadSnapshot(ctx, r, snaps[0].ID)
+		require.NoError(t, err)
+	}
+
+	_, err = policy.GetDefinedPolicy(ctx, r, inShard)
+	require.NoError(t, err)
+
+	or, err := r.OpenObject(ctx, shardOID)
+	require.NoError(t, err)
+	require.NoError(t, or.Close())
+
+	missing, err := object.ParseID(strings.Repeat("ab", 32))
+	require.NoError(t, err)
+
+	_, err = r.OpenObject(ctx, missing)
+	require.ErrorIs(t, err, object.ErrObjectNotFound)
+}
@@ -0,0 +1,395 @@
+package snapshotfs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/units"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+const (
+	// number of bytes read from the beginning of each sampled file.
+	entropySampleSize = 64 << 10
+
+	// contents with entropy above this level (in bits per byte) look encrypted or compressed.
+	highEntropyThreshold = 7.5
+
+	// minimum increase of entropy of a modified file to be considered an entropy spike.
+	minEntropyIncrease = 1.0
+)
+
+// ChangeProfile summarizes changes of files between two snapshots of the same source.
+type ChangeProfile struct {
+	PreviousFileCount int64 `json:"previousFiles"`
+	ModifiedFileCount int64 `json:"modifiedFiles"`
+	DeletedFileCount  int64 `json:"deletedFiles"`
+	AddedFileCount    int64 `json:"addedFiles"`
+
+	// total size of added and modified files.
+	NewBytes int64 `json:"newBytes"`
+
+	// deleted files replaced by a file with the same name but a different or an additional extension.
+	ExtensionChangeCount int64 `json:"extensionChanges"`
+
+	// modified files whose contents have been sampled and how many of them became high-entropy.
+	SampledFileCount     int `json:"sampledFiles"`
+	EntropyIncreaseCount int `json:"entropyIncreases"`
+
+	samples []sampledFile
+}
+
+// sampledFile holds object IDs of a modified file in the previous and current snapshot.
+type sampledFile struct {
+	previous, current object.ID
+}
+
+// ChangedFileCount returns the number of modified, deleted and added files.
+func (p *ChangeProfile) ChangedFileCount() int64 {
+	return p.ModifiedFileCount + p.DeletedFileCount + p.AddedFileCount
+}
+
+// ChangedFilesPercent returns the percentage of files from the previous snapshot which have been modified or deleted.
+func (p *ChangeProfile) ChangedFilesPercent() float64 {
+	return percentage(p.ModifiedFileCount+p.DeletedFileCount, p.PreviousFileCount)
+}
+
+// EntropyIncreasePercent returns the percentage of sampled files whose contents became high-entropy.
+func (p *ChangeProfile) EntropyIncreasePercent() float64 {
+	return percentage(int64(p.EntropyIncreaseCount), int64(p.SampledFileCount))
+}
+
+// ExtensionChangesPercent returns the percentage of files from the previous snapshot whose extension has changed.
+func (p *ChangeProfile) ExtensionChangesPercent() float64 {
+	return percentage(p.ExtensionChangeCount, p.PreviousFileCount)
+}
+
+// RansomwareIndicators returns descriptions of indicators of ransomware activity exceeding the thresholds
+// of the provided policy. Because each indicator on its own is common in legitimate changes, such as
+// replacing a directory or compressing files, the result is empty unless at least two of them are present.
+func (p *ChangeProfile) RansomwareIndicators(pol *policy.AnomalyDetectionPolicy) []string {
+	if p.PreviousFileCount < int64(pol.MinFiles.OrDefault(0)) {
+		return nil
+	}
+
+	var result []string
+
+	if v := p.ChangedFilesPercent(); v >= float64(pol.ChangedFilesPercent.OrDefault(100)) { //nolint:gomnd
+		result = append(result, fmt.Sprintf("%.1f%% of files modified or deleted", v))
+	}
+
+	if v := p.EntropyIncreasePercent(); p.SampledFileCount > 0 && v >= float64(pol.EntropyIncreasePercent.OrDefault(100)) { //nolint:gomnd
+		result = append(result, fmt.Sprintf("%.1f%% of sampled files became high-entropy", v))
+	}
+
+	if v := p.ExtensionChangesPercent(); v >= float64(pol.ExtensionChangesPercent.OrDefault(100)) { //nolint:gomnd
+		result = append(result, fmt.Sprintf("%.1f%% of files had their extension changed", v))
+	}
+
+	if len(result) < 2 { //nolint:gomnd
+		return nil
+	}
+
+	return result
+}
+
+// ChangeRateViolations returns descriptions of changes exceeding the expected change rate of the provided policy.
+func (p *ChangeProfile) ChangeRateViolations(pol *policy.AnomalyDetectionPolicy) []string {
+	var result []string
+
+	if limit := pol.MaxNewBytes; limit != nil && p.NewBytes > int64(*limit) {
+		result = append(result, fmt.Sprintf("%v of new or modified files exceed the expected %v", units.BytesString(p.NewBytes), units.BytesString(int64(*limit))))
+	}
+
+	if limit := pol.MaxChangedFiles; limit != nil && p.ChangedFileCount() > int64(*limit) {
+		result = append(result, fmt.Sprintf("%v changed files exceed the expected %v", p.ChangedFileCount(), *limit))
+	}
+
+	return result
+}
+
+// DetectAnomalies compares the provided snapshot with the most recent complete snapshot of the same source
+// according to the anomaly detection policy. Changes which look like ransomware activity or exceed the expected
+// change rate are recorded in the anomalies of the manifest and the actions configured for them in the policy
+// are returned.
+func DetectAnomalies(ctx context.Context, rep repo.Repository, pol *policy.Policy, previous []*snapshot.Manifest, man *snapshot.Manifest) ([]policy.AnomalyAction, error) {
+	ad := &pol.AnomalyDetection
+
+	ransomwareAction := ad.RansomwareAction.OrDefault(policy.AnomalyActionNone)
+	changeRateAction := ad.ChangeRateAction.OrDefault(policy.AnomalyActionNone)
+
+	if ad.MaxNewBytes == nil && ad.MaxChangedFiles == nil {
+		changeRateAction = policy.AnomalyActionNone
+	}
+
+	if ransomwareAction == policy.AnomalyActionNone && changeRateAction == policy.AnomalyActionNone {
+		return nil, nil
+	}
+
+	if man.IncompleteReason != "" {
+		return nil, nil
+	}
+
+	var base *snapshot.Manifest
+
+	for _, m := range previous {
+		if m.IncompleteReason == "" && (base == nil || m.StartTime.After(base.StartTime)) {
+			base = m
+		}
+	}
+
+	if base == nil {
+		return nil, nil
+	}
+
+	maxSampledFiles := 0
+	if ransomwareAction != policy.AnomalyActionNone {
+		maxSampledFiles = ad.SampleFiles.OrDefault(0)
+	}
+
+	cp, err := AnalyzeChanges(ctx, rep, base, man, maxSampledFiles)
+	if err != nil {
+		return nil, errors.Wrap(err, "error analyzing changes")
+	}
+
+	var actions []policy.AnomalyAction
+
+	if ransomwareAction != policy.AnomalyActionNone {
+		if indicators := cp.RansomwareIndicators(ad); len(indicators) > 0 {
+			man.Anomalies = append(man.Anomalies, "possible ransomware activity: "+strings.Join(indicators, ", "))
+			actions = append(actions, ransomwareAction)
+		}
+	}
+
+	if changeRateAction != policy.AnomalyActionNone {
+		if violations := cp.ChangeRateViolations(ad); len(violations) > 0 {
+			man.Anomalies = append(man.Anomalies, "unexpected change rate: "+strings.Join(violations, ", "))
+
+			if !slices.Contains(actions, changeRateAction) {
+				actions = append(actions, changeRateAction)
+			}
+		}
+	}
+
+	return actions, nil
+}
+
+func percentage(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return 100 * float64(part) / float64(total) //nolint:gomnd
+}
+
+// AnalyzeChanges compares the provided snapshots of the same source and determines how many files have been
+// modified, deleted, added or renamed to a different extension. The beginnings of up to maxSampledFiles
+// modified files are read from the repository to determine whether their contents became high-entropy.
+// Subtrees whose object IDs have not changed are not traversed.
+func AnalyzeChanges(ctx context.Context, rep repo.Repository, previous, current *snapshot.Manifest, maxSampledFiles int) (*ChangeProfile, error) {
+	p := &ChangeProfile{}
+
+	if ds := previous.RootEntry.DirSummary; ds != nil {
+		p.PreviousFileCount = ds.TotalFileCount
+	} else {
+		p.PreviousFileCount = int64(previous.Stats.TotalFileCount)
+	}
+
+	if previous.RootEntry.Type != snapshot.EntryTypeDirectory || current.RootEntry.Type != snapshot.EntryTypeDirectory {
+		return p, nil
+	}
+
+	if err := p.compareDirectories(ctx, rep, previous.RootEntry.ObjectID, current.RootEntry.ObjectID, maxSampledFiles); err != nil {
+		return nil, err
+	}
+
+	for _, s := range p.samples {
+		increased, err := entropyIncreased(ctx, rep, s.previous, s.current)
+		if err != nil {
+			return nil, err
+		}
+
+		p.SampledFileCount++
+
+		if increased {
+			p.EntropyIncreaseCount++
+		}
+	}
+
+	return p, nil
+}
+
+func (p *ChangeProfile) compareDirectories(ctx context.Context, rep repo.Repository, previousID, currentID object.ID, maxSampledFiles int) error {
+	if previousID == currentID {
+		return nil
+	}
+
+	prevEntries, err := loadDirEntries(ctx, rep, previousID)
+	if err != nil {
+		return err
+	}
+
+	var curEntries []*snapshot.DirEntry
+
+	if currentID != object.EmptyID {
+		curEntries, err = loadDirEntries(ctx, rep, currentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	previous := map[string]bool{}
+	for _, e := range prevEntries {
+		previous[e.Name] = true
+	}
+
+	current := map[string]*snapshot.DirEntry{}
+
+	// names of added files without their last extension.
+	addedStems := map[string]bool{}
+
+	for _, e := range curEntries {
+		current[e.Name] = e
+
+		switch {
+		case previous[e.Name]:
+		case e.Type == snapshot.EntryTypeFile:
+			p.AddedFileCount++
+			p.NewBytes += e.FileSize
+			addedStems[stripExtension(e.Name)] = true
+
+		case e.Type == snapshot.EntryTypeDirectory && e.DirSummary != nil:
+			// added directory, no need to traverse it.
+			p.AddedFileCount += e.DirSummary.TotalFileCount
+			p.NewBytes += e.DirSummary.TotalFileSize
+		}
+	}
+
+	for _, pe := range prevEntries {
+		ce := current[pe.Name]
+
+		switch {
+		case pe.Type == snapshot.EntryTypeDirectory && ce == nil && pe.DirSummary != nil:
+			// deleted directory, no need to traverse it.
+			p.DeletedFileCount += pe.DirSummary.TotalFileCount
+
+		case pe.Type == snapshot.EntryTypeDirectory:
+			cid := object.EmptyID
+			if ce != nil && ce.Type == snapshot.EntryTypeDirectory {
+				cid = ce.ObjectID
+			}
+
+			if err := p.compareDirectories(ctx, rep, pe.ObjectID, cid, maxSampledFiles); err != nil {
+				return err
+			}
+
+		case pe.Type != snapshot.EntryTypeFile:
+			// ignore symlinks and other special entries.
+
+		case ce == nil || ce.Type != snapshot.EntryTypeFile:
+			p.DeletedFileCount++
+
+			// replaced by a new file with an additional extension (such as 'file.doc.locked')
+			// or a different extension (such as 'file.enc').
+			if addedStems[pe.Name] || addedStems[stripExtension(pe.Name)] {
+				p.ExtensionChangeCount++
+			}
+
+		case ce.ObjectID != pe.ObjectID:
+			p.ModifiedFileCount++
+			p.NewBytes += ce.FileSize
+
+			if len(p.samples) < maxSampledFiles {
+				p.samples = append(p.samples, sampledFile{pe.ObjectID, ce.ObjectID})
+			}
+		}
+	}
+
+	return nil
+}
+
+func stripExtension(name string) string {
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+func loadDirEntries(ctx context.Context, rep repo.Repository, oid object.ID) ([]*snapshot.DirEntry, error) {
+	r, err := rep.OpenObject(ctx, oid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open directory %v", oid)
+	}
+	defer r.Close() //nolint:errcheck
+
+	entries, _, err := readDirEntries(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to read directory %v", oid)
+	}
+
+	return entries, nil
+}
+
+func entropyIncreased(ctx context.Context, rep repo.Repository, previousID, currentID object.ID) (bool, error) {
+	before, err := sampleEntropy(ctx, rep, previousID)
+	if err != nil {
+		return false, err
+	}
+
+	after, err := sampleEntropy(ctx, rep, currentID)
+	if err != nil {
+		return false, err
+	}
+
+	return after >= highEntropyThreshold && after-before >= minEntropyIncrease, nil
+}
+
+// sampleEntropy returns Shannon entropy in bits per byte of the beginning of the provided object.
+func sampleEntropy(ctx context.Context, rep repo.Repository, oid object.ID) (float64, error) {
+	r, err := rep.OpenObject(ctx, oid)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unable to open object %v", oid)
+	}
+	defer r.Close() //nolint:errcheck
+
+	buf := make([]byte, entropySampleSize)
+
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return 0, errors.Wrapf(err, "unable to read object %v", oid)
+	}
+
+	return shannonEntropy(buf[:n]), nil
+}
+
+func shannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+
+	for _, b := range data {
+		counts[b]++
+	}
+
+	var e float64
+
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+
+		f := float64(c) / float64(len(data))
+		e -= f * math.Log2(f)
+	}
+
+	return e
+}
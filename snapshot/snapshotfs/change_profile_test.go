@@ -0,0 +1,220 @@
+package snapshotfs_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestAnalyzeChanges(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	src := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"}
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+
+	textFile := func(i int, version string) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("document %v version %v\n", i, version)), 200)
+	}
+
+	randomFile := func() []byte {
+		b := make([]byte, 4096)
+		rand.Read(b)
+
+		return b
+	}
+
+	newRoot := func() (*mockfs.Directory, *mockfs.Directory) {
+		root := mockfs.NewDirectory()
+		unchanged := root.AddDir("unchanged", 0o755)
+
+		for i := 0; i < 10; i++ {
+			unchanged.AddFile(fmt.Sprintf("file%v.txt", i), textFile(i, "unchanged"), 0o644)
+		}
+
+		return root, unchanged
+	}
+
+	root1, _ := newRoot()
+	for i := 0; i < 10; i++ {
+		root1.AddFile(fmt.Sprintf("doc%v.txt", i), textFile(i, "v1"), 0o644)
+	}
+
+	man1, err := u.Upload(ctx, root1, nil, src)
+	require.NoError(t, err)
+
+	// 5 files encrypted in place, 5 replaced with encrypted files with additional extension.
+	encrypted, _ := newRoot()
+	for i := 0; i < 5; i++ {
+		encrypted.AddFile(fmt.Sprintf("doc%v.txt", i), randomFile(), 0o644)
+	}
+
+	for i := 5; i < 10; i++ {
+		encrypted.AddFile(fmt.Sprintf("doc%v.txt.locked", i), randomFile(), 0o644)
+	}
+
+	man2, err := u.Upload(ctx, encrypted, nil, src)
+	require.NoError(t, err)
+
+	// all files edited, a new one added.
+	edited, _ := newRoot()
+	for i := 0; i < 10; i++ {
+		edited.AddFile(fmt.Sprintf("doc%v.txt", i), textFile(i, "v2"), 0o644)
+	}
+
+	edited.AddFile("new.txt", textFile(11, "v1"), 0o644)
+
+	man3, err := u.Upload(ctx, edited, nil, src)
+	require.NoError(t, err)
+
+	cp, err := snapshotfs.AnalyzeChanges(ctx, env.RepositoryWriter, man1, man2, 3)
+	require.NoError(t, err)
+	require.Equal(t, &snapshotfs.ChangeProfile{
+		PreviousFileCount:    20,
+		ModifiedFileCount:    5,
+		DeletedFileCount:     5,
+		AddedFileCount:       5,
+		NewBytes:             40960,
+		ExtensionChangeCount: 5,
+		SampledFileCount:     3,
+		EntropyIncreaseCount: 3,
+	}, withoutSamples(cp))
+
+	pol := &policy.AnomalyDetectionPolicy{
+		MinFiles:                optionalInt(10),
+		ChangedFilesPercent:     optionalInt(50),
+		EntropyIncreasePercent:  optionalInt(50),
+		ExtensionChangesPercent: optionalInt(25),
+	}
+
+	require.Equal(t, []string{
+		"50.0% of files modified or deleted",
+		"100.0% of sampled files became high-entropy",
+		"25.0% of files had their extension changed",
+	}, cp.RansomwareIndicators(pol))
+
+	// too few files to analyze.
+	pol.MinFiles = optionalInt(21)
+	require.Empty(t, cp.RansomwareIndicators(pol))
+
+	pol.MinFiles = optionalInt(10)
+
+	cp, err = snapshotfs.AnalyzeChanges(ctx, env.RepositoryWriter, man1, man3, 100)
+	require.NoError(t, err)
+	require.Equal(t, &snapshotfs.ChangeProfile{
+		PreviousFileCount:    20,
+		ModifiedFileCount:    10,
+		AddedFileCount:       1,
+		NewBytes:             48600,
+		SampledFileCount:     10,
+		EntropyIncreaseCount: 0,
+	}, withoutSamples(cp))
+
+	// mass modification alone is not considered ransomware activity.
+	require.Empty(t, cp.RansomwareIndicators(pol))
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	src := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/src"}
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+
+	root1 := mockfs.NewDirectory()
+	root2 := mockfs.NewDirectory()
+
+	for i := 0; i < 4; i++ {
+		b := make([]byte, 4096)
+		rand.Read(b)
+
+		root1.AddFile(fmt.Sprintf("file%v", i), make([]byte, 4096), 0o644)
+		root2.AddFile(fmt.Sprintf("file%v.enc", i), b, 0o644)
+	}
+
+	man1, err := u.Upload(ctx, root1, nil, src)
+	require.NoError(t, err)
+
+	man2, err := u.Upload(ctx, root2, nil, src)
+	require.NoError(t, err)
+
+	pol := *policy.DefaultPolicy
+	pol.AnomalyDetection.MinFiles = optionalInt(1)
+
+	// disabled by default.
+	actions, err := snapshotfs.DetectAnomalies(ctx, env.RepositoryWriter, &pol, []*snapshot.Manifest{man1}, man2)
+	require.NoError(t, err)
+	require.Empty(t, actions)
+	require.Empty(t, man2.Anomalies)
+
+	pol.AnomalyDetection.RansomwareAction = policy.NewAnomalyAction(policy.AnomalyActionConfirm)
+
+	// no previous snapshot.
+	actions, err = snapshotfs.DetectAnomalies(ctx, env.RepositoryWriter, &pol, nil, man2)
+	require.NoError(t, err)
+	require.Empty(t, actions)
+
+	actions, err = snapshotfs.DetectAnomalies(ctx, env.RepositoryWriter, &pol, []*snapshot.Manifest{man1}, man2)
+	require.NoError(t, err)
+	require.Equal(t, []policy.AnomalyAction{policy.AnomalyActionConfirm}, actions)
+	require.Equal(t, []string{"possible ransomware activity: 100.0% of files modified or deleted, 100.0% of files had their extension changed"}, man2.Anomalies)
+
+	// change rate within expected limits.
+	man2.Anomalies = nil
+	pol.AnomalyDetection.MaxChangedFiles = optionalInt(8)
+	pol.AnomalyDetection.MaxNewBytes = optionalInt64(4 * 4096)
+
+	actions, err = snapshotfs.DetectAnomalies(ctx, env.RepositoryWriter, &pol, []*snapshot.Manifest{man1}, man2)
+	require.NoError(t, err)
+	require.Equal(t, []policy.AnomalyAction{policy.AnomalyActionConfirm}, actions)
+	require.Len(t, man2.Anomalies, 1)
+
+	// change rate exceeded, the default action is to notify.
+	man2.Anomalies = nil
+	pol.AnomalyDetection.MaxChangedFiles = optionalInt(7)
+	pol.AnomalyDetection.MaxNewBytes = optionalInt64(10000)
+
+	actions, err = snapshotfs.DetectAnomalies(ctx, env.RepositoryWriter, &pol, []*snapshot.Manifest{man1}, man2)
+	require.NoError(t, err)
+	require.Equal(t, []policy.AnomalyAction{policy.AnomalyActionConfirm, policy.AnomalyActionNotify}, actions)
+	require.Equal(t, "unexpected change rate: 16.4 KB of new or modified files exceed the expected 10 KB, 8 changed files exceed the expected 7", man2.Anomalies[1])
+
+	// change rate detection works without ransomware detection.
+	man2.Anomalies = nil
+	pol.AnomalyDetection.RansomwareAction = policy.NewAnomalyAction(policy.AnomalyActionNone)
+
+	actions, err = snapshotfs.DetectAnomalies(ctx, env.RepositoryWriter, &pol, []*snapshot.Manifest{man1}, man2)
+	require.NoError(t, err)
+	require.Equal(t, []policy.AnomalyAction{policy.AnomalyActionNotify}, actions)
+	require.Len(t, man2.Anomalies, 1)
+}
+
+func withoutSamples(cp *snapshotfs.ChangeProfile) *snapshotfs.ChangeProfile {
+	return &snapshotfs.ChangeProfile{
+		PreviousFileCount:    cp.PreviousFileCount,
+		ModifiedFileCount:    cp.ModifiedFileCount,
+		DeletedFileCount:     cp.DeletedFileCount,
+		AddedFileCount:       cp.AddedFileCount,
+		NewBytes:             cp.NewBytes,
+		ExtensionChangeCount: cp.ExtensionChangeCount,
+		SampledFileCount:     cp.SampledFileCount,
+		EntropyIncreaseCount: cp.EntropyIncreaseCount,
+	}
+}
+
+func optionalInt(v int) *policy.OptionalInt {
+	o := policy.OptionalInt(v)
+	return &o
+}
+
+func optionalInt64(v int64) *policy.OptionalInt64 {
+	o := policy.OptionalInt64(v)
+	return &o
+}
@@ -0,0 +1,162 @@
+package snapshotfs
+
+import (
+	"context"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// Classifier assigns classification tags (such as "source-code" or "media") to files during upload.
+// Tags are aggregated into the snapshot manifest, which allows later decisions based on the kind of
+// data in a snapshot. Classifiers may be invoked concurrently.
+type Classifier interface {
+	Classify(ctx context.Context, relativePath string, f fs.File) ([]string, error)
+}
+
+// ClassifierFunc is an adapter that allows ordinary functions to be used as classifiers.
+type ClassifierFunc func(ctx context.Context, relativePath string, f fs.File) ([]string, error)
+
+// Classify implements Classifier.
+func (c ClassifierFunc) Classify(ctx context.Context, relativePath string, f fs.File) ([]string, error) {
+	return c(ctx, relativePath, f)
+}
+
+// ExtensionClassifier tags files based on their lowercase extension, including the leading dot.
+type ExtensionClassifier map[string]string
+
+// Classify implements Classifier.
+func (c ExtensionClassifier) Classify(ctx context.Context, relativePath string, f fs.File) ([]string, error) {
+	if tag, ok := c[strings.ToLower(path.Ext(relativePath))]; ok {
+		return []string{tag}, nil
+	}
+
+	return nil, nil
+}
+
+// ContentPatternClassifier tags files whose leading bytes match any of the patterns.
+type ContentPatternClassifier struct {
+	Tag      string
+	Patterns []*regexp.Regexp
+
+	// maximum number of bytes to examine, defaults to DefaultContentClassifierMaxBytes.
+	MaxBytes int64
+}
+
+// DefaultContentClassifierMaxBytes is the default number of leading bytes examined by ContentPatternClassifier.
+const DefaultContentClassifierMaxBytes = 64 << 10
+
+// Classify implements Classifier.
+func (c *ContentPatternClassifier) Classify(ctx context.Context, relativePath string, f fs.File) ([]string, error) {
+	maxBytes := c.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultContentClassifierMaxBytes
+	}
+
+	r, err := f.Open(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open file")
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	b, err := io.ReadAll(io.LimitReader(r, maxBytes))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read file")
+	}
+
+	for _, p := range c.Patterns {
+		if p.Match(b) {
+			return []string{c.Tag}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// defaultExtensionClassification maps common file extensions to classification tags.
+//
+//nolint:gochecknoglobals
+var defaultExtensionClassification = map[string][]string{
+	"source-code": {".c", ".cc", ".cpp", ".cs", ".go", ".h", ".java", ".js", ".kt", ".php", ".py", ".rb", ".rs", ".scala", ".sh", ".swift", ".ts"},
+	"media":       {".avi", ".flac", ".gif", ".heic", ".jpeg", ".jpg", ".mkv", ".mov", ".mp3", ".mp4", ".png", ".raw", ".wav", ".webm"},
+	"document":    {".doc", ".docx", ".md", ".odt", ".pdf", ".ppt", ".pptx", ".rtf", ".txt", ".xls", ".xlsx"},
+	"archive":     {".7z", ".bz2", ".gz", ".rar", ".tar", ".tgz", ".xz", ".zip", ".zst"},
+}
+
+// DefaultExtensionClassifier returns a classifier tagging common source code, media, document and archive files.
+func DefaultExtensionClassifier() ExtensionClassifier {
+	result := ExtensionClassifier{}
+
+	for tag, exts := range defaultExtensionClassification {
+		for _, ext := range exts {
+			result[ext] = tag
+		}
+	}
+
+	return result
+}
+
+// classifyFile invokes all classifiers for the file and records its tags, classification errors are logged
+// and don't affect the snapshot.
+func (u *Uploader) classifyFile(ctx context.Context, relativePath string, f fs.File) []string {
+	if len(u.Classifiers) == 0 {
+		return nil
+	}
+
+	var tags []string
+
+	for _, c := range u.Classifiers {
+		t, err := c.Classify(ctx, relativePath, f)
+		if err != nil {
+			uploadLog(ctx).Debugw("unable to classify file", "path", relativePath, "error", err)
+			continue
+		}
+
+		tags = append(tags, t...)
+	}
+
+	u.classifications.AddFile(tags, f.Size())
+
+	return tags
+}
+
+// classifyCachedFile records tags of a file unchanged since the previous snapshot, reusing its previous
+// classification when available to avoid reading the file again.
+func (u *Uploader) classifyCachedFile(ctx context.Context, relativePath string, f fs.File, cached fs.Entry) []string {
+	if len(u.Classifiers) == 0 {
+		return nil
+	}
+
+	if hde, ok := cached.(snapshot.HasDirEntry); ok && u.reuseClassifications {
+		tags := hde.DirEntry().Classifications
+
+		u.classifications.AddFile(tags, f.Size())
+
+		return tags
+	}
+
+	return u.classifyFile(ctx, relativePath, f)
+}
+
+// canReuseClassifications returns true if all previous snapshots were classified using the configuration
+// with the provided ID.
+func canReuseClassifications(classifiersID string, previousManifests []*snapshot.Manifest) bool {
+	if classifiersID == "" || len(previousManifests) == 0 {
+		return false
+	}
+
+	for _, m := range previousManifests {
+		if m.ClassifiersID != classifiersID {
+			return false
+		}
+	}
+
+	return true
+}
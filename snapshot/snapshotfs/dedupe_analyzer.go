@@ -0,0 +1,201 @@
+package snapshotfs
+
+import (
+	"context"
+	"io"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/fs/ignorefs"
+	"github.com/kopia/kopia/internal/gather"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/hashing"
+	"github.com/kopia/kopia/repo/splitter"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+const dedupeAnalyzerReadBufferSize = 1 << 20
+
+// DedupeAnalysis summarizes how much of the analyzed local data is already present in the repository.
+type DedupeAnalysis struct {
+	Files  int `json:"files"`
+	Errors int `json:"errors"`
+
+	Chunks     int   `json:"chunks"`
+	TotalBytes int64 `json:"totalBytes"`
+
+	// chunks already stored in the repository.
+	ExistingChunks int   `json:"existingChunks"`
+	ExistingBytes  int64 `json:"existingBytes"`
+
+	// chunks repeated within the analyzed data, which would only be uploaded once.
+	DuplicateChunks int   `json:"duplicateChunks"`
+	DuplicateBytes  int64 `json:"duplicateBytes"`
+
+	// chunks which would have to be uploaded.
+	NewChunks int   `json:"newChunks"`
+	NewBytes  int64 `json:"newBytes"`
+}
+
+// DedupeAnalyzerProgress receives notifications about the progress of AnalyzeDeduplication.
+type DedupeAnalyzerProgress interface {
+	Processing(ctx context.Context, dirname string)
+	Error(ctx context.Context, filename string, err error)
+}
+
+type dedupeAnalyzer struct {
+	rep      repo.DirectRepository
+	split    splitter.Factory
+	hash     hashing.HashFunc
+	progress DedupeAnalyzerProgress
+
+	seen   map[content.ID]bool
+	buf    []byte
+	result DedupeAnalysis
+}
+
+// AnalyzeDeduplication splits and hashes files in the provided directory the same way snapshots do without
+// uploading anything and reports how much of the data already exists in the repository.
+// Files excluded by the policy tree are skipped.
+func AnalyzeDeduplication(ctx context.Context, rep repo.DirectRepository, entry fs.Directory, policyTree *policy.Tree, progress DedupeAnalyzerProgress) (*DedupeAnalysis, error) {
+	split := splitter.GetFactory(rep.ObjectFormat().Splitter)
+	if split == nil {
+		return nil, errors.Errorf("unsupported splitter %q", rep.ObjectFormat().Splitter)
+	}
+
+	a := &dedupeAnalyzer{
+		rep:      rep,
+		split:    split,
+		hash:     rep.ContentReader().ContentFormat().HashFunc(),
+		progress: progress,
+		seen:     map[content.ID]bool{},
+		buf:      make([]byte, dedupeAnalyzerReadBufferSize),
+	}
+
+	if err := a.analyzeDirectory(ctx, ignorefs.New(entry, policyTree), "."); err != nil {
+		return nil, err
+	}
+
+	return &a.result, nil
+}
+
+func (a *dedupeAnalyzer) analyzeDirectory(ctx context.Context, dir fs.Directory, relativePath string) error {
+	a.progress.Processing(ctx, relativePath)
+
+	//nolint:wrapcheck
+	return fs.IterateEntries(ctx, dir, func(ctx context.Context, e fs.Entry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entryPath := path.Join(relativePath, e.Name())
+
+		switch e := e.(type) {
+		case fs.Directory:
+			return a.analyzeDirectory(ctx, e, entryPath)
+
+		case fs.File:
+			if err := a.analyzeFile(ctx, e); err != nil {
+				a.result.Errors++
+				a.progress.Error(ctx, entryPath, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+func (a *dedupeAnalyzer) analyzeFile(ctx context.Context, f fs.File) error {
+	r, err := f.Open(ctx)
+	if err != nil {
+		return errors.Wrap(err, "unable to open file")
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	a.result.Files++
+
+	sp := a.split()
+	defer sp.Close()
+
+	var chunk gather.WriteBuffer
+	defer chunk.Close()
+
+	for {
+		n, readErr := r.Read(a.buf)
+
+		data := a.buf[:n]
+
+		for len(data) > 0 {
+			p := sp.NextSplitPoint(data)
+			if p < 0 {
+				chunk.Append(data)
+				break
+			}
+
+			chunk.Append(data[:p])
+			data = data[p:]
+
+			if err := a.addChunk(ctx, chunk.Bytes()); err != nil {
+				return err
+			}
+
+			chunk.Reset()
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+
+		if readErr != nil {
+			return errors.Wrap(readErr, "read error")
+		}
+	}
+
+	if chunk.Length() > 0 {
+		return a.addChunk(ctx, chunk.Bytes())
+	}
+
+	return nil
+}
+
+func (a *dedupeAnalyzer) addChunk(ctx context.Context, data gather.Bytes) error {
+	var hashOutput [hashing.MaxHashSize]byte
+
+	cid, err := content.IDFromHash("", a.hash(hashOutput[:0], data))
+	if err != nil {
+		return errors.Wrap(err, "invalid hash")
+	}
+
+	length := int64(data.Length())
+
+	a.result.Chunks++
+	a.result.TotalBytes += length
+
+	switch {
+	case a.seen[cid]:
+		a.result.DuplicateChunks++
+		a.result.DuplicateBytes += length
+
+	case a.contentExists(ctx, cid):
+		a.result.ExistingChunks++
+		a.result.ExistingBytes += length
+
+	default:
+		a.result.NewChunks++
+		a.result.NewBytes += length
+	}
+
+	a.seen[cid] = true
+
+	return nil
+}
+
+func (a *dedupeAnalyzer) contentExists(ctx context.Context, cid content.ID) bool {
+	ci, err := a.rep.ContentInfo(ctx, cid)
+
+	return err == nil && !ci.GetDeleted()
+}
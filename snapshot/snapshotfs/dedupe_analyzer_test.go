@@ -0,0 +1,65 @@
+package snapshotfs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+type testDedupeAnalyzerProgress struct {
+	errors []string
+}
+
+func (p *testDedupeAnalyzerProgress) Processing(ctx context.Context, dirname string) {}
+
+func (p *testDedupeAnalyzerProgress) Error(ctx context.Context, filename string, err error) {
+	p.errors = append(p.errors, filename)
+}
+
+func TestAnalyzeDeduplication(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	rep, ok := th.repo.(repo.DirectRepository)
+	require.True(t, ok)
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	var progress testDedupeAnalyzerProgress
+
+	before, err := AnalyzeDeduplication(ctx, rep, th.sourceDir, policyTree, &progress)
+	require.NoError(t, err)
+	require.Positive(t, before.Files)
+	require.Positive(t, before.NewBytes)
+	require.Zero(t, before.ExistingBytes)
+	require.Equal(t, before.TotalBytes, before.NewBytes+before.DuplicateBytes)
+
+	_, err = NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	// after the data has been uploaded, everything exists except for chunks repeated locally.
+	after, err := AnalyzeDeduplication(ctx, rep, th.sourceDir, policyTree, &progress)
+	require.NoError(t, err)
+	require.Equal(t, before.TotalBytes, after.TotalBytes)
+	require.Equal(t, before.DuplicateBytes, after.DuplicateBytes)
+	require.Equal(t, before.NewBytes, after.ExistingBytes)
+	require.Zero(t, after.NewBytes)
+
+	th.sourceDir.AddFile("new-file", bytes.Repeat([]byte{7}, 1000), defaultPermissions)
+	th.sourceDir.AddFile("new-file-copy", bytes.Repeat([]byte{7}, 1000), defaultPermissions)
+
+	after, err = AnalyzeDeduplication(ctx, rep, th.sourceDir, policyTree, &progress)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), after.NewBytes)
+	require.Equal(t, before.DuplicateBytes+1000, after.DuplicateBytes)
+	require.Empty(t, progress.errors)
+}
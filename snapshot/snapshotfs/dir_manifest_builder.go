@@ -1,10 +1,12 @@
 package snapshotfs
 
 import (
+	"path"
 	"sort"
 	"sync"
 
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
 )
 
@@ -16,6 +18,10 @@ type DirManifestBuilder struct {
 	summary fs.DirectorySummary
 	// +checklocks:mu
 	entries []*snapshot.DirEntry
+	// +checklocks:mu
+	failedNames []string
+	// +checklocks:mu
+	tombstones []*snapshot.DirEntry
 }
 
 // Clone clones the current state of dirManifestBuilder.
@@ -24,8 +30,10 @@ func (b *DirManifestBuilder) Clone() *DirManifestBuilder {
 	defer b.mu.Unlock()
 
 	return &DirManifestBuilder{
-		summary: b.summary.Clone(),
-		entries: append([]*snapshot.DirEntry(nil), b.entries...),
+		summary:     b.summary.Clone(),
+		entries:     append([]*snapshot.DirEntry(nil), b.entries...),
+		failedNames: append([]string(nil), b.failedNames...),
+		tombstones:  append([]*snapshot.DirEntry(nil), b.tombstones...),
 	}
 }
 
@@ -80,6 +88,39 @@ func (b *DirManifestBuilder) AddFailedEntry(relPath string, isIgnoredError bool,
 		EntryPath: relPath,
 		Error:     err.Error(),
 	})
+
+	b.failedNames = append(b.failedNames, path.Base(relPath))
+}
+
+// AddTombstones records entries from previous snapshots of the directory, which have not been
+// added to the builder, as deleted. Entries that failed to be snapshotted are not considered deleted.
+func (b *DirManifestBuilder) AddTombstones(previous []*snapshot.DirEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seen := map[string]bool{}
+
+	for _, de := range b.entries {
+		seen[de.Name] = true
+	}
+
+	for _, n := range b.failedNames {
+		seen[n] = true
+	}
+
+	for _, de := range previous {
+		if seen[de.Name] {
+			continue
+		}
+
+		seen[de.Name] = true
+
+		t := *de
+		t.ObjectID = object.EmptyID
+		t.DirSummary = nil
+
+		b.tombstones = append(b.tombstones, &t)
+	}
 }
 
 // Build builds the directory manifest.
@@ -101,19 +142,14 @@ func (b *DirManifestBuilder) Build(dirModTime fs.UTCTimestamp, incompleteReason
 	b.summary.FailedEntries = sortedTopFailures(b.summary.FailedEntries)
 
 	// sort the result, directories first, then non-directories, ordered by name
-	sort.Slice(b.entries, func(i, j int) bool {
-		if leftDir, rightDir := isDir(entries[i]), isDir(entries[j]); leftDir != rightDir {
-			// directories get sorted before non-directories
-			return leftDir
-		}
-
-		return entries[i].Name < entries[j].Name
-	})
+	snapshot.SortDirEntries(entries)
+	snapshot.SortDirEntries(b.tombstones)
 
 	return &snapshot.DirManifest{
 		StreamType: directoryStreamType,
 		Summary:    &s,
 		Entries:    entries,
+		Tombstones: b.tombstones,
 	}
 }
 
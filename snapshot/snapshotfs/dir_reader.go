@@ -14,15 +14,33 @@
 
 // readDirEntries reads all directory entries from the specified reader.
 func readDirEntries(r io.Reader) ([]*snapshot.DirEntry, *fs.DirectorySummary, error) {
+	dir, err := readDirManifest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dir.Entries, dir.Summary, nil
+}
+
+// readDirManifest reads the directory manifest from the specified reader.
+func readDirManifest(r io.Reader) (*snapshot.DirManifest, error) {
 	var dir snapshot.DirManifest
 
 	if err := json.NewDecoder(r).Decode(&dir); err != nil {
-		return nil, nil, errors.Wrap(err, "unable to parse directory object")
+		return nil, errors.Wrap(err, "unable to parse directory object")
 	}
 
 	if dir.StreamType != directoryStreamType {
-		return nil, nil, errors.Errorf("invalid directory stream type")
+		return nil, errors.Errorf("invalid directory stream type")
 	}
 
-	return dir.Entries, dir.Summary, nil
+	if err := snapshot.ValidateDirEntriesOrder(dir.Entries); err != nil {
+		return nil, errors.Wrap(err, "invalid directory object")
+	}
+
+	if err := snapshot.ValidateDirEntriesOrder(dir.Tombstones); err != nil {
+		return nil, errors.Wrap(err, "invalid directory tombstones")
+	}
+
+	return &dir, nil
 }
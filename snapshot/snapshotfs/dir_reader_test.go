@@ -0,0 +1,28 @@
+package snapshotfs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestReadDirEntries_RejectsUnsorted(t *testing.T) {
+	sorted := `{"stream":"kopia:directory","entries":[{"name":"d","type":"d"},{"name":"a","type":"f"},{"name":"b","type":"f"}]}`
+
+	entries, _, err := readDirEntries(strings.NewReader(sorted))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+
+	unsorted := `{"stream":"kopia:directory","entries":[{"name":"b","type":"f"},{"name":"a","type":"f"}]}`
+
+	_, _, err = readDirEntries(strings.NewReader(unsorted))
+	require.ErrorIs(t, err, snapshot.ErrUnsortedDirEntries)
+
+	dirAfterFile := `{"stream":"kopia:directory","entries":[{"name":"a","type":"f"},{"name":"d","type":"d"}]}`
+
+	_, _, err = readDirEntries(strings.NewReader(dirAfterFile))
+	require.ErrorIs(t, err, snapshot.ErrUnsortedDirEntries)
+}
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"encoding/json"
+	"io"
 
 	"github.com/pkg/errors"
 
@@ -11,15 +12,28 @@
 	"github.com/kopia/kopia/snapshot"
 )
 
-func writeDirManifest(ctx context.Context, rep repo.RepositoryWriter, dirRelativePath string, dirManifest *snapshot.DirManifest) (object.ID, error) {
+// dirObjectDescriptionPrefix is the prefix of descriptions of directory objects.
+const dirObjectDescriptionPrefix = "DIR:"
+
+func writeDirManifest(ctx context.Context, rep repo.RepositoryWriter, dirRelativePath string, dirManifest *snapshot.DirManifest, dr object.DescriptionRecorder, streaming bool) (object.ID, error) {
+	if err := snapshot.ValidateDirEntriesOrder(dirManifest.Entries); err != nil {
+		return object.EmptyID, errors.Wrapf(err, "invalid directory manifest for %q", dirRelativePath)
+	}
+
 	writer := rep.NewObjectWriter(ctx, object.WriterOptions{
-		Description: "DIR:" + dirRelativePath,
-		Prefix:      objectIDPrefixDirectory,
+		Description:         dirObjectDescriptionPrefix + dirRelativePath,
+		Prefix:              objectIDPrefixDirectory,
+		DescriptionRecorder: dr,
 	})
 
 	defer writer.Close() //nolint:errcheck
 
-	if err := json.NewEncoder(writer).Encode(dirManifest); err != nil {
+	encode := encodeDirManifest
+	if streaming {
+		encode = encodeDirManifestStreaming
+	}
+
+	if err := encode(writer, dirManifest); err != nil {
 		return object.EmptyID, errors.Wrap(err, "unable to encode directory JSON")
 	}
 
@@ -30,3 +44,75 @@ func writeDirManifest(ctx context.Context, rep repo.RepositoryWriter, dirRelativ
 
 	return oid, nil
 }
+
+func encodeDirManifest(w io.Writer, dirManifest *snapshot.DirManifest) error {
+	//nolint:wrapcheck
+	return json.NewEncoder(w).Encode(dirManifest)
+}
+
+// encodeDirManifestStreaming produces the same output as encodeDirManifest, but encodes entries one
+// at a time instead of buffering JSON of the entire directory in memory.
+func encodeDirManifestStreaming(w io.Writer, dirManifest *snapshot.DirManifest) error {
+	ew := &errWriter{w: w}
+
+	ew.writeString(`{"stream":`)
+	ew.writeJSON(dirManifest.StreamType)
+	ew.writeString(`,"entries":`)
+	ew.writeEntries(dirManifest.Entries)
+	ew.writeString(`,"summary":`)
+	ew.writeJSON(dirManifest.Summary)
+
+	if len(dirManifest.Tombstones) > 0 {
+		ew.writeString(`,"tombstones":`)
+		ew.writeEntries(dirManifest.Tombstones)
+	}
+
+	ew.writeString("}\n")
+
+	return ew.err
+}
+
+// errWriter writes to the underlying writer until the first error, which is retained.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *errWriter) writeString(s string) {
+	if w.err == nil {
+		_, w.err = io.WriteString(w.w, s)
+	}
+}
+
+func (w *errWriter) writeJSON(v interface{}) {
+	if w.err != nil {
+		return
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		w.err = err
+		return
+	}
+
+	_, w.err = w.w.Write(b)
+}
+
+func (w *errWriter) writeEntries(entries []*snapshot.DirEntry) {
+	if entries == nil {
+		w.writeString("null")
+		return
+	}
+
+	w.writeString("[")
+
+	for i, e := range entries {
+		if i > 0 {
+			w.writeString(",")
+		}
+
+		w.writeJSON(e)
+	}
+
+	w.writeString("]")
+}
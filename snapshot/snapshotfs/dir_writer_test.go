@@ -0,0 +1,64 @@
+package snapshotfs
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/snapshot"
+)
+
+func TestEncodeDirManifestStreaming(t *testing.T) {
+	entries := []*snapshot.DirEntry{
+		{Name: "a<b>", Type: snapshot.EntryTypeFile, FileSize: 10},
+		{Name: "dir", Type: snapshot.EntryTypeDirectory, DirSummary: &fs.DirectorySummary{TotalFileCount: 3}},
+	}
+
+	cases := []*snapshot.DirManifest{
+		{StreamType: directoryStreamType},
+		{StreamType: directoryStreamType, Entries: []*snapshot.DirEntry{}},
+		{StreamType: directoryStreamType, Entries: entries, Summary: &fs.DirectorySummary{TotalFileCount: 4, TotalFileSize: 10}},
+		{StreamType: directoryStreamType, Entries: entries[:1], Tombstones: entries[1:]},
+	}
+
+	for _, dm := range cases {
+		var want, got bytes.Buffer
+
+		require.NoError(t, encodeDirManifest(&want, dm))
+		require.NoError(t, encodeDirManifestStreaming(&got, dm))
+		require.Equal(t, want.String(), got.String())
+	}
+}
+
+// TestEncodeDirManifestStreamingAllFields makes sure that fields added to DirManifest are also
+// written by encodeDirManifestStreaming, which does not use reflection to encode the manifest.
+func TestEncodeDirManifestStreamingAllFields(t *testing.T) {
+	entries := []*snapshot.DirEntry{{Name: "a", Type: snapshot.EntryTypeFile}}
+
+	dm := &snapshot.DirManifest{}
+	v := reflect.ValueOf(dm).Elem()
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+
+		switch {
+		case f.Kind() == reflect.String:
+			f.SetString(directoryStreamType)
+		case f.Type() == reflect.TypeOf(entries):
+			f.Set(reflect.ValueOf(entries))
+		case f.Kind() == reflect.Pointer:
+			f.Set(reflect.New(f.Type().Elem()))
+		default:
+			t.Fatalf("unsupported DirManifest field %v, update encodeDirManifestStreaming and this test", v.Type().Field(i).Name)
+		}
+	}
+
+	var want, got bytes.Buffer
+
+	require.NoError(t, encodeDirManifest(&want, dm))
+	require.NoError(t, encodeDirManifestStreaming(&got, dm))
+	require.Equal(t, want.String(), got.String())
+}
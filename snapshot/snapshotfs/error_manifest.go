@@ -0,0 +1,110 @@
+package snapshotfs
+
+import (
+	"context"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// Phases of snapshot creation in which entry errors can occur.
+const (
+	ErrorPhaseReadDirectory = "read-directory"
+	ErrorPhaseReadEntry     = "read-entry"
+	ErrorPhaseUnknownType   = "unknown-type"
+	ErrorPhaseUpload        = "upload"
+)
+
+// maxErrorManifestEntries is the maximum number of errors recorded in the error manifest of a snapshot,
+// remaining errors are only counted.
+const maxErrorManifestEntries = 100000
+
+// EntryError describes a single error encountered when snapshotting an entry.
+type EntryError struct {
+	Path    string `json:"path"`
+	Phase   string `json:"phase"`
+	Errno   int    `json:"errno,omitempty"`
+	Error   string `json:"error"`
+	Ignored bool   `json:"ignored,omitempty"`
+}
+
+// ErrorManifest is the persistent list of errors encountered when creating a snapshot.
+type ErrorManifest struct {
+	StreamID string       `json:"stream"`
+	Entries  []EntryError `json:"entries"`
+
+	// number of errors that were not recorded because the manifest was full.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+func (m *ErrorManifest) streamID() string {
+	return m.StreamID
+}
+
+const errorManifestStreamID = "kopia:snapshot-errors"
+
+// errorManifestCollector accumulates errors of entries encountered during upload.
+type errorManifestCollector struct {
+	snapshotStreamCollector[EntryError]
+}
+
+func newErrorManifestCollector() *errorManifestCollector {
+	return &errorManifestCollector{snapshotStreamCollector[EntryError]{maxEntries: maxErrorManifestEntries}}
+}
+
+func (c *errorManifestCollector) recordError(relPath, phase string, err error, isIgnored bool) {
+	if c == nil {
+		return
+	}
+
+	e := EntryError{
+		Path:    relPath,
+		Phase:   phase,
+		Error:   err.Error(),
+		Ignored: isIgnored,
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		e.Errno = int(errno)
+	}
+
+	c.add(e)
+}
+
+// writeManifest writes all collected errors as a single object and returns its ID or nil if there were no errors.
+func (c *errorManifestCollector) writeManifest(ctx context.Context, rep repo.RepositoryWriter) (*object.ID, error) {
+	entries, omitted := c.sorted(func(a, b EntryError) bool {
+		return a.Path < b.Path
+	})
+
+	if len(entries) == 0 && omitted == 0 {
+		return nil, nil
+	}
+
+	return writeSnapshotStream(ctx, rep, "SNAPSHOT-ERRORS", "error manifest", &ErrorManifest{
+		StreamID: errorManifestStreamID,
+		Entries:  entries,
+		Omitted:  omitted,
+	})
+}
+
+// ReadErrorManifest returns errors encountered when creating the provided snapshot.
+// Snapshots created without errors or before error manifests were introduced return an empty manifest.
+func ReadErrorManifest(ctx context.Context, rep repo.Repository, man *snapshot.Manifest) (*ErrorManifest, error) {
+	if man.ErrorManifest == nil {
+		return &ErrorManifest{StreamID: errorManifestStreamID}, nil
+	}
+
+	var em ErrorManifest
+
+	if err := readSnapshotStream(ctx, rep, *man.ErrorManifest, man.ID, "error manifest", errorManifestStreamID, &em); err != nil {
+		return nil, err
+	}
+
+	return &em, nil
+}
@@ -0,0 +1,171 @@
+package snapshotfs
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// NameResolver resolves a friendly snapshot reference, such as "/some/path:latest", to a snapshot manifest
+// and the slash-separated path of an entry nested within it.
+// Resolvers must return nil manifest and no error for references they don't recognize.
+type NameResolver func(ctx context.Context, rep repo.Repository, ref string) (man *snapshot.Manifest, nestedPath string, err error)
+
+//nolint:gochecknoglobals
+var (
+	nameResolversMutex sync.RWMutex
+	nameResolvers      = []NameResolver{resolveSourceSnapshotName}
+)
+
+// RegisterNameResolver registers a resolver of friendly snapshot references,
+// which will be consulted before previously registered resolvers.
+func RegisterNameResolver(r NameResolver) {
+	nameResolversMutex.Lock()
+	defer nameResolversMutex.Unlock()
+
+	nameResolvers = append([]NameResolver{r}, nameResolvers...)
+}
+
+// ResolveSnapshotName resolves a friendly snapshot reference using registered resolvers.
+// Returns nil manifest if the reference was not recognized by any resolver.
+//
+// The built-in resolver understands references made of snapshot source followed by:
+//
+//	:latest      - the latest snapshot
+//	:oldest      - the oldest snapshot
+//	:yesterday   - the latest snapshot taken before the start of the current day
+//	:YYYY-MM-DD  - the latest snapshot taken before the end of the provided day
+//	~N           - the Nth snapshot before the latest one
+//
+// optionally followed by the path of a nested entry, for example: "/home/user:latest/Documents".
+func ResolveSnapshotName(ctx context.Context, rep repo.Repository, ref string) (man *snapshot.Manifest, nestedPath string, err error) {
+	nameResolversMutex.RLock()
+	resolvers := append([]NameResolver(nil), nameResolvers...)
+	nameResolversMutex.RUnlock()
+
+	for _, r := range resolvers {
+		man, nestedPath, err := r(ctx, rep, ref)
+		if err != nil || man != nil {
+			return man, nestedPath, err
+		}
+	}
+
+	return nil, "", nil
+}
+
+//nolint:gochecknoglobals
+var (
+	snapshotSelectorRegexp = regexp.MustCompile(`^(.+):(latest|oldest|yesterday|\d{4}-\d{2}-\d{2})(/.*)?$`)
+	snapshotOffsetRegexp   = regexp.MustCompile(`^(.+)~(\d{1,6})(/.*)?$`)
+)
+
+func resolveSourceSnapshotName(ctx context.Context, rep repo.Repository, ref string) (*snapshot.Manifest, string, error) {
+	var (
+		source, nestedPath string
+		pick               func(newestFirst []*snapshot.Manifest) (*snapshot.Manifest, error)
+	)
+
+	if m := snapshotSelectorRegexp.FindStringSubmatch(ref); m != nil {
+		p, err := pickBySelector(m[2], rep.Time())
+		if err != nil {
+			return nil, "", err
+		}
+
+		source, pick, nestedPath = m[1], p, m[3]
+	} else if m := snapshotOffsetRegexp.FindStringSubmatch(ref); m != nil {
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid snapshot offset in %q", ref)
+		}
+
+		source, pick, nestedPath = m[1], pickByOffset(n), m[3]
+	} else {
+		return nil, "", nil
+	}
+
+	si, err := snapshot.ParseSourceInfo(source, rep.ClientOptions().Hostname, rep.ClientOptions().Username)
+	if err != nil || si.Path == "" {
+		// not a snapshot source, let other resolvers handle it.
+		return nil, "", nil //nolint:nilerr
+	}
+
+	mans, err := snapshot.ListSnapshots(ctx, rep, si)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "error listing snapshots of %v", si)
+	}
+
+	var complete []*snapshot.Manifest
+
+	for _, m := range mans {
+		// only complete snapshots of the entire source are addressable by friendly names.
+		if m.IncompleteReason == "" && m.Subpath == "" {
+			complete = append(complete, m)
+		}
+	}
+
+	if len(complete) == 0 {
+		return nil, "", nil
+	}
+
+	man, err := pick(snapshot.SortByTime(complete, true))
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "unable to resolve %q", ref)
+	}
+
+	return man, strings.TrimPrefix(nestedPath, "/"), nil
+}
+
+func pickBySelector(selector string, now time.Time) (func([]*snapshot.Manifest) (*snapshot.Manifest, error), error) {
+	switch selector {
+	case "latest":
+		return pickByOffset(0), nil
+
+	case "oldest":
+		return func(mans []*snapshot.Manifest) (*snapshot.Manifest, error) {
+			return mans[len(mans)-1], nil
+		}, nil
+
+	case "yesterday":
+		y, m, d := now.Local().Date()
+
+		return pickLatestBefore(time.Date(y, m, d, 0, 0, 0, 0, time.Local)), nil
+
+	default:
+		day, err := time.ParseInLocation("2006-01-02", selector, time.Local)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid date %q", selector)
+		}
+
+		return pickLatestBefore(day.AddDate(0, 0, 1)), nil
+	}
+}
+
+func pickByOffset(n int) func([]*snapshot.Manifest) (*snapshot.Manifest, error) {
+	return func(mans []*snapshot.Manifest) (*snapshot.Manifest, error) {
+		if n >= len(mans) {
+			return nil, errors.Errorf("only %v snapshots are available", len(mans))
+		}
+
+		return mans[n], nil
+	}
+}
+
+func pickLatestBefore(t time.Time) func([]*snapshot.Manifest) (*snapshot.Manifest, error) {
+	return func(mans []*snapshot.Manifest) (*snapshot.Manifest, error) {
+		for _, m := range mans {
+			if m.StartTime.ToTime().Before(t) {
+				return m, nil
+			}
+		}
+
+		return nil, errors.Errorf("no snapshots taken before %v", t.Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,113 @@
+package snapshotfs_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestResolveSnapshotName(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	sourceRoot := mockfs.NewDirectory()
+	sourceRoot.AddDir("dir1", 0o755).AddFile("file1", []byte{1, 2, 3}, 0o644)
+
+	src := snapshot.SourceInfo{
+		Host:     env.Repository.ClientOptions().Hostname,
+		UserName: env.Repository.ClientOptions().Username,
+		Path:     "/dummy",
+	}
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+	proto, err := u.Upload(ctx, sourceRoot, nil, src)
+	require.NoError(t, err)
+
+	now := env.RepositoryWriter.Time()
+
+	saveAt := func(startTime time.Time, incompleteReason string) *snapshot.Manifest {
+		m := *proto
+		m.StartTime = fs.UTCTimestampFromTime(startTime)
+		m.IncompleteReason = incompleteReason
+
+		_, err := snapshot.SaveSnapshot(ctx, env.RepositoryWriter, &m)
+		require.NoError(t, err)
+
+		return &m
+	}
+
+	oldest := saveAt(now.Add(-72*time.Hour), "")
+	older := saveAt(now.Add(-48*time.Hour), "")
+	saveAt(now.Add(-24*time.Hour), snapshotfs.IncompleteReasonCanceled)
+	latest := saveAt(now, "")
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	cases := []struct {
+		ref        string
+		want       *snapshot.Manifest
+		wantNested string
+	}{
+		{"/dummy:latest", latest, ""},
+		{"/dummy:oldest", oldest, ""},
+		{"/dummy:yesterday", older, ""},
+		{"/dummy~0", latest, ""},
+		{"/dummy~1", older, ""},
+		{"/dummy~2/dir1/file1", oldest, "dir1/file1"},
+		{"/dummy:" + oldest.StartTime.ToTime().Local().Format("2006-01-02"), oldest, ""},
+		{"/dummy:latest/dir1", latest, "dir1"},
+		{src.String() + ":latest", latest, ""},
+	}
+
+	for _, tc := range cases {
+		man, nested, err := snapshotfs.ResolveSnapshotName(ctx, env.Repository, tc.ref)
+		require.NoError(t, err, tc.ref)
+		require.NotNil(t, man, tc.ref)
+		require.Equal(t, tc.want.ID, man.ID, tc.ref)
+		require.Equal(t, tc.wantNested, nested, tc.ref)
+	}
+
+	// references which are not friendly snapshot names.
+	for _, ref := range []string{"/dummy", "/other:latest", proto.RootObjectID().String(), "/dummy:newest"} {
+		man, _, err := snapshotfs.ResolveSnapshotName(ctx, env.Repository, ref)
+		require.NoError(t, err, ref)
+		require.Nil(t, man, ref)
+	}
+
+	_, _, err = snapshotfs.ResolveSnapshotName(ctx, env.Repository, "/dummy~3")
+	require.Error(t, err)
+
+	_, _, err = snapshotfs.ResolveSnapshotName(ctx, env.Repository, "/dummy:2000-01-01")
+	require.Error(t, err)
+
+	// friendly names are understood when looking up entries.
+	e, err := snapshotfs.FilesystemEntryFromIDWithPath(ctx, env.Repository, "/dummy:latest/dir1/file1", false)
+	require.NoError(t, err)
+	require.Equal(t, "file1", e.Name())
+
+	oid, err := snapshotfs.ParseObjectIDWithPath(ctx, env.Repository, "/dummy~1")
+	require.NoError(t, err)
+	require.Equal(t, proto.RootObjectID(), oid)
+
+	// custom resolvers take precedence.
+	snapshotfs.RegisterNameResolver(func(ctx context.Context, rep repo.Repository, ref string) (*snapshot.Manifest, string, error) {
+		if ref == "@favorite" {
+			return oldest, "dir1", nil
+		}
+
+		return nil, "", nil
+	})
+
+	man, nested, err := snapshotfs.ResolveSnapshotName(ctx, env.Repository, "@favorite")
+	require.NoError(t, err)
+	require.Equal(t, oldest.ID, man.ID)
+	require.Equal(t, "dir1", nested)
+}
@@ -0,0 +1,153 @@
+package snapshotfs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// ObjectDescription associates an object written by a snapshot with its human-readable description.
+type ObjectDescription struct {
+	ObjectID    object.ID `json:"oid"`
+	Description string    `json:"desc"`
+}
+
+// ObjectOrigin describes where an object was written.
+type ObjectOrigin struct {
+	ObjectID    object.ID          `json:"oid"`
+	Description string             `json:"description"`
+	Snapshot    *snapshot.Manifest `json:"-"`
+}
+
+// String returns a human-readable description of the object origin.
+func (o ObjectOrigin) String() string {
+	desc := o.Description
+	if rel, ok := strings.CutPrefix(desc, dirObjectDescriptionPrefix); ok {
+		desc = "directory object for " + path.Join(o.Snapshot.Source.Path, o.Snapshot.Subpath, rel)
+	}
+
+	return fmt.Sprintf("%v from snapshot %v of %v at %v", desc, o.Snapshot.ID, o.Snapshot.Source, o.Snapshot.StartTime.Format("2006-01-02 15:04:05 MST"))
+}
+
+// objectDescriptionIndex is the persistent format of the object description index.
+type objectDescriptionIndex struct {
+	StreamID string              `json:"stream"`
+	Entries  []ObjectDescription `json:"entries"`
+}
+
+func (i *objectDescriptionIndex) streamID() string {
+	return i.StreamID
+}
+
+const objectDescriptionIndexStreamID = "kopia:object-descriptions"
+
+// objectDescriptionCollector accumulates descriptions of objects written during upload.
+type objectDescriptionCollector struct {
+	snapshotStreamCollector[ObjectDescription]
+}
+
+func (c *objectDescriptionCollector) RecordObjectDescription(oid object.ID, description string) {
+	if c == nil {
+		return
+	}
+
+	c.add(ObjectDescription{oid, description})
+}
+
+// writeIndex writes all collected descriptions as a single object and returns its ID or nil if nothing was collected.
+func (c *objectDescriptionCollector) writeIndex(ctx context.Context, rep repo.RepositoryWriter) (*object.ID, error) {
+	entries, _ := c.sorted(func(a, b ObjectDescription) bool {
+		return a.Description < b.Description
+	})
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	return writeSnapshotStream(ctx, rep, "OBJECT-DESCRIPTIONS", "object descriptions", &objectDescriptionIndex{
+		StreamID: objectDescriptionIndexStreamID,
+		Entries:  entries,
+	})
+}
+
+// ReadObjectDescriptions returns descriptions of objects written by the provided snapshot.
+func ReadObjectDescriptions(ctx context.Context, rep repo.Repository, man *snapshot.Manifest) ([]ObjectDescription, error) {
+	if man.ObjectDescriptions == nil {
+		return nil, nil
+	}
+
+	var idx objectDescriptionIndex
+
+	if err := readSnapshotStream(ctx, rep, *man.ObjectDescriptions, man.ID, "object descriptions", objectDescriptionIndexStreamID, &idx); err != nil {
+		return nil, err
+	}
+
+	return idx.Entries, nil
+}
+
+// FindContentOrigins looks up object description indexes of all snapshots and returns origins of
+// objects stored in the provided contents.
+func FindContentOrigins(ctx context.Context, rep repo.Repository, contentIDs []content.ID) (map[content.ID][]ObjectOrigin, error) {
+	wanted := map[content.ID]bool{}
+	for _, cid := range contentIDs {
+		wanted[cid] = true
+	}
+
+	ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list snapshots")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load snapshots")
+	}
+
+	result := map[content.ID][]ObjectOrigin{}
+
+	for _, man := range manifests {
+		entries, err := ReadObjectDescriptions(ctx, rep, man)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			for _, cid := range objectContentIDs(ctx, rep, e.ObjectID) {
+				if wanted[cid] {
+					result[cid] = append(result[cid], ObjectOrigin{e.ObjectID, e.Description, man})
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// objectContentIDs returns IDs of contents holding the provided object, if the object is damaged
+// only the contents that can be determined without reading it are returned.
+func objectContentIDs(ctx context.Context, rep repo.Repository, oid object.ID) []content.ID {
+	if cids, err := rep.VerifyObject(ctx, oid); err == nil {
+		return cids
+	}
+
+	for {
+		if cid, _, ok := oid.ContentID(); ok {
+			return []content.ID{cid}
+		}
+
+		ind, ok := oid.IndexObjectID()
+		if !ok {
+			return nil
+		}
+
+		oid = ind
+	}
+}
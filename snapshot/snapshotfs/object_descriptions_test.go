@@ -0,0 +1,54 @@
+package snapshotfs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestUploadObjectDescriptions(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	u := NewUploader(th.repo)
+	si := snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/home/x"}
+
+	man, err := u.Upload(ctx, th.sourceDir, policy.BuildTree(nil, policy.DefaultPolicy), si)
+	require.NoError(t, err)
+	require.NotNil(t, man.ObjectDescriptions)
+
+	man.ID, err = snapshot.SaveSnapshot(ctx, th.repo, man)
+	require.NoError(t, err)
+
+	entries, err := ReadObjectDescriptions(ctx, th.repo, man)
+	require.NoError(t, err)
+
+	descriptions := map[string]bool{}
+
+	for _, e := range entries {
+		descriptions[e.Description] = true
+
+		if e.Description == "DIR:." {
+			require.Equal(t, man.RootObjectID(), e.ObjectID)
+		}
+	}
+
+	for _, want := range []string{"DIR:.", "DIR:d1", "DIR:d1/d1", "DIR:d1/d2", "DIR:d2", "DIR:d2/d1"} {
+		require.True(t, descriptions[want], want)
+	}
+
+	rootContentID, _, ok := man.RootObjectID().ContentID()
+	require.True(t, ok)
+
+	origins, err := FindContentOrigins(ctx, th.repo, []content.ID{rootContentID})
+	require.NoError(t, err)
+	require.Len(t, origins[rootContentID], 1)
+	require.Contains(t, origins[rootContentID][0].String(), "directory object for /home/x from snapshot "+string(man.ID)+" of user@host:/home/x")
+}
@@ -15,9 +15,23 @@
 	"github.com/kopia/kopia/snapshot"
 )
 
-// ParseObjectIDWithPath interprets the given ID string (which could be an object ID optionally followed by
-// nested path specification) and returns corresponding object.ID.
+// ParseObjectIDWithPath interprets the given ID string (which could be an object ID or a friendly snapshot
+// reference optionally followed by nested path specification) and returns corresponding object.ID.
 func ParseObjectIDWithPath(ctx context.Context, rep repo.Repository, objectIDWithPath string) (object.ID, error) {
+	man, nestedPath, err := ResolveSnapshotName(ctx, rep, objectIDWithPath)
+	if err != nil {
+		return object.EmptyID, err
+	}
+
+	if man != nil {
+		root, err := SnapshotRoot(rep, man)
+		if err != nil {
+			return object.EmptyID, err
+		}
+
+		return parseNestedObjectID(ctx, root, strings.Split(nestedPath, "/"))
+	}
+
 	parts := strings.Split(objectIDWithPath, "/")
 
 	oid, err := object.ParseID(parts[0])
@@ -140,10 +154,24 @@ func latestManifest(mans []*snapshot.Manifest) *snapshot.Manifest {
 }
 
 // FilesystemEntryFromIDWithPath returns a filesystem entry for the provided object ID, which
-// can be a snapshot manifest ID or an object ID with path.
+// can be a snapshot manifest ID, a friendly snapshot reference (see ResolveSnapshotName) or an object ID with path.
 // If multiple snapshots match and they don't agree on root object attributes and consistentAttributes==true
 // the function fails, otherwise it returns the latest of the snapshots.
 func FilesystemEntryFromIDWithPath(ctx context.Context, rep repo.Repository, rootID string, consistentAttributes bool) (fs.Entry, error) {
+	man, nestedPath, err := ResolveSnapshotName(ctx, rep, rootID)
+	if err != nil {
+		return nil, err
+	}
+
+	if man != nil {
+		root, err := SnapshotRoot(rep, man)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetNestedEntry(ctx, root, strings.Split(nestedPath, "/"))
+	}
+
 	pathElements := strings.Split(filepath.ToSlash(rootID), "/")
 
 	if len(pathElements) > 1 {
@@ -154,7 +182,7 @@ func FilesystemEntryFromIDWithPath(ctx context.Context, rep repo.Repository, roo
 
 	var startingEntry fs.Entry
 
-	man, err := findSnapshotByRootObjectIDOrManifestID(ctx, rep, pathElements[0], consistentAttributes)
+	man, err = findSnapshotByRootObjectIDOrManifestID(ctx, rep, pathElements[0], consistentAttributes)
 	if err != nil {
 		return nil, err
 	}
@@ -57,6 +57,15 @@ func (e *repositoryEntry) ModTime() time.Time {
 	return e.metadata.ModTime.ToTime()
 }
 
+// ChangeTime implements fs.EntryWithChangeTime.
+func (e *repositoryEntry) ChangeTime() time.Time {
+	if e.metadata.ChangeTime == 0 {
+		return time.Time{}
+	}
+
+	return e.metadata.ChangeTime.ToTime()
+}
+
 func (e *repositoryEntry) ObjectID() object.ID {
 	return e.metadata.ObjectID
 }
@@ -67,8 +76,10 @@ func (e *repositoryEntry) Sys() interface{} {
 
 func (e *repositoryEntry) Owner() fs.OwnerInfo {
 	return fs.OwnerInfo{
-		UserID:  e.metadata.UserID,
-		GroupID: e.metadata.GroupID,
+		UserID:    e.metadata.UserID,
+		GroupID:   e.metadata.GroupID,
+		UserName:  e.metadata.UserName,
+		GroupName: e.metadata.GroupName,
 	}
 }
 
@@ -84,6 +95,25 @@ func (e *repositoryEntry) LocalFilesystemPath() string {
 	return ""
 }
 
+// MacOSMetadata implements fs.EntryWithMacOSMetadata.
+func (e *repositoryEntry) MacOSMetadata(ctx context.Context) (*fs.MacOSMetadata, error) {
+	return e.metadata.MacOS.ToFS(), nil
+}
+
+// OpenResourceFork implements fs.EntryWithMacOSMetadata.
+func (e *repositoryEntry) OpenResourceFork(ctx context.Context) (io.ReadCloser, error) {
+	if e.metadata.MacOS == nil || e.metadata.MacOS.ResourceFork == nil {
+		return nil, errors.Errorf("%v does not have a resource fork", e.metadata.Name)
+	}
+
+	r, err := e.repo.OpenObject(ctx, *e.metadata.MacOS.ResourceFork)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open resource fork")
+	}
+
+	return r, nil
+}
+
 func (e *repositoryEntry) Close() {
 }
 
@@ -92,7 +122,15 @@ type repositoryDirectory struct {
 
 	mu         sync.Mutex
 	summary    *fs.DirectorySummary
-	dirEntries map[string]*snapshot.DirEntry
+	dirEntries []*snapshot.DirEntry // sorted in canonical order
+	tombstones []*snapshot.DirEntry
+}
+
+// DirectoryWithTombstones is implemented by snapshot directories which may carry a list of entries
+// deleted since the previous snapshot.
+type DirectoryWithTombstones interface {
+	fs.Directory
+	Tombstones(ctx context.Context) ([]*snapshot.DirEntry, error)
 }
 
 type repositoryFile struct {
@@ -116,6 +154,18 @@ func (rd *repositoryDirectory) Summary(ctx context.Context) (*fs.DirectorySummar
 	return rd.summary, nil
 }
 
+// Tombstones returns the entries which were deleted since the previous snapshot, if they were recorded.
+func (rd *repositoryDirectory) Tombstones(ctx context.Context) ([]*snapshot.DirEntry, error) {
+	if err := rd.ensureDirEntriesLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+
+	return rd.tombstones, nil
+}
+
 func (rd *repositoryDirectory) SupportsMultipleIterations() bool {
 	return true
 }
@@ -125,7 +175,7 @@ func (rd *repositoryDirectory) Child(ctx context.Context, name string) (fs.Entry
 		return nil, err
 	}
 
-	de := rd.dirEntries[name]
+	de := snapshot.FindDirEntry(rd.dirEntries, name)
 	if de == nil {
 		return nil, fs.ErrEntryNotFound
 	}
@@ -176,24 +226,21 @@ func (rd *repositoryDirectory) loadLocked(ctx context.Context) error {
 	}
 	defer r.Close() //nolint:errcheck
 
-	ent, summ, err := readDirEntries(r)
+	dir, err := readDirManifest(r)
 	if err != nil {
 		return errors.Wrapf(err, "unable to read dir entries for: %v", rd.metadata.ObjectID)
 	}
 
-	for _, md := range ent {
+	for _, md := range dir.Entries {
 		if md.Type == snapshot.EntryTypeDirectory && md.DirSummary != nil {
 			md.FileSize = md.DirSummary.TotalFileSize
 			md.ModTime = md.DirSummary.MaxModTime
 		}
 	}
 
-	rd.summary = summ
-	rd.dirEntries = map[string]*snapshot.DirEntry{}
-
-	for _, e := range ent {
-		rd.dirEntries[e.Name] = e
-	}
+	rd.summary = dir.Summary
+	rd.dirEntries = append([]*snapshot.DirEntry{}, dir.Entries...)
+	rd.tombstones = dir.Tombstones
 
 	return nil
 }
@@ -214,6 +261,11 @@ func (rf *repositoryFile) Open(ctx context.Context) (fs.Reader, error) {
 	return withFileInfo(r, rf), nil
 }
 
+// LinkType implements fs.SymlinkWithLinkType.
+func (rsl *repositorySymlink) LinkType() string {
+	return rsl.metadata.LinkType
+}
+
 func (rsl *repositorySymlink) Readlink(ctx context.Context) (string, error) {
 	r, err := rsl.repo.OpenObject(ctx, rsl.metadata.ObjectID)
 	if err != nil {
@@ -348,9 +400,10 @@ func IsDirectoryID(oid object.ID) bool {
 }
 
 var (
-	_ fs.Directory = (*repositoryDirectory)(nil)
-	_ fs.File      = (*repositoryFile)(nil)
-	_ fs.Symlink   = (*repositorySymlink)(nil)
+	_ fs.Directory           = (*repositoryDirectory)(nil)
+	_ fs.File                = (*repositoryFile)(nil)
+	_ fs.Symlink             = (*repositorySymlink)(nil)
+	_ fs.SymlinkWithLinkType = (*repositorySymlink)(nil)
 )
 
 var (
@@ -0,0 +1,196 @@
+package snapshotfs
+
+import (
+	"context"
+	"encoding/hex"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+)
+
+// RootReferenceManifestType is the manifest type of root references.
+const RootReferenceManifestType = "rootReference"
+
+const (
+	rootReferenceKeySize     = 32
+	rootReferenceTokenIssuer = "kopia-root-reference"
+)
+
+//nolint:gochecknoglobals
+var rootReferenceKeyPurpose = []byte("root-reference")
+
+var (
+	// ErrRootReferenceNotFound is returned when the root reference does not exist or has been deleted.
+	ErrRootReferenceNotFound = errors.New("root reference not found")
+
+	// ErrInvalidRootReferenceToken is returned when the root reference token is malformed, was not issued
+	// by the repository or does not match the reference.
+	ErrInvalidRootReferenceToken = errors.New("invalid root reference token")
+
+	// ErrRootReferenceExpired is returned when resolving a token of an expired root reference.
+	ErrRootReferenceExpired = errors.New("root reference has expired")
+)
+
+// RootReference pins an immutable tree identified by its root object ID, so that it's retained by
+// garbage collection even after all snapshots referencing it are deleted, and allows minting tokens
+// which external systems can store to retrieve the tree later.
+type RootReference struct {
+	ID manifest.ID `json:"-"`
+
+	RootObjectID object.ID `json:"rootObjectID"`
+	Description  string    `json:"description,omitempty"`
+	CreatedBy    string    `json:"createdBy"`
+	CreateTime   time.Time `json:"createTime"`
+	ExpireTime   time.Time `json:"expireTime,omitempty"`
+}
+
+// Expired returns true if the reference has an expiration time that is not after the provided time.
+func (r *RootReference) Expired(now time.Time) bool {
+	return !r.ExpireTime.IsZero() && !r.ExpireTime.After(now)
+}
+
+// PinRoot creates a root reference to the provided object, which must exist in the repository.
+// Zero expiration time means the reference never expires.
+func PinRoot(ctx context.Context, rep repo.RepositoryWriter, oid object.ID, description string, expireTime time.Time) (*RootReference, error) {
+	if _, err := rep.VerifyObject(ctx, oid); err != nil {
+		return nil, errors.Wrapf(err, "unable to verify object %v", oid)
+	}
+
+	r := &RootReference{
+		RootObjectID: oid,
+		Description:  description,
+		CreatedBy:    rep.ClientOptions().UsernameAtHost(),
+		CreateTime:   rep.Time().UTC(),
+		ExpireTime:   expireTime,
+	}
+
+	id, err := rep.PutManifest(ctx, map[string]string{
+		manifest.TypeLabelKey: RootReferenceManifestType,
+		"rootObjectID":        oid.String(),
+	}, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to save root reference")
+	}
+
+	r.ID = id
+
+	return r, nil
+}
+
+// GetRootReference loads the root reference with the provided ID.
+func GetRootReference(ctx context.Context, rep repo.Repository, id manifest.ID) (*RootReference, error) {
+	r := &RootReference{}
+
+	em, err := rep.GetManifest(ctx, id, r)
+	if err != nil {
+		if errors.Is(err, manifest.ErrNotFound) {
+			return nil, ErrRootReferenceNotFound
+		}
+
+		return nil, errors.Wrapf(err, "unable to load root reference %v", id)
+	}
+
+	if em.Labels[manifest.TypeLabelKey] != RootReferenceManifestType {
+		return nil, ErrRootReferenceNotFound
+	}
+
+	r.ID = id
+
+	return r, nil
+}
+
+// ListRootReferences returns root references stored in the repository ordered by creation time.
+func ListRootReferences(ctx context.Context, rep repo.Repository) ([]*RootReference, error) {
+	entries, err := rep.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey: RootReferenceManifestType,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find root references")
+	}
+
+	var result []*RootReference
+
+	for _, e := range entries {
+		r, err := GetRootReference(ctx, rep, e.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, r)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreateTime.Before(result[j].CreateTime)
+	})
+
+	return result, nil
+}
+
+// UnpinRoot deletes the root reference with the provided ID, which invalidates all its tokens.
+func UnpinRoot(ctx context.Context, rep repo.RepositoryWriter, id manifest.ID) error {
+	if _, err := GetRootReference(ctx, rep, id); err != nil {
+		return err
+	}
+
+	return errors.Wrap(rep.DeleteManifest(ctx, id), "unable to delete root reference")
+}
+
+// RootReferenceToken returns a token identifying the repository, the reference and its root object,
+// which can be later resolved using ResolveRootReferenceToken.
+//
+// Tokens are signed using a key derived from the repository master key. They don't carry any key material,
+// since contents are encrypted using repository-wide keys, so resolving a token always requires access to the
+// repository, either directly or through a server which grants access to the referenced tree to holders of the token.
+func RootReferenceToken(rep repo.DirectRepository, r *RootReference) (string, error) {
+	//nolint:wrapcheck
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.RegisteredClaims{
+		Issuer:   rootReferenceTokenIssuer,
+		Audience: jwt.ClaimStrings{hex.EncodeToString(rep.UniqueID())},
+		Subject:  r.RootObjectID.String(),
+		ID:       string(r.ID),
+		IssuedAt: jwt.NewNumericDate(rep.Time()),
+	}).SignedString(rep.DeriveKey(rootReferenceKeyPurpose, rootReferenceKeySize))
+}
+
+// ResolveRootReferenceToken verifies the provided token and returns the root reference it was issued for.
+func ResolveRootReferenceToken(ctx context.Context, rep repo.DirectRepository, token string) (*RootReference, error) {
+	var claims jwt.RegisteredClaims
+
+	// expiration is determined by the reference itself, so that it can't be extended by re-issuing tokens.
+	if _, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return rep.DeriveKey(rootReferenceKeyPurpose, rootReferenceKeySize), nil
+	}, jwt.WithoutClaimsValidation(), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})); err != nil {
+		return nil, errors.Wrap(ErrInvalidRootReferenceToken, err.Error())
+	}
+
+	if claims.Issuer != rootReferenceTokenIssuer || !claims.VerifyAudience(hex.EncodeToString(rep.UniqueID()), true) {
+		return nil, ErrInvalidRootReferenceToken
+	}
+
+	r, err := GetRootReference(ctx, rep, manifest.ID(claims.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	if r.RootObjectID.String() != claims.Subject {
+		return nil, ErrInvalidRootReferenceToken
+	}
+
+	if r.Expired(rep.Time()) {
+		return nil, ErrRootReferenceExpired
+	}
+
+	return r, nil
+}
+
+// RootReferenceEntry returns the filesystem entry for the root of the provided reference.
+func RootReferenceEntry(ctx context.Context, rep repo.Repository, r *RootReference) fs.Entry {
+	return AutoDetectEntryFromObjectID(ctx, rep, r.RootObjectID, string(r.ID))
+}
@@ -0,0 +1,93 @@
+package snapshotfs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestRootReferences(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	w := te.RepositoryWriter
+
+	dir := mockfs.NewDirectory()
+	dir.AddFile("file1", []byte{1, 2, 3}, 0o644)
+
+	man, err := snapshotfs.NewUploader(w).Upload(ctx, dir, nil, te.LocalPathSourceInfo("/some/path"))
+	require.NoError(t, err)
+
+	r1, err := snapshotfs.PinRoot(ctx, w, man.RootObjectID(), "first", time.Time{})
+	require.NoError(t, err)
+
+	r2, err := snapshotfs.PinRoot(ctx, w, man.RootObjectID(), "second", w.Time().Add(time.Hour))
+	require.NoError(t, err)
+
+	refs, err := snapshotfs.ListRootReferences(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, refs, 2)
+	require.Equal(t, r1.ID, refs[0].ID)
+	require.Equal(t, "second", refs[1].Description)
+
+	tok1, err := snapshotfs.RootReferenceToken(w, r1)
+	require.NoError(t, err)
+
+	got, err := snapshotfs.ResolveRootReferenceToken(ctx, w, tok1)
+	require.NoError(t, err)
+	require.Equal(t, r1.ID, got.ID)
+	require.Equal(t, man.RootObjectID(), got.RootObjectID)
+
+	root, ok := snapshotfs.RootReferenceEntry(ctx, w, got).(fs.Directory)
+	require.True(t, ok)
+
+	f, err := root.Child(ctx, "file1")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), f.Size())
+
+	// tampered tokens are rejected.
+	_, err = snapshotfs.ResolveRootReferenceToken(ctx, w, tok1+"x")
+	require.ErrorIs(t, err, snapshotfs.ErrInvalidRootReferenceToken)
+
+	_, err = snapshotfs.ResolveRootReferenceToken(ctx, w, "not-a-token")
+	require.ErrorIs(t, err, snapshotfs.ErrInvalidRootReferenceToken)
+
+	// tokens issued by another repository are rejected.
+	_, te2 := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	_, err = snapshotfs.ResolveRootReferenceToken(ctx, te2.RepositoryWriter, tok1)
+	require.ErrorIs(t, err, snapshotfs.ErrInvalidRootReferenceToken)
+
+	// objects which don't exist can't be pinned.
+	missing, err := object.ParseID("k0123456789abcdef0123456789abcdef")
+	require.NoError(t, err)
+
+	_, err = snapshotfs.PinRoot(ctx, w, missing, "", time.Time{})
+	require.Error(t, err)
+
+	// unpinning invalidates tokens.
+	require.NoError(t, snapshotfs.UnpinRoot(ctx, w, r1.ID))
+
+	_, err = snapshotfs.ResolveRootReferenceToken(ctx, w, tok1)
+	require.ErrorIs(t, err, snapshotfs.ErrRootReferenceNotFound)
+
+	require.ErrorIs(t, snapshotfs.UnpinRoot(ctx, w, r1.ID), snapshotfs.ErrRootReferenceNotFound)
+	require.ErrorIs(t, snapshotfs.UnpinRoot(ctx, w, manifest.ID("no-such-manifest")), snapshotfs.ErrRootReferenceNotFound)
+
+	// expired references can't be resolved.
+	tok2, err := snapshotfs.RootReferenceToken(w, r2)
+	require.NoError(t, err)
+
+	_, err = snapshotfs.ResolveRootReferenceToken(ctx, w, tok2)
+	require.NoError(t, err)
+
+	require.False(t, r2.Expired(w.Time()))
+	require.True(t, r2.Expired(w.Time().Add(2*time.Hour)))
+}
@@ -0,0 +1,354 @@
+package snapshotfs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// ErrScratchTreeCommitted is returned when attempting to modify a ScratchTree after it has been committed.
+var ErrScratchTreeCommitted = errors.New("scratch tree has already been committed")
+
+// ScratchTree is a writable copy-on-write overlay on top of a directory tree stored in the repository,
+// such as a snapshot root. Directories are loaded lazily as they are modified and only the directories
+// along modified paths are written on Commit(), all other subtrees are referenced by their existing
+// object IDs, so that editing a backup (for example redacting files or assembling a synthetic full
+// snapshot from pieces of other snapshots) does not rewrite unchanged data.
+//
+// Paths are slash-separated and relative to the root of the tree. ScratchTree is not safe for concurrent use.
+type ScratchTree struct {
+	rep       repo.RepositoryWriter
+	root      *scratchDir
+	committed bool
+}
+
+// scratchDir is a directory of a ScratchTree, whose entries are loaded lazily.
+type scratchDir struct {
+	entry    *snapshot.DirEntry
+	entries  map[string]*snapshot.DirEntry // nil until loaded
+	subdirs  map[string]*scratchDir        // modified subdirectories
+	modified bool
+}
+
+// NewScratchTree returns a ScratchTree on top of the provided directory entry.
+func NewScratchTree(rep repo.RepositoryWriter, root *snapshot.DirEntry) (*ScratchTree, error) {
+	if root == nil || root.Type != snapshot.EntryTypeDirectory {
+		return nil, errors.Errorf("root of a scratch tree must be a directory")
+	}
+
+	return &ScratchTree{
+		rep:  rep,
+		root: &scratchDir{entry: root},
+	}, nil
+}
+
+// CloneSnapshot returns a ScratchTree on top of the root of the provided snapshot.
+func CloneSnapshot(rep repo.RepositoryWriter, man *snapshot.Manifest) (*ScratchTree, error) {
+	return NewScratchTree(rep, man.RootEntry)
+}
+
+// Lookup returns the entry at the provided path, reflecting all modifications made so far.
+// It returns fs.ErrEntryNotFound if the entry does not exist.
+func (t *ScratchTree) Lookup(ctx context.Context, relPath string) (*snapshot.DirEntry, error) {
+	parts := splitScratchPath(relPath)
+	if len(parts) == 0 {
+		return t.root.entry, nil
+	}
+
+	dir, err := t.findDir(ctx, parts[:len(parts)-1], false, false)
+	if err != nil {
+		return nil, err
+	}
+
+	de := dir.entries[parts[len(parts)-1]]
+	if de == nil {
+		return nil, errors.Wrap(fs.ErrEntryNotFound, relPath)
+	}
+
+	return de, nil
+}
+
+// Remove removes the entry (file, symlink or an entire subtree) at the provided path.
+func (t *ScratchTree) Remove(ctx context.Context, relPath string) error {
+	if t.committed {
+		return ErrScratchTreeCommitted
+	}
+
+	parts := splitScratchPath(relPath)
+	if len(parts) == 0 {
+		return errors.Errorf("can't remove the root of a scratch tree")
+	}
+
+	dir, err := t.findDir(ctx, parts[:len(parts)-1], true, false)
+	if err != nil {
+		return err
+	}
+
+	name := parts[len(parts)-1]
+
+	if dir.entries[name] == nil {
+		return errors.Wrap(fs.ErrEntryNotFound, relPath)
+	}
+
+	delete(dir.entries, name)
+	delete(dir.subdirs, name)
+
+	return nil
+}
+
+// SetEntry places the provided entry at the given path, replacing any existing entry. The entry
+// typically comes from another snapshot and can be a directory, in which case the subtree is referenced
+// without being copied. Missing parent directories are created.
+func (t *ScratchTree) SetEntry(ctx context.Context, relPath string, de *snapshot.DirEntry) error {
+	if t.committed {
+		return ErrScratchTreeCommitted
+	}
+
+	parts := splitScratchPath(relPath)
+	if len(parts) == 0 {
+		return errors.Errorf("can't replace the root of a scratch tree")
+	}
+
+	dir, err := t.findDir(ctx, parts[:len(parts)-1], true, true)
+	if err != nil {
+		return err
+	}
+
+	name := parts[len(parts)-1]
+
+	e := *de
+	e.Name = name
+
+	dir.entries[name] = &e
+	delete(dir.subdirs, name)
+
+	return nil
+}
+
+// WriteFile writes the provided contents as a new file at the given path, replacing any existing entry.
+// Missing parent directories are created.
+func (t *ScratchTree) WriteFile(ctx context.Context, relPath string, r io.Reader, mode os.FileMode, modTime time.Time) error {
+	if t.committed {
+		return ErrScratchTreeCommitted
+	}
+
+	w := t.rep.NewObjectWriter(ctx, object.WriterOptions{
+		Description: "FILE:" + path.Base(relPath),
+	})
+	defer w.Close() //nolint:errcheck
+
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write %v", relPath)
+	}
+
+	oid, err := w.Result()
+	if err != nil {
+		return errors.Wrapf(err, "unable to write %v", relPath)
+	}
+
+	return t.SetEntry(ctx, relPath, &snapshot.DirEntry{
+		Type:        snapshot.EntryTypeFile,
+		Permissions: snapshot.Permissions(mode & fs.ModBits),
+		FileSize:    n,
+		ModTime:     fs.UTCTimestampFromTime(modTime),
+		ObjectID:    oid,
+	})
+}
+
+// Mkdir creates an empty directory at the given path along with any missing parents.
+// It's not an error if the directory already exists.
+func (t *ScratchTree) Mkdir(ctx context.Context, relPath string) error {
+	if t.committed {
+		return ErrScratchTreeCommitted
+	}
+
+	_, err := t.findDir(ctx, splitScratchPath(relPath), true, true)
+
+	return err
+}
+
+// Commit writes all modified directories to the repository and returns the new root entry.
+// Unmodified subtrees keep their object IDs. The tree can't be modified after it has been committed.
+func (t *ScratchTree) Commit(ctx context.Context) (*snapshot.DirEntry, error) {
+	if t.committed {
+		return nil, ErrScratchTreeCommitted
+	}
+
+	root, err := t.commitDir(ctx, ".", t.root)
+	if err != nil {
+		return nil, err
+	}
+
+	t.committed = true
+
+	return root, nil
+}
+
+// CommitSnapshot commits the tree and saves it as a new snapshot of the provided source.
+func (t *ScratchTree) CommitSnapshot(ctx context.Context, src snapshot.SourceInfo, opt SyntheticSnapshotOptions) (*snapshot.Manifest, error) {
+	root, err := t.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return CreateSnapshotFromObject(ctx, t.rep, src, root.ObjectID, opt)
+}
+
+func (t *ScratchTree) commitDir(ctx context.Context, relPath string, dir *scratchDir) (*snapshot.DirEntry, error) {
+	if !dir.modified {
+		// reuse the existing object.
+		return dir.entry, nil
+	}
+
+	var builder DirManifestBuilder
+
+	for name, de := range dir.entries {
+		if sd := dir.subdirs[name]; sd != nil {
+			var err error
+
+			if de, err = t.commitDir(ctx, path.Join(relPath, name), sd); err != nil {
+				return nil, err
+			}
+		}
+
+		builder.AddEntry(de)
+	}
+
+	incompleteReason := ""
+	if dir.entry.DirSummary != nil {
+		incompleteReason = dir.entry.DirSummary.IncompleteReason
+	}
+
+	dm := builder.Build(dir.entry.ModTime, incompleteReason)
+
+	oid, err := writeDirManifest(ctx, t.rep, relPath, dm, nil, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to write directory %v", relPath)
+	}
+
+	result := *dir.entry
+	result.ObjectID = oid
+	result.DirSummary = dm.Summary
+
+	return &result, nil
+}
+
+// findDir returns the directory at the provided path with its entries loaded. When modify is true,
+// all directories along the path are marked as modified and missing ones are created if create is true.
+func (t *ScratchTree) findDir(ctx context.Context, parts []string, modify, create bool) (*scratchDir, error) {
+	dir := t.root
+
+	for i, name := range parts {
+		if err := t.loadEntries(ctx, dir); err != nil {
+			return nil, err
+		}
+
+		if modify {
+			dir.modified = true
+		}
+
+		if sd := dir.subdirs[name]; sd != nil {
+			dir = sd
+			continue
+		}
+
+		de := dir.entries[name]
+
+		switch {
+		case de == nil && modify && create:
+			de = &snapshot.DirEntry{
+				Name:        name,
+				Type:        snapshot.EntryTypeDirectory,
+				Permissions: snapshot.Permissions(defaultSyntheticDirPermissions),
+				ModTime:     fs.UTCTimestampFromTime(t.rep.Time()),
+			}
+
+			// new directory has no object yet, start with no entries.
+			sd := &scratchDir{
+				entry:    de,
+				entries:  map[string]*snapshot.DirEntry{},
+				subdirs:  map[string]*scratchDir{},
+				modified: true,
+			}
+
+			dir.entries[name] = de
+			dir.subdirs[name] = sd
+			dir = sd
+
+			continue
+
+		case de == nil:
+			return nil, errors.Wrap(fs.ErrEntryNotFound, path.Join(parts[:i+1]...))
+
+		case de.Type != snapshot.EntryTypeDirectory:
+			return nil, errors.Errorf("%v is not a directory", path.Join(parts[:i+1]...))
+		}
+
+		sd := &scratchDir{entry: de}
+		if modify {
+			dir.subdirs[name] = sd
+		}
+
+		dir = sd
+	}
+
+	if err := t.loadEntries(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if modify {
+		dir.modified = true
+	}
+
+	return dir, nil
+}
+
+// loadEntries loads entries of the directory from the repository unless they have already been loaded.
+func (t *ScratchTree) loadEntries(ctx context.Context, dir *scratchDir) error {
+	if dir.entries != nil {
+		return nil
+	}
+
+	r, err := t.rep.OpenObject(ctx, dir.entry.ObjectID)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open directory object %v", dir.entry.ObjectID)
+	}
+	defer r.Close() //nolint:errcheck
+
+	entries, _, err := readDirEntries(r)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read directory object %v", dir.entry.ObjectID)
+	}
+
+	dir.entries = map[string]*snapshot.DirEntry{}
+	dir.subdirs = map[string]*scratchDir{}
+
+	for _, de := range entries {
+		dir.entries[de.Name] = de
+	}
+
+	return nil
+}
+
+func splitScratchPath(relPath string) []string {
+	var result []string
+
+	for _, p := range strings.Split(path.Clean("/"+relPath), "/") {
+		if p != "" {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,119 @@
+package snapshotfs
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestScratchTree(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	man, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	st, err := CloneSnapshot(th.repo, man)
+	require.NoError(t, err)
+
+	origD1, err := st.Lookup(ctx, "d1")
+	require.NoError(t, err)
+
+	origD2D1, err := st.Lookup(ctx, "d2/d1")
+	require.NoError(t, err)
+
+	// redact a file, add a new one and graft a subtree in a new directory.
+	require.NoError(t, st.Remove(ctx, "d2/d1/f1"))
+	require.NoError(t, st.WriteFile(ctx, "d2/new-file", bytes.NewReader([]byte("hello")), 0o644, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+	require.NoError(t, st.SetEntry(ctx, "new/dir/copy-of-d1", origD1))
+	require.NoError(t, st.Mkdir(ctx, "empty"))
+
+	require.ErrorIs(t, st.Remove(ctx, "d2/no-such-file"), fs.ErrEntryNotFound)
+	require.ErrorIs(t, st.Remove(ctx, "d3/no-such-dir/file"), fs.ErrEntryNotFound)
+	require.Error(t, st.SetEntry(ctx, "f1/something", origD1))
+
+	_, err = st.Lookup(ctx, "d2/d1/f1")
+	require.ErrorIs(t, err, fs.ErrEntryNotFound)
+
+	man2, err := st.CommitSnapshot(ctx, snapshot.SourceInfo{Host: "host", UserName: "user", Path: "/edited"}, SyntheticSnapshotOptions{
+		Description: "edited",
+	})
+	require.NoError(t, err)
+	require.NotEqual(t, man.RootEntry.ObjectID, man2.RootEntry.ObjectID)
+
+	require.ErrorIs(t, st.Remove(ctx, "f1"), ErrScratchTreeCommitted)
+
+	// one file was removed, one was added and files of d1 are now present twice.
+	require.Equal(t, man.RootEntry.DirSummary.TotalFileCount-1+1+origD1.DirSummary.TotalFileCount, man2.RootEntry.DirSummary.TotalFileCount)
+
+	root := EntryFromDirEntry(th.repo, man2.RootEntry).(fs.Directory)
+
+	// unmodified subtrees are referenced by their original object IDs.
+	d1, err := GetNestedEntry(ctx, root, []string{"d1"})
+	require.NoError(t, err)
+	require.Equal(t, origD1.ObjectID, d1.(object.HasObjectID).ObjectID())
+
+	grafted, err := GetNestedEntry(ctx, root, []string{"new", "dir", "copy-of-d1"})
+	require.NoError(t, err)
+	require.Equal(t, origD1.ObjectID, grafted.(object.HasObjectID).ObjectID())
+
+	// modified directory got rewritten.
+	d2d1, err := GetNestedEntry(ctx, root, []string{"d2", "d1"})
+	require.NoError(t, err)
+	require.NotEqual(t, origD2D1.ObjectID, d2d1.(object.HasObjectID).ObjectID())
+
+	_, err = GetNestedEntry(ctx, root, []string{"d2", "d1", "f1"})
+	require.Error(t, err)
+
+	nf, err := GetNestedEntry(ctx, root, []string{"d2", "new-file"})
+	require.NoError(t, err)
+
+	r, err := nf.(fs.File).Open(ctx)
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "hello", string(b))
+
+	empty, err := GetNestedEntry(ctx, root, []string{"empty"})
+	require.NoError(t, err)
+	require.True(t, empty.IsDir())
+}
+
+func TestScratchTree_LookupDoesNotModify(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	man, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policy.BuildTree(nil, policy.DefaultPolicy), snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	st, err := CloneSnapshot(th.repo, man)
+	require.NoError(t, err)
+
+	_, err = st.Lookup(ctx, "d1/d1/f1")
+	require.NoError(t, err)
+
+	root, err := st.Commit(ctx)
+	require.NoError(t, err)
+	require.Equal(t, man.RootEntry.ObjectID, root.ObjectID)
+
+	_, err = NewScratchTree(th.repo, &snapshot.DirEntry{Type: snapshot.EntryTypeFile})
+	require.True(t, err != nil && !errors.Is(err, fs.ErrEntryNotFound))
+}
@@ -0,0 +1,98 @@
+package snapshotfs
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+)
+
+// snapshotStream is a JSON object attached to a snapshot manifest, identified by its stream ID.
+type snapshotStream interface {
+	streamID() string
+}
+
+// snapshotStreamCollector accumulates entries of a snapshot stream during upload.
+// When maxEntries is positive, entries exceeding it are only counted.
+type snapshotStreamCollector[T any] struct {
+	maxEntries int
+
+	mu sync.Mutex
+	// +checklocks:mu
+	entries []T
+	// +checklocks:mu
+	omitted int
+}
+
+func (c *snapshotStreamCollector[T]) add(e T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.omitted++
+		return
+	}
+
+	c.entries = append(c.entries, e)
+}
+
+// sorted returns a sorted copy of collected entries and the number of omitted ones.
+func (c *snapshotStreamCollector[T]) sorted(less func(a, b T) bool) (entries []T, omitted int) {
+	c.mu.Lock()
+	entries = append([]T(nil), c.entries...)
+	omitted = c.omitted
+	c.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return less(entries[i], entries[j])
+	})
+
+	return entries, omitted
+}
+
+// writeSnapshotStream writes the stream as a single object, 'what' describes the stream in error messages.
+func writeSnapshotStream(ctx context.Context, rep repo.RepositoryWriter, description, what string, s snapshotStream) (*object.ID, error) {
+	w := rep.NewObjectWriter(ctx, object.WriterOptions{
+		Description: description,
+		Prefix:      objectIDPrefixDirectory,
+	})
+
+	defer w.Close() //nolint:errcheck
+
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		return nil, errors.Wrapf(err, "unable to encode %v", what)
+	}
+
+	oid, err := w.Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to write %v", what)
+	}
+
+	return &oid, nil
+}
+
+// readSnapshotStream reads the stream object of the provided snapshot into s and verifies its stream ID.
+func readSnapshotStream(ctx context.Context, rep repo.Repository, oid object.ID, snapshotID manifest.ID, what, wantStreamID string, s snapshotStream) error {
+	r, err := rep.OpenObject(ctx, oid)
+	if err != nil {
+		return errors.Wrapf(err, "unable to open %v of snapshot %v", what, snapshotID)
+	}
+
+	defer r.Close() //nolint:errcheck
+
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return errors.Wrapf(err, "unable to decode %v of snapshot %v", what, snapshotID)
+	}
+
+	if got := s.streamID(); got != wantStreamID {
+		return errors.Errorf("unexpected %v stream %q in snapshot %v", what, got, snapshotID)
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@
 	"github.com/kopia/kopia/internal/bigmap"
 	"github.com/kopia/kopia/internal/workshare"
 	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
 )
 
 const walkersPerCPU = 4
@@ -42,6 +43,20 @@ func oidOf(e fs.Entry) object.ID {
 	return object.EmptyID
 }
 
+// resourceForkPathSuffix is appended to the path of entries when reporting their resource forks.
+const resourceForkPathSuffix = "/..namedfork/rsrc"
+
+// resourceForkOf returns the ID of the object holding macOS resource fork of the entry, if any.
+func resourceForkOf(e fs.Entry) object.ID {
+	if h, ok := e.(snapshot.HasDirEntry); ok {
+		if m := h.DirEntry().MacOS; m != nil && m.ResourceFork != nil {
+			return *m.ResourceFork
+		}
+	}
+
+	return object.EmptyID
+}
+
 // ReportError reports the error.
 func (w *TreeWalker) ReportError(ctx context.Context, entryPath string, err error) {
 	w.mu.Lock()
@@ -99,6 +114,13 @@ func (w *TreeWalker) processEntry(ctx context.Context, e fs.Entry, entryPath str
 			w.ReportError(ctx, entryPath, err)
 			return
 		}
+
+		if rf := resourceForkOf(e); rf != object.EmptyID {
+			if err := ec(ctx, e, rf, entryPath+resourceForkPathSuffix); err != nil {
+				w.ReportError(ctx, entryPath, err)
+				return
+			}
+		}
 	}
 
 	if dir, ok := e.(fs.Directory); ok {
@@ -33,6 +33,7 @@ type Verifier struct {
 
 	queued    atomic.Int32
 	processed atomic.Int32
+	failed    atomic.Int32
 
 	fileWorkQueue chan verifyFileWorkItem
 	rep           repo.Repository
@@ -40,6 +41,24 @@ type Verifier struct {
 	workersWG     sync.WaitGroup
 
 	blobMap map[blob.ID]blob.Metadata // when != nil, will check that each backing blob exists
+
+	failures failureDomains
+}
+
+// VerifierStats represents verification progress.
+type VerifierStats struct {
+	Queued    int32 `json:"queued"`
+	Processed int32 `json:"processed"`
+	Failed    int32 `json:"failed"`
+}
+
+// Stats returns current verification statistics.
+func (v *Verifier) Stats() VerifierStats {
+	return VerifierStats{
+		Queued:    v.queued.Load(),
+		Processed: v.processed.Load(),
+		Failed:    v.failed.Load(),
+	}
 }
 
 // ShowStats logs verification statistics.
@@ -69,29 +88,61 @@ func (v *Verifier) VerifyFile(ctx context.Context, oid object.ID, entryPath stri
 		return errors.Wrap(err, "verify object")
 	}
 
-	if v.blobMap != nil {
+	var packBlobs []blob.ID
+
+	if v.blobMap != nil || len(v.opts.SuspectBlobs) > 0 {
+		seen := map[blob.ID]bool{}
+
 		for _, cid := range contentIDs {
 			ci, err := v.rep.ContentInfo(ctx, cid)
 			if err != nil {
 				return errors.Wrapf(err, "error verifying content %v", cid)
 			}
 
-			if _, ok := v.blobMap[ci.GetPackBlobID()]; !ok {
-				return errors.Errorf("object %v is backed by missing blob %v", oid, ci.GetPackBlobID())
+			packID := ci.GetPackBlobID()
+
+			if v.blobMap != nil {
+				if _, ok := v.blobMap[packID]; !ok {
+					err := errors.Errorf("object %v is backed by missing blob %v", oid, packID)
+					v.reportFailure(oid, entryPath, []blob.ID{packID}, err)
+
+					return err
+				}
+			}
+
+			if !seen[packID] {
+				seen[packID] = true
+				packBlobs = append(packBlobs, packID)
 			}
 		}
 	}
 
+	// files stored in suspect blobs are always read in full.
+	suspect := v.isSuspect(packBlobs)
+
 	//nolint:gosec
-	if 100*rand.Float64() < v.opts.VerifyFilesPercent {
+	if suspect || 100*rand.Float64() < v.opts.VerifyFilesPercent {
 		if err := v.readEntireObject(ctx, oid, entryPath); err != nil {
-			return errors.Wrapf(err, "error reading object %v", oid)
+			err = errors.Wrapf(err, "error reading object %v", oid)
+			v.reportFailure(oid, entryPath, v.unreadablePackBlobsOf(ctx, contentIDs), err)
+
+			return err
 		}
 	}
 
+	if suspect {
+		v.failures.recordAffectedFile(AffectedFile{Path: entryPath, ObjectID: oid, Blobs: packBlobs})
+	}
+
 	return nil
 }
 
+func (v *Verifier) reportFailure(oid object.ID, entryPath string, blobIDs []blob.ID, err error) {
+	v.failed.Add(1)
+	v.failures.recordFailedBlobs(blobIDs)
+	v.failures.recordAffectedFile(AffectedFile{Path: entryPath, ObjectID: oid, Blobs: blobIDs, Error: err.Error()})
+}
+
 // verifyObject enqueues a single object for verification.
 func (v *Verifier) verifyObject(ctx context.Context, e fs.Entry, oid object.ID, entryPath string) error {
 	if v.throttle.ShouldOutput(time.Second) {
@@ -129,6 +180,10 @@ type VerifierOptions struct {
 	Parallelism        int
 	MaxErrors          int
 	BlobMap            map[blob.ID]blob.Metadata
+
+	// SuspectBlobs are pack blobs likely affected by earlier failures, files stored in them are always read in full
+	// and reported as potentially affected.
+	SuspectBlobs map[blob.ID]bool
 }
 
 // InParallel starts parallel verification and invokes the provided function which can
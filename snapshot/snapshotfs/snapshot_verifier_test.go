@@ -2,8 +2,10 @@
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -141,6 +143,71 @@ func TestSnapshotVerifier(t *testing.T) {
 		}), "is backed by missing blob")
 	})
 
+	t.Run("FailureDomains", func(t *testing.T) {
+		fullBlobMap, err := blob.ReadBlobMap(ctx, te.RepositoryWriter.BlobReader())
+		require.NoError(t, err)
+
+		bm, err := blob.ReadBlobMap(ctx, te.RepositoryWriter.BlobReader())
+		require.NoError(t, err)
+
+		var packBlobs []blob.ID
+
+		for k := range bm {
+			if strings.HasPrefix(string(k), "p") {
+				packBlobs = append(packBlobs, k)
+				delete(bm, k)
+			}
+		}
+
+		v := snapshotfs.NewVerifier(ctx, te2, snapshotfs.VerifierOptions{MaxErrors: 30, BlobMap: bm})
+
+		require.ErrorContains(t, v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
+			tw.Process(ctx, snapshotfs.DirectoryEntry(te.Repository, obj1, nil), ".")
+			return nil
+		}), "encountered 3 errors")
+
+		require.ElementsMatch(t, packBlobs, v.FailedBlobs())
+
+		affected := v.AffectedFiles()
+		require.Len(t, affected, 3)
+		require.Equal(t, "file1", affected[0].Path)
+		require.Contains(t, affected[0].Error, "is backed by missing blob")
+
+		history := snapshotfs.NewVerifyFailureHistory()
+		for _, id := range v.FailedBlobs() {
+			history.Blobs[id] = te.Repository.Time()
+		}
+
+		historyFile := filepath.Join(t.TempDir(), "history.json")
+		require.NoError(t, history.Save(historyFile))
+
+		history, err = snapshotfs.LoadVerifyFailureHistory(historyFile)
+		require.NoError(t, err)
+		require.Len(t, history.Blobs, len(packBlobs))
+
+		// blobs have been restored, files stored in suspect blobs are read and reported as readable.
+		v = snapshotfs.NewVerifier(ctx, te2, snapshotfs.VerifierOptions{
+			MaxErrors:    30,
+			BlobMap:      fullBlobMap,
+			SuspectBlobs: history.SuspectBlobs(fullBlobMap, time.Hour),
+		})
+
+		require.NoError(t, v.VerifySuspectBlobs(ctx))
+		require.NoError(t, v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
+			tw.Process(ctx, snapshotfs.DirectoryEntry(te.Repository, obj1, nil), ".")
+			return nil
+		}))
+
+		require.Empty(t, v.FailedBlobs())
+
+		affected = v.AffectedFiles()
+		require.Len(t, affected, 3)
+		require.Empty(t, affected[0].Error)
+
+		history.Expire(te.Repository.Time().Add(time.Hour))
+		require.Empty(t, history.Blobs)
+	})
+
 	t.Run("FullFileReadsNoBlobMap", func(t *testing.T) {
 		opts := snapshotfs.VerifierOptions{
 			VerifyFilesPercent: 100,
@@ -0,0 +1,200 @@
+package snapshotfs
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// SourceSharingStats describes how much of the data referenced by snapshots of a single source
+// is unique to that source and how much is shared with other sources.
+// All byte counts are sizes of contents as stored in the repository.
+type SourceSharingStats struct {
+	Source        snapshot.SourceInfo `json:"source"`
+	SnapshotCount int                 `json:"snapshots"`
+
+	// contents referenced by any snapshot of the source.
+	// +checkatomic
+	ContentCount int64 `json:"contents"`
+	// +checkatomic
+	ContentBytes int64 `json:"contentBytes"`
+
+	// contents referenced only by snapshots of the source.
+	UniqueContentCount int64 `json:"uniqueContents"`
+	UniqueContentBytes int64 `json:"uniqueContentBytes"`
+
+	// contents also referenced by snapshots of other sources.
+	SharedContentCount int64 `json:"sharedContents"`
+	SharedContentBytes int64 `json:"sharedContentBytes"`
+}
+
+// SourceSharingReport summarizes sharing of contents between snapshot sources.
+type SourceSharingReport struct {
+	Sources []*SourceSharingStats `json:"sources"`
+
+	// contents referenced by at least one snapshot.
+	ReferencedContentCount int64 `json:"referencedContents"`
+	ReferencedContentBytes int64 `json:"referencedContentBytes"`
+
+	// contents not referenced by any snapshot, such as metadata or contents awaiting garbage collection.
+	UnreferencedContentCount int64 `json:"unreferencedContents"`
+	UnreferencedContentBytes int64 `json:"unreferencedContentBytes"`
+}
+
+// CalculateSourceSharingStats determines, for each source of the provided snapshots, how much data
+// is referenced uniquely by that source and how much is shared with other sources.
+//
+// Snapshots of each source are walked to determine the contents they reference, after which
+// the contents in the repository index are attributed to the sources referencing them.
+func CalculateSourceSharingStats(ctx context.Context, rep repo.DirectRepository, manifests []*snapshot.Manifest) (*SourceSharingReport, error) {
+	// content ID => index of the first source referencing it.
+	owners, err := bigmap.NewMap(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewMap")
+	}
+
+	defer owners.Close(ctx)
+
+	// contents referenced by more than one source.
+	shared, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "NewSet")
+	}
+
+	defer shared.Close(ctx)
+
+	report := &SourceSharingReport{}
+
+	for _, snapshots := range snapshot.GroupBySource(manifests) {
+		st := &SourceSharingStats{
+			Source:        snapshots[0].Source,
+			SnapshotCount: len(snapshots),
+		}
+
+		if err := collectSourceContents(ctx, rep, snapshots, uint32(len(report.Sources)), st, owners, shared); err != nil {
+			return nil, errors.Wrapf(err, "error processing snapshots of %v", st.Source)
+		}
+
+		report.Sources = append(report.Sources, st)
+	}
+
+	var ownerBuf [4]byte
+
+	if err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+		var cidbuf [128]byte
+
+		cid := ci.GetContentID().Append(cidbuf[:0])
+		l := int64(ci.GetPackedLength())
+
+		owner, ok, err := owners.Get(ctx, ownerBuf[:0], cid)
+		if err != nil {
+			return errors.Wrap(err, "error looking up content owner")
+		}
+
+		if !ok {
+			report.UnreferencedContentCount++
+			report.UnreferencedContentBytes += l
+
+			return nil
+		}
+
+		report.ReferencedContentCount++
+		report.ReferencedContentBytes += l
+
+		if !shared.Contains(cid) {
+			st := report.Sources[binary.BigEndian.Uint32(owner)]
+			st.UniqueContentCount++
+			st.UniqueContentBytes += l
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "error iterating contents")
+	}
+
+	for _, st := range report.Sources {
+		st.SharedContentCount = st.ContentCount - st.UniqueContentCount
+		st.SharedContentBytes = st.ContentBytes - st.UniqueContentBytes
+	}
+
+	sort.Slice(report.Sources, func(i, j int) bool {
+		return report.Sources[i].Source.String() < report.Sources[j].Source.String()
+	})
+
+	return report, nil
+}
+
+func collectSourceContents(ctx context.Context, rep repo.Repository, snapshots []*snapshot.Manifest, sourceIndex uint32, st *SourceSharingStats, owners *bigmap.Map, shared *bigmap.Set) error {
+	seen, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return errors.Wrap(err, "NewSet")
+	}
+
+	defer seen.Close(ctx)
+
+	var ownerValue [4]byte
+
+	binary.BigEndian.PutUint32(ownerValue[:], sourceIndex)
+
+	tw, twerr := NewTreeWalker(ctx, TreeWalkerOptions{
+		EntryCallback: func(ctx context.Context, _ fs.Entry, oid object.ID, _ string) error {
+			contentIDs, err := rep.VerifyObject(ctx, oid)
+			if err != nil {
+				return errors.Wrapf(err, "error verifying object %v", oid)
+			}
+
+			var cidbuf [128]byte
+
+			for _, cid := range contentIDs {
+				key := cid.Append(cidbuf[:0])
+
+				if !seen.Put(ctx, key) {
+					continue
+				}
+
+				info, err := rep.ContentInfo(ctx, cid)
+				if err != nil {
+					return errors.Wrapf(err, "error getting content info for %v", cid)
+				}
+
+				atomic.AddInt64(&st.ContentCount, 1)
+				atomic.AddInt64(&st.ContentBytes, int64(info.GetPackedLength()))
+
+				if !owners.PutIfAbsent(ctx, key, ownerValue[:]) {
+					// already referenced by a previously processed source.
+					shared.Put(ctx, key)
+				}
+			}
+
+			return nil
+		},
+	})
+	if twerr != nil {
+		return errors.Wrap(twerr, "tree walker")
+	}
+	defer tw.Close(ctx)
+
+	for _, snap := range snapshots {
+		root, err := SnapshotRoot(rep, snap)
+		if err != nil {
+			return errors.Wrapf(err, "unable to get snapshot root for %v", snap.ID)
+		}
+
+		if err := tw.Process(ctx, root, snap.Source.String()+"@"+snap.StartTime.Format(time.RFC3339)); err != nil {
+			return errors.Wrapf(err, "error processing snapshot %v", snap.ID)
+		}
+	}
+
+	return nil
+}
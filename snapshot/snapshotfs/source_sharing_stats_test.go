@@ -0,0 +1,68 @@
+package snapshotfs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestCalculateSourceSharingStats(t *testing.T) {
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	// root1 and root2 share one file, each has one unique file and a unique root directory.
+	root1 := mockfs.NewDirectory()
+	root1.AddFile("shared", []byte{1, 2, 3}, 0o644)
+	root1.AddFile("file1", []byte{1, 2, 3, 4}, 0o644)
+
+	root2 := mockfs.NewDirectory()
+	root2.AddFile("shared", []byte{1, 2, 3}, 0o644)
+	root2.AddFile("file2", []byte{1, 2, 3, 4, 5}, 0o644)
+
+	sourceFor := func(p string) snapshot.SourceInfo {
+		return snapshot.SourceInfo{
+			Host:     env.Repository.ClientOptions().Hostname,
+			UserName: env.Repository.ClientOptions().Username,
+			Path:     p,
+		}
+	}
+
+	u := snapshotfs.NewUploader(env.RepositoryWriter)
+
+	man1, err := u.Upload(ctx, root1, nil, sourceFor("/src1"))
+	require.NoError(t, err)
+
+	man2, err := u.Upload(ctx, root2, nil, sourceFor("/src2"))
+	require.NoError(t, err)
+
+	// second snapshot of the same source does not change anything.
+	man3, err := u.Upload(ctx, root2, nil, sourceFor("/src2"))
+	require.NoError(t, err)
+
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	report, err := snapshotfs.CalculateSourceSharingStats(ctx, env.RepositoryWriter, []*snapshot.Manifest{man3, man1, man2})
+	require.NoError(t, err)
+
+	require.Len(t, report.Sources, 2)
+	require.Equal(t, int64(5), report.ReferencedContentCount)
+
+	for i, st := range report.Sources {
+		require.Equal(t, sourceFor([]string{"/src1", "/src2"}[i]), st.Source)
+		require.Equal(t, int64(3), st.ContentCount)
+		require.Equal(t, int64(2), st.UniqueContentCount)
+		require.Equal(t, int64(1), st.SharedContentCount)
+		require.Equal(t, st.ContentBytes, st.UniqueContentBytes+st.SharedContentBytes)
+	}
+
+	require.Equal(t, 1, report.Sources[0].SnapshotCount)
+	require.Equal(t, 2, report.Sources[1].SnapshotCount)
+
+	require.Equal(t,
+		report.ReferencedContentBytes,
+		report.Sources[0].UniqueContentBytes+report.Sources[1].UniqueContentBytes+report.Sources[0].SharedContentBytes)
+}
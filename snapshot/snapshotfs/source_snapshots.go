@@ -4,6 +4,7 @@
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -77,6 +78,10 @@ func (s *sourceSnapshots) Iterate(ctx context.Context) (fs.DirectoryIterator, er
 
 	for _, m := range manifests {
 		name := m.StartTime.Format("20060102-150405")
+		if m.Subpath != "" {
+			name += " [" + strings.ReplaceAll(m.Subpath, "/", "_") + "]"
+		}
+
 		if m.IncompleteReason != "" {
 			name += fmt.Sprintf(" (%v)", m.IncompleteReason)
 		}
@@ -0,0 +1,98 @@
+package snapshotfs
+
+import (
+	"context"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// SyntheticSnapshotOptions controls creation of snapshots from existing directory objects.
+type SyntheticSnapshotOptions struct {
+	Description string
+	Tags        map[string]string
+	Pins        []string
+
+	// StartTime and EndTime of the snapshot, both default to the current repository time.
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// CreateSnapshotFromObject creates and saves snapshot manifest for the provided source, whose root is an existing
+// directory object, which enables tools that assemble directory trees directly using the repository API
+// to turn them into regular snapshots.
+func CreateSnapshotFromObject(ctx context.Context, rep repo.RepositoryWriter, src snapshot.SourceInfo, rootOID object.ID, opt SyntheticSnapshotOptions) (*snapshot.Manifest, error) {
+	r, err := rep.OpenObject(ctx, rootOID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to open object %v", rootOID)
+	}
+	defer r.Close() //nolint:errcheck
+
+	dm, err := readDirManifest(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "object %v is not a valid directory", rootOID)
+	}
+
+	summ := dm.Summary
+	if summ == nil {
+		summ = &fs.DirectorySummary{}
+	}
+
+	now := rep.Time()
+
+	if opt.EndTime.IsZero() {
+		opt.EndTime = now
+	}
+
+	if opt.StartTime.IsZero() {
+		opt.StartTime = opt.EndTime
+	}
+
+	if opt.StartTime.After(opt.EndTime) {
+		return nil, errors.Errorf("start time is after end time")
+	}
+
+	man := &snapshot.Manifest{
+		Source:           src,
+		Description:      opt.Description,
+		StartTime:        fs.UTCTimestampFromTime(opt.StartTime),
+		EndTime:          fs.UTCTimestampFromTime(opt.EndTime),
+		IncompleteReason: summ.IncompleteReason,
+		Tags:             opt.Tags,
+		RootEntry: &snapshot.DirEntry{
+			Name:        path.Base(filepath.ToSlash(src.Path)),
+			Type:        snapshot.EntryTypeDirectory,
+			Permissions: snapshot.Permissions(defaultSyntheticDirPermissions),
+			ModTime:     summ.MaxModTime,
+			ObjectID:    rootOID,
+			DirSummary:  summ,
+		},
+	}
+
+	man.Stats.TotalFileSize = summ.TotalFileSize
+	man.Stats.TotalFileCount = int32(summ.TotalFileCount)
+	man.Stats.TotalDirectoryCount = int32(summ.TotalDirCount)
+	man.Stats.ErrorCount = int32(summ.FatalErrorCount)
+	man.Stats.IgnoredErrorCount = int32(summ.IgnoredErrorCount)
+
+	man.UpdatePins(opt.Pins, nil)
+
+	id, err := snapshot.SaveSnapshot(ctx, rep, man)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to save snapshot")
+	}
+
+	man.ID = id
+
+	return man, nil
+}
+
+// defaultSyntheticDirPermissions are the permissions of root directories of synthetic snapshots.
+const defaultSyntheticDirPermissions = 0o755
@@ -79,6 +79,10 @@ type Uploader struct {
 	// Number of files to hash and upload in parallel.
 	ParallelUploads int
 
+	// Reduce memory usage by hashing one file at a time, not overlapping writes with uploads and
+	// streaming directory manifests.
+	LowMemory bool
+
 	// Enable snapshot actions
 	EnableActions bool
 
@@ -95,14 +99,38 @@ type Uploader struct {
 	// When set to true, do not ignore any files, regardless of policy settings.
 	DisableIgnoreRules bool
 
+	// Classifiers assigning tags to files, which are aggregated in the snapshot manifest.
+	Classifiers []Classifier
+
+	// Identifies the configuration of Classifiers. Tags of files unchanged since previous snapshots
+	// classified using the same configuration are reused without invoking classifiers.
+	ClassifiersID string
+
 	// Labels to apply to every checkpoint made for this snapshot.
 	CheckpointLabels map[string]string
 
+	// When set, the uploaded entry is the subtree of the source at the given relative path,
+	// which is recorded in the snapshot and checkpoint manifests.
+	Subpath string
+
 	repo repo.RepositoryWriter
 
 	// stats must be allocated on heap to enforce 64-bit alignment due to atomic access on ARM.
 	stats *snapshot.Stats
 
+	histograms *snapshot.HistogramBuilder
+
+	classifications *snapshot.ClassificationBuilder
+
+	// when set, classifications of cached files are taken from previous snapshots.
+	reuseClassifications bool
+
+	directoryUploadStats *snapshot.DirectoryUploadStatsBuilder
+
+	objectDescriptions *objectDescriptionCollector
+
+	errorManifest *errorManifestCollector
+
 	isCanceled atomic.Bool
 
 	getTicker func(time.Duration) <-chan time.Time
@@ -165,7 +193,7 @@ func (u *Uploader) uploadFileInternal(ctx context.Context, parentCheckpointRegis
 	chunkSize := pol.UploadPolicy.ParallelUploadAboveSize.OrDefault(-1)
 	if chunkSize < 0 || f.Size() <= chunkSize {
 		// all data fits in 1 full chunks, upload directly
-		return u.uploadFileData(ctx, parentCheckpointRegistry, f, f.Name(), 0, -1, comp)
+		return u.uploadFileData(ctx, parentCheckpointRegistry, relativePath, f, f.Name(), 0, -1, comp)
 	}
 
 	// we always have N+1 parts, first N are exactly chunkSize, last one has undetermined length
@@ -191,11 +219,11 @@ func (u *Uploader) uploadFileInternal(ctx context.Context, parentCheckpointRegis
 		if wg.CanShareWork(u.workerPool) {
 			// another goroutine is available, delegate to them
 			wg.RunAsync(u.workerPool, func(c *workshare.Pool[*uploadWorkItem], request *uploadWorkItem) {
-				parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, f, uuid.NewString(), offset, length, comp)
+				parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, relativePath, f, uuid.NewString(), offset, length, comp)
 			}, nil)
 		} else {
 			// just do the work in the current goroutine
-			parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, f, uuid.NewString(), offset, length, comp)
+			parts[i], partErrors[i] = u.uploadFileData(ctx, parentCheckpointRegistry, relativePath, f, uuid.NewString(), offset, length, comp)
 		}
 	}
 
@@ -234,7 +262,7 @@ func concatenateParts(ctx context.Context, rep repo.RepositoryWriter, name strin
 	return de, nil
 }
 
-func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry *checkpointRegistry, f fs.File, fname string, offset, length int64, compressor compression.Name) (*snapshot.DirEntry, error) {
+func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry *checkpointRegistry, relativePath string, f fs.File, fname string, offset, length int64, compressor compression.Name) (*snapshot.DirEntry, error) {
 	file, err := f.Open(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to open file")
@@ -242,9 +270,10 @@ func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry
 	defer file.Close() //nolint:errcheck
 
 	writer := u.repo.NewObjectWriter(ctx, object.WriterOptions{
-		Description: "FILE:" + fname,
-		Compressor:  compressor,
-		AsyncWrites: 1, // upload chunk in parallel to writing another chunk
+		Description:      "FILE:" + fname,
+		Compressor:       compressor,
+		AsyncWrites:      u.asyncWrites(),
+		OnContentWritten: u.recordDirectoryUploadStats(relativePath),
 	})
 	defer writer.Close() //nolint:errcheck
 
@@ -275,7 +304,7 @@ func (u *Uploader) uploadFileData(ctx context.Context, parentCheckpointRegistry
 		s = io.LimitReader(s, length)
 	}
 
-	written, err := u.copyWithProgress(writer, s)
+	written, err := u.copyWithProgress(relativePath, writer, s)
 	if err != nil {
 		return nil, err
 	}
@@ -316,7 +345,7 @@ func (u *Uploader) uploadSymlinkInternal(ctx context.Context, relativePath strin
 	})
 	defer writer.Close() //nolint:errcheck
 
-	written, err := u.copyWithProgress(writer, bytes.NewBufferString(target))
+	written, err := u.copyWithProgress(relativePath, writer, bytes.NewBufferString(target))
 	if err != nil {
 		return nil, err
 	}
@@ -354,13 +383,14 @@ func (u *Uploader) uploadStreamingFileInternal(ctx context.Context, relativePath
 
 	comp := pol.CompressionPolicy.CompressorForFile(f)
 	writer := u.repo.NewObjectWriter(ctx, object.WriterOptions{
-		Description: "STREAMFILE:" + f.Name(),
-		Compressor:  comp,
+		Description:      "STREAMFILE:" + f.Name(),
+		Compressor:       comp,
+		OnContentWritten: u.recordDirectoryUploadStats(relativePath),
 	})
 
 	defer writer.Close() //nolint:errcheck
 
-	written, err := u.copyWithProgress(writer, reader)
+	written, err := u.copyWithProgress(relativePath, writer, reader)
 	if err != nil {
 		return nil, err
 	}
@@ -384,7 +414,15 @@ func (u *Uploader) uploadStreamingFileInternal(ctx context.Context, relativePath
 	return de, nil
 }
 
-func (u *Uploader) copyWithProgress(dst io.Writer, src io.Reader) (int64, error) {
+// recordDirectoryUploadStats returns a function which attributes contents written to a file with a given
+// relative path to its top-level directory.
+func (u *Uploader) recordDirectoryUploadStats(relativePath string) func(numBytes int64, deduplicated bool) {
+	return func(numBytes int64, deduplicated bool) {
+		u.directoryUploadStats.Add(relativePath, numBytes, deduplicated)
+	}
+}
+
+func (u *Uploader) copyWithProgress(relativePath string, dst io.Writer, src io.Reader) (int64, error) {
 	uploadBuf := iocopy.GetBuffer()
 	defer iocopy.ReleaseBuffer(uploadBuf)
 
@@ -403,6 +441,7 @@ func (u *Uploader) copyWithProgress(dst io.Writer, src io.Reader) (int64, error)
 				written += int64(wroteBytes)
 				u.totalWrittenBytes.Add(int64(wroteBytes))
 				u.Progress.HashedBytes(int64(wroteBytes))
+				u.Progress.HashedFileBytes(relativePath, int64(wroteBytes))
 			}
 
 			if writeErr != nil {
@@ -463,7 +502,10 @@ func newDirEntry(md fs.Entry, fname string, oid object.ID) (*snapshot.DirEntry,
 		ModTime:     fs.UTCTimestampFromTime(md.ModTime()),
 		UserID:      md.Owner().UserID,
 		GroupID:     md.Owner().GroupID,
+		UserName:    md.Owner().UserName,
+		GroupName:   md.Owner().GroupName,
 		ObjectID:    oid,
+		LinkType:    fs.LinkType(md),
 	}, nil
 }
 
@@ -487,7 +529,7 @@ func newCachedDirEntry(md, cached fs.Entry, fname string) (*snapshot.DirEntry, e
 func (u *Uploader) uploadFileWithCheckpointing(ctx context.Context, relativePath string, file fs.File, pol *policy.Policy, sourceInfo snapshot.SourceInfo) (*snapshot.DirEntry, error) {
 	var cp checkpointRegistry
 
-	cancelCheckpointer := u.periodicallyCheckpoint(ctx, &cp, &snapshot.Manifest{Source: sourceInfo})
+	cancelCheckpointer := u.periodicallyCheckpoint(ctx, &cp, &snapshot.Manifest{Source: sourceInfo, Subpath: u.Subpath})
 	defer cancelCheckpointer()
 
 	res, err := u.uploadFileInternal(ctx, &cp, relativePath, file, pol)
@@ -586,7 +628,7 @@ func (u *Uploader) uploadDirWithCheckpointing(ctx context.Context, rootDir fs.Di
 		cp  checkpointRegistry
 	)
 
-	cancelCheckpointer := u.periodicallyCheckpoint(ctx, &cp, &snapshot.Manifest{Source: sourceInfo})
+	cancelCheckpointer := u.periodicallyCheckpoint(ctx, &cp, &snapshot.Manifest{Source: sourceInfo, Subpath: u.Subpath})
 	defer cancelCheckpointer()
 
 	var hc actionContext
@@ -697,14 +739,34 @@ func commonMetadataEquals(e1, e2 fs.Entry) bool {
 		return false
 	}
 
-	if l, r := e1.Owner(), e2.Owner(); l != r {
+	if !ownerIDsEqual(e1.Owner(), e2.Owner()) {
 		return false
 	}
 
 	return true
 }
 
-func metadataEquals(e1, e2 fs.Entry) bool {
+// ownerIDsEqual compares numeric owner IDs only, user and group names are informational
+// and renaming users or groups must not invalidate previous snapshots.
+func ownerIDsEqual(o1, o2 fs.OwnerInfo) bool {
+	return o1.UserID == o2.UserID && o1.GroupID == o2.GroupID
+}
+
+func metadataEquals(e1, e2 fs.Entry, mode policy.ChangeDetectionMode) bool {
+	//nolint:exhaustive
+	switch mode {
+	case policy.ChangeDetectionModTime:
+		return commonMetadataEquals(e1, e2)
+
+	case policy.ChangeDetectionChangeTime:
+		l, r := fs.ChangeTime(e1), fs.ChangeTime(e2)
+		if !l.IsZero() && !r.IsZero() {
+			return l.Equal(r) && e1.Mode() == e2.Mode() && ownerIDsEqual(e1.Owner(), e2.Owner()) && e1.Size() == e2.Size()
+		}
+
+		// change time is not available on this platform or in the previous snapshot, fall back to the default.
+	}
+
 	if !commonMetadataEquals(e1, e2) {
 		return false
 	}
@@ -719,6 +781,11 @@ func metadataEquals(e1, e2 fs.Entry) bool {
 func findCachedEntry(ctx context.Context, entryRelativePath string, entry fs.Entry, prevDirs []fs.Directory, pol *policy.Tree) fs.Entry {
 	var missedEntry fs.Entry
 
+	mode := pol.EffectivePolicy().UploadPolicy.ChangeDetection.OrDefault(policy.ChangeDetectionModTimeAndSize)
+	if mode == policy.ChangeDetectionAlwaysHash {
+		return nil
+	}
+
 	for _, e := range prevDirs {
 		if ent, err := e.Child(ctx, entry.Name()); err == nil {
 			switch entry.(type) {
@@ -727,7 +794,7 @@ func findCachedEntry(ctx context.Context, entryRelativePath string, entry fs.Ent
 					return ent
 				}
 			default:
-				if metadataEquals(entry, ent) {
+				if metadataEquals(entry, ent, mode) {
 					return ent
 				}
 			}
@@ -750,6 +817,20 @@ func findCachedEntry(ctx context.Context, entryRelativePath string, entry fs.Ent
 	return nil
 }
 
+// withChangeTime records the change time of the entry when the policy uses it to detect changes,
+// otherwise it's not stored to avoid rewriting directories whose entries only had their ctime updated.
+func withChangeTime(e fs.Entry, de *snapshot.DirEntry, pol *policy.Tree) *snapshot.DirEntry {
+	if de == nil || pol.EffectivePolicy().UploadPolicy.ChangeDetection.OrDefault(policy.ChangeDetectionModTimeAndSize) != policy.ChangeDetectionChangeTime {
+		return de
+	}
+
+	if ct := fs.ChangeTime(e); !ct.IsZero() {
+		de.ChangeTime = fs.UTCTimestampFromTime(ct)
+	}
+
+	return de
+}
+
 func (u *Uploader) maybeIgnoreCachedEntry(ctx context.Context, ent fs.Entry) fs.Entry {
 	if h, ok := ent.(object.HasObjectID); ok {
 		if 100*rand.Float64() < u.ForceHashPercentage { //nolint:gosec
@@ -763,7 +844,20 @@ func (u *Uploader) maybeIgnoreCachedEntry(ctx context.Context, ent fs.Entry) fs.
 	return nil
 }
 
+// asyncWrites returns the number of chunks of a file uploaded in parallel to writing another chunk.
+func (u *Uploader) asyncWrites() int {
+	if u.LowMemory {
+		return 0
+	}
+
+	return 1
+}
+
 func (u *Uploader) effectiveParallelFileReads(pol *policy.Policy) int {
+	if u.LowMemory {
+		return 1
+	}
+
 	p := u.ParallelUploads
 	if p > 0 {
 		// command-line override takes precedence.
@@ -849,9 +943,12 @@ func (u *Uploader) processSingle(
 		if cachedEntry := u.maybeIgnoreCachedEntry(ctx, findCachedEntry(ctx, entryRelativePath, entry, prevDirs, policyTree)); cachedEntry != nil {
 			atomic.AddInt32(&u.stats.CachedFiles, 1)
 			atomic.AddInt64(&u.stats.TotalFileSize, cachedEntry.Size())
+			u.directoryUploadStats.Add(entryRelativePath, cachedEntry.Size(), true)
 			u.Progress.CachedFile(entryRelativePath, cachedEntry.Size())
 
 			cachedDirEntry, err := newCachedDirEntry(entry, cachedEntry, entry.Name())
+			cachedDirEntry, err = u.withMacOSMetadata(ctx, entry, cachedEntry, cachedDirEntry, err)
+			cachedDirEntry = withChangeTime(entry, cachedDirEntry, policyTree)
 
 			u.Progress.FinishedFile(entryRelativePath, err)
 
@@ -859,10 +956,14 @@ func (u *Uploader) processSingle(
 				return errors.Wrap(err, "unable to create dir entry")
 			}
 
+			if f, ok := entry.(fs.File); ok {
+				cachedDirEntry.Classifications = u.classifyCachedFile(ctx, entryRelativePath, f, cachedEntry)
+			}
+
 			return u.processEntryUploadResult(ctx, cachedDirEntry, nil, entryRelativePath, parentDirBuilder,
 				false,
 				u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.CacheHit.OrDefault(policy.LogDetailNone)),
-				"cached", t0)
+				"cached", ErrorPhaseUpload, t0)
 		}
 	}
 
@@ -890,7 +991,7 @@ func (u *Uploader) processSingle(
 			var dre dirReadError
 			if errors.As(err, &dre) {
 				isIgnoredError := childTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreDirectoryErrors.OrDefault(false)
-				u.reportErrorAndMaybeCancel(dre.error, isIgnoredError, parentDirBuilder, entryRelativePath)
+				u.reportErrorAndMaybeCancel(dre.error, isIgnoredError, parentDirBuilder, entryRelativePath, ErrorPhaseReadDirectory)
 			} else {
 				return errors.Wrapf(err, "unable to process directory %q", entry.Name())
 			}
@@ -902,40 +1003,50 @@ func (u *Uploader) processSingle(
 
 	case fs.Symlink:
 		de, err := u.uploadSymlinkInternal(ctx, entryRelativePath, entry)
+		de, err = u.withMacOSMetadata(ctx, entry, nil, de, err)
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
 			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
-			"snapshotted symlink", t0)
+			"snapshotted symlink", ErrorPhaseUpload, t0)
 
 	case fs.File:
 		atomic.AddInt32(&u.stats.NonCachedFiles, 1)
 
 		de, err := u.uploadFileInternal(ctx, parentCheckpointRegistry, entryRelativePath, entry, policyTree.Child(entry.Name()).EffectivePolicy())
+		de, err = u.withMacOSMetadata(ctx, entry, nil, de, err)
+		de = withChangeTime(entry, de, policyTree)
+
+		if err == nil {
+			de.Classifications = u.classifyFile(ctx, entryRelativePath, entry)
+		}
 
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
 			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
-			"snapshotted file", t0)
+			"snapshotted file", ErrorPhaseUpload, t0)
 
 	case fs.ErrorEntry:
 		var (
 			isIgnoredError bool
 			prefix         string
+			phase          string
 		)
 
 		if errors.Is(entry.ErrorInfo(), fs.ErrUnknown) {
 			isIgnoredError = policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreUnknownTypes.OrDefault(true)
 			prefix = "unknown entry"
+			phase = ErrorPhaseUnknownType
 		} else {
 			isIgnoredError = policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false)
 			prefix = "error"
+			phase = ErrorPhaseReadEntry
 		}
 
 		return u.processEntryUploadResult(ctx, nil, entry.ErrorInfo(), entryRelativePath, parentDirBuilder,
 			isIgnoredError,
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
-			prefix, t0)
+			prefix, phase, t0)
 
 	case fs.StreamingFile:
 		atomic.AddInt32(&u.stats.NonCachedFiles, 1)
@@ -945,18 +1056,22 @@ func (u *Uploader) processSingle(
 		return u.processEntryUploadResult(ctx, de, err, entryRelativePath, parentDirBuilder,
 			policyTree.EffectivePolicy().ErrorHandlingPolicy.IgnoreFileErrors.OrDefault(false),
 			u.OverrideEntryLogDetail.OrDefault(policyTree.EffectivePolicy().LoggingPolicy.Entries.Snapshotted.OrDefault(policy.LogDetailNone)),
-			"snapshotted streaming file", t0)
+			"snapshotted streaming file", ErrorPhaseUpload, t0)
 
 	default:
 		return errors.Errorf("unexpected entry type: %T %v", entry, entry.Mode())
 	}
 }
 
-func (u *Uploader) processEntryUploadResult(ctx context.Context, de *snapshot.DirEntry, err error, entryRelativePath string, parentDirBuilder *DirManifestBuilder, isIgnored bool, logDetail policy.LogDetail, logMessage string, t0 timetrack.Timer) error {
+func (u *Uploader) processEntryUploadResult(ctx context.Context, de *snapshot.DirEntry, err error, entryRelativePath string, parentDirBuilder *DirManifestBuilder, isIgnored bool, logDetail policy.LogDetail, logMessage, errorPhase string, t0 timetrack.Timer) error {
 	if err != nil {
-		u.reportErrorAndMaybeCancel(err, isIgnored, parentDirBuilder, entryRelativePath)
+		u.reportErrorAndMaybeCancel(err, isIgnored, parentDirBuilder, entryRelativePath, errorPhase)
 	} else {
 		parentDirBuilder.AddEntry(de)
+
+		if de.Type == snapshot.EntryTypeFile {
+			u.histograms.AddFile(entryRelativePath, de.FileSize)
+		}
 	}
 
 	maybeLogEntryProcessed(
@@ -1154,7 +1269,7 @@ func uploadDirInternal(
 		}
 
 		checkpointManifest := thisCheckpointBuilder.Build(fs.UTCTimestampFromTime(directory.ModTime()), IncompleteReasonCheckpoint)
-		oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, checkpointManifest)
+		oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, checkpointManifest, nil, u.LowMemory)
 		if err != nil {
 			return nil, errors.Wrap(err, "error writing dir manifest")
 		}
@@ -1167,17 +1282,42 @@ func uploadDirInternal(
 		return nil, err
 	}
 
+	if policyTree.EffectivePolicy().UploadPolicy.RecordDeletedEntries.OrDefault(false) && !u.IsCanceled() {
+		thisDirBuilder.AddTombstones(previousDirEntries(ctx, previousDirs))
+	}
+
 	dirManifest := thisDirBuilder.Build(fs.UTCTimestampFromTime(directory.ModTime()), u.incompleteReason())
 
-	oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, dirManifest)
+	oid, err := writeDirManifest(ctx, u.repo, dirRelativePath, dirManifest, u.objectDescriptions, u.LowMemory)
 	if err != nil {
 		return nil, errors.Wrapf(err, "error writing dir manifest: %v", directory.Name())
 	}
 
-	return newDirEntryWithSummary(directory, oid, dirManifest.Summary)
+	de, err := newDirEntryWithSummary(directory, oid, dirManifest.Summary)
+
+	return u.withMacOSMetadata(ctx, directory, nil, de, err)
 }
 
-func (u *Uploader) reportErrorAndMaybeCancel(err error, isIgnored bool, dmb *DirManifestBuilder, entryRelativePath string) {
+// previousDirEntries returns entries of all previous snapshots of a directory.
+func previousDirEntries(ctx context.Context, previousDirs []fs.Directory) []*snapshot.DirEntry {
+	var result []*snapshot.DirEntry
+
+	for _, d := range previousDirs {
+		if err := fs.IterateEntries(ctx, d, func(ctx context.Context, e fs.Entry) error {
+			if h, ok := e.(snapshot.HasDirEntry); ok {
+				result = append(result, h.DirEntry())
+			}
+
+			return nil
+		}); err != nil {
+			uploadLog(ctx).Debugw("unable to read previous directory", "dir", d.Name(), "error", err)
+		}
+	}
+
+	return result
+}
+
+func (u *Uploader) reportErrorAndMaybeCancel(err error, isIgnored bool, dmb *DirManifestBuilder, entryRelativePath, phase string) {
 	if u.IsCanceled() && errors.Is(err, errCanceled) {
 		// already canceled, do not report another.
 		return
@@ -1192,6 +1332,7 @@ func (u *Uploader) reportErrorAndMaybeCancel(err error, isIgnored bool, dmb *Dir
 	rc := rootCauseError(err)
 	u.Progress.Error(entryRelativePath, rc, isIgnored)
 	dmb.AddFailedEntry(entryRelativePath, isIgnored, rc)
+	u.errorManifest.recordError(entryRelativePath, phase, rc, isIgnored)
 
 	if u.FailFast && !isIgnored {
 		u.Cancel()
@@ -1256,17 +1397,22 @@ func (u *Uploader) Upload(
 	uploadLog(ctx).Debugw("uploading", "source", sourceInfo, "previousManifests", len(previousManifests), "parallel", parallel)
 
 	s := &snapshot.Manifest{
-		Source: sourceInfo,
+		Source:  sourceInfo,
+		Subpath: u.Subpath,
 	}
 
 	u.workerPool = workshare.NewPool[*uploadWorkItem](parallel - 1)
 	defer u.workerPool.Close()
 
 	u.stats = &snapshot.Stats{}
+	u.histograms = &snapshot.HistogramBuilder{}
+	u.classifications = &snapshot.ClassificationBuilder{}
+	u.reuseClassifications = canReuseClassifications(u.ClassifiersID, previousManifests)
+	u.directoryUploadStats = &snapshot.DirectoryUploadStatsBuilder{}
+	u.objectDescriptions = &objectDescriptionCollector{}
+	u.errorManifest = newErrorManifestCollector()
 	u.totalWrittenBytes.Store(0)
 
-	var err error
-
 	s.StartTime = fs.UTCTimestampFromTime(u.repo.Time())
 
 	var scanWG sync.WaitGroup
@@ -1275,6 +1421,17 @@ func (u *Uploader) Upload(
 
 	defer cancelScan()
 
+	estimateSource := source
+	if dir, ok := source.(fs.Directory); ok {
+		estimateSource = u.wrapIgnorefs(estimateLog(ctx), dir, policyTree, false /* reportIgnoreStats */)
+	}
+
+	// when the policy limits the size of the snapshot, it is estimated before uploading anything.
+	preflight, err := u.checkMaxSnapshotSize(ctx, estimateSource, policyTree)
+	if err != nil {
+		return nil, err
+	}
+
 	switch entry := source.(type) {
 	case fs.Directory:
 		var previousDirs []fs.Directory
@@ -1285,17 +1442,20 @@ func (u *Uploader) Upload(
 			}
 		}
 
-		scanWG.Add(1)
-
-		go func() {
-			defer scanWG.Done()
+		if preflight != nil {
+			u.Progress.EstimatedDataSize(preflight.numFiles, preflight.totalFileSize)
+		} else {
+			scanWG.Add(1)
 
-			wrapped := u.wrapIgnorefs(estimateLog(ctx), entry, policyTree, false /* reportIgnoreStats */)
+			go func() {
+				defer scanWG.Done()
 
-			ds, _ := u.scanDirectory(scanctx, wrapped, policyTree)
+				//nolint:forcetypeassert
+				ds, _ := u.scanDirectory(scanctx, estimateSource.(fs.Directory), policyTree)
 
-			u.Progress.EstimatedDataSize(ds.numFiles, ds.totalFileSize)
-		}()
+				u.Progress.EstimatedDataSize(ds.numFiles, ds.totalFileSize)
+			}()
+		}
 
 		wrapped := u.wrapIgnorefs(uploadLog(ctx), entry, policyTree, true /* reportIgnoreStats */)
 
@@ -1319,6 +1479,23 @@ func (u *Uploader) Upload(
 	s.IncompleteReason = u.incompleteReason()
 	s.EndTime = fs.UTCTimestampFromTime(u.repo.Time())
 	s.Stats = *u.stats
+	s.Histograms = u.histograms.Build()
+	s.Classifications = u.classifications.Build()
+
+	if len(u.Classifiers) > 0 {
+		s.ClassifiersID = u.ClassifiersID
+	}
+	s.DirectoryUploadStats = u.directoryUploadStats.Build()
+
+	s.ObjectDescriptions, err = u.objectDescriptions.writeIndex(ctx, u.repo)
+	if err != nil {
+		return nil, err
+	}
+
+	s.ErrorManifest, err = u.errorManifest.writeManifest(ctx, u.repo)
+	if err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
@@ -1350,5 +1527,9 @@ func (u *Uploader) wrapIgnorefs(logger logging.Logger, entry fs.Directory, polic
 		}
 
 		u.stats.AddExcluded(md)
+	}), ignorefs.ReportFilesIgnoredByOwner(func(ctx context.Context, fname string, md fs.Entry, policyTree *policy.Tree) {
+		if reportIgnoreStats {
+			atomic.AddInt32(&u.stats.ExcludedByOwnerCount, 1)
+		}
 	}))
 }
@@ -19,6 +19,7 @@
 	"github.com/kopia/kopia/fs"
 	"github.com/kopia/kopia/fs/localfs"
 	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
 )
 
@@ -252,3 +253,35 @@ func cleanupActionContext(ctx context.Context, hc *actionContext) {
 		}
 	}
 }
+
+// ExecuteSnapshotAnomalyAction runs the provided action command after anomalies have been detected in the
+// provided snapshot. Descriptions of the anomalies are passed in KOPIA_SNAPSHOT_ANOMALIES, one per line.
+func (u *Uploader) ExecuteSnapshotAnomalyAction(ctx context.Context, h *policy.ActionCommand, man *snapshot.Manifest) error {
+	const actionType = "snapshot-anomaly"
+
+	if h == nil {
+		return nil
+	}
+
+	if !u.EnableActions {
+		uploadLog(ctx).Infof("Not executing %v action on %v because it's been disabled for this client.", actionType, man.Source)
+		return nil
+	}
+
+	wd, err := os.MkdirTemp("", "kopia-action")
+	if err != nil {
+		return errors.Wrap(err, "error temporary directory for action execution")
+	}
+
+	defer cleanupActionContext(ctx, &actionContext{WorkDir: wd})
+
+	hc := &actionContext{
+		SnapshotID:   string(man.ID),
+		SourcePath:   man.Source.Path,
+		SnapshotPath: man.Source.Path,
+	}
+
+	envars := append(hc.envars(actionType), "KOPIA_SNAPSHOT_ANOMALIES="+strings.Join(man.Anomalies, "\n"))
+
+	return errors.Wrapf(runActionCommand(ctx, actionType, h, envars, nil, wd), "error running '%v' action", actionType)
+}
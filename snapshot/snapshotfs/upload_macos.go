@@ -0,0 +1,85 @@
+package snapshotfs
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/iocopy"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+)
+
+// withMacOSMetadata records macOS-specific metadata of the entry in the provided directory entry.
+// Resource forks of files are uploaded as separate objects, unless the entry is unchanged since the provided
+// previous snapshot entry (nil if none) which has a resource fork of the same size.
+func (u *Uploader) withMacOSMetadata(ctx context.Context, e, prev fs.Entry, de *snapshot.DirEntry, err error) (*snapshot.DirEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	me, ok := e.(fs.EntryWithMacOSMetadata)
+	if !ok {
+		return de, nil
+	}
+
+	md, err := me.MacOSMetadata(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read macOS metadata")
+	}
+
+	de.MacOS = snapshot.MacOSMetadataFromFS(md)
+
+	if de.MacOS == nil || md.ResourceForkSize == 0 || de.Type != snapshot.EntryTypeFile {
+		return de, nil
+	}
+
+	if oid := previousResourceFork(prev, md.ResourceForkSize); oid != nil {
+		de.MacOS.ResourceFork = oid
+		de.MacOS.ResourceForkSize = md.ResourceForkSize
+
+		return de, nil
+	}
+
+	r, err := me.OpenResourceFork(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open resource fork")
+	}
+	defer r.Close() //nolint:errcheck
+
+	w := u.repo.NewObjectWriter(ctx, object.WriterOptions{
+		Description: "RSRC:" + e.Name(),
+	})
+	defer w.Close() //nolint:errcheck
+
+	n, err := iocopy.Copy(w, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read resource fork")
+	}
+
+	oid, err := w.Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to write resource fork")
+	}
+
+	de.MacOS.ResourceFork = &oid
+	de.MacOS.ResourceForkSize = n
+
+	return de, nil
+}
+
+// previousResourceFork returns the object ID of the resource fork of the previous snapshot entry if it has the provided size.
+func previousResourceFork(prev fs.Entry, size int64) *object.ID {
+	h, ok := prev.(snapshot.HasDirEntry)
+	if !ok {
+		return nil
+	}
+
+	if m := h.DirEntry().MacOS; m != nil && m.ResourceFork != nil && m.ResourceForkSize == size {
+		oid := *m.ResourceFork
+		return &oid
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package snapshotfs
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/testlogging"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/policy"
+)
+
+func TestUploadMacOSMetadata(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	btime := time.Date(2020, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	sourceDir := mockfs.NewDirectory()
+	sourceDir.AddFile("plain", []byte{1, 2, 3}, defaultPermissions)
+	sourceDir.AddFile("mac", []byte{4, 5, 6}, defaultPermissions).SetMacOSMetadata(&fs.MacOSMetadata{
+		BirthTime:        btime,
+		Flags:            0x8000, // UF_HIDDEN
+		FinderInfo:       []byte("TEXTttxt"),
+		Quarantine:       "0081;5f000000;Safari;",
+		ResourceForkSize: 5,
+	}, []byte("rsrc!"))
+	sourceDir.AddDir("d", defaultPermissions).SetMacOSMetadata(&fs.MacOSMetadata{BirthTime: btime}, nil)
+
+	u := NewUploader(th.repo)
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	man, err := u.Upload(ctx, sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	root := EntryFromDirEntry(th.repo, man.RootEntry).(fs.Directory)
+
+	plain, err := root.Child(ctx, "plain")
+	require.NoError(t, err)
+	require.Nil(t, plain.(snapshot.HasDirEntry).DirEntry().MacOS)
+
+	mac, err := root.Child(ctx, "mac")
+	require.NoError(t, err)
+
+	md, err := mac.(fs.EntryWithMacOSMetadata).MacOSMetadata(ctx)
+	require.NoError(t, err)
+	require.True(t, btime.Equal(md.BirthTime))
+	require.Equal(t, uint32(0x8000), md.Flags)
+	require.Equal(t, []byte("TEXTttxt"), md.FinderInfo)
+	require.Equal(t, "0081;5f000000;Safari;", md.Quarantine)
+	require.Equal(t, int64(5), md.ResourceForkSize)
+
+	r, err := mac.(fs.EntryWithMacOSMetadata).OpenResourceFork(ctx)
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "rsrc!", string(b))
+	r.Close()
+
+	d, err := root.Child(ctx, "d")
+	require.NoError(t, err)
+
+	md, err = d.(fs.EntryWithMacOSMetadata).MacOSMetadata(ctx)
+	require.NoError(t, err)
+	require.True(t, btime.Equal(md.BirthTime))
+
+	// tree walker reports resource forks, so they are retained by garbage collection.
+	var walked []object.ID
+
+	w, err := NewTreeWalker(ctx, TreeWalkerOptions{
+		Parallelism: 1,
+		EntryCallback: func(ctx context.Context, entry fs.Entry, oid object.ID, entryPath string) error {
+			walked = append(walked, oid)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	defer w.Close(ctx)
+
+	require.NoError(t, w.Process(ctx, root, ""))
+	require.Contains(t, walked, *mac.(snapshot.HasDirEntry).DirEntry().MacOS.ResourceFork)
+
+	// resource forks of unchanged files are not uploaded again.
+	mf, err := sourceDir.Child(ctx, "mac")
+	require.NoError(t, err)
+
+	mf.(*mockfs.File).SetMacOSMetadata(&fs.MacOSMetadata{ResourceForkSize: 5}, []byte("other"))
+
+	man2, err := u.Upload(ctx, sourceDir, policyTree, snapshot.SourceInfo{}, man)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), man2.Stats.CachedFiles)
+
+	mac2, err := EntryFromDirEntry(th.repo, man2.RootEntry).(fs.Directory).Child(ctx, "mac")
+	require.NoError(t, err)
+	require.Equal(t, *mac.(snapshot.HasDirEntry).DirEntry().MacOS.ResourceFork, *mac2.(snapshot.HasDirEntry).DirEntry().MacOS.ResourceFork)
+}
@@ -41,6 +41,9 @@ type UploadProgress interface {
 	// HashedBytes is emitted while hashing any blocks of bytes.
 	HashedBytes(numBytes int64)
 
+	// HashedFileBytes is emitted along with HashedBytes and identifies the file being hashed.
+	HashedFileBytes(fname string, numBytes int64)
+
 	// Error is emitted when an error is encountered.
 	Error(path string, err error, isIgnored bool)
 
@@ -76,6 +79,11 @@ func (p *NullUploadProgress) UploadFinished() {}
 //nolint:revive
 func (p *NullUploadProgress) HashedBytes(numBytes int64) {}
 
+// HashedFileBytes implements UploadProgress.
+//
+//nolint:revive
+func (p *NullUploadProgress) HashedFileBytes(fname string, numBytes int64) {}
+
 // ExcludedFile implements UploadProgress.
 //
 //nolint:revive
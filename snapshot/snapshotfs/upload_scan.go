@@ -4,7 +4,10 @@
 	"context"
 	"sync/atomic"
 
+	"github.com/pkg/errors"
+
 	"github.com/kopia/kopia/fs"
+	"github.com/kopia/kopia/internal/units"
 	"github.com/kopia/kopia/snapshot"
 	"github.com/kopia/kopia/snapshot/policy"
 )
@@ -41,3 +44,41 @@ func (u *Uploader) scanDirectory(ctx context.Context, dir fs.Directory, policyTr
 
 	return res, err
 }
+
+// ErrMaxSnapshotSizeExceeded is returned when the estimated size of a snapshot exceeds the maximum size
+// allowed by the upload policy.
+var ErrMaxSnapshotSizeExceeded = errors.New("estimated snapshot size exceeds the maximum allowed by the policy")
+
+// checkMaxSnapshotSize estimates the size of the snapshot before uploading and fails (or warns)
+// if it exceeds the maximum size specified in the upload policy. It returns the estimate, if one was made.
+func (u *Uploader) checkMaxSnapshotSize(ctx context.Context, source fs.Entry, policyTree *policy.Tree) (*scanResults, error) {
+	up := policyTree.EffectivePolicy().UploadPolicy
+
+	maxSize := up.MaxSnapshotSize.OrDefault(0)
+	if maxSize <= 0 {
+		//nolint:nilnil
+		return nil, nil
+	}
+
+	res := &scanResults{numFiles: 1, totalFileSize: source.Size()}
+
+	if dir, ok := source.(fs.Directory); ok {
+		*res = scanResults{}
+
+		if err := Estimate(ctx, dir, policyTree, res, 1); err != nil {
+			return nil, errors.Wrap(err, "unable to estimate snapshot size")
+		}
+	}
+
+	if res.totalFileSize <= maxSize {
+		return res, nil
+	}
+
+	if up.MaxSnapshotSizeWarnOnly.OrDefault(false) {
+		uploadLog(ctx).Warnf("estimated snapshot size %v exceeds the maximum of %v", units.BytesString(res.totalFileSize), units.BytesString(maxSize))
+
+		return res, nil
+	}
+
+	return nil, errors.Wrapf(ErrMaxSnapshotSizeExceeded, "%v in %v files, maximum is %v", units.BytesString(res.totalFileSize), res.numFiles, units.BytesString(maxSize))
+}
@@ -15,6 +15,7 @@
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -228,6 +229,51 @@ func TestUpload(t *testing.T) {
 	}
 }
 
+func TestUploadReusesClassificationsOfCachedFiles(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	var classified atomic.Int32
+
+	u := NewUploader(th.repo)
+	u.Classifiers = []Classifier{ClassifierFunc(func(ctx context.Context, relativePath string, f fs.File) ([]string, error) {
+		classified.Add(1)
+
+		if strings.HasSuffix(relativePath, "f1") {
+			return []string{"tag1"}, nil
+		}
+
+		return nil, nil
+	})}
+	u.ClassifiersID = "test"
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	s1, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.EqualValues(t, s1.Stats.NonCachedFiles, classified.Load())
+
+	classified.Store(0)
+	th.sourceDir.AddFile("d2/d1/f3", []byte{1, 2, 3, 4, 5}, defaultPermissions)
+
+	s2, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{}, s1)
+	require.NoError(t, err)
+
+	// only the new file is classified, tags of unchanged files are taken from the previous snapshot.
+	require.EqualValues(t, 1, classified.Load())
+	require.Equal(t, s1.Classifications, s2.Classifications)
+
+	// all files are classified again when the configuration of classifiers changes.
+	classified.Store(0)
+	u.ClassifiersID = "changed"
+
+	s3, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{}, s2)
+	require.NoError(t, err)
+	require.EqualValues(t, s3.Stats.CachedFiles, classified.Load())
+}
+
 func TestUpload_TopLevelDirectoryReadFailure(t *testing.T) {
 	ctx := testlogging.Context(t)
 	th := newUploadTestHarness(ctx, t)
@@ -446,6 +492,102 @@ func TestUpload_ErrorEntries(t *testing.T) {
 	}
 }
 
+func TestUpload_LowMemory(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	s1, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	u := NewUploader(th.repo)
+	u.LowMemory = true
+
+	require.Equal(t, 1, u.effectiveParallelFileReads(policyTree.EffectivePolicy()))
+
+	s2, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	// streaming directory manifests produces identical objects.
+	require.Equal(t, s1.RootObjectID(), s2.RootObjectID())
+	require.Equal(t, atomic.LoadInt32(&s1.Stats.NonCachedFiles), atomic.LoadInt32(&s2.Stats.NonCachedFiles))
+}
+
+func TestUpload_DirectoryUploadStats(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	sumStats := func(m *snapshot.Manifest) (newBytes, deduplicatedBytes int64) {
+		for _, d := range m.DirectoryUploadStats {
+			newBytes += d.NewBytes
+			deduplicatedBytes += d.DeduplicatedBytes
+		}
+
+		return newBytes, deduplicatedBytes
+	}
+
+	s1, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	// only 3 distinct file contents of 3, 4 and 5 bytes are new.
+	newBytes, deduplicatedBytes := sumStats(s1)
+	require.EqualValues(t, 12, newBytes)
+	require.Equal(t, s1.Stats.TotalFileSize, newBytes+deduplicatedBytes)
+
+	th.sourceDir.AddFile("d2/new-file", []byte("some new contents"), defaultPermissions)
+
+	s2, err := NewUploader(th.repo).Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{}, s1)
+	require.NoError(t, err)
+
+	newBytes, deduplicatedBytes = sumStats(s2)
+	require.EqualValues(t, len("some new contents"), newBytes)
+	require.Equal(t, s2.Stats.TotalFileSize, newBytes+deduplicatedBytes)
+
+	require.Equal(t, "d2", s2.DirectoryUploadStats[0].Path)
+	require.EqualValues(t, len("some new contents"), s2.DirectoryUploadStats[0].NewBytes)
+}
+
+func TestUpload_ErrorManifest(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	u := NewUploader(th.repo)
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	man, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.Nil(t, man.ErrorManifest)
+
+	em, err := ReadErrorManifest(ctx, th.repo, man)
+	require.NoError(t, err)
+	require.Empty(t, em.Entries)
+
+	th.sourceDir.Subdir("d1").AddErrorEntry("some-unknown-entry", os.ModeIrregular, fs.ErrUnknown)
+	th.sourceDir.Subdir("d2").AddErrorEntry("some-failed-entry", 0, &os.PathError{Op: "open", Path: "some-failed-entry", Err: syscall.EACCES})
+	th.sourceDir.Subdir("d2").Subdir("d1").FailReaddir(errTest)
+
+	man, err = u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NotNil(t, man.ErrorManifest)
+
+	em, err = ReadErrorManifest(ctx, th.repo, man)
+	require.NoError(t, err)
+	require.Equal(t, []EntryError{
+		{Path: "d1/some-unknown-entry", Phase: ErrorPhaseUnknownType, Error: "unknown or unsupported entry type", Ignored: true},
+		{Path: "d2/d1", Phase: ErrorPhaseReadDirectory, Error: errTest.Error()},
+		{Path: "d2/some-failed-entry", Phase: ErrorPhaseReadEntry, Errno: int(syscall.EACCES), Error: syscall.EACCES.Error()},
+	}, em.Entries)
+}
+
 func TestUpload_SubDirectoryReadFailureNoFailFast(t *testing.T) {
 	ctx := testlogging.Context(t)
 	th := newUploadTestHarness(ctx, t)
@@ -1637,3 +1779,141 @@ func verifyLogDetails(t *testing.T, desc string, wantDetailKeys []string, keysAn
 	sort.Strings(wantDetailKeys)
 	require.Equal(t, wantDetailKeys, gotDetailKeys, "invalid details for "+desc)
 }
+
+func TestUpload_MaxSnapshotSize(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	maxSize := policy.OptionalInt64(10)
+	trueValue := policy.OptionalBool(true)
+
+	var filesFinished atomic.Int32
+
+	u := NewUploader(th.repo)
+	u.Progress = &mockProgress{
+		UploadProgress: u.Progress,
+		finishedFileCheck: func(string, error) {
+			filesFinished.Add(1)
+		},
+	}
+
+	policyTree := policy.BuildTree(map[string]*policy.Policy{
+		".": {UploadPolicy: policy.UploadPolicy{MaxSnapshotSize: &maxSize}},
+	}, policy.DefaultPolicy)
+
+	_, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.ErrorIs(t, err, ErrMaxSnapshotSizeExceeded)
+	require.Zero(t, filesFinished.Load(), "no files must be uploaded")
+
+	// files excluded by policy are not counted.
+	policyTree = policy.BuildTree(map[string]*policy.Policy{
+		".": {
+			UploadPolicy: policy.UploadPolicy{MaxSnapshotSize: &maxSize},
+			FilesPolicy:  policy.FilesPolicy{IgnoreRules: []string{"d1", "d2", "f2", "f3"}},
+		},
+	}, policy.DefaultPolicy)
+
+	_, err = u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	// only warn when requested.
+	policyTree = policy.BuildTree(map[string]*policy.Policy{
+		".": {UploadPolicy: policy.UploadPolicy{MaxSnapshotSize: &maxSize, MaxSnapshotSizeWarnOnly: &trueValue}},
+	}, policy.DefaultPolicy)
+
+	man, err := u.Upload(ctx, th.sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+	require.NotZero(t, man.Stats.TotalFileCount)
+}
+
+func TestUpload_Junctions(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newUploadTestHarness(ctx, t)
+
+	defer th.cleanup()
+
+	sourceDir := mockfs.NewDirectory()
+	sourceDir.AddSymlink("symlink", "target", defaultPermissions)
+	sourceDir.AddSymlink("junction", `C:\target`, defaultPermissions).SetLinkType(fs.LinkTypeJunction)
+
+	policyTree := policy.BuildTree(nil, policy.DefaultPolicy)
+
+	man, err := NewUploader(th.repo).Upload(ctx, sourceDir, policyTree, snapshot.SourceInfo{})
+	require.NoError(t, err)
+
+	root := EntryFromDirEntry(th.repo, man.RootEntry).(fs.Directory)
+
+	sl, err := root.Child(ctx, "symlink")
+	require.NoError(t, err)
+	require.Equal(t, "", fs.LinkType(sl))
+
+	j, err := root.Child(ctx, "junction")
+	require.NoError(t, err)
+	require.Equal(t, fs.LinkTypeJunction, fs.LinkType(j))
+	require.Equal(t, snapshot.EntryTypeSymlink, j.(snapshot.HasDirEntry).DirEntry().Type)
+
+	target, err := j.(fs.Symlink).Readlink(ctx)
+	require.NoError(t, err)
+	require.Equal(t, `C:\target`, target)
+}
+
+func TestUpload_ChangeDetection(t *testing.T) {
+	ctime1 := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctime2 := ctime1.Add(time.Hour)
+
+	cases := []struct {
+		mode          policy.ChangeDetectionMode
+		modify        func(f *mockfs.File)
+		wantNonCached int32
+	}{
+		{policy.ChangeDetectionModTimeAndSize, func(f *mockfs.File) {}, 0},
+		{policy.ChangeDetectionModTimeAndSize, func(f *mockfs.File) { f.SetModTime(mockfs.DefaultModTime.Add(time.Second)) }, 1},
+		{policy.ChangeDetectionModTimeAndSize, func(f *mockfs.File) { f.SetContents([]byte{9, 9, 9, 9}) }, 1},
+		{policy.ChangeDetectionModTime, func(f *mockfs.File) { f.SetContents([]byte{9, 9, 9, 9}) }, 0},
+		{policy.ChangeDetectionModTime, func(f *mockfs.File) { f.SetModTime(mockfs.DefaultModTime.Add(time.Second)) }, 1},
+		{policy.ChangeDetectionChangeTime, func(f *mockfs.File) { f.SetModTime(mockfs.DefaultModTime.Add(time.Second)) }, 0},
+		{policy.ChangeDetectionChangeTime, func(f *mockfs.File) { f.SetChangeTime(ctime2) }, 1},
+		{policy.ChangeDetectionChangeTime, func(f *mockfs.File) { f.SetContents([]byte{9, 9, 9, 9}) }, 1},
+		{policy.ChangeDetectionModTimeAndSize, func(f *mockfs.File) { f.SetOwner(fs.OwnerInfo{UserName: "renamed", GroupName: "renamed"}) }, 0},
+		{policy.ChangeDetectionModTimeAndSize, func(f *mockfs.File) { f.SetOwner(fs.OwnerInfo{UserID: 1000}) }, 1},
+		{policy.ChangeDetectionChangeTime, func(f *mockfs.File) { f.SetOwner(fs.OwnerInfo{UserName: "renamed"}) }, 0},
+		{policy.ChangeDetectionAlwaysHash, func(f *mockfs.File) {}, 2},
+	}
+
+	for _, tc := range cases {
+		ctx := testlogging.Context(t)
+		th := newUploadTestHarness(ctx, t)
+
+		sourceDir := mockfs.NewDirectory()
+		f1 := sourceDir.AddFile("f1", []byte{1, 2, 3}, defaultPermissions)
+		f1.SetChangeTime(ctime1)
+		sourceDir.AddFile("f2", []byte{4, 5, 6}, defaultPermissions).SetChangeTime(ctime1)
+
+		policyTree := policy.BuildTree(map[string]*policy.Policy{
+			".": {
+				UploadPolicy: policy.UploadPolicy{
+					ChangeDetection: policy.NewChangeDetectionMode(tc.mode),
+				},
+			},
+		}, policy.DefaultPolicy)
+
+		s1, err := NewUploader(th.repo).Upload(ctx, sourceDir, policyTree, snapshot.SourceInfo{})
+		require.NoError(t, err)
+
+		tc.modify(f1)
+
+		s2, err := NewUploader(th.repo).Upload(ctx, sourceDir, policyTree, snapshot.SourceInfo{}, s1)
+		require.NoError(t, err)
+		require.Equal(t, tc.wantNonCached, atomic.LoadInt32(&s2.Stats.NonCachedFiles), "mode %v", tc.mode)
+
+		// change time is only stored when it's used for change detection.
+		root := EntryFromDirEntry(th.repo, s2.RootEntry).(fs.Directory)
+		e, err := root.Child(ctx, "f2")
+		require.NoError(t, err)
+		require.Equal(t, tc.mode == policy.ChangeDetectionChangeTime, !fs.ChangeTime(e).IsZero())
+
+		th.cleanup()
+	}
+}
@@ -0,0 +1,272 @@
+package snapshotfs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/atomicfile"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/object"
+)
+
+// VerifyFailureHistory keeps track of pack blobs and snapshot sources which recently produced
+// verification errors, so that subsequent verifications can check them first.
+type VerifyFailureHistory struct {
+	// pack blobs which failed verification and the time of the most recent failure.
+	Blobs map[blob.ID]time.Time `json:"blobs"`
+
+	// snapshot sources with files which failed verification and the time of the most recent failure.
+	Sources map[string]time.Time `json:"sources"`
+}
+
+// NewVerifyFailureHistory returns empty verification failure history.
+func NewVerifyFailureHistory() *VerifyFailureHistory {
+	return &VerifyFailureHistory{
+		Blobs:   map[blob.ID]time.Time{},
+		Sources: map[string]time.Time{},
+	}
+}
+
+// LoadVerifyFailureHistory loads the history from the provided file, missing file results in empty history.
+func LoadVerifyFailureHistory(fname string) (*VerifyFailureHistory, error) {
+	h := NewVerifyFailureHistory()
+
+	b, err := os.ReadFile(fname) //nolint:gosec
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+
+		return nil, errors.Wrap(err, "unable to read verification failure history")
+	}
+
+	if err := json.Unmarshal(b, h); err != nil {
+		return nil, errors.Wrap(err, "invalid verification failure history")
+	}
+
+	if h.Blobs == nil {
+		h.Blobs = map[blob.ID]time.Time{}
+	}
+
+	if h.Sources == nil {
+		h.Sources = map[string]time.Time{}
+	}
+
+	return h, nil
+}
+
+// Save writes the history to the provided file.
+func (h *VerifyFailureHistory) Save(fname string) error {
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal verification failure history")
+	}
+
+	return errors.Wrap(atomicfile.Write(fname, bytes.NewReader(b)), "unable to write verification failure history")
+}
+
+// Expire removes failures recorded before the provided time.
+func (h *VerifyFailureHistory) Expire(cutoff time.Time) {
+	for k, t := range h.Blobs {
+		if t.Before(cutoff) {
+			delete(h.Blobs, k)
+		}
+	}
+
+	for k, t := range h.Sources {
+		if t.Before(cutoff) {
+			delete(h.Sources, k)
+		}
+	}
+}
+
+// SuspectBlobs returns pack blobs that should be re-checked first: blobs which recently failed verification
+// and their neighbors - blobs of the same kind written within the provided time window of a failed blob,
+// which are likely to have been affected by the same storage problem.
+func (h *VerifyFailureHistory) SuspectBlobs(blobMap map[blob.ID]blob.Metadata, window time.Duration) map[blob.ID]bool {
+	result := map[blob.ID]bool{}
+
+	for failed := range h.Blobs {
+		result[failed] = true
+
+		fm, ok := blobMap[failed]
+		if !ok || window <= 0 {
+			continue
+		}
+
+		for id, bm := range blobMap {
+			if id[0] != failed[0] {
+				continue
+			}
+
+			if d := bm.Timestamp.Sub(fm.Timestamp); d >= -window && d <= window {
+				result[id] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// AffectedFile describes a file which is stored in a failed or suspect pack blob.
+type AffectedFile struct {
+	Path     string    `json:"path"`
+	ObjectID object.ID `json:"objectID"`
+	Blobs    []blob.ID `json:"blobs"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// failureDomains collects pack blobs which failed during verification and files affected by them.
+type failureDomains struct {
+	mu sync.Mutex
+	// +checklocks:mu
+	failedBlobs map[blob.ID]bool
+	// +checklocks:mu
+	affected []AffectedFile
+}
+
+func (f *failureDomains) recordFailedBlobs(ids []blob.ID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failedBlobs == nil {
+		f.failedBlobs = map[blob.ID]bool{}
+	}
+
+	for _, id := range ids {
+		f.failedBlobs[id] = true
+	}
+}
+
+func (f *failureDomains) recordAffectedFile(af AffectedFile) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.affected = append(f.affected, af)
+}
+
+// FailedBlobs returns pack blobs which failed verification.
+func (v *Verifier) FailedBlobs() []blob.ID {
+	v.failures.mu.Lock()
+	defer v.failures.mu.Unlock()
+
+	var result []blob.ID
+
+	for id := range v.failures.failedBlobs {
+		result = append(result, id)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+
+	return result
+}
+
+// AffectedFiles returns files which failed verification or are stored in suspect pack blobs.
+func (v *Verifier) AffectedFiles() []AffectedFile {
+	v.failures.mu.Lock()
+	defer v.failures.mu.Unlock()
+
+	result := append([]AffectedFile(nil), v.failures.affected...)
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+
+	return result
+}
+
+// VerifySuspectBlobs reads all contents stored in suspect pack blobs before verifying snapshots,
+// to quickly find out whether the problem which caused earlier failures persists.
+func (v *Verifier) VerifySuspectBlobs(ctx context.Context) error {
+	if len(v.opts.SuspectBlobs) == 0 {
+		return nil
+	}
+
+	dr, ok := v.rep.(repo.DirectRepository)
+	if !ok {
+		return nil
+	}
+
+	var checked, failed int
+
+	err := dr.ContentReader().IteratePacks(ctx, content.IteratePackOptions{IncludeContentInfos: true}, func(pi content.PackInfo) error {
+		if !v.opts.SuspectBlobs[pi.PackID] {
+			return nil
+		}
+
+		checked++
+
+		for _, ci := range pi.ContentInfos {
+			if _, err := dr.ContentReader().GetContent(ctx, ci.GetContentID()); err != nil {
+				verifierLog(ctx).Errorf("content %v in suspect blob %v is not readable: %v", ci.GetContentID(), pi.PackID, err)
+				v.failures.recordFailedBlobs([]blob.ID{pi.PackID})
+				failed++
+
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "error iterating packs")
+	}
+
+	verifierLog(ctx).Infof("Checked %v suspect blobs, %v failed.", checked, failed)
+
+	return nil
+}
+
+// packBlobsOf returns the pack blobs storing provided contents, ignoring contents which can't be found.
+func (v *Verifier) packBlobsOf(ctx context.Context, contentIDs []content.ID) []blob.ID {
+	seen := map[blob.ID]bool{}
+
+	var result []blob.ID
+
+	for _, cid := range contentIDs {
+		ci, err := v.rep.ContentInfo(ctx, cid)
+		if err != nil || seen[ci.GetPackBlobID()] {
+			continue
+		}
+
+		seen[ci.GetPackBlobID()] = true
+		result = append(result, ci.GetPackBlobID())
+	}
+
+	return result
+}
+
+// unreadablePackBlobsOf returns the pack blobs storing contents which cannot be read.
+func (v *Verifier) unreadablePackBlobsOf(ctx context.Context, contentIDs []content.ID) []blob.ID {
+	dr, ok := v.rep.(repo.DirectRepository)
+	if !ok {
+		return nil
+	}
+
+	var failed []content.ID
+
+	for _, cid := range contentIDs {
+		if _, err := dr.ContentReader().GetContent(ctx, cid); err != nil {
+			failed = append(failed, cid)
+		}
+	}
+
+	return v.packBlobsOf(ctx, failed)
+}
+
+func (v *Verifier) isSuspect(blobIDs []blob.ID) bool {
+	for _, id := range blobIDs {
+		if v.opts.SuspectBlobs[id] {
+			return true
+		}
+	}
+
+	return false
+}
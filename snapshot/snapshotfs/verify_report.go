@@ -0,0 +1,204 @@
+package snapshotfs
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/blob"
+	"github.com/kopia/kopia/repo/manifest"
+)
+
+// VerificationReportManifestType is the manifest type of verification reports.
+const VerificationReportManifestType = "verificationReport"
+
+const verificationReportKeySize = 32
+
+//nolint:gochecknoglobals
+var verificationReportKeyPurpose = []byte("verification-report")
+
+// VerificationReport summarizes a single verification of repository contents.
+//
+// Reports are signed using a key derived from the repository master key and each report includes
+// the signature of the report preceding it, so that reports which were altered or removed from the
+// middle of the chain can be detected. Reports saved concurrently may follow the same report, such
+// forks are expected and are not reported as problems.
+//
+// The signing key is derived from the master key, which anyone who knows the repository password can
+// obtain, so signatures only protect against tampering by parties which can write to the storage
+// but don't have the password. Removal of the most recent reports can't be detected.
+type VerificationReport struct {
+	ID manifest.ID `json:"-"`
+
+	// Sequence is the position of the report in the chain, reports in different forks may share it.
+	Sequence  int       `json:"seq"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Hostname  string    `json:"hostname"`
+	Username  string    `json:"username"`
+
+	Snapshots          []string      `json:"snapshots,omitempty"`
+	ObjectIDs          []string      `json:"objectIDs,omitempty"`
+	VerifyFilesPercent float64       `json:"verifyFilesPercent"`
+	Stats              VerifierStats `json:"stats"`
+	FailedBlobs        []blob.ID     `json:"failedBlobs,omitempty"`
+	Error              string        `json:"error,omitempty"`
+
+	PreviousSignature string `json:"previousSignature,omitempty"`
+	Signature         string `json:"signature"`
+}
+
+// Succeeded returns true if the verification did not find any problems.
+func (r *VerificationReport) Succeeded() bool {
+	return r.Error == "" && r.Stats.Failed == 0
+}
+
+func (r *VerificationReport) computeSignature(key []byte) (string, error) {
+	unsigned := *r
+	unsigned.Signature = ""
+
+	b, err := json.Marshal(unsigned)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to marshal report")
+	}
+
+	h := hmac.New(sha256.New, key)
+	h.Write(b) //nolint:errcheck
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SaveVerificationReport appends the provided report to the chain of reports stored in the repository.
+func SaveVerificationReport(ctx context.Context, rep repo.DirectRepositoryWriter, r *VerificationReport) (manifest.ID, error) {
+	key := rep.DeriveKey(verificationReportKeyPurpose, verificationReportKeySize)
+
+	existing, err := ListVerificationReports(ctx, rep)
+	if err != nil {
+		return "", err
+	}
+
+	r.Sequence = 0
+	r.PreviousSignature = ""
+
+	// follow the most recent report, other writers may be doing the same concurrently, which forks the chain.
+	if len(existing) > 0 {
+		last := existing[len(existing)-1]
+
+		r.Sequence = last.Sequence + 1
+		r.PreviousSignature = last.Signature
+	}
+
+	r.Signature, err = r.computeSignature(key)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := rep.PutManifest(ctx, map[string]string{
+		manifest.TypeLabelKey: VerificationReportManifestType,
+		"hostname":            r.Hostname,
+		"username":            r.Username,
+	}, r)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to save verification report")
+	}
+
+	r.ID = id
+
+	return id, nil
+}
+
+// ListVerificationReports returns verification reports stored in the repository ordered by sequence number.
+func ListVerificationReports(ctx context.Context, rep repo.Repository) ([]*VerificationReport, error) {
+	entries, err := rep.FindManifests(ctx, map[string]string{
+		manifest.TypeLabelKey: VerificationReportManifestType,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to find verification reports")
+	}
+
+	var result []*VerificationReport
+
+	for _, e := range entries {
+		r := &VerificationReport{}
+
+		if _, err := rep.GetManifest(ctx, e.ID, r); err != nil {
+			return nil, errors.Wrapf(err, "unable to load verification report %v", e.ID)
+		}
+
+		r.ID = e.ID
+
+		result = append(result, r)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Sequence != result[j].Sequence {
+			return result[i].Sequence < result[j].Sequence
+		}
+
+		return result[i].EndTime.Before(result[j].EndTime)
+	})
+
+	return result, nil
+}
+
+// CheckVerificationReports verifies signatures of the provided reports and that the report preceding each
+// of them is present. It returns a description of each problem found.
+func CheckVerificationReports(rep repo.DirectRepository, reports []*VerificationReport) []string {
+	key := rep.DeriveKey(verificationReportKeyPurpose, verificationReportKeySize)
+
+	var problems []string
+
+	var (
+		valid   = map[string]*VerificationReport{}
+		invalid = map[string]bool{}
+	)
+
+	for _, r := range reports {
+		sig, err := r.computeSignature(key)
+		if err != nil || !hmac.Equal([]byte(sig), []byte(r.Signature)) {
+			problems = append(problems, fmt.Sprintf("report %v has invalid signature", r.ID))
+			invalid[r.Signature] = true
+
+			continue
+		}
+
+		valid[r.Signature] = r
+	}
+
+	for _, r := range reports {
+		if valid[r.Signature] != r {
+			continue
+		}
+
+		if r.PreviousSignature == "" {
+			if r.Sequence != 0 {
+				problems = append(problems, fmt.Sprintf("report %v is not the first report, earlier reports are missing", r.ID))
+			}
+
+			continue
+		}
+
+		prev := valid[r.PreviousSignature]
+
+		switch {
+		case prev == nil && invalid[r.PreviousSignature]:
+			// previous report has already been reported as invalid.
+
+		case prev == nil:
+			problems = append(problems, fmt.Sprintf("report %v does not follow any valid report, reports are missing or were modified", r.ID))
+
+		case r.Sequence != prev.Sequence+1:
+			problems = append(problems, fmt.Sprintf("report %v has unexpected sequence number after %v", r.ID, prev.ID))
+		}
+	}
+
+	return problems
+}
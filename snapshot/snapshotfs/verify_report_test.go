@@ -0,0 +1,107 @@
+package snapshotfs_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+func TestVerificationReports(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	w := te.RepositoryWriter
+
+	for i := 0; i < 3; i++ {
+		_, err := snapshotfs.SaveVerificationReport(ctx, w, &snapshotfs.VerificationReport{
+			StartTime: w.Time().UTC(),
+			EndTime:   w.Time().UTC(),
+			Hostname:  "host",
+			Username:  "user",
+			Snapshots: []string{"user@host:/path"},
+			Stats:     snapshotfs.VerifierStats{Queued: 3, Processed: 3},
+		})
+		require.NoError(t, err)
+	}
+
+	reports, err := snapshotfs.ListVerificationReports(ctx, w)
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+
+	for i, r := range reports {
+		require.Equal(t, i, r.Sequence)
+		require.True(t, r.Succeeded())
+	}
+
+	require.Equal(t, reports[0].Signature, reports[1].PreviousSignature)
+	require.Empty(t, snapshotfs.CheckVerificationReports(w, reports))
+
+	// modified report is detected.
+	modified := *reports[1]
+	modified.Stats.Failed = 0
+	modified.Stats.Processed = 100
+
+	require.Len(t, snapshotfs.CheckVerificationReports(w, []*snapshotfs.VerificationReport{reports[0], &modified, reports[2]}), 1)
+
+	// removed report is detected.
+	require.Len(t, snapshotfs.CheckVerificationReports(w, []*snapshotfs.VerificationReport{reports[0], reports[2]}), 1)
+	require.Len(t, snapshotfs.CheckVerificationReports(w, reports[1:]), 1)
+
+	// forged report, which was re-linked without knowing the key, is detected.
+	forged := *reports[2]
+	forged.PreviousSignature = reports[0].Signature
+	forged.Sequence = 1
+
+	require.Len(t, snapshotfs.CheckVerificationReports(w, []*snapshotfs.VerificationReport{reports[0], &forged}), 1)
+}
+
+func TestVerificationReports_ConcurrentWriters(t *testing.T) {
+	ctx, te := repotesting.NewEnvironment(t, repotesting.FormatNotImportant)
+
+	newReport := func(w repo.RepositoryWriter) *snapshotfs.VerificationReport {
+		return &snapshotfs.VerificationReport{
+			StartTime: w.Time().UTC(),
+			EndTime:   w.Time().UTC(),
+			Hostname:  "host",
+			Username:  "user",
+		}
+	}
+
+	_, err := snapshotfs.SaveVerificationReport(ctx, te.RepositoryWriter, newReport(te.RepositoryWriter))
+	require.NoError(t, err)
+	require.NoError(t, te.RepositoryWriter.Flush(ctx))
+
+	// two writers which don't see each other's reports fork the chain.
+	w1 := te.RepositoryWriter
+	w2 := te.MustOpenAnother(t).(repo.DirectRepositoryWriter) //nolint:forcetypeassert
+
+	for _, w := range []repo.DirectRepositoryWriter{w1, w2} {
+		_, err = snapshotfs.SaveVerificationReport(ctx, w, newReport(w))
+		require.NoError(t, err)
+		require.NoError(t, w.Flush(ctx))
+	}
+
+	te.MustReopen(t)
+
+	reports, err := snapshotfs.ListVerificationReports(ctx, te.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+	require.Equal(t, reports[1].PreviousSignature, reports[2].PreviousSignature)
+	require.Empty(t, snapshotfs.CheckVerificationReports(te.RepositoryWriter, reports))
+
+	// next report follows one of the forks.
+	_, err = snapshotfs.SaveVerificationReport(ctx, te.RepositoryWriter, newReport(te.RepositoryWriter))
+	require.NoError(t, err)
+
+	reports, err = snapshotfs.ListVerificationReports(ctx, te.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, reports, 4)
+	require.Equal(t, 2, reports[3].Sequence)
+	require.Empty(t, snapshotfs.CheckVerificationReports(te.RepositoryWriter, reports))
+
+	// removing a report from the middle of the chain is still detected.
+	require.Len(t, snapshotfs.CheckVerificationReports(te.RepositoryWriter, []*snapshotfs.VerificationReport{reports[0], reports[3]}), 1)
+}
@@ -0,0 +1,306 @@
+package snapshotgc
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/internal/bigmap"
+	"github.com/kopia/kopia/internal/stats"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/content"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/repo/manifest"
+	"github.com/kopia/kopia/repo/object"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+// contents are written shortly after snapshot start and flushed shortly after the snapshot manifest is created,
+// extend time windows of snapshots by this amount to account for clock differences and flushing.
+const estimateWindowSlack = 5 * time.Minute
+
+// maintenance tasks which rewrite in-use contents, giving them new timestamps.
+//
+//nolint:gochecknoglobals
+var contentRewritingTasks = []maintenance.TaskType{
+	maintenance.TaskRewriteContentsQuick,
+	maintenance.TaskRewriteContentsFull,
+}
+
+// EstimateStats contains approximate statistics about unused contents.
+type EstimateStats struct {
+	UnusedBytes    int64 `json:"unusedBytes"`
+	InUseBytes     int64 `json:"inUseBytes"`
+	SystemBytes    int64 `json:"systemBytes"`
+	TooRecentBytes int64 `json:"tooRecentBytes"`
+
+	UnusedCount    uint32 `json:"unusedCount"`
+	InUseCount     uint32 `json:"inUseCount"`
+	SystemCount    uint32 `json:"systemCount"`
+	TooRecentCount uint32 `json:"tooRecentCount"`
+
+	// time of the last full garbage collection the estimate is based on, zero if there was none.
+	BaselineTime time.Time `json:"baselineTime,omitempty"`
+}
+
+// gcBaseline is the compact state recorded during the last full garbage collection.
+type gcBaseline struct {
+	// contents older than this which survived garbage collection were in use at that time.
+	Time time.Time `json:"time"`
+
+	// time when garbage collection listed snapshots and root references.
+	ListTime time.Time `json:"listTime"`
+
+	// number of snapshots and root references retaining contents at ListTime, comparing it with the number of
+	// those still present tells whether any of them have since been deleted.
+	Referrers int `json:"referrers"`
+}
+
+// snapshotWindow describes the time window during which a snapshot has been writing contents.
+type snapshotWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+func windowOf(m *snapshot.Manifest) snapshotWindow {
+	return snapshotWindow{
+		Start: m.StartTime.ToTime(),
+		End:   m.EndTime.ToTime(),
+	}
+}
+
+// countReferrers returns the number of snapshots and root references which existed at the provided time.
+func countReferrers(manifests []*snapshot.Manifest, refs []*snapshotfs.RootReference, t time.Time) int {
+	n := 0
+
+	for _, m := range manifests {
+		if !m.EndTime.ToTime().After(t) {
+			n++
+		}
+	}
+
+	for _, r := range refs {
+		if !r.CreateTime.After(t) {
+			n++
+		}
+	}
+
+	return n
+}
+
+// timeRanges is a sorted list of non-overlapping time ranges.
+type timeRanges []snapshotWindow
+
+func newTimeRanges(windows []snapshotWindow) timeRanges {
+	var sorted []snapshotWindow
+
+	for _, w := range windows {
+		sorted = append(sorted, snapshotWindow{
+			Start: w.Start.Add(-estimateWindowSlack),
+			End:   w.End.Add(estimateWindowSlack),
+		})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var result timeRanges
+
+	for _, w := range sorted {
+		if n := len(result); n > 0 && !w.Start.After(result[n-1].End) {
+			if w.End.After(result[n-1].End) {
+				result[n-1].End = w.End
+			}
+
+			continue
+		}
+
+		result = append(result, w)
+	}
+
+	return result
+}
+
+func (r timeRanges) contains(t time.Time) bool {
+	// find the first range ending at or after t.
+	i := sort.Search(len(r), func(i int) bool {
+		return !r[i].End.Before(t)
+	})
+
+	return i < len(r) && !r[i].Start.After(t)
+}
+
+// EstimateUnused approximates the amount of contents no longer referenced from any snapshot without walking
+// snapshot trees, which makes it suitable for very large repositories.
+//
+// The estimate relies on timestamps of contents in the index: contents written while a snapshot that still
+// exists was being created are assumed to be in use. Contents written before the last full garbage collection
+// survived it and are assumed to be in use, unless snapshots or root references which existed at that time
+// have since been deleted, in which case those not written by remaining snapshots are assumed to be unused.
+// Trees pinned by root references which are not snapshot roots are walked, since their contents may have been
+// written by deleted snapshots. Contents written by deleted snapshots but still referenced by later snapshots
+// are counted as unused, so the estimate tends to be higher than the actual amount of reclaimable space
+// reported by FindUnused.
+func EstimateUnused(ctx context.Context, rep repo.DirectRepository, safety maintenance.SafetyParameters, now time.Time) (EstimateStats, error) {
+	var (
+		st                               EstimateStats
+		unused, inUse, system, tooRecent stats.CountSum
+	)
+
+	manifests, err := loadAllSnapshots(ctx, rep)
+	if err != nil {
+		return st, err
+	}
+
+	refs, err := loadActiveRootReferences(ctx, rep, now)
+	if err != nil {
+		return st, err
+	}
+
+	sched, err := maintenance.GetSchedule(ctx, rep)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to get maintenance schedule")
+	}
+
+	// baseline recorded during the last full garbage collection.
+	b, err := baselineFromSchedule(sched)
+	if err != nil {
+		return st, err
+	}
+
+	referrersDeleted := countReferrers(manifests, refs, b.ListTime) < b.Referrers
+
+	pinned, err := pinnedContents(ctx, rep, manifests, refs)
+	if err != nil {
+		return st, err
+	}
+	defer pinned.Close(ctx)
+
+	var liveWindows []snapshotWindow
+
+	for _, m := range manifests {
+		liveWindows = append(liveWindows, windowOf(m))
+	}
+
+	// contents rewritten by maintenance are in use.
+	for _, tt := range contentRewritingTasks {
+		for _, r := range sched.Runs[tt] {
+			liveWindows = append(liveWindows, snapshotWindow{Start: r.Start, End: r.End})
+		}
+	}
+
+	liveRanges := newTimeRanges(liveWindows)
+
+	err = rep.ContentReader().IterateContents(ctx, content.IterateOptions{}, func(ci content.Info) error {
+		l := int64(ci.GetPackedLength())
+		ts := ci.Timestamp()
+
+		var cidbuf [128]byte
+
+		switch {
+		case ci.GetContentID().Prefix() == manifest.ContentPrefix:
+			system.Add(l)
+
+		case now.Sub(ts) < safety.MinContentAgeSubjectToGC:
+			tooRecent.Add(l)
+
+		case liveRanges.contains(ts), pinned.Contains(ci.GetContentID().Append(cidbuf[:0])):
+			inUse.Add(l)
+
+		case !ts.After(b.Time) && !referrersDeleted:
+			// survived the last garbage collection and nothing has been deleted since.
+			inUse.Add(l)
+
+		default:
+			unused.Add(l)
+		}
+
+		return nil
+	})
+
+	st.UnusedCount, st.UnusedBytes = unused.Approximate()
+	st.InUseCount, st.InUseBytes = inUse.Approximate()
+	st.SystemCount, st.SystemBytes = system.Approximate()
+	st.TooRecentCount, st.TooRecentBytes = tooRecent.Approximate()
+	st.BaselineTime = b.Time
+
+	return st, errors.Wrap(err, "error iterating contents")
+}
+
+// pinnedContents returns contents of trees pinned by root references which are not roots of any snapshot.
+func pinnedContents(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest, refs []*snapshotfs.RootReference) (*bigmap.Set, error) {
+	snapshotRoots := map[object.ID]bool{}
+
+	for _, m := range manifests {
+		snapshotRoots[m.RootObjectID()] = true
+	}
+
+	var toWalk []*snapshotfs.RootReference
+
+	for _, r := range refs {
+		if !snapshotRoots[r.RootObjectID] {
+			toWalk = append(toWalk, r)
+		}
+	}
+
+	pinned, err := bigmap.NewSet(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create new set")
+	}
+
+	if err := findInUseContentIDs(ctx, rep, nil, toWalk, pinned); err != nil {
+		pinned.Close(ctx)
+		return nil, errors.Wrap(err, "unable to find pinned contents")
+	}
+
+	return pinned, nil
+}
+
+func loadAllSnapshots(ctx context.Context, rep repo.Repository) ([]*snapshot.Manifest, error) {
+	ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list snapshot manifest IDs")
+	}
+
+	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to load manifest IDs")
+	}
+
+	return manifests, nil
+}
+
+func baselineFromSchedule(sched *maintenance.Schedule) (*gcBaseline, error) {
+	b := &gcBaseline{}
+
+	if len(sched.SnapshotGCBaseline) > 0 {
+		if err := json.Unmarshal(sched.SnapshotGCBaseline, b); err != nil {
+			return nil, errors.Wrap(err, "invalid garbage collection baseline")
+		}
+	}
+
+	return b, nil
+}
+
+// setBaseline records the state of the repository during full garbage collection in the provided schedule.
+func setBaseline(sched *maintenance.Schedule, cutoff, listTime time.Time, manifests []*snapshot.Manifest, refs []*snapshotfs.RootReference) error {
+	b := &gcBaseline{
+		Time:      cutoff,
+		ListTime:  listTime,
+		Referrers: countReferrers(manifests, refs, listTime),
+	}
+
+	v, err := json.Marshal(b)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize garbage collection baseline")
+	}
+
+	sched.SnapshotGCBaseline = v
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package snapshotgc_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kopia/kopia/internal/faketime"
+	"github.com/kopia/kopia/internal/mockfs"
+	"github.com/kopia/kopia/internal/repotesting"
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+	"github.com/kopia/kopia/snapshot/snapshotgc"
+)
+
+func TestEstimateUnused(t *testing.T) {
+	ft := faketime.NewTimeAdvance(time.Date(2020, 9, 10, 0, 0, 0, 0, time.UTC))
+
+	ctx, env := repotesting.NewEnvironment(t, repotesting.FormatNotImportant, repotesting.Options{
+		OpenOptions: func(o *repo.Options) {
+			o.TimeNowFunc = ft.NowFunc()
+		},
+	})
+
+	snapshotOf := func(path string, data []byte) *snapshot.Manifest {
+		t.Helper()
+
+		dir := mockfs.NewDirectory()
+		dir.AddFile("file", data, 0o644)
+
+		man, err := snapshotfs.NewUploader(env.RepositoryWriter).Upload(ctx, dir, nil, snapshot.SourceInfo{Host: "host", UserName: "user", Path: path})
+		require.NoError(t, err)
+
+		_, err = snapshot.SaveSnapshot(ctx, env.RepositoryWriter, man)
+		require.NoError(t, err)
+		require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+		ft.Advance(time.Hour)
+
+		return man
+	}
+
+	estimate := func() snapshotgc.EstimateStats {
+		t.Helper()
+
+		st, err := snapshotgc.EstimateUnused(ctx, env.RepositoryWriter, maintenance.SafetyNone, env.RepositoryWriter.Time())
+		require.NoError(t, err)
+
+		return st
+	}
+
+	s1 := snapshotOf("/src1", []byte{1, 2, 3})
+	s2 := snapshotOf("/src2", []byte{4, 5, 6})
+
+	st := estimate()
+	require.Zero(t, st.UnusedCount)
+	require.Positive(t, st.InUseCount)
+	require.True(t, st.BaselineTime.IsZero())
+
+	// without baseline, contents written by deleted snapshot are estimated as unused.
+	require.NoError(t, env.RepositoryWriter.DeleteManifest(ctx, s1.ID))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	st = estimate()
+	require.Equal(t, uint32(3), st.UnusedCount) // file, directory and object descriptions
+
+	// full garbage collection deletes them and records a baseline.
+	_, err := snapshotgc.Run(ctx, env.RepositoryWriter, true, maintenance.SafetyNone, env.RepositoryWriter.Time())
+	require.NoError(t, err)
+
+	ft.Advance(time.Hour)
+
+	st = estimate()
+	require.Zero(t, st.UnusedCount)
+	require.False(t, st.BaselineTime.IsZero())
+
+	// snapshots deleted after the baseline are detected.
+	require.NoError(t, env.RepositoryWriter.DeleteManifest(ctx, s2.ID))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	st = estimate()
+	require.Equal(t, uint32(3), st.UnusedCount)
+
+	// exact results also include contents already deleted by the previous garbage collection.
+	exact, err := snapshotgc.FindUnused(ctx, env.RepositoryWriter, maintenance.SafetyNone, env.RepositoryWriter.Time())
+	require.NoError(t, err)
+	require.Equal(t, exact.UnusedCount, 2*st.UnusedCount)
+
+	// trees pinned by root references remain in use after their snapshots are deleted.
+	s3 := snapshotOf("/src3", []byte{7, 8, 9})
+
+	_, err = snapshotfs.PinRoot(ctx, env.RepositoryWriter, s3.RootObjectID(), "pinned", time.Time{})
+	require.NoError(t, err)
+
+	_, err = snapshotgc.Run(ctx, env.RepositoryWriter, true, maintenance.SafetyNone, env.RepositoryWriter.Time())
+	require.NoError(t, err)
+
+	ft.Advance(time.Hour)
+
+	require.NoError(t, env.RepositoryWriter.DeleteManifest(ctx, s3.ID))
+	require.NoError(t, env.RepositoryWriter.Flush(ctx))
+
+	st = estimate()
+	require.Equal(t, uint32(1), st.UnusedCount) // object descriptions
+}
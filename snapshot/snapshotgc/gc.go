@@ -23,35 +23,29 @@
 
 var log = logging.Module("snapshotgc")
 
-func findInUseContentIDs(ctx context.Context, rep repo.Repository, used *bigmap.Set) error {
-	ids, err := snapshot.ListSnapshotManifests(ctx, rep, nil, nil)
-	if err != nil {
-		return errors.Wrap(err, "unable to list snapshot manifest IDs")
-	}
+func findInUseContentIDs(ctx context.Context, rep repo.Repository, manifests []*snapshot.Manifest, refs []*snapshotfs.RootReference, used *bigmap.Set) error {
+	markObjectInUse := func(ctx context.Context, oid object.ID) error {
+		contentIDs, verr := rep.VerifyObject(ctx, oid)
+		if verr != nil {
+			return errors.Wrapf(verr, "error verifying %v", oid)
+		}
 
-	manifests, err := snapshot.LoadSnapshots(ctx, rep, ids)
-	if err != nil {
-		return errors.Wrap(err, "unable to load manifest IDs")
+		var cidbuf [128]byte
+
+		for _, cid := range contentIDs {
+			used.Put(ctx, cid.Append(cidbuf[:0]))
+		}
+
+		return nil
 	}
 
 	w, twerr := snapshotfs.NewTreeWalker(ctx, snapshotfs.TreeWalkerOptions{
 		EntryCallback: func(ctx context.Context, entry fs.Entry, oid object.ID, entryPath string) error {
-			contentIDs, verr := rep.VerifyObject(ctx, oid)
-			if verr != nil {
-				return errors.Wrapf(verr, "error verifying %v", oid)
-			}
-
-			var cidbuf [128]byte
-
-			for _, cid := range contentIDs {
-				used.Put(ctx, cid.Append(cidbuf[:0]))
-			}
-
-			return nil
+			return markObjectInUse(ctx, oid)
 		},
 	})
 	if twerr != nil {
-		return errors.Wrap(err, "unable to create tree walker")
+		return errors.Wrap(twerr, "unable to create tree walker")
 	}
 
 	defer w.Close(ctx)
@@ -67,6 +61,24 @@ func findInUseContentIDs(ctx context.Context, rep repo.Repository, used *bigmap.
 		if err := w.Process(ctx, root, ""); err != nil {
 			return errors.Wrap(err, "error processing snapshot root")
 		}
+
+		if m.ObjectDescriptions != nil {
+			if err := markObjectInUse(ctx, *m.ObjectDescriptions); err != nil {
+				return errors.Wrap(err, "error processing object descriptions")
+			}
+		}
+
+		if m.ErrorManifest != nil {
+			if err := markObjectInUse(ctx, *m.ErrorManifest); err != nil {
+				return errors.Wrap(err, "error processing error manifest")
+			}
+		}
+	}
+
+	for _, r := range refs {
+		if err := w.Process(ctx, snapshotfs.RootReferenceEntry(ctx, rep, r), ""); err != nil {
+			return errors.Wrapf(err, "error processing root reference %v", r.ID)
+		}
 	}
 
 	return nil
@@ -76,8 +88,13 @@ func findInUseContentIDs(ctx context.Context, rep repo.Repository, used *bigmap.
 func Run(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time) (Stats, error) {
 	var st Stats
 
-	err := maintenance.ReportRun(ctx, rep, maintenance.TaskSnapshotGarbageCollection, nil, func() error {
-		if err := runInternal(ctx, rep, gcDelete, safety, maintenanceStartTime, &st); err != nil {
+	sched, err := maintenance.GetSchedule(ctx, rep)
+	if err != nil {
+		return st, errors.Wrap(err, "unable to get maintenance schedule")
+	}
+
+	err = maintenance.ReportRun(ctx, rep, maintenance.TaskSnapshotGarbageCollection, sched, func() error {
+		if err := runInternal(ctx, rep, gcDelete, safety, maintenanceStartTime, sched, &st); err != nil {
 			return err
 		}
 
@@ -98,7 +115,17 @@ func Run(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, sa
 	return st, errors.Wrap(err, "error running snapshot gc")
 }
 
-func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time, st *Stats) error {
+// FindUnused performs the mark phase of garbage collection and returns statistics about contents which are
+// no longer referenced from any snapshot and are old enough to be deleted, without deleting or undeleting anything.
+func FindUnused(ctx context.Context, rep repo.DirectRepositoryWriter, safety maintenance.SafetyParameters, now time.Time) (Stats, error) {
+	var st Stats
+
+	err := runInternal(ctx, rep, false, safety, now, nil, &st)
+
+	return st, err
+}
+
+func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete bool, safety maintenance.SafetyParameters, maintenanceStartTime time.Time, sched *maintenance.Schedule, st *Stats) error {
 	var unused, inUse, system, tooRecent, undeleted stats.CountSum
 
 	used, serr := bigmap.NewSet(ctx)
@@ -107,7 +134,17 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 	}
 	defer used.Close(ctx)
 
-	if err := findInUseContentIDs(ctx, rep, used); err != nil {
+	manifests, err := loadAllSnapshots(ctx, rep)
+	if err != nil {
+		return err
+	}
+
+	refs, err := loadActiveRootReferences(ctx, rep, maintenanceStartTime)
+	if err != nil {
+		return err
+	}
+
+	if err := findInUseContentIDs(ctx, rep, manifests, refs, used); err != nil {
 		return errors.Wrap(err, "unable to find in-use content ID")
 	}
 
@@ -115,7 +152,7 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 
 	// Ensure that the iteration includes deleted contents, so those can be
 	// undeleted (recovered).
-	err := rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
+	err = rep.ContentReader().IterateContents(ctx, content.IterateOptions{IncludeDeleted: true}, func(ci content.Info) error {
 		if manifest.ContentPrefix == ci.GetContentID().Prefix() {
 			system.Add(int64(ci.GetPackedLength()))
 			return nil
@@ -125,9 +162,12 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 
 		if used.Contains(ci.GetContentID().Append(cidbuf[:0])) {
 			if ci.GetDeleted() {
-				if err := rep.ContentManager().UndeleteContent(ctx, ci.GetContentID()); err != nil {
-					return errors.Wrapf(err, "Could not undelete referenced content: %v", ci)
+				if gcDelete {
+					if err := rep.ContentManager().UndeleteContent(ctx, ci.GetContentID()); err != nil {
+						return errors.Wrapf(err, "Could not undelete referenced content: %v", ci)
+					}
 				}
+
 				undeleted.Add(int64(ci.GetPackedLength()))
 			}
 
@@ -174,5 +214,38 @@ func runInternal(ctx context.Context, rep repo.DirectRepositoryWriter, gcDelete
 		return errors.Wrap(err, "error iterating contents")
 	}
 
+	if !gcDelete {
+		return nil
+	}
+
+	if sched != nil {
+		// contents older than this which were not deleted are in use by the snapshots found above,
+		// the baseline will be persisted along with the schedule when the run is reported.
+		if err := setBaseline(sched, maintenanceStartTime.Add(-safety.MinContentAgeSubjectToGC), maintenanceStartTime, manifests, refs); err != nil {
+			return err
+		}
+	}
+
 	return errors.Wrap(rep.Flush(ctx), "flush error")
 }
+
+// loadActiveRootReferences returns root references which have not expired, whose trees must be retained.
+func loadActiveRootReferences(ctx context.Context, rep repo.Repository, now time.Time) ([]*snapshotfs.RootReference, error) {
+	refs, err := snapshotfs.ListRootReferences(ctx, rep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list root references")
+	}
+
+	var result []*snapshotfs.RootReference
+
+	for _, r := range refs {
+		if r.Expired(now) {
+			log(ctx).Debugf("root reference %v has expired, not retaining %v", r.ID, r.RootObjectID)
+			continue
+		}
+
+		result = append(result, r)
+	}
+
+	return result, nil
+}
@@ -5,6 +5,15 @@ type Stats struct {
 	// Keep int64 fields first to ensure they get aligned to at least 64-bit
 	// boundaries which is required for atomic access on ARM and x86-32.
 	// Also results in a smaller struct size
-	UnusedBytes, InUseBytes, SystemBytes, TooRecentBytes, UndeletedBytes int64
-	UnusedCount, InUseCount, SystemCount, TooRecentCount, UndeletedCount uint32
+	UnusedBytes    int64 `json:"unusedBytes"`
+	InUseBytes     int64 `json:"inUseBytes"`
+	SystemBytes    int64 `json:"systemBytes"`
+	TooRecentBytes int64 `json:"tooRecentBytes"`
+	UndeletedBytes int64 `json:"undeletedBytes"`
+
+	UnusedCount    uint32 `json:"unusedCount"`
+	InUseCount     uint32 `json:"inUseCount"`
+	SystemCount    uint32 `json:"systemCount"`
+	TooRecentCount uint32 `json:"tooRecentCount"`
+	UndeletedCount uint32 `json:"undeletedCount"`
 }
@@ -23,7 +23,32 @@ func(ctx context.Context, runParams maintenance.RunParameters) error {
 				}
 			}
 
-			//nolint:wrapcheck
-			return maintenance.Run(ctx, runParams, safety)
+			if err := maintenance.Run(ctx, runParams, safety); err != nil {
+				//nolint:wrapcheck
+				return err
+			}
+
+			if runParams.Verify {
+				return runVerification(ctx, dr, runParams)
+			}
+
+			return nil
 		})
 }
+
+// DryRun reports the impact of each task of the complete snapshot and repository maintenance in the provided mode,
+// without changing anything.
+func DryRun(ctx context.Context, dr repo.DirectRepositoryWriter, mode maintenance.Mode, safety maintenance.SafetyParameters) ([]*maintenance.DryRunTask, error) {
+	//nolint:wrapcheck
+	return maintenance.DryRun(ctx, dr, mode, safety, func(ctx context.Context, t *maintenance.DryRunTask) error {
+		st, err := snapshotgc.FindUnused(ctx, dr, safety, dr.Time())
+		if err != nil {
+			return errors.Wrap(err, "snapshot GC failure")
+		}
+
+		t.Contents = int(st.UnusedCount)
+		t.ContentBytes = st.UnusedBytes
+
+		return nil
+	})
+}
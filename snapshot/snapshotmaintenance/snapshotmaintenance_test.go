@@ -21,6 +21,7 @@
 	"github.com/kopia/kopia/repo/maintenance"
 	"github.com/kopia/kopia/repo/object"
 	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
 	"github.com/kopia/kopia/snapshot/snapshotmaintenance"
 )
 
@@ -80,6 +81,42 @@ func (s *formatSpecificTestSuite) TestSnapshotGCSimple(t *testing.T) {
 	t.Log("root info:", pretty.Sprint(info))
 }
 
+func (s *formatSpecificTestSuite) TestSnapshotGCRetainsPinnedRoots(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	th.sourceDir.AddDir("d1", defaultPermissions)
+	th.sourceDir.AddFile("d1/f2", []byte{1, 2, 3, 4}, defaultPermissions)
+
+	s1 := mustSnapshot(t, th.RepositoryWriter, th.sourceDir, snapshot.SourceInfo{
+		Host:     "host",
+		UserName: "user",
+		Path:     "/foo",
+	})
+
+	ref, err := snapshotfs.PinRoot(ctx, th.RepositoryWriter, s1.RootObjectID(), "", time.Time{})
+	require.NoError(t, err)
+
+	require.NoError(t, th.RepositoryWriter.DeleteManifest(ctx, s1.ID))
+	mustFlush(t, th.RepositoryWriter)
+
+	rootCID := []content.ID{mustGetContentID(t, s1.RootObjectID())}
+
+	// the tree is retained while the reference exists.
+	th.fakeTime.Advance(maintenance.SafetyFull.MinContentAgeSubjectToGC + time.Hour)
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+	mustFlush(t, th.RepositoryWriter)
+	checkContentDeletion(t, th.RepositoryWriter, rootCID, false)
+
+	require.NoError(t, snapshotfs.UnpinRoot(ctx, th.RepositoryWriter, ref.ID))
+	mustFlush(t, th.RepositoryWriter)
+
+	th.fakeTime.Advance(maintenance.SafetyFull.MinContentAgeSubjectToGC + time.Hour)
+	require.NoError(t, snapshotmaintenance.Run(ctx, th.RepositoryWriter, maintenance.ModeFull, true, maintenance.SafetyFull))
+	mustFlush(t, th.RepositoryWriter)
+	checkContentDeletion(t, th.RepositoryWriter, rootCID, true)
+}
+
 // Test maintenance when a directory is deleted and then reused.
 // Scenario / events:
 //   - create snapshot s1 on a directory d is created
@@ -322,6 +359,70 @@ func (s *formatSpecificTestSuite) TestMaintenanceAutoLiveness(t *testing.T) {
 	require.NotEmpty(t, sched.Runs[maintenance.TaskSnapshotGarbageCollection], maintenance.TaskSnapshotGarbageCollection)
 }
 
+func (s *formatSpecificTestSuite) TestMaintenanceScheduledVerification(t *testing.T) {
+	ctx := testlogging.Context(t)
+	th := newTestHarness(t, s.formatVersion)
+
+	th.sourceDir.AddDir("d1", defaultPermissions)
+	th.sourceDir.AddFile("d1/f2", []byte{1, 2, 3, 4}, defaultPermissions)
+
+	mustSnapshot(t, th.RepositoryWriter, th.sourceDir, snapshot.SourceInfo{
+		Host:     "host",
+		UserName: "user",
+		Path:     "/foo",
+	})
+	mustFlush(t, th.RepositoryWriter)
+
+	dp := maintenance.DefaultParams()
+	dp.Owner = th.Repository.ClientOptions().UsernameAtHost()
+	dp.VerifyCycle.Enabled = true
+	dp.VerifyCycle.FilesPercent = 100
+
+	require.NoError(t, maintenance.SetParams(ctx, th.RepositoryWriter, &dp))
+	mustFlush(t, th.RepositoryWriter)
+
+	runMaintenance := func() {
+		t.Helper()
+
+		require.NoError(t, repo.DirectWriteSession(ctx, th.RepositoryWriter, repo.WriteSessionOptions{}, func(ctx context.Context, dw repo.DirectRepositoryWriter) error {
+			return snapshotmaintenance.Run(ctx, dw, maintenance.ModeAuto, false, maintenance.SafetyFull)
+		}))
+	}
+
+	runMaintenance()
+
+	sched, err := maintenance.GetSchedule(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, sched.Runs[maintenance.TaskVerifySnapshots], 1)
+	require.True(t, sched.Runs[maintenance.TaskVerifySnapshots][0].Success)
+	require.True(t, sched.NextVerifyTime.After(th.fakeTime.NowFunc()()))
+
+	reports, err := snapshotfs.ListVerificationReports(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	require.True(t, reports[0].Succeeded())
+	require.Len(t, reports[0].Snapshots, 1)
+
+	// verification is not due again until the interval elapses.
+	runMaintenance()
+
+	sched, err = maintenance.GetSchedule(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, sched.Runs[maintenance.TaskVerifySnapshots], 1)
+
+	th.fakeTime.Advance(maintenance.DefaultVerifyInterval + time.Hour)
+	runMaintenance()
+
+	sched, err = maintenance.GetSchedule(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, sched.Runs[maintenance.TaskVerifySnapshots], 2)
+
+	reports, err = snapshotfs.ListVerificationReports(ctx, th.RepositoryWriter)
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	require.Empty(t, snapshotfs.CheckVerificationReports(th.RepositoryWriter.(repo.DirectRepository), reports))
+}
+
 func (th *testHarness) fakeTimeOpenRepoOption(o *repo.Options) {
 	o.TimeNowFunc = th.fakeTime.NowFunc()
 }
@@ -0,0 +1,96 @@
+package snapshotmaintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/kopia/kopia/repo"
+	"github.com/kopia/kopia/repo/logging"
+	"github.com/kopia/kopia/repo/maintenance"
+	"github.com/kopia/kopia/snapshot"
+	"github.com/kopia/kopia/snapshot/snapshotfs"
+)
+
+var log = logging.Module("snapshotmaintenance")
+
+// runVerification verifies all snapshots according to the verification cycle parameters, records the outcome
+// in the maintenance schedule and saves a verification report in the repository.
+func runVerification(ctx context.Context, dr repo.DirectRepositoryWriter, runParams maintenance.RunParameters) error {
+	//nolint:wrapcheck
+	return maintenance.ReportRun(ctx, dr, maintenance.TaskVerifySnapshots, nil, func() error {
+		report, verifyErr := verifySnapshots(ctx, dr, runParams.Params.VerifyCycle.FilesPercent)
+
+		if _, err := snapshotfs.SaveVerificationReport(ctx, dr, report); err != nil {
+			return errors.Wrap(err, "unable to save verification report")
+		}
+
+		return verifyErr
+	})
+}
+
+func verifySnapshots(ctx context.Context, dr repo.DirectRepositoryWriter, filesPercent float64) (*snapshotfs.VerificationReport, error) {
+	log(ctx).Infof("Verifying snapshots (%v%% of files)...", filesPercent)
+
+	report := &snapshotfs.VerificationReport{
+		StartTime:          dr.Time().UTC(),
+		Hostname:           dr.ClientOptions().Hostname,
+		Username:           dr.ClientOptions().Username,
+		VerifyFilesPercent: filesPercent,
+	}
+
+	v := snapshotfs.NewVerifier(ctx, dr, snapshotfs.VerifierOptions{
+		VerifyFilesPercent: filesPercent,
+	})
+
+	verifyErr := v.InParallel(ctx, func(tw *snapshotfs.TreeWalker) error {
+		manifests, err := snapshot.ListSnapshotManifests(ctx, dr, nil, nil)
+		if err != nil {
+			return errors.Wrap(err, "unable to list snapshot manifests")
+		}
+
+		snapshots, err := snapshot.LoadSnapshots(ctx, dr, manifests)
+		if err != nil {
+			return errors.Wrap(err, "unable to load snapshots")
+		}
+
+		for _, man := range snapshots {
+			if man.RootEntry == nil {
+				continue
+			}
+
+			rootPath := fmt.Sprintf("%v@%v", man.Source, man.StartTime.ToTime().UTC().Format(time.RFC3339))
+
+			root, err := snapshotfs.SnapshotRoot(dr, man)
+			if err != nil {
+				return errors.Wrapf(err, "unable to get snapshot root: %q", rootPath)
+			}
+
+			report.Snapshots = append(report.Snapshots, rootPath)
+
+			// ignore error now, return aggregate error at a higher level.
+			//nolint:errcheck
+			tw.Process(ctx, root, rootPath)
+		}
+
+		return nil
+	})
+
+	v.ShowFinalStats(ctx)
+
+	sort.Strings(report.Snapshots)
+
+	report.EndTime = dr.Time().UTC()
+	report.Stats = v.Stats()
+	report.FailedBlobs = v.FailedBlobs()
+
+	if verifyErr != nil {
+		report.Error = verifyErr.Error()
+	}
+
+	//nolint:wrapcheck
+	return report, verifyErr
+}
@@ -29,6 +29,8 @@ type Stats struct {
 	ExcludedFileCount int32 `json:"excludedFileCount"`
 	// +checkatomic
 	ExcludedDirCount int32 `json:"excludedDirCount"`
+	// +checkatomic
+	ExcludedByOwnerCount int32 `json:"excludedByOwnerCount,omitempty"`
 
 	// +checkatomic
 	IgnoredErrorCount int32 `json:"ignoredErrorCount"`
@@ -72,3 +72,41 @@ func TestDiff(t *testing.T) {
 		}
 	}
 }
+
+func TestDiffWithTombstones(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	dataDir := testutil.TempDirectory(t)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "gone", "nested"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "gone", "nested", "f1"), []byte("f1"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dataDir, "some-file"), []byte("some-data"), 0o600))
+
+	e.RunAndExpectSuccess(t, "policy", "set", dataDir, "--record-deleted-entries=true")
+	e.RunAndExpectSuccess(t, "snapshot", "create", dataDir)
+
+	require.NoError(t, os.RemoveAll(filepath.Join(dataDir, "gone")))
+	require.NoError(t, os.Remove(filepath.Join(dataDir, "some-file")))
+	e.RunAndExpectSuccess(t, "snapshot", "create", dataDir)
+
+	si := clitestutil.ListSnapshotsAndExpectSuccess(t, e, dataDir)
+	require.Len(t, si, 1)
+	require.Len(t, si[0].Snapshots, 2)
+
+	// deletions are reported from tombstones without listing previous contents of deleted directories.
+	out := e.RunAndExpectSuccess(t, "diff", si[0].Snapshots[0].ObjectID, si[0].Snapshots[1].ObjectID)
+	require.Contains(t, out, "removed directory ./gone")
+	require.Contains(t, out, "removed file ./some-file (9 bytes)")
+	require.NotContains(t, out, "removed file ./gone/nested/f1 (2 bytes)")
+
+	// comparing files requires previous contents.
+	out = e.RunAndExpectSuccess(t, "diff", "-f", "--diff-command=true", si[0].Snapshots[0].ObjectID, si[0].Snapshots[1].ObjectID)
+	require.Contains(t, out, "removed file ./gone/nested/f1 (2 bytes)")
+}
@@ -9,6 +9,7 @@
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
@@ -287,10 +288,12 @@ func TestSnapshotRestore(t *testing.T) {
 		{fname: "output.tar", args: nil, validator: verifyValidTarFile},
 		{fname: "output.tar.gz", args: nil, validator: verifyValidTarGzipFile},
 		{fname: "output.tgz", args: nil, validator: verifyValidTarGzipFile},
+		{fname: "output.sqfs", args: nil, validator: verifyValidSquashFSFile},
 		// forced formats
 		{fname: "output.nonzip.blah", args: []string{"--mode=zip"}, validator: verifyValidZipFile},
 		{fname: "output.nontar.blah", args: []string{"--mode=tar"}, validator: verifyValidTarFile},
 		{fname: "output.notargz.blah", args: []string{"--mode=tgz"}, validator: verifyValidTarGzipFile},
+		{fname: "output.nonsquashfs.blah", args: []string{"--mode=squashfs"}, validator: verifyValidSquashFSFile},
 	}
 
 	restoreArchiveDir := testutil.TempDirectory(t)
@@ -307,6 +310,16 @@ func TestSnapshotRestore(t *testing.T) {
 		}
 	})
 
+	// export snapshot and a subtree of it as SquashFS images.
+	exportFile := filepath.Join(restoreArchiveDir, "export.img")
+	e.RunAndExpectSuccess(t, "export", snapID, exportFile)
+	verifyValidSquashFSFile(t, exportFile)
+
+	// refuses to overwrite existing file unless asked to.
+	e.RunAndExpectFailure(t, "export", snapID, exportFile)
+	e.RunAndExpectSuccess(t, "export", "--format=squashfs", "--overwrite", rootID+"/subdir1", exportFile)
+	verifyValidSquashFSFile(t, exportFile)
+
 	// create a directory whose name ends with '.zip' and override mode to force treating it as directory.
 	zipDir := filepath.Join(restoreArchiveDir, "outputdir.zip")
 	e.RunAndExpectSuccess(t, "snapshot", "restore", snapID, zipDir, "--mode=local")
@@ -808,6 +821,26 @@ func verifyValidTarGzipFile(t *testing.T, fname string) {
 	verifyValidTarReader(t, tar.NewReader(gz))
 }
 
+func verifyValidSquashFSFile(t *testing.T, fname string) {
+	t.Helper()
+
+	b, err := os.ReadFile(fname)
+	require.NoError(t, err)
+
+	require.Greater(t, len(b), 96)
+	require.Equal(t, "hsqs", string(b[0:4]))
+	require.Zero(t, len(b)%4096)
+
+	// when available, have the reference implementation extract the whole image.
+	unsquashfs, err := exec.LookPath("unsquashfs")
+	if err != nil {
+		return
+	}
+
+	out, err := exec.Command(unsquashfs, "-no-progress", "-d", filepath.Join(t.TempDir(), "extracted"), fname).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
 func TestSnapshotRestoreByPath(t *testing.T) {
 	t.Parallel()
 
@@ -838,6 +871,42 @@ func TestSnapshotRestoreByPath(t *testing.T) {
 	compareDirs(t, source, restoreDir)
 }
 
+func TestSnapshotRestoreByFriendlyName(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	source := testutil.TempDirectory(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(source, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "sub", "a.txt"), []byte("v1"), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", source)
+
+	require.NoError(t, os.WriteFile(filepath.Join(source, "sub", "a.txt"), []byte("v2"), 0o600))
+
+	e.RunAndExpectSuccess(t, "snapshot", "create", source)
+
+	restoreDir := testutil.TempDirectory(t)
+	e.RunAndExpectSuccess(t, "snapshot", "restore", source+"~1/sub", restoreDir)
+
+	data, err := os.ReadFile(filepath.Join(restoreDir, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(data))
+
+	require.Equal(t, []string{"a.txt"}, e.RunAndExpectSuccess(t, "ls", source+":latest/sub"))
+	require.Equal(t, []string{"v2"}, e.RunAndExpectSuccess(t, "show", source+":latest/sub/a.txt"))
+	require.Equal(t, []string{"v1"}, e.RunAndExpectSuccess(t, "show", source+":oldest/sub/a.txt"))
+
+	e.RunAndExpectSuccess(t, "diff", source+":oldest", source+":latest")
+
+	e.RunAndExpectFailure(t, "snapshot", "restore", source+"~2", restoreDir)
+}
+
 func TestRestoreByPathWithoutTarget(t *testing.T) {
 	t.Parallel()
 
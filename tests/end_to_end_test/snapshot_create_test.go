@@ -1,6 +1,8 @@
 package endtoend_test
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -732,6 +734,47 @@ func TestSnapshotCreateAllFlushPerSource(t *testing.T) {
 	require.Len(t, metadataBlobList3, len(metadataBlobList2)+3)
 }
 
+func TestSnapshotCreateInterrupted(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	srcdir := testutil.TempDirectory(t)
+
+	for i := 0; i < 2000; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(srcdir, fmt.Sprintf("file%v", i)), bytes.Repeat([]byte{byte(i)}, 10000+i%100), 0o600))
+	}
+
+	wait, interrupt := e.RunAndProcessStderrInt(t, func(line string) bool {
+		return !strings.Contains(line, "Snapshotting")
+	}, "snapshot", "create", srcdir, "--parallel=1")
+
+	interrupt(os.Interrupt)
+
+	require.ErrorIs(t, wait(), cli.ErrInterrupted)
+
+	// incomplete snapshot has been saved and its contents flushed.
+	var incomplete []cli.SnapshotManifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", srcdir, "--incomplete", "--json"), &incomplete)
+	require.Len(t, incomplete, 1)
+	require.Equal(t, "canceled", incomplete[0].IncompleteReason)
+
+	e.RunAndExpectSuccess(t, "content", "verify")
+
+	// next run completes the snapshot.
+	var man snapshot.Manifest
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", srcdir, "--json"), &man)
+	require.Empty(t, man.IncompleteReason)
+	require.Equal(t, int64(2000), man.RootEntry.DirSummary.TotalFileCount)
+}
+
 func TestSnapshotCreateAllSnapshotPath(t *testing.T) {
 	t.Parallel()
 
@@ -799,3 +842,71 @@ func TestSnapshotCreateWithAllAndPath(t *testing.T) {
 	e.RunAndExpectSuccess(t, "snapshot", "create", sharedTestDataDir2)
 	e.RunAndExpectFailure(t, "snapshot", "create", sharedTestDataDir1, "--all")
 }
+
+func TestSnapshotCreateSubpath(t *testing.T) {
+	t.Parallel()
+
+	runner := testenv.NewInProcRunner(t)
+	e := testenv.NewCLITest(t, testenv.RepoFormatNotImportant, runner)
+
+	defer e.RunAndExpectSuccess(t, "repo", "disconnect")
+	e.RunAndExpectSuccess(t, "repo", "create", "filesystem", "--path", e.RepoDir)
+
+	baseDir := testutil.TempDirectory(t)
+	require.NoError(t, createFileStructure(baseDir, []testFileEntry{
+		{Name: "a/b/file1"},
+		{Name: "a/b/file2"},
+		{Name: "c/file3"},
+	}))
+
+	var (
+		full, partial snapshot.Manifest
+		all           []cli.SnapshotManifest
+	)
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", baseDir, "--json"), &full)
+	require.Empty(t, full.Subpath)
+
+	// partial snapshot is recorded under the same source and uses the full snapshot as a baseline.
+	_, stderr := e.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", baseDir, "--subpath", "a/b/")
+	require.Contains(t, strings.Join(stderr, "\n"), "0 hashed (0 B), 2 cached")
+
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "create", baseDir, "--subpath", "a/b/", "--json"), &partial)
+	require.Equal(t, full.Source, partial.Source)
+	require.Equal(t, "a/b", partial.Subpath)
+
+	lines := e.RunAndExpectSuccess(t, "ls", partial.RootObjectID().String())
+	require.Len(t, lines, 2)
+
+	require.Contains(t, strings.Join(e.RunAndExpectSuccess(t, "snapshot", "list", baseDir), "\n"), "subpath:a/b")
+
+	// full snapshots don't use partial snapshots as a baseline.
+	_, stderr = e.RunAndExpectSuccessWithErrOut(t, "snapshot", "create", baseDir)
+	require.Contains(t, strings.Join(stderr, "\n"), "0 hashed (0 B), 3 cached")
+
+	// partial snapshots are retained independently of full snapshots.
+	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "snapshot", "list", baseDir, "--json"), &all)
+	require.Len(t, all, 4)
+	require.Contains(t, all[0].RetentionReasons, "latest-2")
+	require.Contains(t, all[2].RetentionReasons, "latest-1")
+	require.Contains(t, all[3].RetentionReasons, "latest-1")
+
+	e.RunAndExpectFailure(t, "snapshot", "create", baseDir, "--subpath", "../a")
+	e.RunAndExpectFailure(t, "snapshot", "create", baseDir, "--subpath", "no-such-dir")
+
+	// restoring by path resolves paths within the latest partial snapshot relative to its subpath
+	// and falls back to older full snapshots for paths outside of it.
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "a", "b", "file1"), []byte("changed"), 0o600))
+	e.RunAndExpectSuccess(t, "snapshot", "create", baseDir, "--subpath", "a/b")
+
+	restoreDir := testutil.TempDirectory(t)
+
+	e.RunAndExpectSuccess(t, "restore", filepath.Join(baseDir, "a", "b", "file1"), filepath.Join(restoreDir, "file1"), "--snapshot-time=latest")
+
+	b, err := os.ReadFile(filepath.Join(restoreDir, "file1"))
+	require.NoError(t, err)
+	require.Equal(t, "changed", string(b))
+
+	e.RunAndExpectSuccess(t, "restore", filepath.Join(baseDir, "c"), filepath.Join(restoreDir, "c"), "--snapshot-time=latest")
+	require.FileExists(t, filepath.Join(restoreDir, "c", "file3"))
+}
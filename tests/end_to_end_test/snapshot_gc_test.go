@@ -34,8 +34,8 @@ func (s *formatSpecificTestSuite) TestSnapshotGC(t *testing.T) {
 	// take a snapshot of a directory with 1 file
 	e.RunAndExpectSuccess(t, "snap", "create", dataDir)
 
-	// data block + directory block + manifest block
-	expectedContentCount += 3
+	// data block + directory block + object descriptions block + manifest block
+	expectedContentCount += 4
 	e.RunAndVerifyOutputLineCount(t, expectedContentCount, "content", "list")
 
 	// now delete all manifests, making the content unreachable
@@ -58,7 +58,7 @@ func (s *formatSpecificTestSuite) TestSnapshotGC(t *testing.T) {
 	// because of default safety level which only looks at contents above certain age.
 	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=full")
 
-	// data block + directory block + manifest block + manifest block from manifest deletion
+	// data block + directory block + object descriptions block + manifest block + manifest block from manifest deletion
 	var contentInfo []content.Info
 
 	testutil.MustParseJSONLines(t, e.RunAndExpectSuccess(t, "content", "list", "--json"), &contentInfo)
@@ -73,7 +73,7 @@ func (s *formatSpecificTestSuite) TestSnapshotGC(t *testing.T) {
 	// garbage-collect for real, this time without age limit
 	e.RunAndExpectSuccess(t, "maintenance", "run", "--full", "--safety=none")
 
-	// two contents are deleted
-	expectedContentCount -= 2
+	// three contents are deleted
+	expectedContentCount -= 3
 	e.RunAndVerifyOutputLineCount(t, expectedContentCount, "content", "list")
 }